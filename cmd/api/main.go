@@ -40,8 +40,10 @@ func main() {
 	defer db.Close()
 	logger.InfoLogger.Println("Conexão com banco de dados estabelecida")
 
-	// Configurar rotas
-	router := setupRoutes()
+	// Configurar rotas; os processos em segundo plano param junto com o servidor
+	fundo, pararFundo := context.WithCancel(context.Background())
+	router, filaJobs := setupRoutes(fundo)
+	filaJobs.Iniciar()
 
 	// Configurar servidor
 	addr := fmt.Sprintf(":%s", port)
@@ -79,6 +81,12 @@ func main() {
 		if err := server.Shutdown(ctx); err != nil {
 			logger.ErrorLogger.Fatalf("Erro ao encerrar servidor: %v", err)
 		}
+
+		// Aguardar os jobs em execução dentro do mesmo prazo
+		if err := filaJobs.Parar(ctx); err != nil {
+			logger.ErrorLogger.Printf("Jobs interrompidos no encerramento: %v", err)
+		}
+		pararFundo()
 		
 		close(done)
 	}()
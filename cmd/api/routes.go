@@ -1,26 +1,319 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
 
-	_"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/atividade"
+	"github.com/Pantaleaogc/gvero/internal/boleto"
+	"github.com/Pantaleaogc/gvero/internal/campo"
+	"github.com/Pantaleaogc/gvero/internal/chaveapi"
 	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/conta"
+	"github.com/Pantaleaogc/gvero/internal/dashboard"
+	"github.com/Pantaleaogc/gvero/internal/doisfatores"
+	"github.com/Pantaleaogc/gvero/internal/email"
 	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/estoque"
+	"github.com/Pantaleaogc/gvero/internal/events"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/fiscal"
+	"github.com/Pantaleaogc/gvero/internal/jobs"
+	"github.com/Pantaleaogc/gvero/internal/kanban"
+	"github.com/Pantaleaogc/gvero/internal/lgpd"
+	"github.com/Pantaleaogc/gvero/internal/notificacao"
+	"github.com/Pantaleaogc/gvero/internal/pedido"
+	"github.com/Pantaleaogc/gvero/internal/pix"
+	"github.com/Pantaleaogc/gvero/internal/produto"
+	"github.com/Pantaleaogc/gvero/internal/seguranca"
+	"github.com/Pantaleaogc/gvero/internal/sso"
 	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/internal/webhook"
+	"github.com/Pantaleaogc/gvero/pkg/cep"
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+	"github.com/Pantaleaogc/gvero/pkg/database"
+	"github.com/Pantaleaogc/gvero/pkg/limite"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/Pantaleaogc/gvero/pkg/nfe"
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
 
-// SetupRoutes configura todas as rotas da aplicação
-func setupRoutes() http.Handler {
+// SetupRoutes configura todas as rotas da aplicação. Os processos em segundo plano param quando ctx é
+// cancelado; a fila de jobs retornada é iniciada e drenada por main junto com o servidor.
+func setupRoutes(ctx context.Context) (http.Handler, *jobs.Service) {
 	r := chi.NewRouter()
 
+	// Eventos de domínio gravados no outbox junto com as alterações e entregues aos assinantes.
+	// EVENTOS_ARMAZENAMENTO=mysql grava o outbox no banco, na transação dos repositórios no MySQL;
+	// enquanto os cadastros ficam em memória, o padrão é o outbox em memória de cada instância
+	var outbox events.Outbox = events.NewMemoryOutbox()
+	if os.Getenv("EVENTOS_ARMAZENAMENTO") == "mysql" && database.GetDB() != nil {
+		outboxMySQL := events.NewMySQLOutbox(database.GetDB())
+		if err := outboxMySQL.Migrar(); err != nil {
+			logger.ErrorLogger.Fatalf("Erro ao criar as tabelas do outbox de eventos: %v", err)
+		}
+		outbox = outboxMySQL
+	}
+	barramento := events.NewBarramento(outbox)
+
+	// Repositórios compartilhados entre os módulos
+	usuarioRepo := events.PublicarUsuarios(usuario.NewMemoryRepository(), barramento)
+	empresaRepo := events.PublicarEmpresas(empresa.NewMemoryRepository(), barramento)
+	notificacaoRepo := notificacao.NewMemoryRepository()
+	notificacaoHub := notificacao.NewHub()
+	atividadeRepo := atividade.NewMemoryRepository()
+	financeiroRepo := financeiro.NewMemoryRepository()
+	pixRepo := pix.NewMemoryRepository()
+	boletoRepo := boleto.NewMemoryRepository()
+	produtoRepo := produto.NewMemoryRepository()
+	estoqueRepo := estoque.NewMemoryRepository()
+	fiscalRepo := fiscal.NewMemoryRepository()
+	campoRepo := campo.NewMemoryRepository()
+	lgpdRepo := lgpd.NewMemoryRepository()
+	webhookRepo := webhook.NewMemoryRepository()
+	emailRepo := email.NewMemoryRepository()
+
+	// Fila de jobs no MySQL, compartilhada entre as instâncias; JOBS_ARMAZENAMENTO=memoria dispensa o banco
+	var jobsRepo jobs.Repository = jobs.NewMemoryRepository()
+	if os.Getenv("JOBS_ARMAZENAMENTO") != "memoria" && database.GetDB() != nil {
+		jobsMySQL := jobs.NewMySQLRepository(database.GetDB())
+		if err := jobsMySQL.Migrar(); err != nil {
+			logger.ErrorLogger.Fatalf("Erro ao criar as tabelas da fila de jobs: %v", err)
+		}
+		jobsRepo = jobsMySQL
+	}
+
+	// Serviços
+	jobsService := jobs.NewService(jobsRepo)
+	if n, err := strconv.Atoi(os.Getenv("JOBS_CONCORRENCIA")); err == nil {
+		jobsService.Fila(jobs.FilaPadrao, n)
+	}
+	jobsService.Registrar(jobs.TipoExpurgo, "", jobsService.Expurgo(30*24*time.Hour))
+	if err := jobsService.Agendar("expurgo-jobs", "0 3 * * *", jobs.TipoExpurgo, nil); err != nil {
+		logger.ErrorLogger.Fatalf("Agendamento inválido: %v", err)
+	}
+
+	// E-mails transacionais entregues pela fila de jobs. Sem EMAIL_SMTP_HOST, são entregues a um
+	// servidor SMTP simulado, que recusa destinatários de domínios ".invalid"
+	var transporteEmail email.Transporte
+	if host := os.Getenv("EMAIL_SMTP_HOST"); host != "" {
+		porta, _ := strconv.Atoi(os.Getenv("EMAIL_SMTP_PORTA"))
+		transporteEmail = email.NewSMTP(email.ConfigSMTP{
+			Host:    host,
+			Porta:   porta,
+			Usuario: os.Getenv("EMAIL_SMTP_USUARIO"),
+			Senha:   os.Getenv("EMAIL_SMTP_SENHA"),
+			TLS:     os.Getenv("EMAIL_SMTP_TLS"),
+		})
+	} else {
+		smtpFake, err := email.NewFakeSMTP("127.0.0.1:0")
+		if err != nil {
+			logger.ErrorLogger.Fatalf("Erro ao iniciar o servidor SMTP simulado: %v", err)
+		}
+		go func() {
+			<-ctx.Done()
+			smtpFake.Fechar()
+		}()
+		logger.InfoLogger.Println("Aviso: EMAIL_SMTP_HOST não configurado, e-mails entregues ao servidor SMTP simulado")
+		transporteEmail = email.NewSMTP(email.ConfigSMTP{Host: smtpFake.Host(), Porta: smtpFake.Porta(), TLS: email.TLSNenhum})
+	}
+	remetenteEmail := os.Getenv("EMAIL_REMETENTE")
+	if remetenteEmail == "" {
+		remetenteEmail = "nao-responda@localhost"
+	}
+	emailService := email.NewService(emailRepo, transporteEmail, empresaRepo, jobsService, remetenteEmail)
+
+	// Proteção do login contra força bruta; os contadores ficam no MySQL para valer entre as
+	// instâncias, e SEGURANCA_ARMAZENAMENTO=memoria dispensa o banco
+	var contadoresLogin seguranca.Contadores = seguranca.NewMemoryContadores()
+	if os.Getenv("SEGURANCA_ARMAZENAMENTO") != "memoria" && database.GetDB() != nil {
+		contadoresMySQL := seguranca.NewMySQLContadores(database.GetDB())
+		if err := contadoresMySQL.Migrar(); err != nil {
+			logger.ErrorLogger.Fatalf("Erro ao criar a tabela dos contadores de login: %v", err)
+		}
+		contadoresLogin = contadoresMySQL
+	}
+	segurancaService := seguranca.NewService(contadoresLogin, seguranca.NewMemoryRepository())
+	jobsService.Registrar(seguranca.TipoJobExpurgo, "", func(ctx context.Context, j *jobs.Job) error {
+		return segurancaService.Expurgar(90 * 24 * time.Hour)
+	})
+	if err := jobsService.Agendar("expurgo-seguranca", "30 3 * * *", seguranca.TipoJobExpurgo, nil); err != nil {
+		logger.ErrorLogger.Fatalf("Agendamento inválido: %v", err)
+	}
+
+	// Chaves de API das integrações, aceitas pelo auth.Middleware no cabeçalho "Authorization: ApiKey ..."
+	chaveAPIService := chaveapi.NewService(chaveapi.NewMemoryRepository())
+	auth.UsarChavesAPI(chaveAPIService)
+
+	// Segundo fator (TOTP) do login; o emissor é o nome exibido nos aplicativos autenticadores
+	doisFatoresService := doisfatores.NewService(doisfatores.NewMemoryRepository(), usuarioRepo, "Gvero")
+
+	// Recuperação de senha e convites; os links dos e-mails apontam para o frontend
+	contaRepo := conta.NewMemoryRepository()
+	urlFrontend := os.Getenv("APP_URL")
+	if urlFrontend == "" {
+		urlFrontend = "http://localhost:8080"
+	}
+	contaService := conta.NewService(contaRepo, usuarioRepo, empresaRepo, emailService, urlFrontend)
+
+	notificacaoService := notificacao.NewService(notificacaoRepo, notificacaoHub, usuarioRepo)
+
+	// Eventos enviados aos webhooks das empresas; o despachante refaz as entregas que falharem
+	webhookService := webhook.NewService(webhookRepo)
+	go webhookService.Executar(ctx, 15*time.Second)
+	pedidoRepo := events.PublicarPedidos(pedido.NewMemoryRepository(), barramento)
+
+	// Dados pessoais dos clientes cifrados em repouso quando as chaves estão configuradas
+	var clienteBase cliente.Repository = cliente.NewMemoryRepository()
+	var clienteCifrado *cliente.RepositorioCifrado
+	var cifrador *cripto.Cifrador
+	if chaves := os.Getenv("CRIPTO_CHAVES_MESTRAS"); chaves != "" {
+		var err error
+		cifrador, err = cripto.NewCifradorConfig(chaves, os.Getenv("CRIPTO_CHAVE_INDICE"))
+		if err != nil {
+			logger.ErrorLogger.Fatalf("Configuração de criptografia inválida: %v", err)
+		}
+		clienteCifrado = cliente.NewRepositorioCifrado(clienteBase, cifrador)
+		clienteBase = clienteCifrado
+	} else if os.Getenv("CRIPTO_PERMITIR_TEXTO_PURO") == "true" {
+		logger.InfoLogger.Println("Aviso: CRIPTO_PERMITIR_TEXTO_PURO ativo, dados pessoais gravados sem cifragem (apenas desenvolvimento)")
+	} else {
+		logger.ErrorLogger.Fatal("CRIPTO_CHAVES_MESTRAS não configurada; defina CRIPTO_PERMITIR_TEXTO_PURO=true apenas em desenvolvimento")
+	}
+	clienteRepo := events.PublicarClientes(clienteBase, barramento)
+	campoService := campo.NewService(campoRepo)
+
+	// Login SSO pelos provedores OpenID Connect das empresas; o redirect_uri cadastrado no provedor
+	// usa o endereço público da API, o login concluído volta ao frontend e os segredos dos clientes
+	// são cifrados com as mesmas chaves
+	urlAPI := os.Getenv("API_URL")
+	if urlAPI == "" {
+		urlAPI = "http://localhost:8080"
+	}
+	ssoService := sso.NewService(sso.NewMemoryRepository(), usuarioRepo, func(empresaID int) (int, error) {
+		e, err := empresaRepo.GetByID(empresaID)
+		if err != nil {
+			return 0, err
+		}
+		return e.MaxUsuarios, nil
+	}, nil, cifrador, urlAPI, urlFrontend)
+
+	// Rotação das chaves mestras: o comando recifrar enfileira este job na fila de cada instância, que
+	// regrava os dados de todas as empresas guardados nela. INSTANCIA identifica a fila; vazio usa o host
+	instancia := os.Getenv("INSTANCIA")
+	if instancia == "" {
+		instancia, _ = os.Hostname()
+	}
+	jobsService.Registrar(cliente.TipoJobRecifrar, cliente.FilaRecifrar(instancia), func(ctx context.Context, j *jobs.Job) error {
+		if cifrador == nil {
+			return nil
+		}
+		empresas, err := empresaRepo.List(0, 0)
+		if err != nil {
+			return err
+		}
+		total := 0
+		for _, e := range empresas {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			n, err := clienteCifrado.Recifrar(e.ID)
+			if err != nil {
+				return fmt.Errorf("empresa %d: %w", e.ID, err)
+			}
+			m, err := ssoService.Recifrar(e.ID)
+			if err != nil {
+				return fmt.Errorf("empresa %d: %w", e.ID, err)
+			}
+			total += n + m
+		}
+		logger.InfoLogger.Printf("Recifragem concluída: %d registros regravados em %d empresas", total, len(empresas))
+		return nil
+	})
+
+	// Consulta de CEP pelo ViaCEP, com a base offline quando o serviço não responde
+	cepOffline := cep.NewOffline()
+	if arquivo := os.Getenv("CEP_OFFLINE_ARQUIVO"); arquivo != "" {
+		if o, err := cep.NewOfflineArquivo(arquivo); err != nil {
+			logger.ErrorLogger.Printf("Base offline de CEP não carregada, usando a embutida: %v", err)
+		} else {
+			cepOffline = o
+		}
+	}
+	clienteService := cliente.NewService(clienteRepo, cep.NewCadeia(cep.NewViaCEP(os.Getenv("CEP_URL")), cepOffline), campoService, time.Minute)
+
+	atividadeService := atividade.NewService(atividadeRepo, clienteRepo)
+	kanbanRepo := events.PublicarKanban(kanban.NewMemoryRepository(), barramento)
+	kanbanService := kanban.NewService(kanbanRepo, clienteRepo, usuarioRepo)
+	financeiroService := financeiro.NewService(financeiroRepo, clienteRepo)
+
+	// Integração com PSP real ainda não implementada: cobranças dinâmicas usam o PSP simulado
+	pixSegredo := os.Getenv("PIX_WEBHOOK_SECRET")
+	pixPSP := pix.NewFakePSP(os.Getenv("PIX_PSP_URL"), os.Getenv("PIX_WEBHOOK_URL"), pixSegredo)
+	pixService := pix.NewService(pixRepo, pixPSP, clienteRepo, financeiroRepo, financeiroService, jobsService)
+	boletoService := boleto.NewService(boletoRepo, clienteRepo, financeiroRepo, financeiroService)
+	produtoService := produto.NewService(produtoRepo, clienteRepo, campoService)
+	estoqueService := estoque.NewService(estoqueRepo, produtoRepo)
+	pedidoService := pedido.NewService(pedidoRepo, clienteRepo, produtoRepo, produtoService,
+		estoqueService, financeiroService, empresaRepo)
+	pedidoService.UsarEmail(emailService)
+
+	// Sem os schemas oficiais da NF-e a emissão fica indisponível (ver schemas/nfe/README.md)
+	schemasNFe := os.Getenv("NFE_SCHEMAS_DIR")
+	if schemasNFe == "" {
+		schemasNFe = filepath.Join("schemas", "nfe")
+	}
+	validadorNFe, err := nfe.NewValidador(filepath.Join(schemasNFe, "nfe_v4.00.xsd"))
+	if err != nil {
+		logger.ErrorLogger.Printf("Schemas da NF-e não carregados, emissão desabilitada: %v", err)
+		validadorNFe = nil
+	}
+	// Integração com a SEFAZ ainda não implementada: notas são autorizadas pela SEFAZ simulada
+	fiscalService := fiscal.NewService(fiscalRepo, pedidoRepo, clienteRepo, produtoRepo, financeiroRepo,
+		nfe.NewMockSEFAZ(), validadorNFe)
+
+	lgpdService := lgpd.NewService(lgpdRepo, clienteRepo, clienteService, atividadeRepo, financeiroRepo,
+		pixRepo, boletoRepo, pedidoRepo, fiscalRepo)
+
+	// Registros que acompanham o cliente quando duplicados são mesclados
+	clienteService.Vincular("atividades", atividadeRepo)
+	clienteService.Vincular("negocios", kanbanRepo)
+	clienteService.Vincular("titulos", financeiroRepo)
+	clienteService.Vincular("cobrancas_pix", pixRepo)
+	clienteService.Vincular("boletos", boletoRepo)
+	clienteService.Vincular("pedidos", pedidoRepo)
+	clienteService.Vincular("notas_fiscais", fiscalRepo)
+	clienteService.Vincular("tabelas_preco", produtoRepo)
+
+	dashboardService := dashboard.NewService(clienteRepo, kanbanService, kanbanService, kanbanService, time.Minute)
+
+	// Assinantes dos eventos de domínio; qualquer alteração da empresa descarta o cache do dashboard
+	notificacao.AssinarEventos(barramento, notificacaoService)
+	webhook.AssinarEventos(barramento, webhookService)
+	barramento.Assinar("dashboard", func(ev *events.Evento) error {
+		dashboardService.Invalidar(ev.EmpresaID)
+		return nil
+	})
+	go barramento.Executar(ctx, 10*time.Second)
+
 	// Middleware básicos
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
 	r.Use(middleware.RequestID)
-	r.Use(middleware.RealIP)
+	// X-Forwarded-For e X-Real-IP só valem vindos dos proxies reversos configurados
+	proxies, err := limite.NewProxies(os.Getenv("PROXIES_CONFIAVEIS"))
+	if err != nil {
+		logger.ErrorLogger.Fatalf("PROXIES_CONFIAVEIS inválida: %v", err)
+	}
+	r.Use(proxies.RealIP)
 
 	// Rota raiz para verificação de saúde
 	r.Get("/", func(w http.ResponseWriter, r *http.Request) {
@@ -34,18 +327,82 @@ func setupRoutes() http.Handler {
 		        r.Route("/v1", func(r chi.Router) {
 		            
 		  // Rotas de autenticação
-                r.Mount("/auth", auth.Routes())
+                r.Mount("/auth", auth.Routes(usuarioRepo, doisFatoresService, segurancaService, ssoService, conta.RotasAuth(contaRepo, contaService)))
+
+			// Convites de novos usuários da empresa
+			    r.Mount("/convites", conta.Routes(contaRepo, contaService))
+
+			// Log de segurança e desbloqueio de contas e IPs
+			    r.Mount("/seguranca", auth.SegurancaRoutes(usuarioRepo, segurancaService))
+
+			// Chaves de API das integrações
+			    r.Mount("/chaves-api", auth.ChavesRoutes(chaveAPIService))
                 
 			// Rotas de usuários
-			    r.Mount("/usuarios", usuario.Routes())
+			    r.Mount("/usuarios", auth.UsuariosRoutes(usuarioRepo))
 			
 			// Rotas de clientes
-			    r.Mount("/clientes", cliente.Routes())
+			    r.Mount("/clientes", cliente.Routes(clienteRepo, clienteService,
+			        atividade.RotasCliente(atividadeRepo, atividadeService), cliente.RotasCifragem(clienteCifrado)))
 			
 			// Rotas de empresas
-			    r.Mount("/empresas", empresa.Routes())
+			    r.Mount("/empresas", empresa.Routes(empresaRepo))
+
+			// Campos personalizados de clientes e produtos
+			    r.Mount("/campos", campo.Routes(campoRepo, campoService))
+
+			// Dashboard
+			    r.Mount("/dashboard", dashboard.Routes(dashboardService, usuarioRepo))
+
+			// Quadros de negócios, projetos e tarefas
+			    r.Mount("/kanban", kanban.Routes(kanbanRepo, kanbanService))
+
+			// Notificações
+			    r.Mount("/notificacoes", notificacao.Routes(notificacaoRepo, notificacaoHub))
+
+			// Atividades
+			    r.Mount("/atividades", atividade.Routes(atividadeRepo, atividadeService))
+
+			// Financeiro
+			    r.Mount("/financeiro", financeiro.Routes(financeiroRepo, financeiroService))
+
+			// PIX
+			    r.Mount("/pix", pix.Routes(pixRepo, pixService))
+
+			// Boletos
+			    r.Mount("/boletos", boleto.Routes(boletoRepo, boletoService))
+
+			// Catálogo de produtos e serviços
+			    r.Mount("/produtos", produto.Routes(produtoRepo, produtoService))
+
+			// Estoque
+			    r.Mount("/estoque", estoque.Routes(estoqueRepo, estoqueService))
+
+			// Orçamentos e pedidos de venda
+			    r.Mount("/pedidos", pedido.Routes(pedidoRepo, pedidoService))
+
+			// Notas fiscais
+			    r.Mount("/fiscal", fiscal.Routes(fiscalRepo, fiscalService))
+
+			// Consentimentos, solicitações de titulares e anonimização (LGPD)
+			    r.Mount("/lgpd", lgpd.Routes(lgpdRepo, lgpdService))
+
+			// Administração dos jobs em segundo plano
+			    r.Mount("/jobs", jobs.Routes(jobsRepo, jobsService, usuarioRepo))
+
+			// E-mails: registro de envios, modelos, marca e lista de supressão
+			    r.Mount("/emails", email.Routes(emailRepo, emailService))
+
+			// Devoluções informadas pelo provedor de e-mail
+			    r.Mount("/emails/devolucoes", email.DevolucoesRoutes(emailService, os.Getenv("EMAIL_DEVOLUCOES_SEGREDO")))
+
+			// Webhooks enviados pela empresa: endpoints e registro de entregas
+			    r.Mount("/webhooks", webhook.Routes(webhookRepo, webhookService))
+
+			// Webhooks recebidos de integrações externas
+			    r.Mount("/webhooks/pix", pix.WebhookRoutes(pixService, pixSegredo))
 		})
 	})
 
-	return r
+	return r, jobsService
 }
\ No newline at end of file
@@ -0,0 +1,42 @@
+// Comando oidc-mock executa um provedor OpenID Connect simulado para testar o login SSO sem
+// um provedor real. Configure a empresa com o emissor, o client ID e o segredo exibidos.
+package main
+
+import (
+	"flag"
+	"net/http"
+	"strings"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/Pantaleaogc/gvero/pkg/oidc"
+)
+
+func main() {
+	logger.Init()
+	defer logger.Close()
+
+	endereco := flag.String("endereco", "localhost:9000", "endereço em que o provedor atende")
+	clientID := flag.String("client-id", "gvero", "client ID aceito")
+	segredo := flag.String("client-secret", "segredo-local", "segredo do cliente aceito")
+	email := flag.String("email", "usuario@exemplo.com.br", "e-mail da identidade emitida (login_hint substitui)")
+	nome := flag.String("nome", "Usuário de Teste", "nome da identidade emitida")
+	grupos := flag.String("grupos", "", "grupos da identidade, separados por vírgula, na declaração groups")
+	flag.Parse()
+
+	emissor := "http://" + *endereco
+	mock, err := oidc.NewMock(emissor, *clientID, *segredo)
+	if err != nil {
+		logger.ErrorLogger.Fatalf("Erro ao criar o provedor simulado: %v", err)
+	}
+	mock.Identidade["sub"] = "mock-" + *email
+	mock.Identidade["email"] = *email
+	mock.Identidade["name"] = *nome
+	if *grupos != "" {
+		mock.Identidade["groups"] = strings.Split(*grupos, ",")
+	}
+
+	logger.InfoLogger.Printf("Provedor OIDC simulado em %s (client ID %q, segredo %q)", emissor, *clientID, *segredo)
+	if err := http.ListenAndServe(*endereco, mock); err != nil {
+		logger.ErrorLogger.Fatalf("Erro no provedor simulado: %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+// Comando recifrar enfileira na fila de jobs do MySQL a recifragem dos dados pessoais de todas as
+// empresas e aguarda a conclusão. Use-o depois de incluir uma nova chave mestra em CRIPTO_CHAVES_MESTRAS;
+// quando terminar com sucesso, a chave antiga pode ser retirada da configuração. Cada instância da API
+// guarda os próprios cadastros, por isso recebe um job na sua fila e recifra os dados dela; todas
+// precisam estar no ar com a nova configuração e ser informadas em -instancias.
+package main
+
+import (
+	"flag"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/jobs"
+	"github.com/Pantaleaogc/gvero/pkg/database"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	logger.Init()
+	defer logger.Close()
+
+	prazo := flag.Duration("prazo", time.Hour, "tempo máximo de espera pela conclusão")
+	lista := flag.String("instancias", "", "INSTANCIA de cada instância da API, separadas por vírgula")
+	flag.Parse()
+
+	var instancias []string
+	for _, i := range strings.Split(*lista, ",") {
+		if i = strings.TrimSpace(i); i != "" {
+			instancias = append(instancias, i)
+		}
+	}
+	if len(instancias) == 0 {
+		logger.ErrorLogger.Fatal("Informe em -instancias todas as instâncias da API")
+	}
+
+	if err := godotenv.Load("configs/.env"); err != nil {
+		logger.InfoLogger.Printf("Aviso: arquivo .env não encontrado: %v", err)
+	}
+
+	db, err := database.InitDB()
+	if err != nil {
+		logger.ErrorLogger.Fatalf("Erro ao conectar ao banco de dados: %v", err)
+	}
+	defer db.Close()
+
+	// O serviço não é iniciado: só enfileira, e o handler registrado aqui nunca executa
+	repo := jobs.NewMySQLRepository(db)
+	service := jobs.NewService(repo)
+
+	// Com a chave fixa, uma recifragem já em andamento na instância é reaproveitada em vez de duplicada
+	pendentes := make([]*jobs.Job, 0, len(instancias))
+	for _, instancia := range instancias {
+		service.Registrar(cliente.TipoJobRecifrar, cliente.FilaRecifrar(instancia), nil)
+		j, err := service.Enfileirar(0, cliente.TipoJobRecifrar, nil, jobs.Opcoes{Chave: "recifrar:" + instancia})
+		if err != nil {
+			logger.ErrorLogger.Fatalf("Erro ao enfileirar a recifragem de %s: %v", instancia, err)
+		}
+		logger.InfoLogger.Printf("Recifragem de %s no job %d", instancia, j.ID)
+		pendentes = append(pendentes, j)
+	}
+
+	limite := time.Now().Add(*prazo)
+	for i, j := range pendentes {
+		for j.Ativo() {
+			if time.Now().After(limite) {
+				logger.ErrorLogger.Fatalf("Job %d de %s não concluído em %s (status %s)", j.ID, instancias[i], *prazo, j.Status)
+			}
+			time.Sleep(2 * time.Second)
+			if j, err = repo.GetByID(j.ID); err != nil {
+				logger.ErrorLogger.Fatalf("Erro ao consultar o job: %v", err)
+			}
+		}
+
+		if j.Status != jobs.StatusConcluido {
+			logger.ErrorLogger.Fatalf("Recifragem de %s terminou com status %s: %s", instancias[i], j.Status, j.Erro)
+		}
+	}
+	logger.InfoLogger.Printf("Recifragem concluída em %d instâncias; a chave mestra anterior pode ser retirada", len(instancias))
+}
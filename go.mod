@@ -3,7 +3,13 @@ module github.com/Pantaleaogc/gvero
 go 1.21
 
 require (
+	github.com/boombuler/barcode v1.0.1
 	github.com/go-chi/chi/v5 v5.0.10
+	github.com/go-pdf/fpdf v0.9.0
 	github.com/go-sql-driver/mysql v1.7.1
+	github.com/golang-jwt/jwt/v5 v5.3.1
 	github.com/joho/godotenv v1.5.1
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang.org/x/crypto v0.11.0
+	software.sslmate.com/src/go-pkcs12 v0.7.3
 )
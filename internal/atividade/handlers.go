@@ -0,0 +1,316 @@
+package atividade
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP para atividades
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas para atividades
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/", h.List)
+	r.Post("/", h.Create)
+	r.Get("/{id}", h.GetByID)
+	r.Put("/{id}", h.Update)
+	r.Delete("/{id}", h.Delete)
+
+	return r
+}
+
+// RotasCliente retorna as sub-rotas de atividades montadas dentro das rotas de clientes
+func RotasCliente(repo Repository, service *Service) func(r chi.Router) {
+	h := NewHandlers(repo, service)
+
+	return func(r chi.Router) {
+		r.Get("/{id}/timeline", h.Timeline)
+		r.Post("/{id}/atividades", h.CreateParaCliente)
+	}
+}
+
+// List lista as atividades da empresa do usuário atual.
+// Filtros: cliente_id, negocio_id, usuario_id ("me" para o usuário atual) e tipo.
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	if limit <= 0 {
+		limit = 50 // valor padrão
+	}
+
+	f := Filtro{
+		EmpresaID: user.Empresa,
+		Tipo:      q.Get("tipo"),
+	}
+	f.ClienteID, _ = strconv.Atoi(q.Get("cliente_id"))
+	f.NegocioID, _ = strconv.Atoi(q.Get("negocio_id"))
+	if q.Get("usuario_id") == "me" {
+		f.UsuarioID = user.ID
+	} else {
+		f.UsuarioID, _ = strconv.Atoi(q.Get("usuario_id"))
+	}
+
+	atividades, err := h.repo.List(f, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	total, err := h.repo.Count(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ListaAtividades{
+		Itens:  atividades,
+		Total:  total,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// GetByID retorna uma atividade por ID
+func (h *Handlers) GetByID(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	a, err := h.repo.GetByID(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// Create registra uma atividade manual (ligação, reunião, email ou nota)
+func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var a Atividade
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.criar(w, user, &a)
+}
+
+// CreateParaCliente registra uma atividade manual vinculada ao cliente da URL
+func (h *Handlers) CreateParaCliente(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	clienteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var a Atividade
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.ClienteID = clienteID
+
+	h.criar(w, user, &a)
+}
+
+// criar valida e grava uma atividade manual em nome do usuário atual
+func (h *Handlers) criar(w http.ResponseWriter, user auth.User, a *Atividade) {
+	if !tipoManual(a.Tipo) {
+		http.Error(w, "Tipo de atividade inválido", http.StatusBadRequest)
+		return
+	}
+
+	// Garantir que a atividade seja associada à empresa do usuário
+	a.EmpresaID = user.Empresa
+	if a.UsuarioID == 0 {
+		a.UsuarioID = user.ID
+	}
+
+	if err := h.validarCliente(a); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Create(a); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(a)
+}
+
+// Update atualiza uma atividade existente
+func (h *Handlers) Update(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	existing, err := h.repo.GetByID(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	// Eventos de sistema não podem ser editados
+	if existing.Tipo == TipoSistema {
+		http.Error(w, "Atividades de sistema não podem ser alteradas", http.StatusForbidden)
+		return
+	}
+
+	var a Atividade
+	if err := json.NewDecoder(r.Body).Decode(&a); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !tipoManual(a.Tipo) {
+		http.Error(w, "Tipo de atividade inválido", http.StatusBadRequest)
+		return
+	}
+
+	a.ID = id
+	a.EmpresaID = user.Empresa
+	if a.UsuarioID == 0 {
+		a.UsuarioID = existing.UsuarioID
+	}
+
+	if err := h.validarCliente(&a); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Update(&a); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(a)
+}
+
+// Delete remove uma atividade
+func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id, user.Empresa); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Timeline retorna a linha do tempo de um cliente
+func (h *Handlers) Timeline(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	clienteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 50 // valor padrão
+	}
+
+	t, err := h.service.TimelineCliente(user.Empresa, clienteID, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// validarCliente garante que o cliente vinculado pertence à empresa da atividade
+func (h *Handlers) validarCliente(a *Atividade) error {
+	if a.ClienteID == 0 {
+		return nil
+	}
+	if _, err := h.service.clientes.GetByID(a.ClienteID, a.EmpresaID); err != nil {
+		return errors.New("cliente não encontrado")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package atividade
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// requisitar chama o manipulador como o usuário informado, com o ID na rota
+func requisitar(handler http.HandlerFunc, user auth.User, metodo string, id int, corpo string) int {
+	req := httptest.NewRequest(metodo, "/", strings.NewReader(corpo))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", strconv.Itoa(id))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+	ctx = context.WithValue(ctx, auth.UserContextKey, user)
+	rec := httptest.NewRecorder()
+	handler(rec, req.WithContext(ctx))
+	return rec.Code
+}
+
+func TestHandlersEscopoDaEmpresa(t *testing.T) {
+	s, repo, c1, c2 := novoServico(t)
+	h := NewHandlers(repo, s)
+	ana := auth.User{ID: 1, Empresa: 1}
+
+	sistema := &Atividade{EmpresaID: 1, ClienteID: c1.ID, Tipo: TipoSistema, Titulo: "Pedido faturado"}
+	alheia := &Atividade{EmpresaID: 2, ClienteID: c2.ID, Tipo: TipoNota, Titulo: "nota"}
+	for _, a := range []*Atividade{sistema, alheia} {
+		if err := repo.Create(a); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		nome     string
+		handler  http.HandlerFunc
+		metodo   string
+		id       int
+		corpo    string
+		esperado int
+	}{
+		{"nota para o próprio cliente", h.CreateParaCliente, http.MethodPost, c1.ID, `{"tipo":"nota","titulo":"ok"}`, http.StatusCreated},
+		{"nota para cliente de outra empresa", h.CreateParaCliente, http.MethodPost, c2.ID, `{"tipo":"nota","titulo":"x"}`, http.StatusBadRequest},
+		{"atividade de sistema manual", h.CreateParaCliente, http.MethodPost, c1.ID, `{"tipo":"sistema","titulo":"x"}`, http.StatusBadRequest},
+		{"alterar atividade de sistema", h.Update, http.MethodPut, sistema.ID, `{"tipo":"nota","titulo":"x"}`, http.StatusForbidden},
+		{"ler atividade de outra empresa", h.GetByID, http.MethodGet, alheia.ID, "", http.StatusNotFound},
+		{"alterar atividade de outra empresa", h.Update, http.MethodPut, alheia.ID, `{"tipo":"nota","titulo":"x"}`, http.StatusNotFound},
+		{"linha do tempo de outra empresa", h.Timeline, http.MethodGet, c2.ID, "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		if status := requisitar(tt.handler, ana, tt.metodo, tt.id, tt.corpo); status != tt.esperado {
+			t.Errorf("%s: status %d, esperado %d", tt.nome, status, tt.esperado)
+		}
+	}
+
+	if status := requisitar(h.Delete, ana, http.MethodDelete, alheia.ID, ""); status == http.StatusNoContent {
+		t.Error("atividade de outra empresa excluída")
+	}
+	if a, err := repo.GetByID(alheia.ID, 2); err != nil || a.Titulo != "nota" {
+		t.Errorf("atividade de outra empresa alterada: %+v, %v", a, err)
+	}
+}
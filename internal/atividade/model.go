@@ -0,0 +1,96 @@
+package atividade
+
+import (
+	"time"
+)
+
+// Tipos de atividade
+const (
+	TipoLigacao = "ligacao"
+	TipoReuniao = "reuniao"
+	TipoEmail   = "email"
+	TipoNota    = "nota"
+	TipoSistema = "sistema"
+)
+
+// Resultados sugeridos para ligações e reuniões
+const (
+	ResultadoRealizada    = "realizada"
+	ResultadoNaoAtendida  = "nao_atendida"
+	ResultadoCaixaPostal  = "caixa_postal"
+	ResultadoReagendada   = "reagendada"
+	ResultadoSemInteresse = "sem_interesse"
+)
+
+// Atividade representa uma interação ou evento registrado no sistema
+type Atividade struct {
+	ID             int       `json:"id"`
+	EmpresaID      int       `json:"empresa_id"`
+	Tipo           string    `json:"tipo"`
+	Titulo         string    `json:"titulo"`
+	Descricao      string    `json:"descricao,omitempty"`
+	ClienteID      int       `json:"cliente_id,omitempty"`
+	NegocioID      int       `json:"negocio_id,omitempty"`
+	UsuarioID      int       `json:"usuario_id,omitempty"`
+	Data           time.Time `json:"data"` // quando a atividade aconteceu
+	DuracaoMinutos int       `json:"duracao_minutos,omitempty"`
+	Resultado      string    `json:"resultado,omitempty"`
+	DataCriacao    time.Time `json:"data_criacao"`
+}
+
+// Filtro restringe a listagem de atividades; campos zerados não filtram
+type Filtro struct {
+	EmpresaID int
+	ClienteID int
+	NegocioID int
+	UsuarioID int
+	Tipo      string
+}
+
+// ItemTimeline representa uma entrada da linha do tempo de um cliente
+type ItemTimeline struct {
+	Tipo      string     `json:"tipo"`
+	Origem    string     `json:"origem"` // "atividade" ou "cliente"
+	Data      time.Time  `json:"data"`
+	Titulo    string     `json:"titulo"`
+	Descricao string     `json:"descricao,omitempty"`
+	Atividade *Atividade `json:"atividade,omitempty"`
+}
+
+// Timeline representa uma página da linha do tempo
+type Timeline struct {
+	Itens  []ItemTimeline `json:"itens"`
+	Total  int            `json:"total"`
+	Limit  int            `json:"limit"`
+	Offset int            `json:"offset"`
+}
+
+// ListaAtividades representa uma página de atividades
+type ListaAtividades struct {
+	Itens  []*Atividade `json:"itens"`
+	Total  int          `json:"total"`
+	Limit  int          `json:"limit"`
+	Offset int          `json:"offset"`
+}
+
+// Repository define a interface para acesso aos dados de atividades
+type Repository interface {
+	Create(a *Atividade) error
+	GetByID(id int, empresaID int) (*Atividade, error)
+	Update(a *Atividade) error
+	Delete(id int, empresaID int) error
+	List(f Filtro, limit, offset int) ([]*Atividade, error)
+	Count(f Filtro) (int, error)
+
+	// TransferirCliente reaponta para paraID os registros de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
+
+// tipoManual verifica se o tipo pode ser registrado manualmente pelos usuários
+func tipoManual(tipo string) bool {
+	switch tipo {
+	case TipoLigacao, TipoReuniao, TipoEmail, TipoNota:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package atividade
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	atividades map[int]*Atividade
+	nextID     int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		atividades: make(map[int]*Atividade),
+		nextID:     1,
+	}
+}
+
+// Create adiciona uma nova atividade
+func (r *MemoryRepository) Create(a *Atividade) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Validação básica
+	if a.Tipo == "" || a.Titulo == "" {
+		return errors.New("tipo e título são obrigatórios")
+	}
+
+	if a.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if a.DuracaoMinutos < 0 {
+		return errors.New("duração inválida")
+	}
+
+	// Configurar campos
+	a.ID = r.nextID
+	r.nextID++
+	a.DataCriacao = time.Now()
+	if a.Data.IsZero() {
+		a.Data = a.DataCriacao
+	}
+
+	// Adicionar ao mapa
+	r.atividades[a.ID] = a
+	return nil
+}
+
+// GetByID busca uma atividade por ID e empresa
+func (r *MemoryRepository) GetByID(id int, empresaID int) (*Atividade, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	a, exists := r.atividades[id]
+	if !exists || a.EmpresaID != empresaID {
+		return nil, errors.New("atividade não encontrada")
+	}
+	return a, nil
+}
+
+// Update atualiza uma atividade existente
+func (r *MemoryRepository) Update(a *Atividade) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.atividades[a.ID]
+	if !exists {
+		return errors.New("atividade não encontrada")
+	}
+
+	// Verificar se pertence à mesma empresa
+	if existing.EmpresaID != a.EmpresaID {
+		return errors.New("operação não permitida: atividade pertence a outra empresa")
+	}
+
+	// Validação básica
+	if a.Tipo == "" || a.Titulo == "" {
+		return errors.New("tipo e título são obrigatórios")
+	}
+
+	// Preservar campos que não devem ser alterados
+	a.DataCriacao = existing.DataCriacao
+	if a.Data.IsZero() {
+		a.Data = existing.Data
+	}
+
+	// Atualizar
+	r.atividades[a.ID] = a
+	return nil
+}
+
+// Delete remove uma atividade
+func (r *MemoryRepository) Delete(id int, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	a, exists := r.atividades[id]
+	if !exists {
+		return errors.New("atividade não encontrada")
+	}
+
+	// Verificar se pertence à mesma empresa
+	if a.EmpresaID != empresaID {
+		return errors.New("operação não permitida: atividade pertence a outra empresa")
+	}
+
+	delete(r.atividades, id)
+	return nil
+}
+
+// List retorna as atividades do filtro, das mais recentes para as mais antigas
+func (r *MemoryRepository) List(f Filtro, limit, offset int) ([]*Atividade, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := r.filtrar(f)
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Data.Equal(result[j].Data) {
+			return result[i].ID > result[j].ID
+		}
+		return result[i].Data.After(result[j].Data)
+	})
+
+	if offset >= len(result) {
+		return []*Atividade{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// Count retorna a quantidade de atividades do filtro
+func (r *MemoryRepository) Count(f Filtro) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.filtrar(f)), nil
+}
+
+// filtrar retorna as atividades que atendem ao filtro (deve ser chamado com o lock adquirido)
+func (r *MemoryRepository) filtrar(f Filtro) []*Atividade {
+	result := make([]*Atividade, 0)
+	for _, a := range r.atividades {
+		if a.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.ClienteID > 0 && a.ClienteID != f.ClienteID {
+			continue
+		}
+		if f.NegocioID > 0 && a.NegocioID != f.NegocioID {
+			continue
+		}
+		if f.UsuarioID > 0 && a.UsuarioID != f.UsuarioID {
+			continue
+		}
+		if f.Tipo != "" && a.Tipo != f.Tipo {
+			continue
+		}
+		result = append(result, a)
+	}
+	return result
+}
+
+// TransferirCliente move as atividades de um cliente para outro e retorna quantos foram alterados
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, a := range r.atividades {
+		if a.EmpresaID == empresaID && a.ClienteID == deID {
+			a.ClienteID = paraID
+			total++
+		}
+	}
+	return total, nil
+}
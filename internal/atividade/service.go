@@ -0,0 +1,115 @@
+package atividade
+
+import (
+	"sort"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// Service monta a linha do tempo combinando as atividades registradas com os eventos do cadastro do cliente
+type Service struct {
+	repo     Repository
+	clientes cliente.Repository
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, clientes cliente.Repository) *Service {
+	return &Service{
+		repo:     repo,
+		clientes: clientes,
+	}
+}
+
+// RegistrarSistema grava um evento de sistema na linha do tempo
+func (s *Service) RegistrarSistema(empresaID, clienteID, usuarioID int, titulo, descricao string) error {
+	return s.repo.Create(&Atividade{
+		EmpresaID: empresaID,
+		Tipo:      TipoSistema,
+		Titulo:    titulo,
+		Descricao: descricao,
+		ClienteID: clienteID,
+		UsuarioID: usuarioID,
+	})
+}
+
+// TimelineCliente retorna a linha do tempo paginada de um cliente, da mais recente para a mais antiga
+func (s *Service) TimelineCliente(empresaID, clienteID int, limit, offset int) (*Timeline, error) {
+	c, err := s.clientes.GetByID(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Os eventos derivados do cadastro são poucos; as atividades vêm ordenadas do repositório.
+	// Buscar apenas o necessário para cobrir a página após a intercalação.
+	derivados := eventosCliente(c)
+	necessarios := offset + limit
+	if limit <= 0 {
+		necessarios = 0 // sem limite
+	}
+	atividades, err := s.repo.List(Filtro{EmpresaID: empresaID, ClienteID: clienteID}, necessarios, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	totalAtividades, err := s.repo.Count(Filtro{EmpresaID: empresaID, ClienteID: clienteID})
+	if err != nil {
+		return nil, err
+	}
+
+	itens := make([]ItemTimeline, 0, len(atividades)+len(derivados))
+	for _, a := range atividades {
+		itens = append(itens, ItemTimeline{
+			Tipo:      a.Tipo,
+			Origem:    "atividade",
+			Data:      a.Data,
+			Titulo:    a.Titulo,
+			Descricao: a.Descricao,
+			Atividade: a,
+		})
+	}
+	itens = append(itens, derivados...)
+
+	sort.SliceStable(itens, func(i, j int) bool {
+		return itens[i].Data.After(itens[j].Data)
+	})
+
+	t := &Timeline{
+		Itens:  []ItemTimeline{},
+		Total:  totalAtividades + len(derivados),
+		Limit:  limit,
+		Offset: offset,
+	}
+
+	if offset < len(itens) {
+		itens = itens[offset:]
+		if limit > 0 && limit < len(itens) {
+			itens = itens[:limit]
+		}
+		t.Itens = itens
+	}
+
+	return t, nil
+}
+
+// eventosCliente gera os itens da linha do tempo derivados do próprio cadastro do cliente
+func eventosCliente(c *cliente.Cliente) []ItemTimeline {
+	itens := []ItemTimeline{
+		{
+			Tipo:   TipoSistema,
+			Origem: "cliente",
+			Data:   c.DataCriacao,
+			Titulo: "Cliente cadastrado",
+		},
+	}
+
+	if !c.UltimaCompra.IsZero() {
+		itens = append(itens, ItemTimeline{
+			Tipo:   TipoSistema,
+			Origem: "cliente",
+			Data:   c.UltimaCompra,
+			Titulo: "Última compra",
+		})
+	}
+
+	return itens
+}
@@ -0,0 +1,73 @@
+package atividade
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// novoServico cadastra um cliente em cada uma das empresas 1 e 2
+func novoServico(t *testing.T) (*Service, *MemoryRepository, *cliente.Cliente, *cliente.Cliente) {
+	t.Helper()
+	clientes := cliente.NewMemoryRepository()
+	c1 := &cliente.Cliente{Nome: "Ana", Email: "ana@acme.com", EmpresaID: 1}
+	c2 := &cliente.Cliente{Nome: "Bia", Email: "bia@beta.com", EmpresaID: 2}
+	for _, c := range []*cliente.Cliente{c1, c2} {
+		if err := clientes.Create(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	repo := NewMemoryRepository()
+	return NewService(repo, clientes), repo, c1, c2
+}
+
+// A linha do tempo intercala atividades e eventos do cadastro e pagina sobre o conjunto
+func TestTimelineCliente(t *testing.T) {
+	s, repo, c, _ := novoServico(t)
+	c.UltimaCompra = c.DataCriacao.Add(-48 * time.Hour)
+
+	base := c.DataCriacao
+	for i, horas := range []int{-72, -24, 1} {
+		if err := repo.Create(&Atividade{EmpresaID: 1, ClienteID: c.ID, Tipo: TipoNota, Titulo: "nota " + strconv.Itoa(i), Data: base.Add(time.Duration(horas) * time.Hour)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Atividades de outro cliente e de outra empresa ficam fora
+	repo.Create(&Atividade{EmpresaID: 1, ClienteID: c.ID + 1, Tipo: TipoNota, Titulo: "outro"})
+	repo.Create(&Atividade{EmpresaID: 2, ClienteID: c.ID, Tipo: TipoNota, Titulo: "outra empresa"})
+
+	tests := []struct {
+		limit, offset int
+		titulos       []string
+	}{
+		{0, 0, []string{"nota 2", "Cliente cadastrado", "nota 1", "Última compra", "nota 0"}},
+		{2, 0, []string{"nota 2", "Cliente cadastrado"}},
+		{2, 2, []string{"nota 1", "Última compra"}},
+		{2, 4, []string{"nota 0"}},
+		{2, 6, []string{}},
+	}
+	for _, tt := range tests {
+		timeline, err := s.TimelineCliente(1, c.ID, tt.limit, tt.offset)
+		if err != nil {
+			t.Fatal(err)
+		}
+		titulos := make([]string, 0, len(timeline.Itens))
+		for _, item := range timeline.Itens {
+			titulos = append(titulos, item.Titulo)
+		}
+		if strings.Join(titulos, ",") != strings.Join(tt.titulos, ",") || timeline.Total != 5 {
+			t.Errorf("limit %d offset %d: %v (total %d), esperado %v (total 5)", tt.limit, tt.offset, titulos, timeline.Total, tt.titulos)
+		}
+	}
+}
+
+// A linha do tempo de um cliente de outra empresa não é encontrada
+func TestTimelineOutraEmpresa(t *testing.T) {
+	s, _, _, c2 := novoServico(t)
+	if _, err := s.TimelineCliente(1, c2.ID, 10, 0); err == nil {
+		t.Error("linha do tempo de outra empresa retornada")
+	}
+}
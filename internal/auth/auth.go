@@ -5,7 +5,11 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/Pantaleaogc/gvero/internal/doisfatores"
+	"github.com/Pantaleaogc/gvero/internal/seguranca"
+	"github.com/Pantaleaogc/gvero/internal/sso"
 	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/limite"
 	"github.com/Pantaleaogc/gvero/pkg/logger"
 	"github.com/go-chi/chi/v5"
 	"github.com/golang-jwt/jwt/v5"
@@ -25,6 +29,17 @@ type LoginRequest struct {
 type LoginResponse struct {
 	Token string         `json:"token"`
 	User  UserResponse   `json:"user"`
+
+	// Códigos de recuperação, presentes apenas quando o segundo fator é ativado no login
+	CodigosRecuperacao []string `json:"codigos_recuperacao,omitempty"`
+}
+
+// DesafioResponse é a resposta do login quando a senha confere, mas falta o segundo fator
+type DesafioResponse struct {
+	SegundoFator        bool      `json:"segundo_fator"`
+	CadastroObrigatorio bool      `json:"cadastro_obrigatorio,omitempty"` // a política exige cadastrar o segundo fator
+	Desafio             string    `json:"desafio"`
+	Expira              time.Time `json:"expira"`
 }
 
 // UserResponse representa os dados do usuário na resposta
@@ -38,26 +53,48 @@ type UserResponse struct {
 
 // Handlers contém os handlers HTTP para autenticação
 type Handlers struct {
-	userRepo usuario.Repository
+	userRepo    usuario.Repository
+	doisFatores *doisfatores.Service
+	protecao    *seguranca.Service
+	sso         *sso.Service
+	inicioSSO   *limite.Janela
 }
 
 // NewHandlers cria uma nova instância de Handlers
-func NewHandlers(userRepo usuario.Repository) *Handlers {
+func NewHandlers(userRepo usuario.Repository, doisFatores *doisfatores.Service, protecao *seguranca.Service, sso *sso.Service) *Handlers {
 	return &Handlers{
-		userRepo: userRepo,
+		userRepo:    userRepo,
+		doisFatores: doisFatores,
+		protecao:    protecao,
+		sso:         sso,
+		inicioSSO:   limite.NewJanela(limiteInicioSSO, janelaInicioSSO),
 	}
 }
 
-// Routes retorna as rotas para autenticação
-func Routes() http.Handler {
-	userRepo := usuario.NewMemoryRepository()
-	h := NewHandlers(userRepo)
+// Routes retorna as rotas para autenticação. Sem doisFatores, o login usa apenas a senha; sem
+// protecao, as tentativas não são limitadas nem registradas no log de segurança; sem sso, não
+// há login pelo provedor de identidade das empresas.
+// As subrotas de outros módulos (recuperação de senha, convites) são públicas, salvo quando
+// aplicam o Middleware.
+func Routes(userRepo usuario.Repository, doisFatores *doisfatores.Service, protecao *seguranca.Service, sso *sso.Service, subrotas ...func(r chi.Router)) http.Handler {
+	h := NewHandlers(userRepo, doisFatores, protecao, sso)
 
 	r := chi.NewRouter()
 	r.Post("/login", h.Login)
 	r.With(Middleware).Get("/verify", h.Verify)
 	r.With(Middleware).Post("/logout", h.Logout)
 
+	if doisFatores != nil {
+		h.rotasDoisFatores(r)
+	}
+	if sso != nil {
+		h.rotasSSO(r)
+	}
+
+	for _, registrar := range subrotas {
+		registrar(r)
+	}
+
 	return r
 }
 
@@ -70,22 +107,47 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Recusar antes de conferir a senha durante o atraso progressivo ou o bloqueio
+	tentativa := seguranca.Tentativa{Email: req.Email, IP: limite.IP(r)}
+	if !h.permitirTentativa(w, tentativa) {
+		return
+	}
+
 	// Buscar usuário por email
 	user, err := h.userRepo.GetByEmail(req.Email)
 	if err != nil {
-		logger.InfoLogger.Printf("Tentativa de login com email não encontrado: %s", req.Email)
+		usuario.SimularVerificacao(req.Password)
+		logger.InfoLogger.Printf("Tentativa de login com email não encontrado: %s", seguranca.MascararEmail(req.Email))
+		h.registrarFalha(tentativa, seguranca.MotivoEmailDesconhecido)
 		http.Error(w, "Credenciais inválidas", http.StatusUnauthorized)
 		return
 	}
+	tentativa.UsuarioID, tentativa.EmpresaID = user.ID, user.EmpresaID
 
-	// Verificar senha (em produção, você deve usar bcrypt ou similar)
-	// Nota: Este é um exemplo simples, em produção use hashing adequado
-	if user.Senha != req.Password {
-		logger.InfoLogger.Printf("Tentativa de login com senha incorreta para: %s", req.Email)
+	// Verificar senha pelo hash bcrypt
+	if !user.VerificarSenha(req.Password) {
+		logger.InfoLogger.Printf("Tentativa de login com senha incorreta para: %s", seguranca.MascararEmail(req.Email))
+		h.registrarFalha(tentativa, seguranca.MotivoSenhaIncorreta)
 		http.Error(w, "Credenciais inválidas", http.StatusUnauthorized)
 		return
 	}
+	h.estornarTentativa(tentativa)
+
+	// Com o segundo fator ativo ou exigido pela empresa, o token só sai depois do código; as
+	// falhas da conta só zeram quando o login termina
+	if h.doisFatores != nil {
+		ativo := h.doisFatores.Ativo(user.ID)
+		if ativo || h.doisFatores.Exigido(user, empresaDoUsuario(user)) {
+			h.responderDesafio(w, user, !ativo)
+			return
+		}
+	}
+
+	h.responderLogin(w, r, user, nil)
+}
 
+// responderLogin emite o token JWT da sessão e responde com os dados do usuário
+func (h *Handlers) responderLogin(w http.ResponseWriter, r *http.Request, user *usuario.Usuario, codigosRecuperacao []string) {
 	// Gerar token JWT
 	token, err := generateJWT(user)
 	if err != nil {
@@ -106,11 +168,13 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
 			Nome:      user.Nome,
 			Email:     user.Email,
 			Tipo:      user.Tipo,
-			EmpresaID: 1, // Você deve obter isso do usuário em uma implementação real
+			EmpresaID: empresaDoUsuario(user),
 		},
+		CodigosRecuperacao: codigosRecuperacao,
 	}
 
-	logger.InfoLogger.Printf("Login bem-sucedido: %s (ID: %d)", user.Email, user.ID)
+	h.registrarSucesso(h.tentativaDe(r, user))
+	logger.InfoLogger.Printf("Login bem-sucedido: %s (ID: %d)", seguranca.MascararEmail(user.Email), user.ID)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -137,7 +201,7 @@ func (h *Handlers) Verify(w http.ResponseWriter, r *http.Request) {
 		Nome:      userObj.Nome,
 		Email:     userObj.Email,
 		Tipo:      userObj.Tipo,
-		EmpresaID: 1, // Você deve obter isso do usuário em uma implementação real
+		EmpresaID: empresaDoUsuario(userObj),
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -163,7 +227,20 @@ func generateJWT(user *usuario.Usuario) (string, error) {
 		"tipo":  user.Tipo,
 		"exp":   expirationTime.Unix(),
 	}
+	if user.EmpresaID > 0 {
+		claims["empresa"] = user.EmpresaID
+	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString(jwtKey)
+}
+
+// empresaDoUsuario retorna a empresa da sessão do usuário, usando a empresa padrão quando não houver
+// vínculo. Não serve para decidir acesso: o administrador do sistema, sem empresa, não pertence à
+// empresa padrão
+func empresaDoUsuario(user *usuario.Usuario) int {
+	if user.EmpresaID > 0 {
+		return user.EmpresaID
+	}
+	return 1
 }
\ No newline at end of file
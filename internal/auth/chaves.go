@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Pantaleaogc/gvero/internal/chaveapi"
+	"github.com/Pantaleaogc/gvero/pkg/limite"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// PapelIntegracao é o papel dos acessos por chave de API. Não passa em RequireRole("admin"),
+// de modo que uma chave não administra usuários nem cria outras chaves.
+const PapelIntegracao = "integracao"
+
+// ChaveContextKey guarda no contexto a chave de API da requisição
+const ChaveContextKey = contextKey("chave_api")
+
+// chavesAPI habilita o cabeçalho "Authorization: ApiKey ..." no Middleware
+var chavesAPI *chaveapi.Service
+
+// UsarChavesAPI faz o Middleware aceitar as chaves de API do serviço, além dos tokens JWT
+func UsarChavesAPI(service *chaveapi.Service) {
+	chavesAPI = service
+}
+
+// ChaveFromContext retorna a chave de API quando a requisição foi autenticada por ela
+func ChaveFromContext(ctx context.Context) (*chaveapi.Chave, bool) {
+	c, ok := ctx.Value(ChaveContextKey).(*chaveapi.Chave)
+	return c, ok
+}
+
+// ChavesRoutes retorna as rotas de administração das chaves de API da empresa
+func ChavesRoutes(service *chaveapi.Service) http.Handler {
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Use(RequireRole("admin"))
+
+	r.Get("/", listarChaves(service))
+	r.Post("/", criarChave(service))
+	r.Get("/escopos", listarEscopos)
+	r.Delete("/{id}", revogarChave(service))
+
+	return r
+}
+
+// autenticarChave conclui o Middleware para as chaves de API: a chave precisa de um escopo para o
+// recurso da rota (/api/v1/<recurso>), com nível escrita nos métodos que alteram dados
+func autenticarChave(w http.ResponseWriter, r *http.Request, next http.Handler, valor string) {
+	if chavesAPI == nil {
+		http.Error(w, "Formato de autorização inválido", http.StatusUnauthorized)
+		return
+	}
+
+	c, err := chavesAPI.Autenticar(strings.TrimSpace(valor), limite.IP(r))
+	if err != nil {
+		logger.DebugLogger.Printf("Chave de API recusada em %s: %v", r.URL.Path, err)
+		http.Error(w, "Chave de API inválida ou expirada", http.StatusUnauthorized)
+		return
+	}
+
+	recurso := recursoDaRota(r.URL.Path)
+	escrita := r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodOptions
+	if !c.Permite(recurso, escrita) {
+		logger.DebugLogger.Printf("Chave de API %s sem escopo para %s %s", c.Prefixo, r.Method, r.URL.Path)
+		http.Error(w, "A chave de API não tem escopo para este recurso", http.StatusForbidden)
+		return
+	}
+
+	// Os registros gravados pela chave ficam em nome do administrador que a criou; o prefixo
+	// identifica a chave no e-mail do usuário e no log das alterações
+	user := User{
+		ID:      c.UsuarioID,
+		Email:   "chave:" + c.Prefixo,
+		Role:    PapelIntegracao,
+		Empresa: c.EmpresaID,
+	}
+	if escrita {
+		logger.InfoLogger.Printf("Chave de API %s (empresa %d, criada por usuário %d): %s %s", c.Prefixo, c.EmpresaID, c.UsuarioID, r.Method, r.URL.Path)
+	} else {
+		logger.DebugLogger.Printf("Chave de API autenticada: %s (empresa %d)", c.Prefixo, c.EmpresaID)
+	}
+
+	ctx := context.WithValue(r.Context(), UserContextKey, user)
+	ctx = context.WithValue(ctx, ChaveContextKey, c)
+	next.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// recursoDaRota extrai o módulo de /api/v1/<recurso>/...
+func recursoDaRota(caminho string) string {
+	_, resto, ok := strings.Cut(caminho, "/api/v1/")
+	if !ok {
+		return ""
+	}
+	recurso, _, _ := strings.Cut(resto, "/")
+	return recurso
+}
+
+// listarChaves lista as chaves da empresa, sem os valores
+func listarChaves(service *chaveapi.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		chaves, err := service.List(user.Empresa)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(chaves)
+	}
+}
+
+// criarChave cria uma chave e retorna o seu valor, exibido apenas nesta resposta
+func criarChave(service *chaveapi.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		var in chaveapi.NovaChave
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "Formato inválido", http.StatusBadRequest)
+			return
+		}
+
+		c, err := service.Criar(user.Empresa, user.ID, in)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(c)
+	}
+}
+
+// revogarChave invalida a chave imediatamente
+func revogarChave(service *chaveapi.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return
+		}
+
+		if err := service.Revogar(user.Empresa, id, user.ID); err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// listarEscopos retorna o catálogo de escopos aceitos na criação
+func listarEscopos(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(chaveapi.Escopos)
+}
@@ -0,0 +1,107 @@
+package auth
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/chaveapi"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// protegida responde com o usuário que o Middleware colocou no contexto
+func protegida() http.Handler {
+	return Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _ := FromContext(r.Context())
+		w.Write([]byte(user.Role + "|" + user.Email))
+	}))
+}
+
+func usarChaves(t *testing.T) *chaveapi.Service {
+	t.Helper()
+	s := chaveapi.NewService(chaveapi.NewMemoryRepository())
+	UsarChavesAPI(s)
+	t.Cleanup(func() { UsarChavesAPI(nil) })
+	return s
+}
+
+func TestMiddlewareChaveAPI(t *testing.T) {
+	s := usarChaves(t)
+	c, err := s.Criar(1, 7, chaveapi.NovaChave{Nome: "ERP", Escopos: []string{"clientes:leitura"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := protegida()
+
+	casos := []struct {
+		nome      string
+		metodo    string
+		caminho   string
+		cabecalho string
+		status    int
+	}{
+		{"leitura no escopo", http.MethodGet, "/api/v1/clientes", "ApiKey " + c.Valor, http.StatusOK},
+		{"esquema em minúsculas", http.MethodGet, "/api/v1/clientes/3", "apikey " + c.Valor, http.StatusOK},
+		{"escrita sem o nível", http.MethodPost, "/api/v1/clientes", "ApiKey " + c.Valor, http.StatusForbidden},
+		{"recurso fora do escopo", http.MethodGet, "/api/v1/financeiro", "ApiKey " + c.Valor, http.StatusForbidden},
+		{"notificações do criador", http.MethodGet, "/api/v1/notificacoes", "ApiKey " + c.Valor, http.StatusForbidden},
+		{"chave inválida", http.MethodGet, "/api/v1/clientes", "ApiKey " + c.Valor + "x", http.StatusUnauthorized},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			req := httptest.NewRequest(caso.metodo, caso.caminho, nil)
+			req.Header.Set("Authorization", caso.cabecalho)
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if rec.Code != caso.status {
+				t.Fatalf("status = %d, esperado %d", rec.Code, caso.status)
+			}
+			if rec.Code == http.StatusOK && rec.Body.String() != PapelIntegracao+"|chave:"+c.Prefixo {
+				t.Errorf("usuário da chave = %s", rec.Body)
+			}
+		})
+	}
+
+	// A chave nunca passa nas rotas de administradores
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/clientes", nil)
+	req.Header.Set("Authorization", "ApiKey "+c.Valor)
+	rec := httptest.NewRecorder()
+	Middleware(RequireRole("admin")(http.NotFoundHandler())).ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("chave em rota de administrador = %d", rec.Code)
+	}
+}
+
+// O log do formato inválido traz só o esquema, nunca a credencial
+func TestMiddlewareNaoRegistraCredencial(t *testing.T) {
+	var saida bytes.Buffer
+	anterior := logger.DebugLogger
+	logger.DebugLogger = log.New(&saida, "", 0)
+	t.Cleanup(func() { logger.DebugLogger = anterior })
+
+	h := protegida()
+	for _, cabecalho := range []string{
+		"Token gvk_segredo_a",
+		"gvk_segredo_b",
+		"gvk_segredo_c valor",
+		"Basic Z3ZrX3NlZ3JlZG9fZA==",
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/clientes", nil)
+		req.Header.Set("Authorization", cabecalho)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%q = %d", cabecalho, rec.Code)
+		}
+	}
+
+	if strings.Contains(saida.String(), "segredo") || strings.Contains(saida.String(), "Z3Zr") {
+		t.Errorf("credencial no log:\n%s", saida.String())
+	}
+	if !strings.Contains(saida.String(), `"Token"`) {
+		t.Errorf("esquema ausente do log:\n%s", saida.String())
+	}
+}
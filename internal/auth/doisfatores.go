@@ -0,0 +1,321 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/Pantaleaogc/gvero/internal/doisfatores"
+	"github.com/Pantaleaogc/gvero/internal/seguranca"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// CodigoRequest contém o código do aplicativo autenticador ou um código de recuperação
+type CodigoRequest struct {
+	Desafio string `json:"desafio,omitempty"`
+	Codigo  string `json:"codigo"`
+}
+
+// rotasDoisFatores registra a segunda etapa do login e o gerenciamento do segundo fator
+func (h *Handlers) rotasDoisFatores(r chi.Router) {
+	// Segunda etapa do login, autenticada pelo desafio emitido após a senha
+	r.Post("/login/2fa", h.LoginSegundoFator)
+	r.Post("/login/2fa/cadastro", h.CadastrarPorDesafio)
+	r.Post("/login/2fa/ativar", h.AtivarPorDesafio)
+
+	r.Route("/2fa", func(r chi.Router) {
+		r.Use(Middleware)
+		r.Get("/", h.StatusSegundoFator)
+		r.Post("/cadastro", h.CadastrarSegundoFator)
+		r.Post("/ativar", h.AtivarSegundoFator)
+		r.Post("/desativar", h.DesativarSegundoFator)
+		r.Post("/codigos-recuperacao", h.RenovarCodigosRecuperacao)
+
+		r.With(RequireRole("admin")).Get("/politica", h.GetPolitica)
+		r.With(RequireRole("admin")).Put("/politica", h.SalvarPolitica)
+	})
+}
+
+// responderDesafio encerra a primeira etapa do login com o desafio do segundo fator
+func (h *Handlers) responderDesafio(w http.ResponseWriter, user *usuario.Usuario, cadastro bool) {
+	valor, d, err := h.doisFatores.IniciarDesafio(user.ID, cadastro)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro ao iniciar desafio de segundo fator: %v", err)
+		http.Error(w, "Erro interno", http.StatusInternalServerError)
+		return
+	}
+
+	logger.InfoLogger.Printf("Senha conferida, aguardando segundo fator: %s (ID: %d)", seguranca.MascararEmail(user.Email), user.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(DesafioResponse{
+		SegundoFator:        true,
+		CadastroObrigatorio: cadastro,
+		Desafio:             valor,
+		Expira:              d.Expira,
+	})
+}
+
+// LoginSegundoFator conclui o login com o desafio e o código, emitindo o token JWT
+func (h *Handlers) LoginSegundoFator(w http.ResponseWriter, r *http.Request) {
+	var req CodigoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.doisFatores.Desafio(req.Desafio)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	user, err := h.userRepo.GetByID(d.UsuarioID)
+	if err != nil {
+		http.Error(w, "Usuário não encontrado", http.StatusUnauthorized)
+		return
+	}
+
+	// Os códigos errados contam como falhas da conta: sem isso, quem tem a senha poderia
+	// tentar os códigos indefinidamente, cinco a cada novo desafio
+	tentativa := h.tentativaDe(r, user)
+	if !h.permitirTentativa(w, tentativa) {
+		return
+	}
+
+	if _, err := h.doisFatores.ConcluirDesafio(req.Desafio, req.Codigo); err != nil {
+		logger.InfoLogger.Printf("Segundo fator recusado no login: %v", err)
+		if h.protecao != nil {
+			h.protecao.RegistrarSegundoFator(tentativa, false, err.Error())
+		}
+		h.registrarFalha(tentativa, seguranca.MotivoSegundoFator)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	h.estornarTentativa(tentativa)
+	if h.protecao != nil {
+		h.protecao.RegistrarSegundoFator(tentativa, true, "")
+	}
+	h.responderLogin(w, r, user, nil)
+}
+
+// CadastrarPorDesafio inicia o cadastro obrigatório do segundo fator durante o login
+func (h *Handlers) CadastrarPorDesafio(w http.ResponseWriter, r *http.Request) {
+	var req CodigoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.doisFatores.Desafio(req.Desafio)
+	if err != nil || !d.Cadastro {
+		http.Error(w, doisfatores.ErrDesafioInvalido.Error(), http.StatusUnauthorized)
+		return
+	}
+	user, err := h.userRepo.GetByID(d.UsuarioID)
+	if err != nil {
+		http.Error(w, "Usuário não encontrado", http.StatusUnauthorized)
+		return
+	}
+
+	prov, err := h.doisFatores.Cadastrar(user)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prov)
+}
+
+// AtivarPorDesafio ativa o segundo fator cadastrado durante o login e conclui o login,
+// retornando o token JWT e os códigos de recuperação
+func (h *Handlers) AtivarPorDesafio(w http.ResponseWriter, r *http.Request) {
+	var req CodigoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.doisFatores.Desafio(req.Desafio)
+	if err != nil || !d.Cadastro {
+		http.Error(w, doisfatores.ErrDesafioInvalido.Error(), http.StatusUnauthorized)
+		return
+	}
+	user, err := h.userRepo.GetByID(d.UsuarioID)
+	if err != nil {
+		http.Error(w, "Usuário não encontrado", http.StatusUnauthorized)
+		return
+	}
+
+	// Como no login com o segundo fator, os códigos errados contam como falhas da conta
+	tentativa := h.tentativaDe(r, user)
+	if !h.permitirTentativa(w, tentativa) {
+		return
+	}
+
+	_, codigos, err := h.doisFatores.AtivarPorDesafio(req.Desafio, req.Codigo)
+	if err != nil {
+		h.registrarFalha(tentativa, seguranca.MotivoSegundoFator)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	h.estornarTentativa(tentativa)
+	h.responderLogin(w, r, user, codigos)
+}
+
+// StatusSegundoFator informa se o usuário atual tem o segundo fator ativo e se é exigido
+func (h *Handlers) StatusSegundoFator(w http.ResponseWriter, r *http.Request) {
+	user, u, ok := h.usuarioAtual(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.doisFatores.Status(u, user.Empresa))
+}
+
+// CadastrarSegundoFator gera o segredo e o QR Code para o aplicativo autenticador
+func (h *Handlers) CadastrarSegundoFator(w http.ResponseWriter, r *http.Request) {
+	_, u, ok := h.usuarioAtual(w, r)
+	if !ok {
+		return
+	}
+
+	prov, err := h.doisFatores.Cadastrar(u)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(prov)
+}
+
+// AtivarSegundoFator confirma o cadastro com o primeiro código e retorna os códigos de recuperação
+func (h *Handlers) AtivarSegundoFator(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var req CodigoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	codigos, err := h.doisFatores.Ativar(user.ID, req.Codigo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"codigos_recuperacao": codigos})
+}
+
+// DesativarSegundoFator remove o segundo fator mediante um código válido
+func (h *Handlers) DesativarSegundoFator(w http.ResponseWriter, r *http.Request) {
+	user, u, ok := h.usuarioAtual(w, r)
+	if !ok {
+		return
+	}
+
+	var req CodigoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.doisFatores.Desativar(u, user.Empresa, req.Codigo); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RenovarCodigosRecuperacao substitui os códigos de recuperação mediante um código válido
+func (h *Handlers) RenovarCodigosRecuperacao(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var req CodigoRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	codigos, err := h.doisFatores.RenovarCodigosRecuperacao(user.ID, req.Codigo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string][]string{"codigos_recuperacao": codigos})
+}
+
+// GetPolitica retorna a política de segundo fator da empresa
+func (h *Handlers) GetPolitica(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.doisFatores.Politica(user.Empresa))
+}
+
+// SalvarPolitica altera a política de segundo fator da empresa. Administradores sem o segundo
+// fator passam a cadastrá-lo no próximo login.
+func (h *Handlers) SalvarPolitica(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var p doisfatores.Politica
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+	p.EmpresaID = user.Empresa
+	p.UsuarioID = user.ID
+
+	politica, err := h.doisFatores.SalvarPolitica(&p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logger.InfoLogger.Printf("Política de segundo fator da empresa %d alterada por %s: exigir admins = %t", user.Empresa, user.Email, politica.ExigirAdmins)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(politica)
+}
+
+// usuarioAtual carrega o cadastro do usuário autenticado
+func (h *Handlers) usuarioAtual(w http.ResponseWriter, r *http.Request) (User, *usuario.Usuario, bool) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return user, nil, false
+	}
+
+	u, err := h.userRepo.GetByID(user.ID)
+	if err != nil {
+		http.Error(w, "Usuário não encontrado", http.StatusUnauthorized)
+		return user, nil, false
+	}
+	return user, u, true
+}
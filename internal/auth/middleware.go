@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	
 	"github.com/Pantaleaogc/gvero/pkg/logger"
@@ -18,6 +19,14 @@ type contextKey string
 
 const UserContextKey = contextKey("user")
 
+// User é o usuário autenticado da requisição, extraído do token JWT ou da chave de API
+type User struct {
+	ID      int
+	Email   string
+	Role    string
+	Empresa int
+}
+
 // Middleware verifica o token JWT e injeta o usuário no contexto
 func Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -29,10 +38,19 @@ func Middleware(next http.Handler) http.Handler {
 			return
 		}
 
-		// Verificar formato do token
+		// Verificar formato do token; integrações usam "ApiKey <chave>". O esquema não diferencia
+		// maiúsculas, e só ele vai para o log: o restante do cabeçalho é a credencial.
 		parts := strings.SplitN(authHeader, " ", 2)
-		if len(parts) != 2 || parts[0] != "Bearer" {
-			logger.DebugLogger.Printf("Formato de token inválido: %s", authHeader)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "ApiKey") {
+			autenticarChave(w, r, next, parts[1])
+			return
+		}
+		if len(parts) != 2 || !strings.EqualFold(parts[0], "Bearer") {
+			esquema := "ausente"
+			if len(parts) == 2 {
+				esquema = esquemaDe(parts[0])
+			}
+			logger.DebugLogger.Printf("Formato de autorização inválido em %s: esquema %s", r.URL.Path, esquema)
 			http.Error(w, "Formato de autorização inválido", http.StatusUnauthorized)
 			return
 		}
@@ -103,6 +121,20 @@ func validateJWT(tokenStr string) (User, error) {
 	return User{}, errors.New("token inválido")
 }
 
+// esquemaDe retorna o esquema do cabeçalho Authorization para o log. Esquemas são palavras curtas;
+// qualquer outra coisa pode ser uma credencial colada no lugar e não é registrada.
+func esquemaDe(s string) string {
+	if len(s) == 0 || len(s) > 20 {
+		return "desconhecido"
+	}
+	for _, c := range s {
+		if (c < 'A' || c > 'Z') && (c < 'a' || c > 'z') {
+			return "desconhecido"
+		}
+	}
+	return strconv.Quote(s)
+}
+
 // FromContext extrai o usuário do contexto
 func FromContext(ctx context.Context) (User, bool) {
 	user, ok := ctx.Value(UserContextKey).(User)
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/seguranca"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/limite"
+	"github.com/go-chi/chi/v5"
+)
+
+// EstadoContaResponse é a situação das falhas de login de um usuário
+type EstadoContaResponse struct {
+	UsuarioID int `json:"usuario_id"`
+	*seguranca.Estado
+	Bloqueada bool `json:"bloqueada"`
+}
+
+// SegurancaRoutes retorna as rotas de administração da proteção do login: log de segurança e
+// desbloqueio de contas e IPs, restritas a administradores
+func SegurancaRoutes(userRepo usuario.Repository, protecao *seguranca.Service) http.Handler {
+	h := NewHandlers(userRepo, nil, protecao, nil)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Use(RequireRole("admin"))
+
+	r.Get("/eventos", h.ListEventos)
+	r.Get("/contas/{id}", h.EstadoConta)
+	r.Post("/contas/{id}/desbloquear", h.DesbloquearConta)
+	r.Post("/ips/{ip}/desbloquear", h.DesbloquearIP)
+
+	return r
+}
+
+// ListEventos lista o log de segurança da empresa. Filtros: tipo, usuario_id e ip.
+func (h *Handlers) ListEventos(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	usuarioID, _ := strconv.Atoi(q.Get("usuario_id"))
+
+	if limit <= 0 {
+		limit = 50 // valor padrão
+	}
+
+	eventos, err := h.protecao.Eventos(seguranca.FiltroEventos{
+		EmpresaID: user.Empresa,
+		UsuarioID: usuarioID,
+		Tipo:      q.Get("tipo"),
+		IP:        q.Get("ip"),
+	}, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(eventos)
+}
+
+// EstadoConta retorna as falhas recentes e o bloqueio de um usuário da empresa
+func (h *Handlers) EstadoConta(w http.ResponseWriter, r *http.Request) {
+	_, u, ok := h.usuarioDaEmpresa(w, r)
+	if !ok {
+		return
+	}
+
+	estado, err := h.protecao.EstadoConta(u.Email)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(EstadoContaResponse{
+		UsuarioID: u.ID,
+		Estado:    estado,
+		Bloqueada: estado.BloqueadoAte != nil && time.Now().Before(*estado.BloqueadoAte),
+	})
+}
+
+// DesbloquearConta zera as falhas e o bloqueio de um usuário da empresa
+func (h *Handlers) DesbloquearConta(w http.ResponseWriter, r *http.Request) {
+	user, u, ok := h.usuarioDaEmpresa(w, r)
+	if !ok {
+		return
+	}
+
+	t := seguranca.Tentativa{Email: u.Email, IP: limite.IP(r), UsuarioID: u.ID, EmpresaID: u.EmpresaID}
+	if err := h.protecao.DesbloquearConta(t, user.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DesbloquearIP zera as falhas e o bloqueio de um IP. O IP é compartilhado entre as empresas,
+// por isso só o administrador do sistema pode desbloqueá-lo.
+func (h *Handlers) DesbloquearIP(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+	if !h.adminGlobal(user) {
+		http.Error(w, "Apenas o administrador do sistema pode desbloquear IPs", http.StatusForbidden)
+		return
+	}
+
+	if err := h.protecao.DesbloquearIP(chi.URLParam(r, "ip"), user.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminGlobal indica se o usuário da requisição é o administrador do sistema
+func (h *Handlers) adminGlobal(user User) bool {
+	return AdminGlobal(h.userRepo, user)
+}
+
+// AdminGlobal indica se o usuário é o administrador do sistema: Tipo "admin" sem empresa vinculada.
+// Os administradores das empresas têm o mesmo Tipo, mas estão vinculados a uma empresa. A decisão
+// vem do registro gravado, pois o token sem empresa é lido como da empresa 1.
+func AdminGlobal(userRepo usuario.Repository, user User) bool {
+	if user.Role != "admin" || user.ID == 0 {
+		return false
+	}
+	u, err := userRepo.GetByID(user.ID)
+	return err == nil && u.Status && u.Tipo == "admin" && u.EmpresaID == 0
+}
+
+// usuarioDaEmpresa carrega o usuário da URL garantindo que pertence à empresa do administrador. O
+// administrador do sistema, sem empresa, só é visto e desbloqueado por um administrador do sistema
+func (h *Handlers) usuarioDaEmpresa(w http.ResponseWriter, r *http.Request) (User, *usuario.Usuario, bool) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return user, nil, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return user, nil, false
+	}
+
+	u, err := h.userRepo.GetByID(id)
+	if err != nil || (u.EmpresaID == 0 && !AdminGlobal(h.userRepo, user)) || (u.EmpresaID != 0 && u.EmpresaID != user.Empresa) {
+		http.Error(w, "Usuário não encontrado", http.StatusNotFound)
+		return user, nil, false
+	}
+	return user, u, true
+}
+
+// permitirTentativa consulta a proteção do login, respondendo 429 durante o atraso ou o bloqueio.
+// A tentativa permitida já conta como falha até estornarTentativa.
+func (h *Handlers) permitirTentativa(w http.ResponseWriter, t seguranca.Tentativa) bool {
+	if h.protecao == nil {
+		return true
+	}
+
+	var espera *seguranca.ErroEspera
+	if err := h.protecao.Verificar(t); errors.As(err, &espera) {
+		segundos := int(math.Ceil(time.Until(espera.Ate).Seconds()))
+		if segundos < 1 {
+			segundos = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(segundos))
+		http.Error(w, espera.Error(), http.StatusTooManyRequests)
+		return false
+	}
+	return true
+}
+
+// registrarFalha conta a falha de login, quando a proteção está configurada
+func (h *Handlers) registrarFalha(t seguranca.Tentativa, motivo string) {
+	if h.protecao != nil {
+		h.protecao.RegistrarFalha(t, motivo)
+	}
+}
+
+// estornarTentativa desconta a tentativa cuja credencial conferiu, quando a proteção está configurada
+func (h *Handlers) estornarTentativa(t seguranca.Tentativa) {
+	if h.protecao != nil {
+		h.protecao.Estornar(t)
+	}
+}
+
+// registrarSucesso zera as falhas da conta, quando a proteção está configurada
+func (h *Handlers) registrarSucesso(t seguranca.Tentativa) {
+	if h.protecao != nil {
+		h.protecao.RegistrarSucesso(t)
+	}
+}
+
+// tentativaDe identifica a tentativa de login do usuário já conhecido. Os eventos do administrador do
+// sistema ficam sem empresa, fora dos registros de segurança das empresas
+func (h *Handlers) tentativaDe(r *http.Request, user *usuario.Usuario) seguranca.Tentativa {
+	return seguranca.Tentativa{
+		Email:     user.Email,
+		IP:        limite.IP(r),
+		UsuarioID: user.ID,
+		EmpresaID: user.EmpresaID,
+	}
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/seguranca"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+)
+
+// O administrador do sistema não tem empresa: o administrador da empresa padrão não o consulta nem
+// o desbloqueia, e só outro administrador do sistema o faz
+func TestSegurancaAdministradorDoSistema(t *testing.T) {
+	repo := usuario.NewMemoryRepository()
+	h := SegurancaRoutes(repo, seguranca.NewService(seguranca.NewMemoryContadores(), seguranca.NewMemoryRepository()))
+
+	sistema := &usuario.Usuario{Nome: "Root", Email: "root@gvero.test", Tipo: "admin"}
+	tokenSistema := criarUsuario(t, repo, sistema)
+	tokenAcme := criarUsuario(t, repo, &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "admin", EmpresaID: 1})
+	colega := &usuario.Usuario{Nome: "Caio", Email: "caio@acme.com", Tipo: "usuario", EmpresaID: 1}
+	criarUsuario(t, repo, colega)
+	outro := &usuario.Usuario{Nome: "Bia", Email: "bia@beta.com", Tipo: "usuario", EmpresaID: 2}
+	criarUsuario(t, repo, outro)
+
+	tests := []struct {
+		nome     string
+		token    string
+		metodo   string
+		caminho  string
+		esperado int
+	}{
+		{"empresa consulta o sistema", tokenAcme, http.MethodGet, "/contas/" + strconv.Itoa(sistema.ID), http.StatusNotFound},
+		{"empresa desbloqueia o sistema", tokenAcme, http.MethodPost, "/contas/" + strconv.Itoa(sistema.ID) + "/desbloquear", http.StatusNotFound},
+		{"empresa consulta o colega", tokenAcme, http.MethodGet, "/contas/" + strconv.Itoa(colega.ID), http.StatusOK},
+		{"empresa consulta outra empresa", tokenAcme, http.MethodGet, "/contas/" + strconv.Itoa(outro.ID), http.StatusNotFound},
+		{"sistema consulta a si mesmo", tokenSistema, http.MethodGet, "/contas/" + strconv.Itoa(sistema.ID), http.StatusOK},
+		{"sistema se desbloqueia", tokenSistema, http.MethodPost, "/contas/" + strconv.Itoa(sistema.ID) + "/desbloquear", http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		if status, corpo := requisitar(t, h, tt.token, tt.metodo, tt.caminho, ""); status != tt.esperado {
+			t.Errorf("%s = %d %s, esperado %d", tt.nome, status, corpo, tt.esperado)
+		}
+	}
+}
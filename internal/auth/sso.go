@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/sso"
+	"github.com/Pantaleaogc/gvero/pkg/limite"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// Limite de logins SSO iniciados por IP; cada início grava um fluxo pendente
+const (
+	limiteInicioSSO = 30
+	janelaInicioSSO = 15 * time.Minute
+)
+
+// TrocaSSORequest contém o código do login SSO entregue ao frontend
+type TrocaSSORequest struct {
+	Codigo string `json:"codigo"`
+}
+
+// ConfiguracaoSSOResponse é a configuração do SSO com o redirect_uri a cadastrar no provedor
+type ConfiguracaoSSOResponse struct {
+	*sso.Configuracao
+	URLCallback string `json:"url_callback"`
+}
+
+// rotasSSO registra o login pelo provedor de identidade da empresa e a sua configuração
+func (h *Handlers) rotasSSO(r chi.Router) {
+	r.Route("/sso", func(r chi.Router) {
+		r.Group(func(r chi.Router) {
+			r.Use(Middleware)
+			r.Use(RequireRole("admin"))
+			r.Get("/configuracao", h.GetConfiguracaoSSO)
+			r.Put("/configuracao", h.SalvarConfiguracaoSSO)
+			r.Delete("/configuracao", h.RemoverConfiguracaoSSO)
+		})
+
+		// Públicas: o navegador é enviado ao provedor e volta com o código, e o frontend troca o
+		// código do login concluído pelo token
+		r.Get("/{empresa}", h.IniciarSSO)
+		r.Get("/{empresa}/callback", h.CallbackSSO)
+		r.Post("/trocar", h.TrocarSSO)
+	})
+}
+
+// IniciarSSO redireciona o navegador ao provedor da empresa. Parâmetro opcional: email, repassado
+// ao provedor como sugestão de conta.
+func (h *Handlers) IniciarSSO(w http.ResponseWriter, r *http.Request) {
+	empresaID, err := strconv.Atoi(chi.URLParam(r, "empresa"))
+	if err != nil {
+		http.Error(w, "Empresa inválida", http.StatusBadRequest)
+		return
+	}
+	if !h.inicioSSO.Permitir(limite.IP(r)) {
+		w.Header().Set("Retry-After", strconv.Itoa(int(janelaInicioSSO.Seconds())))
+		http.Error(w, "Muitas tentativas. Tente novamente mais tarde.", http.StatusTooManyRequests)
+		return
+	}
+
+	destino, cookie, err := h.sso.Iniciar(r.Context(), empresaID, r.URL.Query().Get("email"))
+	if err != nil {
+		responderErroSSO(w, err)
+		return
+	}
+	http.SetCookie(w, cookie)
+	http.Redirect(w, r, destino, http.StatusFound)
+}
+
+// CallbackSSO recebe o retorno do provedor e envia o navegador ao frontend com o código de troca;
+// o token não passa pela URL nem fica no histórico do navegador
+func (h *Handlers) CallbackSSO(w http.ResponseWriter, r *http.Request) {
+	empresaID, err := strconv.Atoi(chi.URLParam(r, "empresa"))
+	if err != nil {
+		http.Error(w, "Empresa inválida", http.StatusBadRequest)
+		return
+	}
+
+	// O cookie do fluxo vale uma única volta do provedor
+	var cookie string
+	if c, err := r.Cookie(sso.NomeCookieFluxo); err == nil {
+		cookie = c.Value
+	}
+	http.SetCookie(w, h.sso.CookieExpirado(empresaID))
+
+	q := r.URL.Query()
+	if erro := q.Get("error"); erro != "" {
+		logger.InfoLogger.Printf("Login SSO da empresa %d cancelado no provedor: %s %s", empresaID, erro, q.Get("error_description"))
+		http.Error(w, "Login cancelado no provedor de identidade", http.StatusUnauthorized)
+		return
+	}
+
+	destino, err := h.sso.Concluir(r.Context(), empresaID, q.Get("state"), q.Get("code"), cookie)
+	if err != nil {
+		responderErroSSO(w, err)
+		return
+	}
+	http.Redirect(w, r, destino, http.StatusFound)
+}
+
+// TrocarSSO conclui o login SSO com o token JWT, como no login por senha. Com o segundo fator
+// ativo ou exigido pela empresa, o token só sai depois do código, salvo se a configuração do SSO
+// confia no segundo fator do provedor.
+func (h *Handlers) TrocarSSO(w http.ResponseWriter, r *http.Request) {
+	var req TrocaSSORequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	user, dispensado, err := h.sso.Trocar(req.Codigo)
+	if err != nil {
+		if errors.Is(err, sso.ErrTrocaInvalida) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		responderErroSSO(w, err)
+		return
+	}
+
+	if h.doisFatores != nil && !dispensado {
+		ativo := h.doisFatores.Ativo(user.ID)
+		if ativo || h.doisFatores.Exigido(user, empresaDoUsuario(user)) {
+			h.responderDesafio(w, user, !ativo)
+			return
+		}
+	}
+	h.responderLogin(w, r, user, nil)
+}
+
+// GetConfiguracaoSSO retorna a configuração do SSO da empresa; sem configuração, o SSO está inativo
+func (h *Handlers) GetConfiguracaoSSO(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	c, err := h.sso.Configuracao(user.Empresa)
+	if err != nil {
+		c = &sso.Configuracao{EmpresaID: user.Empresa}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ConfiguracaoSSOResponse{Configuracao: c, URLCallback: h.sso.URLCallback(user.Empresa)})
+}
+
+// SalvarConfiguracaoSSO grava o provedor de identidade da empresa
+func (h *Handlers) SalvarConfiguracaoSSO(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var c sso.Configuracao
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+	c.EmpresaID = user.Empresa
+
+	salva, err := h.sso.SalvarConfiguracao(r.Context(), &c, user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ConfiguracaoSSOResponse{Configuracao: salva, URLCallback: h.sso.URLCallback(user.Empresa)})
+}
+
+// RemoverConfiguracaoSSO desativa o login SSO da empresa
+func (h *Handlers) RemoverConfiguracaoSSO(w http.ResponseWriter, r *http.Request) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.sso.RemoverConfiguracao(user.Empresa); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	logger.InfoLogger.Printf("Login SSO da empresa %d removido por %s", user.Empresa, user.Email)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// responderErroSSO escolhe o status conforme a falha do fluxo
+func responderErroSSO(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, sso.ErrNaoConfigurado):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, sso.ErrFluxoInvalido):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusForbidden)
+	}
+}
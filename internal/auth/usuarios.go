@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/go-chi/chi/v5"
+)
+
+// UsuarioRequest são os campos de um usuário que o administrador pode informar. Papel, empresa e
+// senha não fazem parte: o usuário entra como "usuario" na empresa do administrador e define a
+// senha pela recuperação de senha
+type UsuarioRequest struct {
+	Nome   string `json:"nome"`
+	Email  string `json:"email"`
+	Status *bool  `json:"status"`
+}
+
+// UsuariosRoutes retorna as rotas de administração dos usuários, restritas aos administradores e
+// aos usuários da empresa de cada um
+func UsuariosRoutes(userRepo usuario.Repository) http.Handler {
+	h := NewHandlers(userRepo, nil, nil, nil)
+
+	r := chi.NewRouter()
+	r.Use(Middleware)
+	r.Use(RequireRole("admin"))
+
+	r.Get("/", h.ListUsuarios)
+	r.Post("/", h.CriarUsuario)
+	r.Get("/{id}", h.GetUsuario)
+	r.Put("/{id}", h.AtualizarUsuario)
+	r.Delete("/{id}", h.RemoverUsuario)
+
+	return r
+}
+
+// ListUsuarios lista os usuários da empresa do administrador
+func (h *Handlers) ListUsuarios(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.administrador(w, r)
+	if !ok {
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	usuarios, err := h.userRepo.ListByEmpresa(admin.EmpresaID, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(usuarios)
+}
+
+// GetUsuario retorna um usuário da empresa do administrador
+func (h *Handlers) GetUsuario(w http.ResponseWriter, r *http.Request) {
+	_, u, ok := h.usuarioDoAdministrador(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+// CriarUsuario cria um usuário comum na empresa do administrador, sem senha
+func (h *Handlers) CriarUsuario(w http.ResponseWriter, r *http.Request) {
+	admin, ok := h.administrador(w, r)
+	if !ok {
+		return
+	}
+
+	var req UsuarioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u := &usuario.Usuario{
+		Nome:      strings.TrimSpace(req.Nome),
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		Status:    req.Status == nil || *req.Status,
+		Tipo:      "usuario",
+		EmpresaID: admin.EmpresaID,
+	}
+	if err := h.userRepo.Create(u); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(u)
+}
+
+// AtualizarUsuario altera o nome e o status de um usuário da empresa do administrador. O e-mail
+// não muda por aqui, pois levaria a recuperação de senha da conta para outra caixa
+func (h *Handlers) AtualizarUsuario(w http.ResponseWriter, r *http.Request) {
+	admin, atual, ok := h.usuarioDoAdministrador(w, r)
+	if !ok {
+		return
+	}
+
+	var req UsuarioRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if req.Email != "" && !strings.EqualFold(strings.TrimSpace(req.Email), atual.Email) {
+		http.Error(w, "O e-mail do usuário não pode ser alterado", http.StatusBadRequest)
+		return
+	}
+	if req.Status != nil && !*req.Status && atual.ID == admin.ID {
+		http.Error(w, "O administrador não pode desativar a própria conta", http.StatusBadRequest)
+		return
+	}
+
+	// Parte de uma cópia do registro gravado: os demais campos continuam como estão
+	u := *atual
+	if nome := strings.TrimSpace(req.Nome); nome != "" {
+		u.Nome = nome
+	}
+	if req.Status != nil {
+		u.Status = *req.Status
+	}
+	u.Senha = ""
+	if err := h.userRepo.Update(&u); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+// RemoverUsuario remove um usuário da empresa do administrador
+func (h *Handlers) RemoverUsuario(w http.ResponseWriter, r *http.Request) {
+	admin, u, ok := h.usuarioDoAdministrador(w, r)
+	if !ok {
+		return
+	}
+	if u.ID == admin.ID {
+		http.Error(w, "O administrador não pode remover a própria conta", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.userRepo.Delete(u.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// administrador carrega o registro gravado do administrador da requisição. A empresa vem do
+// registro, não do token, que assume a empresa 1 quando não traz a empresa
+func (h *Handlers) administrador(w http.ResponseWriter, r *http.Request) (*usuario.Usuario, bool) {
+	user, ok := FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	admin, err := h.userRepo.GetByID(user.ID)
+	if err != nil || !admin.Status || admin.Tipo != "admin" {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return nil, false
+	}
+	return admin, true
+}
+
+// usuarioDoAdministrador carrega o usuário da URL garantindo que pertence à empresa do administrador
+func (h *Handlers) usuarioDoAdministrador(w http.ResponseWriter, r *http.Request) (*usuario.Usuario, *usuario.Usuario, bool) {
+	admin, ok := h.administrador(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, nil, false
+	}
+
+	u, err := h.userRepo.GetByID(id)
+	if err != nil || u.EmpresaID != admin.EmpresaID {
+		http.Error(w, "Usuário não encontrado", http.StatusNotFound)
+		return nil, nil, false
+	}
+	return admin, u, true
+}
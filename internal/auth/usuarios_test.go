@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// criarUsuario grava o usuário no repositório e retorna o token dele
+func criarUsuario(t *testing.T, repo usuario.Repository, u *usuario.Usuario) string {
+	t.Helper()
+	u.Status = true
+	if err := repo.Create(u); err != nil {
+		t.Fatal(err)
+	}
+	token, err := generateJWT(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+// requisitar envia a requisição às rotas com o token e retorna o status e o corpo
+func requisitar(t *testing.T, h http.Handler, token, metodo, caminho, corpo string) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(metodo, caminho, strings.NewReader(corpo))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec.Code, rec.Body.String()
+}
+
+func TestUsuariosEscopoDaEmpresa(t *testing.T) {
+	repo := usuario.NewMemoryRepository()
+	h := UsuariosRoutes(repo)
+
+	tokenAcme := criarUsuario(t, repo, &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "admin", EmpresaID: 1})
+	colega := &usuario.Usuario{Nome: "Caio", Email: "caio@acme.com", Tipo: "usuario", EmpresaID: 1}
+	tokenColega := criarUsuario(t, repo, colega)
+	outro := &usuario.Usuario{Nome: "Bia", Email: "bia@beta.com", Tipo: "usuario", EmpresaID: 2}
+	criarUsuario(t, repo, outro)
+	// O administrador do sistema não tem empresa, e o token dele não leva a empresa
+	tokenSistema := criarUsuario(t, repo, &usuario.Usuario{Nome: "Root", Email: "root@gvero.test", Tipo: "admin"})
+
+	if status, _ := requisitar(t, h, "", http.MethodGet, "/", ""); status != http.StatusUnauthorized {
+		t.Errorf("lista sem token = %d", status)
+	}
+	if status, _ := requisitar(t, h, tokenColega, http.MethodGet, "/", ""); status != http.StatusForbidden {
+		t.Errorf("lista por usuário comum = %d", status)
+	}
+
+	status, corpo := requisitar(t, h, tokenAcme, http.MethodGet, "/", "")
+	var lista []usuario.Usuario
+	if err := json.Unmarshal([]byte(corpo), &lista); status != http.StatusOK || err != nil {
+		t.Fatalf("lista = %d %s", status, corpo)
+	}
+	if len(lista) != 2 {
+		t.Errorf("lista da empresa 1 com %d usuários", len(lista))
+	}
+	for _, u := range lista {
+		if u.EmpresaID != 1 {
+			t.Errorf("lista da empresa 1 trouxe %s", u.Email)
+		}
+	}
+
+	status, corpo = requisitar(t, h, tokenSistema, http.MethodGet, "/", "")
+	if status != http.StatusOK || strings.Contains(corpo, "acme.com") {
+		t.Errorf("lista do administrador do sistema = %d %s", status, corpo)
+	}
+
+	caminho := "/" + strconv.Itoa(outro.ID)
+	for _, c := range []struct{ metodo, corpo string }{
+		{http.MethodGet, ""},
+		{http.MethodPut, `{"nome":"Invadido"}`},
+		{http.MethodDelete, ""},
+	} {
+		if status, _ := requisitar(t, h, tokenAcme, c.metodo, caminho, c.corpo); status != http.StatusNotFound {
+			t.Errorf("%s no usuário de outra empresa = %d", c.metodo, status)
+		}
+	}
+	if u, err := repo.GetByID(outro.ID); err != nil || u.Nome != "Bia" {
+		t.Errorf("usuário de outra empresa alterado: %+v, %v", u, err)
+	}
+}
+
+func TestUsuariosCamposPermitidos(t *testing.T) {
+	repo := usuario.NewMemoryRepository()
+	h := UsuariosRoutes(repo)
+
+	token := criarUsuario(t, repo, &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "admin", EmpresaID: 1})
+
+	status, corpo := requisitar(t, h, token, http.MethodPost, "/",
+		`{"nome":"Caio","email":"caio@acme.com","tipo":"admin","empresa_id":2,"senha":"Segredo123!"}`)
+	if status != http.StatusCreated {
+		t.Fatalf("criar = %d %s", status, corpo)
+	}
+	var criado usuario.Usuario
+	json.Unmarshal([]byte(corpo), &criado)
+	u, _ := repo.GetByID(criado.ID)
+	if u.Tipo != "usuario" || u.EmpresaID != 1 || u.Senha != "" {
+		t.Errorf("usuário criado como %s na empresa %d, senha %q", u.Tipo, u.EmpresaID, u.Senha)
+	}
+
+	hash, _ := usuario.HashSenha("Segredo123!")
+	u.Senha = hash
+	caminho := "/" + strconv.Itoa(u.ID)
+
+	status, _ = requisitar(t, h, token, http.MethodPut, caminho,
+		`{"nome":"Caio Souza","status":false,"tipo":"admin","empresa_id":2,"senha":"outra"}`)
+	if status != http.StatusOK {
+		t.Fatalf("atualizar = %d", status)
+	}
+	u, _ = repo.GetByID(u.ID)
+	if u.Nome != "Caio Souza" || u.Status || u.Tipo != "usuario" || u.EmpresaID != 1 || u.Senha != hash {
+		t.Errorf("atualização fora dos campos permitidos: %+v", u)
+	}
+
+	if status, _ := requisitar(t, h, token, http.MethodPut, caminho, `{"email":"outro@fora.com"}`); status != http.StatusBadRequest {
+		t.Errorf("troca de e-mail = %d", status)
+	}
+	if u, _ = repo.GetByID(u.ID); u.Email != "caio@acme.com" {
+		t.Errorf("e-mail alterado para %s", u.Email)
+	}
+}
@@ -0,0 +1,291 @@
+package boleto
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// tamanhoMaximoRetorno limita o upload de arquivos de retorno
+const tamanhoMaximoRetorno = 10 << 20
+
+// Handlers contém os manipuladores HTTP para boletos
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas para boletos
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/beneficiario", h.GetBeneficiario)
+	r.With(auth.RequireRole("admin")).Put("/beneficiario", h.SaveBeneficiario)
+
+	r.Get("/", h.List)
+	r.Post("/", h.Create)
+	r.Get("/{id}", h.GetByID)
+	r.Get("/{id}/pdf", h.PDF)
+	r.Post("/{id}/cancelar", h.Cancelar)
+
+	r.Post("/remessas", h.GerarRemessa)
+	r.Post("/retornos", h.ProcessarRetorno)
+
+	return r
+}
+
+// GetBeneficiario retorna a configuração de cobrança da empresa do usuário atual
+func (h *Handlers) GetBeneficiario(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	ben, err := h.repo.GetBeneficiario(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ben)
+}
+
+// SaveBeneficiario grava a configuração de cobrança da empresa do usuário atual
+func (h *Handlers) SaveBeneficiario(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var ben Beneficiario
+	if err := json.NewDecoder(r.Body).Decode(&ben); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ben.EmpresaID = user.Empresa
+	if err := h.repo.SaveBeneficiario(&ben); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ben)
+}
+
+// List lista os boletos da empresa. Filtros: cliente_id e status.
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	clienteID, _ := strconv.Atoi(r.URL.Query().Get("cliente_id"))
+
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+
+	boletos, err := h.repo.List(user.Empresa, clienteID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(boletos)
+}
+
+// GetByID retorna um boleto por ID
+func (h *Handlers) GetByID(w http.ResponseWriter, r *http.Request) {
+	b, ok := h.boleto(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b)
+}
+
+// Create emite um novo boleto
+func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in NovoBoleto
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	b, err := h.service.Emitir(user.Empresa, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(b)
+}
+
+// PDF renderiza o boleto para impressão
+func (h *Handlers) PDF(w http.ResponseWriter, r *http.Request) {
+	b, ok := h.boleto(w, r)
+	if !ok {
+		return
+	}
+
+	ben, err := h.repo.GetBeneficiario(b.EmpresaID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	pdf, err := PDF(b, ben)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"boleto-%s.pdf\"", b.NossoNumero))
+	w.Write(pdf)
+}
+
+// Cancelar cancela um boleto ainda não pago
+func (h *Handlers) Cancelar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	b, err := h.service.Cancelar(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(b)
+}
+
+// GerarRemessa gera o arquivo de remessa com os boletos pendentes. Parâmetro: formato (240 ou 400).
+func (h *Handlers) GerarRemessa(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	conteudo, nome, err := h.service.GerarRemessa(user.Empresa, formato(r))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=us-ascii")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", nome))
+	w.Write(conteudo)
+}
+
+// ProcessarRetorno concilia um arquivo de retorno do banco. Parâmetro: formato (240 ou 400).
+// O arquivo pode ser enviado no corpo da requisição ou no campo "arquivo" de um formulário multipart.
+func (h *Handlers) ProcessarRetorno(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var origem io.Reader = http.MaxBytesReader(w, r.Body, tamanhoMaximoRetorno)
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		arquivo, _, err := r.FormFile("arquivo")
+		if err != nil {
+			http.Error(w, "Arquivo de retorno não enviado", http.StatusBadRequest)
+			return
+		}
+		defer arquivo.Close()
+		origem = io.LimitReader(arquivo, tamanhoMaximoRetorno)
+	}
+
+	conteudo, err := io.ReadAll(origem)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.service.ProcessarRetorno(user.Empresa, formato(r), conteudo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// boleto carrega o boleto da URL, respondendo com erro quando não encontrado
+func (h *Handlers) boleto(w http.ResponseWriter, r *http.Request) (*Boleto, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	b, err := h.repo.GetByID(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+
+	return b, true
+}
+
+// formato lê o formato CNAB da query string, usando CNAB 240 como padrão
+func formato(r *http.Request) string {
+	if f := r.URL.Query().Get("formato"); f != "" {
+		return f
+	}
+	return CNAB240
+}
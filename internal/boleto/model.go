@@ -0,0 +1,98 @@
+package boleto
+
+import (
+	"time"
+)
+
+// Status de boleto
+const (
+	StatusEmitido    = "emitido"    // gerado, ainda não enviado em remessa
+	StatusRegistrado = "registrado" // enviado ao banco em arquivo de remessa
+	StatusPago       = "pago"
+	StatusCancelado  = "cancelado"
+)
+
+// Formatos de arquivo CNAB
+const (
+	CNAB240 = "240"
+	CNAB400 = "400"
+)
+
+// Beneficiario representa a configuração de cobrança bancária de uma empresa
+type Beneficiario struct {
+	EmpresaID      int     `json:"empresa_id"`
+	Banco          string  `json:"banco"` // código COMPE: 001 (Banco do Brasil) ou 341 (Itaú)
+	Agencia        string  `json:"agencia"`
+	DigitoAgencia  string  `json:"digito_agencia,omitempty"`
+	Conta          string  `json:"conta"`
+	DigitoConta    string  `json:"digito_conta,omitempty"`
+	Carteira       string  `json:"carteira"`
+	Convenio       string  `json:"convenio,omitempty"`
+	Nome           string  `json:"nome"`
+	CNPJ           string  `json:"cnpj"`
+	Endereco       string  `json:"endereco,omitempty"`
+	LocalPagamento string  `json:"local_pagamento,omitempty"`
+	Instrucoes     string  `json:"instrucoes,omitempty"`
+	JurosMensal    float64 `json:"juros_mensal"` // % ao mês para boletos avulsos
+	Multa          float64 `json:"multa"`        // % para boletos avulsos
+}
+
+// Pagador representa o sacado impresso no boleto
+type Pagador struct {
+	Nome      string `json:"nome"`
+	Documento string `json:"documento"`
+	Endereco  string `json:"endereco,omitempty"`
+}
+
+// Boleto representa um boleto emitido pela empresa
+type Boleto struct {
+	ID                  int        `json:"id"`
+	EmpresaID           int        `json:"empresa_id"`
+	Banco               string     `json:"banco"`
+	ClienteID           int        `json:"cliente_id"`
+	TituloID            int        `json:"titulo_id,omitempty"`
+	ParcelaID           int        `json:"parcela_id,omitempty"`
+	NumeroDocumento     string     `json:"numero_documento"`
+	NossoNumero         string     `json:"nosso_numero"`
+	NossoNumeroExibicao string     `json:"nosso_numero_exibicao"`
+	Valor               float64    `json:"valor"`
+	JurosDia            float64    `json:"juros_dia"`
+	Multa               float64    `json:"multa"`
+	Vencimento          time.Time  `json:"vencimento"`
+	DataEmissao         time.Time  `json:"data_emissao"`
+	CodigoBarras        string     `json:"codigo_barras"`
+	LinhaDigitavel      string     `json:"linha_digitavel"`
+	Instrucoes          string     `json:"instrucoes,omitempty"`
+	Pagador             Pagador    `json:"pagador"`
+	Status              string     `json:"status"`
+	Remessa             int        `json:"remessa,omitempty"`
+	DataPagamento       *time.Time `json:"data_pagamento,omitempty"`
+	ValorPago           float64    `json:"valor_pago,omitempty"`
+	DataCriacao         time.Time  `json:"data_criacao"`
+}
+
+// ResultadoRetorno resume o processamento de um arquivo de retorno
+type ResultadoRetorno struct {
+	Ocorrencias    int      `json:"ocorrencias"`
+	Liquidados     []int    `json:"liquidados"`
+	Ignorados      int      `json:"ignorados"`
+	NaoEncontrados []string `json:"nao_encontrados"`
+	Erros          []string `json:"erros,omitempty"`
+}
+
+// Repository define a interface para acesso aos dados de boletos
+type Repository interface {
+	SaveBeneficiario(b *Beneficiario) error
+	GetBeneficiario(empresaID int) (*Beneficiario, error)
+	ProximoNossoNumero(empresaID int) (int64, error)
+	ProximaRemessa(empresaID int) (int, error)
+
+	Create(b *Boleto) error
+	GetByID(id int, empresaID int) (*Boleto, error)
+	GetByNossoNumero(empresaID int, nossoNumero string) (*Boleto, error)
+	Update(b *Boleto) error
+	List(empresaID int, clienteID int, status string, limit, offset int) ([]*Boleto, error)
+
+	// TransferirCliente reaponta para paraID os registros de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
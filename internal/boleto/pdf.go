@@ -0,0 +1,248 @@
+package boleto
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/Pantaleaogc/gvero/pkg/febraban"
+	"github.com/go-pdf/fpdf"
+)
+
+// Dimensões do boleto em milímetros
+const (
+	margem          = 10.0
+	larguraUtil     = 190.0
+	alturaCampo     = 8.0
+	larguraDireita  = 45.0
+	larguraBarras   = 103.0
+	alturaBarras    = 13.0
+	alturaCabecalho = 9.0
+)
+
+// PDF renderiza o boleto com o recibo do pagador e a ficha de compensação
+func PDF(b *Boleto, ben *Beneficiario) ([]byte, error) {
+	banco, err := febraban.BancoPorCodigo(b.Banco)
+	if err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margem, margem, margem)
+	pdf.SetAutoPageBreak(false, margem)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("cp1252")
+
+	r := &renderizador{pdf: pdf, tr: tr}
+	esquerda := larguraUtil - larguraDireita
+	agenciaCodigo := agenciaCodigoBeneficiario(ben)
+
+	// Recibo do pagador
+	y := margem
+	r.cabecalho(y, banco, b.LinhaDigitavel)
+	y += alturaCabecalho
+	r.campo(margem, y, esquerda, "Beneficiário", fmt.Sprintf("%s - CNPJ %s", ben.Nome, ben.CNPJ), false)
+	r.campo(margem+esquerda, y, larguraDireita, "Vencimento", b.Vencimento.Format("02/01/2006"), true)
+	y += alturaCampo
+	r.campo(margem, y, esquerda, "Pagador", b.Pagador.Nome, false)
+	r.campo(margem+esquerda, y, larguraDireita, "Agência/Código do Beneficiário", agenciaCodigo, true)
+	y += alturaCampo
+	r.campo(margem, y, esquerda/2, "Número do Documento", b.NumeroDocumento, false)
+	r.campo(margem+esquerda/2, y, esquerda/2, "Nosso Número", b.NossoNumeroExibicao, false)
+	r.campo(margem+esquerda, y, larguraDireita, "(=) Valor do Documento", moeda(b.Valor), true)
+	y += alturaCampo
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.Text(margem+esquerda, y+3, tr("Autenticação mecânica - Recibo do Pagador"))
+
+	// Linha de corte
+	y += 12
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(margem, y, margem+larguraUtil, y)
+	pdf.SetDashPattern([]float64{}, 0)
+	y += 6
+
+	// Ficha de compensação
+	r.cabecalho(y, banco, b.LinhaDigitavel)
+	y += alturaCabecalho
+
+	local := ben.LocalPagamento
+	if local == "" {
+		local = "Pagável em qualquer banco até o vencimento"
+	}
+	r.campo(margem, y, esquerda, "Local de Pagamento", local, false)
+	r.campo(margem+esquerda, y, larguraDireita, "Vencimento", b.Vencimento.Format("02/01/2006"), true)
+	y += alturaCampo
+
+	beneficiario := ben.Nome + " - CNPJ " + ben.CNPJ
+	if ben.Endereco != "" {
+		beneficiario += " - " + ben.Endereco
+	}
+	r.campo(margem, y, esquerda, "Beneficiário", beneficiario, false)
+	r.campo(margem+esquerda, y, larguraDireita, "Agência/Código do Beneficiário", agenciaCodigo, true)
+	y += alturaCampo
+
+	colunas := []coluna{
+		{"Data do Documento", b.DataEmissao.Format("02/01/2006"), 0.2},
+		{"Número do Documento", b.NumeroDocumento, 0.25},
+		{"Espécie Doc.", "DM", 0.12},
+		{"Aceite", "N", 0.1},
+		{"Data Processamento", b.DataEmissao.Format("02/01/2006"), 0.33},
+	}
+	r.linha(y, esquerda, colunas)
+	r.campo(margem+esquerda, y, larguraDireita, "Nosso Número", b.NossoNumeroExibicao, true)
+	y += alturaCampo
+
+	colunas = []coluna{
+		{"Uso do Banco", "", 0.2},
+		{"Carteira", ben.Carteira, 0.15},
+		{"Espécie", "R$", 0.12},
+		{"Quantidade", "", 0.25},
+		{"Valor", "", 0.28},
+	}
+	r.linha(y, esquerda, colunas)
+	r.campo(margem+esquerda, y, larguraDireita, "(=) Valor do Documento", moeda(b.Valor), true)
+	y += alturaCampo
+
+	// Instruções à esquerda e campos de encargos à direita
+	alturaInstrucoes := alturaCampo * 5
+	pdf.Rect(margem, y, esquerda, alturaInstrucoes, "D")
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.Text(margem+1, y+2.5, tr("Instruções (texto de responsabilidade do beneficiário)"))
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetXY(margem+1, y+4)
+	pdf.MultiCell(esquerda-2, 3.8, tr(b.Instrucoes), "", "L", false)
+	for i, rotulo := range []string{"(-) Desconto/Abatimento", "(-) Outras Deduções", "(+) Mora/Multa", "(+) Outros Acréscimos", "(=) Valor Cobrado"} {
+		r.campo(margem+esquerda, y+float64(i)*alturaCampo, larguraDireita, rotulo, "", true)
+	}
+	y += alturaInstrucoes
+
+	pagador := fmt.Sprintf("%s - %s", b.Pagador.Nome, b.Pagador.Documento)
+	pdf.Rect(margem, y, larguraUtil, alturaCampo*1.5, "D")
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.Text(margem+1, y+2.5, "Pagador")
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.Text(margem+1, y+6, tr(pagador))
+	pdf.Text(margem+1, y+10, tr(b.Pagador.Endereco))
+	y += alturaCampo * 1.5
+
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.Text(margem+esquerda, y+3, tr("Autenticação mecânica - Ficha de Compensação"))
+	y += 5
+
+	if err := r.codigoBarras(margem, y, b.CodigoBarras); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// coluna é um campo de uma linha do boleto, com largura proporcional à linha
+type coluna struct {
+	rotulo, valor string
+	largura       float64
+}
+
+type renderizador struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+// cabecalho desenha o nome do banco, o código com DV e a linha digitável
+func (r *renderizador) cabecalho(y float64, banco febraban.Banco, linhaDigitavel string) {
+	pdf := r.pdf
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetXY(margem, y)
+	pdf.CellFormat(50, alturaCabecalho, r.tr(banco.Nome()), "B", 0, "LB", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(22, alturaCabecalho, febraban.CodigoBancoComDV(banco.Codigo()), "LRB", 0, "CB", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(larguraUtil-72, alturaCabecalho, linhaDigitavel, "B", 0, "RB", false, 0, "")
+}
+
+// campo desenha uma caixa com rótulo pequeno e valor
+func (r *renderizador) campo(x, y, largura float64, rotulo, valor string, direita bool) {
+	pdf := r.pdf
+	pdf.Rect(x, y, largura, alturaCampo, "D")
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.Text(x+1, y+2.5, r.tr(rotulo))
+
+	alinhamento := "L"
+	if direita {
+		alinhamento = "R"
+	}
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.SetXY(x+1, y+3)
+	pdf.CellFormat(largura-2, alturaCampo-3, truncar(pdf, r.tr(valor), largura-2), "", 0, alinhamento, false, 0, "")
+}
+
+// linha desenha uma sequência de campos com larguras proporcionais
+func (r *renderizador) linha(y, largura float64, colunas []coluna) {
+	x := margem
+	for _, c := range colunas {
+		w := largura * c.largura
+		r.campo(x, y, w, c.rotulo, c.valor, false)
+		x += w
+	}
+}
+
+// codigoBarras desenha o código de barras Interleaved 2 of 5 com 103 mm de largura
+func (r *renderizador) codigoBarras(x, y float64, codigo string) error {
+	elementos, err := febraban.I25(codigo)
+	if err != nil {
+		return err
+	}
+
+	modulos := 0
+	for _, e := range elementos {
+		modulos += e
+	}
+	modulo := larguraBarras / float64(modulos)
+
+	r.pdf.SetFillColor(0, 0, 0)
+	for i, e := range elementos {
+		w := float64(e) * modulo
+		if i%2 == 0 {
+			r.pdf.Rect(x, y, w, alturaBarras, "F")
+		}
+		x += w
+	}
+	return nil
+}
+
+// agenciaCodigoBeneficiario formata agência e conta (ou convênio) como impresso no boleto
+func agenciaCodigoBeneficiario(ben *Beneficiario) string {
+	agencia := ben.Agencia
+	if ben.DigitoAgencia != "" {
+		agencia += "-" + ben.DigitoAgencia
+	}
+	codigo := ben.Conta
+	if ben.DigitoConta != "" {
+		codigo += "-" + ben.DigitoConta
+	}
+	return agencia + " / " + codigo
+}
+
+// truncar corta o texto para caber na largura informada
+func truncar(pdf *fpdf.Fpdf, s string, largura float64) string {
+	for len(s) > 0 && pdf.GetStringWidth(s) > largura {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func moeda(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	inteiro, centavos := s[:len(s)-3], s[len(s)-2:]
+
+	var partes []string
+	for len(inteiro) > 3 {
+		partes = append([]string{inteiro[len(inteiro)-3:]}, partes...)
+		inteiro = inteiro[:len(inteiro)-3]
+	}
+	partes = append([]string{inteiro}, partes...)
+	return strings.Join(partes, ".") + "," + centavos
+}
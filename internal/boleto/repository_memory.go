@@ -0,0 +1,207 @@
+package boleto
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/febraban"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	beneficiarios map[int]*Beneficiario
+	nossoNumero   map[int]int64
+	remessas      map[int]int
+	boletos       map[int]*Boleto
+	nextID        int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		beneficiarios: make(map[int]*Beneficiario),
+		nossoNumero:   make(map[int]int64),
+		remessas:      make(map[int]int),
+		boletos:       make(map[int]*Boleto),
+		nextID:        1,
+	}
+}
+
+// SaveBeneficiario grava a configuração de cobrança da empresa
+func (r *MemoryRepository) SaveBeneficiario(b *Beneficiario) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if _, err := febraban.BancoPorCodigo(b.Banco); err != nil {
+		return err
+	}
+
+	if b.Agencia == "" || b.Conta == "" || b.Carteira == "" || b.Nome == "" || b.CNPJ == "" {
+		return errors.New("agência, conta, carteira, nome e CNPJ são obrigatórios")
+	}
+
+	r.beneficiarios[b.EmpresaID] = b
+	return nil
+}
+
+// GetBeneficiario busca a configuração de cobrança da empresa
+func (r *MemoryRepository) GetBeneficiario(empresaID int) (*Beneficiario, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, exists := r.beneficiarios[empresaID]
+	if !exists {
+		return nil, errors.New("beneficiário não configurado")
+	}
+	return b, nil
+}
+
+// ProximoNossoNumero reserva o próximo sequencial de nosso número da empresa
+func (r *MemoryRepository) ProximoNossoNumero(empresaID int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.nossoNumero[empresaID]++
+	return r.nossoNumero[empresaID], nil
+}
+
+// ProximaRemessa reserva o próximo número sequencial de arquivo de remessa
+func (r *MemoryRepository) ProximaRemessa(empresaID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.remessas[empresaID]++
+	return r.remessas[empresaID], nil
+}
+
+// Create adiciona um novo boleto
+func (r *MemoryRepository) Create(b *Boleto) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if b.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if b.NossoNumero == "" || b.CodigoBarras == "" {
+		return errors.New("nosso número e código de barras são obrigatórios")
+	}
+
+	// Verificar se o nosso número já existe
+	for _, existing := range r.boletos {
+		if existing.EmpresaID == b.EmpresaID && existing.NossoNumero == b.NossoNumero {
+			return errors.New("nosso número já utilizado")
+		}
+	}
+
+	b.ID = r.nextID
+	r.nextID++
+	b.DataCriacao = time.Now()
+
+	r.boletos[b.ID] = b
+	return nil
+}
+
+// GetByID busca um boleto por ID e empresa
+func (r *MemoryRepository) GetByID(id int, empresaID int) (*Boleto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	b, exists := r.boletos[id]
+	if !exists || b.EmpresaID != empresaID {
+		return nil, errors.New("boleto não encontrado")
+	}
+	return b, nil
+}
+
+// GetByNossoNumero busca um boleto pelo nosso número informado no retorno do banco.
+// Zeros à esquerda são ignorados, pois cada layout preenche o campo de forma diferente.
+func (r *MemoryRepository) GetByNossoNumero(empresaID int, nossoNumero string) (*Boleto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	procurado := strings.TrimLeft(nossoNumero, "0")
+	for _, b := range r.boletos {
+		if b.EmpresaID == empresaID && strings.TrimLeft(b.NossoNumero, "0") == procurado {
+			return b, nil
+		}
+	}
+	return nil, errors.New("boleto não encontrado")
+}
+
+// Update atualiza um boleto existente
+func (r *MemoryRepository) Update(b *Boleto) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.boletos[b.ID]
+	if !exists || existing.EmpresaID != b.EmpresaID {
+		return errors.New("boleto não encontrado")
+	}
+
+	// Preservar campos que não devem ser alterados
+	b.DataCriacao = existing.DataCriacao
+	b.NossoNumero = existing.NossoNumero
+	b.CodigoBarras = existing.CodigoBarras
+
+	r.boletos[b.ID] = b
+	return nil
+}
+
+// List retorna os boletos da empresa, dos mais recentes para os mais antigos
+func (r *MemoryRepository) List(empresaID int, clienteID int, status string, limit, offset int) ([]*Boleto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Boleto, 0)
+	for _, b := range r.boletos {
+		if b.EmpresaID != empresaID {
+			continue
+		}
+		if clienteID > 0 && b.ClienteID != clienteID {
+			continue
+		}
+		if status != "" && b.Status != status {
+			continue
+		}
+		result = append(result, b)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*Boleto{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// TransferirCliente move os boletos de um cliente para outro e retorna quantos foram alterados
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, b := range r.boletos {
+		if b.EmpresaID == empresaID && b.ClienteID == deID {
+			b.ClienteID = paraID
+			total++
+		}
+	}
+	return total, nil
+}
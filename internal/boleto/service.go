@@ -0,0 +1,358 @@
+package boleto
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/pkg/febraban"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// NovoBoleto contém os dados para emitir um boleto.
+// Informe ParcelaID para cobrar uma conta a receber ou ClienteID para cobrar o cliente diretamente.
+type NovoBoleto struct {
+	ClienteID       int       `json:"cliente_id"`
+	ParcelaID       int       `json:"parcela_id"`
+	Valor           float64   `json:"valor"`
+	Vencimento      time.Time `json:"vencimento"`
+	NumeroDocumento string    `json:"numero_documento"`
+	Instrucoes      string    `json:"instrucoes"`
+}
+
+// Service emite boletos, gera remessas e concilia os arquivos de retorno
+type Service struct {
+	repo       Repository
+	clientes   cliente.Repository
+	financeiro financeiro.Repository
+	baixas     *financeiro.Service
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, clientes cliente.Repository, financeiroRepo financeiro.Repository, baixas *financeiro.Service) *Service {
+	return &Service{
+		repo:       repo,
+		clientes:   clientes,
+		financeiro: financeiroRepo,
+		baixas:     baixas,
+	}
+}
+
+// Emitir calcula o nosso número, o código de barras e a linha digitável e grava o boleto
+func (s *Service) Emitir(empresaID int, in NovoBoleto) (*Boleto, error) {
+	ben, err := s.repo.GetBeneficiario(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	banco, err := febraban.BancoPorCodigo(ben.Banco)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Boleto{
+		EmpresaID:       empresaID,
+		Banco:           ben.Banco,
+		ClienteID:       in.ClienteID,
+		ParcelaID:       in.ParcelaID,
+		NumeroDocumento: in.NumeroDocumento,
+		Valor:           in.Valor,
+		Vencimento:      in.Vencimento,
+		DataEmissao:     time.Now(),
+		Instrucoes:      in.Instrucoes,
+		Status:          StatusEmitido,
+	}
+	jurosMensal, multa := ben.JurosMensal, ben.Multa
+
+	if in.ParcelaID > 0 {
+		t, err := s.financeiro.GetTituloPorParcela(in.ParcelaID, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if t.Tipo != financeiro.TipoReceber {
+			return nil, errors.New("apenas contas a receber podem ser cobradas por boleto")
+		}
+
+		for _, p := range t.Parcelas {
+			if p.ID != in.ParcelaID {
+				continue
+			}
+			if p.Status == financeiro.StatusPago || p.Status == financeiro.StatusCancelado {
+				return nil, fmt.Errorf("parcela já está %s", p.Status)
+			}
+			if b.Valor <= 0 {
+				b.Valor = p.Saldo()
+			}
+			if b.Vencimento.IsZero() {
+				b.Vencimento = p.Vencimento
+			}
+			if b.NumeroDocumento == "" {
+				b.NumeroDocumento = fmt.Sprintf("%d-%d", t.ID, p.Numero)
+			}
+		}
+
+		b.TituloID = t.ID
+		b.ClienteID = t.ClienteID
+		jurosMensal, multa = t.JurosMensal, t.Multa
+	}
+
+	if b.ClienteID <= 0 {
+		return nil, errors.New("informe o cliente ou a parcela a ser cobrada")
+	}
+	c, err := s.clientes.GetByID(b.ClienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Valor <= 0 {
+		return nil, errors.New("valor inválido")
+	}
+	if b.Vencimento.IsZero() {
+		return nil, errors.New("data de vencimento é obrigatória")
+	}
+	agora := time.Now()
+	hoje := time.Date(agora.Year(), agora.Month(), agora.Day(), 0, 0, 0, 0, agora.Location())
+	if b.Vencimento.Before(hoje) {
+		return nil, errors.New("vencimento não pode estar no passado; informe um novo vencimento")
+	}
+
+	b.Valor = arredondar(b.Valor)
+	b.JurosDia = arredondar(b.Valor * jurosMensal / 100 / 30)
+	b.Multa = multa
+	b.Pagador = Pagador{
+		Nome:      c.Nome,
+		Documento: documentoCliente(c),
+		Endereco:  c.Endereco,
+	}
+	if b.Instrucoes == "" {
+		b.Instrucoes = instrucoesPadrao(ben, b)
+	}
+
+	seq, err := s.repo.ProximoNossoNumero(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	codigo, err := febraban.Gerar(banco, conta(ben), seq, b.Vencimento, b.Valor)
+	if err != nil {
+		return nil, err
+	}
+
+	b.NossoNumero = codigo.NossoNumero
+	b.NossoNumeroExibicao = codigo.NossoNumeroExibicao
+	b.CodigoBarras = codigo.Barras
+	b.LinhaDigitavel = codigo.LinhaDigitavel
+	if b.NumeroDocumento == "" {
+		b.NumeroDocumento = strconv.FormatInt(seq, 10)
+	}
+
+	if err := s.repo.Create(b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Cancelar cancela um boleto ainda não pago
+func (s *Service) Cancelar(id int, empresaID int) (*Boleto, error) {
+	b, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Status == StatusPago {
+		return nil, errors.New("boleto já foi pago")
+	}
+
+	b.Status = StatusCancelado
+	if err := s.repo.Update(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// GerarRemessa gera o arquivo de remessa com os boletos ainda não enviados ao banco
+// e retorna o conteúdo e o nome sugerido do arquivo
+func (s *Service) GerarRemessa(empresaID int, formato string) ([]byte, string, error) {
+	ben, err := s.repo.GetBeneficiario(empresaID)
+	if err != nil {
+		return nil, "", err
+	}
+	banco, err := febraban.BancoPorCodigo(ben.Banco)
+	if err != nil {
+		return nil, "", err
+	}
+
+	gerar := febraban.GerarRemessa240
+	if formato == CNAB400 {
+		gerar = febraban.GerarRemessa400
+	} else if formato != CNAB240 {
+		return nil, "", fmt.Errorf("formato CNAB inválido: %s", formato)
+	}
+
+	boletos, err := s.repo.List(empresaID, 0, StatusEmitido, 0, 0)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(boletos) == 0 {
+		return nil, "", errors.New("nenhum boleto pendente de remessa")
+	}
+
+	r := febraban.Remessa{
+		Beneficiario: febraban.Beneficiario{
+			Banco:     banco,
+			Conta:     conta(ben),
+			Nome:      ben.Nome,
+			Documento: ben.CNPJ,
+		},
+		Data: time.Now(),
+	}
+	for _, b := range boletos {
+		r.Titulos = append(r.Titulos, febraban.TituloRemessa{
+			NossoNumero:     b.NossoNumero,
+			NumeroDocumento: b.NumeroDocumento,
+			Emissao:         b.DataEmissao,
+			Vencimento:      b.Vencimento,
+			Valor:           b.Valor,
+			JurosDia:        b.JurosDia,
+			Multa:           b.Multa,
+			Pagador: febraban.Pagador{
+				Nome:      b.Pagador.Nome,
+				Documento: b.Pagador.Documento,
+				Endereco:  b.Pagador.Endereco,
+			},
+		})
+	}
+
+	if r.Sequencial, err = s.repo.ProximaRemessa(empresaID); err != nil {
+		return nil, "", err
+	}
+
+	conteudo, err := gerar(r)
+	if err != nil {
+		return nil, "", err
+	}
+
+	for _, b := range boletos {
+		b.Status = StatusRegistrado
+		b.Remessa = r.Sequencial
+		if err := s.repo.Update(b); err != nil {
+			return nil, "", err
+		}
+	}
+
+	nome := fmt.Sprintf("CB%s%03d.REM", r.Data.Format("0201"), r.Sequencial%1000)
+	logger.InfoLogger.Printf("Remessa %d gerada para a empresa %d com %d boletos", r.Sequencial, empresaID, len(boletos))
+	return conteudo, nome, nil
+}
+
+// ProcessarRetorno lê o arquivo de retorno do banco e baixa os boletos liquidados.
+// Boletos já pagos são ignorados, de modo que o mesmo arquivo pode ser reprocessado.
+func (s *Service) ProcessarRetorno(empresaID int, formato string, conteudo []byte) (*ResultadoRetorno, error) {
+	ler := febraban.LerRetorno240
+	if formato == CNAB400 {
+		ler = febraban.LerRetorno400
+	} else if formato != CNAB240 {
+		return nil, fmt.Errorf("formato CNAB inválido: %s", formato)
+	}
+
+	ocorrencias, err := ler(conteudo)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &ResultadoRetorno{
+		Ocorrencias:    len(ocorrencias),
+		Liquidados:     []int{},
+		NaoEncontrados: []string{},
+	}
+
+	for _, o := range ocorrencias {
+		if !o.Liquidacao {
+			res.Ignorados++
+			continue
+		}
+
+		b, err := s.repo.GetByNossoNumero(empresaID, o.NossoNumero)
+		if err != nil {
+			res.NaoEncontrados = append(res.NaoEncontrados, o.NossoNumero)
+			continue
+		}
+		if b.Status == StatusPago {
+			res.Ignorados++
+			continue
+		}
+
+		data := o.DataOcorrencia
+		if data.IsZero() {
+			data = time.Now()
+		}
+
+		b.Status = StatusPago
+		b.ValorPago = o.ValorPago
+		b.DataPagamento = &data
+		if err := s.repo.Update(b); err != nil {
+			res.Erros = append(res.Erros, fmt.Sprintf("boleto %d: %v", b.ID, err))
+			continue
+		}
+		res.Liquidados = append(res.Liquidados, b.ID)
+
+		if b.ParcelaID > 0 {
+			_, err := s.baixas.RegistrarPagamento(empresaID, b.ParcelaID, financeiro.NovoPagamento{
+				Data:       data,
+				Valor:      o.ValorPago,
+				Desconto:   o.Desconto,
+				Observacao: "Boleto " + b.NossoNumeroExibicao,
+			})
+			if err != nil {
+				// O pagamento já foi creditado; a baixa deve ser conciliada manualmente
+				logger.ErrorLogger.Printf("Boleto %d liquidado, mas a baixa da parcela %d falhou: %v", b.ID, b.ParcelaID, err)
+				res.Erros = append(res.Erros, fmt.Sprintf("boleto %d: baixa da parcela %d falhou: %v", b.ID, b.ParcelaID, err))
+			}
+		}
+	}
+
+	logger.InfoLogger.Printf("Retorno processado para a empresa %d: %d liquidados de %d ocorrências", empresaID, len(res.Liquidados), res.Ocorrencias)
+	return res, nil
+}
+
+// conta converte a configuração do beneficiário para os dados bancários do febraban
+func conta(ben *Beneficiario) febraban.Conta {
+	return febraban.Conta{
+		Agencia:       ben.Agencia,
+		DigitoAgencia: ben.DigitoAgencia,
+		Conta:         ben.Conta,
+		DigitoConta:   ben.DigitoConta,
+		Carteira:      ben.Carteira,
+		Convenio:      ben.Convenio,
+	}
+}
+
+func documentoCliente(c *cliente.Cliente) string {
+	if c.CNPJ != "" {
+		return c.CNPJ
+	}
+	return c.CPF
+}
+
+// instrucoesPadrao monta as instruções de cobrança a partir dos encargos do boleto
+func instrucoesPadrao(ben *Beneficiario, b *Boleto) string {
+	var linhas []string
+	if ben.Instrucoes != "" {
+		linhas = append(linhas, ben.Instrucoes)
+	}
+	if b.Multa > 0 {
+		linhas = append(linhas, fmt.Sprintf("Após o vencimento cobrar multa de %s%%.", moeda(b.Multa)))
+	}
+	if b.JurosDia > 0 {
+		linhas = append(linhas, fmt.Sprintf("Após o vencimento cobrar juros de R$ %s por dia de atraso.", moeda(b.JurosDia)))
+	}
+	return strings.Join(linhas, "\n")
+}
+
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,144 @@
+package campo
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP para campos personalizados
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas para campos personalizados; apenas administradores alteram as definições
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/", h.List)
+	r.Get("/{id}", h.GetByID)
+
+	r.With(auth.RequireRole("admin")).Post("/", h.Save)
+	r.With(auth.RequireRole("admin")).Put("/{id}", h.Save)
+	r.With(auth.RequireRole("admin")).Delete("/{id}", h.Delete)
+
+	return r
+}
+
+// List lista as definições de campos da empresa. Filtro: entidade.
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	definicoes, err := h.repo.List(user.Empresa, r.URL.Query().Get("entidade"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(definicoes)
+}
+
+// GetByID retorna uma definição de campo por ID
+func (h *Handlers) GetByID(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.repo.GetByID(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// Save cria ou atualiza uma definição de campo
+func (h *Handlers) Save(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var d Definicao
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d.ID = 0
+	if idParam := chi.URLParam(r, "id"); idParam != "" {
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return
+		}
+		d.ID = id
+	}
+	d.EmpresaID = user.Empresa
+
+	criando := d.ID == 0
+	if err := h.service.SalvarDefinicao(&d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if criando {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(d)
+}
+
+// Delete remove uma definição de campo
+func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.Delete(id, user.Empresa); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,61 @@
+package campo
+
+import (
+	"time"
+)
+
+// Entidades que aceitam campos personalizados
+const (
+	EntidadeCliente = "cliente"
+	EntidadeProduto = "produto"
+)
+
+// Tipos de campo personalizado
+const (
+	TipoTexto    = "texto"
+	TipoNumero   = "numero"
+	TipoData     = "data"     // valores no formato AAAA-MM-DD
+	TipoSelecao  = "selecao"  // um valor entre as opções
+	TipoMultipla = "multipla" // vários valores entre as opções
+)
+
+// Definicao descreve um campo personalizado criado pela empresa para uma entidade
+type Definicao struct {
+	ID              int       `json:"id"`
+	EmpresaID       int       `json:"empresa_id"`
+	Entidade        string    `json:"entidade"`
+	Chave           string    `json:"chave"` // identificador usado no JSON e nos filtros; não muda após criado
+	Rotulo          string    `json:"rotulo"`
+	Tipo            string    `json:"tipo"`
+	Obrigatorio     bool      `json:"obrigatorio"`
+	Opcoes          []string  `json:"opcoes,omitempty"`         // seleção e múltipla escolha
+	TamanhoMaximo   int       `json:"tamanho_maximo,omitempty"` // texto
+	Padrao          string    `json:"padrao,omitempty"`         // expressão regular para texto
+	Minimo          *float64  `json:"minimo,omitempty"`         // número
+	Maximo          *float64  `json:"maximo,omitempty"`         // número
+	Ajuda           string    `json:"ajuda,omitempty"`          // texto de apoio exibido no formulário
+	Ordem           int       `json:"ordem"`
+	DataCriacao     time.Time `json:"data_criacao"`
+	DataAtualizacao time.Time `json:"data_atualizacao"`
+}
+
+// Valores guarda os campos personalizados de um registro, indexados pela chave.
+// Após a validação, números são float64, datas e textos são string e múltipla escolha é []string.
+type Valores map[string]interface{}
+
+// Filtro restringe listagens pelo valor de um campo personalizado
+type Filtro struct {
+	Chave    string
+	Operador string // vazio para igualdade, "min" ou "max" para limites
+	Valor    string
+}
+
+// Repository define a interface para acesso às definições de campos
+type Repository interface {
+	Create(d *Definicao) error
+	GetByID(id int, empresaID int) (*Definicao, error)
+	Update(d *Definicao) error
+	Delete(id int, empresaID int) error
+	// List retorna as definições da entidade em ordem de exibição; entidade vazia lista todas
+	List(empresaID int, entidade string) ([]*Definicao, error)
+}
@@ -0,0 +1,116 @@
+package campo
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	definicoes map[int]*Definicao
+	nextID     int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		definicoes: make(map[int]*Definicao),
+		nextID:     1,
+	}
+}
+
+// Create adiciona uma nova definição de campo
+func (r *MemoryRepository) Create(d *Definicao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	// A chave é única por empresa e entidade
+	for _, existing := range r.definicoes {
+		if existing.EmpresaID == d.EmpresaID && existing.Entidade == d.Entidade && existing.Chave == d.Chave {
+			return errors.New("já existe um campo com esta chave")
+		}
+	}
+
+	d.ID = r.nextID
+	r.nextID++
+	d.DataCriacao = time.Now()
+	d.DataAtualizacao = d.DataCriacao
+
+	r.definicoes[d.ID] = d
+	return nil
+}
+
+// GetByID busca uma definição por ID e empresa
+func (r *MemoryRepository) GetByID(id int, empresaID int) (*Definicao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, exists := r.definicoes[id]
+	if !exists || d.EmpresaID != empresaID {
+		return nil, errors.New("campo não encontrado")
+	}
+	return d, nil
+}
+
+// Update atualiza uma definição existente, preservando entidade, chave e tipo
+func (r *MemoryRepository) Update(d *Definicao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.definicoes[d.ID]
+	if !exists || existing.EmpresaID != d.EmpresaID {
+		return errors.New("campo não encontrado")
+	}
+
+	// Preservar campos que não devem ser alterados
+	d.Entidade = existing.Entidade
+	d.Chave = existing.Chave
+	d.Tipo = existing.Tipo
+	d.DataCriacao = existing.DataCriacao
+	d.DataAtualizacao = time.Now()
+
+	r.definicoes[d.ID] = d
+	return nil
+}
+
+// Delete remove uma definição; os valores já gravados nos registros são descartados na próxima alteração
+func (r *MemoryRepository) Delete(id int, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	d, exists := r.definicoes[id]
+	if !exists || d.EmpresaID != empresaID {
+		return errors.New("campo não encontrado")
+	}
+
+	delete(r.definicoes, id)
+	return nil
+}
+
+// List retorna as definições da empresa ordenadas por ordem de exibição e rótulo
+func (r *MemoryRepository) List(empresaID int, entidade string) ([]*Definicao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Definicao, 0)
+	for _, d := range r.definicoes {
+		if d.EmpresaID == empresaID && (entidade == "" || d.Entidade == entidade) {
+			result = append(result, d)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Ordem != result[j].Ordem {
+			return result[i].Ordem < result[j].Ordem
+		}
+		return result[i].Rotulo < result[j].Rotulo
+	})
+	return result, nil
+}
@@ -0,0 +1,250 @@
+package campo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// formatoChave restringe as chaves a identificadores simples, seguros em JSON e na query string
+var formatoChave = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// Service implementa a validação dos campos personalizados
+type Service struct {
+	repo Repository
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo: repo,
+	}
+}
+
+// SalvarDefinicao valida e cria ou atualiza a definição de um campo
+func (s *Service) SalvarDefinicao(d *Definicao) error {
+	d.Chave = strings.TrimSpace(d.Chave)
+	d.Rotulo = strings.TrimSpace(d.Rotulo)
+
+	if d.ID == 0 {
+		if d.Entidade != EntidadeCliente && d.Entidade != EntidadeProduto {
+			return fmt.Errorf("entidade inválida: %s", d.Entidade)
+		}
+		if !formatoChave.MatchString(d.Chave) {
+			return errors.New("chave deve começar com letra e conter apenas letras minúsculas, números e _")
+		}
+	} else {
+		// Entidade, chave e tipo são fixos: os valores já gravados dependem deles
+		atual, err := s.repo.GetByID(d.ID, d.EmpresaID)
+		if err != nil {
+			return err
+		}
+		d.Entidade, d.Chave, d.Tipo = atual.Entidade, atual.Chave, atual.Tipo
+	}
+
+	if d.Rotulo == "" {
+		return errors.New("rótulo é obrigatório")
+	}
+
+	switch d.Tipo {
+	case TipoTexto, TipoNumero, TipoData:
+		d.Opcoes = nil
+	case TipoSelecao, TipoMultipla:
+		opcoes := make([]string, 0, len(d.Opcoes))
+		vistas := make(map[string]bool)
+		for _, o := range d.Opcoes {
+			o = strings.TrimSpace(o)
+			if o == "" || vistas[strings.ToLower(o)] {
+				continue
+			}
+			vistas[strings.ToLower(o)] = true
+			opcoes = append(opcoes, o)
+		}
+		if len(opcoes) == 0 {
+			return errors.New("informe as opções do campo")
+		}
+		d.Opcoes = opcoes
+	default:
+		return fmt.Errorf("tipo de campo inválido: %s", d.Tipo)
+	}
+
+	if d.Padrao != "" {
+		if d.Tipo != TipoTexto {
+			return errors.New("padrão só se aplica a campos de texto")
+		}
+		if _, err := regexp.Compile(d.Padrao); err != nil {
+			return fmt.Errorf("padrão inválido: %v", err)
+		}
+	}
+	if d.TamanhoMaximo < 0 {
+		return errors.New("tamanho máximo não pode ser negativo")
+	}
+	if d.Minimo != nil && d.Maximo != nil && *d.Minimo > *d.Maximo {
+		return errors.New("mínimo maior que o máximo")
+	}
+
+	if d.ID == 0 {
+		return s.repo.Create(d)
+	}
+	return s.repo.Update(d)
+}
+
+// Validar confere os valores informados contra as definições da entidade e os normaliza.
+// Chaves sem definição são descartadas; campos obrigatórios ausentes geram erro.
+func (s *Service) Validar(empresaID int, entidade string, valores Valores) (Valores, error) {
+	definicoes, err := s.repo.List(empresaID, entidade)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(Valores)
+	var erros []string
+	for _, d := range definicoes {
+		bruto, informado := valores[d.Chave]
+		if !informado || vazio(bruto) {
+			if d.Obrigatorio {
+				erros = append(erros, fmt.Sprintf("%s é obrigatório", d.Rotulo))
+			}
+			continue
+		}
+
+		v, err := normalizar(d, bruto)
+		if err != nil {
+			erros = append(erros, fmt.Sprintf("%s: %v", d.Rotulo, err))
+			continue
+		}
+		result[d.Chave] = v
+	}
+
+	if len(erros) > 0 {
+		return nil, errors.New("campos personalizados inválidos: " + strings.Join(erros, "; "))
+	}
+	if len(result) == 0 {
+		return nil, nil
+	}
+	return result, nil
+}
+
+// normalizar converte o valor para o tipo do campo e aplica as regras da definição
+func normalizar(d *Definicao, bruto interface{}) (interface{}, error) {
+	switch d.Tipo {
+	case TipoTexto:
+		texto, ok := bruto.(string)
+		if !ok {
+			return nil, errors.New("deve ser texto")
+		}
+		texto = strings.TrimSpace(texto)
+		if d.TamanhoMaximo > 0 && utf8.RuneCountInString(texto) > d.TamanhoMaximo {
+			return nil, fmt.Errorf("excede %d caracteres", d.TamanhoMaximo)
+		}
+		if d.Padrao != "" {
+			if re, err := regexp.Compile(d.Padrao); err == nil && !re.MatchString(texto) {
+				return nil, errors.New("formato inválido")
+			}
+		}
+		return texto, nil
+
+	case TipoNumero:
+		var numero float64
+		switch v := bruto.(type) {
+		case float64:
+			numero = v
+		case string:
+			n, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(v), ",", ".", 1), 64)
+			if err != nil {
+				return nil, errors.New("deve ser um número")
+			}
+			numero = n
+		default:
+			return nil, errors.New("deve ser um número")
+		}
+		if d.Minimo != nil && numero < *d.Minimo {
+			return nil, fmt.Errorf("deve ser no mínimo %v", *d.Minimo)
+		}
+		if d.Maximo != nil && numero > *d.Maximo {
+			return nil, fmt.Errorf("deve ser no máximo %v", *d.Maximo)
+		}
+		return numero, nil
+
+	case TipoData:
+		texto, ok := bruto.(string)
+		if !ok {
+			return nil, errors.New("deve ser uma data AAAA-MM-DD")
+		}
+		data, err := time.Parse("2006-01-02", strings.TrimSpace(texto))
+		if err != nil {
+			return nil, errors.New("deve ser uma data AAAA-MM-DD")
+		}
+		return data.Format("2006-01-02"), nil
+
+	case TipoSelecao:
+		texto, ok := bruto.(string)
+		if !ok {
+			return nil, errors.New("deve ser uma das opções")
+		}
+		return opcao(d, texto)
+
+	case TipoMultipla:
+		var itens []interface{}
+		switch v := bruto.(type) {
+		case []interface{}:
+			itens = v
+		case []string:
+			for _, item := range v {
+				itens = append(itens, item)
+			}
+		default:
+			return nil, errors.New("deve ser uma lista de opções")
+		}
+
+		result := make([]string, 0, len(itens))
+		vistas := make(map[string]bool)
+		for _, item := range itens {
+			texto, ok := item.(string)
+			if !ok {
+				return nil, errors.New("deve ser uma lista de opções")
+			}
+			o, err := opcao(d, texto)
+			if err != nil {
+				return nil, err
+			}
+			if !vistas[o] {
+				vistas[o] = true
+				result = append(result, o)
+			}
+		}
+		return result, nil
+	}
+
+	return nil, fmt.Errorf("tipo de campo inválido: %s", d.Tipo)
+}
+
+// opcao retorna a opção da definição correspondente ao texto, com a grafia cadastrada
+func opcao(d *Definicao, texto string) (string, error) {
+	texto = strings.TrimSpace(texto)
+	for _, o := range d.Opcoes {
+		if strings.EqualFold(o, texto) {
+			return o, nil
+		}
+	}
+	return "", fmt.Errorf("opção inválida: %s", texto)
+}
+
+// vazio indica se o valor equivale a um campo não preenchido
+func vazio(v interface{}) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case []interface{}:
+		return len(v) == 0
+	case []string:
+		return len(v) == 0
+	}
+	return false
+}
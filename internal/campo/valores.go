@@ -0,0 +1,108 @@
+package campo
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// prefixoFiltro identifica os parâmetros de filtro por campo personalizado na query string
+const prefixoFiltro = "campo."
+
+// FiltrosDaQuery lê os filtros no formato campo.<chave>=valor, campo.<chave>.min=valor
+// e campo.<chave>.max=valor. Os limites valem para números e datas.
+func FiltrosDaQuery(q url.Values) []Filtro {
+	var filtros []Filtro
+	for parametro, valores := range q {
+		if !strings.HasPrefix(parametro, prefixoFiltro) || len(valores) == 0 {
+			continue
+		}
+		chave := strings.TrimPrefix(parametro, prefixoFiltro)
+		operador := ""
+		if i := strings.LastIndex(chave, "."); i > 0 {
+			chave, operador = chave[:i], chave[i+1:]
+			if operador != "min" && operador != "max" {
+				continue
+			}
+		}
+		filtros = append(filtros, Filtro{Chave: chave, Operador: operador, Valor: valores[0]})
+	}
+
+	// Ordem estável para que a mesma query produza a mesma listagem
+	sort.Slice(filtros, func(i, j int) bool {
+		if filtros[i].Chave != filtros[j].Chave {
+			return filtros[i].Chave < filtros[j].Chave
+		}
+		return filtros[i].Operador < filtros[j].Operador
+	})
+	return filtros
+}
+
+// Atende indica se os valores satisfazem todos os filtros
+func (v Valores) Atende(filtros []Filtro) bool {
+	for _, f := range filtros {
+		if !atende(v[f.Chave], f) {
+			return false
+		}
+	}
+	return true
+}
+
+// Contem indica se algum valor de texto contém o termo, sem diferenciar maiúsculas
+func (v Valores) Contem(termo string) bool {
+	termo = strings.ToLower(termo)
+	for _, valor := range v {
+		switch valor := valor.(type) {
+		case string:
+			if strings.Contains(strings.ToLower(valor), termo) {
+				return true
+			}
+		case []string:
+			for _, item := range valor {
+				if strings.Contains(strings.ToLower(item), termo) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func atende(valor interface{}, f Filtro) bool {
+	switch valor := valor.(type) {
+	case float64:
+		alvo, err := strconv.ParseFloat(strings.Replace(f.Valor, ",", ".", 1), 64)
+		if err != nil {
+			return false
+		}
+		switch f.Operador {
+		case "min":
+			return valor >= alvo
+		case "max":
+			return valor <= alvo
+		}
+		return valor == alvo
+
+	case string:
+		// Datas AAAA-MM-DD comparadas como texto seguem a ordem cronológica
+		switch f.Operador {
+		case "min":
+			return valor >= f.Valor
+		case "max":
+			return valor <= f.Valor
+		}
+		return strings.EqualFold(valor, f.Valor)
+
+	case []string:
+		if f.Operador != "" {
+			return false
+		}
+		for _, item := range valor {
+			if strings.EqualFold(item, f.Valor) {
+				return true
+			}
+		}
+	}
+	return false
+}
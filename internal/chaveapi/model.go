@@ -0,0 +1,114 @@
+package chaveapi
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Níveis de acesso dos escopos; escrita inclui a leitura
+const (
+	NivelLeitura = "leitura"
+	NivelEscrita = "escrita"
+)
+
+// Recursos são os módulos da API (/api/v1/<recurso>) que aceitam chaves. Autenticação, usuários,
+// empresas e demais áreas administrativas ficam de fora: as integrações não administram a conta.
+// As notificações também: a chave age em nome de quem a criou, e leria as notificações dele.
+var Recursos = []string{
+	"atividades",
+	"boletos",
+	"campos",
+	"clientes",
+	"dashboard",
+	"estoque",
+	"financeiro",
+	"fiscal",
+	"kanban",
+	"pedidos",
+	"pix",
+	"produtos",
+}
+
+// Escopos é o catálogo de escopos no formato <recurso>:<nível>, como clientes:leitura
+var Escopos = catalogoEscopos()
+
+// ErrChaveInvalida é a resposta única para chaves inexistentes, revogadas ou expiradas
+var ErrChaveInvalida = errors.New("chave de API inválida, revogada ou expirada")
+
+// Chave é uma chave de API da empresa. O valor completo só é exibido na criação; ficam gravados
+// o hash e o prefixo, que identifica a chave nas listagens e nos logs.
+type Chave struct {
+	ID          int        `json:"id"`
+	EmpresaID   int        `json:"empresa_id"`
+	Nome        string     `json:"nome"`
+	Prefixo     string     `json:"prefixo"`
+	Hash        string     `json:"-"`
+	Valor       string     `json:"chave,omitempty"` // exibido apenas na criação
+	Escopos     []string   `json:"escopos"`
+	Expira      *time.Time `json:"expira,omitempty"`
+	UltimoUso   *time.Time `json:"ultimo_uso,omitempty"`
+	UltimoIP    string     `json:"ultimo_ip,omitempty"`
+	UsuarioID   int        `json:"usuario_id"` // administrador que criou a chave
+	DataCriacao time.Time  `json:"data_criacao"`
+	Revogada    *time.Time `json:"revogada,omitempty"`
+	Status      string     `json:"status,omitempty"` // calculado na listagem
+}
+
+// Status das chaves, calculados a partir das datas
+const (
+	StatusAtiva    = "ativa"
+	StatusExpirada = "expirada"
+	StatusRevogada = "revogada"
+)
+
+// StatusEm calcula o status da chave no instante informado
+func (c *Chave) StatusEm(agora time.Time) string {
+	switch {
+	case c.Revogada != nil:
+		return StatusRevogada
+	case c.Expira != nil && !agora.Before(*c.Expira):
+		return StatusExpirada
+	}
+	return StatusAtiva
+}
+
+// Permite informa se a chave dá acesso ao recurso; escrita exige o nível escrita. Escopos de
+// recursos retirados do catálogo deixam de valer nas chaves já emitidas.
+func (c *Chave) Permite(recurso string, escrita bool) bool {
+	for _, e := range c.Escopos {
+		r, nivel, _ := strings.Cut(e, ":")
+		if r != recurso || !escopoValido(e) {
+			continue
+		}
+		if nivel == NivelEscrita || !escrita {
+			return true
+		}
+	}
+	return false
+}
+
+// NovaChave são os dados informados na criação
+type NovaChave struct {
+	Nome    string     `json:"nome"`
+	Escopos []string   `json:"escopos"`
+	Expira  *time.Time `json:"expira,omitempty"` // sem data, a chave vale até ser revogada
+}
+
+// Repository define a interface para acesso às chaves de API
+type Repository interface {
+	Create(c *Chave) error
+	Get(id int) (*Chave, error)
+	GetByPrefixo(prefixo string) (*Chave, error)
+	List(empresaID int) ([]*Chave, error)
+	Revogar(id int, quando time.Time) error
+	RegistrarUso(id int, quando time.Time, ip string) error
+}
+
+func catalogoEscopos() []string {
+	escopos := make([]string, 0, 2*len(Recursos))
+	for _, r := range Recursos {
+		escopos = append(escopos, r+":"+NivelLeitura, r+":"+NivelEscrita)
+	}
+	return escopos
+}
@@ -0,0 +1,120 @@
+package chaveapi
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	chaves map[int]*Chave
+	nextID int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		chaves: make(map[int]*Chave),
+		nextID: 1,
+	}
+}
+
+// Create grava uma nova chave; o prefixo é único
+func (r *MemoryRepository) Create(c *Chave) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.Prefixo == "" || c.Hash == "" {
+		return errors.New("prefixo e hash são obrigatórios")
+	}
+	for _, existente := range r.chaves {
+		if existente.Prefixo == c.Prefixo {
+			return errors.New("prefixo já está em uso")
+		}
+	}
+
+	c.ID = r.nextID
+	r.nextID++
+	copia := copiarChave(c)
+	copia.Valor = ""
+	r.chaves[c.ID] = copia
+	return nil
+}
+
+// Get busca a chave pelo ID
+func (r *MemoryRepository) Get(id int) (*Chave, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.chaves[id]
+	if !exists {
+		return nil, errors.New("chave não encontrada")
+	}
+	return copiarChave(c), nil
+}
+
+// GetByPrefixo busca a chave pelo prefixo
+func (r *MemoryRepository) GetByPrefixo(prefixo string) (*Chave, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.chaves {
+		if c.Prefixo == prefixo {
+			return copiarChave(c), nil
+		}
+	}
+	return nil, errors.New("chave não encontrada")
+}
+
+// List retorna as chaves da empresa, das mais recentes para as mais antigas
+func (r *MemoryRepository) List(empresaID int) ([]*Chave, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*Chave
+	for _, c := range r.chaves {
+		if c.EmpresaID == empresaID {
+			result = append(result, copiarChave(c))
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result, nil
+}
+
+// Revogar invalida a chave; revogar de novo mantém a data original
+func (r *MemoryRepository) Revogar(id int, quando time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.chaves[id]
+	if !exists {
+		return errors.New("chave não encontrada")
+	}
+	if c.Revogada == nil {
+		c.Revogada = &quando
+	}
+	return nil
+}
+
+// RegistrarUso grava o último uso da chave
+func (r *MemoryRepository) RegistrarUso(id int, quando time.Time, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.chaves[id]
+	if !exists {
+		return errors.New("chave não encontrada")
+	}
+	c.UltimoUso = &quando
+	c.UltimoIP = ip
+	return nil
+}
+
+func copiarChave(c *Chave) *Chave {
+	copia := *c
+	copia.Escopos = append([]string(nil), c.Escopos...)
+	return &copia
+}
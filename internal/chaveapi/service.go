@@ -0,0 +1,217 @@
+package chaveapi
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// Formato das chaves: gv_<prefixo>_<segredo>, com o prefixo de 8 caracteres e o segredo de 256 bits
+const (
+	inicioChave     = "gv_"
+	bytesPrefixo    = 5
+	bytesSegredo    = 32
+	tentativasCriar = 3
+)
+
+// Limites das chaves
+const (
+	// MaxChavesAtivas é o número de chaves ativas por empresa
+	MaxChavesAtivas = 50
+	// intervaloUso evita gravar o último uso a cada requisição
+	intervaloUso = time.Minute
+)
+
+var base32Prefixo = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Service cria, autentica e revoga as chaves de API das empresas
+type Service struct {
+	repo  Repository
+	agora func() time.Time
+}
+
+// NewService cria um novo serviço
+func NewService(repo Repository) *Service {
+	return &Service{
+		repo:  repo,
+		agora: time.Now,
+	}
+}
+
+// Criar gera uma nova chave para a empresa. O valor retornado em Chave.Valor não pode ser
+// consultado depois.
+func (s *Service) Criar(empresaID, autorID int, in NovaChave) (*Chave, error) {
+	nome := strings.TrimSpace(in.Nome)
+	if nome == "" {
+		return nil, errors.New("informe o nome da chave")
+	}
+	escopos, err := validarEscopos(in.Escopos)
+	if err != nil {
+		return nil, err
+	}
+	agora := s.agora()
+	if in.Expira != nil && !in.Expira.After(agora) {
+		return nil, errors.New("a expiração deve ser uma data futura")
+	}
+
+	chaves, err := s.repo.List(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	ativas := 0
+	for _, c := range chaves {
+		if c.StatusEm(agora) == StatusAtiva {
+			ativas++
+		}
+	}
+	if ativas >= MaxChavesAtivas {
+		return nil, fmt.Errorf("limite de %d chaves ativas atingido; revogue as que não são usadas", MaxChavesAtivas)
+	}
+
+	c := &Chave{
+		EmpresaID:   empresaID,
+		Nome:        nome,
+		Escopos:     escopos,
+		Expira:      in.Expira,
+		UsuarioID:   autorID,
+		DataCriacao: agora,
+	}
+	// Um prefixo repetido é recusado pelo repositório; basta sortear outro
+	for i := 0; ; i++ {
+		valor, prefixo, err := gerarChave()
+		if err != nil {
+			return nil, err
+		}
+		c.Valor, c.Prefixo, c.Hash = valor, prefixo, hashChave(valor)
+		if err = s.repo.Create(c); err == nil {
+			break
+		}
+		if i == tentativasCriar-1 {
+			return nil, err
+		}
+	}
+
+	logger.InfoLogger.Printf("Chave de API %s (%s) criada na empresa %d por usuário %d com os escopos %s", c.Prefixo, c.Nome, empresaID, autorID, strings.Join(escopos, ", "))
+	c.Status = StatusAtiva
+	return c, nil
+}
+
+// List retorna as chaves da empresa com o status atual
+func (s *Service) List(empresaID int) ([]*Chave, error) {
+	chaves, err := s.repo.List(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	agora := s.agora()
+	for _, c := range chaves {
+		c.Status = c.StatusEm(agora)
+	}
+	return chaves, nil
+}
+
+// Revogar invalida imediatamente a chave da empresa
+func (s *Service) Revogar(empresaID, id, autorID int) error {
+	c, err := s.repo.Get(id)
+	if err != nil || c.EmpresaID != empresaID {
+		return errors.New("chave não encontrada")
+	}
+	if err := s.repo.Revogar(id, s.agora()); err != nil {
+		return err
+	}
+	logger.InfoLogger.Printf("Chave de API %s revogada na empresa %d por usuário %d", c.Prefixo, empresaID, autorID)
+	return nil
+}
+
+// Autenticar valida o valor da chave e registra o uso. Todas as falhas retornam ErrChaveInvalida.
+func (s *Service) Autenticar(valor, ip string) (*Chave, error) {
+	prefixo, ok := prefixoDe(valor)
+	if !ok {
+		return nil, ErrChaveInvalida
+	}
+	c, err := s.repo.GetByPrefixo(prefixo)
+	if err != nil {
+		return nil, ErrChaveInvalida
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Hash), []byte(hashChave(valor))) != 1 {
+		return nil, ErrChaveInvalida
+	}
+	agora := s.agora()
+	if c.StatusEm(agora) != StatusAtiva {
+		return nil, ErrChaveInvalida
+	}
+
+	if c.UltimoUso == nil || agora.Sub(*c.UltimoUso) >= intervaloUso || c.UltimoIP != ip {
+		if err := s.repo.RegistrarUso(c.ID, agora, ip); err != nil {
+			logger.ErrorLogger.Printf("Erro ao registrar uso da chave de API %s: %v", c.Prefixo, err)
+		}
+		c.UltimoUso, c.UltimoIP = &agora, ip
+	}
+	return c, nil
+}
+
+// validarEscopos confere os escopos contra o catálogo, sem repetições
+func validarEscopos(escopos []string) ([]string, error) {
+	if len(escopos) == 0 {
+		return nil, errors.New("informe ao menos um escopo")
+	}
+	vistos := make(map[string]bool, len(escopos))
+	result := make([]string, 0, len(escopos))
+	for _, e := range escopos {
+		e = strings.ToLower(strings.TrimSpace(e))
+		if !escopoValido(e) {
+			return nil, fmt.Errorf("escopo inválido: %s", e)
+		}
+		if !vistos[e] {
+			vistos[e] = true
+			result = append(result, e)
+		}
+	}
+	return result, nil
+}
+
+func escopoValido(escopo string) bool {
+	for _, e := range Escopos {
+		if e == escopo {
+			return true
+		}
+	}
+	return false
+}
+
+// gerarChave sorteia o valor da chave e retorna também o seu prefixo
+func gerarChave() (string, string, error) {
+	b := make([]byte, bytesPrefixo+bytesSegredo)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+	prefixo := inicioChave + strings.ToLower(base32Prefixo.EncodeToString(b[:bytesPrefixo]))
+	return prefixo + "_" + base64.RawURLEncoding.EncodeToString(b[bytesPrefixo:]), prefixo, nil
+}
+
+// prefixoDe extrai o prefixo do valor; o segredo em base64 pode conter "_", o prefixo não
+func prefixoDe(valor string) (string, bool) {
+	resto, ok := strings.CutPrefix(valor, inicioChave)
+	if !ok {
+		return "", false
+	}
+	prefixo, segredo, ok := strings.Cut(resto, "_")
+	if !ok || prefixo == "" || segredo == "" {
+		return "", false
+	}
+	return inicioChave + prefixo, true
+}
+
+// hashChave é a forma gravada da chave; SHA-256 basta, pois o segredo tem 256 bits aleatórios
+func hashChave(valor string) string {
+	h := sha256.Sum256([]byte(valor))
+	return hex.EncodeToString(h[:])
+}
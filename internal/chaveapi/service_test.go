@@ -0,0 +1,102 @@
+package chaveapi
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func TestCriarAutenticarRevogar(t *testing.T) {
+	repo := NewMemoryRepository()
+	s := NewService(repo)
+
+	c, err := s.Criar(1, 7, NovaChave{Nome: "ERP", Escopos: []string{"clientes:leitura", "pedidos:escrita"}})
+	if err != nil {
+		t.Fatalf("Criar: %v", err)
+	}
+	if c.Valor == "" || c.Hash == c.Valor {
+		t.Fatalf("chave criada sem valor ou com o valor gravado: %+v", c)
+	}
+	if gravada, _ := repo.Get(c.ID); gravada.Valor != "" {
+		t.Error("valor da chave gravado no repositório")
+	}
+
+	a, err := s.Autenticar(c.Valor, "203.0.113.1")
+	if err != nil || a.EmpresaID != 1 || a.UsuarioID != 7 {
+		t.Fatalf("Autenticar = %+v, %v", a, err)
+	}
+	if _, err := s.Autenticar(c.Valor+"x", "203.0.113.1"); !errors.Is(err, ErrChaveInvalida) {
+		t.Errorf("chave alterada aceita: %v", err)
+	}
+
+	if err := s.Revogar(2, c.ID, 9); err == nil {
+		t.Error("chave revogada por outra empresa")
+	}
+	if err := s.Revogar(1, c.ID, 7); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Autenticar(c.Valor, "203.0.113.1"); !errors.Is(err, ErrChaveInvalida) {
+		t.Errorf("chave revogada aceita: %v", err)
+	}
+}
+
+func TestChaveExpirada(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+	expira := time.Now().Add(time.Hour)
+	c, err := s.Criar(1, 7, NovaChave{Nome: "ERP", Escopos: []string{"clientes:leitura"}, Expira: &expira})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	depois := expira.Add(time.Second)
+	s.agora = func() time.Time { return depois }
+	if _, err := s.Autenticar(c.Valor, "203.0.113.1"); !errors.Is(err, ErrChaveInvalida) {
+		t.Errorf("chave expirada aceita: %v", err)
+	}
+}
+
+func TestEscopos(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+	for _, escopos := range [][]string{
+		nil,
+		{"usuarios:leitura"},
+		{"empresas:escrita"},
+		{"notificacoes:leitura"},
+		{"clientes:admin"},
+	} {
+		if _, err := s.Criar(1, 7, NovaChave{Nome: "ERP", Escopos: escopos}); err == nil {
+			t.Errorf("escopos %v aceitos", escopos)
+		}
+	}
+
+	// Chaves emitidas antes de um recurso sair do catálogo perdem o acesso a ele
+	c := &Chave{Escopos: []string{"clientes:leitura", "notificacoes:escrita"}}
+	casos := []struct {
+		recurso string
+		escrita bool
+		permite bool
+	}{
+		{"clientes", false, true},
+		{"clientes", true, false},
+		{"notificacoes", false, false},
+		{"usuarios", false, false},
+	}
+	for _, caso := range casos {
+		if c.Permite(caso.recurso, caso.escrita) != caso.permite {
+			t.Errorf("Permite(%s, escrita %t) = %t", caso.recurso, caso.escrita, !caso.permite)
+		}
+	}
+}
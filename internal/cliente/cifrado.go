@@ -0,0 +1,465 @@
+package cliente
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/campo"
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+	"github.com/go-chi/chi/v5"
+)
+
+// Campos de índice cego; os valores são normalizados antes do cálculo
+const (
+	indiceDocumento = "cliente.documento"
+	indiceEmail     = "cliente.email"
+)
+
+// TipoJobRecifrar é o job que recifra os dados pessoais de todas as empresas; enfileirado pelo comando recifrar
+const TipoJobRecifrar = "clientes.recifrar"
+
+// FilaRecifrar é a fila de recifragem consumida apenas pela instância informada. Enquanto os cadastros
+// ficam na memória de cada instância, cada uma precisa recifrar os próprios dados
+func FilaRecifrar(instancia string) string {
+	return "recifrar." + instancia
+}
+
+// RepositorioCifrado decora um Repository gravando cifrados CPF, CNPJ, e-mail, telefone e endereço dos
+// clientes, e-mail e telefones dos contatos e as cópias guardadas nas mesclagens. Quem usa o repositório
+// continua recebendo os dados abertos. A busca por documento ou e-mail completo usa os índices cegos;
+// buscas parciais passam a considerar apenas os campos não cifrados.
+type RepositorioCifrado struct {
+	Repository
+	cifrador *cripto.Cifrador
+}
+
+// NewRepositorioCifrado cria o decorador de cifragem sobre o repositório
+func NewRepositorioCifrado(repo Repository, cifrador *cripto.Cifrador) *RepositorioCifrado {
+	return &RepositorioCifrado{
+		Repository: repo,
+		cifrador:   cifrador,
+	}
+}
+
+// Create cifra os dados pessoais e cadastra o cliente
+func (r *RepositorioCifrado) Create(c *Cliente) error {
+	cifrado, err := r.cifrarCliente(c)
+	if err != nil {
+		return err
+	}
+	if err := r.Repository.Create(cifrado); err != nil {
+		return err
+	}
+	c.ID, c.DataCriacao, c.Status = cifrado.ID, cifrado.DataCriacao, cifrado.Status
+	c.HistoricoStatus = cifrado.HistoricoStatus
+	c.IndiceDocumento, c.IndiceEmail = cifrado.IndiceDocumento, cifrado.IndiceEmail
+	return nil
+}
+
+// GetByID busca o cliente e decifra seus dados
+func (r *RepositorioCifrado) GetByID(id int, empresaID int) (*Cliente, error) {
+	c, err := r.Repository.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	return r.decifrarCliente(c)
+}
+
+// Update cifra os dados pessoais e atualiza o cliente
+func (r *RepositorioCifrado) Update(c *Cliente) error {
+	cifrado, err := r.cifrarCliente(c)
+	if err != nil {
+		return err
+	}
+	if err := r.Repository.Update(cifrado); err != nil {
+		return err
+	}
+	c.DataCriacao, c.HistoricoStatus = cifrado.DataCriacao, cifrado.HistoricoStatus
+	c.IndiceDocumento, c.IndiceEmail = cifrado.IndiceDocumento, cifrado.IndiceEmail
+	return nil
+}
+
+// List retorna os clientes com os dados decifrados
+func (r *RepositorioCifrado) List(empresaID int, limit, offset int, filtros ...campo.Filtro) ([]*Cliente, error) {
+	clientes, err := r.Repository.List(empresaID, limit, offset, filtros...)
+	if err != nil {
+		return nil, err
+	}
+	return r.decifrarClientes(clientes)
+}
+
+// Search busca por CPF, CNPJ ou e-mail completos pelos índices cegos; os demais termos seguem para o repositório
+func (r *RepositorioCifrado) Search(empresaID int, query string, limit, offset int, filtros ...campo.Filtro) ([]*Cliente, error) {
+	documento, email := r.indicesBusca(query)
+	if documento == "" && email == "" {
+		clientes, err := r.Repository.Search(empresaID, query, limit, offset, filtros...)
+		if err != nil {
+			return nil, err
+		}
+		return r.decifrarClientes(clientes)
+	}
+
+	todos, err := r.Repository.List(empresaID, 0, 0, filtros...)
+	if err != nil {
+		return nil, err
+	}
+	encontrados := make([]*Cliente, 0)
+	for _, c := range ordenarPorID(todos) {
+		if (documento != "" && c.IndiceDocumento == documento) || (email != "" && c.IndiceEmail == email) {
+			encontrados = append(encontrados, c)
+		}
+	}
+
+	if offset >= len(encontrados) {
+		return []*Cliente{}, nil
+	}
+	encontrados = encontrados[offset:]
+	if limit > 0 && limit < len(encontrados) {
+		encontrados = encontrados[:limit]
+	}
+	return r.decifrarClientes(encontrados)
+}
+
+// CreateContato cifra os dados do contato e o adiciona ao cliente
+func (r *RepositorioCifrado) CreateContato(c *Contato) error {
+	cifrado, err := r.cifrarContato(c)
+	if err != nil {
+		return err
+	}
+	if err := r.Repository.CreateContato(cifrado); err != nil {
+		return err
+	}
+	c.ID, c.Principal = cifrado.ID, cifrado.Principal
+	c.DataCriacao, c.DataAtualizacao = cifrado.DataCriacao, cifrado.DataAtualizacao
+	return nil
+}
+
+// GetContato busca o contato e decifra seus dados
+func (r *RepositorioCifrado) GetContato(id, clienteID, empresaID int) (*Contato, error) {
+	c, err := r.Repository.GetContato(id, clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	return r.decifrarContato(c)
+}
+
+// UpdateContato cifra os dados do contato e o atualiza
+func (r *RepositorioCifrado) UpdateContato(c *Contato) error {
+	cifrado, err := r.cifrarContato(c)
+	if err != nil {
+		return err
+	}
+	if err := r.Repository.UpdateContato(cifrado); err != nil {
+		return err
+	}
+	c.DataCriacao, c.DataAtualizacao = cifrado.DataCriacao, cifrado.DataAtualizacao
+	return nil
+}
+
+// ListContatos retorna os contatos com os dados decifrados
+func (r *RepositorioCifrado) ListContatos(clienteID, empresaID int) ([]*Contato, error) {
+	contatos, err := r.Repository.ListContatos(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Contato, 0, len(contatos))
+	for _, c := range contatos {
+		aberto, err := r.decifrarContato(c)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, aberto)
+	}
+	return result, nil
+}
+
+// CreateMesclagem cifra as cópias dos cadastros e registra a mesclagem
+func (r *RepositorioCifrado) CreateMesclagem(m *Mesclagem) error {
+	cifrada, err := r.cifrarMesclagem(m)
+	if err != nil {
+		return err
+	}
+	if err := r.Repository.CreateMesclagem(cifrada); err != nil {
+		return err
+	}
+	m.ID, m.Data = cifrada.ID, cifrada.Data
+	return nil
+}
+
+// ListMesclagens retorna as mesclagens com as cópias decifradas
+func (r *RepositorioCifrado) ListMesclagens(clienteID, empresaID int) ([]*Mesclagem, error) {
+	mesclagens, err := r.Repository.ListMesclagens(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*Mesclagem, 0, len(mesclagens))
+	for _, m := range mesclagens {
+		aberta := *m
+		if m.Anterior != nil {
+			if aberta.Anterior, err = r.decifrarCliente(m.Anterior); err != nil {
+				return nil, err
+			}
+		}
+		if m.Mesclado != nil {
+			if aberta.Mesclado, err = r.decifrarCliente(m.Mesclado); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, &aberta)
+	}
+	return result, nil
+}
+
+// Recifrar regrava os clientes, contatos e mesclagens da empresa que ainda estão em texto puro ou cifrados
+// por uma chave mestra anterior, recalculando os índices. Retorna quantos registros foram regravados.
+// Depois de executado para todas as empresas, a chave mestra antiga pode ser retirada da configuração.
+func (r *RepositorioCifrado) Recifrar(empresaID int) (int, error) {
+	clientes, err := r.Repository.List(empresaID, 0, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for _, bruto := range clientes {
+		if !r.clienteAtualizado(bruto) {
+			aberto, err := r.decifrarCliente(bruto)
+			if err != nil {
+				return total, err
+			}
+			if err := r.Update(aberto); err != nil {
+				return total, err
+			}
+			total++
+		}
+
+		contatos, err := r.Repository.ListContatos(bruto.ID, empresaID)
+		if err != nil {
+			return total, err
+		}
+		for _, c := range contatos {
+			if r.cifrador.Atualizado(c.Email) && r.cifrador.Atualizado(c.Telefone) && r.cifrador.Atualizado(c.Celular) {
+				continue
+			}
+			aberto, err := r.decifrarContato(c)
+			if err != nil {
+				return total, err
+			}
+			if err := r.UpdateContato(aberto); err != nil {
+				return total, err
+			}
+			total++
+		}
+
+		mesclagens, err := r.Repository.ListMesclagens(bruto.ID, empresaID)
+		if err != nil {
+			return total, err
+		}
+		for _, m := range mesclagens {
+			// Cada mesclagem aparece para o cliente mantido; o removido não está mais na listagem
+			if m.ClienteID != bruto.ID || (r.clienteAtualizado(m.Anterior) && r.clienteAtualizado(m.Mesclado)) {
+				continue
+			}
+			aberta := *m
+			if m.Anterior != nil {
+				if aberta.Anterior, err = r.decifrarCliente(m.Anterior); err != nil {
+					return total, err
+				}
+			}
+			if m.Mesclado != nil {
+				if aberta.Mesclado, err = r.decifrarCliente(m.Mesclado); err != nil {
+					return total, err
+				}
+			}
+			cifrada, err := r.cifrarMesclagem(&aberta)
+			if err != nil {
+				return total, err
+			}
+			if err := r.Repository.UpdateMesclagem(cifrada); err != nil {
+				return total, err
+			}
+			total++
+		}
+	}
+	return total, nil
+}
+
+// RotasCifragem retorna a sub-rota de rotação de chaves montada dentro das rotas de clientes.
+// Sem cifragem configurada (repo nil) nenhuma rota é adicionada.
+func RotasCifragem(repo *RepositorioCifrado) func(r chi.Router) {
+	return func(r chi.Router) {
+		if repo == nil {
+			return
+		}
+		r.With(auth.RequireRole("admin")).Post("/recifrar", func(w http.ResponseWriter, r *http.Request) {
+			user, ok := auth.FromContext(r.Context())
+			if !ok {
+				http.Error(w, "Não autorizado", http.StatusUnauthorized)
+				return
+			}
+
+			total, err := repo.Recifrar(user.Empresa)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]int{"recifrados": total})
+		})
+	}
+}
+
+func (r *RepositorioCifrado) cifrarCliente(c *Cliente) (*Cliente, error) {
+	cifrado := *c
+	cifrado.IndiceDocumento = r.cifrador.Indice(indiceDocumento, documentoCliente(c))
+	cifrado.IndiceEmail = r.cifrador.Indice(indiceEmail, normalizarEmail(c.Email))
+
+	campos := []struct {
+		nome  string
+		valor *string
+	}{
+		{"cliente.cpf", &cifrado.CPF},
+		{"cliente.cnpj", &cifrado.CNPJ},
+		{"cliente.email", &cifrado.Email},
+		{"cliente.telefone", &cifrado.Telefone},
+		{"cliente.endereco", &cifrado.Endereco},
+	}
+	for _, f := range campos {
+		valor, err := r.cifrador.Cifrar(f.nome, cripto.ContextoEmpresa(c.EmpresaID), *f.valor)
+		if err != nil {
+			return nil, err
+		}
+		*f.valor = valor
+	}
+	return &cifrado, nil
+}
+
+func (r *RepositorioCifrado) decifrarCliente(c *Cliente) (*Cliente, error) {
+	aberto := *c
+	campos := []struct {
+		nome  string
+		valor *string
+	}{
+		{"cliente.cpf", &aberto.CPF},
+		{"cliente.cnpj", &aberto.CNPJ},
+		{"cliente.email", &aberto.Email},
+		{"cliente.telefone", &aberto.Telefone},
+		{"cliente.endereco", &aberto.Endereco},
+	}
+	for _, f := range campos {
+		valor, err := r.cifrador.Decifrar(f.nome, cripto.ContextoEmpresa(c.EmpresaID), *f.valor)
+		if err != nil {
+			return nil, err
+		}
+		*f.valor = valor
+	}
+	return &aberto, nil
+}
+
+func (r *RepositorioCifrado) decifrarClientes(clientes []*Cliente) ([]*Cliente, error) {
+	result := make([]*Cliente, 0, len(clientes))
+	for _, c := range clientes {
+		aberto, err := r.decifrarCliente(c)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, aberto)
+	}
+	return result, nil
+}
+
+// clienteAtualizado indica se o cadastro gravado já está cifrado pela chave atual e indexado
+func (r *RepositorioCifrado) clienteAtualizado(c *Cliente) bool {
+	if c == nil || c.Anonimizado() {
+		return true
+	}
+	if c.IndiceEmail == "" && c.Email != "" {
+		return false
+	}
+	if c.IndiceDocumento == "" && (c.CPF != "" || c.CNPJ != "") {
+		return false
+	}
+	for _, valor := range []string{c.CPF, c.CNPJ, c.Email, c.Telefone, c.Endereco} {
+		if !r.cifrador.Atualizado(valor) {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *RepositorioCifrado) cifrarContato(c *Contato) (*Contato, error) {
+	cifrado := *c
+	contexto := cripto.ContextoEmpresa(c.EmpresaID)
+	var err error
+	if cifrado.Email, err = r.cifrador.Cifrar("contato.email", contexto, c.Email); err != nil {
+		return nil, err
+	}
+	if cifrado.Telefone, err = r.cifrador.Cifrar("contato.telefone", contexto, c.Telefone); err != nil {
+		return nil, err
+	}
+	if cifrado.Celular, err = r.cifrador.Cifrar("contato.celular", contexto, c.Celular); err != nil {
+		return nil, err
+	}
+	return &cifrado, nil
+}
+
+func (r *RepositorioCifrado) decifrarContato(c *Contato) (*Contato, error) {
+	aberto := *c
+	contexto := cripto.ContextoEmpresa(c.EmpresaID)
+	var err error
+	if aberto.Email, err = r.cifrador.Decifrar("contato.email", contexto, c.Email); err != nil {
+		return nil, err
+	}
+	if aberto.Telefone, err = r.cifrador.Decifrar("contato.telefone", contexto, c.Telefone); err != nil {
+		return nil, err
+	}
+	if aberto.Celular, err = r.cifrador.Decifrar("contato.celular", contexto, c.Celular); err != nil {
+		return nil, err
+	}
+	return &aberto, nil
+}
+
+func (r *RepositorioCifrado) cifrarMesclagem(m *Mesclagem) (*Mesclagem, error) {
+	cifrada := *m
+	var err error
+	if m.Anterior != nil {
+		if cifrada.Anterior, err = r.cifrarCliente(m.Anterior); err != nil {
+			return nil, err
+		}
+	}
+	if m.Mesclado != nil {
+		if cifrada.Mesclado, err = r.cifrarCliente(m.Mesclado); err != nil {
+			return nil, err
+		}
+	}
+	return &cifrada, nil
+}
+
+// indicesBusca calcula os índices de um termo que seja um CPF, CNPJ ou e-mail completo
+func (r *RepositorioCifrado) indicesBusca(query string) (documento, email string) {
+	query = strings.TrimSpace(query)
+	if strings.Contains(query, "@") {
+		return "", r.cifrador.Indice(indiceEmail, normalizarEmail(query))
+	}
+	if strings.Trim(query, "0123456789.-/ ") != "" {
+		return "", ""
+	}
+	if d := apenasDigitos(query); len(d) == 11 || len(d) == 14 {
+		return r.cifrador.Indice(indiceDocumento, d), ""
+	}
+	return "", ""
+}
+
+// documentoCliente retorna os dígitos do CPF ou, na falta dele, do CNPJ
+func documentoCliente(c *Cliente) string {
+	if d := apenasDigitos(c.CPF); d != "" {
+		return d
+	}
+	return apenasDigitos(c.CNPJ)
+}
+
+func normalizarEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,227 @@
+package cliente
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+)
+
+// chaveMestra gera uma chave mestra no formato id:base64 da configuração
+func chaveMestra(t *testing.T, id string) string {
+	t.Helper()
+	chave := make([]byte, cripto.TamanhoChave)
+	if _, err := rand.Read(chave); err != nil {
+		t.Fatal(err)
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(chave)
+}
+
+func novoCifrador(t *testing.T, chavesMestras string) *cripto.Cifrador {
+	t.Helper()
+	indice := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, cripto.TamanhoChave))
+	c, err := cripto.NewCifradorConfig(chavesMestras, indice)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// clienteCompleto retorna um cliente com todos os dados pessoais cifrados preenchidos
+func clienteCompleto() *Cliente {
+	return &Cliente{EmpresaID: 1, Nome: "Ana Souza", Email: "Ana@Acme.com", TipoPessoa: "fisica", CPF: "529.982.247-25",
+		Telefone: "11 98765-4321", Endereco: "Rua A, 10"}
+}
+
+// Os dados pessoais ficam cifrados no repositório e voltam abertos para quem usa o decorador
+func TestRepositorioCifrado(t *testing.T) {
+	bruto := NewMemoryRepository()
+	repo := NewRepositorioCifrado(bruto, novoCifrador(t, chaveMestra(t, "k1")))
+
+	c := clienteCompleto()
+	if err := repo.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.ID == 0 || c.CPF != "529.982.247-25" {
+		t.Errorf("cliente depois de criar: %+v", c)
+	}
+
+	gravado, _ := bruto.GetByID(c.ID, 1)
+	for _, valor := range []string{gravado.CPF, gravado.Email, gravado.Telefone, gravado.Endereco} {
+		if !cripto.Cifrado(valor) || strings.Contains(valor, "Ana") || strings.Contains(valor, "98765") {
+			t.Errorf("valor gravado em aberto: %q", valor)
+		}
+	}
+	if gravado.Nome != "Ana Souza" || gravado.IndiceDocumento == "" || gravado.IndiceEmail == "" {
+		t.Errorf("cadastro gravado: %+v", gravado)
+	}
+
+	aberto, err := repo.GetByID(c.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aberto.CPF != c.CPF || aberto.Email != c.Email || aberto.Telefone != c.Telefone || aberto.Endereco != c.Endereco {
+		t.Errorf("cadastro decifrado: %+v", aberto)
+	}
+
+	// O índice cego mantém a unicidade do documento com outra formatação
+	repetido := clienteCompleto()
+	repetido.CPF = "52998224725"
+	repetido.Email = "outra@acme.com"
+	if err := repo.Create(repetido); err == nil {
+		t.Error("CPF repetido aceito")
+	}
+
+	// O texto cifrado de uma empresa não é aceito no registro de outra
+	copia := *gravado
+	copia.ID, copia.EmpresaID = 0, 2
+	copia.IndiceDocumento, copia.IndiceEmail = "", ""
+	if err := bruto.Create(&copia); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.GetByID(copia.ID, 2); err == nil {
+		t.Error("valor cifrado decifrado no contexto de outra empresa")
+	}
+}
+
+func TestBuscaPorIndiceCego(t *testing.T) {
+	repo := NewRepositorioCifrado(NewMemoryRepository(), novoCifrador(t, chaveMestra(t, "k1")))
+	c := clienteCompleto()
+	if err := repo.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	outro := &Cliente{EmpresaID: 1, Nome: "Bia", Email: "bia@acme.com"}
+	if err := repo.Create(outro); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		busca    string
+		esperado int
+	}{
+		{"52998224725", c.ID},
+		{" 529.982.247-25 ", c.ID},
+		{"ANA@acme.com", c.ID},
+		{"Bia", outro.ID},
+		{"acme.com", 0}, // e-mail parcial não é encontrado nos campos cifrados
+		{"98765", 0},
+	}
+	for _, tt := range tests {
+		encontrados, err := repo.Search(1, tt.busca, 0, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.esperado == 0 {
+			if len(encontrados) != 0 {
+				t.Errorf("%q: %d clientes encontrados, esperado nenhum", tt.busca, len(encontrados))
+			}
+			continue
+		}
+		if len(encontrados) != 1 || encontrados[0].ID != tt.esperado || encontrados[0].Email == "" || cripto.Cifrado(encontrados[0].Email) {
+			t.Errorf("%q: %+v, esperado o cliente %d decifrado", tt.busca, encontrados, tt.esperado)
+		}
+	}
+	if encontrados, _ := repo.Search(2, "52998224725", 0, 0); len(encontrados) != 0 {
+		t.Error("cliente encontrado pela busca de outra empresa")
+	}
+}
+
+// Contatos e as cópias guardadas nas mesclagens também ficam cifrados
+func TestContatosEMesclagensCifrados(t *testing.T) {
+	bruto := NewMemoryRepository()
+	repo := NewRepositorioCifrado(bruto, novoCifrador(t, chaveMestra(t, "k1")))
+	s := NewService(repo, nil, campo.NewService(campo.NewMemoryRepository()), time.Hour)
+
+	mantido := &Cliente{EmpresaID: 1, Nome: "Ana Souza", Email: "ana@acme.com"}
+	duplicado := clienteCompleto()
+	duplicado.Email = "ana.souza@gmail.com"
+	for _, c := range []*Cliente{mantido, duplicado} {
+		if err := s.Criar(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	contato := &Contato{EmpresaID: 1, ClienteID: duplicado.ID, Nome: "Financeiro", Email: "fin@acme.com", Celular: "11 91234-5678"}
+	if err := repo.CreateContato(contato); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Mesclar(1, mantido.ID, duplicado.ID, 7); err != nil {
+		t.Fatal(err)
+	}
+
+	contatos, _ := bruto.ListContatos(mantido.ID, 1)
+	if len(contatos) != 1 || !cripto.Cifrado(contatos[0].Email) || !cripto.Cifrado(contatos[0].Celular) {
+		t.Errorf("contato gravado: %+v", contatos)
+	}
+	if contatos, _ = repo.ListContatos(mantido.ID, 1); len(contatos) != 1 || contatos[0].Celular != "11 91234-5678" {
+		t.Errorf("contato decifrado: %+v", contatos)
+	}
+
+	gravadas, _ := bruto.ListMesclagens(mantido.ID, 1)
+	if len(gravadas) != 1 || !cripto.Cifrado(gravadas[0].Mesclado.CPF) || !cripto.Cifrado(gravadas[0].Anterior.Email) {
+		t.Fatalf("mesclagem gravada: %+v", gravadas)
+	}
+	abertas, _ := repo.ListMesclagens(mantido.ID, 1)
+	if len(abertas) != 1 || abertas[0].Mesclado.CPF != "529.982.247-25" || abertas[0].Anterior.Email != "ana@acme.com" {
+		t.Errorf("mesclagem decifrada: %+v", abertas)
+	}
+
+	c, _ := repo.GetByID(mantido.ID, 1)
+	if c.CPF != "529.982.247-25" || c.Telefone != "11 98765-4321" {
+		t.Errorf("cadastro mesclado: %+v", c)
+	}
+}
+
+// Recifrar cifra os registros em texto puro e os cifrados por uma chave mestra anterior;
+// depois disso a chave antiga pode ser retirada
+func TestRecifrar(t *testing.T) {
+	bruto := NewMemoryRepository()
+	k1, k2 := chaveMestra(t, "k1"), chaveMestra(t, "k2")
+
+	// Um cadastro anterior à cifragem e outro cifrado pela chave k1
+	puro := clienteCompleto()
+	if err := bruto.Create(puro); err != nil {
+		t.Fatal(err)
+	}
+	if err := bruto.CreateContato(&Contato{EmpresaID: 1, ClienteID: puro.ID, Nome: "Bia", Email: "bia@acme.com"}); err != nil {
+		t.Fatal(err)
+	}
+	antigo := &Cliente{EmpresaID: 1, Nome: "Caio", Email: "caio@acme.com", Telefone: "11 3333-4444"}
+	if err := NewRepositorioCifrado(bruto, novoCifrador(t, k1)).Create(antigo); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewRepositorioCifrado(bruto, novoCifrador(t, k2+","+k1))
+	total, err := repo.Recifrar(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if total != 3 {
+		t.Errorf("%d registros recifrados, esperado 3", total)
+	}
+	if total, _ = repo.Recifrar(1); total != 0 {
+		t.Errorf("%d registros recifrados na segunda execução", total)
+	}
+
+	for _, id := range []int{puro.ID, antigo.ID} {
+		gravado, _ := bruto.GetByID(id, 1)
+		if !strings.HasPrefix(gravado.Email, "enc:v2:k2:") || !strings.HasPrefix(gravado.Telefone, "enc:v2:k2:") || gravado.IndiceEmail == "" {
+			t.Errorf("cliente %d depois de recifrar: %+v", id, gravado)
+		}
+	}
+
+	semChaveAntiga := NewRepositorioCifrado(bruto, novoCifrador(t, k2))
+	if c, err := semChaveAntiga.GetByID(antigo.ID, 1); err != nil || c.Email != "caio@acme.com" {
+		t.Errorf("cliente decifrado sem a chave antiga: %+v, %v", c, err)
+	}
+	if encontrados, _ := semChaveAntiga.Search(1, "529.982.247-25", 0, 0); len(encontrados) != 1 {
+		t.Error("cadastro em texto puro não foi indexado")
+	}
+	if contatos, err := semChaveAntiga.ListContatos(puro.ID, 1); err != nil || len(contatos) != 1 || contatos[0].Email != "bia@acme.com" {
+		t.Errorf("contato depois de recifrar: %+v, %v", contatos, err)
+	}
+}
@@ -0,0 +1,279 @@
+package cliente
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/pkg/cep"
+	"github.com/go-chi/chi/v5"
+)
+
+// ConsultarCEP retorna o endereço correspondente ao CEP
+func (h *Handlers) ConsultarCEP(w http.ResponseWriter, r *http.Request) {
+	endereco, err := h.service.ConsultarCEP(r.Context(), chi.URLParam(r, "cep"))
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, cep.ErrNaoEncontrado) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endereco)
+}
+
+// ListContatos lista os contatos do cliente
+func (h *Handlers) ListContatos(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	contatos, err := h.repo.ListContatos(clienteID, empresaID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contatos)
+}
+
+// GetContato retorna um contato do cliente
+func (h *Handlers) GetContato(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "contatoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	contato, err := h.repo.GetContato(id, clienteID, empresaID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contato)
+}
+
+// CreateContato adiciona um contato ao cliente
+func (h *Handlers) CreateContato(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	var c Contato
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.ClienteID = clienteID
+	c.EmpresaID = empresaID
+	if err := h.service.CriarContato(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// UpdateContato atualiza um contato do cliente
+func (h *Handlers) UpdateContato(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "contatoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var c Contato
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.ID = id
+	c.ClienteID = clienteID
+	c.EmpresaID = empresaID
+	if err := h.service.AtualizarContato(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// DeleteContato remove um contato do cliente
+func (h *Handlers) DeleteContato(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "contatoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteContato(id, clienteID, empresaID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListEnderecos lista os endereços do cliente. Filtro: tipo.
+func (h *Handlers) ListEnderecos(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	enderecos, err := h.repo.ListEnderecos(clienteID, empresaID, r.URL.Query().Get("tipo"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(enderecos)
+}
+
+// GetEndereco retorna um endereço do cliente
+func (h *Handlers) GetEndereco(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "enderecoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	endereco, err := h.repo.GetEndereco(id, clienteID, empresaID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(endereco)
+}
+
+// CreateEndereco adiciona um endereço ao cliente, completando pelo CEP os campos omitidos
+func (h *Handlers) CreateEndereco(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	var e Endereco
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	e.ClienteID = clienteID
+	e.EmpresaID = empresaID
+	if err := h.service.CriarEndereco(r.Context(), &e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(e)
+}
+
+// UpdateEndereco atualiza um endereço do cliente
+func (h *Handlers) UpdateEndereco(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "enderecoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var e Endereco
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	e.ID = id
+	e.ClienteID = clienteID
+	e.EmpresaID = empresaID
+	if err := h.service.AtualizarEndereco(r.Context(), &e); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+// DeleteEndereco remove um endereço do cliente
+func (h *Handlers) DeleteEndereco(w http.ResponseWriter, r *http.Request) {
+	empresaID, clienteID, ok := parametrosCliente(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "enderecoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteEndereco(id, clienteID, empresaID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parametrosCliente lê a empresa do usuário e o ID do cliente da URL
+func parametrosCliente(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+
+	clienteID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return 0, 0, false
+	}
+
+	return user.Empresa, clienteID, true
+}
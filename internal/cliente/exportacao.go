@@ -0,0 +1,86 @@
+package cliente
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Exportar grava em CSV (separado por ponto e vírgula, compatível com planilhas em português)
+// os clientes que atendem ao filtro, com uma coluna para cada campo personalizado preenchido
+func (s *Service) Exportar(w io.Writer, f FiltroClientes) error {
+	clientes, err := s.Listar(f, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	chaves := make(map[string]bool)
+	for _, c := range clientes {
+		for chave := range c.Campos {
+			chaves[chave] = true
+		}
+	}
+	campos := make([]string, 0, len(chaves))
+	for chave := range chaves {
+		campos = append(campos, chave)
+	}
+	sort.Strings(campos)
+
+	// BOM para que planilhas reconheçam o UTF-8
+	if _, err := io.WriteString(w, "\uFEFF"); err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+
+	cabecalho := []string{"id", "nome", "tipo_pessoa", "cnpj", "cpf", "email", "telefone", "endereco",
+		"status", "tags", "data_criacao", "ultima_compra"}
+	for _, chave := range campos {
+		cabecalho = append(cabecalho, "campo."+chave)
+	}
+	if err := cw.Write(cabecalho); err != nil {
+		return err
+	}
+
+	for _, c := range clientes {
+		status := "inativo"
+		if c.Status {
+			status = "ativo"
+		}
+		linha := []string{strconv.Itoa(c.ID), c.Nome, c.TipoPessoa, c.CNPJ, c.CPF, c.Email, c.Telefone, c.Endereco,
+			status, strings.Join(c.Tags, ", "), formatarData(c.DataCriacao), formatarData(c.UltimaCompra)}
+		for _, chave := range campos {
+			linha = append(linha, formatarValor(c.Campos[chave]))
+		}
+		if err := cw.Write(linha); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatarData(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
+
+func formatarValor(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		return strings.Join(v, ", ")
+	}
+	return fmt.Sprint(v)
+}
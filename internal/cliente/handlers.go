@@ -1,30 +1,36 @@
 package cliente
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/campo"
 	"github.com/go-chi/chi/v5"
 )
 
 // Handlers contém os manipuladores HTTP para clientes
 type Handlers struct {
-	repo Repository
+	repo    Repository
+	service *Service
 }
 
 // NewHandlers cria uma nova instância de Handlers
-func NewHandlers(repo Repository) *Handlers {
+func NewHandlers(repo Repository, service *Service) *Handlers {
 	return &Handlers{
-		repo: repo,
+		repo:    repo,
+		service: service,
 	}
 }
 
-// Routes retorna as rotas para clientes
-func Routes() http.Handler {
-	repo := NewMemoryRepository()
-	h := NewHandlers(repo)
+// Routes retorna as rotas para clientes.
+// Subrotas permite que outros módulos registrem rotas aninhadas, como /{id}/timeline.
+func Routes(repo Repository, service *Service, subrotas ...func(r chi.Router)) http.Handler {
+	h := NewHandlers(repo, service)
 
 	r := chi.NewRouter()
 	// Middleware de autenticação
@@ -37,10 +43,42 @@ func Routes() http.Handler {
 	r.Put("/{id}", h.Update)
 	r.Delete("/{id}", h.Delete)
 
+	r.Get("/cep/{cep}", h.ConsultarCEP)
+	r.Get("/exportar", h.Exportar)
+	r.Get("/tags", h.Tags)
+	r.Get("/duplicados", h.Duplicados)
+	r.Post("/{id}/mesclar", h.Mesclar)
+	r.Get("/{id}/mesclagens", h.Mesclagens)
+
+	r.Get("/segmentos", h.ListSegmentos)
+	r.Post("/segmentos", h.SaveSegmento)
+	r.Post("/segmentos/previa", h.PreviaSegmento)
+	r.Get("/segmentos/{segmentoID}", h.GetSegmento)
+	r.Put("/segmentos/{segmentoID}", h.SaveSegmento)
+	r.Delete("/segmentos/{segmentoID}", h.DeleteSegmento)
+	r.Get("/segmentos/{segmentoID}/clientes", h.MembrosSegmento)
+
+	r.Get("/{id}/contatos", h.ListContatos)
+	r.Post("/{id}/contatos", h.CreateContato)
+	r.Get("/{id}/contatos/{contatoID}", h.GetContato)
+	r.Put("/{id}/contatos/{contatoID}", h.UpdateContato)
+	r.Delete("/{id}/contatos/{contatoID}", h.DeleteContato)
+
+	r.Get("/{id}/enderecos", h.ListEnderecos)
+	r.Post("/{id}/enderecos", h.CreateEndereco)
+	r.Get("/{id}/enderecos/{enderecoID}", h.GetEndereco)
+	r.Put("/{id}/enderecos/{enderecoID}", h.UpdateEndereco)
+	r.Delete("/{id}/enderecos/{enderecoID}", h.DeleteEndereco)
+
+	for _, registrar := range subrotas {
+		registrar(r)
+	}
+
 	return r
 }
 
-// List lista todos os clientes da empresa do usuário atual
+// List lista os clientes da empresa do usuário atual.
+// Filtros: tag, segmento (ID), campo.<chave>, campo.<chave>.min e campo.<chave>.max.
 func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
 	user, ok := auth.FromContext(r.Context())
 	if !ok {
@@ -55,7 +93,9 @@ func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
 		limit = 100 // valor padrão
 	}
 
-	clientes, err := h.repo.List(user.Empresa, limit, offset)
+	f := filtroClientes(r, user.Empresa)
+	f.Busca = ""
+	clientes, err := h.service.Listar(f, limit, offset)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -106,7 +146,7 @@ func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
 	// Garantir que o cliente seja associado à empresa do usuário
 	c.EmpresaID = user.Empresa
 
-	if err := h.repo.Create(&c); err != nil {
+	if err := h.service.Criar(&c); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -140,7 +180,7 @@ func (h *Handlers) Update(w http.ResponseWriter, r *http.Request) {
 	c.ID = id
 	c.EmpresaID = user.Empresa
 
-	if err := h.repo.Update(&c); err != nil {
+	if err := h.service.Atualizar(&c); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -163,7 +203,7 @@ func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.repo.Delete(id, user.Empresa); err != nil {
+	if err := h.service.Excluir(id, user.Empresa); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -171,7 +211,7 @@ func (h *Handlers) Delete(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// Search busca clientes por termo
+// Search busca clientes por termo, aceitando os mesmos filtros de List
 func (h *Handlers) Search(w http.ResponseWriter, r *http.Request) {
 	user, ok := auth.FromContext(r.Context())
 	if !ok {
@@ -192,12 +232,303 @@ func (h *Handlers) Search(w http.ResponseWriter, r *http.Request) {
 		limit = 100 // valor padrão
 	}
 
-	clientes, err := h.repo.Search(user.Empresa, query, limit, offset)
+	clientes, err := h.service.Listar(filtroClientes(r, user.Empresa), limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(clientes)
+}
+
+// Exportar gera um CSV com os clientes, aceitando os mesmos filtros de Search (q opcional)
+func (h *Handlers) Exportar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.service.Exportar(&buf, filtroClientes(r, user.Empresa)); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"clientes-%s.csv\"", time.Now().Format("20060102")))
+	w.Write(buf.Bytes())
+}
+
+// Tags lista as tags usadas pelos clientes da empresa
+func (h *Handlers) Tags(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	tags, err := h.service.Tags(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tags)
+}
+
+// Duplicados lista os pares de clientes que provavelmente são o mesmo cadastro.
+// Filtros: minimo (pontuação de 1 a 100) e cliente_id.
+func (h *Handlers) Duplicados(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	minimo, _ := strconv.Atoi(r.URL.Query().Get("minimo"))
+	clienteID, _ := strconv.Atoi(r.URL.Query().Get("cliente_id"))
+
+	if minimo <= 0 {
+		minimo = PontuacaoMinimaDuplicidade // valor padrão
+	}
+
+	duplicados, err := h.service.Duplicados(user.Empresa, clienteID, minimo)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(duplicados)
+}
+
+// Mesclar incorpora o cliente informado em duplicado_id ao cliente da rota, que é mantido
+func (h *Handlers) Mesclar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var in struct {
+		DuplicadoID int `json:"duplicado_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.service.Mesclar(user.Empresa, id, in.DuplicadoID, user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// Mesclagens lista o histórico de mesclagens do cliente
+func (h *Handlers) Mesclagens(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	mesclagens, err := h.repo.ListMesclagens(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mesclagens)
+}
+
+// ListSegmentos lista os segmentos salvos da empresa
+func (h *Handlers) ListSegmentos(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	segmentos, err := h.repo.ListSegmentos(user.Empresa)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(segmentos)
+}
+
+// GetSegmento retorna um segmento por ID
+func (h *Handlers) GetSegmento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "segmentoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	seg, err := h.repo.GetSegmento(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(seg)
+}
+
+// SaveSegmento cria ou atualiza um segmento
+func (h *Handlers) SaveSegmento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var seg Segmento
+	if err := json.NewDecoder(r.Body).Decode(&seg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	seg.ID = 0
+	if idParam := chi.URLParam(r, "segmentoID"); idParam != "" {
+		id, err := strconv.Atoi(idParam)
+		if err != nil {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return
+		}
+		seg.ID = id
+	}
+	seg.EmpresaID = user.Empresa
+
+	criando := seg.ID == 0
+	if err := h.service.SalvarSegmento(&seg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if criando {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(seg)
+}
+
+// DeleteSegmento remove um segmento
+func (h *Handlers) DeleteSegmento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "segmentoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.ExcluirSegmento(id, user.Empresa); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PreviaSegmento avalia uma regra sem salvá-la, retornando o total e até 20 clientes
+func (h *Handlers) PreviaSegmento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in struct {
+		Regra string `json:"regra"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	previa, err := h.service.Previa(user.Empresa, in.Regra, 20)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(previa)
+}
+
+// MembrosSegmento lista os clientes do segmento
+func (h *Handlers) MembrosSegmento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "segmentoID"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+
+	clientes, err := h.service.Listar(FiltroClientes{EmpresaID: user.Empresa, SegmentoID: id}, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(clientes)
 }
+
+// filtroClientes lê da query string os filtros q, tag, segmento e campo.<chave>
+func filtroClientes(r *http.Request, empresaID int) FiltroClientes {
+	q := r.URL.Query()
+	segmentoID, _ := strconv.Atoi(q.Get("segmento"))
+	return FiltroClientes{
+		EmpresaID:  empresaID,
+		Busca:      q.Get("q"),
+		Tag:        q.Get("tag"),
+		SegmentoID: segmentoID,
+		Campos:     campo.FiltrosDaQuery(q),
+	}
+}
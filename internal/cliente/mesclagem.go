@@ -0,0 +1,335 @@
+package cliente
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Pesos de cada indício na pontuação de duplicidade; a soma é limitada a 100
+const (
+	pesoDocumento      = 100
+	pesoEmail          = 60
+	pesoTelefone       = 40
+	pesoNomeIgual      = 40
+	pesoNomeSemelhante = 30
+
+	// PontuacaoMinimaDuplicidade é o padrão do relatório: nome parecido sozinho não basta,
+	// pois homônimos são comuns; nome e telefone, e-mail ou documento sim
+	PontuacaoMinimaDuplicidade = 60
+
+	// similaridadeMinimaNome é a proporção mínima de caracteres iguais para nomes semelhantes
+	similaridadeMinimaNome = 0.85
+)
+
+// sufixosNome são termos de razão social ignorados na comparação de nomes
+var sufixosNome = map[string]bool{
+	"ltda": true, "me": true, "epp": true, "eireli": true, "sa": true,
+	"s/a": true, "cia": true, "mei": true, "ss": true,
+}
+
+// semAcento troca as letras acentuadas do português pela letra base
+var semAcento = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "î", "i", "ì", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "û", "u", "ù", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Vincular registra um módulo cujos registros devem acompanhar o cliente na mesclagem.
+// Deve ser chamado na configuração da aplicação, antes de atender requisições.
+func (s *Service) Vincular(entidade string, v Vinculo) {
+	s.vinculos[entidade] = v
+}
+
+// Duplicados compara os clientes da empresa e retorna os pares com pontuação a partir de minimo,
+// do mais provável para o menos provável. Com clienteID, compara apenas esse cliente com os demais.
+func (s *Service) Duplicados(empresaID, clienteID, minimo int) ([]Duplicidade, error) {
+	clientes, err := s.repo.List(empresaID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	// Cadastros anonimizados compartilham o mesmo nome e não devem ser apontados
+	ativos := make([]*Cliente, 0, len(clientes))
+	for _, c := range clientes {
+		if !c.Anonimizado() {
+			ativos = append(ativos, c)
+		}
+	}
+	clientes = ordenarPorID(ativos)
+
+	chaves := make([]chavesDuplicidade, len(clientes))
+	for i, c := range clientes {
+		chaves[i] = novaChave(c)
+	}
+
+	result := make([]Duplicidade, 0)
+	for i := range clientes {
+		for j := i + 1; j < len(clientes); j++ {
+			if clienteID > 0 && clientes[i].ID != clienteID && clientes[j].ID != clienteID {
+				continue
+			}
+			pontuacao, motivos := comparar(chaves[i], chaves[j])
+			if pontuacao < minimo || pontuacao == 0 {
+				continue
+			}
+			result = append(result, Duplicidade{
+				Cliente:   clientes[i],
+				Duplicado: clientes[j],
+				Pontuacao: pontuacao,
+				Motivos:   motivos,
+			})
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Pontuacao > result[j].Pontuacao
+	})
+	return result, nil
+}
+
+// Mesclar incorpora o cliente duplicadoID ao cliente clienteID: completa o cadastro mantido com os dados
+// que faltam, reaponta contatos, endereços e os registros dos módulos vinculados, remove o duplicado e
+// registra a operação. Se um módulo falhar, o duplicado é mantido e a mesclagem pode ser repetida.
+func (s *Service) Mesclar(empresaID, clienteID, duplicadoID, usuarioID int) (*Mesclagem, error) {
+	if clienteID == duplicadoID {
+		return nil, errors.New("um cliente não pode ser mesclado com ele mesmo")
+	}
+
+	mantido, err := s.repo.GetByID(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	duplicado, err := s.repo.GetByID(duplicadoID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if mantido.Anonimizado() || duplicado.Anonimizado() {
+		return nil, errors.New("clientes anonimizados não podem ser mesclados")
+	}
+
+	anterior := *mantido
+	removido := *duplicado
+	m := &Mesclagem{
+		EmpresaID:    empresaID,
+		ClienteID:    clienteID,
+		MescladoID:   duplicadoID,
+		UsuarioID:    usuarioID,
+		Anterior:     &anterior,
+		Mesclado:     &removido,
+		Transferidos: make(map[string]int),
+	}
+
+	total, err := s.repo.TransferirCliente(empresaID, duplicadoID, clienteID)
+	if err != nil {
+		return nil, fmt.Errorf("transferência de contatos e endereços: %v", err)
+	}
+	m.Transferidos["contatos_enderecos"] = total
+
+	entidades := make([]string, 0, len(s.vinculos))
+	for entidade := range s.vinculos {
+		entidades = append(entidades, entidade)
+	}
+	sort.Strings(entidades)
+	for _, entidade := range entidades {
+		total, err := s.vinculos[entidade].TransferirCliente(empresaID, duplicadoID, clienteID)
+		if err != nil {
+			return nil, fmt.Errorf("transferência de %s: %v", entidade, err)
+		}
+		m.Transferidos[entidade] = total
+	}
+
+	// O duplicado sai antes da atualização para que seu documento possa passar ao cliente mantido
+	if err := s.repo.Delete(duplicadoID, empresaID); err != nil {
+		return nil, err
+	}
+	mesclado := anterior
+	completarCliente(&mesclado, &removido)
+	if err := s.repo.Update(&mesclado); err != nil {
+		return nil, err
+	}
+	s.invalidarSegmentos(empresaID)
+
+	m.Data = time.Now()
+	if err := s.repo.CreateMesclagem(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// completarCliente preenche o cadastro mantido com o que só o duplicado possui
+func completarCliente(c, d *Cliente) {
+	completar(&c.CNPJ, d.CNPJ)
+	completar(&c.CPF, d.CPF)
+	completar(&c.Email, d.Email)
+	completar(&c.Telefone, d.Telefone)
+	completar(&c.Endereco, d.Endereco)
+	completar(&c.TipoPessoa, d.TipoPessoa)
+
+	if d.Observacoes != "" && d.Observacoes != c.Observacoes {
+		if c.Observacoes == "" {
+			c.Observacoes = d.Observacoes
+		} else {
+			c.Observacoes += "\n" + d.Observacoes
+		}
+	}
+	if d.UltimaCompra.After(c.UltimaCompra) {
+		c.UltimaCompra = d.UltimaCompra
+	}
+	c.Status = c.Status || d.Status
+
+	tags := append(append([]string{}, c.Tags...), d.Tags...)
+	c.Tags = nil
+	for _, t := range tags {
+		if !possuiTag(c, t) {
+			c.Tags = append(c.Tags, t)
+		}
+	}
+	sort.Strings(c.Tags)
+
+	if len(d.Campos) > 0 {
+		campos := make(map[string]interface{}, len(c.Campos)+len(d.Campos))
+		for k, v := range d.Campos {
+			campos[k] = v
+		}
+		for k, v := range c.Campos {
+			campos[k] = v
+		}
+		c.Campos = campos
+	}
+}
+
+// chavesDuplicidade guarda os dados normalizados usados na comparação
+type chavesDuplicidade struct {
+	cpf      string
+	cnpj     string
+	email    string
+	telefone string
+	nome     string
+}
+
+func novaChave(c *Cliente) chavesDuplicidade {
+	return chavesDuplicidade{
+		cpf:      apenasDigitos(c.CPF),
+		cnpj:     apenasDigitos(c.CNPJ),
+		email:    strings.ToLower(strings.TrimSpace(c.Email)),
+		telefone: normalizarTelefone(c.Telefone),
+		nome:     normalizarNome(c.Nome),
+	}
+}
+
+// comparar pontua a semelhança entre dois cadastros
+func comparar(a, b chavesDuplicidade) (int, []string) {
+	pontuacao := 0
+	motivos := make([]string, 0)
+
+	if (a.cpf != "" && a.cpf == b.cpf) || (a.cnpj != "" && a.cnpj == b.cnpj) {
+		pontuacao += pesoDocumento
+		motivos = append(motivos, "documento")
+	}
+	if a.email != "" && a.email == b.email {
+		pontuacao += pesoEmail
+		motivos = append(motivos, "email")
+	}
+	if a.telefone != "" && a.telefone == b.telefone {
+		pontuacao += pesoTelefone
+		motivos = append(motivos, "telefone")
+	}
+	if a.nome != "" && b.nome != "" {
+		if a.nome == b.nome {
+			pontuacao += pesoNomeIgual
+			motivos = append(motivos, "nome")
+		} else if similaridade(a.nome, b.nome) >= similaridadeMinimaNome {
+			pontuacao += pesoNomeSemelhante
+			motivos = append(motivos, "nome semelhante")
+		}
+	}
+
+	if pontuacao > 100 {
+		pontuacao = 100
+	}
+	return pontuacao, motivos
+}
+
+// normalizarNome remove acentos, pontuação, termos de razão social e a ordem das palavras
+func normalizarNome(nome string) string {
+	nome = semAcento.Replace(strings.ToLower(nome))
+	palavras := strings.FieldsFunc(nome, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '/'
+	})
+
+	result := make([]string, 0, len(palavras))
+	for _, p := range palavras {
+		p = strings.Trim(p, "/")
+		if p == "" || sufixosNome[p] {
+			continue
+		}
+		result = append(result, p)
+	}
+	sort.Strings(result)
+	return strings.Join(result, " ")
+}
+
+// normalizarTelefone mantém DDD e número, sem código do país nem zero de discagem
+func normalizarTelefone(telefone string) string {
+	d := apenasDigitos(telefone)
+	if len(d) >= 12 && strings.HasPrefix(d, "55") {
+		d = d[2:]
+	}
+	d = strings.TrimLeft(d, "0")
+	if len(d) < 8 {
+		return ""
+	}
+	return d
+}
+
+func apenasDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// similaridade retorna 1 menos a distância de edição proporcional ao maior nome
+func similaridade(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	maior := len(ra)
+	if len(rb) > maior {
+		maior = len(rb)
+	}
+	diferenca := len(ra) - len(rb)
+	if diferenca < 0 {
+		diferenca = -diferenca
+	}
+	// A diferença de tamanho já é o mínimo de edições necessárias
+	if float64(diferenca) > float64(maior)*(1-similaridadeMinimaNome) {
+		return 0
+	}
+
+	anterior := make([]int, len(rb)+1)
+	atual := make([]int, len(rb)+1)
+	for j := range anterior {
+		anterior[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		atual[0] = i
+		for j := 1; j <= len(rb); j++ {
+			custo := 1
+			if ra[i-1] == rb[j-1] {
+				custo = 0
+			}
+			atual[j] = min(anterior[j]+1, atual[j-1]+1, anterior[j-1]+custo)
+		}
+		anterior, atual = atual, anterior
+	}
+	return 1 - float64(anterior[len(rb)])/float64(maior)
+}
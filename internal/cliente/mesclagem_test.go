@@ -0,0 +1,162 @@
+package cliente
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompararDuplicidade(t *testing.T) {
+	tests := []struct {
+		nome      string
+		a, b      Cliente
+		pontuacao int
+		motivos   string
+	}{
+		{"mesmo CPF com outra formatação", Cliente{CPF: "529.982.247-25"}, Cliente{CPF: "52998224725"}, 100, "documento"},
+		{"e-mail e telefone", Cliente{Email: "Ana@Acme.com ", Telefone: "+55 (11) 98765-4321"}, Cliente{Email: "ana@acme.com", Telefone: "011 98765-4321"}, 100, "email,telefone"},
+		{"razão social sem acento nem sufixo", Cliente{Nome: "Padaria São João Ltda"}, Cliente{Nome: "padaria sao joao"}, 40, "nome"},
+		{"palavras em outra ordem", Cliente{Nome: "Maria Silva"}, Cliente{Nome: "Silva, Maria"}, 40, "nome"},
+		{"nome semelhante", Cliente{Nome: "Joana Pereira Lima"}, Cliente{Nome: "Joana Pereyra Lima"}, 30, "nome semelhante"},
+		{"nome e telefone", Cliente{Nome: "Joana Pereira Lima", Telefone: "11 3333-4444"}, Cliente{Nome: "Joana Pereyra Lima", Telefone: "(11) 3333-4444"}, 70, "telefone,nome semelhante"},
+		{"telefone curto não conta", Cliente{Telefone: "190"}, Cliente{Telefone: "190"}, 0, ""},
+		{"nomes diferentes", Cliente{Nome: "Ana"}, Cliente{Nome: "Bia"}, 0, ""},
+	}
+	for _, tt := range tests {
+		pontuacao, motivos := comparar(novaChave(&tt.a), novaChave(&tt.b))
+		if pontuacao != tt.pontuacao || strings.Join(motivos, ",") != tt.motivos {
+			t.Errorf("%s: %d %v, esperado %d [%s]", tt.nome, pontuacao, motivos, tt.pontuacao, tt.motivos)
+		}
+	}
+}
+
+func TestDuplicados(t *testing.T) {
+	s, _ := novoServico(t)
+	ana := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana Souza", Email: "ana@acme.com"})
+	dupla := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana Sousa", Email: "ANA@acme.com"})
+	homonimo := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Bia Lima", Email: "bia@acme.com"})
+	criar(t, s, &Cliente{EmpresaID: 1, Nome: "Bia Lima", Email: "bia.lima@gmail.com"})
+	criar(t, s, &Cliente{EmpresaID: 2, Nome: "Ana Souza", Email: "ana@acme.com"})
+
+	pares, err := s.Duplicados(1, 0, PontuacaoMinimaDuplicidade)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pares) != 1 || pares[0].Cliente.ID != ana.ID || pares[0].Duplicado.ID != dupla.ID || pares[0].Pontuacao != 90 {
+		t.Fatalf("pares: %+v", pares)
+	}
+
+	// Homônimos só aparecem abaixo da pontuação mínima
+	if pares, _ = s.Duplicados(1, homonimo.ID, 1); len(pares) != 1 || pares[0].Pontuacao != pesoNomeIgual {
+		t.Errorf("pares do homônimo: %+v", pares)
+	}
+
+	// Cadastros anonimizados não são apontados
+	if err := s.Anonimizar(dupla.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+	if pares, _ = s.Duplicados(1, ana.ID, 1); len(pares) != 0 {
+		t.Errorf("pares depois de anonimizar: %+v", pares)
+	}
+}
+
+// vinculoTeste registra as transferências pedidas pela mesclagem
+type vinculoTeste struct {
+	falha         error
+	de, para, qtd int
+}
+
+func (v *vinculoTeste) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	if v.falha != nil {
+		return 0, v.falha
+	}
+	v.de, v.para = deID, paraID
+	return v.qtd, nil
+}
+
+func TestMesclar(t *testing.T) {
+	s, repo := novoServico(t)
+	pedidos := &vinculoTeste{qtd: 3}
+	s.Vincular("pedidos", pedidos)
+
+	mantido := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana Souza", Email: "ana@acme.com", Tags: []string{"vip"}, Observacoes: "antiga"})
+	duplicado := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana Sousa", Email: "ana.souza@gmail.com", CPF: "529.982.247-25",
+		Telefone: "11 98765-4321", Tags: []string{"sp", "vip"}, Observacoes: "nova"})
+	if err := repo.CreateContato(&Contato{EmpresaID: 1, ClienteID: duplicado.ID, Nome: "Financeiro"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := s.Mesclar(1, mantido.ID, duplicado.ID, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Transferidos["pedidos"] != 3 || m.Transferidos["contatos_enderecos"] != 1 || pedidos.de != duplicado.ID || pedidos.para != mantido.ID {
+		t.Errorf("transferências: %v, pedidos de %d para %d", m.Transferidos, pedidos.de, pedidos.para)
+	}
+	if m.Anterior.CPF != "" || m.Mesclado.ID != duplicado.ID || m.UsuarioID != 7 {
+		t.Errorf("registro da mesclagem: %+v", m)
+	}
+
+	if _, err := repo.GetByID(duplicado.ID, 1); err == nil {
+		t.Error("duplicado mantido depois da mesclagem")
+	}
+	c, err := repo.GetByID(mantido.ID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Email != "ana@acme.com" || c.CPF != "529.982.247-25" || c.Telefone != "11 98765-4321" ||
+		strings.Join(c.Tags, ",") != "sp,vip" || c.Observacoes != "antiga\nnova" {
+		t.Errorf("cadastro mesclado: %+v", c)
+	}
+	if contatos, _ := repo.ListContatos(mantido.ID, 1); len(contatos) != 1 {
+		t.Errorf("%d contatos no cliente mantido, esperado 1", len(contatos))
+	}
+	for _, id := range []int{mantido.ID, duplicado.ID} {
+		if lista, _ := repo.ListMesclagens(id, 1); len(lista) != 1 {
+			t.Errorf("cliente %d com %d mesclagens registradas", id, len(lista))
+		}
+	}
+}
+
+// Se um módulo vinculado falha, o duplicado é mantido para a mesclagem ser repetida
+func TestMesclarComFalha(t *testing.T) {
+	s, repo := novoServico(t)
+	s.Vincular("pedidos", &vinculoTeste{falha: errors.New("indisponível")})
+	mantido := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana", Email: "ana@acme.com"})
+	duplicado := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana", Email: "ana@gmail.com", CPF: "529.982.247-25"})
+
+	if _, err := s.Mesclar(1, mantido.ID, duplicado.ID, 7); err == nil {
+		t.Fatal("mesclagem concluída com falha no módulo")
+	}
+	if _, err := repo.GetByID(duplicado.ID, 1); err != nil {
+		t.Error("duplicado removido na mesclagem com falha")
+	}
+	if c, _ := repo.GetByID(mantido.ID, 1); c.CPF != "" {
+		t.Errorf("cliente mantido alterado: %+v", c)
+	}
+}
+
+func TestMesclarInvalido(t *testing.T) {
+	s, _ := novoServico(t)
+	ana := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana", Email: "ana@acme.com"})
+	bia := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Bia", Email: "bia@acme.com"})
+	alheio := criar(t, s, &Cliente{EmpresaID: 2, Nome: "Ana", Email: "ana@acme.com"})
+	anonimo := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Caio", Email: "caio@acme.com"})
+	if err := s.Anonimizar(anonimo.ID, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		nome                 string
+		clienteID, duplicado int
+	}{
+		{"com ele mesmo", ana.ID, ana.ID},
+		{"com cliente de outra empresa", ana.ID, alheio.ID},
+		{"com cliente anonimizado", bia.ID, anonimo.ID},
+	}
+	for _, tt := range tests {
+		if _, err := s.Mesclar(1, tt.clienteID, tt.duplicado, 7); err == nil {
+			t.Errorf("%s: mesclagem aceita", tt.nome)
+		}
+	}
+}
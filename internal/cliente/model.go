@@ -1,7 +1,10 @@
 package cliente
 
 import (
+	"fmt"
 	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
 )
 
 // Cliente representa um cliente no sistema
@@ -19,6 +22,181 @@ type Cliente struct {
 	Observacoes  string    `json:"observacoes,omitempty"`
 	UltimaCompra time.Time `json:"ultima_compra,omitempty"`
 	TipoPessoa   string    `json:"tipo_pessoa"` // "fisica" ou "juridica"
+
+	Tags   []string      `json:"tags,omitempty"`
+	Campos campo.Valores `json:"campos,omitempty"` // campos personalizados definidos pela empresa
+
+	DataAnonimizacao time.Time `json:"data_anonimizacao,omitempty"` // preenchida quando os dados pessoais foram eliminados
+
+	// HistoricoStatus registra as ativações e inativações, mantido pelo repositório
+	HistoricoStatus []MudancaStatus `json:"historico_status,omitempty"`
+
+	// Índices cegos dos campos cifrados em repouso, mantidos por RepositorioCifrado
+	IndiceDocumento string `json:"-"`
+	IndiceEmail     string `json:"-"`
+}
+
+// MudancaStatus registra que o cliente passou a ativo ou inativo na data
+type MudancaStatus struct {
+	Status bool      `json:"status"`
+	Data   time.Time `json:"data"`
+}
+
+// AtivoEm informa se o cliente estava ativo no instante, pelo histórico de status.
+// Cadastros sem histórico usam o status atual.
+func (c *Cliente) AtivoEm(t time.Time) bool {
+	if t.Before(c.DataCriacao) {
+		return false
+	}
+	if len(c.HistoricoStatus) == 0 {
+		return c.Status
+	}
+	ativo := !c.HistoricoStatus[0].Status
+	for _, m := range c.HistoricoStatus {
+		if m.Data.After(t) {
+			break
+		}
+		ativo = m.Status
+	}
+	return ativo
+}
+
+// registrarStatus acrescenta ao histórico a mudança em relação ao status anterior
+func (c *Cliente) registrarStatus(anterior []MudancaStatus, quando time.Time) {
+	c.HistoricoStatus = anterior
+	if n := len(anterior); n == 0 || anterior[n-1].Status != c.Status {
+		c.HistoricoStatus = append(append([]MudancaStatus(nil), anterior...), MudancaStatus{Status: c.Status, Data: quando})
+	}
+}
+
+// Anonimizado indica se os dados pessoais do cliente já foram eliminados
+func (c *Cliente) Anonimizado() bool {
+	return !c.DataAnonimizacao.IsZero()
+}
+
+// anonimizar descarta de forma irreversível os dados que identificam o titular.
+// O e-mail fictício mantém o cadastro válido; tipo de pessoa e datas seguem disponíveis para relatórios.
+func (c *Cliente) anonimizar(agora time.Time) {
+	c.Nome = "Titular anonimizado"
+	c.Email = fmt.Sprintf("anonimizado-%d@anonimizado.invalid", c.ID)
+	c.CNPJ = ""
+	c.CPF = ""
+	c.Telefone = ""
+	c.Endereco = ""
+	c.Observacoes = ""
+	c.Tags = nil
+	c.Campos = nil
+	c.Status = false
+	c.DataAnonimizacao = agora
+	c.IndiceDocumento = ""
+	c.IndiceEmail = ""
+}
+
+// chaveDocumento identifica o CPF ou CNPJ do cliente para a verificação de unicidade:
+// o índice cego quando os campos estão cifrados, senão os dígitos do documento
+func (c *Cliente) chaveDocumento() string {
+	if c.IndiceDocumento != "" {
+		return c.IndiceDocumento
+	}
+	return documentoCliente(c)
+}
+
+// Segmento agrupa dinamicamente os clientes que atendem a uma regra (ver CompilarRegra)
+type Segmento struct {
+	ID              int       `json:"id"`
+	EmpresaID       int       `json:"empresa_id"`
+	Nome            string    `json:"nome"`
+	Descricao       string    `json:"descricao,omitempty"`
+	Regra           string    `json:"regra"`
+	DataCriacao     time.Time `json:"data_criacao"`
+	DataAtualizacao time.Time `json:"data_atualizacao"`
+}
+
+// FiltroClientes reúne os filtros da listagem e da exportação de clientes; campos zerados não filtram
+type FiltroClientes struct {
+	EmpresaID  int
+	Busca      string
+	Tag        string
+	SegmentoID int
+	Campos     []campo.Filtro
+}
+
+// TotalTag informa quantos clientes usam uma tag
+type TotalTag struct {
+	Tag   string `json:"tag"`
+	Total int    `json:"total"`
+}
+
+// Duplicidade aponta dois clientes que provavelmente são o mesmo cadastro.
+// Cliente é o mais antigo, sugerido para ser mantido na mesclagem.
+type Duplicidade struct {
+	Cliente   *Cliente `json:"cliente"`
+	Duplicado *Cliente `json:"duplicado"`
+	Pontuacao int      `json:"pontuacao"` // de 0 a 100
+	Motivos   []string `json:"motivos"`
+}
+
+// Mesclagem registra a fusão de um cliente duplicado em outro, para auditoria
+type Mesclagem struct {
+	ID           int            `json:"id"`
+	EmpresaID    int            `json:"empresa_id"`
+	ClienteID    int            `json:"cliente_id"`  // cliente mantido
+	MescladoID   int            `json:"mesclado_id"` // cliente removido
+	UsuarioID    int            `json:"usuario_id"`
+	Anterior     *Cliente       `json:"anterior"`     // cadastro do cliente mantido antes da fusão
+	Mesclado     *Cliente       `json:"mesclado"`     // cadastro do cliente removido
+	Transferidos map[string]int `json:"transferidos"` // registros reapontados por entidade
+	Data         time.Time      `json:"data"`
+}
+
+// Vinculo é implementado pelos módulos que guardam referências a clientes,
+// para que a mesclagem reaponte seus registros para o cliente mantido
+type Vinculo interface {
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
+
+// Tipos de endereço do cliente
+const (
+	EnderecoCobranca = "cobranca"
+	EnderecoEntrega  = "entrega"
+	EnderecoOutro    = "outro"
+)
+
+// Contato representa uma pessoa de contato do cliente
+type Contato struct {
+	ID              int       `json:"id"`
+	ClienteID       int       `json:"cliente_id"`
+	EmpresaID       int       `json:"empresa_id"`
+	Nome            string    `json:"nome"`
+	Cargo           string    `json:"cargo,omitempty"`
+	Departamento    string    `json:"departamento,omitempty"`
+	Email           string    `json:"email,omitempty"`
+	Telefone        string    `json:"telefone,omitempty"`
+	Celular         string    `json:"celular,omitempty"`
+	Principal       bool      `json:"principal"` // um contato principal por cliente
+	Observacoes     string    `json:"observacoes,omitempty"`
+	DataCriacao     time.Time `json:"data_criacao"`
+	DataAtualizacao time.Time `json:"data_atualizacao"`
+}
+
+// Endereco representa um endereço estruturado do cliente
+type Endereco struct {
+	ID              int       `json:"id"`
+	ClienteID       int       `json:"cliente_id"`
+	EmpresaID       int       `json:"empresa_id"`
+	Tipo            string    `json:"tipo"`      // "cobranca", "entrega" ou "outro"
+	Principal       bool      `json:"principal"` // um endereço principal por tipo
+	CEP             string    `json:"cep"`
+	Logradouro      string    `json:"logradouro"`
+	Numero          string    `json:"numero"`
+	Complemento     string    `json:"complemento,omitempty"`
+	Bairro          string    `json:"bairro"`
+	CodigoMunicipio string    `json:"codigo_municipio"` // código IBGE de 7 dígitos
+	Municipio       string    `json:"municipio"`
+	UF              string    `json:"uf"`
+	Referencia      string    `json:"referencia,omitempty"`
+	DataCriacao     time.Time `json:"data_criacao"`
+	DataAtualizacao time.Time `json:"data_atualizacao"`
 }
 
 // Repository define a interface para acesso aos dados de clientes
@@ -27,6 +205,38 @@ type Repository interface {
 	GetByID(id int, empresaID int) (*Cliente, error)
 	Update(c *Cliente) error
 	Delete(id int, empresaID int) error
-	List(empresaID int, limit, offset int) ([]*Cliente, error)
-	Search(empresaID int, query string, limit, offset int) ([]*Cliente, error)
+	// List e Search aceitam filtros opcionais por campos personalizados
+	List(empresaID int, limit, offset int, filtros ...campo.Filtro) ([]*Cliente, error)
+	Search(empresaID int, query string, limit, offset int, filtros ...campo.Filtro) ([]*Cliente, error)
+
+	// Contatos e endereços; ao marcar um registro como principal os demais deixam de ser
+	CreateContato(c *Contato) error
+	GetContato(id, clienteID, empresaID int) (*Contato, error)
+	UpdateContato(c *Contato) error
+	DeleteContato(id, clienteID, empresaID int) error
+	ListContatos(clienteID, empresaID int) ([]*Contato, error)
+
+	CreateEndereco(e *Endereco) error
+	GetEndereco(id, clienteID, empresaID int) (*Endereco, error)
+	UpdateEndereco(e *Endereco) error
+	DeleteEndereco(id, clienteID, empresaID int) error
+	// ListEnderecos retorna os endereços do cliente; tipo vazio lista todos
+	ListEnderecos(clienteID, empresaID int, tipo string) ([]*Endereco, error)
+
+	CreateSegmento(s *Segmento) error
+	GetSegmento(id, empresaID int) (*Segmento, error)
+	UpdateSegmento(s *Segmento) error
+	DeleteSegmento(id, empresaID int) error
+	ListSegmentos(empresaID int) ([]*Segmento, error)
+
+	// TransferirCliente move contatos e endereços de um cliente para outro
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+	CreateMesclagem(m *Mesclagem) error
+	UpdateMesclagem(m *Mesclagem) error
+	// ListMesclagens retorna as mesclagens em que o cliente foi mantido ou removido, da mais recente para a mais antiga
+	ListMesclagens(clienteID, empresaID int) ([]*Mesclagem, error)
+
+	// Anonimizar elimina os dados pessoais do cliente, seus contatos e endereços e as cópias guardadas nas mesclagens,
+	// inclusive as dos cadastros que foram mesclados nele
+	Anonimizar(id, empresaID int) error
 }
@@ -0,0 +1,500 @@
+package cliente
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Regras de segmento combinam condições sobre os clientes, por exemplo:
+//
+//	tipo_pessoa = juridica AND ultima_compra < now-90d AND tag:vip
+//
+// Conectivos: AND (E), OR (OU), NOT (NAO) e parênteses. Operadores: = != < <= > >= e ~ (contém).
+// Campos: nome, email, telefone, cnpj, cpf, endereco, observacoes, tipo_pessoa, status,
+// data_criacao, ultima_compra e campo.<chave> para campos personalizados.
+// Datas aceitam AAAA-MM-DD, now (ou hoje) e deslocamentos como now-90d, now-2w, now-6m e now-1y.
+// Clientes que nunca compraram têm ultima_compra anterior a qualquer data.
+
+// Regra é uma regra de segmento já interpretada
+type Regra struct {
+	expr expressao
+}
+
+type expressao interface {
+	avaliar(c *Cliente, agora time.Time) bool
+}
+
+// CompilarRegra interpreta a expressão de um segmento
+func CompilarRegra(texto string) (*Regra, error) {
+	tokens, err := separar(texto)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, errors.New("regra vazia")
+	}
+
+	p := &interpretador{tokens: tokens}
+	expr, err := p.ou()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("regra inválida: %q inesperado", p.tokens[p.pos].texto)
+	}
+	return &Regra{expr: expr}, nil
+}
+
+// Atende indica se o cliente satisfaz a regra no instante informado
+func (r *Regra) Atende(c *Cliente, agora time.Time) bool {
+	return r.expr.avaliar(c, agora)
+}
+
+// Análise léxica
+
+type tipoToken int
+
+const (
+	tokenPalavra tipoToken = iota
+	tokenTexto             // entre aspas
+	tokenOperador
+	tokenAbre
+	tokenFecha
+)
+
+type token struct {
+	tipo  tipoToken
+	texto string
+}
+
+func separar(s string) ([]token, error) {
+	var tokens []token
+	runas := []rune(s)
+	for i := 0; i < len(runas); {
+		r := runas[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, token{tokenAbre, "("})
+			i++
+		case r == ')':
+			tokens = append(tokens, token{tokenFecha, ")"})
+			i++
+		case r == '"':
+			var b strings.Builder
+			i++
+			for ; i < len(runas) && runas[i] != '"'; i++ {
+				if runas[i] == '\\' && i+1 < len(runas) {
+					i++
+				}
+				b.WriteRune(runas[i])
+			}
+			if i >= len(runas) {
+				return nil, errors.New("regra inválida: aspas não fechadas")
+			}
+			tokens = append(tokens, token{tokenTexto, b.String()})
+			i++
+		case strings.ContainsRune("=!<>~", r):
+			op := string(r)
+			if i+1 < len(runas) && runas[i+1] == '=' && r != '=' && r != '~' {
+				op += "="
+			}
+			if op == "!" {
+				return nil, errors.New("regra inválida: use != para diferente")
+			}
+			tokens = append(tokens, token{tokenOperador, op})
+			i += len(op)
+		default:
+			inicio := i
+			for i < len(runas) && !unicode.IsSpace(runas[i]) && !strings.ContainsRune(`()"=!<>~`, runas[i]) {
+				i++
+			}
+			tokens = append(tokens, token{tokenPalavra, string(runas[inicio:i])})
+		}
+	}
+	return tokens, nil
+}
+
+// Análise sintática
+
+type interpretador struct {
+	tokens []token
+	pos    int
+}
+
+func (p *interpretador) proximo() (token, bool) {
+	if p.pos >= len(p.tokens) {
+		return token{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// conectivo consome a palavra-chave se for uma das informadas
+func (p *interpretador) conectivo(palavras ...string) bool {
+	t, ok := p.proximo()
+	if !ok || t.tipo != tokenPalavra {
+		return false
+	}
+	for _, palavra := range palavras {
+		if strings.EqualFold(t.texto, palavra) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *interpretador) ou() (expressao, error) {
+	esq, err := p.e()
+	if err != nil {
+		return nil, err
+	}
+	for p.conectivo("OR", "OU") {
+		dir, err := p.e()
+		if err != nil {
+			return nil, err
+		}
+		esq = disjuncao{esq, dir}
+	}
+	return esq, nil
+}
+
+func (p *interpretador) e() (expressao, error) {
+	esq, err := p.nao()
+	if err != nil {
+		return nil, err
+	}
+	for p.conectivo("AND", "E") {
+		dir, err := p.nao()
+		if err != nil {
+			return nil, err
+		}
+		esq = conjuncao{esq, dir}
+	}
+	return esq, nil
+}
+
+func (p *interpretador) nao() (expressao, error) {
+	if p.conectivo("NOT", "NAO", "NÃO") {
+		expr, err := p.nao()
+		if err != nil {
+			return nil, err
+		}
+		return negacao{expr}, nil
+	}
+	return p.primaria()
+}
+
+func (p *interpretador) primaria() (expressao, error) {
+	t, ok := p.proximo()
+	if !ok {
+		return nil, errors.New("regra inválida: condição esperada no final")
+	}
+
+	switch {
+	case t.tipo == tokenAbre:
+		p.pos++
+		expr, err := p.ou()
+		if err != nil {
+			return nil, err
+		}
+		if f, ok := p.proximo(); !ok || f.tipo != tokenFecha {
+			return nil, errors.New("regra inválida: parêntese não fechado")
+		}
+		p.pos++
+		return expr, nil
+
+	case t.tipo == tokenPalavra && strings.HasPrefix(strings.ToLower(t.texto), "tag:"):
+		p.pos++
+		tag := t.texto[len("tag:"):]
+		if tag == "" {
+			// tag:"nome com espaços"
+			if v, ok := p.proximo(); ok && v.tipo == tokenTexto {
+				tag = v.texto
+				p.pos++
+			}
+		}
+		tag = NormalizarTag(tag)
+		if tag == "" {
+			return nil, errors.New("regra inválida: tag vazia")
+		}
+		return temTag{tag}, nil
+
+	case t.tipo == tokenPalavra:
+		p.pos++
+		op, ok := p.proximo()
+		if !ok || op.tipo != tokenOperador {
+			return nil, fmt.Errorf("regra inválida: operador esperado após %q", t.texto)
+		}
+		p.pos++
+		v, ok := p.proximo()
+		if !ok || (v.tipo != tokenPalavra && v.tipo != tokenTexto) {
+			return nil, fmt.Errorf("regra inválida: valor esperado após %s %s", t.texto, op.texto)
+		}
+		p.pos++
+		return novaComparacao(strings.ToLower(t.texto), op.texto, v.texto)
+	}
+
+	return nil, fmt.Errorf("regra inválida: %q inesperado", t.texto)
+}
+
+// Expressões
+
+type conjuncao struct{ esq, dir expressao }
+type disjuncao struct{ esq, dir expressao }
+type negacao struct{ expr expressao }
+type temTag struct{ tag string }
+
+func (x conjuncao) avaliar(c *Cliente, agora time.Time) bool {
+	return x.esq.avaliar(c, agora) && x.dir.avaliar(c, agora)
+}
+
+func (x disjuncao) avaliar(c *Cliente, agora time.Time) bool {
+	return x.esq.avaliar(c, agora) || x.dir.avaliar(c, agora)
+}
+
+func (x negacao) avaliar(c *Cliente, agora time.Time) bool {
+	return !x.expr.avaliar(c, agora)
+}
+
+func (x temTag) avaliar(c *Cliente, _ time.Time) bool {
+	return possuiTag(c, x.tag)
+}
+
+// Comparações
+
+// camposTexto associa os campos de texto da regra aos atributos do cliente
+var camposTexto = map[string]func(c *Cliente) string{
+	"nome":        func(c *Cliente) string { return c.Nome },
+	"email":       func(c *Cliente) string { return c.Email },
+	"telefone":    func(c *Cliente) string { return c.Telefone },
+	"cnpj":        func(c *Cliente) string { return c.CNPJ },
+	"cpf":         func(c *Cliente) string { return c.CPF },
+	"endereco":    func(c *Cliente) string { return c.Endereco },
+	"observacoes": func(c *Cliente) string { return c.Observacoes },
+	"tipo_pessoa": func(c *Cliente) string { return c.TipoPessoa },
+}
+
+// camposData associa os campos de data da regra aos atributos do cliente
+var camposData = map[string]func(c *Cliente) time.Time{
+	"data_criacao":  func(c *Cliente) time.Time { return c.DataCriacao },
+	"ultima_compra": func(c *Cliente) time.Time { return c.UltimaCompra },
+}
+
+type comparacaoTexto struct {
+	campo func(c *Cliente) string
+	op    string
+	valor string
+}
+
+type comparacaoStatus struct {
+	op    string
+	valor bool
+}
+
+type comparacaoData struct {
+	campo func(c *Cliente) time.Time
+	op    string
+	valor data
+}
+
+type comparacaoCampo struct {
+	chave string
+	op    string
+	valor string
+}
+
+func novaComparacao(campo, op, valor string) (expressao, error) {
+	if f, ok := camposTexto[campo]; ok {
+		return comparacaoTexto{f, op, valor}, nil
+	}
+
+	if f, ok := camposData[campo]; ok {
+		if op == "~" {
+			return nil, fmt.Errorf("regra inválida: ~ não se aplica a %s", campo)
+		}
+		d, err := lerData(valor)
+		if err != nil {
+			return nil, err
+		}
+		return comparacaoData{f, op, d}, nil
+	}
+
+	if campo == "status" {
+		if op != "=" && op != "!=" {
+			return nil, errors.New("regra inválida: status aceita apenas = e !=")
+		}
+		switch strings.ToLower(valor) {
+		case "true", "ativo":
+			return comparacaoStatus{op, true}, nil
+		case "false", "inativo":
+			return comparacaoStatus{op, false}, nil
+		}
+		return nil, fmt.Errorf("regra inválida: status deve ser ativo ou inativo, não %q", valor)
+	}
+
+	if strings.HasPrefix(campo, "campo.") && len(campo) > len("campo.") {
+		return comparacaoCampo{strings.TrimPrefix(campo, "campo."), op, valor}, nil
+	}
+
+	return nil, fmt.Errorf("regra inválida: campo desconhecido %q", campo)
+}
+
+func (x comparacaoTexto) avaliar(c *Cliente, _ time.Time) bool {
+	return compararTexto(x.campo(c), x.op, x.valor)
+}
+
+func (x comparacaoStatus) avaliar(c *Cliente, _ time.Time) bool {
+	return (c.Status == x.valor) == (x.op == "=")
+}
+
+func (x comparacaoData) avaliar(c *Cliente, agora time.Time) bool {
+	v, alvo := x.campo(c), x.valor.em(agora)
+	if x.op == "=" || x.op == "!=" {
+		// Igualdade de datas considera o dia
+		igual := v.In(alvo.Location()).Format("2006-01-02") == alvo.Format("2006-01-02")
+		return igual == (x.op == "=")
+	}
+	return compararOrdem(compararTempo(v, alvo), x.op)
+}
+
+func (x comparacaoCampo) avaliar(c *Cliente, _ time.Time) bool {
+	v, ok := c.Campos[x.chave]
+	if !ok {
+		return x.op == "!="
+	}
+
+	switch v := v.(type) {
+	case float64:
+		alvo, err := strconv.ParseFloat(strings.Replace(x.valor, ",", ".", 1), 64)
+		if err != nil {
+			return false
+		}
+		switch {
+		case v < alvo:
+			return compararOrdem(-1, x.op)
+		case v > alvo:
+			return compararOrdem(1, x.op)
+		}
+		return compararOrdem(0, x.op)
+	case string:
+		return compararTexto(v, x.op, x.valor)
+	case []string:
+		// Múltipla escolha: = e ~ verificam se alguma opção atende; != se nenhuma é igual
+		contem := false
+		for _, item := range v {
+			if compararTexto(item, "=", x.valor) || (x.op == "~" && compararTexto(item, "~", x.valor)) {
+				contem = true
+				break
+			}
+		}
+		switch x.op {
+		case "=", "~":
+			return contem
+		case "!=":
+			return !contem
+		}
+	}
+	return false
+}
+
+func compararTexto(v, op, alvo string) bool {
+	a, b := strings.ToLower(v), strings.ToLower(alvo)
+	if op == "~" {
+		return strings.Contains(a, b)
+	}
+	return compararOrdem(strings.Compare(a, b), op)
+}
+
+func compararTempo(a, b time.Time) int {
+	switch {
+	case a.Before(b):
+		return -1
+	case a.After(b):
+		return 1
+	}
+	return 0
+}
+
+// compararOrdem aplica o operador ao resultado de uma comparação (-1, 0 ou 1)
+func compararOrdem(cmp int, op string) bool {
+	switch op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// data é uma data fixa ou relativa ao momento da avaliação
+type data struct {
+	fixa                     time.Time
+	relativa                 bool
+	anos, meses, dias, horas int
+}
+
+func (d data) em(agora time.Time) time.Time {
+	if !d.relativa {
+		return d.fixa
+	}
+	return agora.AddDate(d.anos, d.meses, d.dias).Add(time.Duration(d.horas) * time.Hour)
+}
+
+func lerData(valor string) (data, error) {
+	v := strings.ToLower(valor)
+	for _, base := range []string{"now", "hoje"} {
+		if !strings.HasPrefix(v, base) {
+			continue
+		}
+		resto := v[len(base):]
+		if resto == "" {
+			return data{relativa: true}, nil
+		}
+		if len(resto) < 3 || (resto[0] != '-' && resto[0] != '+') {
+			break
+		}
+		n, err := strconv.Atoi(resto[1 : len(resto)-1])
+		if err != nil || n < 0 {
+			break
+		}
+		if resto[0] == '-' {
+			n = -n
+		}
+		d := data{relativa: true}
+		switch resto[len(resto)-1] {
+		case 'h':
+			d.horas = n
+		case 'd':
+			d.dias = n
+		case 'w':
+			d.dias = 7 * n
+		case 'm':
+			d.meses = n
+		case 'y':
+			d.anos = n
+		default:
+			return data{}, fmt.Errorf("regra inválida: unidade de tempo desconhecida em %q (use h, d, w, m ou y)", valor)
+		}
+		return d, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02", valor, time.Local)
+	if err != nil {
+		return data{}, fmt.Errorf("regra inválida: data %q deve ser AAAA-MM-DD ou now±N(d|w|m|y)", valor)
+	}
+	return data{fixa: t}, nil
+}
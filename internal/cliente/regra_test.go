@@ -0,0 +1,102 @@
+package cliente
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
+)
+
+func TestRegraAtende(t *testing.T) {
+	agora := time.Date(2024, 6, 15, 12, 0, 0, 0, time.Local)
+	empresa := &Cliente{
+		Nome:         "Acme Ltda",
+		Email:        "compras@acme.com",
+		TipoPessoa:   "juridica",
+		Status:       true,
+		DataCriacao:  time.Date(2023, 1, 10, 9, 0, 0, 0, time.Local),
+		UltimaCompra: agora.AddDate(0, 0, -120),
+		Tags:         []string{"vip", "grande conta"},
+		Campos:       campo.Valores{"porte": "grande", "funcionarios": float64(250), "setores": []string{"varejo", "indústria"}},
+	}
+	pessoa := &Cliente{
+		Nome:        "Ana Souza",
+		Email:       "ana@gmail.com",
+		TipoPessoa:  "fisica",
+		DataCriacao: agora.AddDate(0, 0, -3),
+	}
+
+	tests := []struct {
+		regra   string
+		empresa bool
+		pessoa  bool
+	}{
+		{"tipo_pessoa = juridica", true, false},
+		{"tipo_pessoa = JURIDICA", true, false},
+		{"tipo_pessoa != juridica", false, true},
+		{"email ~ gmail", false, true},
+		{`nome = "Ana Souza"`, false, true},
+		{"tag:vip", true, false},
+		{`tag:"Grande  Conta"`, true, false},
+		{"NOT tag:vip", false, true},
+		{"status = ativo", true, false},
+		{"status = inativo", false, true},
+		{"ultima_compra < now-90d", true, true},
+		{"ultima_compra < now-6m", false, true},
+		{"ultima_compra >= now-1y", true, false},
+		{"data_criacao > now-1w", false, true},
+		{"data_criacao = 2023-01-10", true, false},
+		{"data_criacao < 2024-01-01", true, false},
+		{"campo.porte = grande", true, false},
+		{"campo.porte != grande", false, true},
+		{"campo.funcionarios >= 100", true, false},
+		{"campo.funcionarios < 100,5", false, false},
+		{"campo.setores = varejo", true, false},
+		{"campo.setores ~ indus", false, false},
+		{"campo.setores ~ indú", true, false},
+		{"tipo_pessoa = juridica AND ultima_compra < now-90d AND tag:vip", true, false},
+		{"tipo_pessoa = fisica OR tag:vip", true, true},
+		{"tipo_pessoa = fisica E (tag:vip OU email ~ gmail)", false, true},
+		{"NAO (tipo_pessoa = fisica OR tag:vip)", false, false},
+		{"tag:vip OR tipo_pessoa = fisica AND email ~ acme", true, false},
+	}
+	for _, tt := range tests {
+		r, err := CompilarRegra(tt.regra)
+		if err != nil {
+			t.Errorf("%s: %v", tt.regra, err)
+			continue
+		}
+		if got := r.Atende(empresa, agora); got != tt.empresa {
+			t.Errorf("%s: empresa %v, esperado %v", tt.regra, got, tt.empresa)
+		}
+		if got := r.Atende(pessoa, agora); got != tt.pessoa {
+			t.Errorf("%s: pessoa %v, esperado %v", tt.regra, got, tt.pessoa)
+		}
+	}
+}
+
+func TestRegraInvalida(t *testing.T) {
+	tests := []string{
+		"",
+		"tipo_pessoa",
+		"tipo_pessoa =",
+		"idade > 30",
+		"status > ativo",
+		"status = talvez",
+		"ultima_compra ~ 2024",
+		"ultima_compra < ontem",
+		"ultima_compra < now-3x",
+		"nome ! ana",
+		`nome = "ana`,
+		"(tag:vip",
+		"tag:vip)",
+		"tag:vip AND",
+		"tag:",
+		"campo. = x",
+	}
+	for _, regra := range tests {
+		if _, err := CompilarRegra(regra); err == nil {
+			t.Errorf("%q: regra aceita", regra)
+		}
+	}
+}
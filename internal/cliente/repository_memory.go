@@ -2,23 +2,39 @@ package cliente
 
 import (
 	"errors"
+	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
 )
 
 // MemoryRepository implementa Repository em memória
 type MemoryRepository struct {
-	mu       sync.RWMutex
-	clientes map[int]*Cliente
-	nextID   int
+	mu           sync.RWMutex
+	clientes     map[int]*Cliente
+	contatos     map[int]*Contato
+	enderecos    map[int]*Endereco
+	segmentos    map[int]*Segmento
+	mesclagens   []*Mesclagem
+	nextID       int
+	nextContato  int
+	nextEndereco int
+	nextSegmento int
 }
 
 // NewMemoryRepository cria um novo repositório em memória
 func NewMemoryRepository() *MemoryRepository {
 	return &MemoryRepository{
-		clientes: make(map[int]*Cliente),
-		nextID:   1,
+		clientes:     make(map[int]*Cliente),
+		contatos:     make(map[int]*Contato),
+		enderecos:    make(map[int]*Endereco),
+		segmentos:    make(map[int]*Segmento),
+		nextID:       1,
+		nextContato:  1,
+		nextEndereco: 1,
+		nextSegmento: 1,
 	}
 }
 
@@ -44,6 +60,10 @@ func (r *MemoryRepository) Create(c *Cliente) error {
 		return errors.New("empresa inválida")
 	}
 
+	if err := r.verificarDocumento(c); err != nil {
+		return err
+	}
+
 	// Configurar campos
 	c.ID = r.nextID
 	r.nextID++
@@ -51,6 +71,7 @@ func (r *MemoryRepository) Create(c *Cliente) error {
 	if c.Status == false {
 		c.Status = true // padrão ativo
 	}
+	c.registrarStatus(nil, c.DataCriacao)
 
 	// Adicionar ao mapa
 	r.clientes[c.ID] = c
@@ -89,8 +110,13 @@ func (r *MemoryRepository) Update(c *Cliente) error {
 		return errors.New("nome e email são obrigatórios")
 	}
 
+	if err := r.verificarDocumento(c); err != nil {
+		return err
+	}
+
 	// Preservar campos que não devem ser alterados
 	c.DataCriacao = existing.DataCriacao
+	c.registrarStatus(existing.HistoricoStatus, time.Now())
 
 	// Atualizar
 	r.clientes[c.ID] = c
@@ -113,11 +139,21 @@ func (r *MemoryRepository) Delete(id int, empresaID int) error {
 	}
 
 	delete(r.clientes, id)
+	for cid, contato := range r.contatos {
+		if contato.ClienteID == id {
+			delete(r.contatos, cid)
+		}
+	}
+	for eid, e := range r.enderecos {
+		if e.ClienteID == id {
+			delete(r.enderecos, eid)
+		}
+	}
 	return nil
 }
 
 // List retorna uma lista de clientes de uma empresa
-func (r *MemoryRepository) List(empresaID int, limit, offset int) ([]*Cliente, error) {
+func (r *MemoryRepository) List(empresaID int, limit, offset int, filtros ...campo.Filtro) ([]*Cliente, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -130,7 +166,7 @@ func (r *MemoryRepository) List(empresaID int, limit, offset int) ([]*Cliente, e
 	skipCount := 0
 
 	for _, c := range r.clientes {
-		if c.EmpresaID != empresaID {
+		if c.EmpresaID != empresaID || !c.Campos.Atende(filtros) {
 			continue
 		}
 
@@ -151,7 +187,7 @@ func (r *MemoryRepository) List(empresaID int, limit, offset int) ([]*Cliente, e
 }
 
 // Search procura clientes por termos
-func (r *MemoryRepository) Search(empresaID int, query string, limit, offset int) ([]*Cliente, error) {
+func (r *MemoryRepository) Search(empresaID int, query string, limit, offset int, filtros ...campo.Filtro) ([]*Cliente, error) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
@@ -165,15 +201,16 @@ func (r *MemoryRepository) Search(empresaID int, query string, limit, offset int
 	query = strings.ToLower(query)
 
 	for _, c := range r.clientes {
-		if c.EmpresaID != empresaID {
+		if c.EmpresaID != empresaID || !c.Campos.Atende(filtros) {
 			continue
 		}
 
-		// Buscar por nome, email, CPF ou CNPJ
+		// Buscar por nome, email, CPF, CNPJ ou campos personalizados
 		match := strings.Contains(strings.ToLower(c.Nome), query) ||
 			strings.Contains(strings.ToLower(c.Email), query) ||
 			strings.Contains(c.CPF, query) ||
-			strings.Contains(c.CNPJ, query)
+			strings.Contains(c.CNPJ, query) ||
+			c.Campos.Contem(query)
 
 		if !match {
 			continue
@@ -194,3 +231,515 @@ func (r *MemoryRepository) Search(empresaID int, query string, limit, offset int
 
 	return result, nil
 }
+
+// CreateContato adiciona um contato ao cliente
+func (r *MemoryRepository) CreateContato(c *Contato) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.verificarCliente(c.ClienteID, c.EmpresaID); err != nil {
+		return err
+	}
+	if c.Nome == "" {
+		return errors.New("nome do contato é obrigatório")
+	}
+
+	c.ID = r.nextContato
+	r.nextContato++
+	c.DataCriacao = time.Now()
+	c.DataAtualizacao = c.DataCriacao
+
+	r.contatos[c.ID] = c
+	r.ajustarContatoPrincipal(c)
+	return nil
+}
+
+// GetContato busca um contato do cliente
+func (r *MemoryRepository) GetContato(id, clienteID, empresaID int) (*Contato, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.contatos[id]
+	if !exists || c.ClienteID != clienteID || c.EmpresaID != empresaID {
+		return nil, errors.New("contato não encontrado")
+	}
+	return c, nil
+}
+
+// UpdateContato atualiza um contato existente
+func (r *MemoryRepository) UpdateContato(c *Contato) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.contatos[c.ID]
+	if !exists || existing.ClienteID != c.ClienteID || existing.EmpresaID != c.EmpresaID {
+		return errors.New("contato não encontrado")
+	}
+	if c.Nome == "" {
+		return errors.New("nome do contato é obrigatório")
+	}
+
+	// Preservar campos que não devem ser alterados
+	c.DataCriacao = existing.DataCriacao
+	c.DataAtualizacao = time.Now()
+
+	r.contatos[c.ID] = c
+	r.ajustarContatoPrincipal(c)
+	return nil
+}
+
+// DeleteContato remove um contato; se era o principal, o mais antigo restante assume
+func (r *MemoryRepository) DeleteContato(id, clienteID, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.contatos[id]
+	if !exists || c.ClienteID != clienteID || c.EmpresaID != empresaID {
+		return errors.New("contato não encontrado")
+	}
+
+	delete(r.contatos, id)
+	if c.Principal {
+		if restantes := r.contatosDoCliente(clienteID); len(restantes) > 0 {
+			restantes[0].Principal = true
+		}
+	}
+	return nil
+}
+
+// ListContatos retorna os contatos do cliente, começando pelo principal
+func (r *MemoryRepository) ListContatos(clienteID, empresaID int) ([]*Contato, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if err := r.verificarCliente(clienteID, empresaID); err != nil {
+		return nil, err
+	}
+
+	result := r.contatosDoCliente(clienteID)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Principal && !result[j].Principal })
+	return result, nil
+}
+
+// CreateEndereco adiciona um endereço ao cliente
+func (r *MemoryRepository) CreateEndereco(e *Endereco) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.verificarCliente(e.ClienteID, e.EmpresaID); err != nil {
+		return err
+	}
+
+	e.ID = r.nextEndereco
+	r.nextEndereco++
+	e.DataCriacao = time.Now()
+	e.DataAtualizacao = e.DataCriacao
+
+	r.enderecos[e.ID] = e
+	r.ajustarEnderecoPrincipal(e, "")
+	return nil
+}
+
+// GetEndereco busca um endereço do cliente
+func (r *MemoryRepository) GetEndereco(id, clienteID, empresaID int) (*Endereco, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, exists := r.enderecos[id]
+	if !exists || e.ClienteID != clienteID || e.EmpresaID != empresaID {
+		return nil, errors.New("endereço não encontrado")
+	}
+	return e, nil
+}
+
+// UpdateEndereco atualiza um endereço existente
+func (r *MemoryRepository) UpdateEndereco(e *Endereco) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.enderecos[e.ID]
+	if !exists || existing.ClienteID != e.ClienteID || existing.EmpresaID != e.EmpresaID {
+		return errors.New("endereço não encontrado")
+	}
+
+	// Preservar campos que não devem ser alterados
+	e.DataCriacao = existing.DataCriacao
+	e.DataAtualizacao = time.Now()
+
+	r.enderecos[e.ID] = e
+	anterior := ""
+	if existing.Principal && existing.Tipo != e.Tipo {
+		anterior = existing.Tipo
+	}
+	r.ajustarEnderecoPrincipal(e, anterior)
+	return nil
+}
+
+// DeleteEndereco remove um endereço; se era o principal do tipo, o mais antigo restante assume
+func (r *MemoryRepository) DeleteEndereco(id, clienteID, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, exists := r.enderecos[id]
+	if !exists || e.ClienteID != clienteID || e.EmpresaID != empresaID {
+		return errors.New("endereço não encontrado")
+	}
+
+	delete(r.enderecos, id)
+	if e.Principal {
+		r.promoverEndereco(clienteID, e.Tipo)
+	}
+	return nil
+}
+
+// ListEnderecos retorna os endereços do cliente, com os principais primeiro
+func (r *MemoryRepository) ListEnderecos(clienteID, empresaID int, tipo string) ([]*Endereco, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if err := r.verificarCliente(clienteID, empresaID); err != nil {
+		return nil, err
+	}
+
+	result := r.enderecosDoCliente(clienteID, tipo)
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Principal && !result[j].Principal })
+	return result, nil
+}
+
+// CreateSegmento adiciona um novo segmento
+func (r *MemoryRepository) CreateSegmento(s *Segmento) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+	if err := r.verificarNomeSegmento(s); err != nil {
+		return err
+	}
+
+	s.ID = r.nextSegmento
+	r.nextSegmento++
+	s.DataCriacao = time.Now()
+	s.DataAtualizacao = s.DataCriacao
+
+	r.segmentos[s.ID] = s
+	return nil
+}
+
+// GetSegmento busca um segmento por ID e empresa
+func (r *MemoryRepository) GetSegmento(id, empresaID int) (*Segmento, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, exists := r.segmentos[id]
+	if !exists || s.EmpresaID != empresaID {
+		return nil, errors.New("segmento não encontrado")
+	}
+	return s, nil
+}
+
+// UpdateSegmento atualiza um segmento existente
+func (r *MemoryRepository) UpdateSegmento(s *Segmento) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.segmentos[s.ID]
+	if !exists || existing.EmpresaID != s.EmpresaID {
+		return errors.New("segmento não encontrado")
+	}
+	if err := r.verificarNomeSegmento(s); err != nil {
+		return err
+	}
+
+	// Preservar campos que não devem ser alterados
+	s.DataCriacao = existing.DataCriacao
+	s.DataAtualizacao = time.Now()
+
+	r.segmentos[s.ID] = s
+	return nil
+}
+
+// DeleteSegmento remove um segmento
+func (r *MemoryRepository) DeleteSegmento(id, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	s, exists := r.segmentos[id]
+	if !exists || s.EmpresaID != empresaID {
+		return errors.New("segmento não encontrado")
+	}
+
+	delete(r.segmentos, id)
+	return nil
+}
+
+// ListSegmentos retorna os segmentos da empresa ordenados por nome
+func (r *MemoryRepository) ListSegmentos(empresaID int) ([]*Segmento, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Segmento, 0)
+	for _, s := range r.segmentos {
+		if s.EmpresaID == empresaID {
+			result = append(result, s)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Nome) < strings.ToLower(result[j].Nome)
+	})
+	return result, nil
+}
+
+// verificarNomeSegmento impede dois segmentos com o mesmo nome na empresa
+func (r *MemoryRepository) verificarNomeSegmento(s *Segmento) error {
+	for _, outro := range r.segmentos {
+		if outro.ID != s.ID && outro.EmpresaID == s.EmpresaID && strings.EqualFold(outro.Nome, s.Nome) {
+			return errors.New("já existe um segmento com este nome")
+		}
+	}
+	return nil
+}
+
+// verificarCliente confere se o cliente existe e pertence à empresa
+func (r *MemoryRepository) verificarCliente(clienteID, empresaID int) error {
+	c, exists := r.clientes[clienteID]
+	if !exists || c.EmpresaID != empresaID {
+		return errors.New("cliente não encontrado")
+	}
+	return nil
+}
+
+// ajustarContatoPrincipal garante exatamente um contato principal por cliente
+func (r *MemoryRepository) ajustarContatoPrincipal(c *Contato) {
+	contatos := r.contatosDoCliente(c.ClienteID)
+	if !c.Principal {
+		for _, outro := range contatos {
+			if outro.Principal {
+				return
+			}
+		}
+		contatos[0].Principal = true
+		return
+	}
+	for _, outro := range contatos {
+		if outro.ID != c.ID {
+			outro.Principal = false
+		}
+	}
+}
+
+// ajustarEnderecoPrincipal garante exatamente um endereço principal por tipo.
+// tipoAnterior indica o tipo que perdeu seu principal quando o endereço mudou de tipo.
+func (r *MemoryRepository) ajustarEnderecoPrincipal(e *Endereco, tipoAnterior string) {
+	if tipoAnterior != "" {
+		r.promoverEndereco(e.ClienteID, tipoAnterior)
+	}
+
+	mesmoTipo := r.enderecosDoCliente(e.ClienteID, e.Tipo)
+	if !e.Principal {
+		for _, outro := range mesmoTipo {
+			if outro.Principal {
+				return
+			}
+		}
+		mesmoTipo[0].Principal = true
+		return
+	}
+	for _, outro := range mesmoTipo {
+		if outro.ID != e.ID {
+			outro.Principal = false
+		}
+	}
+}
+
+// promoverEndereco torna principal o endereço mais antigo do tipo, se nenhum outro for
+func (r *MemoryRepository) promoverEndereco(clienteID int, tipo string) {
+	restantes := r.enderecosDoCliente(clienteID, tipo)
+	for _, e := range restantes {
+		if e.Principal {
+			return
+		}
+	}
+	if len(restantes) > 0 {
+		restantes[0].Principal = true
+	}
+}
+
+// contatosDoCliente retorna os contatos do cliente em ordem de cadastro
+func (r *MemoryRepository) contatosDoCliente(clienteID int) []*Contato {
+	result := make([]*Contato, 0)
+	for _, c := range r.contatos {
+		if c.ClienteID == clienteID {
+			result = append(result, c)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// enderecosDoCliente retorna os endereços do cliente em ordem de cadastro; tipo vazio retorna todos
+func (r *MemoryRepository) enderecosDoCliente(clienteID int, tipo string) []*Endereco {
+	result := make([]*Endereco, 0)
+	for _, e := range r.enderecos {
+		if e.ClienteID == clienteID && (tipo == "" || e.Tipo == tipo) {
+			result = append(result, e)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// TransferirCliente move contatos e endereços de um cliente para outro.
+// Registros que chegam como principais deixam de ser quando o destino já possui um principal.
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	contatoPrincipal := false
+	enderecoPrincipal := make(map[string]bool)
+	for _, c := range r.contatos {
+		if c.ClienteID == paraID && c.Principal {
+			contatoPrincipal = true
+		}
+	}
+	for _, e := range r.enderecos {
+		if e.ClienteID == paraID && e.Principal {
+			enderecoPrincipal[e.Tipo] = true
+		}
+	}
+
+	total := 0
+	for _, c := range r.contatos {
+		if c.EmpresaID != empresaID || c.ClienteID != deID {
+			continue
+		}
+		c.ClienteID = paraID
+		if c.Principal && contatoPrincipal {
+			c.Principal = false
+		}
+		total++
+	}
+	for _, e := range r.enderecos {
+		if e.EmpresaID != empresaID || e.ClienteID != deID {
+			continue
+		}
+		e.ClienteID = paraID
+		if e.Principal && enderecoPrincipal[e.Tipo] {
+			e.Principal = false
+		}
+		total++
+	}
+	return total, nil
+}
+
+// CreateMesclagem registra uma mesclagem de clientes
+func (r *MemoryRepository) CreateMesclagem(m *Mesclagem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	m.ID = len(r.mesclagens) + 1
+	if m.Data.IsZero() {
+		m.Data = time.Now()
+	}
+	r.mesclagens = append(r.mesclagens, m)
+	return nil
+}
+
+// UpdateMesclagem substitui as cópias dos cadastros guardadas em uma mesclagem
+func (r *MemoryRepository) UpdateMesclagem(m *Mesclagem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.ID <= 0 || m.ID > len(r.mesclagens) || r.mesclagens[m.ID-1].EmpresaID != m.EmpresaID {
+		return errors.New("mesclagem não encontrada")
+	}
+	r.mesclagens[m.ID-1] = m
+	return nil
+}
+
+// ListMesclagens retorna as mesclagens que envolvem o cliente
+func (r *MemoryRepository) ListMesclagens(clienteID, empresaID int) ([]*Mesclagem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Mesclagem, 0)
+	for i := len(r.mesclagens) - 1; i >= 0; i-- {
+		m := r.mesclagens[i]
+		if m.EmpresaID == empresaID && (m.ClienteID == clienteID || m.MescladoID == clienteID) {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// Anonimizar elimina os dados pessoais do cliente
+func (r *MemoryRepository) Anonimizar(id, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.clientes[id]
+	if !exists || c.EmpresaID != empresaID {
+		return errors.New("cliente não encontrado")
+	}
+
+	agora := time.Now()
+	anonimo := *c
+	anonimo.anonimizar(agora)
+	anonimo.registrarStatus(c.HistoricoStatus, agora)
+	r.clientes[id] = &anonimo
+
+	for cid, contato := range r.contatos {
+		if contato.ClienteID == id {
+			delete(r.contatos, cid)
+		}
+	}
+	for eid, e := range r.enderecos {
+		if e.ClienteID == id {
+			delete(r.enderecos, eid)
+		}
+	}
+	titular := r.incorporados(id, empresaID)
+	for _, m := range r.mesclagens {
+		if m.EmpresaID != empresaID {
+			continue
+		}
+		if m.Anterior != nil && titular[m.Anterior.ID] {
+			m.Anterior.anonimizar(agora)
+		}
+		if m.Mesclado != nil && titular[m.Mesclado.ID] {
+			m.Mesclado.anonimizar(agora)
+		}
+	}
+	return nil
+}
+
+// incorporados retorna o cliente e os cadastros mesclados nele, direta ou indiretamente:
+// todos descrevem o mesmo titular (deve ser chamado com o lock adquirido)
+func (r *MemoryRepository) incorporados(id, empresaID int) map[int]bool {
+	ids := map[int]bool{id: true}
+	for alterado := true; alterado; {
+		alterado = false
+		for _, m := range r.mesclagens {
+			if m.EmpresaID == empresaID && ids[m.ClienteID] && !ids[m.MescladoID] {
+				ids[m.MescladoID] = true
+				alterado = true
+			}
+		}
+	}
+	return ids
+}
+
+// verificarDocumento impede dois clientes da empresa com o mesmo CPF ou CNPJ
+func (r *MemoryRepository) verificarDocumento(c *Cliente) error {
+	chave := c.chaveDocumento()
+	if chave == "" {
+		return nil
+	}
+	for _, outro := range r.clientes {
+		if outro.ID != c.ID && outro.EmpresaID == c.EmpresaID && outro.chaveDocumento() == chave {
+			return errors.New("já existe um cliente com este CPF/CNPJ")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,212 @@
+package cliente
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+// PreviaSegmento mostra quantos clientes uma regra selecionaria, com uma amostra
+type PreviaSegmento struct {
+	Total   int        `json:"total"`
+	Amostra []*Cliente `json:"amostra"`
+}
+
+// SalvarSegmento valida a regra e cria ou atualiza o segmento
+func (s *Service) SalvarSegmento(seg *Segmento) error {
+	seg.Nome = strings.TrimSpace(seg.Nome)
+	seg.Regra = strings.TrimSpace(seg.Regra)
+	if seg.Nome == "" {
+		return errors.New("nome do segmento é obrigatório")
+	}
+	if _, err := CompilarRegra(seg.Regra); err != nil {
+		return err
+	}
+
+	var err error
+	if seg.ID == 0 {
+		err = s.repo.CreateSegmento(seg)
+	} else {
+		err = s.repo.UpdateSegmento(seg)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.membros, seg.ID)
+	s.mu.Unlock()
+	return nil
+}
+
+// ExcluirSegmento remove o segmento e seus membros em cache
+func (s *Service) ExcluirSegmento(id, empresaID int) error {
+	if err := s.repo.DeleteSegmento(id, empresaID); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	delete(s.membros, id)
+	s.mu.Unlock()
+	return nil
+}
+
+// Membros retorna os clientes do segmento, para listagens, exportações e envios de campanhas
+func (s *Service) Membros(empresaID, segmentoID int) ([]*Cliente, error) {
+	return s.Listar(FiltroClientes{EmpresaID: empresaID, SegmentoID: segmentoID}, 0, 0)
+}
+
+// Previa avalia uma regra ainda não salva
+func (s *Service) Previa(empresaID int, regra string, tamanhoAmostra int) (*PreviaSegmento, error) {
+	r, err := CompilarRegra(regra)
+	if err != nil {
+		return nil, err
+	}
+
+	clientes, err := s.repo.List(empresaID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	previa := &PreviaSegmento{Amostra: make([]*Cliente, 0)}
+	agora := time.Now()
+	for _, c := range ordenarPorID(clientes) {
+		if !r.Atende(c, agora) {
+			continue
+		}
+		previa.Total++
+		if len(previa.Amostra) < tamanhoAmostra {
+			previa.Amostra = append(previa.Amostra, c)
+		}
+	}
+	return previa, nil
+}
+
+// Listar aplica busca, tag, segmento e campos personalizados e pagina o resultado por ID
+func (s *Service) Listar(f FiltroClientes, limit, offset int) ([]*Cliente, error) {
+	var (
+		clientes []*Cliente
+		err      error
+	)
+	if f.Busca != "" {
+		clientes, err = s.repo.Search(f.EmpresaID, f.Busca, 0, 0, f.Campos...)
+	} else {
+		clientes, err = s.repo.List(f.EmpresaID, 0, 0, f.Campos...)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var membros map[int]bool
+	if f.SegmentoID > 0 {
+		if membros, err = s.idsSegmento(f.EmpresaID, f.SegmentoID); err != nil {
+			return nil, err
+		}
+	}
+	tag := NormalizarTag(f.Tag)
+
+	result := make([]*Cliente, 0)
+	for _, c := range ordenarPorID(clientes) {
+		if membros != nil && !membros[c.ID] {
+			continue
+		}
+		if tag != "" && !possuiTag(c, tag) {
+			continue
+		}
+		result = append(result, c)
+	}
+
+	if offset >= len(result) {
+		return []*Cliente{}, nil
+	}
+	result = result[offset:]
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// Tags lista as tags em uso na empresa com a quantidade de clientes de cada uma
+func (s *Service) Tags(empresaID int) ([]TotalTag, error) {
+	clientes, err := s.repo.List(empresaID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	totais := make(map[string]int)
+	for _, c := range clientes {
+		for _, t := range c.Tags {
+			totais[t]++
+		}
+	}
+
+	result := make([]TotalTag, 0, len(totais))
+	for t, total := range totais {
+		result = append(result, TotalTag{Tag: t, Total: total})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Tag < result[j].Tag })
+	return result, nil
+}
+
+// idsSegmento retorna os IDs dos membros do segmento, recalculando quando o cache expira
+func (s *Service) idsSegmento(empresaID, segmentoID int) (map[int]bool, error) {
+	s.mu.Lock()
+	if entrada, ok := s.membros[segmentoID]; ok && entrada.empresaID == empresaID && time.Now().Before(entrada.expira) {
+		s.mu.Unlock()
+		return entrada.ids, nil
+	}
+	s.mu.Unlock()
+
+	seg, err := s.repo.GetSegmento(segmentoID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	regra, err := CompilarRegra(seg.Regra)
+	if err != nil {
+		return nil, err
+	}
+	clientes, err := s.repo.List(empresaID, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make(map[int]bool)
+	agora := time.Now()
+	for _, c := range clientes {
+		if regra.Atende(c, agora) {
+			ids[c.ID] = true
+		}
+	}
+
+	s.mu.Lock()
+	s.membros[segmentoID] = entradaSegmento{empresaID: empresaID, ids: ids, expira: agora.Add(s.ttl)}
+	s.mu.Unlock()
+	return ids, nil
+}
+
+// invalidarSegmentos descarta os membros em cache dos segmentos da empresa
+func (s *Service) invalidarSegmentos(empresaID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, entrada := range s.membros {
+		if entrada.empresaID == empresaID {
+			delete(s.membros, id)
+		}
+	}
+}
+
+func possuiTag(c *Cliente, tag string) bool {
+	for _, t := range c.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func ordenarPorID(clientes []*Cliente) []*Cliente {
+	sort.Slice(clientes, func(i, j int) bool { return clientes[i].ID < clientes[j].ID })
+	return clientes
+}
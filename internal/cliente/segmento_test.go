@@ -0,0 +1,123 @@
+package cliente
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
+)
+
+// novoServico cria o serviço sobre um repositório em memória, sem campos personalizados definidos
+func novoServico(t *testing.T) (*Service, *MemoryRepository) {
+	t.Helper()
+	repo := NewMemoryRepository()
+	return NewService(repo, nil, campo.NewService(campo.NewMemoryRepository()), time.Hour), repo
+}
+
+func criar(t *testing.T, s *Service, c *Cliente) *Cliente {
+	t.Helper()
+	if err := s.Criar(c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func ids(clientes []*Cliente) []int {
+	result := make([]int, 0, len(clientes))
+	for _, c := range clientes {
+		result = append(result, c.ID)
+	}
+	return result
+}
+
+func TestMembrosDoSegmento(t *testing.T) {
+	s, _ := novoServico(t)
+	ana := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana", Email: "ana@acme.com", Tags: []string{" VIP "}})
+	bia := criar(t, s, &Cliente{EmpresaID: 1, Nome: "Bia", Email: "bia@acme.com"})
+	criar(t, s, &Cliente{EmpresaID: 2, Nome: "Caio", Email: "caio@beta.com", Tags: []string{"vip"}})
+
+	seg := &Segmento{EmpresaID: 1, Nome: " VIPs ", Regra: "tag:vip"}
+	if err := s.SalvarSegmento(seg); err != nil {
+		t.Fatal(err)
+	}
+	membros, err := s.Membros(1, seg.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(membros); len(got) != 1 || got[0] != ana.ID {
+		t.Errorf("membros %v, esperado [%d]", got, ana.ID)
+	}
+
+	// Alterar um cliente descarta os membros em cache
+	alterada := *bia
+	alterada.Tags = []string{"vip"}
+	if err := s.Atualizar(&alterada); err != nil {
+		t.Fatal(err)
+	}
+	if membros, _ = s.Membros(1, seg.ID); len(membros) != 2 {
+		t.Errorf("%d membros depois de marcar a Bia, esperado 2", len(membros))
+	}
+
+	// Alterar a regra também
+	seg.Regra = `nome = "Ana"`
+	if err := s.SalvarSegmento(seg); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.Membros(1, seg.ID); len(got) != 1 || got[0].ID != ana.ID {
+		t.Errorf("membros depois de alterar a regra: %v", ids(got))
+	}
+
+	if _, err := s.Membros(2, seg.ID); err == nil {
+		t.Error("segmento lido por outra empresa")
+	}
+}
+
+func TestSalvarSegmentoInvalido(t *testing.T) {
+	s, _ := novoServico(t)
+	tests := []struct {
+		nome string
+		seg  Segmento
+	}{
+		{"sem nome", Segmento{EmpresaID: 1, Nome: "  ", Regra: "tag:vip"}},
+		{"regra inválida", Segmento{EmpresaID: 1, Nome: "x", Regra: "tag:vip AND"}},
+	}
+	for _, tt := range tests {
+		if err := s.SalvarSegmento(&tt.seg); err == nil {
+			t.Errorf("%s: segmento salvo", tt.nome)
+		}
+	}
+}
+
+func TestExportarSegmento(t *testing.T) {
+	s, _ := novoServico(t)
+	criar(t, s, &Cliente{EmpresaID: 1, Nome: "Ana; Souza", Email: "ana@acme.com", Tags: []string{"vip", "sp"}})
+	criar(t, s, &Cliente{EmpresaID: 1, Nome: "Bia", Email: "bia@acme.com"})
+	seg := &Segmento{EmpresaID: 1, Nome: "VIPs", Regra: "tag:vip"}
+	if err := s.SalvarSegmento(seg); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := s.Exportar(&buf, FiltroClientes{EmpresaID: 1, SegmentoID: seg.ID}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "\uFEFF") {
+		t.Error("exportação sem BOM")
+	}
+
+	r := csv.NewReader(strings.NewReader(strings.TrimPrefix(buf.String(), "\uFEFF")))
+	r.Comma = ';'
+	linhas, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(linhas) != 2 {
+		t.Fatalf("%d linhas exportadas, esperado cabeçalho e um cliente", len(linhas))
+	}
+	if linhas[0][1] != "nome" || linhas[1][1] != "Ana; Souza" || linhas[1][8] != "ativo" || linhas[1][9] != "sp, vip" {
+		t.Errorf("exportação: %v", linhas)
+	}
+}
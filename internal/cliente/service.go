@@ -0,0 +1,237 @@
+package cliente
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
+	"github.com/Pantaleaogc/gvero/pkg/cep"
+)
+
+// tamanhoMaximoTag limita o tamanho de cada tag
+const tamanhoMaximoTag = 40
+
+// Service implementa as regras de cadastro, contatos, endereços, segmentação e mesclagem dos clientes
+type Service struct {
+	repo   Repository
+	ceps   cep.Provider
+	campos *campo.Service
+
+	// Módulos cujos registros acompanham o cliente na mesclagem, por entidade
+	vinculos map[string]Vinculo
+
+	// Membros dos segmentos calculados sob demanda e mantidos por ttl
+	ttl     time.Duration
+	mu      sync.Mutex
+	membros map[int]entradaSegmento
+}
+
+type entradaSegmento struct {
+	empresaID int
+	ids       map[int]bool
+	expira    time.Time
+}
+
+// NewService cria uma nova instância de Service; ttl define por quanto tempo os membros de um segmento ficam em cache
+func NewService(repo Repository, ceps cep.Provider, campos *campo.Service, ttl time.Duration) *Service {
+	return &Service{
+		repo:     repo,
+		ceps:     ceps,
+		campos:   campos,
+		ttl:      ttl,
+		membros:  make(map[int]entradaSegmento),
+		vinculos: make(map[string]Vinculo),
+	}
+}
+
+// Criar valida os campos personalizados e as tags e cadastra o cliente
+func (s *Service) Criar(c *Cliente) error {
+	c.DataAnonimizacao = time.Time{}
+	if err := s.preparar(c); err != nil {
+		return err
+	}
+	if err := s.repo.Create(c); err != nil {
+		return err
+	}
+	s.invalidarSegmentos(c.EmpresaID)
+	return nil
+}
+
+// Atualizar valida os campos personalizados e as tags e atualiza o cliente
+func (s *Service) Atualizar(c *Cliente) error {
+	atual, err := s.repo.GetByID(c.ID, c.EmpresaID)
+	if err != nil {
+		return err
+	}
+	if atual.Anonimizado() {
+		return errors.New("cliente anonimizado não pode ser alterado")
+	}
+	c.DataAnonimizacao = time.Time{}
+
+	if err := s.preparar(c); err != nil {
+		return err
+	}
+	if err := s.repo.Update(c); err != nil {
+		return err
+	}
+	s.invalidarSegmentos(c.EmpresaID)
+	return nil
+}
+
+// Anonimizar elimina os dados pessoais do cliente, mantendo os registros que apontam para ele
+func (s *Service) Anonimizar(id, empresaID int) error {
+	if err := s.repo.Anonimizar(id, empresaID); err != nil {
+		return err
+	}
+	s.invalidarSegmentos(empresaID)
+	return nil
+}
+
+// Excluir remove o cliente
+func (s *Service) Excluir(id, empresaID int) error {
+	if err := s.repo.Delete(id, empresaID); err != nil {
+		return err
+	}
+	s.invalidarSegmentos(empresaID)
+	return nil
+}
+
+// preparar normaliza as tags e valida os campos personalizados do cliente
+func (s *Service) preparar(c *Cliente) error {
+	valores, err := s.campos.Validar(c.EmpresaID, campo.EntidadeCliente, c.Campos)
+	if err != nil {
+		return err
+	}
+	c.Campos = valores
+
+	tags := make([]string, 0, len(c.Tags))
+	vistas := make(map[string]bool)
+	for _, t := range c.Tags {
+		t = NormalizarTag(t)
+		if t == "" || vistas[t] {
+			continue
+		}
+		if utf8.RuneCountInString(t) > tamanhoMaximoTag {
+			return fmt.Errorf("tag excede %d caracteres: %s", tamanhoMaximoTag, t)
+		}
+		vistas[t] = true
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	c.Tags = tags
+	if len(c.Tags) == 0 {
+		c.Tags = nil
+	}
+	return nil
+}
+
+// NormalizarTag padroniza a tag em minúsculas, sem espaços nas pontas nem repetidos
+func NormalizarTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// ConsultarCEP busca o endereço do CEP no provedor configurado
+func (s *Service) ConsultarCEP(ctx context.Context, numero string) (*cep.Endereco, error) {
+	return s.ceps.Consultar(ctx, numero)
+}
+
+// CriarContato valida e adiciona um contato ao cliente
+func (s *Service) CriarContato(c *Contato) error {
+	if err := validarContato(c); err != nil {
+		return err
+	}
+	return s.repo.CreateContato(c)
+}
+
+// AtualizarContato valida e atualiza um contato do cliente
+func (s *Service) AtualizarContato(c *Contato) error {
+	if err := validarContato(c); err != nil {
+		return err
+	}
+	return s.repo.UpdateContato(c)
+}
+
+// CriarEndereco completa o endereço pelo CEP, valida e adiciona ao cliente
+func (s *Service) CriarEndereco(ctx context.Context, e *Endereco) error {
+	if err := s.prepararEndereco(ctx, e); err != nil {
+		return err
+	}
+	return s.repo.CreateEndereco(e)
+}
+
+// AtualizarEndereco completa o endereço pelo CEP, valida e atualiza
+func (s *Service) AtualizarEndereco(ctx context.Context, e *Endereco) error {
+	if err := s.prepararEndereco(ctx, e); err != nil {
+		return err
+	}
+	return s.repo.UpdateEndereco(e)
+}
+
+// prepararEndereco normaliza os campos e preenche pelo CEP os que não foram informados
+func (s *Service) prepararEndereco(ctx context.Context, e *Endereco) error {
+	switch e.Tipo {
+	case EnderecoCobranca, EnderecoEntrega, EnderecoOutro:
+	case "":
+		e.Tipo = EnderecoCobranca
+	default:
+		return fmt.Errorf("tipo de endereço inválido: %s", e.Tipo)
+	}
+
+	numero, err := cep.Normalizar(e.CEP)
+	if err != nil {
+		return err
+	}
+	e.CEP = numero
+	e.UF = strings.ToUpper(strings.TrimSpace(e.UF))
+
+	if e.Logradouro == "" || e.Bairro == "" || e.Municipio == "" || e.CodigoMunicipio == "" || e.UF == "" {
+		// Sem resposta do CEP valem os campos informados, conferidos abaixo
+		if encontrado, err := s.ceps.Consultar(ctx, e.CEP); err == nil {
+			if e.UF != "" && e.UF != encontrado.UF {
+				return fmt.Errorf("CEP %s pertence à UF %s", e.CEP, encontrado.UF)
+			}
+			completar(&e.Logradouro, encontrado.Logradouro)
+			completar(&e.Bairro, encontrado.Bairro)
+			completar(&e.Municipio, encontrado.Municipio)
+			completar(&e.CodigoMunicipio, encontrado.CodigoMunicipio)
+			completar(&e.UF, encontrado.UF)
+		}
+	}
+
+	if e.Logradouro == "" || e.Numero == "" || e.Bairro == "" || e.Municipio == "" {
+		return errors.New("logradouro, número, bairro e município são obrigatórios")
+	}
+	if err := cep.ValidarUF(e.UF); err != nil {
+		return err
+	}
+	return cep.ValidarMunicipio(e.CodigoMunicipio, e.UF)
+}
+
+// validarContato exige nome e ao menos uma forma de contato
+func validarContato(c *Contato) error {
+	c.Nome = strings.TrimSpace(c.Nome)
+	c.Email = strings.TrimSpace(c.Email)
+	if c.Nome == "" {
+		return errors.New("nome do contato é obrigatório")
+	}
+	if c.Email == "" && c.Telefone == "" && c.Celular == "" {
+		return errors.New("informe email, telefone ou celular do contato")
+	}
+	if c.Email != "" && !strings.Contains(c.Email, "@") {
+		return errors.New("email do contato inválido")
+	}
+	return nil
+}
+
+// completar preenche o campo apenas se estiver vazio
+func completar(campo *string, valor string) {
+	if *campo == "" {
+		*campo = valor
+	}
+}
@@ -0,0 +1,264 @@
+package conta
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/seguranca"
+	"github.com/Pantaleaogc/gvero/pkg/limite"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// mensagemEsqueciSenha é a resposta de /esqueci-senha, a mesma exista ou não a conta
+const mensagemEsqueciSenha = "Se o e-mail estiver cadastrado, você receberá um link para redefinir a senha."
+
+// Limites de frequência dos fluxos públicos e dos convites
+const (
+	limitePorIP      = 20 // requisições por IP a cada 15 minutos, em cada rota pública
+	limitePorEmail   = 3  // e-mails de redefinição por endereço a cada hora
+	limiteConvites   = 50 // convites por empresa a cada dia
+	janelaPorIP      = 15 * time.Minute
+	janelaPorEmail   = time.Hour
+	janelaDeConvites = 24 * time.Hour
+)
+
+// Handlers contém os manipuladores HTTP da recuperação de senha e dos convites
+type Handlers struct {
+	repo     Repository
+	service  *Service
+	porIP    *limite.Janela
+	porEmail *limite.Janela
+	convites *limite.Janela
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:     repo,
+		service:  service,
+		porIP:    limite.NewJanela(limitePorIP, janelaPorIP),
+		porEmail: limite.NewJanela(limitePorEmail, janelaPorEmail),
+		convites: limite.NewJanela(limiteConvites, janelaDeConvites),
+	}
+}
+
+// RotasAuth registra em /auth as rotas públicas de recuperação de senha e de aceite de convites
+func RotasAuth(repo Repository, service *Service) func(r chi.Router) {
+	h := NewHandlers(repo, service)
+	return func(r chi.Router) {
+		r.Post("/esqueci-senha", h.EsqueciSenha)
+		r.Post("/redefinir-senha", h.RedefinirSenha)
+		r.Post("/convites/consultar", h.ConsultarConvite)
+		r.Post("/convites/aceitar", h.AceitarConvite)
+	}
+}
+
+// Routes retorna as rotas de administração dos convites da empresa
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+	r.Use(auth.RequireRole("admin"))
+
+	r.Get("/", h.ListConvites)
+	r.Post("/", h.Convidar)
+	r.Delete("/{id}", h.RevogarConvite)
+
+	return r
+}
+
+// EsqueciSenha aceita o pedido de redefinição sempre com a mesma resposta; o serviço envia o
+// link em segundo plano, para que o tempo de resposta também não indique se a conta existe.
+func (h *Handlers) EsqueciSenha(w http.ResponseWriter, r *http.Request) {
+	if !h.permitirIP(w, r, "esqueci-senha") {
+		return
+	}
+
+	var in struct {
+		Email  string `json:"email"`
+		Idioma string `json:"idioma"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Email == "" {
+		http.Error(w, "Informe o e-mail", http.StatusBadRequest)
+		return
+	}
+
+	// Acima do limite por endereço, o pedido é descartado sem mudar a resposta
+	if h.porEmail.Permitir(normalizarEmail(in.Email)) {
+		h.service.EsqueciSenha(in.Email, in.Idioma)
+	} else {
+		logger.InfoLogger.Printf("Limite de pedidos de redefinição atingido para %s", seguranca.MascararEmail(in.Email))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(map[string]string{"mensagem": mensagemEsqueciSenha})
+}
+
+// RedefinirSenha define a nova senha a partir do token recebido por e-mail
+func (h *Handlers) RedefinirSenha(w http.ResponseWriter, r *http.Request) {
+	if !h.permitirIP(w, r, "redefinir-senha") {
+		return
+	}
+
+	var in struct {
+		Token string `json:"token"`
+		Senha string `json:"senha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RedefinirSenha(in.Token, in.Senha); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]bool{"success": true})
+}
+
+// ConsultarConvite retorna a empresa e o e-mail do convite, para a página de aceite
+func (h *Handlers) ConsultarConvite(w http.ResponseWriter, r *http.Request) {
+	if !h.permitirIP(w, r, "convites") {
+		return
+	}
+
+	var in struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	resumo, err := h.service.ConsultarConvite(in.Token)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resumo)
+}
+
+// AceitarConvite cria a conta do convidado com o nome e a senha escolhidos
+func (h *Handlers) AceitarConvite(w http.ResponseWriter, r *http.Request) {
+	if !h.permitirIP(w, r, "convites") {
+		return
+	}
+
+	var in struct {
+		Token string `json:"token"`
+		Nome  string `json:"nome"`
+		Senha string `json:"senha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, "Formato inválido", http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.service.AceitarConvite(in.Token, in.Nome, in.Senha)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(auth.UserResponse{
+		ID:        u.ID,
+		Nome:      u.Nome,
+		Email:     u.Email,
+		Tipo:      u.Tipo,
+		EmpresaID: u.EmpresaID,
+	})
+}
+
+// ListConvites lista os convites da empresa. Filtro: pendentes=true.
+func (h *Handlers) ListConvites(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	pendentes, _ := strconv.ParseBool(r.URL.Query().Get("pendentes"))
+	convites, err := h.service.ListConvites(user.Empresa, pendentes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(convites)
+}
+
+// Convidar envia um convite para o e-mail entrar na empresa
+func (h *Handlers) Convidar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in NovoConvite
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !h.convites.Permitir(strconv.Itoa(user.Empresa)) {
+		http.Error(w, "Limite diário de convites atingido", http.StatusTooManyRequests)
+		return
+	}
+
+	convite, err := h.service.Convidar(user.Empresa, user.ID, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(convite)
+}
+
+// RevogarConvite invalida um convite pendente
+func (h *Handlers) RevogarConvite(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.RevogarConvite(user.Empresa, id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// permitirIP aplica o limite por IP da rota pública, respondendo 429 quando excedido
+func (h *Handlers) permitirIP(w http.ResponseWriter, r *http.Request, rota string) bool {
+	if h.porIP.Permitir(rota + ":" + limite.IP(r)) {
+		return true
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(int(janelaPorIP.Seconds())))
+	http.Error(w, "Muitas tentativas. Tente novamente mais tarde.", http.StatusTooManyRequests)
+	return false
+}
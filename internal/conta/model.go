@@ -0,0 +1,87 @@
+package conta
+
+import (
+	"errors"
+	"time"
+)
+
+// Tipos de token enviados por e-mail
+const (
+	TipoRedefinicaoSenha = "redefinicao_senha"
+	TipoConvite          = "convite"
+)
+
+// Validade dos tokens a partir da emissão
+const (
+	ValidadeRedefinicao = time.Hour
+	ValidadeConvite     = 7 * 24 * time.Hour
+)
+
+// PapelUsuario é o papel dos convidados, que vira o Tipo do usuário. O Tipo "admin" é o
+// administrador global do sistema e nunca é concedido por convite.
+const PapelUsuario = "usuario"
+
+// Status dos tokens, calculado a partir das datas
+const (
+	StatusPendente = "pendente"
+	StatusUsado    = "usado"
+	StatusExpirado = "expirado"
+	StatusRevogado = "revogado"
+)
+
+// ErrTokenInvalido é a resposta única para tokens inexistentes, usados, expirados ou revogados
+var ErrTokenInvalido = errors.New("link inválido ou expirado")
+
+// Token é um link de uso único enviado por e-mail. Apenas o hash SHA-256 é gravado;
+// o valor aparece somente no e-mail.
+type Token struct {
+	ID            int        `json:"id"`
+	Tipo          string     `json:"tipo"`
+	Hash          string     `json:"-"`
+	UsuarioID     int        `json:"usuario_id,omitempty"` // redefinição: usuário dono da senha
+	EmpresaID     int        `json:"empresa_id,omitempty"` // convite: empresa do convidado
+	Email         string     `json:"email"`
+	Nome          string     `json:"nome,omitempty"`
+	Papel         string     `json:"papel,omitempty"`
+	CriadoPor     int        `json:"criado_por,omitempty"`
+	Status        string     `json:"status,omitempty"` // preenchido nas consultas
+	Expira        time.Time  `json:"expira"`
+	DataUso       *time.Time `json:"data_uso,omitempty"`
+	DataRevogacao *time.Time `json:"data_revogacao,omitempty"`
+	DataCriacao   time.Time  `json:"data_criacao"`
+}
+
+// StatusEm calcula o status do token no instante informado
+func (t *Token) StatusEm(agora time.Time) string {
+	switch {
+	case t.DataUso != nil:
+		return StatusUsado
+	case t.DataRevogacao != nil:
+		return StatusRevogado
+	case !agora.Before(t.Expira):
+		return StatusExpirado
+	}
+	return StatusPendente
+}
+
+// Filtro restringe a listagem de tokens; campos vazios não filtram
+type Filtro struct {
+	Tipo      string
+	EmpresaID int
+	UsuarioID int
+	Email     string
+	Pendentes bool // apenas não usados, não revogados e não expirados
+}
+
+// Repository define a interface para acesso aos tokens
+type Repository interface {
+	Create(t *Token) error
+	Get(id int) (*Token, error)
+	GetByHash(hash string) (*Token, error)
+	List(f Filtro) ([]*Token, error)
+
+	// Usar marca o token pendente como usado; falha se outro uso ou revogação chegou antes
+	Usar(id int, agora time.Time) error
+	// Revogar invalida o token se ainda estiver pendente
+	Revogar(id int, agora time.Time) error
+}
@@ -0,0 +1,136 @@
+package conta
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu     sync.RWMutex
+	tokens map[int]*Token
+	nextID int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		tokens: make(map[int]*Token),
+		nextID: 1,
+	}
+}
+
+// Create adiciona um novo token
+func (r *MemoryRepository) Create(t *Token) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.Hash == "" || t.Email == "" {
+		return errors.New("hash e email são obrigatórios")
+	}
+	for _, existente := range r.tokens {
+		if existente.Hash == t.Hash {
+			return errors.New("token duplicado")
+		}
+	}
+
+	t.ID = r.nextID
+	r.nextID++
+	if t.DataCriacao.IsZero() {
+		t.DataCriacao = time.Now()
+	}
+
+	c := *t
+	r.tokens[t.ID] = &c
+	return nil
+}
+
+// Get busca um token por ID
+func (r *MemoryRepository) Get(id int) (*Token, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, exists := r.tokens[id]
+	if !exists {
+		return nil, errors.New("token não encontrado")
+	}
+	c := *t
+	return &c, nil
+}
+
+// GetByHash busca um token pelo hash do valor enviado no e-mail
+func (r *MemoryRepository) GetByHash(hash string) (*Token, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, t := range r.tokens {
+		if t.Hash == hash {
+			c := *t
+			return &c, nil
+		}
+	}
+	return nil, errors.New("token não encontrado")
+}
+
+// List retorna os tokens do filtro, dos mais recentes para os mais antigos
+func (r *MemoryRepository) List(f Filtro) ([]*Token, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	agora := time.Now()
+	result := make([]*Token, 0)
+	for _, t := range r.tokens {
+		if f.Tipo != "" && t.Tipo != f.Tipo {
+			continue
+		}
+		if f.EmpresaID != 0 && t.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.UsuarioID != 0 && t.UsuarioID != f.UsuarioID {
+			continue
+		}
+		if f.Email != "" && !strings.EqualFold(t.Email, f.Email) {
+			continue
+		}
+		if f.Pendentes && t.StatusEm(agora) != StatusPendente {
+			continue
+		}
+		c := *t
+		result = append(result, &c)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result, nil
+}
+
+// Usar marca o token pendente como usado
+func (r *MemoryRepository) Usar(id int, agora time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, exists := r.tokens[id]
+	if !exists || t.StatusEm(agora) != StatusPendente {
+		return ErrTokenInvalido
+	}
+	t.DataUso = &agora
+	return nil
+}
+
+// Revogar invalida o token pendente
+func (r *MemoryRepository) Revogar(id int, agora time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, exists := r.tokens[id]
+	if !exists {
+		return errors.New("token não encontrado")
+	}
+	if t.StatusEm(agora) != StatusPendente {
+		return errors.New("token não está pendente")
+	}
+	t.DataRevogacao = &agora
+	return nil
+}
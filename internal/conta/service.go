@@ -0,0 +1,425 @@
+package conta
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/email"
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// tamanhoToken é o número de bytes aleatórios dos tokens enviados por e-mail
+const tamanhoToken = 32
+
+// Páginas do frontend que recebem os tokens
+const (
+	paginaRedefinicao = "redefinir-senha"
+	paginaConvite     = "aceitar-convite"
+)
+
+// NovoConvite são os dados informados pelo administrador ao convidar
+type NovoConvite struct {
+	Email  string `json:"email"`
+	Nome   string `json:"nome"`
+	Papel  string `json:"papel"`  // apenas usuario (padrão)
+	Idioma string `json:"idioma"` // idioma do e-mail; vazio usa o da marca da empresa
+}
+
+// ResumoConvite é o que o convidado vê antes de aceitar
+type ResumoConvite struct {
+	Email   string    `json:"email"`
+	Nome    string    `json:"nome,omitempty"`
+	Empresa string    `json:"empresa"`
+	Expira  time.Time `json:"expira"`
+}
+
+// Service emite e consome os tokens de redefinição de senha e de convite
+type Service struct {
+	repo     Repository
+	usuarios usuario.Repository
+	empresas empresa.Repository
+	emails   *email.Service
+	urlBase  string
+	agora    func() time.Time
+	envios   sync.WaitGroup // envios de redefinição em segundo plano
+}
+
+// NewService cria um novo serviço. urlBase é o endereço do frontend usado nos links dos e-mails.
+func NewService(repo Repository, usuarios usuario.Repository, empresas empresa.Repository, emails *email.Service, urlBase string) *Service {
+	return &Service{
+		repo:     repo,
+		usuarios: usuarios,
+		empresas: empresas,
+		emails:   emails,
+		urlBase:  strings.TrimRight(urlBase, "/"),
+		agora:    time.Now,
+	}
+}
+
+// EsqueciSenha envia o link de redefinição quando o e-mail pertence a um usuário ativo.
+// Para não revelar quais contas existem, e-mails desconhecidos são ignorados sem erro, e a emissão
+// e o envio do link acontecem em segundo plano, sem alongar a resposta quando a conta existe.
+func (s *Service) EsqueciSenha(endereco, idioma string) {
+	u, err := s.buscarUsuario(endereco)
+	if err != nil || !u.Status {
+		return
+	}
+
+	s.envios.Add(1)
+	go func() {
+		defer s.envios.Done()
+		if err := s.enviarRedefinicao(u, idioma); err != nil {
+			logger.ErrorLogger.Printf("Erro ao enviar redefinição de senha ao usuário %d: %v", u.ID, err)
+		}
+	}()
+}
+
+// enviarRedefinicao emite o link de redefinição, revogando os anteriores, e o envia ao usuário
+func (s *Service) enviarRedefinicao(u *usuario.Usuario, idioma string) error {
+	// Apenas o link mais recente vale
+	s.revogarPendentes(Filtro{Tipo: TipoRedefinicaoSenha, UsuarioID: u.ID})
+
+	valor, _, err := s.emitir(&Token{
+		Tipo:      TipoRedefinicaoSenha,
+		UsuarioID: u.ID,
+		Email:     u.Email,
+		Nome:      u.Nome,
+	}, ValidadeRedefinicao)
+	if err != nil {
+		return err
+	}
+
+	// Enviado como e-mail do sistema: no registro de envios da empresa, o link ficaria
+	// visível aos administradores
+	_, err = s.emails.Enviar(email.Envio{
+		UsuarioID: u.ID,
+		Modelo:    email.ModeloRedefinicaoSenha,
+		Idioma:    idiomaValido(idioma),
+		Para:      []string{u.Email},
+		Dados: map[string]interface{}{
+			"nome":  u.Nome,
+			"link":  s.link(paginaRedefinicao, valor),
+			"horas": int(ValidadeRedefinicao / time.Hour),
+		},
+	})
+	return err
+}
+
+// Aguardar espera os envios de redefinição em andamento
+func (s *Service) Aguardar() {
+	s.envios.Wait()
+}
+
+// RedefinirSenha troca a senha do dono do token e invalida os demais links de redefinição
+func (s *Service) RedefinirSenha(valor, senha string) error {
+	if err := usuario.ValidarSenha(senha); err != nil {
+		return err
+	}
+
+	t, err := s.token(valor, TipoRedefinicaoSenha)
+	if err != nil {
+		return err
+	}
+	u, err := s.usuarios.GetByID(t.UsuarioID)
+	// A troca de e-mail depois da emissão também invalida o link
+	if err != nil || !u.Status || !strings.EqualFold(u.Email, t.Email) {
+		return ErrTokenInvalido
+	}
+
+	hash, err := usuario.HashSenha(senha)
+	if err != nil {
+		return err
+	}
+	if err := s.repo.Usar(t.ID, s.agora()); err != nil {
+		return ErrTokenInvalido
+	}
+
+	c := *u
+	c.Senha = hash
+	if err := s.usuarios.Update(&c); err != nil {
+		return err
+	}
+	s.revogarPendentes(Filtro{Tipo: TipoRedefinicaoSenha, UsuarioID: u.ID})
+
+	logger.InfoLogger.Printf("Senha redefinida: usuário %d", u.ID)
+	return nil
+}
+
+// Convidar envia o convite para o e-mail entrar na empresa. Convites pendentes para o mesmo
+// e-mail são revogados. O resultado não depende de o e-mail já ter conta: nesse caso, o aceite
+// é recusado e o convidado é orientado a entrar com a sua senha.
+func (s *Service) Convidar(empresaID, autorID int, in NovoConvite) (*Token, error) {
+	endereco, err := validarEmail(in.Email)
+	if err != nil {
+		return nil, err
+	}
+	papel := in.Papel
+	if papel == "" {
+		papel = PapelUsuario
+	}
+	if papel != PapelUsuario {
+		return nil, errors.New("o convite só concede o papel usuario")
+	}
+
+	emp, err := s.empresas.GetByID(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verificarVagas(emp, endereco); err != nil {
+		return nil, err
+	}
+
+	s.revogarPendentes(Filtro{Tipo: TipoConvite, EmpresaID: empresaID, Email: endereco})
+
+	valor, t, err := s.emitir(&Token{
+		Tipo:      TipoConvite,
+		EmpresaID: empresaID,
+		Email:     endereco,
+		Nome:      strings.TrimSpace(in.Nome),
+		Papel:     papel,
+		CriadoPor: autorID,
+	}, ValidadeConvite)
+	if err != nil {
+		return nil, err
+	}
+
+	convidante := ""
+	if autor, err := s.usuarios.GetByID(autorID); err == nil {
+		convidante = autor.Nome
+	}
+	_, err = s.emails.Enviar(email.Envio{
+		EmpresaID: empresaID,
+		UsuarioID: autorID,
+		Modelo:    email.ModeloConvite,
+		Idioma:    idiomaValido(in.Idioma),
+		Para:      []string{endereco},
+		Dados: map[string]interface{}{
+			"nome":       t.Nome,
+			"convidante": convidante,
+			"link":       s.link(paginaConvite, valor),
+			"dias":       int(ValidadeConvite / (24 * time.Hour)),
+		},
+	})
+	if err != nil {
+		s.repo.Revogar(t.ID, s.agora())
+		return nil, fmt.Errorf("erro ao enviar o convite: %w", err)
+	}
+
+	t.Status = t.StatusEm(s.agora())
+	return t, nil
+}
+
+// ConsultarConvite retorna os dados exibidos ao convidado na página de aceite
+func (s *Service) ConsultarConvite(valor string) (*ResumoConvite, error) {
+	t, err := s.token(valor, TipoConvite)
+	if err != nil {
+		return nil, err
+	}
+	emp, err := s.empresas.GetByID(t.EmpresaID)
+	if err != nil {
+		return nil, ErrTokenInvalido
+	}
+	return &ResumoConvite{Email: t.Email, Nome: t.Nome, Empresa: emp.Nome, Expira: t.Expira}, nil
+}
+
+// AceitarConvite cria o usuário do convidado na empresa, com a senha escolhida por ele
+func (s *Service) AceitarConvite(valor, nome, senha string) (*usuario.Usuario, error) {
+	if err := usuario.ValidarSenha(senha); err != nil {
+		return nil, err
+	}
+
+	t, err := s.token(valor, TipoConvite)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.empresas.GetByID(t.EmpresaID); err != nil {
+		return nil, ErrTokenInvalido
+	}
+
+	nome = strings.TrimSpace(nome)
+	if nome == "" {
+		nome = t.Nome
+	}
+	if nome == "" {
+		return nil, errors.New("informe o seu nome")
+	}
+
+	// Só quem recebeu o e-mail chega aqui, então a mensagem não expõe a conta a terceiros
+	if _, err := s.buscarUsuario(t.Email); err == nil {
+		return nil, errors.New("este e-mail já tem uma conta; entre com a sua senha")
+	}
+
+	hash, err := usuario.HashSenha(senha)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.Usar(t.ID, s.agora()); err != nil {
+		return nil, ErrTokenInvalido
+	}
+
+	u := &usuario.Usuario{
+		Nome:      nome,
+		Email:     t.Email,
+		Senha:     hash,
+		Status:    true,
+		Tipo:      t.Papel,
+		EmpresaID: t.EmpresaID,
+	}
+	if err := s.usuarios.Create(u); err != nil {
+		return nil, err
+	}
+
+	logger.InfoLogger.Printf("Convite %d aceito: usuário %d entrou na empresa %d", t.ID, u.ID, u.EmpresaID)
+	return u, nil
+}
+
+// ListConvites lista os convites da empresa com o status atual
+func (s *Service) ListConvites(empresaID int, pendentes bool) ([]*Token, error) {
+	convites, err := s.repo.List(Filtro{Tipo: TipoConvite, EmpresaID: empresaID, Pendentes: pendentes})
+	if err != nil {
+		return nil, err
+	}
+	agora := s.agora()
+	for _, t := range convites {
+		t.Status = t.StatusEm(agora)
+	}
+	return convites, nil
+}
+
+// RevogarConvite invalida um convite pendente da empresa
+func (s *Service) RevogarConvite(empresaID, id int) error {
+	t, err := s.repo.Get(id)
+	if err != nil || t.Tipo != TipoConvite || t.EmpresaID != empresaID {
+		return errors.New("convite não encontrado")
+	}
+	return s.repo.Revogar(id, s.agora())
+}
+
+// emitir grava o hash de um novo token e retorna o valor a ser enviado
+func (s *Service) emitir(t *Token, validade time.Duration) (string, *Token, error) {
+	b := make([]byte, tamanhoToken)
+	if _, err := rand.Read(b); err != nil {
+		return "", nil, err
+	}
+	valor := base64.RawURLEncoding.EncodeToString(b)
+
+	t.Hash = hashToken(valor)
+	t.DataCriacao = s.agora()
+	t.Expira = t.DataCriacao.Add(validade)
+	if err := s.repo.Create(t); err != nil {
+		return "", nil, err
+	}
+	return valor, t, nil
+}
+
+// token carrega o token pendente do tipo informado. Todas as falhas retornam o mesmo erro.
+func (s *Service) token(valor, tipo string) (*Token, error) {
+	if valor == "" {
+		return nil, ErrTokenInvalido
+	}
+	t, err := s.repo.GetByHash(hashToken(valor))
+	if err != nil || t.Tipo != tipo || t.StatusEm(s.agora()) != StatusPendente {
+		return nil, ErrTokenInvalido
+	}
+	return t, nil
+}
+
+// revogarPendentes invalida os tokens pendentes do filtro
+func (s *Service) revogarPendentes(f Filtro) {
+	f.Pendentes = true
+	tokens, err := s.repo.List(f)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro ao listar tokens pendentes: %v", err)
+		return
+	}
+	agora := s.agora()
+	for _, t := range tokens {
+		s.repo.Revogar(t.ID, agora)
+	}
+}
+
+// verificarVagas confere o limite de usuários do plano, contando os convites pendentes
+func (s *Service) verificarVagas(emp *empresa.Empresa, endereco string) error {
+	if emp.MaxUsuarios <= 0 {
+		return nil
+	}
+
+	usuarios, err := s.usuarios.List(0, 0)
+	if err != nil {
+		return err
+	}
+	ocupadas := 0
+	for _, u := range usuarios {
+		if u.EmpresaID == emp.ID {
+			ocupadas++
+		}
+	}
+
+	pendentes, err := s.repo.List(Filtro{Tipo: TipoConvite, EmpresaID: emp.ID, Pendentes: true})
+	if err != nil {
+		return err
+	}
+	for _, t := range pendentes {
+		// O convite anterior ao mesmo e-mail será substituído
+		if !strings.EqualFold(t.Email, endereco) {
+			ocupadas++
+		}
+	}
+
+	if ocupadas >= emp.MaxUsuarios {
+		return fmt.Errorf("limite de %d usuários do plano atingido, incluindo convites pendentes", emp.MaxUsuarios)
+	}
+	return nil
+}
+
+// buscarUsuario busca pelo e-mail normalizado e, para cadastros antigos, como foi digitado
+func (s *Service) buscarUsuario(endereco string) (*usuario.Usuario, error) {
+	u, err := s.usuarios.GetByEmail(normalizarEmail(endereco))
+	if err != nil {
+		u, err = s.usuarios.GetByEmail(strings.TrimSpace(endereco))
+	}
+	return u, err
+}
+
+// link monta o endereço da página do frontend que recebe o token
+func (s *Service) link(pagina, valor string) string {
+	return s.urlBase + "/" + pagina + "?token=" + url.QueryEscape(valor)
+}
+
+// hashToken é a forma gravada do token; SHA-256 basta, pois o valor tem 256 bits aleatórios
+func hashToken(valor string) string {
+	h := sha256.Sum256([]byte(valor))
+	return hex.EncodeToString(h[:])
+}
+
+func normalizarEmail(endereco string) string {
+	return strings.ToLower(strings.TrimSpace(endereco))
+}
+
+func validarEmail(endereco string) (string, error) {
+	endereco = normalizarEmail(endereco)
+	a, err := mail.ParseAddress(endereco)
+	if err != nil || a.Address != endereco {
+		return "", errors.New("e-mail inválido")
+	}
+	return endereco, nil
+}
+
+// idiomaValido descarta idiomas sem modelos, usando o idioma da marca
+func idiomaValido(idioma string) string {
+	if email.IdiomaValido(idioma) {
+		return idioma
+	}
+	return ""
+}
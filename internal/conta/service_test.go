@@ -0,0 +1,146 @@
+package conta
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/email"
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/jobs"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// ambiente é o serviço com a empresa 1 e os e-mails registrados sem entrega
+type ambiente struct {
+	s        *Service
+	usuarios usuario.Repository
+	emails   *email.MemoryRepository
+}
+
+func novoAmbiente(t *testing.T) *ambiente {
+	t.Helper()
+	empresas := empresa.NewMemoryRepository()
+	if err := empresas.Create(&empresa.Empresa{Nome: "Acme", CNPJ: "11222333000181", Email: "contato@acme.com", Status: true}); err != nil {
+		t.Fatal(err)
+	}
+	emails := email.NewMemoryRepository()
+	// A fila não é iniciada: as mensagens ficam registradas para a consulta do teste
+	fila := jobs.NewService(jobs.NewMemoryRepository())
+	emailService := email.NewService(emails, nil, empresas, fila, "nao-responda@gvero.test")
+
+	usuarios := usuario.NewMemoryRepository()
+	s := NewService(NewMemoryRepository(), usuarios, empresas, emailService, "https://app.gvero.test")
+	return &ambiente{s: s, usuarios: usuarios, emails: emails}
+}
+
+var linkToken = regexp.MustCompile(`token=([A-Za-z0-9%_-]+)`)
+
+// tokens retorna os tokens enviados por e-mail ao endereço, do mais antigo ao mais recente
+func (a *ambiente) tokens(t *testing.T, para string) []string {
+	t.Helper()
+	mensagens, err := a.emails.ListMensagens(email.FiltroMensagens{EmpresaID: 0, Para: para}, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tokens []string
+	for i := len(mensagens) - 1; i >= 0; i-- {
+		m := linkToken.FindStringSubmatch(mensagens[i].Texto)
+		if m == nil {
+			t.Fatalf("e-mail sem link: %s", mensagens[i].Texto)
+		}
+		valor, _ := url.QueryUnescape(m[1])
+		tokens = append(tokens, valor)
+	}
+	return tokens
+}
+
+func TestRedefinicaoDeUsoUnico(t *testing.T) {
+	a := novoAmbiente(t)
+	u := &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "usuario", Status: true, EmpresaID: 1}
+	a.usuarios.Create(u)
+
+	a.s.EsqueciSenha("Ana@Acme.com ", "")
+	a.s.EsqueciSenha("ana@acme.com", "")
+	a.s.Aguardar()
+	tokens := a.tokens(t, "ana@acme.com")
+	if len(tokens) != 2 {
+		t.Fatalf("%d e-mails de redefinição", len(tokens))
+	}
+
+	// Apenas o link mais recente vale
+	if err := a.s.RedefinirSenha(tokens[0], "NovaSenha123"); !errors.Is(err, ErrTokenInvalido) {
+		t.Errorf("link substituído aceito: %v", err)
+	}
+	if err := a.s.RedefinirSenha(tokens[1], "curta"); err == nil {
+		t.Error("senha curta aceita")
+	}
+	if err := a.s.RedefinirSenha(tokens[1], "NovaSenha123"); err != nil {
+		t.Fatalf("RedefinirSenha: %v", err)
+	}
+	if atual, _ := a.usuarios.GetByID(u.ID); !atual.VerificarSenha("NovaSenha123") {
+		t.Error("senha não redefinida")
+	}
+	if err := a.s.RedefinirSenha(tokens[1], "OutraSenha123"); !errors.Is(err, ErrTokenInvalido) {
+		t.Errorf("link reutilizado: %v", err)
+	}
+}
+
+func TestEsqueciSenhaSemConta(t *testing.T) {
+	a := novoAmbiente(t)
+	inativo := &usuario.Usuario{Nome: "Caio", Email: "caio@acme.com", Tipo: "usuario", EmpresaID: 1}
+	a.usuarios.Create(inativo)
+	inativo.Status = false
+
+	a.s.EsqueciSenha("ninguem@acme.com", "")
+	a.s.EsqueciSenha("caio@acme.com", "")
+	a.s.Aguardar()
+	mensagens, _ := a.emails.ListMensagens(email.FiltroMensagens{EmpresaID: 0}, 0, 0)
+	if len(mensagens) != 0 {
+		t.Errorf("%d e-mails enviados sem conta ativa", len(mensagens))
+	}
+}
+
+func TestConviteConcedeApenasUsuario(t *testing.T) {
+	a := novoAmbiente(t)
+	admin := &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "admin", Status: true, EmpresaID: 1}
+	a.usuarios.Create(admin)
+
+	if _, err := a.s.Convidar(1, admin.ID, NovoConvite{Email: "bia@acme.com", Papel: "admin"}); err == nil {
+		t.Error("convite de administrador aceito")
+	}
+	if _, err := a.s.Convidar(1, admin.ID, NovoConvite{Email: "bia@acme.com", Nome: "Bia"}); err != nil {
+		t.Fatalf("Convidar: %v", err)
+	}
+	tokens := a.tokens(t, "bia@acme.com")
+	if len(tokens) != 1 {
+		t.Fatalf("%d convites enviados", len(tokens))
+	}
+
+	if err := a.s.RevogarConvite(2, 1); err == nil {
+		t.Error("convite revogado por outra empresa")
+	}
+	u, err := a.s.AceitarConvite(tokens[0], "", "SenhaDaBia123")
+	if err != nil {
+		t.Fatalf("AceitarConvite: %v", err)
+	}
+	if u.Tipo != PapelUsuario || u.EmpresaID != 1 || u.Nome != "Bia" || !u.VerificarSenha("SenhaDaBia123") {
+		t.Errorf("convidado criado como %+v", u)
+	}
+	if _, err := a.s.AceitarConvite(tokens[0], "Bia", "SenhaDaBia123"); !errors.Is(err, ErrTokenInvalido) {
+		t.Errorf("convite reutilizado: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package dashboard
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/go-chi/chi/v5"
+)
+
+const formatoData = "2006-01-02"
+
+// Handlers contém os manipuladores HTTP do dashboard
+type Handlers struct {
+	service  *Service
+	usuarios usuario.Repository
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(service *Service, usuarios usuario.Repository) *Handlers {
+	return &Handlers{
+		service:  service,
+		usuarios: usuarios,
+	}
+}
+
+// Routes retorna as rotas do dashboard
+func Routes(service *Service, usuarios usuario.Repository) http.Handler {
+	h := NewHandlers(service, usuarios)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/", h.Get)
+
+	return r
+}
+
+// Get retorna as métricas da tela inicial.
+// Parâmetros: inicio e fim (AAAA-MM-DD), usuario_id, comparar=true e empresa_id (apenas o
+// administrador do sistema).
+func (h *Handlers) Get(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+
+	periodo, err := periodoDaQuery(q.Get("inicio"), q.Get("fim"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f := Filtro{
+		EmpresaID: user.Empresa,
+		Periodo:   periodo,
+	}
+
+	// Só o administrador do sistema consulta outras empresas; os das empresas veem a própria
+	if empresaID, _ := strconv.Atoi(q.Get("empresa_id")); empresaID > 0 && empresaID != f.EmpresaID {
+		if !auth.AdminGlobal(h.usuarios, user) {
+			http.Error(w, "Acesso negado", http.StatusForbidden)
+			return
+		}
+		f.EmpresaID = empresaID
+	}
+
+	if usuarioID := q.Get("usuario_id"); usuarioID != "" {
+		if usuarioID == "me" {
+			// A chave de API age em nome de quem a criou, mas não é esse usuário
+			if _, chave := auth.ChaveFromContext(r.Context()); chave {
+				http.Error(w, "usuario_id=me não se aplica a chaves de API", http.StatusBadRequest)
+				return
+			}
+			f.UsuarioID = user.ID
+		} else if f.UsuarioID, err = strconv.Atoi(usuarioID); err != nil {
+			http.Error(w, "usuario_id inválido", http.StatusBadRequest)
+			return
+		}
+	}
+
+	comparar, _ := strconv.ParseBool(q.Get("comparar"))
+
+	resumo, err := h.service.Resumo(f, comparar)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resumo)
+}
+
+// periodoDaQuery interpreta o intervalo informado; o padrão são os últimos 30 dias
+func periodoDaQuery(inicio, fim string) (Periodo, error) {
+	hoje := time.Now().Truncate(24 * time.Hour)
+	p := Periodo{
+		Inicio: hoje.AddDate(0, 0, -29),
+		Fim:    hoje.AddDate(0, 0, 1),
+	}
+
+	if fim != "" {
+		t, err := time.Parse(formatoData, fim)
+		if err != nil {
+			return p, errors.New("parâmetro inválido: fim")
+		}
+		// O fim é inclusivo na query, então o período vai até o início do dia seguinte
+		p.Fim = t.AddDate(0, 0, 1)
+	}
+
+	if inicio != "" {
+		t, err := time.Parse(formatoData, inicio)
+		if err != nil {
+			return p, errors.New("parâmetro inválido: inicio")
+		}
+		p.Inicio = t
+	} else if fim != "" {
+		p.Inicio = p.Fim.AddDate(0, 0, -30)
+	}
+
+	if !p.Inicio.Before(p.Fim) {
+		return p, errors.New("parâmetro inválido: período")
+	}
+
+	return p, nil
+}
@@ -0,0 +1,96 @@
+package dashboard
+
+import (
+	"time"
+)
+
+// Periodo representa o intervalo de datas considerado nas métricas
+type Periodo struct {
+	Inicio time.Time `json:"inicio"`
+	Fim    time.Time `json:"fim"`
+}
+
+// Anterior retorna o período imediatamente anterior com a mesma duração
+func (p Periodo) Anterior() Periodo {
+	duracao := p.Fim.Sub(p.Inicio)
+	return Periodo{
+		Inicio: p.Inicio.Add(-duracao),
+		Fim:    p.Inicio,
+	}
+}
+
+// Contem verifica se a data está dentro do período (início inclusivo, fim exclusivo)
+func (p Periodo) Contem(t time.Time) bool {
+	return !t.Before(p.Inicio) && t.Before(p.Fim)
+}
+
+// Filtro define o escopo de cálculo das métricas
+type Filtro struct {
+	EmpresaID int
+	UsuarioID int // 0 para considerar todos os usuários da empresa
+	Periodo   Periodo
+}
+
+// MetricasClientes resume a base de clientes
+type MetricasClientes struct {
+	Total  int `json:"total"`
+	Ativos int `json:"ativos"`
+	Novos  int `json:"novos"`
+}
+
+// MetricasNegocios resume o pipeline de negócios
+type MetricasNegocios struct {
+	Total    int     `json:"total"`
+	Novos    int     `json:"novos"`
+	Fechados int     `json:"fechados"`
+	Valor    float64 `json:"valor"`
+}
+
+// MetricasProjetos resume os projetos
+type MetricasProjetos struct {
+	Total       int `json:"total"`
+	EmAndamento int `json:"em_andamento"`
+	Concluidos  int `json:"concluidos"`
+}
+
+// MetricasTarefas resume as tarefas
+type MetricasTarefas struct {
+	Total      int `json:"total"`
+	Pendentes  int `json:"pendentes"`
+	Concluidas int `json:"concluidas"`
+	Atrasadas  int `json:"atrasadas"`
+}
+
+// Metricas agrupa todos os indicadores exibidos na tela inicial
+type Metricas struct {
+	Clientes MetricasClientes `json:"clientes"`
+	Negocios MetricasNegocios `json:"negocios"`
+	Projetos MetricasProjetos `json:"projetos"`
+	Tarefas  MetricasTarefas  `json:"tarefas"`
+}
+
+// Resumo é a resposta do endpoint de dashboard
+type Resumo struct {
+	EmpresaID int                `json:"empresa_id"`
+	UsuarioID int                `json:"usuario_id,omitempty"`
+	Periodo   Periodo            `json:"periodo"`
+	Atual     Metricas           `json:"atual"`
+	Anterior  *Metricas          `json:"anterior,omitempty"`
+	Variacao  map[string]float64 `json:"variacao,omitempty"` // variação percentual em relação ao período anterior
+	GeradoEm  time.Time          `json:"gerado_em"`
+}
+
+// FonteNegocios fornece as métricas do pipeline de negócios
+type FonteNegocios interface {
+	MetricasNegocios(f Filtro) (MetricasNegocios, error)
+}
+
+// FonteProjetos fornece as métricas de projetos
+type FonteProjetos interface {
+	MetricasProjetos(f Filtro) (MetricasProjetos, error)
+}
+
+// FonteTarefas fornece as métricas de tarefas
+type FonteTarefas interface {
+	MetricasTarefas(f Filtro) (MetricasTarefas, error)
+}
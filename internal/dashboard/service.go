@@ -0,0 +1,215 @@
+package dashboard
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// Service calcula os agregados do dashboard a partir dos repositórios
+type Service struct {
+	clientes cliente.Repository
+	negocios FonteNegocios
+	projetos FonteProjetos
+	tarefas  FonteTarefas
+
+	ttl      time.Duration
+	mu       sync.Mutex
+	cache    map[string]entradaCache
+	geracoes map[int]uint64 // avança a cada Invalidar da empresa
+}
+
+type entradaCache struct {
+	metricas Metricas
+	expira   time.Time
+}
+
+// NewService cria uma nova instância de Service.
+// As fontes de negócios, projetos e tarefas são opcionais; quando nil, as métricas correspondentes ficam zeradas.
+func NewService(clientes cliente.Repository, negocios FonteNegocios, projetos FonteProjetos, tarefas FonteTarefas, ttl time.Duration) *Service {
+	return &Service{
+		clientes: clientes,
+		negocios: negocios,
+		projetos: projetos,
+		tarefas:  tarefas,
+		ttl:      ttl,
+		cache:    make(map[string]entradaCache),
+		geracoes: make(map[int]uint64),
+	}
+}
+
+// Resumo calcula as métricas do período e, opcionalmente, do período anterior
+func (s *Service) Resumo(f Filtro, comparar bool) (*Resumo, error) {
+	atual, err := s.Metricas(f)
+	if err != nil {
+		return nil, err
+	}
+
+	resumo := &Resumo{
+		EmpresaID: f.EmpresaID,
+		UsuarioID: f.UsuarioID,
+		Periodo:   f.Periodo,
+		Atual:     atual,
+		GeradoEm:  time.Now(),
+	}
+
+	if comparar {
+		fAnterior := f
+		fAnterior.Periodo = f.Periodo.Anterior()
+
+		anterior, err := s.Metricas(fAnterior)
+		if err != nil {
+			return nil, err
+		}
+		resumo.Anterior = &anterior
+		resumo.Variacao = variacao(atual, anterior)
+	}
+
+	return resumo, nil
+}
+
+// Metricas retorna as métricas do filtro, usando o cache enquanto ele for válido. O cálculo
+// só entra no cache se a empresa não foi invalidada durante ele; senão, pode ter lido dados de
+// antes do evento.
+func (s *Service) Metricas(f Filtro) (Metricas, error) {
+	chave := chaveCache(f)
+
+	s.mu.Lock()
+	if entrada, ok := s.cache[chave]; ok && time.Now().Before(entrada.expira) {
+		s.mu.Unlock()
+		return entrada.metricas, nil
+	}
+	geracao := s.geracoes[f.EmpresaID]
+	s.mu.Unlock()
+
+	m, err := s.calcular(f)
+	if err != nil {
+		return Metricas{}, err
+	}
+
+	s.mu.Lock()
+	if s.geracoes[f.EmpresaID] == geracao {
+		s.cache[chave] = entradaCache{metricas: m, expira: time.Now().Add(s.ttl)}
+	}
+	s.limparExpirados()
+	s.mu.Unlock()
+
+	return m, nil
+}
+
+// Invalidar descarta as métricas em cache de uma empresa; chamado a cada evento de domínio da empresa
+func (s *Service) Invalidar(empresaID int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.geracoes[empresaID]++
+	prefixo := fmt.Sprintf("%d|", empresaID)
+	for chave := range s.cache {
+		if strings.HasPrefix(chave, prefixo) {
+			delete(s.cache, chave)
+		}
+	}
+}
+
+// calcular consulta os repositórios e monta as métricas
+func (s *Service) calcular(f Filtro) (Metricas, error) {
+	var m Metricas
+	var err error
+
+	if m.Clientes, err = s.metricasClientes(f); err != nil {
+		return Metricas{}, err
+	}
+
+	if s.negocios != nil {
+		if m.Negocios, err = s.negocios.MetricasNegocios(f); err != nil {
+			return Metricas{}, err
+		}
+	}
+
+	if s.projetos != nil {
+		if m.Projetos, err = s.projetos.MetricasProjetos(f); err != nil {
+			return Metricas{}, err
+		}
+	}
+
+	if s.tarefas != nil {
+		if m.Tarefas, err = s.tarefas.MetricasTarefas(f); err != nil {
+			return Metricas{}, err
+		}
+	}
+
+	return m, nil
+}
+
+// metricasClientes agrega os clientes da empresa.
+// Clientes não possuem usuário responsável, então o filtro por usuário não se aplica aqui.
+func (s *Service) metricasClientes(f Filtro) (MetricasClientes, error) {
+	var m MetricasClientes
+
+	clientes, err := s.clientes.List(f.EmpresaID, 0, 0)
+	if err != nil {
+		return m, err
+	}
+
+	// Ativos são os clientes ativos no fim do período, pelo histórico de status
+	ultimoInstante := f.Periodo.Fim.Add(-time.Nanosecond)
+	for _, c := range clientes {
+		// Considerar apenas clientes que já existiam até o fim do período
+		if !c.DataCriacao.Before(f.Periodo.Fim) {
+			continue
+		}
+
+		m.Total++
+		if c.AtivoEm(ultimoInstante) {
+			m.Ativos++
+		}
+		if f.Periodo.Contem(c.DataCriacao) {
+			m.Novos++
+		}
+	}
+
+	return m, nil
+}
+
+// limparExpirados remove entradas vencidas do cache (deve ser chamado com o lock adquirido)
+func (s *Service) limparExpirados() {
+	agora := time.Now()
+	for chave, entrada := range s.cache {
+		if agora.After(entrada.expira) {
+			delete(s.cache, chave)
+		}
+	}
+}
+
+func chaveCache(f Filtro) string {
+	return fmt.Sprintf("%d|%d|%d|%d", f.EmpresaID, f.UsuarioID, f.Periodo.Inicio.Unix(), f.Periodo.Fim.Unix())
+}
+
+// variacao calcula a variação percentual dos principais indicadores
+func variacao(atual, anterior Metricas) map[string]float64 {
+	return map[string]float64{
+		"clientes.total":      percentual(float64(atual.Clientes.Total), float64(anterior.Clientes.Total)),
+		"clientes.ativos":     percentual(float64(atual.Clientes.Ativos), float64(anterior.Clientes.Ativos)),
+		"clientes.novos":      percentual(float64(atual.Clientes.Novos), float64(anterior.Clientes.Novos)),
+		"negocios.total":      percentual(float64(atual.Negocios.Total), float64(anterior.Negocios.Total)),
+		"negocios.fechados":   percentual(float64(atual.Negocios.Fechados), float64(anterior.Negocios.Fechados)),
+		"negocios.valor":      percentual(atual.Negocios.Valor, anterior.Negocios.Valor),
+		"projetos.total":      percentual(float64(atual.Projetos.Total), float64(anterior.Projetos.Total)),
+		"projetos.concluidos": percentual(float64(atual.Projetos.Concluidos), float64(anterior.Projetos.Concluidos)),
+		"tarefas.total":       percentual(float64(atual.Tarefas.Total), float64(anterior.Tarefas.Total)),
+		"tarefas.concluidas":  percentual(float64(atual.Tarefas.Concluidas), float64(anterior.Tarefas.Concluidas)),
+	}
+}
+
+func percentual(atual, anterior float64) float64 {
+	if anterior == 0 {
+		if atual == 0 {
+			return 0
+		}
+		return 100
+	}
+	return (atual - anterior) / anterior * 100
+}
@@ -0,0 +1,126 @@
+package dashboard
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/chaveapi"
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+)
+
+// fonteLenta conta os cálculos e, quando configurada, pausa no meio de um deles
+type fonteLenta struct {
+	calculos  atomic.Int32
+	iniciado  chan struct{}
+	continuar chan struct{}
+}
+
+func (f *fonteLenta) MetricasNegocios(Filtro) (MetricasNegocios, error) {
+	n := f.calculos.Add(1)
+	if n == 1 && f.iniciado != nil {
+		close(f.iniciado)
+		<-f.continuar
+	}
+	return MetricasNegocios{Total: int(n)}, nil
+}
+
+func filtroTeste(empresaID int) Filtro {
+	hoje := time.Now().Truncate(24 * time.Hour)
+	return Filtro{EmpresaID: empresaID, Periodo: Periodo{Inicio: hoje.AddDate(0, 0, -29), Fim: hoje.AddDate(0, 0, 1)}}
+}
+
+func TestCacheInvalidado(t *testing.T) {
+	fonte := &fonteLenta{}
+	s := NewService(cliente.NewMemoryRepository(), fonte, nil, nil, time.Minute)
+	f := filtroTeste(1)
+
+	s.Metricas(f)
+	s.Metricas(f)
+	if n := fonte.calculos.Load(); n != 1 {
+		t.Errorf("%d cálculos com o cache válido", n)
+	}
+
+	s.Invalidar(2)
+	s.Metricas(f)
+	if n := fonte.calculos.Load(); n != 1 {
+		t.Errorf("a invalidação de outra empresa descartou o cache: %d cálculos", n)
+	}
+
+	s.Invalidar(1)
+	if m, _ := s.Metricas(f); m.Negocios.Total != 2 {
+		t.Errorf("métricas depois da invalidação = %+v", m.Negocios)
+	}
+}
+
+// Um evento durante o cálculo invalida o resultado em andamento, que leu os dados de antes dele
+func TestInvalidarDuranteCalculo(t *testing.T) {
+	fonte := &fonteLenta{iniciado: make(chan struct{}), continuar: make(chan struct{})}
+	s := NewService(cliente.NewMemoryRepository(), fonte, nil, nil, time.Minute)
+	f := filtroTeste(1)
+
+	feito := make(chan struct{})
+	go func() {
+		defer close(feito)
+		s.Metricas(f)
+	}()
+	<-fonte.iniciado
+	s.Invalidar(1)
+	close(fonte.continuar)
+	<-feito
+
+	if m, _ := s.Metricas(f); m.Negocios.Total != 2 {
+		t.Errorf("resultado anterior ao evento ficou no cache: %+v", m.Negocios)
+	}
+}
+
+func TestEmpresaDaConsulta(t *testing.T) {
+	usuarios := usuario.NewMemoryRepository()
+	sistema := &usuario.Usuario{Nome: "Root", Email: "root@gvero.test", Tipo: "admin", Status: true}
+	adminAcme := &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "admin", Status: true, EmpresaID: 1}
+	usuarios.Create(sistema)
+	usuarios.Create(adminAcme)
+	h := NewHandlers(NewService(cliente.NewMemoryRepository(), nil, nil, nil, time.Minute), usuarios)
+
+	casos := []struct {
+		nome   string
+		user   auth.User
+		query  string
+		status int
+	}{
+		{"própria empresa", auth.User{ID: adminAcme.ID, Role: "admin", Empresa: 1}, "empresa_id=1", http.StatusOK},
+		{"admin da empresa em outra empresa", auth.User{ID: adminAcme.ID, Role: "admin", Empresa: 1}, "empresa_id=2", http.StatusForbidden},
+		{"token forjado sem registro", auth.User{ID: 99, Role: "admin", Empresa: 1}, "empresa_id=2", http.StatusForbidden},
+		{"administrador do sistema", auth.User{ID: sistema.ID, Role: "admin", Empresa: 1}, "empresa_id=2", http.StatusOK},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/?"+c.query, nil)
+			req = req.WithContext(context.WithValue(req.Context(), auth.UserContextKey, c.user))
+			rec := httptest.NewRecorder()
+			h.Get(rec, req)
+			if rec.Code != c.status {
+				t.Errorf("status = %d, esperado %d", rec.Code, c.status)
+			}
+		})
+	}
+}
+
+// A chave de API age em nome de quem a criou, mas "me" não resolve para esse usuário
+func TestUsuarioMeComChave(t *testing.T) {
+	h := NewHandlers(NewService(cliente.NewMemoryRepository(), nil, nil, nil, time.Minute), usuario.NewMemoryRepository())
+
+	req := httptest.NewRequest(http.MethodGet, "/?usuario_id=me", nil)
+	ctx := context.WithValue(req.Context(), auth.UserContextKey, auth.User{ID: 7, Role: auth.PapelIntegracao, Empresa: 1})
+	ctx = context.WithValue(ctx, auth.ChaveContextKey, &chaveapi.Chave{ID: 1, EmpresaID: 1, UsuarioID: 7})
+	rec := httptest.NewRecorder()
+	h.Get(rec, req.WithContext(ctx))
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("usuario_id=me com chave = %d", rec.Code)
+	}
+}
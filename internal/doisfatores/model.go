@@ -0,0 +1,82 @@
+package doisfatores
+
+import (
+	"errors"
+	"time"
+)
+
+// Parâmetros dos desafios e dos códigos de recuperação
+const (
+	// ValidadeDesafio é o tempo para informar o código depois da senha
+	ValidadeDesafio = 5 * time.Minute
+	// MaxTentativasDesafio é o número de códigos errados aceitos antes de exigir a senha de novo
+	MaxTentativasDesafio = 5
+	// QuantidadeCodigosRecuperacao é o número de códigos gerados na ativação e na renovação
+	QuantidadeCodigosRecuperacao = 10
+)
+
+// ErrDesafioInvalido é a resposta única para desafios inexistentes, expirados ou esgotados
+var ErrDesafioInvalido = errors.New("desafio inválido ou expirado; entre novamente com a senha")
+
+// ErrCodigoInvalido indica um código TOTP ou de recuperação incorreto ou já usado
+var ErrCodigoInvalido = errors.New("código inválido")
+
+// Cadastro é o segundo fator de um usuário. Enquanto não é ativado com o primeiro código,
+// o segredo fica pendente e o login não o exige.
+type Cadastro struct {
+	UsuarioID          int        `json:"usuario_id"`
+	Segredo            string     `json:"-"`
+	Ativo              bool       `json:"ativo"`
+	UltimoPasso        int64      `json:"-"` // passo do último código aceito, para recusar reutilização
+	CodigosRecuperacao []string   `json:"-"` // hashes SHA-256 dos códigos ainda não usados
+	DataAtivacao       *time.Time `json:"data_ativacao,omitempty"`
+	DataCriacao        time.Time  `json:"data_criacao"`
+}
+
+// Politica define a exigência do segundo fator na empresa
+type Politica struct {
+	EmpresaID       int       `json:"empresa_id"`
+	ExigirAdmins    bool      `json:"exigir_admins"` // administradores só entram com o segundo fator
+	UsuarioID       int       `json:"usuario_id,omitempty"`
+	DataAtualizacao time.Time `json:"data_atualizacao"`
+}
+
+// Desafio é a etapa pendente do login entre a senha e o código. O valor é entregue ao
+// cliente; apenas o hash é gravado.
+type Desafio struct {
+	Hash       string    `json:"-"`
+	UsuarioID  int       `json:"usuario_id"`
+	Cadastro   bool      `json:"cadastro"` // o usuário precisa cadastrar o segundo fator antes de entrar
+	Tentativas int       `json:"tentativas"`
+	Expira     time.Time `json:"expira"`
+}
+
+// Status resume o segundo fator do usuário
+type Status struct {
+	Ativo            bool       `json:"ativo"`
+	Exigido          bool       `json:"exigido"`
+	CodigosRestantes int        `json:"codigos_recuperacao_restantes"`
+	DataAtivacao     *time.Time `json:"data_ativacao,omitempty"`
+}
+
+// Provisionamento são os dados exibidos ao usuário para configurar o aplicativo autenticador
+type Provisionamento struct {
+	Segredo string `json:"segredo"`
+	URL     string `json:"url"`    // otpauth://
+	QRCode  string `json:"qrcode"` // PNG em data URL
+}
+
+// Repository define a interface para acesso aos cadastros, políticas e desafios
+type Repository interface {
+	GetCadastro(usuarioID int) (*Cadastro, error)
+	SalvarCadastro(c *Cadastro) error
+	DeleteCadastro(usuarioID int) error
+
+	GetPolitica(empresaID int) (*Politica, error)
+	SalvarPolitica(p *Politica) error
+
+	CreateDesafio(d *Desafio) error
+	GetDesafio(hash string) (*Desafio, error)
+	UpdateDesafio(d *Desafio) error
+	DeleteDesafio(hash string) error
+}
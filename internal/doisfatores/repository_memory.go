@@ -0,0 +1,153 @@
+package doisfatores
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu        sync.RWMutex
+	cadastros map[int]*Cadastro
+	politicas map[int]*Politica
+	desafios  map[string]*Desafio
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		cadastros: make(map[int]*Cadastro),
+		politicas: make(map[int]*Politica),
+		desafios:  make(map[string]*Desafio),
+	}
+}
+
+// GetCadastro busca o segundo fator do usuário
+func (r *MemoryRepository) GetCadastro(usuarioID int) (*Cadastro, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.cadastros[usuarioID]
+	if !exists {
+		return nil, errors.New("segundo fator não cadastrado")
+	}
+	return copiarCadastro(c), nil
+}
+
+// SalvarCadastro cria ou substitui o segundo fator do usuário
+func (r *MemoryRepository) SalvarCadastro(c *Cadastro) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.UsuarioID <= 0 || c.Segredo == "" {
+		return errors.New("usuário e segredo são obrigatórios")
+	}
+	r.cadastros[c.UsuarioID] = copiarCadastro(c)
+	return nil
+}
+
+// DeleteCadastro remove o segundo fator do usuário
+func (r *MemoryRepository) DeleteCadastro(usuarioID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.cadastros[usuarioID]; !exists {
+		return errors.New("segundo fator não cadastrado")
+	}
+	delete(r.cadastros, usuarioID)
+	return nil
+}
+
+// GetPolitica busca a política da empresa
+func (r *MemoryRepository) GetPolitica(empresaID int) (*Politica, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, exists := r.politicas[empresaID]
+	if !exists {
+		return nil, errors.New("política não encontrada")
+	}
+	c := *p
+	return &c, nil
+}
+
+// SalvarPolitica cria ou substitui a política da empresa
+func (r *MemoryRepository) SalvarPolitica(p *Politica) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+	p.DataAtualizacao = time.Now()
+	c := *p
+	r.politicas[p.EmpresaID] = &c
+	return nil
+}
+
+// CreateDesafio adiciona um desafio de login
+func (r *MemoryRepository) CreateDesafio(d *Desafio) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if d.Hash == "" || d.UsuarioID <= 0 {
+		return errors.New("hash e usuário são obrigatórios")
+	}
+
+	// Aproveita a escrita para descartar os desafios expirados
+	agora := time.Now()
+	for hash, existente := range r.desafios {
+		if agora.After(existente.Expira) {
+			delete(r.desafios, hash)
+		}
+	}
+
+	c := *d
+	r.desafios[d.Hash] = &c
+	return nil
+}
+
+// GetDesafio busca um desafio pelo hash do valor entregue ao cliente
+func (r *MemoryRepository) GetDesafio(hash string) (*Desafio, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, exists := r.desafios[hash]
+	if !exists {
+		return nil, ErrDesafioInvalido
+	}
+	c := *d
+	return &c, nil
+}
+
+// UpdateDesafio atualiza um desafio existente
+func (r *MemoryRepository) UpdateDesafio(d *Desafio) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.desafios[d.Hash]; !exists {
+		return ErrDesafioInvalido
+	}
+	c := *d
+	r.desafios[d.Hash] = &c
+	return nil
+}
+
+// DeleteDesafio remove um desafio
+func (r *MemoryRepository) DeleteDesafio(hash string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.desafios[hash]; !exists {
+		return ErrDesafioInvalido
+	}
+	delete(r.desafios, hash)
+	return nil
+}
+
+func copiarCadastro(c *Cadastro) *Cadastro {
+	cp := *c
+	cp.CodigosRecuperacao = append([]string(nil), c.CodigosRecuperacao...)
+	return &cp
+}
@@ -0,0 +1,362 @@
+package doisfatores
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/Pantaleaogc/gvero/pkg/qrcode"
+	"github.com/Pantaleaogc/gvero/pkg/totp"
+)
+
+// papelAdmin é o Tipo de usuário alcançado pela política da empresa
+const papelAdmin = "admin"
+
+// alfabetoRecuperacao evita caracteres confundíveis (0/o, 1/l/i)
+const alfabetoRecuperacao = "abcdefghjkmnpqrstuvwxyz23456789"
+
+// Service cadastra o segundo fator dos usuários e conduz a segunda etapa do login
+type Service struct {
+	repo     Repository
+	usuarios usuario.Repository
+	emissor  string
+	agora    func() time.Time
+
+	// mu serializa as verificações, para que um código só seja aceito uma vez
+	mu sync.Mutex
+}
+
+// NewService cria um novo serviço. emissor é o nome exibido nos aplicativos autenticadores.
+func NewService(repo Repository, usuarios usuario.Repository, emissor string) *Service {
+	return &Service{
+		repo:     repo,
+		usuarios: usuarios,
+		emissor:  emissor,
+		agora:    time.Now,
+	}
+}
+
+// Ativo indica se o usuário ativou o segundo fator
+func (s *Service) Ativo(usuarioID int) bool {
+	c, err := s.repo.GetCadastro(usuarioID)
+	return err == nil && c.Ativo
+}
+
+// Exigido indica se a política da empresa obriga o usuário a usar o segundo fator
+func (s *Service) Exigido(u *usuario.Usuario, empresaID int) bool {
+	if u.Tipo != papelAdmin {
+		return false
+	}
+	p, err := s.repo.GetPolitica(empresaID)
+	return err == nil && p.ExigirAdmins
+}
+
+// Status retorna a situação do segundo fator do usuário
+func (s *Service) Status(u *usuario.Usuario, empresaID int) *Status {
+	st := &Status{Exigido: s.Exigido(u, empresaID)}
+	if c, err := s.repo.GetCadastro(u.ID); err == nil && c.Ativo {
+		st.Ativo = true
+		st.CodigosRestantes = len(c.CodigosRecuperacao)
+		st.DataAtivacao = c.DataAtivacao
+	}
+	return st
+}
+
+// Cadastrar gera um novo segredo pendente para o usuário configurar o aplicativo. O segredo
+// só passa a valer na ativação; um cadastro pendente anterior é substituído.
+func (s *Service) Cadastrar(u *usuario.Usuario) (*Provisionamento, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, err := s.repo.GetCadastro(u.ID); err == nil && c.Ativo {
+		return nil, errors.New("segundo fator já ativo; desative-o antes de cadastrar outro aplicativo")
+	}
+
+	segredo, err := totp.GerarSegredo()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SalvarCadastro(&Cadastro{UsuarioID: u.ID, Segredo: segredo, DataCriacao: s.agora()}); err != nil {
+		return nil, err
+	}
+
+	url := totp.URL(s.emissor, u.Email, segredo)
+	png, err := qrcode.PNG(url, 256)
+	if err != nil {
+		return nil, err
+	}
+	return &Provisionamento{
+		Segredo: segredo,
+		URL:     url,
+		QRCode:  "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Ativar confirma o cadastro pendente com o primeiro código do aplicativo e retorna os
+// códigos de recuperação, exibidos apenas esta vez
+func (s *Service) Ativar(usuarioID int, codigo string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.ativar(usuarioID, codigo)
+}
+
+// ativar confirma o cadastro pendente. Deve ser chamado com s.mu travado.
+func (s *Service) ativar(usuarioID int, codigo string) ([]string, error) {
+	c, err := s.repo.GetCadastro(usuarioID)
+	if err != nil {
+		return nil, errors.New("inicie o cadastro do segundo fator")
+	}
+	if c.Ativo {
+		return nil, errors.New("segundo fator já ativo")
+	}
+
+	passo, ok := totp.Validar(c.Segredo, codigo, s.agora())
+	if !ok {
+		return nil, ErrCodigoInvalido
+	}
+
+	codigos, hashes, err := gerarCodigosRecuperacao()
+	if err != nil {
+		return nil, err
+	}
+	agora := s.agora()
+	c.Ativo = true
+	c.UltimoPasso = passo
+	c.CodigosRecuperacao = hashes
+	c.DataAtivacao = &agora
+	if err := s.repo.SalvarCadastro(c); err != nil {
+		return nil, err
+	}
+
+	logger.InfoLogger.Printf("Segundo fator ativado para o usuário %d", usuarioID)
+	return codigos, nil
+}
+
+// Desativar remove o segundo fator mediante um código válido. Usuários obrigados pela
+// política da empresa não podem desativá-lo.
+func (s *Service) Desativar(u *usuario.Usuario, empresaID int, codigo string) error {
+	if s.Exigido(u, empresaID) {
+		return errors.New("a política da empresa exige o segundo fator para administradores")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.verificar(u.ID, codigo); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteCadastro(u.ID); err != nil {
+		return err
+	}
+
+	logger.InfoLogger.Printf("Segundo fator desativado para o usuário %d", u.ID)
+	return nil
+}
+
+// RenovarCodigosRecuperacao invalida os códigos de recuperação e gera novos, mediante um código válido
+func (s *Service) RenovarCodigosRecuperacao(usuarioID int, codigo string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.verificar(usuarioID, codigo); err != nil {
+		return nil, err
+	}
+	c, err := s.repo.GetCadastro(usuarioID)
+	if err != nil {
+		return nil, err
+	}
+
+	codigos, hashes, err := gerarCodigosRecuperacao()
+	if err != nil {
+		return nil, err
+	}
+	c.CodigosRecuperacao = hashes
+	if err := s.repo.SalvarCadastro(c); err != nil {
+		return nil, err
+	}
+	return codigos, nil
+}
+
+// IniciarDesafio cria a etapa pendente do login depois da senha. Com cadastro, o usuário
+// ainda não tem o segundo fator e precisa cadastrá-lo para entrar.
+func (s *Service) IniciarDesafio(usuarioID int, cadastro bool) (string, *Desafio, error) {
+	valor, err := gerarValor()
+	if err != nil {
+		return "", nil, err
+	}
+	d := &Desafio{
+		Hash:      hashValor(valor),
+		UsuarioID: usuarioID,
+		Cadastro:  cadastro,
+		Expira:    s.agora().Add(ValidadeDesafio),
+	}
+	if err := s.repo.CreateDesafio(d); err != nil {
+		return "", nil, err
+	}
+	return valor, d, nil
+}
+
+// Desafio carrega o desafio pendente
+func (s *Service) Desafio(valor string) (*Desafio, error) {
+	if valor == "" {
+		return nil, ErrDesafioInvalido
+	}
+	d, err := s.repo.GetDesafio(hashValor(valor))
+	if err != nil || !s.agora().Before(d.Expira) || d.Tentativas >= MaxTentativasDesafio {
+		return nil, ErrDesafioInvalido
+	}
+	return d, nil
+}
+
+// ConcluirDesafio verifica o código TOTP ou de recuperação e encerra o desafio, retornando o
+// usuário autenticado. Cada erro consome uma tentativa.
+func (s *Service) ConcluirDesafio(valor, codigo string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, err := s.Desafio(valor)
+	if err != nil {
+		return 0, err
+	}
+	if d.Cadastro {
+		return 0, errors.New("cadastre o segundo fator para concluir o login")
+	}
+
+	if err := s.verificar(d.UsuarioID, codigo); err != nil {
+		return 0, s.falhar(d, err)
+	}
+
+	if err := s.repo.DeleteDesafio(d.Hash); err != nil {
+		return 0, ErrDesafioInvalido
+	}
+	return d.UsuarioID, nil
+}
+
+// AtivarPorDesafio conclui o cadastro obrigatório iniciado no login: ativa o segundo fator com o
+// primeiro código e encerra o desafio. Cada código errado consome uma tentativa do desafio.
+func (s *Service) AtivarPorDesafio(valor, codigo string) (int, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	d, err := s.Desafio(valor)
+	if err != nil {
+		return 0, nil, err
+	}
+	if !d.Cadastro {
+		return 0, nil, ErrDesafioInvalido
+	}
+
+	codigos, err := s.ativar(d.UsuarioID, codigo)
+	if errors.Is(err, ErrCodigoInvalido) {
+		return 0, nil, s.falhar(d, err)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+	s.repo.DeleteDesafio(d.Hash)
+	return d.UsuarioID, codigos, nil
+}
+
+// falhar consome uma tentativa do desafio e o encerra ao atingir o limite, exigindo a senha de novo.
+// Deve ser chamado com s.mu travado.
+func (s *Service) falhar(d *Desafio, err error) error {
+	d.Tentativas++
+	if d.Tentativas >= MaxTentativasDesafio {
+		s.repo.DeleteDesafio(d.Hash)
+		logger.InfoLogger.Printf("Desafio de segundo fator esgotado para o usuário %d", d.UsuarioID)
+		return ErrDesafioInvalido
+	}
+	s.repo.UpdateDesafio(d)
+	return err
+}
+
+// Politica retorna a política da empresa; sem política gravada, nada é exigido
+func (s *Service) Politica(empresaID int) *Politica {
+	p, err := s.repo.GetPolitica(empresaID)
+	if err != nil {
+		return &Politica{EmpresaID: empresaID}
+	}
+	return p
+}
+
+// SalvarPolitica altera a política da empresa
+func (s *Service) SalvarPolitica(p *Politica) (*Politica, error) {
+	if err := s.repo.SalvarPolitica(p); err != nil {
+		return nil, err
+	}
+	return s.Politica(p.EmpresaID), nil
+}
+
+// verificar aceita o código TOTP do segundo fator ativo ou consome um código de recuperação.
+// Deve ser chamado com s.mu travado.
+func (s *Service) verificar(usuarioID int, codigo string) error {
+	c, err := s.repo.GetCadastro(usuarioID)
+	if err != nil || !c.Ativo {
+		return errors.New("segundo fator não ativo")
+	}
+
+	if passo, ok := totp.Validar(c.Segredo, codigo, s.agora()); ok {
+		// O mesmo código não vale duas vezes dentro da sua janela
+		if passo <= c.UltimoPasso {
+			return ErrCodigoInvalido
+		}
+		c.UltimoPasso = passo
+		return s.repo.SalvarCadastro(c)
+	}
+
+	hash := hashValor(normalizarRecuperacao(codigo))
+	for i, h := range c.CodigosRecuperacao {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			c.CodigosRecuperacao = append(c.CodigosRecuperacao[:i], c.CodigosRecuperacao[i+1:]...)
+			logger.InfoLogger.Printf("Código de recuperação usado pelo usuário %d; restam %d", usuarioID, len(c.CodigosRecuperacao))
+			return s.repo.SalvarCadastro(c)
+		}
+	}
+	return ErrCodigoInvalido
+}
+
+// gerarCodigosRecuperacao gera os códigos no formato xxxxx-xxxxx e os respectivos hashes
+func gerarCodigosRecuperacao() ([]string, []string, error) {
+	codigos := make([]string, QuantidadeCodigosRecuperacao)
+	hashes := make([]string, QuantidadeCodigosRecuperacao)
+	for i := range codigos {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		for j := range b {
+			b[j] = alfabetoRecuperacao[int(b[j])%len(alfabetoRecuperacao)]
+		}
+		codigos[i] = string(b[:5]) + "-" + string(b[5:])
+		hashes[i] = hashValor(string(b))
+	}
+	return codigos, hashes, nil
+}
+
+// normalizarRecuperacao aceita os códigos com ou sem hífen e em maiúsculas
+func normalizarRecuperacao(codigo string) string {
+	codigo = strings.ToLower(strings.TrimSpace(codigo))
+	return strings.NewReplacer("-", "", " ", "").Replace(codigo)
+}
+
+func gerarValor() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashValor(valor string) string {
+	h := sha256.Sum256([]byte(valor))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,152 @@
+package doisfatores
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/Pantaleaogc/gvero/pkg/totp"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// novoServico cria o serviço com um usuário cadastrado e o relógio controlado pelo teste
+func novoServico(t *testing.T) (*Service, *usuario.Usuario, *time.Time) {
+	t.Helper()
+	usuarios := usuario.NewMemoryRepository()
+	u := &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "usuario", EmpresaID: 1}
+	if err := usuarios.Create(u); err != nil {
+		t.Fatal(err)
+	}
+	agora := time.Unix(1700000000, 0)
+	s := NewService(NewMemoryRepository(), usuarios, "Gvero")
+	s.agora = func() time.Time { return agora }
+	return s, u, &agora
+}
+
+func codigo(t *testing.T, segredo string, em time.Time) string {
+	t.Helper()
+	c, err := totp.Codigo(segredo, em)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// codigoErrado retorna um código de 6 dígitos que não vale em nenhum passo da tolerância
+func codigoErrado(segredo string, em time.Time) string {
+	b := []byte("000000")
+	for {
+		if _, ok := totp.Validar(segredo, string(b), em); !ok {
+			return string(b)
+		}
+		b[0]++
+	}
+}
+
+func TestConcluirDesafioRecusaReutilizacao(t *testing.T) {
+	s, u, agora := novoServico(t)
+	p, err := s.Cadastrar(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ativacao := codigo(t, p.Segredo, *agora)
+	if _, err := s.Ativar(u.ID, ativacao); err != nil {
+		t.Fatalf("Ativar: %v", err)
+	}
+
+	// O código da ativação não conclui o login, nem dentro da tolerância do passo seguinte
+	*agora = agora.Add(totp.Periodo)
+	valor, _, err := s.IniciarDesafio(u.ID, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.ConcluirDesafio(valor, ativacao); !errors.Is(err, ErrCodigoInvalido) {
+		t.Fatalf("código reutilizado: %v", err)
+	}
+
+	novo := codigo(t, p.Segredo, *agora)
+	if id, err := s.ConcluirDesafio(valor, novo); err != nil || id != u.ID {
+		t.Fatalf("ConcluirDesafio = %d, %v", id, err)
+	}
+	if _, err := s.ConcluirDesafio(valor, novo); !errors.Is(err, ErrDesafioInvalido) {
+		t.Errorf("desafio concluído reutilizado: %v", err)
+	}
+
+	// Um login novo também não aceita o código já usado
+	outro, _, _ := s.IniciarDesafio(u.ID, false)
+	if _, err := s.ConcluirDesafio(outro, novo); !errors.Is(err, ErrCodigoInvalido) {
+		t.Errorf("código reutilizado em outro desafio: %v", err)
+	}
+}
+
+func TestConcluirDesafioEsgotaTentativas(t *testing.T) {
+	s, u, agora := novoServico(t)
+	p, _ := s.Cadastrar(u)
+	if _, err := s.Ativar(u.ID, codigo(t, p.Segredo, *agora)); err != nil {
+		t.Fatal(err)
+	}
+	*agora = agora.Add(totp.Periodo)
+
+	valor, _, _ := s.IniciarDesafio(u.ID, false)
+	correto := codigo(t, p.Segredo, *agora)
+	for i := 1; i < MaxTentativasDesafio; i++ {
+		if _, err := s.ConcluirDesafio(valor, codigoErrado(p.Segredo, *agora)); !errors.Is(err, ErrCodigoInvalido) {
+			t.Fatalf("tentativa %d: %v", i, err)
+		}
+	}
+	if _, err := s.ConcluirDesafio(valor, codigoErrado(p.Segredo, *agora)); !errors.Is(err, ErrDesafioInvalido) {
+		t.Fatalf("última tentativa: %v", err)
+	}
+	if _, err := s.ConcluirDesafio(valor, correto); !errors.Is(err, ErrDesafioInvalido) {
+		t.Errorf("desafio esgotado aceitou o código correto: %v", err)
+	}
+}
+
+func TestAtivarPorDesafioEsgotaTentativas(t *testing.T) {
+	s, u, agora := novoServico(t)
+	valor, _, err := s.IniciarDesafio(u.ID, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := s.Cadastrar(u)
+	correto := codigo(t, p.Segredo, *agora)
+
+	for i := 1; i < MaxTentativasDesafio; i++ {
+		if _, _, err := s.AtivarPorDesafio(valor, codigoErrado(p.Segredo, *agora)); !errors.Is(err, ErrCodigoInvalido) {
+			t.Fatalf("tentativa %d: %v", i, err)
+		}
+	}
+	if _, _, err := s.AtivarPorDesafio(valor, codigoErrado(p.Segredo, *agora)); !errors.Is(err, ErrDesafioInvalido) {
+		t.Fatalf("última tentativa: %v", err)
+	}
+
+	// Esgotado, o desafio não ativa o segundo fator nem com o código correto
+	if _, _, err := s.AtivarPorDesafio(valor, correto); !errors.Is(err, ErrDesafioInvalido) {
+		t.Errorf("desafio esgotado aceitou o código correto: %v", err)
+	}
+	if s.Ativo(u.ID) {
+		t.Error("segundo fator ativado por desafio esgotado")
+	}
+
+	// Um novo login recomeça as tentativas
+	valor, _, _ = s.IniciarDesafio(u.ID, true)
+	id, codigos, err := s.AtivarPorDesafio(valor, correto)
+	if err != nil || id != u.ID || len(codigos) != QuantidadeCodigosRecuperacao {
+		t.Fatalf("AtivarPorDesafio = %d, %d códigos, %v", id, len(codigos), err)
+	}
+	if !s.Ativo(u.ID) {
+		t.Error("segundo fator não ativado")
+	}
+}
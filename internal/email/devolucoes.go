@@ -0,0 +1,67 @@
+package email
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// cabecalhoAssinatura contém a assinatura HMAC do corpo da notificação de devoluções
+const cabecalhoAssinatura = "X-Email-Signature"
+
+// tamanhoMaximoDevolucoes limita o corpo aceito no webhook
+const tamanhoMaximoDevolucoes = 1 << 20
+
+// NotificacaoDevolucoes é o corpo enviado pelo provedor de e-mail ao receber devoluções
+type NotificacaoDevolucoes struct {
+	Devolucoes []NotificacaoDevolucao `json:"devolucoes"`
+}
+
+// DevolucoesRoutes retorna a rota que recebe as devoluções e reclamações do provedor de e-mail.
+// As requisições são autenticadas pela assinatura HMAC, não pelo JWT.
+func DevolucoesRoutes(service *Service, segredo string) http.Handler {
+	r := chi.NewRouter()
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, tamanhoMaximoDevolucoes))
+		if err != nil {
+			http.Error(w, "Erro ao ler requisição", http.StatusBadRequest)
+			return
+		}
+
+		if segredo == "" || !hmac.Equal([]byte(r.Header.Get(cabecalhoAssinatura)), []byte(Assinar(body, segredo))) {
+			logger.InfoLogger.Printf("Notificação de devoluções com assinatura inválida de %s", r.RemoteAddr)
+			http.Error(w, "Assinatura inválida", http.StatusUnauthorized)
+			return
+		}
+
+		var n NotificacaoDevolucoes
+		if err := json.Unmarshal(body, &n); err != nil {
+			http.Error(w, "Formato inválido", http.StatusBadRequest)
+			return
+		}
+
+		// Devoluções de mensagens desconhecidas são descartadas para o provedor não reenviá-las
+		for _, d := range n.Devolucoes {
+			if _, err := service.RegistrarDevolucao(d); err != nil {
+				logger.InfoLogger.Printf("Devolução de %s (%s) ignorada: %v", d.Destinatario, d.MessageID, err)
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return r
+}
+
+// Assinar calcula a assinatura HMAC-SHA256 esperada no cabeçalho do webhook de devoluções
+func Assinar(body []byte, segredo string) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
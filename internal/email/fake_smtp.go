@@ -0,0 +1,177 @@
+package email
+
+import (
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dominioRecusado é recusado pelo FakeSMTP, para simular devoluções (RFC 2606)
+const dominioRecusado = ".invalid"
+
+// Recebida é uma mensagem aceita pelo FakeSMTP
+type Recebida struct {
+	De    string
+	Para  []string
+	Dados []byte
+	Data  time.Time
+}
+
+// FakeSMTP simula um servidor SMTP local, sem TLS nem autenticação, para desenvolvimento e testes.
+// Guarda as mensagens recebidas e recusa destinatários de domínios terminados em ".invalid".
+type FakeSMTP struct {
+	listener net.Listener
+
+	mu        sync.Mutex
+	recebidas []Recebida
+	conexoes  map[net.Conn]struct{}
+	wg        sync.WaitGroup
+}
+
+// NewFakeSMTP inicia o servidor simulado no endereço informado (ex.: "127.0.0.1:0")
+func NewFakeSMTP(endereco string) (*FakeSMTP, error) {
+	l, err := net.Listen("tcp", endereco)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &FakeSMTP{listener: l, conexoes: make(map[net.Conn]struct{})}
+	f.wg.Add(1)
+	go f.aceitar()
+	return f, nil
+}
+
+// Host retorna o host em que o servidor escuta
+func (f *FakeSMTP) Host() string {
+	host, _, _ := net.SplitHostPort(f.listener.Addr().String())
+	return host
+}
+
+// Porta retorna a porta em que o servidor escuta
+func (f *FakeSMTP) Porta() int {
+	return f.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Recebidas retorna as mensagens aceitas, das mais antigas para as mais recentes
+func (f *FakeSMTP) Recebidas() []Recebida {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Recebida(nil), f.recebidas...)
+}
+
+// Fechar encerra o servidor e as conexões abertas
+func (f *FakeSMTP) Fechar() error {
+	err := f.listener.Close()
+	f.mu.Lock()
+	for c := range f.conexoes {
+		c.Close()
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+	return err
+}
+
+func (f *FakeSMTP) aceitar() {
+	defer f.wg.Done()
+	for {
+		conn, err := f.listener.Accept()
+		if err != nil {
+			return
+		}
+		f.mu.Lock()
+		f.conexoes[conn] = struct{}{}
+		f.mu.Unlock()
+
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.atender(conn)
+			f.mu.Lock()
+			delete(f.conexoes, conn)
+			f.mu.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// atender conduz a sessão SMTP de uma conexão
+func (f *FakeSMTP) atender(conn net.Conn) {
+	tp := textproto.NewConn(conn)
+	responder := func(codigo int, texto string) bool {
+		return tp.PrintfLine("%d %s", codigo, texto) == nil
+	}
+
+	if !responder(220, "gvero fake SMTP") {
+		return
+	}
+
+	var de string
+	var para []string
+	for {
+		conn.SetReadDeadline(time.Now().Add(time.Minute))
+		linha, err := tp.ReadLine()
+		if err != nil {
+			return
+		}
+		comando, argumento, _ := strings.Cut(linha, " ")
+		argumento = strings.TrimSpace(argumento)
+
+		switch strings.ToUpper(comando) {
+		case "EHLO":
+			tp.PrintfLine("250-gvero")
+			tp.PrintfLine("250-8BITMIME")
+			responder(250, "SMTPUTF8")
+		case "HELO":
+			responder(250, "gvero")
+		case "MAIL":
+			de, para = endereco(argumento), nil
+			responder(250, "OK")
+		case "RCPT":
+			destinatario := endereco(argumento)
+			if strings.HasSuffix(strings.ToLower(destinatario), dominioRecusado) {
+				responder(550, "5.1.1 destinatário inexistente")
+				continue
+			}
+			para = append(para, destinatario)
+			responder(250, "OK")
+		case "DATA":
+			if de == "" || len(para) == 0 {
+				responder(503, "5.5.1 remetente e destinatários são necessários")
+				continue
+			}
+			if !responder(354, "envie a mensagem terminando com <CRLF>.<CRLF>") {
+				return
+			}
+			dados, err := tp.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.recebidas = append(f.recebidas, Recebida{De: de, Para: para, Dados: dados, Data: time.Now()})
+			f.mu.Unlock()
+			de, para = "", nil
+			responder(250, "OK")
+		case "RSET":
+			de, para = "", nil
+			responder(250, "OK")
+		case "NOOP":
+			responder(250, "OK")
+		case "QUIT":
+			responder(221, "até logo")
+			return
+		default:
+			responder(502, "5.5.2 comando não implementado")
+		}
+	}
+}
+
+// endereco extrai o endereço de argumentos como "FROM:<a@b.com> SIZE=10"
+func endereco(argumento string) string {
+	inicio, fim := strings.Index(argumento, "<"), strings.Index(argumento, ">")
+	if inicio < 0 || fim < inicio {
+		return ""
+	}
+	return argumento[inicio+1 : fim]
+}
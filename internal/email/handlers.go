@@ -0,0 +1,371 @@
+package email
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP dos e-mails
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas do registro de envios, dos modelos, da marca e da lista de supressão,
+// restritas a administradores
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+	r.Use(auth.RequireRole("admin"))
+
+	r.Post("/teste", h.Testar)
+
+	r.Get("/modelos", h.ListModelos)
+	r.Put("/modelos/{nome}/{idioma}", h.SalvarModelo)
+	r.Delete("/modelos/{nome}/{idioma}", h.RestaurarModelo)
+	r.Post("/modelos/{nome}/{idioma}/previa", h.Previa)
+
+	r.Get("/marca", h.GetMarca)
+	r.Put("/marca", h.SalvarMarca)
+
+	r.Get("/supressoes", h.ListSupressoes)
+	r.Delete("/supressoes/{email}", h.DeleteSupressao)
+
+	r.Get("/", h.List)
+	r.Get("/{id}", h.Get)
+	r.Get("/{id}/anexos/{indice}", h.Anexo)
+	r.Post("/{id}/reenviar", h.Reenviar)
+
+	return r
+}
+
+// List lista as mensagens da empresa, sem o conteúdo dos anexos. Filtros: status, modelo e para.
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	if limit <= 0 {
+		limit = 50 // valor padrão
+	}
+
+	mensagens, err := h.repo.ListMensagens(FiltroMensagens{
+		EmpresaID: user.Empresa,
+		Status:    q.Get("status"),
+		Modelo:    q.Get("modelo"),
+		Para:      q.Get("para"),
+	}, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	for _, m := range mensagens {
+		semConteudo(m)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mensagens)
+}
+
+// Get retorna uma mensagem com o histórico de devoluções, sem o conteúdo dos anexos
+func (h *Handlers) Get(w http.ResponseWriter, r *http.Request) {
+	m, ok := h.mensagem(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(semConteudo(m))
+}
+
+// Anexo baixa um anexo da mensagem, pela posição iniciada em zero
+func (h *Handlers) Anexo(w http.ResponseWriter, r *http.Request) {
+	m, ok := h.mensagem(w, r)
+	if !ok {
+		return
+	}
+
+	indice, err := strconv.Atoi(chi.URLParam(r, "indice"))
+	if err != nil || indice < 0 || indice >= len(m.Anexos) {
+		http.Error(w, "anexo não encontrado", http.StatusNotFound)
+		return
+	}
+	a := m.Anexos[indice]
+
+	w.Header().Set("Content-Type", tipoAnexo(a))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", a.Nome))
+	w.Write(a.Conteudo)
+}
+
+// Reenviar devolve a mensagem à fila de envio
+func (h *Handlers) Reenviar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.service.Reenviar(user.Empresa, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(semConteudo(m))
+}
+
+// Testar envia o e-mail de teste para o endereço informado
+func (h *Handlers) Testar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in struct {
+		Para   string `json:"para"`
+		Idioma string `json:"idioma"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.service.Testar(user.Empresa, user.ID, in.Para, in.Idioma)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(m)
+}
+
+// ListModelos lista os modelos da empresa em todos os idiomas
+func (h *Handlers) ListModelos(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	modelos, err := h.service.Modelos(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(modelos)
+}
+
+// SalvarModelo personaliza um modelo para a empresa
+func (h *Handlers) SalvarModelo(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var m Modelo
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.EmpresaID = user.Empresa
+	m.Nome = chi.URLParam(r, "nome")
+	m.Idioma = chi.URLParam(r, "idioma")
+
+	salvo, err := h.service.SalvarModelo(&m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(salvo)
+}
+
+// RestaurarModelo remove a personalização, voltando ao modelo embutido
+func (h *Handlers) RestaurarModelo(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.repo.DeleteModelo(user.Empresa, chi.URLParam(r, "nome"), chi.URLParam(r, "idioma")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Previa renderiza o modelo com a marca da empresa e os dados enviados no corpo, sem enviar
+func (h *Handlers) Previa(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var dados map[string]interface{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&dados); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	previa, err := h.service.Renderizar(user.Empresa, chi.URLParam(r, "nome"), chi.URLParam(r, "idioma"), dados)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(previa)
+}
+
+// GetMarca retorna a marca efetiva dos e-mails da empresa
+func (h *Handlers) GetMarca(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	marca, err := h.service.Marca(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(marca)
+}
+
+// SalvarMarca altera a marca dos e-mails da empresa
+func (h *Handlers) SalvarMarca(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var m Marca
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	m.EmpresaID = user.Empresa
+
+	marca, err := h.service.SalvarMarca(&m)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(marca)
+}
+
+// ListSupressoes lista os endereços que não recebem mais e-mails da empresa
+func (h *Handlers) ListSupressoes(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	supressoes, err := h.repo.ListSupressoes(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(supressoes)
+}
+
+// DeleteSupressao volta a permitir envios ao endereço, por exemplo depois de corrigido
+func (h *Handlers) DeleteSupressao(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.repo.DeleteSupressao(user.Empresa, chi.URLParam(r, "email")); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// mensagem carrega a mensagem da URL garantindo que pertence à empresa
+func (h *Handlers) mensagem(w http.ResponseWriter, r *http.Request) (*Mensagem, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	m, err := h.repo.GetMensagem(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+	return m, true
+}
+
+// semConteudo remove o conteúdo dos anexos da mensagem, mantendo nomes e tamanhos
+func semConteudo(m *Mensagem) *Mensagem {
+	anexos := make([]Anexo, len(m.Anexos))
+	for i, a := range m.Anexos {
+		a.Conteudo = nil
+		anexos[i] = a
+	}
+	m.Anexos = anexos
+	return m
+}
@@ -0,0 +1,128 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// montar gera a mensagem no formato MIME: multipart/alternative com texto e HTML e, havendo anexos,
+// envolvida em multipart/mixed
+func montar(m *Mensagem, nomeRemetente string, data time.Time) ([]byte, error) {
+	var buf bytes.Buffer
+
+	de := (&mail.Address{Name: nomeRemetente, Address: m.De}).String()
+	cabecalho(&buf, "From", de)
+	cabecalho(&buf, "To", strings.Join(m.Para, ", "))
+	if m.ResponderPara != "" {
+		cabecalho(&buf, "Reply-To", m.ResponderPara)
+	}
+	cabecalho(&buf, "Subject", mime.QEncoding.Encode("utf-8", m.Assunto))
+	cabecalho(&buf, "Date", data.Format(time.RFC1123Z))
+	cabecalho(&buf, "Message-ID", m.MessageID)
+	cabecalho(&buf, "MIME-Version", "1.0")
+	cabecalho(&buf, "X-Gvero-Mensagem", strconv.Itoa(m.ID))
+
+	var alternativo bytes.Buffer
+	corpo := multipart.NewWriter(&alternativo)
+	if err := escreverTexto(corpo, "text/plain; charset=utf-8", m.Texto); err != nil {
+		return nil, err
+	}
+	if err := escreverTexto(corpo, "text/html; charset=utf-8", m.HTML); err != nil {
+		return nil, err
+	}
+	if err := corpo.Close(); err != nil {
+		return nil, err
+	}
+	tipoCorpo := "multipart/alternative; boundary=" + corpo.Boundary()
+
+	if len(m.Anexos) == 0 {
+		cabecalho(&buf, "Content-Type", tipoCorpo)
+		buf.WriteString("\r\n")
+		buf.Write(alternativo.Bytes())
+		return buf.Bytes(), nil
+	}
+
+	mixed := multipart.NewWriter(&buf)
+	cabecalho(&buf, "Content-Type", "multipart/mixed; boundary="+mixed.Boundary())
+	buf.WriteString("\r\n")
+
+	parte, err := mixed.CreatePart(textproto.MIMEHeader{"Content-Type": {tipoCorpo}})
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parte.Write(alternativo.Bytes()); err != nil {
+		return nil, err
+	}
+	for _, a := range m.Anexos {
+		if err := escreverAnexo(mixed, a); err != nil {
+			return nil, err
+		}
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func cabecalho(buf *bytes.Buffer, nome, valor string) {
+	fmt.Fprintf(buf, "%s: %s\r\n", nome, valor)
+}
+
+func escreverTexto(w *multipart.Writer, tipo, conteudo string) error {
+	parte, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {tipo},
+		"Content-Transfer-Encoding": {"quoted-printable"},
+	})
+	if err != nil {
+		return err
+	}
+	qp := quotedprintable.NewWriter(parte)
+	if _, err := qp.Write([]byte(conteudo)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func escreverAnexo(w *multipart.Writer, a Anexo) error {
+	parte, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {mime.FormatMediaType(tipoAnexo(a), map[string]string{"name": a.Nome})},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Nome})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return err
+	}
+
+	// Linhas de no máximo 76 caracteres (RFC 2045)
+	codificado := base64.StdEncoding.EncodeToString(a.Conteudo)
+	for len(codificado) > 76 {
+		if _, err := parte.Write([]byte(codificado[:76] + "\r\n")); err != nil {
+			return err
+		}
+		codificado = codificado[76:]
+	}
+	_, err = parte.Write([]byte(codificado + "\r\n"))
+	return err
+}
+
+// tipoAnexo retorna o tipo informado ou o deduzido pela extensão do nome
+func tipoAnexo(a Anexo) string {
+	if a.Tipo != "" {
+		return a.Tipo
+	}
+	if t := mime.TypeByExtension(filepath.Ext(a.Nome)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,144 @@
+package email
+
+import (
+	"time"
+)
+
+// Status das mensagens
+const (
+	StatusPendente  = "pendente"  // na fila de envio
+	StatusEnviado   = "enviado"   // aceita pelo servidor SMTP
+	StatusFalhou    = "falhou"    // recusada pelo servidor ou tentativas esgotadas
+	StatusDevolvido = "devolvido" // ao menos um destinatário devolveu a mensagem
+	StatusSuprimido = "suprimido" // todos os destinatários estão na lista de supressão
+)
+
+// Tipos de devolução
+const (
+	DevolucaoPermanente = "permanente" // endereço inexistente ou recusado; o endereço é suprimido
+	DevolucaoTemporaria = "temporaria" // caixa cheia, servidor indisponível; apenas registrada
+	DevolucaoReclamacao = "reclamacao" // destinatário marcou como spam; o endereço é suprimido
+)
+
+// Idiomas com modelos embutidos
+const (
+	IdiomaPortugues = "pt-BR"
+	IdiomaIngles    = "en"
+)
+
+// Idiomas lista os idiomas aceitos nos modelos
+var Idiomas = []string{IdiomaPortugues, IdiomaIngles}
+
+// Limites de cada mensagem
+const (
+	MaxDestinatarios   = 50
+	TamanhoMaximoAnexo = 10 << 20 // soma dos anexos
+)
+
+// Anexo é um arquivo enviado com a mensagem
+type Anexo struct {
+	Nome     string `json:"nome"`
+	Tipo     string `json:"tipo,omitempty"` // tipo MIME; vazio deduz pela extensão do nome
+	Tamanho  int    `json:"tamanho"`
+	Conteudo []byte `json:"conteudo,omitempty"`
+}
+
+// Devolucao registra a devolução de uma mensagem por um destinatário
+type Devolucao struct {
+	Destinatario string    `json:"destinatario"`
+	Tipo         string    `json:"tipo"`
+	Motivo       string    `json:"motivo,omitempty"`
+	Data         time.Time `json:"data"`
+}
+
+// Mensagem é um e-mail renderizado e o registro do seu envio
+type Mensagem struct {
+	ID            int         `json:"id"`
+	EmpresaID     int         `json:"empresa_id"`
+	Modelo        string      `json:"modelo"`
+	Idioma        string      `json:"idioma"`
+	NomeRemetente string      `json:"nome_remetente"`
+	De            string      `json:"de"`
+	ResponderPara string      `json:"responder_para,omitempty"`
+	Para          []string    `json:"para"`
+	Assunto       string      `json:"assunto"`
+	Texto         string      `json:"texto"`
+	HTML          string      `json:"html"`
+	Anexos        []Anexo     `json:"anexos,omitempty"`
+	Status        string      `json:"status"`
+	Erro          string      `json:"erro,omitempty"`
+	MessageID     string      `json:"message_id,omitempty"` // cabeçalho Message-ID, usado para associar devoluções
+	JobID         int64       `json:"job_id,omitempty"`
+	Tentativas    int         `json:"tentativas"`
+	Devolucoes    []Devolucao `json:"devolucoes,omitempty"`
+	UsuarioID     int         `json:"usuario_id,omitempty"` // quem solicitou o envio; zero para envios do sistema
+	DataCriacao   time.Time   `json:"data_criacao"`
+	DataEnvio     *time.Time  `json:"data_envio,omitempty"`
+}
+
+// Modelo é o conteúdo de um e-mail em um idioma. Assunto e Texto usam text/template e HTML usa
+// html/template; o modelo "layout" envolve o corpo de todos os outros.
+type Modelo struct {
+	EmpresaID       int       `json:"empresa_id,omitempty"`
+	Nome            string    `json:"nome"`
+	Idioma          string    `json:"idioma"`
+	Assunto         string    `json:"assunto,omitempty"`
+	Texto           string    `json:"texto"`
+	HTML            string    `json:"html"`
+	Personalizado   bool      `json:"personalizado"` // alterado pela empresa; falso para o modelo embutido
+	DataAtualizacao time.Time `json:"data_atualizacao,omitempty"`
+}
+
+// Marca reúne a identidade visual e os dados de remetente dos e-mails da empresa.
+// O logotipo é o cadastrado na empresa.
+type Marca struct {
+	EmpresaID     int    `json:"empresa_id"`
+	NomeRemetente string `json:"nome_remetente"` // vazio usa o nome da empresa
+	ResponderPara string `json:"responder_para"` // vazio usa o e-mail da empresa
+	CorPrimaria   string `json:"cor_primaria"`   // #rrggbb
+	Rodape        string `json:"rodape"`
+	Idioma        string `json:"idioma"` // idioma padrão dos envios
+}
+
+// Supressao impede novos envios a um endereço que devolveu mensagens ou reclamou
+type Supressao struct {
+	EmpresaID  int       `json:"empresa_id"`
+	Email      string    `json:"email"`
+	Motivo     string    `json:"motivo"`
+	MensagemID int       `json:"mensagem_id,omitempty"`
+	Data       time.Time `json:"data"`
+}
+
+// FiltroMensagens restringe a listagem de mensagens; campos zerados não filtram
+type FiltroMensagens struct {
+	EmpresaID int
+	Status    string
+	Modelo    string
+	Para      string
+}
+
+// Repository define a interface para acesso aos dados de e-mails
+type Repository interface {
+	CreateMensagem(m *Mensagem) error
+	GetMensagem(id, empresaID int) (*Mensagem, error)
+	// GetMensagemPorMessageID busca a mensagem de qualquer empresa pelo cabeçalho Message-ID
+	GetMensagemPorMessageID(messageID string) (*Mensagem, error)
+	UpdateMensagem(m *Mensagem) error
+	// ListMensagens retorna as mensagens das mais recentes para as mais antigas
+	ListMensagens(f FiltroMensagens, limit, offset int) ([]*Mensagem, error)
+
+	// GetModelo retorna o modelo personalizado pela empresa
+	GetModelo(empresaID int, nome, idioma string) (*Modelo, error)
+	SalvarModelo(m *Modelo) error
+	DeleteModelo(empresaID int, nome, idioma string) error
+	ListModelos(empresaID int) ([]*Modelo, error)
+
+	// GetMarca retorna a marca gravada pela empresa
+	GetMarca(empresaID int) (*Marca, error)
+	SalvarMarca(m *Marca) error
+
+	Suprimir(s *Supressao) error
+	GetSupressao(empresaID int, email string) (*Supressao, error)
+	ListSupressoes(empresaID int) ([]*Supressao, error)
+	DeleteSupressao(empresaID int, email string) error
+}
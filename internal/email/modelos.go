@@ -0,0 +1,278 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// Modelos embutidos; as empresas podem personalizar cada um por idioma
+const (
+	ModeloLayout           = "layout" // envolve o corpo dos demais; não tem assunto
+	ModeloTeste            = "teste"
+	ModeloDocumentoVenda   = "documento_venda"
+	ModeloRedefinicaoSenha = "redefinicao_senha"
+	ModeloConvite          = "convite"
+)
+
+// CorPadrao é a cor primária das empresas que não definiram a sua
+const CorPadrao = "#1f6feb"
+
+//go:embed modelos
+var arquivosModelos embed.FS
+
+// modelosPadrao contém os modelos embutidos por idioma e nome
+var modelosPadrao = carregarModelos()
+
+// carregarModelos lê os arquivos modelos/<idioma>/<nome>.{assunto,txt,html}
+func carregarModelos() map[string]map[string]*Modelo {
+	modelos := make(map[string]map[string]*Modelo)
+	err := fs.WalkDir(arquivosModelos, "modelos", func(caminho string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		conteudo, err := arquivosModelos.ReadFile(caminho)
+		if err != nil {
+			return err
+		}
+
+		idioma := path.Base(path.Dir(caminho))
+		arquivo := path.Base(caminho)
+		nome, parte := strings.TrimSuffix(arquivo, path.Ext(arquivo)), path.Ext(arquivo)
+
+		if modelos[idioma] == nil {
+			modelos[idioma] = make(map[string]*Modelo)
+		}
+		m := modelos[idioma][nome]
+		if m == nil {
+			m = &Modelo{Nome: nome, Idioma: idioma}
+			modelos[idioma][nome] = m
+		}
+		switch parte {
+		case ".assunto":
+			m.Assunto = strings.TrimSpace(string(conteudo))
+		case ".txt":
+			m.Texto = string(conteudo)
+		case ".html":
+			m.HTML = string(conteudo)
+		}
+		return nil
+	})
+	if err != nil {
+		panic(fmt.Sprintf("modelos de e-mail embutidos inválidos: %v", err))
+	}
+	return modelos
+}
+
+// ModeloPadrao retorna o modelo embutido; idiomas sem o modelo usam o português
+func ModeloPadrao(nome, idioma string) (*Modelo, bool) {
+	m, ok := modelosPadrao[idioma][nome]
+	if !ok {
+		m, ok = modelosPadrao[IdiomaPortugues][nome]
+	}
+	if !ok {
+		return nil, false
+	}
+	c := *m
+	c.Idioma = idioma
+	return &c, true
+}
+
+// NomesModelos retorna os nomes dos modelos embutidos
+func NomesModelos() []string {
+	nomes := make([]string, 0, len(modelosPadrao[IdiomaPortugues]))
+	for nome := range modelosPadrao[IdiomaPortugues] {
+		nomes = append(nomes, nome)
+	}
+	sort.Strings(nomes)
+	return nomes
+}
+
+// IdiomaValido indica se há modelos no idioma
+func IdiomaValido(idioma string) bool {
+	for _, i := range Idiomas {
+		if i == idioma {
+			return true
+		}
+	}
+	return false
+}
+
+// EmpresaModelo são os dados da empresa disponíveis nos modelos
+type EmpresaModelo struct {
+	Nome     string
+	Email    string
+	Telefone string
+	Endereco string
+	LogoURL  string
+}
+
+// dadosModelo é o contexto da execução dos modelos: {{.Empresa.Nome}}, {{.Marca.CorPrimaria}},
+// {{.Dados.<campo>}}; no layout, {{.Assunto}} e {{.Conteudo}} trazem a mensagem renderizada
+type dadosModelo struct {
+	Empresa  EmpresaModelo
+	Marca    Marca
+	Idioma   string
+	Dados    map[string]interface{}
+	Assunto  string
+	Conteudo interface{}
+}
+
+// Renderizado é o resultado da renderização de um modelo
+type Renderizado struct {
+	Assunto string `json:"assunto"`
+	Texto   string `json:"texto"`
+	HTML    string `json:"html"`
+}
+
+// renderizar executa o modelo e o envolve no layout
+func renderizar(m, layout *Modelo, d dadosModelo) (*Renderizado, error) {
+	funcs := funcoes(d.Idioma)
+
+	assunto, err := executarTexto(m.Nome+".assunto", m.Assunto, funcs, d)
+	if err != nil {
+		return nil, err
+	}
+	// Quebras de linha no assunto permitiriam injetar cabeçalhos
+	d.Assunto = strings.Join(strings.Fields(assunto), " ")
+
+	texto, err := executarTexto(m.Nome+".txt", m.Texto, funcs, d)
+	if err != nil {
+		return nil, err
+	}
+	html, err := executarHTML(m.Nome+".html", m.HTML, funcs, d)
+	if err != nil {
+		return nil, err
+	}
+
+	d.Conteudo = strings.TrimSpace(texto)
+	texto, err = executarTexto("layout.txt", layout.Texto, funcs, d)
+	if err != nil {
+		return nil, err
+	}
+	d.Conteudo = htmltemplate.HTML(html)
+	html, err = executarHTML("layout.html", layout.HTML, funcs, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Renderizado{Assunto: d.Assunto, Texto: strings.TrimSpace(texto) + "\n", HTML: html}, nil
+}
+
+// validarModelo verifica se as partes do modelo são modelos válidos
+func validarModelo(m *Modelo) error {
+	funcs := funcoes(m.Idioma)
+	if _, err := texttemplate.New("assunto").Funcs(funcs).Parse(m.Assunto); err != nil {
+		return fmt.Errorf("assunto inválido: %w", err)
+	}
+	if _, err := texttemplate.New("texto").Funcs(funcs).Parse(m.Texto); err != nil {
+		return fmt.Errorf("texto inválido: %w", err)
+	}
+	if _, err := htmltemplate.New("html").Funcs(htmltemplate.FuncMap(funcs)).Parse(m.HTML); err != nil {
+		return fmt.Errorf("HTML inválido: %w", err)
+	}
+	return nil
+}
+
+func executarTexto(nome, fonte string, funcs texttemplate.FuncMap, d dadosModelo) (string, error) {
+	t, err := texttemplate.New(nome).Funcs(funcs).Parse(fonte)
+	if err != nil {
+		return "", fmt.Errorf("modelo %s inválido: %w", nome, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", fmt.Errorf("erro ao renderizar %s: %w", nome, err)
+	}
+	return buf.String(), nil
+}
+
+func executarHTML(nome, fonte string, funcs texttemplate.FuncMap, d dadosModelo) (string, error) {
+	t, err := htmltemplate.New(nome).Funcs(htmltemplate.FuncMap(funcs)).Parse(fonte)
+	if err != nil {
+		return "", fmt.Errorf("modelo %s inválido: %w", nome, err)
+	}
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, d); err != nil {
+		return "", fmt.Errorf("erro ao renderizar %s: %w", nome, err)
+	}
+	return buf.String(), nil
+}
+
+// funcoes retorna as funções de formatação no padrão do idioma: {{moeda .Dados.total}} e {{data .Dados.validade}}
+func funcoes(idioma string) texttemplate.FuncMap {
+	return texttemplate.FuncMap{
+		"moeda": func(v interface{}) string { return formatarMoeda(numero(v), idioma) },
+		"data":  func(v interface{}) string { return formatarData(v, idioma) },
+	}
+}
+
+// numero converte os valores numéricos recebidos dos serviços ou do JSON das prévias
+func numero(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case float32:
+		return float64(n)
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
+
+func formatarMoeda(v float64, idioma string) string {
+	milhar, decimal := ".", ","
+	if idioma == IdiomaIngles {
+		milhar, decimal = ",", "."
+	}
+
+	sinal := ""
+	if v < 0 {
+		sinal, v = "-", -v
+	}
+	s := fmt.Sprintf("%.2f", v)
+	inteiro, centavos := s[:len(s)-3], s[len(s)-2:]
+
+	var partes []string
+	for len(inteiro) > 3 {
+		partes = append([]string{inteiro[len(inteiro)-3:]}, partes...)
+		inteiro = inteiro[:len(inteiro)-3]
+	}
+	partes = append([]string{inteiro}, partes...)
+	return sinal + "R$ " + strings.Join(partes, milhar) + decimal + centavos
+}
+
+// formatarData aceita time.Time ou texto RFC 3339, como chega nas prévias
+func formatarData(v interface{}, idioma string) string {
+	var t time.Time
+	switch d := v.(type) {
+	case time.Time:
+		t = d
+	case *time.Time:
+		if d == nil {
+			return ""
+		}
+		t = *d
+	case string:
+		var err error
+		if t, err = time.Parse(time.RFC3339, d); err != nil {
+			if t, err = time.Parse("2006-01-02", d); err != nil {
+				return d
+			}
+		}
+	default:
+		return ""
+	}
+	if idioma == IdiomaIngles {
+		return t.Format("Jan 2, 2006")
+	}
+	return t.Format("02/01/2006")
+}
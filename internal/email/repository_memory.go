@@ -0,0 +1,303 @@
+package email
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu         sync.RWMutex
+	mensagens  map[int]*Mensagem
+	modelos    map[string]*Modelo // chave: empresa, nome e idioma
+	marcas     map[int]*Marca
+	supressoes map[string]*Supressao // chave: empresa e e-mail
+	nextID     int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		mensagens:  make(map[int]*Mensagem),
+		modelos:    make(map[string]*Modelo),
+		marcas:     make(map[int]*Marca),
+		supressoes: make(map[string]*Supressao),
+		nextID:     1,
+	}
+}
+
+// CreateMensagem adiciona uma nova mensagem
+func (r *MemoryRepository) CreateMensagem(m *Mensagem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(m.Para) == 0 {
+		return errors.New("a mensagem precisa de destinatários")
+	}
+
+	m.ID = r.nextID
+	r.nextID++
+	if m.DataCriacao.IsZero() {
+		m.DataCriacao = time.Now()
+	}
+
+	c := *m
+	r.mensagens[m.ID] = &c
+	return nil
+}
+
+// GetMensagem busca uma mensagem por ID e empresa
+func (r *MemoryRepository) GetMensagem(id, empresaID int) (*Mensagem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, exists := r.mensagens[id]
+	if !exists || m.EmpresaID != empresaID {
+		return nil, errors.New("mensagem não encontrada")
+	}
+	c := *m
+	return &c, nil
+}
+
+// GetMensagemPorMessageID busca uma mensagem pelo cabeçalho Message-ID
+func (r *MemoryRepository) GetMensagemPorMessageID(messageID string) (*Mensagem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, m := range r.mensagens {
+		if m.MessageID != "" && m.MessageID == messageID {
+			c := *m
+			return &c, nil
+		}
+	}
+	return nil, errors.New("mensagem não encontrada")
+}
+
+// UpdateMensagem substitui uma mensagem existente
+func (r *MemoryRepository) UpdateMensagem(m *Mensagem) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	atual, exists := r.mensagens[m.ID]
+	if !exists || atual.EmpresaID != m.EmpresaID {
+		return errors.New("mensagem não encontrada")
+	}
+
+	c := *m
+	r.mensagens[m.ID] = &c
+	return nil
+}
+
+// ListMensagens retorna as mensagens filtradas, das mais recentes para as mais antigas
+func (r *MemoryRepository) ListMensagens(f FiltroMensagens, limit, offset int) ([]*Mensagem, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	para := strings.ToLower(f.Para)
+	result := make([]*Mensagem, 0)
+	for _, m := range r.mensagens {
+		if f.EmpresaID > 0 && m.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.Status != "" && m.Status != f.Status {
+			continue
+		}
+		if f.Modelo != "" && m.Modelo != f.Modelo {
+			continue
+		}
+		if para != "" && !contem(m.Para, para) {
+			continue
+		}
+		c := *m
+		result = append(result, &c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*Mensagem{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// GetModelo busca o modelo personalizado da empresa
+func (r *MemoryRepository) GetModelo(empresaID int, nome, idioma string) (*Modelo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, exists := r.modelos[chaveModelo(empresaID, nome, idioma)]
+	if !exists {
+		return nil, errors.New("modelo não encontrado")
+	}
+	c := *m
+	return &c, nil
+}
+
+// SalvarModelo cria ou substitui o modelo personalizado da empresa
+func (r *MemoryRepository) SalvarModelo(m *Modelo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.EmpresaID <= 0 || m.Nome == "" || m.Idioma == "" {
+		return errors.New("empresa, nome e idioma são obrigatórios")
+	}
+	m.DataAtualizacao = time.Now()
+
+	c := *m
+	r.modelos[chaveModelo(m.EmpresaID, m.Nome, m.Idioma)] = &c
+	return nil
+}
+
+// DeleteModelo remove o modelo personalizado da empresa
+func (r *MemoryRepository) DeleteModelo(empresaID int, nome, idioma string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chave := chaveModelo(empresaID, nome, idioma)
+	if _, exists := r.modelos[chave]; !exists {
+		return errors.New("modelo não encontrado")
+	}
+	delete(r.modelos, chave)
+	return nil
+}
+
+// ListModelos retorna os modelos personalizados da empresa
+func (r *MemoryRepository) ListModelos(empresaID int) ([]*Modelo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Modelo, 0)
+	for _, m := range r.modelos {
+		if m.EmpresaID == empresaID {
+			c := *m
+			result = append(result, &c)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Nome != result[j].Nome {
+			return result[i].Nome < result[j].Nome
+		}
+		return result[i].Idioma < result[j].Idioma
+	})
+	return result, nil
+}
+
+// GetMarca busca a marca gravada pela empresa
+func (r *MemoryRepository) GetMarca(empresaID int) (*Marca, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	m, exists := r.marcas[empresaID]
+	if !exists {
+		return nil, errors.New("marca não encontrada")
+	}
+	c := *m
+	return &c, nil
+}
+
+// SalvarMarca cria ou substitui a marca da empresa
+func (r *MemoryRepository) SalvarMarca(m *Marca) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.EmpresaID <= 0 {
+		return errors.New("empresa é obrigatória")
+	}
+
+	c := *m
+	r.marcas[m.EmpresaID] = &c
+	return nil
+}
+
+// Suprimir inclui o endereço na lista de supressão da empresa; endereços já suprimidos são mantidos
+func (r *MemoryRepository) Suprimir(s *Supressao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chave := chaveSupressao(s.EmpresaID, s.Email)
+	if _, exists := r.supressoes[chave]; exists {
+		return nil
+	}
+	if s.Data.IsZero() {
+		s.Data = time.Now()
+	}
+
+	c := *s
+	r.supressoes[chave] = &c
+	return nil
+}
+
+// GetSupressao busca o endereço na lista de supressão da empresa
+func (r *MemoryRepository) GetSupressao(empresaID int, email string) (*Supressao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, exists := r.supressoes[chaveSupressao(empresaID, email)]
+	if !exists {
+		return nil, errors.New("endereço não suprimido")
+	}
+	c := *s
+	return &c, nil
+}
+
+// ListSupressoes retorna a lista de supressão da empresa, dos mais recentes para os mais antigos
+func (r *MemoryRepository) ListSupressoes(empresaID int) ([]*Supressao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Supressao, 0)
+	for _, s := range r.supressoes {
+		if s.EmpresaID == empresaID {
+			c := *s
+			result = append(result, &c)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Data.After(result[j].Data)
+	})
+	return result, nil
+}
+
+// DeleteSupressao retira o endereço da lista de supressão da empresa
+func (r *MemoryRepository) DeleteSupressao(empresaID int, email string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chave := chaveSupressao(empresaID, email)
+	if _, exists := r.supressoes[chave]; !exists {
+		return errors.New("endereço não suprimido")
+	}
+	delete(r.supressoes, chave)
+	return nil
+}
+
+func chaveModelo(empresaID int, nome, idioma string) string {
+	return strconv.Itoa(empresaID) + "/" + nome + "/" + idioma
+}
+
+func chaveSupressao(empresaID int, email string) string {
+	return strconv.Itoa(empresaID) + "/" + strings.ToLower(email)
+}
+
+func contem(lista []string, valor string) bool {
+	for _, v := range lista {
+		if strings.EqualFold(v, valor) {
+			return true
+		}
+	}
+	return false
+}
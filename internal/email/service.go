@@ -0,0 +1,514 @@
+package email
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/jobs"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// Envio dos e-mails pela fila de jobs
+const (
+	TipoJobEnvio = "email.enviar"
+	FilaEnvio    = "email"
+)
+
+// maxTentativasEnvio limita as tentativas de entrega; com o intervalo crescente da fila, cobre cerca de 5 minutos
+const maxTentativasEnvio = 6
+
+// nomeSistema identifica os e-mails enviados pelo sistema, sem empresa
+const nomeSistema = "Gvero"
+
+var corValida = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// Envio descreve um e-mail a renderizar e enfileirar
+type Envio struct {
+	EmpresaID int // zero para e-mails do sistema
+	UsuarioID int
+	Modelo    string
+	Idioma    string // vazio usa o idioma da marca da empresa
+	Para      []string
+	Dados     map[string]interface{}
+	Anexos    []Anexo
+}
+
+// NotificacaoDevolucao é a devolução informada pelo provedor de e-mail
+type NotificacaoDevolucao struct {
+	MessageID    string `json:"message_id"`
+	Destinatario string `json:"destinatario"`
+	Tipo         string `json:"tipo"` // permanente, temporaria ou reclamacao
+	Motivo       string `json:"motivo"`
+}
+
+// jobEnvio é o payload do job de envio
+type jobEnvio struct {
+	MensagemID int `json:"mensagem_id"`
+	EmpresaID  int `json:"empresa_id"`
+}
+
+// Service renderiza os e-mails, os enfileira e acompanha as entregas e devoluções
+type Service struct {
+	repo       Repository
+	transporte Transporte
+	empresas   empresa.Repository
+	fila       *jobs.Service
+	remetente  string
+	dominio    string
+	agora      func() time.Time
+}
+
+// NewService cria o serviço e registra o job de envio na fila. Todos os e-mails saem do remetente
+// informado, cujo domínio deve estar autorizado (SPF/DKIM) no servidor SMTP; as respostas vão
+// para o endereço da marca de cada empresa.
+func NewService(repo Repository, transporte Transporte, empresas empresa.Repository, fila *jobs.Service, remetente string) *Service {
+	dominio := "localhost"
+	if i := strings.LastIndex(remetente, "@"); i >= 0 {
+		dominio = remetente[i+1:]
+	}
+
+	s := &Service{
+		repo:       repo,
+		transporte: transporte,
+		empresas:   empresas,
+		fila:       fila,
+		remetente:  remetente,
+		dominio:    dominio,
+		agora:      time.Now,
+	}
+	fila.Registrar(TipoJobEnvio, FilaEnvio, s.processar)
+	return s
+}
+
+// Enviar renderiza o modelo, registra a mensagem e a enfileira para entrega
+func (s *Service) Enviar(e Envio) (*Mensagem, error) {
+	para, err := normalizarEnderecos(e.Para)
+	if err != nil {
+		return nil, err
+	}
+	if len(para) == 0 {
+		return nil, errors.New("informe ao menos um destinatário")
+	}
+	if len(para) > MaxDestinatarios {
+		return nil, fmt.Errorf("no máximo %d destinatários por mensagem", MaxDestinatarios)
+	}
+
+	anexos, err := validarAnexos(e.Anexos)
+	if err != nil {
+		return nil, err
+	}
+
+	emp, marca, err := s.identidade(e.EmpresaID)
+	if err != nil {
+		return nil, err
+	}
+	idioma := e.Idioma
+	if idioma == "" {
+		idioma = marca.Idioma
+	}
+	r, err := s.renderizar(emp, marca, e.Modelo, idioma, e.Dados)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Mensagem{
+		EmpresaID:     e.EmpresaID,
+		Modelo:        e.Modelo,
+		Idioma:        idioma,
+		NomeRemetente: marca.NomeRemetente,
+		De:            s.remetente,
+		ResponderPara: marca.ResponderPara,
+		Para:          para,
+		Assunto:       r.Assunto,
+		Texto:         r.Texto,
+		HTML:          r.HTML,
+		Anexos:        anexos,
+		Status:        StatusPendente,
+		MessageID:     s.novoMessageID(),
+		UsuarioID:     e.UsuarioID,
+		DataCriacao:   s.agora(),
+	}
+	if err := s.repo.CreateMensagem(m); err != nil {
+		return nil, err
+	}
+
+	if err := s.enfileirar(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reenviar devolve a mensagem à fila, mesmo que já tenha sido enviada
+func (s *Service) Reenviar(empresaID, id int) (*Mensagem, error) {
+	m, err := s.repo.GetMensagem(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Status = StatusPendente
+	m.Erro = ""
+	m.Tentativas = 0
+	if err := s.repo.UpdateMensagem(m); err != nil {
+		return nil, err
+	}
+
+	if err := s.enfileirar(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Testar envia o modelo de teste para verificar a configuração de envio e a marca da empresa
+func (s *Service) Testar(empresaID, usuarioID int, para, idioma string) (*Mensagem, error) {
+	return s.Enviar(Envio{
+		EmpresaID: empresaID,
+		UsuarioID: usuarioID,
+		Modelo:    ModeloTeste,
+		Idioma:    idioma,
+		Para:      []string{para},
+	})
+}
+
+// RegistrarDevolucao associa a devolução informada pelo provedor à mensagem. Devoluções permanentes
+// e reclamações incluem o destinatário na lista de supressão da empresa.
+func (s *Service) RegistrarDevolucao(n NotificacaoDevolucao) (*Mensagem, error) {
+	switch n.Tipo {
+	case DevolucaoPermanente, DevolucaoTemporaria, DevolucaoReclamacao:
+	default:
+		return nil, fmt.Errorf("tipo de devolução inválido: %s", n.Tipo)
+	}
+
+	messageID := strings.TrimSpace(n.MessageID)
+	if !strings.HasPrefix(messageID, "<") {
+		messageID = "<" + messageID + ">"
+	}
+	m, err := s.repo.GetMensagemPorMessageID(messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	destinatario := ""
+	for _, p := range m.Para {
+		if strings.EqualFold(p, strings.TrimSpace(n.Destinatario)) {
+			destinatario = p
+		}
+	}
+	if destinatario == "" {
+		return nil, errors.New("o destinatário não pertence à mensagem")
+	}
+
+	s.devolver(m, destinatario, n.Tipo, n.Motivo)
+	if err := s.repo.UpdateMensagem(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Renderizar renderiza o modelo com a marca da empresa, sem enviar; usado nas prévias
+func (s *Service) Renderizar(empresaID int, nome, idioma string, dados map[string]interface{}) (*Renderizado, error) {
+	emp, marca, err := s.identidade(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if idioma == "" {
+		idioma = marca.Idioma
+	}
+	return s.renderizar(emp, marca, nome, idioma, dados)
+}
+
+// Modelos retorna os modelos da empresa em todos os idiomas, personalizados ou embutidos
+func (s *Service) Modelos(empresaID int) ([]*Modelo, error) {
+	result := make([]*Modelo, 0)
+	for _, nome := range NomesModelos() {
+		for _, idioma := range Idiomas {
+			m, err := s.modelo(empresaID, nome, idioma)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// SalvarModelo personaliza um modelo embutido para a empresa
+func (s *Service) SalvarModelo(m *Modelo) (*Modelo, error) {
+	if _, ok := ModeloPadrao(m.Nome, IdiomaPortugues); !ok {
+		return nil, fmt.Errorf("modelo desconhecido: %s", m.Nome)
+	}
+	if !IdiomaValido(m.Idioma) {
+		return nil, fmt.Errorf("idioma inválido: %s", m.Idioma)
+	}
+	if m.Nome == ModeloLayout {
+		m.Assunto = ""
+	} else if strings.TrimSpace(m.Assunto) == "" {
+		return nil, errors.New("assunto é obrigatório")
+	}
+	if strings.TrimSpace(m.Texto) == "" || strings.TrimSpace(m.HTML) == "" {
+		return nil, errors.New("as versões em texto e em HTML são obrigatórias")
+	}
+	if err := validarModelo(m); err != nil {
+		return nil, err
+	}
+
+	m.Personalizado = true
+	if err := s.repo.SalvarModelo(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Marca retorna a marca da empresa, completada com os dados do cadastro da empresa
+func (s *Service) Marca(empresaID int) (*Marca, error) {
+	_, marca, err := s.identidade(empresaID)
+	return marca, err
+}
+
+// SalvarMarca grava a marca da empresa; campos vazios usam os dados do cadastro
+func (s *Service) SalvarMarca(m *Marca) (*Marca, error) {
+	if m.CorPrimaria != "" && !corValida.MatchString(m.CorPrimaria) {
+		return nil, errors.New("cor primária deve estar no formato #rrggbb")
+	}
+	if m.Idioma != "" && !IdiomaValido(m.Idioma) {
+		return nil, fmt.Errorf("idioma inválido: %s", m.Idioma)
+	}
+	if m.ResponderPara != "" {
+		a, err := mail.ParseAddress(m.ResponderPara)
+		if err != nil {
+			return nil, fmt.Errorf("endereço de resposta inválido: %s", m.ResponderPara)
+		}
+		m.ResponderPara = a.Address
+	}
+	m.NomeRemetente = strings.Join(strings.Fields(m.NomeRemetente), " ")
+
+	if err := s.repo.SalvarMarca(m); err != nil {
+		return nil, err
+	}
+	return s.Marca(m.EmpresaID)
+}
+
+// processar é o job de envio: entrega a mensagem aos destinatários não suprimidos
+func (s *Service) processar(ctx context.Context, j *jobs.Job) error {
+	var p jobEnvio
+	if err := j.Decodificar(&p); err != nil {
+		return err
+	}
+
+	m, err := s.repo.GetMensagem(p.MensagemID, p.EmpresaID)
+	if err != nil {
+		logger.ErrorLogger.Printf("Envio de e-mail %d descartado: %v", p.MensagemID, err)
+		return nil
+	}
+	if m.Status != StatusPendente {
+		// Execução repetida de um envio já concluído
+		return nil
+	}
+	m.JobID = j.ID
+	m.Tentativas = j.Tentativas
+
+	para := make([]string, 0, len(m.Para))
+	for _, destinatario := range m.Para {
+		if _, err := s.repo.GetSupressao(m.EmpresaID, destinatario); err != nil {
+			para = append(para, destinatario)
+		}
+	}
+	if len(para) == 0 {
+		m.Status = StatusSuprimido
+		m.Erro = "todos os destinatários estão na lista de supressão"
+		return s.repo.UpdateMensagem(m)
+	}
+
+	dados, err := montar(m, m.NomeRemetente, s.agora())
+	if err != nil {
+		m.Status = StatusFalhou
+		m.Erro = err.Error()
+		return s.repo.UpdateMensagem(m)
+	}
+
+	recusas, err := s.transporte.Enviar(ctx, &Envelope{De: m.De, Para: para, Dados: dados})
+	if err != nil {
+		m.Erro = err.Error()
+
+		var permanente *ErroPermanente
+		if errors.As(err, &permanente) {
+			m.Status = StatusFalhou
+			logger.ErrorLogger.Printf("E-mail %d recusado pelo servidor: %v", m.ID, err)
+			return s.repo.UpdateMensagem(m)
+		}
+
+		if j.Tentativas >= j.MaxTentativas {
+			m.Status = StatusFalhou
+		}
+		if errUpdate := s.repo.UpdateMensagem(m); errUpdate != nil {
+			logger.ErrorLogger.Printf("Erro ao registrar a falha do e-mail %d: %v", m.ID, errUpdate)
+		}
+		return err
+	}
+
+	m.Erro = ""
+	if len(recusas) < len(para) {
+		agora := s.agora()
+		m.Status = StatusEnviado
+		m.DataEnvio = &agora
+	}
+	for _, r := range recusas {
+		s.devolver(m, r.Destinatario, DevolucaoPermanente, r.Motivo)
+	}
+	return s.repo.UpdateMensagem(m)
+}
+
+// devolver registra a devolução na mensagem e suprime o destinatário quando ela é definitiva
+func (s *Service) devolver(m *Mensagem, destinatario, tipo, motivo string) {
+	m.Devolucoes = append(m.Devolucoes, Devolucao{
+		Destinatario: destinatario,
+		Tipo:         tipo,
+		Motivo:       motivo,
+		Data:         s.agora(),
+	})
+	if tipo == DevolucaoTemporaria {
+		return
+	}
+
+	m.Status = StatusDevolvido
+	err := s.repo.Suprimir(&Supressao{
+		EmpresaID:  m.EmpresaID,
+		Email:      destinatario,
+		Motivo:     tipo,
+		MensagemID: m.ID,
+		Data:       s.agora(),
+	})
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro ao suprimir %s: %v", destinatario, err)
+	}
+}
+
+// enfileirar cria o job de envio; a chave impede dois envios simultâneos da mesma mensagem
+func (s *Service) enfileirar(m *Mensagem) error {
+	_, err := s.fila.Enfileirar(m.EmpresaID, TipoJobEnvio, jobEnvio{MensagemID: m.ID, EmpresaID: m.EmpresaID},
+		jobs.Opcoes{Chave: "mensagem-" + strconv.Itoa(m.ID), MaxTentativas: maxTentativasEnvio})
+	if err != nil {
+		m.Status = StatusFalhou
+		m.Erro = err.Error()
+		if errUpdate := s.repo.UpdateMensagem(m); errUpdate != nil {
+			logger.ErrorLogger.Printf("Erro ao registrar a falha do e-mail %d: %v", m.ID, errUpdate)
+		}
+		return fmt.Errorf("erro ao enfileirar o e-mail: %w", err)
+	}
+	return nil
+}
+
+// identidade retorna os dados da empresa e a marca efetiva dos seus e-mails
+func (s *Service) identidade(empresaID int) (EmpresaModelo, *Marca, error) {
+	emp := EmpresaModelo{Nome: nomeSistema}
+	if empresaID > 0 {
+		e, err := s.empresas.GetByID(empresaID)
+		if err != nil {
+			return emp, nil, err
+		}
+		emp = EmpresaModelo{Nome: e.Nome, Email: e.Email, Telefone: e.Telefone, Endereco: e.Endereco, LogoURL: e.LogoURL}
+	}
+
+	marca, err := s.repo.GetMarca(empresaID)
+	if err != nil {
+		marca = &Marca{EmpresaID: empresaID}
+	}
+	if marca.NomeRemetente == "" {
+		marca.NomeRemetente = emp.Nome
+	}
+	if marca.ResponderPara == "" {
+		marca.ResponderPara = emp.Email
+	}
+	if marca.CorPrimaria == "" {
+		marca.CorPrimaria = CorPadrao
+	}
+	if marca.Idioma == "" {
+		marca.Idioma = IdiomaPortugues
+	}
+	return emp, marca, nil
+}
+
+func (s *Service) renderizar(emp EmpresaModelo, marca *Marca, nome, idioma string, dados map[string]interface{}) (*Renderizado, error) {
+	if !IdiomaValido(idioma) {
+		return nil, fmt.Errorf("idioma inválido: %s", idioma)
+	}
+	if nome == ModeloLayout {
+		return nil, errors.New("o layout não é enviado sozinho")
+	}
+
+	m, err := s.modelo(marca.EmpresaID, nome, idioma)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := s.modelo(marca.EmpresaID, ModeloLayout, idioma)
+	if err != nil {
+		return nil, err
+	}
+
+	if dados == nil {
+		dados = map[string]interface{}{}
+	}
+	return renderizar(m, layout, dadosModelo{Empresa: emp, Marca: *marca, Idioma: idioma, Dados: dados})
+}
+
+// modelo retorna o modelo personalizado pela empresa ou, sem ele, o embutido
+func (s *Service) modelo(empresaID int, nome, idioma string) (*Modelo, error) {
+	if m, err := s.repo.GetModelo(empresaID, nome, idioma); err == nil {
+		return m, nil
+	}
+	m, ok := ModeloPadrao(nome, idioma)
+	if !ok {
+		return nil, fmt.Errorf("modelo não encontrado: %s", nome)
+	}
+	return m, nil
+}
+
+func (s *Service) novoMessageID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return "<" + hex.EncodeToString(b) + "@" + s.dominio + ">"
+}
+
+// normalizarEnderecos valida os endereços e remove os repetidos
+func normalizarEnderecos(enderecos []string) ([]string, error) {
+	result := make([]string, 0, len(enderecos))
+	vistos := make(map[string]bool)
+	for _, e := range enderecos {
+		a, err := mail.ParseAddress(strings.TrimSpace(e))
+		if err != nil {
+			return nil, fmt.Errorf("endereço de e-mail inválido: %s", e)
+		}
+		chave := strings.ToLower(a.Address)
+		if !vistos[chave] {
+			vistos[chave] = true
+			result = append(result, a.Address)
+		}
+	}
+	return result, nil
+}
+
+// validarAnexos confere os nomes, preenche os tamanhos e limita o total
+func validarAnexos(anexos []Anexo) ([]Anexo, error) {
+	result := make([]Anexo, 0, len(anexos))
+	total := 0
+	for _, a := range anexos {
+		if strings.TrimSpace(a.Nome) == "" || strings.ContainsAny(a.Nome, "\r\n") {
+			return nil, errors.New("nome de anexo inválido")
+		}
+		a.Tamanho = len(a.Conteudo)
+		total += a.Tamanho
+		result = append(result, a)
+	}
+	if total > TamanhoMaximoAnexo {
+		return nil, fmt.Errorf("os anexos excedem o limite de %d MB", TamanhoMaximoAnexo>>20)
+	}
+	return result, nil
+}
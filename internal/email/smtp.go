@@ -0,0 +1,207 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Modos de segurança da conexão SMTP
+const (
+	TLSStartTLS  = "starttls" // padrão: exige STARTTLS antes de autenticar e enviar
+	TLSImplicito = "tls"      // conexão TLS desde o início, em geral na porta 465
+	TLSNenhum    = "nenhum"   // apenas para servidores locais
+)
+
+// tempoSMTP é o prazo padrão de cada envio
+const tempoSMTP = 30 * time.Second
+
+// Envelope é a mensagem pronta para entrega
+type Envelope struct {
+	De    string   // remetente do envelope, que recebe as devoluções
+	Para  []string // destinatários do envelope
+	Dados []byte   // mensagem no formato MIME
+}
+
+// Recusa é um destinatário recusado permanentemente pelo servidor durante o envio
+type Recusa struct {
+	Destinatario string
+	Motivo       string
+}
+
+// Transporte entrega as mensagens
+type Transporte interface {
+	// Enviar entrega a mensagem aos destinatários aceitos e retorna os recusados permanentemente.
+	// Erros do tipo *ErroPermanente indicam que novas tentativas não adiantam.
+	Enviar(ctx context.Context, e *Envelope) ([]Recusa, error)
+}
+
+// ErroPermanente é uma recusa do servidor que não muda com novas tentativas (respostas 5xx)
+type ErroPermanente struct {
+	Codigo   int
+	Mensagem string
+}
+
+func (e *ErroPermanente) Error() string {
+	return fmt.Sprintf("recusa permanente do servidor SMTP (%d): %s", e.Codigo, e.Mensagem)
+}
+
+// ConfigSMTP contém os dados de acesso ao servidor SMTP
+type ConfigSMTP struct {
+	Host    string
+	Porta   int // zero usa 587, ou 465 no modo TLSImplicito
+	Usuario string
+	Senha   string
+	TLS     string // vazio usa TLSStartTLS
+	Timeout time.Duration
+}
+
+// SMTP entrega as mensagens por um servidor SMTP
+type SMTP struct {
+	cfg ConfigSMTP
+}
+
+// NewSMTP cria o transporte SMTP
+func NewSMTP(cfg ConfigSMTP) *SMTP {
+	if cfg.TLS == "" {
+		cfg.TLS = TLSStartTLS
+	}
+	if cfg.Porta == 0 {
+		cfg.Porta = 587
+		if cfg.TLS == TLSImplicito {
+			cfg.Porta = 465
+		}
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = tempoSMTP
+	}
+	return &SMTP{cfg: cfg}
+}
+
+// Enviar entrega a mensagem em uma conexão nova
+func (t *SMTP) Enviar(ctx context.Context, e *Envelope) ([]Recusa, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.cfg.Timeout)
+	defer cancel()
+
+	c, err := t.conectar(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	// Cancelar o contexto interrompe a conversa em andamento
+	pronto := make(chan struct{})
+	defer close(pronto)
+	go func() {
+		select {
+		case <-ctx.Done():
+			c.Close()
+		case <-pronto:
+		}
+	}()
+
+	// Recusas do remetente são de configuração; só as da mensagem e dos destinatários são permanentes
+	if err := c.Mail(e.De); err != nil {
+		return nil, err
+	}
+
+	var recusas []Recusa
+	aceitos := 0
+	for _, para := range e.Para {
+		if err := c.Rcpt(para); err != nil {
+			var resp *textproto.Error
+			if errors.As(err, &resp) && resp.Code >= 500 {
+				recusas = append(recusas, Recusa{Destinatario: para, Motivo: fmt.Sprintf("%d %s", resp.Code, resp.Msg)})
+				continue
+			}
+			return nil, err
+		}
+		aceitos++
+	}
+	if aceitos == 0 {
+		return recusas, nil
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return nil, classificar(err)
+	}
+	if _, err := w.Write(e.Dados); err != nil {
+		return nil, classificar(err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, classificar(err)
+	}
+
+	c.Quit()
+	return recusas, nil
+}
+
+// conectar abre a conexão, negocia o TLS e autentica
+func (t *SMTP) conectar(ctx context.Context) (*smtp.Client, error) {
+	endereco := net.JoinHostPort(t.cfg.Host, strconv.Itoa(t.cfg.Porta))
+	tlsConfig := &tls.Config{ServerName: t.cfg.Host, MinVersion: tls.VersionTLS12}
+
+	var conn net.Conn
+	var err error
+	if t.cfg.TLS == TLSImplicito {
+		conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", endereco)
+	} else {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", endereco)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("erro ao conectar ao servidor SMTP: %w", err)
+	}
+	if prazo, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(prazo)
+	}
+
+	c, err := smtp.NewClient(conn, t.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("erro ao iniciar a sessão SMTP: %w", err)
+	}
+
+	if host, err := os.Hostname(); err == nil {
+		if err := c.Hello(host); err != nil {
+			c.Close()
+			return nil, err
+		}
+	}
+
+	if t.cfg.TLS == TLSStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			c.Close()
+			return nil, errors.New("o servidor SMTP não oferece STARTTLS")
+		}
+		if err := c.StartTLS(tlsConfig); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("erro ao negociar TLS: %w", err)
+		}
+	}
+
+	if t.cfg.Usuario != "" {
+		if err := c.Auth(smtp.PlainAuth("", t.cfg.Usuario, t.cfg.Senha, t.cfg.Host)); err != nil {
+			c.Close()
+			return nil, fmt.Errorf("erro ao autenticar no servidor SMTP: %w", err)
+		}
+	}
+
+	return c, nil
+}
+
+// classificar converte as respostas 5xx do servidor em *ErroPermanente
+func classificar(err error) error {
+	var resp *textproto.Error
+	if errors.As(err, &resp) && resp.Code >= 500 {
+		return &ErroPermanente{Codigo: resp.Code, Mensagem: resp.Msg}
+	}
+	return err
+}
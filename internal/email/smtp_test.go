@@ -0,0 +1,174 @@
+package email
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+func novoFakeSMTP(t *testing.T) *FakeSMTP {
+	t.Helper()
+	f, err := NewFakeSMTP("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Fechar() })
+	return f
+}
+
+func transporteFake(f *FakeSMTP) *SMTP {
+	return NewSMTP(ConfigSMTP{Host: f.Host(), Porta: f.Porta(), TLS: TLSNenhum, Timeout: 5 * time.Second})
+}
+
+func TestSMTPEnviar(t *testing.T) {
+	f := novoFakeSMTP(t)
+
+	pdf := bytes.Repeat([]byte("%PDF-1.4 orçamento "), 20)
+	m := &Mensagem{
+		ID:        7,
+		De:        "nao-responda@gvero.test",
+		Para:      []string{"ana@acme.com", "ninguem@acme.invalid"},
+		Assunto:   "Orçamento nº 12",
+		Texto:     "Olá, Ana. Segue o orçamento.",
+		HTML:      "<p>Olá, Ana. Segue o orçamento.</p>",
+		Anexos:    []Anexo{{Nome: "orcamento-12.pdf", Conteudo: pdf}},
+		MessageID: "<7.teste@gvero.test>",
+	}
+	dados, err := montar(m, "Acme Ltda", time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recusas, err := transporteFake(f).Enviar(context.Background(), &Envelope{De: m.De, Para: m.Para, Dados: dados})
+	if err != nil {
+		t.Fatalf("Enviar: %v", err)
+	}
+	if len(recusas) != 1 || recusas[0].Destinatario != "ninguem@acme.invalid" || !strings.HasPrefix(recusas[0].Motivo, "550 ") {
+		t.Errorf("recusas = %+v", recusas)
+	}
+
+	recebidas := f.Recebidas()
+	if len(recebidas) != 1 {
+		t.Fatalf("%d mensagens recebidas", len(recebidas))
+	}
+	r := recebidas[0]
+	if r.De != m.De || len(r.Para) != 1 || r.Para[0] != "ana@acme.com" {
+		t.Errorf("envelope recebido: de %s para %v", r.De, r.Para)
+	}
+
+	msg, err := mail.ReadMessage(bytes.NewReader(r.Dados))
+	if err != nil {
+		t.Fatalf("mensagem recebida inválida: %v", err)
+	}
+	assunto, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil || assunto != m.Assunto {
+		t.Errorf("Subject = %q, %v", assunto, err)
+	}
+	if de, err := msg.Header.AddressList("From"); err != nil || de[0].Name != "Acme Ltda" || de[0].Address != m.De {
+		t.Errorf("From = %v, %v", de, err)
+	}
+	if msg.Header.Get("Message-ID") != m.MessageID {
+		t.Errorf("Message-ID = %q", msg.Header.Get("Message-ID"))
+	}
+
+	tipo, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || tipo != "multipart/mixed" {
+		t.Fatalf("Content-Type = %q, %v", tipo, err)
+	}
+	partes := multipart.NewReader(msg.Body, params["boundary"])
+
+	corpo, err := partes.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tipo, params, _ = mime.ParseMediaType(corpo.Header.Get("Content-Type"))
+	if tipo != "multipart/alternative" {
+		t.Fatalf("corpo = %q", tipo)
+	}
+	alternativas := multipart.NewReader(corpo, params["boundary"])
+	for _, esperado := range []string{m.Texto, m.HTML} {
+		p, err := alternativas.NextPart()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// O leitor decodifica o quoted-printable
+		if conteudo, _ := io.ReadAll(p); string(conteudo) != esperado {
+			t.Errorf("alternativa = %q, esperado %q", conteudo, esperado)
+		}
+	}
+
+	anexo, err := partes.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anexo.FileName() != "orcamento-12.pdf" || !strings.HasPrefix(anexo.Header.Get("Content-Type"), "application/pdf") {
+		t.Errorf("anexo %q do tipo %q", anexo.FileName(), anexo.Header.Get("Content-Type"))
+	}
+	// O leitor de DATA do servidor entrega as linhas terminadas em LF
+	codificado, _ := io.ReadAll(anexo)
+	linhas := strings.Fields(string(codificado))
+	conteudo, err := base64.StdEncoding.DecodeString(strings.Join(linhas, ""))
+	if err != nil || !bytes.Equal(conteudo, pdf) {
+		t.Errorf("conteúdo do anexo alterado: %v", err)
+	}
+	if len(linhas) < 2 {
+		t.Errorf("anexo de %d bytes em %d linha", len(pdf), len(linhas))
+	}
+	for _, linha := range linhas {
+		if len(linha) > 76 {
+			t.Fatalf("linha base64 com %d caracteres", len(linha))
+		}
+	}
+}
+
+func TestSMTPTodosRecusados(t *testing.T) {
+	f := novoFakeSMTP(t)
+	para := []string{"a@acme.invalid", "b@acme.invalid"}
+
+	recusas, err := transporteFake(f).Enviar(context.Background(), &Envelope{De: "nao-responda@gvero.test", Para: para, Dados: []byte("Subject: x\r\n\r\nx\r\n")})
+	if err != nil {
+		t.Fatalf("Enviar: %v", err)
+	}
+	if len(recusas) != len(para) {
+		t.Errorf("recusas = %+v", recusas)
+	}
+	if n := len(f.Recebidas()); n != 0 {
+		t.Errorf("%d mensagens entregues sem destinatários aceitos", n)
+	}
+}
+
+// Sem STARTTLS no servidor, o modo padrão não envia a senha nem a mensagem em texto puro
+func TestSMTPExigeStartTLS(t *testing.T) {
+	f := novoFakeSMTP(t)
+	transporte := NewSMTP(ConfigSMTP{Host: f.Host(), Porta: f.Porta(), Usuario: "gvero", Senha: "segredo", Timeout: 5 * time.Second})
+
+	_, err := transporte.Enviar(context.Background(), &Envelope{De: "nao-responda@gvero.test", Para: []string{"ana@acme.com"}, Dados: []byte("x\r\n")})
+	if err == nil || !strings.Contains(err.Error(), "STARTTLS") {
+		t.Errorf("Enviar sem STARTTLS: %v", err)
+	}
+	if n := len(f.Recebidas()); n != 0 {
+		t.Errorf("%d mensagens entregues sem TLS", n)
+	}
+}
+
+func TestClassificar(t *testing.T) {
+	var permanente *ErroPermanente
+	if err := classificar(&textproto.Error{Code: 552, Msg: "5.3.4 mensagem grande demais"}); !errors.As(err, &permanente) || permanente.Codigo != 552 {
+		t.Errorf("552 = %v", err)
+	}
+	if err := classificar(&textproto.Error{Code: 451, Msg: "4.3.0 tente mais tarde"}); errors.As(err, &permanente) {
+		t.Errorf("451 classificado como permanente: %v", err)
+	}
+	if err := classificar(io.ErrUnexpectedEOF); errors.As(err, &permanente) {
+		t.Errorf("falha de conexão classificada como permanente: %v", err)
+	}
+}
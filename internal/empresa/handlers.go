@@ -22,8 +22,7 @@ func NewHandlers(repo Repository) *Handlers {
 }
 
 // Routes retorna as rotas para empresas
-func Routes() http.Handler {
-	repo := NewMemoryRepository()
+func Routes(repo Repository) http.Handler {
 	h := NewHandlers(repo)
 
 	r := chi.NewRouter()
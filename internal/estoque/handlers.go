@@ -0,0 +1,420 @@
+package estoque
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP para o controle de estoque
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas para o controle de estoque
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/depositos", h.ListDepositos)
+	r.Post("/depositos", h.CreateDeposito)
+	r.Get("/depositos/{id}", h.GetDeposito)
+	r.Put("/depositos/{id}", h.UpdateDeposito)
+
+	r.Get("/movimentos", h.ListMovimentos)
+	r.Post("/movimentos", h.CreateMovimento)
+
+	r.Get("/saldos", h.Saldos)
+
+	r.Get("/reservas", h.ListReservas)
+	r.Post("/reservas", h.CreateReservas)
+	r.Post("/reservas/{id}/cancelar", h.CancelarReserva)
+
+	r.Get("/minimos", h.ListMinimos)
+	r.Put("/minimos", h.SaveMinimo)
+	r.Get("/alertas", h.Alertas)
+
+	r.Get("/avaliacao", h.Avaliacao)
+
+	return r
+}
+
+// ListDepositos lista os depósitos da empresa
+func (h *Handlers) ListDepositos(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	depositos, err := h.repo.ListDepositos(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(depositos)
+}
+
+// GetDeposito retorna um depósito por ID
+func (h *Handlers) GetDeposito(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	d, err := h.repo.GetDeposito(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// CreateDeposito cria um novo depósito
+func (h *Handlers) CreateDeposito(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var d Deposito
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d.EmpresaID = user.Empresa
+	if err := h.repo.CreateDeposito(&d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(d)
+}
+
+// UpdateDeposito atualiza um depósito existente
+func (h *Handlers) UpdateDeposito(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var d Deposito
+	if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	d.ID = id
+	d.EmpresaID = user.Empresa
+	if err := h.repo.UpdateDeposito(&d); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(d)
+}
+
+// ListMovimentos consulta o razão. Filtros: produto_id, deposito_id, tipo, inicio e fim (AAAA-MM-DD).
+func (h *Handlers) ListMovimentos(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	produtoID, _ := strconv.Atoi(q.Get("produto_id"))
+	depositoID, _ := strconv.Atoi(q.Get("deposito_id"))
+
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+
+	f := FiltroMovimentos{
+		EmpresaID:  user.Empresa,
+		ProdutoID:  produtoID,
+		DepositoID: depositoID,
+		Tipo:       q.Get("tipo"),
+	}
+	if v := q.Get("inicio"); v != "" {
+		inicio, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Data de início inválida", http.StatusBadRequest)
+			return
+		}
+		f.Inicio = inicio
+	}
+	if v := q.Get("fim"); v != "" {
+		fim, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Data de fim inválida", http.StatusBadRequest)
+			return
+		}
+		f.Fim = fim.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	movimentos, err := h.repo.ListMovimentos(f, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movimentos)
+}
+
+// CreateMovimento registra uma entrada, saída, transferência ou ajuste
+func (h *Handlers) CreateMovimento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in NovoMovimento
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := h.service.Movimentar(user.Empresa, user.ID, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(m)
+}
+
+// Saldos retorna a posição atual do estoque. Filtros: produto_id e deposito_id.
+func (h *Handlers) Saldos(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	produtoID, _ := strconv.Atoi(r.URL.Query().Get("produto_id"))
+	depositoID, _ := strconv.Atoi(r.URL.Query().Get("deposito_id"))
+
+	saldos, err := h.service.Saldos(user.Empresa, produtoID, depositoID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saldos)
+}
+
+// ListReservas lista as reservas. Filtros: pedido_id, produto_id, deposito_id e status.
+func (h *Handlers) ListReservas(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	pedidoID, _ := strconv.Atoi(q.Get("pedido_id"))
+	produtoID, _ := strconv.Atoi(q.Get("produto_id"))
+	depositoID, _ := strconv.Atoi(q.Get("deposito_id"))
+
+	reservas, err := h.repo.ListReservas(FiltroReservas{
+		EmpresaID:  user.Empresa,
+		PedidoID:   pedidoID,
+		ProdutoID:  produtoID,
+		DepositoID: depositoID,
+		Status:     q.Get("status"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reservas)
+}
+
+// CreateReservas reserva os itens de um pedido
+func (h *Handlers) CreateReservas(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in struct {
+		PedidoID int           `json:"pedido_id"`
+		Itens    []ItemReserva `json:"itens"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	reservas, err := h.service.Reservar(user.Empresa, in.PedidoID, in.Itens)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reservas)
+}
+
+// CancelarReserva libera uma reserva ativa
+func (h *Handlers) CancelarReserva(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	res, err := h.service.CancelarReserva(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(res)
+}
+
+// ListMinimos lista os estoques mínimos configurados
+func (h *Handlers) ListMinimos(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	minimos, err := h.repo.ListMinimos(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(minimos)
+}
+
+// SaveMinimo define o estoque mínimo de um produto
+func (h *Handlers) SaveMinimo(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var m Minimo
+	if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m.EmpresaID = user.Empresa
+	if err := h.repo.SaveMinimo(&m); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// Alertas lista os produtos abaixo do estoque mínimo
+func (h *Handlers) Alertas(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	alertas, err := h.service.Alertas(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(alertas)
+}
+
+// Avaliacao valoriza o estoque pelo custo médio. Parâmetro opcional: data (AAAA-MM-DD).
+func (h *Handlers) Avaliacao(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	data := time.Now()
+	if v := r.URL.Query().Get("data"); v != "" {
+		d, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			http.Error(w, "Data inválida", http.StatusBadRequest)
+			return
+		}
+		data = d.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	av, err := h.service.Avaliar(user.Empresa, data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(av)
+}
@@ -0,0 +1,148 @@
+package estoque
+
+import (
+	"time"
+)
+
+// Tipos de movimento de estoque
+const (
+	TipoEntrada       = "entrada"
+	TipoSaida         = "saida"
+	TipoTransferencia = "transferencia"
+	TipoAjuste        = "ajuste"
+)
+
+// Status de reserva
+const (
+	ReservaAtiva     = "ativa"
+	ReservaConsumida = "consumida"
+	ReservaCancelada = "cancelada"
+)
+
+// Deposito representa um almoxarifado ou armazém da empresa
+type Deposito struct {
+	ID          int       `json:"id"`
+	EmpresaID   int       `json:"empresa_id"`
+	Codigo      string    `json:"codigo"`
+	Nome        string    `json:"nome"`
+	Endereco    string    `json:"endereco,omitempty"`
+	Padrao      bool      `json:"padrao"` // usado quando o depósito não é informado
+	Ativo       bool      `json:"ativo"`
+	DataCriacao time.Time `json:"data_criacao"`
+}
+
+// Movimento é um lançamento do razão de estoque. Movimentos nunca são alterados nem excluídos;
+// correções são feitas com novos lançamentos de ajuste.
+type Movimento struct {
+	ID                int       `json:"id"`
+	EmpresaID         int       `json:"empresa_id"`
+	Tipo              string    `json:"tipo"`
+	ProdutoID         int       `json:"produto_id"`
+	DepositoID        int       `json:"deposito_id"`                   // origem em saídas e transferências
+	DepositoDestinoID int       `json:"deposito_destino_id,omitempty"` // apenas transferências
+	Quantidade        float64   `json:"quantidade"`                    // positiva; em ajustes, negativa para baixar
+	CustoUnitario     float64   `json:"custo_unitario,omitempty"`      // entradas e ajustes positivos
+	ReservaID         int       `json:"reserva_id,omitempty"`
+	Documento         string    `json:"documento,omitempty"`
+	Observacao        string    `json:"observacao,omitempty"`
+	UsuarioID         int       `json:"usuario_id"`
+	Data              time.Time `json:"data"`
+}
+
+// Reserva separa uma quantidade do estoque para um pedido de venda
+type Reserva struct {
+	ID          int        `json:"id"`
+	EmpresaID   int        `json:"empresa_id"`
+	PedidoID    int        `json:"pedido_id"`
+	ProdutoID   int        `json:"produto_id"`
+	DepositoID  int        `json:"deposito_id"`
+	Quantidade  float64    `json:"quantidade"`
+	Status      string     `json:"status"`
+	DataCriacao time.Time  `json:"data_criacao"`
+	DataBaixa   *time.Time `json:"data_baixa,omitempty"`
+}
+
+// Minimo define o estoque mínimo de um produto. DepositoID zero considera todos os depósitos.
+type Minimo struct {
+	EmpresaID  int     `json:"empresa_id"`
+	ProdutoID  int     `json:"produto_id"`
+	DepositoID int     `json:"deposito_id"`
+	Quantidade float64 `json:"quantidade"`
+}
+
+// Saldo é a posição de um produto em um depósito, apurada a partir do razão
+type Saldo struct {
+	ProdutoID  int     `json:"produto_id"`
+	DepositoID int     `json:"deposito_id"`
+	Quantidade float64 `json:"quantidade"` // estoque físico
+	Reservado  float64 `json:"reservado"`
+	Disponivel float64 `json:"disponivel"`
+	CustoMedio float64 `json:"custo_medio"`
+	Valor      float64 `json:"valor"`
+}
+
+// Alerta indica um produto abaixo do estoque mínimo
+type Alerta struct {
+	ProdutoID  int     `json:"produto_id"`
+	SKU        string  `json:"sku"`
+	Nome       string  `json:"nome"`
+	DepositoID int     `json:"deposito_id,omitempty"`
+	Minimo     float64 `json:"minimo"`
+	Disponivel float64 `json:"disponivel"`
+	Falta      float64 `json:"falta"`
+}
+
+// ItemAvaliacao é a valorização de um produto pelo custo médio
+type ItemAvaliacao struct {
+	ProdutoID  int     `json:"produto_id"`
+	SKU        string  `json:"sku"`
+	Nome       string  `json:"nome"`
+	Quantidade float64 `json:"quantidade"`
+	CustoMedio float64 `json:"custo_medio"`
+	Valor      float64 `json:"valor"`
+}
+
+// Avaliacao é o relatório de valorização do estoque em uma data
+type Avaliacao struct {
+	Data  time.Time       `json:"data"`
+	Itens []ItemAvaliacao `json:"itens"`
+	Total float64         `json:"total"`
+}
+
+// FiltroMovimentos restringe a consulta ao razão; campos zerados não filtram
+type FiltroMovimentos struct {
+	EmpresaID  int
+	ProdutoID  int
+	DepositoID int // origem ou destino
+	Tipo       string
+	Inicio     time.Time
+	Fim        time.Time
+}
+
+// FiltroReservas restringe a consulta de reservas; campos zerados não filtram
+type FiltroReservas struct {
+	EmpresaID  int
+	PedidoID   int
+	ProdutoID  int
+	DepositoID int
+	Status     string
+}
+
+// Repository define a interface para acesso aos dados de estoque
+type Repository interface {
+	CreateDeposito(d *Deposito) error
+	GetDeposito(id int, empresaID int) (*Deposito, error)
+	UpdateDeposito(d *Deposito) error
+	ListDepositos(empresaID int) ([]*Deposito, error)
+
+	AddMovimento(m *Movimento) error
+	ListMovimentos(f FiltroMovimentos, limit, offset int) ([]*Movimento, error)
+
+	CreateReserva(r *Reserva) error
+	UpdateReserva(r *Reserva) error
+	GetReserva(id int, empresaID int) (*Reserva, error)
+	ListReservas(f FiltroReservas) ([]*Reserva, error)
+
+	SaveMinimo(m *Minimo) error
+	ListMinimos(empresaID int) ([]*Minimo, error)
+}
@@ -0,0 +1,314 @@
+package estoque
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	depositos     map[int]*Deposito
+	movimentos    []*Movimento
+	reservas      map[int]*Reserva
+	minimos       []*Minimo
+	nextID        int
+	nextMovID     int
+	nextReservaID int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		depositos:     make(map[int]*Deposito),
+		reservas:      make(map[int]*Reserva),
+		nextID:        1,
+		nextMovID:     1,
+		nextReservaID: 1,
+	}
+}
+
+// CreateDeposito adiciona um novo depósito
+func (r *MemoryRepository) CreateDeposito(d *Deposito) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.validarDeposito(d); err != nil {
+		return err
+	}
+
+	d.ID = r.nextID
+	r.nextID++
+	d.DataCriacao = time.Now()
+
+	r.depositos[d.ID] = d
+	r.unicoPadrao(d)
+	return nil
+}
+
+// GetDeposito busca um depósito por ID e empresa
+func (r *MemoryRepository) GetDeposito(id int, empresaID int) (*Deposito, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	d, exists := r.depositos[id]
+	if !exists || d.EmpresaID != empresaID {
+		return nil, errors.New("depósito não encontrado")
+	}
+	return d, nil
+}
+
+// UpdateDeposito atualiza um depósito existente
+func (r *MemoryRepository) UpdateDeposito(d *Deposito) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.depositos[d.ID]
+	if !exists || existing.EmpresaID != d.EmpresaID {
+		return errors.New("depósito não encontrado")
+	}
+
+	if err := r.validarDeposito(d); err != nil {
+		return err
+	}
+
+	// Preservar campos que não devem ser alterados
+	d.DataCriacao = existing.DataCriacao
+
+	r.depositos[d.ID] = d
+	r.unicoPadrao(d)
+	return nil
+}
+
+// ListDepositos retorna os depósitos da empresa
+func (r *MemoryRepository) ListDepositos(empresaID int) ([]*Deposito, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Deposito, 0)
+	for _, d := range r.depositos {
+		if d.EmpresaID == empresaID {
+			result = append(result, d)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// AddMovimento acrescenta um lançamento ao razão
+func (r *MemoryRepository) AddMovimento(m *Movimento) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	m.ID = r.nextMovID
+	r.nextMovID++
+	if m.Data.IsZero() {
+		m.Data = time.Now()
+	}
+
+	r.movimentos = append(r.movimentos, m)
+	return nil
+}
+
+// ListMovimentos retorna os lançamentos em ordem cronológica
+func (r *MemoryRepository) ListMovimentos(f FiltroMovimentos, limit, offset int) ([]*Movimento, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Movimento, 0)
+	for _, m := range r.movimentos {
+		if m.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.ProdutoID > 0 && m.ProdutoID != f.ProdutoID {
+			continue
+		}
+		if f.DepositoID > 0 && m.DepositoID != f.DepositoID && m.DepositoDestinoID != f.DepositoID {
+			continue
+		}
+		if f.Tipo != "" && m.Tipo != f.Tipo {
+			continue
+		}
+		if !f.Inicio.IsZero() && m.Data.Before(f.Inicio) {
+			continue
+		}
+		if !f.Fim.IsZero() && m.Data.After(f.Fim) {
+			continue
+		}
+		result = append(result, m)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if !result[i].Data.Equal(result[j].Data) {
+			return result[i].Data.Before(result[j].Data)
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*Movimento{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// CreateReserva adiciona uma nova reserva
+func (r *MemoryRepository) CreateReserva(res *Reserva) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if res.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	res.ID = r.nextReservaID
+	r.nextReservaID++
+	res.DataCriacao = time.Now()
+
+	r.reservas[res.ID] = res
+	return nil
+}
+
+// UpdateReserva atualiza o status de uma reserva
+func (r *MemoryRepository) UpdateReserva(res *Reserva) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.reservas[res.ID]
+	if !exists || existing.EmpresaID != res.EmpresaID {
+		return errors.New("reserva não encontrada")
+	}
+
+	// Preservar campos que não devem ser alterados
+	res.DataCriacao = existing.DataCriacao
+
+	r.reservas[res.ID] = res
+	return nil
+}
+
+// GetReserva busca uma reserva por ID e empresa
+func (r *MemoryRepository) GetReserva(id int, empresaID int) (*Reserva, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	res, exists := r.reservas[id]
+	if !exists || res.EmpresaID != empresaID {
+		return nil, errors.New("reserva não encontrada")
+	}
+	return res, nil
+}
+
+// ListReservas retorna as reservas que atendem ao filtro
+func (r *MemoryRepository) ListReservas(f FiltroReservas) ([]*Reserva, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Reserva, 0)
+	for _, res := range r.reservas {
+		if res.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.PedidoID > 0 && res.PedidoID != f.PedidoID {
+			continue
+		}
+		if f.ProdutoID > 0 && res.ProdutoID != f.ProdutoID {
+			continue
+		}
+		if f.DepositoID > 0 && res.DepositoID != f.DepositoID {
+			continue
+		}
+		if f.Status != "" && res.Status != f.Status {
+			continue
+		}
+		result = append(result, res)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// SaveMinimo grava o estoque mínimo de um produto, substituindo o valor anterior
+func (r *MemoryRepository) SaveMinimo(m *Minimo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if m.EmpresaID <= 0 || m.ProdutoID <= 0 {
+		return errors.New("empresa e produto são obrigatórios")
+	}
+
+	if m.Quantidade < 0 {
+		return errors.New("estoque mínimo não pode ser negativo")
+	}
+
+	for i, existing := range r.minimos {
+		if existing.EmpresaID == m.EmpresaID && existing.ProdutoID == m.ProdutoID && existing.DepositoID == m.DepositoID {
+			r.minimos[i] = m
+			return nil
+		}
+	}
+
+	r.minimos = append(r.minimos, m)
+	return nil
+}
+
+// ListMinimos retorna os estoques mínimos configurados na empresa
+func (r *MemoryRepository) ListMinimos(empresaID int) ([]*Minimo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Minimo, 0)
+	for _, m := range r.minimos {
+		if m.EmpresaID == empresaID {
+			result = append(result, m)
+		}
+	}
+	return result, nil
+}
+
+// validarDeposito verifica os campos do depósito (deve ser chamado com o lock adquirido)
+func (r *MemoryRepository) validarDeposito(d *Deposito) error {
+	if d.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if d.Nome == "" || d.Codigo == "" {
+		return errors.New("código e nome são obrigatórios")
+	}
+
+	for _, outro := range r.depositos {
+		if outro.ID != d.ID && outro.EmpresaID == d.EmpresaID && outro.Codigo == d.Codigo {
+			return errors.New("código de depósito já utilizado")
+		}
+	}
+	return nil
+}
+
+// unicoPadrao garante um único depósito padrão por empresa (deve ser chamado com o lock adquirido)
+func (r *MemoryRepository) unicoPadrao(d *Deposito) {
+	if !d.Padrao {
+		return
+	}
+	for _, outro := range r.depositos {
+		if outro.ID != d.ID && outro.EmpresaID == d.EmpresaID {
+			outro.Padrao = false
+		}
+	}
+}
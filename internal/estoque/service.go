@@ -0,0 +1,536 @@
+package estoque
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/produto"
+)
+
+// NovoMovimento contém os dados de um lançamento manual no razão
+type NovoMovimento struct {
+	Tipo              string    `json:"tipo"`
+	ProdutoID         int       `json:"produto_id"`
+	DepositoID        int       `json:"deposito_id"` // zero usa o depósito padrão
+	DepositoDestinoID int       `json:"deposito_destino_id"`
+	Quantidade        float64   `json:"quantidade"`
+	CustoUnitario     float64   `json:"custo_unitario"` // zero usa o custo do cadastro do produto
+	Documento         string    `json:"documento"`
+	Observacao        string    `json:"observacao"`
+	Data              time.Time `json:"data"`
+}
+
+// ItemReserva é um produto a ser reservado para um pedido
+type ItemReserva struct {
+	ProdutoID  int     `json:"produto_id"`
+	DepositoID int     `json:"deposito_id"` // zero usa o depósito padrão
+	Quantidade float64 `json:"quantidade"`
+}
+
+// Service apura saldos a partir do razão e controla movimentos e reservas
+type Service struct {
+	repo     Repository
+	produtos produto.Repository
+
+	// mu serializa movimentos e reservas para que o disponível nunca fique negativo. A garantia vale
+	// dentro de um processo: com várias instâncias, a verificação do saldo e a gravação precisam
+	// acontecer numa transação do banco
+	mu sync.Mutex
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, produtos produto.Repository) *Service {
+	return &Service{
+		repo:     repo,
+		produtos: produtos,
+	}
+}
+
+// Movimentar valida e registra um lançamento no razão
+func (s *Service) Movimentar(empresaID, usuarioID int, in NovoMovimento) (*Movimento, error) {
+	p, err := s.produtos.GetByID(in.ProdutoID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Tipo != produto.TipoProduto {
+		return nil, errors.New("serviços não possuem controle de estoque")
+	}
+
+	deposito, err := s.deposito(empresaID, in.DepositoID)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Movimento{
+		EmpresaID:     empresaID,
+		Tipo:          in.Tipo,
+		ProdutoID:     p.ID,
+		DepositoID:    deposito.ID,
+		Quantidade:    in.Quantidade,
+		CustoUnitario: in.CustoUnitario,
+		Documento:     in.Documento,
+		Observacao:    in.Observacao,
+		UsuarioID:     usuarioID,
+		Data:          in.Data,
+	}
+
+	// Quantidade que sai do depósito de origem
+	baixa := 0.0
+	switch in.Tipo {
+	case TipoEntrada:
+		if in.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser positiva")
+		}
+		if m.CustoUnitario <= 0 {
+			m.CustoUnitario = p.Custo
+		}
+	case TipoSaida:
+		if in.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser positiva")
+		}
+		m.CustoUnitario = 0
+		baixa = in.Quantidade
+	case TipoTransferencia:
+		if in.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser positiva")
+		}
+		destino, err := s.repo.GetDeposito(in.DepositoDestinoID, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if !destino.Ativo {
+			return nil, errors.New("depósito de destino inativo")
+		}
+		if destino.ID == deposito.ID {
+			return nil, errors.New("depósitos de origem e destino devem ser diferentes")
+		}
+		m.DepositoDestinoID = destino.ID
+		m.CustoUnitario = 0
+		baixa = in.Quantidade
+	case TipoAjuste:
+		if in.Quantidade == 0 {
+			return nil, errors.New("quantidade do ajuste não pode ser zero")
+		}
+		if in.Quantidade < 0 {
+			m.CustoUnitario = 0
+			baixa = -in.Quantidade
+		}
+	default:
+		return nil, fmt.Errorf("tipo de movimento inválido: %s", in.Tipo)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if baixa > 0 {
+		pos, reservado, err := s.posicao(empresaID)
+		if err != nil {
+			return nil, err
+		}
+		k := chave{p.ID, deposito.ID}
+		if disponivel := pos.fisico[k] - reservado[k]; baixa > disponivel+epsilon {
+			return nil, fmt.Errorf("saldo disponível insuficiente para %s: %.3f", p.SKU, disponivel)
+		}
+	}
+
+	if err := s.repo.AddMovimento(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Saldos apura a posição atual por produto e depósito. Filtros zerados não restringem.
+func (s *Service) Saldos(empresaID, produtoID, depositoID int) ([]Saldo, error) {
+	pos, reservado, err := s.posicao(empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	chaves := make(map[chave]bool)
+	for k := range pos.fisico {
+		chaves[k] = true
+	}
+	for k := range reservado {
+		chaves[k] = true
+	}
+
+	saldos := make([]Saldo, 0)
+	for k := range chaves {
+		if produtoID > 0 && k.produto != produtoID {
+			continue
+		}
+		if depositoID > 0 && k.deposito != depositoID {
+			continue
+		}
+
+		quantidade := arredondarQtd(pos.fisico[k])
+		custo := pos.custo[k.produto]
+		saldos = append(saldos, Saldo{
+			ProdutoID:  k.produto,
+			DepositoID: k.deposito,
+			Quantidade: quantidade,
+			Reservado:  arredondarQtd(reservado[k]),
+			Disponivel: arredondarQtd(pos.fisico[k] - reservado[k]),
+			CustoMedio: arredondarCusto(custo),
+			Valor:      arredondar(quantidade * custo),
+		})
+	}
+
+	sort.Slice(saldos, func(i, j int) bool {
+		if saldos[i].ProdutoID != saldos[j].ProdutoID {
+			return saldos[i].ProdutoID < saldos[j].ProdutoID
+		}
+		return saldos[i].DepositoID < saldos[j].DepositoID
+	})
+
+	return saldos, nil
+}
+
+// Reservar separa o estoque dos itens de um pedido. A operação é atômica:
+// se algum item não tiver saldo disponível, nenhuma reserva é criada.
+func (s *Service) Reservar(empresaID, pedidoID int, itens []ItemReserva) ([]*Reserva, error) {
+	if pedidoID <= 0 {
+		return nil, errors.New("pedido inválido")
+	}
+
+	reservas := make([]*Reserva, 0, len(itens))
+	solicitado := make(map[chave]float64)
+	for _, item := range itens {
+		p, err := s.produtos.GetByID(item.ProdutoID, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if p.Tipo != produto.TipoProduto {
+			continue
+		}
+		if item.Quantidade <= 0 {
+			return nil, errors.New("quantidade deve ser positiva")
+		}
+
+		deposito, err := s.deposito(empresaID, item.DepositoID)
+		if err != nil {
+			return nil, err
+		}
+
+		solicitado[chave{p.ID, deposito.ID}] += item.Quantidade
+		reservas = append(reservas, &Reserva{
+			EmpresaID:  empresaID,
+			PedidoID:   pedidoID,
+			ProdutoID:  p.ID,
+			DepositoID: deposito.ID,
+			Quantidade: item.Quantidade,
+			Status:     ReservaAtiva,
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	pos, reservado, err := s.posicao(empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	for k, qtd := range solicitado {
+		if disponivel := pos.fisico[k] - reservado[k]; qtd > disponivel+epsilon {
+			return nil, fmt.Errorf("saldo disponível insuficiente para o produto %d no depósito %d: %.3f", k.produto, k.deposito, disponivel)
+		}
+	}
+
+	for _, r := range reservas {
+		if err := s.repo.CreateReserva(r); err != nil {
+			return nil, err
+		}
+	}
+	return reservas, nil
+}
+
+// CancelarReserva libera uma reserva ativa
+func (s *Service) CancelarReserva(id int, empresaID int) (*Reserva, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.repo.GetReserva(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if r.Status != ReservaAtiva {
+		return nil, fmt.Errorf("reserva já está %s", r.Status)
+	}
+
+	agora := time.Now()
+	r.Status = ReservaCancelada
+	r.DataBaixa = &agora
+	if err := s.repo.UpdateReserva(r); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// LiberarPedido cancela todas as reservas ativas de um pedido
+func (s *Service) LiberarPedido(empresaID, pedidoID int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservas, err := s.repo.ListReservas(FiltroReservas{EmpresaID: empresaID, PedidoID: pedidoID, Status: ReservaAtiva})
+	if err != nil {
+		return err
+	}
+
+	agora := time.Now()
+	for _, r := range reservas {
+		r.Status = ReservaCancelada
+		r.DataBaixa = &agora
+		if err := s.repo.UpdateReserva(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BaixarPedido converte as reservas ativas de um pedido em saídas do estoque
+func (s *Service) BaixarPedido(empresaID, pedidoID, usuarioID int, documento string) ([]*Movimento, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	reservas, err := s.repo.ListReservas(FiltroReservas{EmpresaID: empresaID, PedidoID: pedidoID, Status: ReservaAtiva})
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	movimentos := make([]*Movimento, 0, len(reservas))
+	for _, r := range reservas {
+		m := &Movimento{
+			EmpresaID:  empresaID,
+			Tipo:       TipoSaida,
+			ProdutoID:  r.ProdutoID,
+			DepositoID: r.DepositoID,
+			Quantidade: r.Quantidade,
+			ReservaID:  r.ID,
+			Documento:  documento,
+			UsuarioID:  usuarioID,
+			Data:       agora,
+		}
+		if err := s.repo.AddMovimento(m); err != nil {
+			return movimentos, err
+		}
+
+		r.Status = ReservaConsumida
+		r.DataBaixa = &agora
+		if err := s.repo.UpdateReserva(r); err != nil {
+			return movimentos, err
+		}
+		movimentos = append(movimentos, m)
+	}
+	return movimentos, nil
+}
+
+// Alertas lista os produtos com disponível abaixo do estoque mínimo
+func (s *Service) Alertas(empresaID int) ([]Alerta, error) {
+	minimos, err := s.repo.ListMinimos(empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	pos, reservado, err := s.posicao(empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	alertas := make([]Alerta, 0)
+	for _, m := range minimos {
+		disponivel := 0.0
+		for k, qtd := range pos.fisico {
+			if k.produto == m.ProdutoID && (m.DepositoID == 0 || k.deposito == m.DepositoID) {
+				disponivel += qtd - reservado[k]
+			}
+		}
+		if disponivel >= m.Quantidade {
+			continue
+		}
+
+		a := Alerta{
+			ProdutoID:  m.ProdutoID,
+			DepositoID: m.DepositoID,
+			Minimo:     m.Quantidade,
+			Disponivel: arredondarQtd(disponivel),
+			Falta:      arredondarQtd(m.Quantidade - disponivel),
+		}
+		if p, err := s.produtos.GetByID(m.ProdutoID, empresaID); err == nil {
+			if !p.Ativo {
+				continue
+			}
+			a.SKU = p.SKU
+			a.Nome = p.Nome
+		}
+		alertas = append(alertas, a)
+	}
+
+	sort.Slice(alertas, func(i, j int) bool {
+		return alertas[i].Falta > alertas[j].Falta
+	})
+
+	return alertas, nil
+}
+
+// Avaliar valoriza o estoque pelo custo médio ponderado na data informada
+func (s *Service) Avaliar(empresaID int, data time.Time) (*Avaliacao, error) {
+	movimentos, err := s.repo.ListMovimentos(FiltroMovimentos{EmpresaID: empresaID, Fim: data}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	pos := apurar(movimentos)
+
+	av := &Avaliacao{Data: data, Itens: []ItemAvaliacao{}}
+	for produtoID, quantidade := range pos.total {
+		if math.Abs(quantidade) < epsilon {
+			continue
+		}
+
+		custo := pos.custo[produtoID]
+		item := ItemAvaliacao{
+			ProdutoID:  produtoID,
+			Quantidade: arredondarQtd(quantidade),
+			CustoMedio: arredondarCusto(custo),
+			Valor:      arredondar(quantidade * custo),
+		}
+		if p, err := s.produtos.GetByID(produtoID, empresaID); err == nil {
+			item.SKU = p.SKU
+			item.Nome = p.Nome
+		}
+
+		av.Itens = append(av.Itens, item)
+		av.Total += item.Valor
+	}
+
+	sort.Slice(av.Itens, func(i, j int) bool {
+		return av.Itens[i].Nome < av.Itens[j].Nome
+	})
+	av.Total = arredondar(av.Total)
+
+	return av, nil
+}
+
+// deposito retorna o depósito informado ou o depósito padrão da empresa
+func (s *Service) deposito(empresaID, id int) (*Deposito, error) {
+	if id > 0 {
+		d, err := s.repo.GetDeposito(id, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if !d.Ativo {
+			return nil, errors.New("depósito inativo")
+		}
+		return d, nil
+	}
+
+	depositos, err := s.repo.ListDepositos(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range depositos {
+		if d.Padrao && d.Ativo {
+			return d, nil
+		}
+	}
+	return nil, errors.New("informe o depósito ou configure um depósito padrão")
+}
+
+// posicao apura o razão completo e soma as reservas ativas por produto e depósito
+func (s *Service) posicao(empresaID int) (*posicao, map[chave]float64, error) {
+	movimentos, err := s.repo.ListMovimentos(FiltroMovimentos{EmpresaID: empresaID}, 0, 0)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reservas, err := s.repo.ListReservas(FiltroReservas{EmpresaID: empresaID, Status: ReservaAtiva})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reservado := make(map[chave]float64)
+	for _, r := range reservas {
+		reservado[chave{r.ProdutoID, r.DepositoID}] += r.Quantidade
+	}
+
+	return apurar(movimentos), reservado, nil
+}
+
+// epsilon absorve erros de ponto flutuante na comparação de quantidades
+const epsilon = 1e-9
+
+type chave struct {
+	produto  int
+	deposito int
+}
+
+// posicao é o resultado da apuração do razão
+type posicao struct {
+	fisico map[chave]float64 // quantidade por produto e depósito
+	total  map[int]float64   // quantidade total por produto
+	custo  map[int]float64   // custo médio ponderado por produto
+}
+
+// apurar percorre os movimentos em ordem cronológica calculando saldos e custo médio
+func apurar(movimentos []*Movimento) *posicao {
+	pos := &posicao{
+		fisico: make(map[chave]float64),
+		total:  make(map[int]float64),
+		custo:  make(map[int]float64),
+	}
+
+	entrada := func(produtoID int, quantidade, custo float64) {
+		atual := pos.total[produtoID]
+		if custo > 0 {
+			if atual <= 0 {
+				pos.custo[produtoID] = custo
+			} else {
+				pos.custo[produtoID] = (atual*pos.custo[produtoID] + quantidade*custo) / (atual + quantidade)
+			}
+		}
+		pos.total[produtoID] = atual + quantidade
+	}
+
+	for _, m := range movimentos {
+		origem := chave{m.ProdutoID, m.DepositoID}
+		switch m.Tipo {
+		case TipoEntrada:
+			pos.fisico[origem] += m.Quantidade
+			entrada(m.ProdutoID, m.Quantidade, m.CustoUnitario)
+		case TipoSaida:
+			pos.fisico[origem] -= m.Quantidade
+			pos.total[m.ProdutoID] -= m.Quantidade
+		case TipoTransferencia:
+			pos.fisico[origem] -= m.Quantidade
+			pos.fisico[chave{m.ProdutoID, m.DepositoDestinoID}] += m.Quantidade
+		case TipoAjuste:
+			pos.fisico[origem] += m.Quantidade
+			if m.Quantidade > 0 {
+				entrada(m.ProdutoID, m.Quantidade, m.CustoUnitario)
+			} else {
+				pos.total[m.ProdutoID] += m.Quantidade
+			}
+		}
+	}
+
+	return pos
+}
+
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// arredondarQtd mantém três casas decimais, suficientes para unidades fracionadas (KG, M)
+func arredondarQtd(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// arredondarCusto mantém quatro casas decimais no custo médio unitário
+func arredondarCusto(v float64) float64 {
+	return math.Round(v*10000) / 10000
+}
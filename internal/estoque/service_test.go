@@ -0,0 +1,60 @@
+package estoque
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/produto"
+)
+
+// Reservas e saídas concorrentes nunca deixam o disponível negativo
+func TestReservasConcorrentes(t *testing.T) {
+	repo := NewMemoryRepository()
+	produtos := produto.NewMemoryRepository()
+	s := NewService(repo, produtos)
+
+	p := &produto.Produto{EmpresaID: 1, SKU: "CAN-01", Nome: "Caneta", Custo: 1, Preco: 2}
+	if err := produtos.Create(p); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.CreateDeposito(&Deposito{EmpresaID: 1, Codigo: "CD", Nome: "Central", Padrao: true, Ativo: true}); err != nil {
+		t.Fatal(err)
+	}
+	const estoque = 50
+	if _, err := s.Movimentar(1, 1, NovoMovimento{Tipo: TipoEntrada, ProdutoID: p.ID, Quantidade: estoque}); err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	aceitos := 0.0
+	var wg sync.WaitGroup
+	for i := 0; i < 40; i++ {
+		wg.Add(1)
+		go func(pedido int) {
+			defer wg.Done()
+			var err error
+			if pedido%4 == 0 {
+				_, err = s.Movimentar(1, 1, NovoMovimento{Tipo: TipoSaida, ProdutoID: p.ID, Quantidade: 3})
+			} else {
+				_, err = s.Reservar(1, pedido, []ItemReserva{{ProdutoID: p.ID, Quantidade: 3}})
+			}
+			if err == nil {
+				mu.Lock()
+				aceitos += 3
+				mu.Unlock()
+			}
+		}(i + 1)
+	}
+	wg.Wait()
+
+	saldos, err := s.Saldos(1, p.ID, 0)
+	if err != nil || len(saldos) != 1 {
+		t.Fatalf("saldos = %+v, %v", saldos, err)
+	}
+	if saldos[0].Disponivel < 0 {
+		t.Errorf("disponível negativo: %.3f", saldos[0].Disponivel)
+	}
+	if aceitos > estoque || aceitos < estoque-2 {
+		t.Errorf("%.0f unidades aceitas de %d em estoque", aceitos, estoque)
+	}
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// RetencaoOutbox é o tempo que os eventos já entregues a todos os assinantes ficam no outbox
+const RetencaoOutbox = 24 * time.Hour
+
+// loteEntrega é a quantidade de eventos lidos por assinante a cada rodada
+const loteEntrega = 100
+
+// Handler trata um evento. Retornar erro faz o evento ser entregue de novo na próxima rodada,
+// por isso os assinantes devem tolerar entregas repetidas.
+type Handler func(ev *Evento) error
+
+// assinatura é um assinante registrado no barramento
+type assinatura struct {
+	nome    string
+	tipos   map[string]bool // vazio recebe todos os tipos
+	handler Handler
+}
+
+// Barramento grava os eventos no outbox e os entrega aos assinantes
+type Barramento struct {
+	outbox Outbox
+	avisar chan struct{}
+
+	mu          sync.RWMutex
+	assinaturas []*assinatura
+}
+
+// NewBarramento cria um barramento sobre o outbox
+func NewBarramento(outbox Outbox) *Barramento {
+	return &Barramento{
+		outbox: outbox,
+		avisar: make(chan struct{}, 1),
+	}
+}
+
+// Transacao executa a alteração gravando os eventos emitidos por ela e avisa o despachante
+func (b *Barramento) Transacao(alteracao func(tx *Tx) error) error {
+	if err := b.outbox.Transacao(alteracao); err != nil {
+		return err
+	}
+	b.acordar()
+	return nil
+}
+
+// Assinar registra um assinante para os tipos informados, ou para todos quando nenhum é informado.
+// O nome identifica a posição do assinante no outbox e não deve mudar entre execuções.
+func (b *Barramento) Assinar(nome string, handler Handler, tipos ...string) {
+	a := &assinatura{nome: nome, tipos: make(map[string]bool), handler: handler}
+	for _, t := range tipos {
+		a.tipos[t] = true
+	}
+
+	b.mu.Lock()
+	b.assinaturas = append(b.assinaturas, a)
+	b.mu.Unlock()
+}
+
+// Processar entrega os eventos pendentes a cada assinante e retorna quantas entregas foram feitas.
+// Quando um evento falha, os eventos seguintes do mesmo agregado esperam a próxima rodada,
+// preservando a ordem; os de outros agregados continuam sendo entregues.
+func (b *Barramento) Processar() int {
+	b.mu.RLock()
+	assinaturas := append([]*assinatura(nil), b.assinaturas...)
+	b.mu.RUnlock()
+
+	total := 0
+	for _, a := range assinaturas {
+		pendentes, err := b.outbox.Pendentes(a.nome, loteEntrega)
+		if err != nil {
+			logger.ErrorLogger.Printf("Erro ao ler eventos pendentes de %s: %v", a.nome, err)
+			continue
+		}
+
+		bloqueados := make(map[string]bool)
+		for _, ev := range pendentes {
+			chave := chaveAgregado(ev.Agregado, ev.AgregadoID)
+			if bloqueados[chave] {
+				continue
+			}
+
+			if len(a.tipos) == 0 || a.tipos[ev.Tipo] {
+				if err := a.entregar(ev); err != nil {
+					logger.ErrorLogger.Printf("Erro ao entregar evento %d (%s) a %s: %v", ev.ID, ev.Tipo, a.nome, err)
+					bloqueados[chave] = true
+					continue
+				}
+				total++
+			}
+
+			if err := b.outbox.Confirmar(a.nome, ev.ID); err != nil {
+				logger.ErrorLogger.Printf("Erro ao confirmar evento %d para %s: %v", ev.ID, a.nome, err)
+				bloqueados[chave] = true
+			}
+		}
+	}
+	return total
+}
+
+// Executar entrega os eventos assim que são gravados, refazendo as entregas que falharam
+// a cada intervalo, até o contexto ser cancelado
+func (b *Barramento) Executar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.expurgar()
+		case <-b.avisar:
+		}
+
+		for ctx.Err() == nil && b.Processar() > 0 {
+		}
+	}
+}
+
+// expurgar remove do outbox os eventos antigos entregues a todos os assinantes
+func (b *Barramento) expurgar() {
+	b.mu.RLock()
+	nomes := make([]string, 0, len(b.assinaturas))
+	for _, a := range b.assinaturas {
+		nomes = append(nomes, a.nome)
+	}
+	b.mu.RUnlock()
+
+	if _, err := b.outbox.Expurgar(nomes, time.Now().Add(-RetencaoOutbox)); err != nil {
+		logger.ErrorLogger.Printf("Erro ao expurgar o outbox de eventos: %v", err)
+	}
+}
+
+// acordar avisa o despachante sem bloquear quando ele já tem um aviso pendente
+func (b *Barramento) acordar() {
+	select {
+	case b.avisar <- struct{}{}:
+	default:
+	}
+}
+
+// entregar chama o handler convertendo pânicos em erro, para que um assinante não derrube o despachante
+func (a *assinatura) entregar(ev *Evento) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pânico no assinante: %v", r)
+		}
+	}()
+	return a.handler(ev)
+}
@@ -0,0 +1,288 @@
+// Package events publica os eventos de domínio gerados pelas alterações dos cadastros.
+// Os eventos são gravados num outbox junto com a alteração e entregues aos assinantes
+// pelo Barramento, ao menos uma vez e na ordem em que ocorreram em cada agregado.
+package events
+
+import (
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/kanban"
+	"github.com/Pantaleaogc/gvero/internal/pedido"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+)
+
+// Agregados que emitem eventos
+const (
+	AgregadoCliente = "cliente"
+	AgregadoEmpresa = "empresa"
+	AgregadoUsuario = "usuario"
+	AgregadoPedido  = "pedido"
+	AgregadoNegocio = "negocio"
+	AgregadoProjeto = "projeto"
+	AgregadoTarefa  = "tarefa"
+)
+
+// Tipos de evento
+const (
+	TipoClienteCriado      = "cliente.criado"
+	TipoClienteAlterado    = "cliente.alterado"
+	TipoClienteExcluido    = "cliente.excluido"
+	TipoClienteAnonimizado = "cliente.anonimizado"
+	TipoEmpresaCriada      = "empresa.criada"
+	TipoEmpresaAlterada    = "empresa.alterada"
+	TipoEmpresaExcluida    = "empresa.excluida"
+	TipoUsuarioCriado      = "usuario.criado"
+	TipoUsuarioAlterado    = "usuario.alterado"
+	TipoUsuarioExcluido    = "usuario.excluido"
+	TipoPedidoCriado       = "pedido.criado"
+	TipoPedidoAlterado     = "pedido.alterado"
+	TipoNegocioCriado      = "negocio.criado"
+	TipoNegocioAlterado    = "negocio.alterado"
+	TipoNegocioExcluido    = "negocio.excluido"
+	TipoNegocioGanho       = "negocio.ganho"
+	TipoProjetoCriado      = "projeto.criado"
+	TipoProjetoAlterado    = "projeto.alterado"
+	TipoProjetoExcluido    = "projeto.excluido"
+	TipoTarefaCriada       = "tarefa.criada"
+	TipoTarefaAlterada     = "tarefa.alterada"
+	TipoTarefaExcluida     = "tarefa.excluida"
+	TipoTarefaAtribuida    = "tarefa.atribuida"
+)
+
+// Dominio é implementado pelos eventos tipados. Os eventos guardam cópias dos registros,
+// que não mudam quando o registro é alterado depois.
+type Dominio interface {
+	Tipo() string
+	// Agregado identifica o registro alterado; os eventos de um mesmo agregado são entregues em ordem
+	Agregado() (tipo string, id int)
+	// Escopo retorna a empresa a que o evento pertence
+	Escopo() int
+}
+
+// Evento é um evento de domínio gravado no outbox
+type Evento struct {
+	ID         int64     `json:"id"` // sequência global de gravação
+	Tipo       string    `json:"tipo"`
+	Agregado   string    `json:"agregado"`
+	AgregadoID int       `json:"agregado_id"`
+	Versao     int       `json:"versao"` // sequência do evento dentro do agregado
+	EmpresaID  int       `json:"empresa_id"`
+	Dados      Dominio   `json:"dados"`
+	Data       time.Time `json:"data"`
+}
+
+// ClienteCriado é emitido ao cadastrar um cliente; traz o cadastro com os dados pessoais mascarados
+type ClienteCriado struct {
+	Cliente cliente.Cliente `json:"cliente"`
+}
+
+func (e ClienteCriado) Tipo() string            { return TipoClienteCriado }
+func (e ClienteCriado) Agregado() (string, int) { return AgregadoCliente, e.Cliente.ID }
+func (e ClienteCriado) Escopo() int             { return e.Cliente.EmpresaID }
+
+// ClienteAlterado é emitido ao atualizar um cliente, inclusive na mesclagem de duplicados; traz o
+// cadastro com os dados pessoais mascarados
+type ClienteAlterado struct {
+	Cliente cliente.Cliente `json:"cliente"`
+}
+
+func (e ClienteAlterado) Tipo() string            { return TipoClienteAlterado }
+func (e ClienteAlterado) Agregado() (string, int) { return AgregadoCliente, e.Cliente.ID }
+func (e ClienteAlterado) Escopo() int             { return e.Cliente.EmpresaID }
+
+// ClienteExcluido é emitido ao excluir um cliente
+type ClienteExcluido struct {
+	ID        int `json:"id"`
+	EmpresaID int `json:"empresa_id"`
+}
+
+func (e ClienteExcluido) Tipo() string            { return TipoClienteExcluido }
+func (e ClienteExcluido) Agregado() (string, int) { return AgregadoCliente, e.ID }
+func (e ClienteExcluido) Escopo() int             { return e.EmpresaID }
+
+// ClienteAnonimizado é emitido ao eliminar os dados pessoais de um cliente; traz o cadastro já anonimizado
+type ClienteAnonimizado struct {
+	Cliente cliente.Cliente `json:"cliente"`
+}
+
+func (e ClienteAnonimizado) Tipo() string            { return TipoClienteAnonimizado }
+func (e ClienteAnonimizado) Agregado() (string, int) { return AgregadoCliente, e.Cliente.ID }
+func (e ClienteAnonimizado) Escopo() int             { return e.Cliente.EmpresaID }
+
+// EmpresaCriada é emitido ao cadastrar uma empresa
+type EmpresaCriada struct {
+	Empresa empresa.Empresa `json:"empresa"`
+}
+
+func (e EmpresaCriada) Tipo() string            { return TipoEmpresaCriada }
+func (e EmpresaCriada) Agregado() (string, int) { return AgregadoEmpresa, e.Empresa.ID }
+func (e EmpresaCriada) Escopo() int             { return e.Empresa.ID }
+
+// EmpresaAlterada é emitido ao atualizar uma empresa
+type EmpresaAlterada struct {
+	Empresa empresa.Empresa `json:"empresa"`
+}
+
+func (e EmpresaAlterada) Tipo() string            { return TipoEmpresaAlterada }
+func (e EmpresaAlterada) Agregado() (string, int) { return AgregadoEmpresa, e.Empresa.ID }
+func (e EmpresaAlterada) Escopo() int             { return e.Empresa.ID }
+
+// EmpresaExcluida é emitido ao excluir uma empresa
+type EmpresaExcluida struct {
+	ID int `json:"id"`
+}
+
+func (e EmpresaExcluida) Tipo() string            { return TipoEmpresaExcluida }
+func (e EmpresaExcluida) Agregado() (string, int) { return AgregadoEmpresa, e.ID }
+func (e EmpresaExcluida) Escopo() int             { return e.ID }
+
+// UsuarioCriado é emitido ao cadastrar um usuário; a cópia não leva a senha
+type UsuarioCriado struct {
+	Usuario usuario.Usuario `json:"usuario"`
+}
+
+func (e UsuarioCriado) Tipo() string            { return TipoUsuarioCriado }
+func (e UsuarioCriado) Agregado() (string, int) { return AgregadoUsuario, e.Usuario.ID }
+func (e UsuarioCriado) Escopo() int             { return e.Usuario.EmpresaID }
+
+// UsuarioAlterado é emitido ao atualizar um usuário; a cópia não leva a senha
+type UsuarioAlterado struct {
+	Usuario usuario.Usuario `json:"usuario"`
+}
+
+func (e UsuarioAlterado) Tipo() string            { return TipoUsuarioAlterado }
+func (e UsuarioAlterado) Agregado() (string, int) { return AgregadoUsuario, e.Usuario.ID }
+func (e UsuarioAlterado) Escopo() int             { return e.Usuario.EmpresaID }
+
+// UsuarioExcluido é emitido ao excluir um usuário
+type UsuarioExcluido struct {
+	ID        int `json:"id"`
+	EmpresaID int `json:"empresa_id"`
+}
+
+func (e UsuarioExcluido) Tipo() string            { return TipoUsuarioExcluido }
+func (e UsuarioExcluido) Agregado() (string, int) { return AgregadoUsuario, e.ID }
+func (e UsuarioExcluido) Escopo() int             { return e.EmpresaID }
+
+// PedidoCriado é emitido ao registrar um orçamento ou pedido
+type PedidoCriado struct {
+	Pedido pedido.Pedido `json:"pedido"`
+}
+
+func (e PedidoCriado) Tipo() string            { return TipoPedidoCriado }
+func (e PedidoCriado) Agregado() (string, int) { return AgregadoPedido, e.Pedido.ID }
+func (e PedidoCriado) Escopo() int             { return e.Pedido.EmpresaID }
+
+// PedidoAlterado é emitido a cada atualização do pedido, inclusive mudanças de status
+type PedidoAlterado struct {
+	Pedido pedido.Pedido `json:"pedido"`
+}
+
+func (e PedidoAlterado) Tipo() string            { return TipoPedidoAlterado }
+func (e PedidoAlterado) Agregado() (string, int) { return AgregadoPedido, e.Pedido.ID }
+func (e PedidoAlterado) Escopo() int             { return e.Pedido.EmpresaID }
+
+// NegocioCriado é emitido ao cadastrar um negócio
+type NegocioCriado struct {
+	Negocio kanban.Negocio `json:"negocio"`
+}
+
+func (e NegocioCriado) Tipo() string            { return TipoNegocioCriado }
+func (e NegocioCriado) Agregado() (string, int) { return AgregadoNegocio, e.Negocio.ID }
+func (e NegocioCriado) Escopo() int             { return e.Negocio.EmpresaID }
+
+// NegocioAlterado é emitido a cada atualização do negócio, inclusive mudanças de etapa e status
+type NegocioAlterado struct {
+	Negocio kanban.Negocio `json:"negocio"`
+}
+
+func (e NegocioAlterado) Tipo() string            { return TipoNegocioAlterado }
+func (e NegocioAlterado) Agregado() (string, int) { return AgregadoNegocio, e.Negocio.ID }
+func (e NegocioAlterado) Escopo() int             { return e.Negocio.EmpresaID }
+
+// NegocioExcluido é emitido ao excluir um negócio
+type NegocioExcluido struct {
+	ID        int `json:"id"`
+	EmpresaID int `json:"empresa_id"`
+}
+
+func (e NegocioExcluido) Tipo() string            { return TipoNegocioExcluido }
+func (e NegocioExcluido) Agregado() (string, int) { return AgregadoNegocio, e.ID }
+func (e NegocioExcluido) Escopo() int             { return e.EmpresaID }
+
+// NegocioGanho é emitido, além de NegocioCriado ou NegocioAlterado, quando o negócio passa a ganho
+type NegocioGanho struct {
+	Negocio kanban.Negocio `json:"negocio"`
+}
+
+func (e NegocioGanho) Tipo() string            { return TipoNegocioGanho }
+func (e NegocioGanho) Agregado() (string, int) { return AgregadoNegocio, e.Negocio.ID }
+func (e NegocioGanho) Escopo() int             { return e.Negocio.EmpresaID }
+
+// ProjetoCriado é emitido ao cadastrar um projeto
+type ProjetoCriado struct {
+	Projeto kanban.Projeto `json:"projeto"`
+}
+
+func (e ProjetoCriado) Tipo() string            { return TipoProjetoCriado }
+func (e ProjetoCriado) Agregado() (string, int) { return AgregadoProjeto, e.Projeto.ID }
+func (e ProjetoCriado) Escopo() int             { return e.Projeto.EmpresaID }
+
+// ProjetoAlterado é emitido a cada atualização do projeto
+type ProjetoAlterado struct {
+	Projeto kanban.Projeto `json:"projeto"`
+}
+
+func (e ProjetoAlterado) Tipo() string            { return TipoProjetoAlterado }
+func (e ProjetoAlterado) Agregado() (string, int) { return AgregadoProjeto, e.Projeto.ID }
+func (e ProjetoAlterado) Escopo() int             { return e.Projeto.EmpresaID }
+
+// ProjetoExcluido é emitido ao excluir um projeto
+type ProjetoExcluido struct {
+	ID        int `json:"id"`
+	EmpresaID int `json:"empresa_id"`
+}
+
+func (e ProjetoExcluido) Tipo() string            { return TipoProjetoExcluido }
+func (e ProjetoExcluido) Agregado() (string, int) { return AgregadoProjeto, e.ID }
+func (e ProjetoExcluido) Escopo() int             { return e.EmpresaID }
+
+// TarefaCriada é emitido ao cadastrar uma tarefa
+type TarefaCriada struct {
+	Tarefa kanban.Tarefa `json:"tarefa"`
+}
+
+func (e TarefaCriada) Tipo() string            { return TipoTarefaCriada }
+func (e TarefaCriada) Agregado() (string, int) { return AgregadoTarefa, e.Tarefa.ID }
+func (e TarefaCriada) Escopo() int             { return e.Tarefa.EmpresaID }
+
+// TarefaAlterada é emitido a cada atualização da tarefa
+type TarefaAlterada struct {
+	Tarefa kanban.Tarefa `json:"tarefa"`
+}
+
+func (e TarefaAlterada) Tipo() string            { return TipoTarefaAlterada }
+func (e TarefaAlterada) Agregado() (string, int) { return AgregadoTarefa, e.Tarefa.ID }
+func (e TarefaAlterada) Escopo() int             { return e.Tarefa.EmpresaID }
+
+// TarefaExcluida é emitido ao excluir uma tarefa
+type TarefaExcluida struct {
+	ID        int `json:"id"`
+	EmpresaID int `json:"empresa_id"`
+}
+
+func (e TarefaExcluida) Tipo() string            { return TipoTarefaExcluida }
+func (e TarefaExcluida) Agregado() (string, int) { return AgregadoTarefa, e.ID }
+func (e TarefaExcluida) Escopo() int             { return e.EmpresaID }
+
+// TarefaAtribuida é emitido, além de TarefaCriada ou TarefaAlterada, quando a tarefa recebe um
+// novo responsável
+type TarefaAtribuida struct {
+	Tarefa kanban.Tarefa `json:"tarefa"`
+}
+
+func (e TarefaAtribuida) Tipo() string            { return TipoTarefaAtribuida }
+func (e TarefaAtribuida) Agregado() (string, int) { return AgregadoTarefa, e.Tarefa.ID }
+func (e TarefaAtribuida) Escopo() int             { return e.Tarefa.EmpresaID }
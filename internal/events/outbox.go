@@ -0,0 +1,251 @@
+package events
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tx acumula os eventos emitidos durante uma alteração e as travas dos agregados alterados
+type Tx struct {
+	eventos []Dominio
+	sql     *sql.Tx
+
+	travas   *travas
+	travados map[string]func()
+}
+
+// Emitir registra um evento, gravado apenas se a alteração for concluída. O agregado do evento
+// fica travado até o fim da transação
+func (t *Tx) Emitir(ev Dominio) {
+	t.Travar(ev.Agregado())
+	t.eventos = append(t.eventos, ev)
+}
+
+// Travar reserva o agregado até o fim da transação, para que as alterações concorrentes dele
+// gravem seus eventos na ordem em que acontecem. Deve ser chamado antes de alterar um registro
+// existente; travar de novo o mesmo agregado na mesma transação não tem efeito
+func (t *Tx) Travar(agregado string, id int) {
+	chave := chaveAgregado(agregado, id)
+	if _, ok := t.travados[chave]; ok {
+		return
+	}
+	if t.travados == nil {
+		t.travados = make(map[string]func())
+	}
+	t.travados[chave] = t.travas.travar(chave)
+}
+
+// SQL retorna a transação do banco quando o outbox é gravado no MySQL, ou nil. Os repositórios
+// no MySQL gravam por ela para que a alteração e os eventos sejam confirmados juntos
+func (t *Tx) SQL() *sql.Tx {
+	return t.sql
+}
+
+// liberar solta as travas dos agregados ao fim da transação
+func (t *Tx) liberar() {
+	for _, soltar := range t.travados {
+		soltar()
+	}
+	t.travados = nil
+}
+
+// travas guarda uma trava por agregado, criada sob demanda e descartada quando ninguém a usa
+type travas struct {
+	mu     sync.Mutex
+	chaves map[string]*trava
+}
+
+type trava struct {
+	mu   sync.Mutex
+	refs int
+}
+
+func novasTravas() *travas {
+	return &travas{chaves: make(map[string]*trava)}
+}
+
+// travar espera a trava do agregado e retorna a função que a solta
+func (t *travas) travar(chave string) func() {
+	t.mu.Lock()
+	tr := t.chaves[chave]
+	if tr == nil {
+		tr = &trava{}
+		t.chaves[chave] = tr
+	}
+	tr.refs++
+	t.mu.Unlock()
+
+	tr.mu.Lock()
+	return func() {
+		tr.mu.Unlock()
+		t.mu.Lock()
+		tr.refs--
+		if tr.refs == 0 {
+			delete(t.chaves, chave)
+		}
+		t.mu.Unlock()
+	}
+}
+
+// chaveAgregado identifica o agregado nas travas e nas versões
+func chaveAgregado(agregado string, id int) string {
+	return fmt.Sprintf("%s:%d", agregado, id)
+}
+
+// Outbox guarda os eventos até que todos os assinantes os confirmem
+type Outbox interface {
+	// Transacao executa a alteração e grava os eventos emitidos por ela na mesma transação:
+	// se a alteração falhar, nenhum evento é gravado. Uma alteração aninhada não deve travar
+	// um agregado já travado pela transação de fora
+	Transacao(alteracao func(tx *Tx) error) error
+	// Pendentes retorna, em ordem de gravação, os eventos ainda não confirmados pelo assinante
+	Pendentes(assinante string, limit int) ([]*Evento, error)
+	Confirmar(assinante string, id int64) error
+	// Expurgar remove os eventos gravados antes do instante e já confirmados por todos os assinantes
+	Expurgar(assinantes []string, antesDe time.Time) (int, error)
+}
+
+// MemoryOutbox implementa Outbox em memória. Sem banco de dados, a transação é a trava dos
+// agregados alterados: as alterações de um mesmo agregado acontecem uma de cada vez e gravam
+// seus eventos na ordem em que aconteceram, enquanto as de agregados diferentes seguem em paralelo.
+type MemoryOutbox struct {
+	travas *travas
+
+	mu      sync.Mutex
+	eventos []*Evento // em ordem de ID
+	nextID  int64
+	versoes map[string]int // última versão de cada agregado
+
+	// Cada assinante confirmou todos os eventos até o cursor e os do conjunto acima dele
+	cursores    map[string]int64
+	confirmados map[string]map[int64]bool
+}
+
+// NewMemoryOutbox cria um novo outbox em memória
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{
+		travas:      novasTravas(),
+		nextID:      1,
+		versoes:     make(map[string]int),
+		cursores:    make(map[string]int64),
+		confirmados: make(map[string]map[int64]bool),
+	}
+}
+
+// Transacao executa a alteração e grava os eventos emitidos por ela
+func (o *MemoryOutbox) Transacao(alteracao func(tx *Tx) error) error {
+	tx := &Tx{travas: o.travas}
+	defer tx.liberar()
+
+	if err := alteracao(tx); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	agora := time.Now()
+	for _, d := range tx.eventos {
+		agregado, id := d.Agregado()
+		chave := chaveAgregado(agregado, id)
+		o.versoes[chave]++
+
+		o.eventos = append(o.eventos, &Evento{
+			ID:         o.nextID,
+			Tipo:       d.Tipo(),
+			Agregado:   agregado,
+			AgregadoID: id,
+			Versao:     o.versoes[chave],
+			EmpresaID:  d.Escopo(),
+			Dados:      d,
+			Data:       agora,
+		})
+		o.nextID++
+	}
+	return nil
+}
+
+// Pendentes retorna os eventos não confirmados pelo assinante
+func (o *MemoryOutbox) Pendentes(assinante string, limit int) ([]*Evento, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cursor := o.cursores[assinante]
+	confirmados := o.confirmados[assinante]
+
+	result := make([]*Evento, 0)
+	for _, ev := range o.eventos[o.posicao(cursor):] {
+		if confirmados[ev.ID] {
+			continue
+		}
+		result = append(result, ev)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	return result, nil
+}
+
+// Confirmar marca o evento como entregue ao assinante
+func (o *MemoryOutbox) Confirmar(assinante string, id int64) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if id <= 0 || id >= o.nextID {
+		return errors.New("evento não encontrado")
+	}
+
+	cursor := o.cursores[assinante]
+	if id <= cursor {
+		return nil
+	}
+
+	confirmados := o.confirmados[assinante]
+	if confirmados == nil {
+		confirmados = make(map[int64]bool)
+		o.confirmados[assinante] = confirmados
+	}
+	confirmados[id] = true
+
+	// Avança o cursor sobre os confirmados em sequência
+	for _, ev := range o.eventos[o.posicao(cursor):] {
+		if !confirmados[ev.ID] {
+			break
+		}
+		delete(confirmados, ev.ID)
+		cursor = ev.ID
+	}
+	o.cursores[assinante] = cursor
+	return nil
+}
+
+// Expurgar remove os eventos antigos já entregues a todos os assinantes
+func (o *MemoryOutbox) Expurgar(assinantes []string, antesDe time.Time) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	limite := o.nextID - 1
+	for _, a := range assinantes {
+		if o.cursores[a] < limite {
+			limite = o.cursores[a]
+		}
+	}
+
+	n := 0
+	for n < len(o.eventos) && o.eventos[n].ID <= limite && o.eventos[n].Data.Before(antesDe) {
+		n++
+	}
+	o.eventos = append([]*Evento(nil), o.eventos[n:]...)
+	return n, nil
+}
+
+// posicao retorna o índice do primeiro evento posterior ao cursor
+func (o *MemoryOutbox) posicao(cursor int64) int {
+	return sort.Search(len(o.eventos), func(i int) bool {
+		return o.eventos[i].ID > cursor
+	})
+}
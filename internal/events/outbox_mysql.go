@@ -0,0 +1,224 @@
+package events
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// esquemaOutboxMySQL cria as tabelas do outbox. eventos_versoes guarda a última versão de cada
+// agregado: a linha fica travada até o fim da transação que grava um evento do agregado, o que
+// ordena as alterações do mesmo agregado também entre instâncias diferentes da aplicação.
+var esquemaOutboxMySQL = []string{
+	`CREATE TABLE IF NOT EXISTS eventos (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	tipo VARCHAR(64) NOT NULL,
+	agregado VARCHAR(32) NOT NULL,
+	agregado_id INT NOT NULL,
+	versao INT NOT NULL,
+	empresa_id INT NOT NULL,
+	dados MEDIUMBLOB NOT NULL,
+	data DATETIME(6) NOT NULL,
+	UNIQUE KEY uk_eventos_versao (agregado, agregado_id, versao),
+	KEY ix_eventos_data (data)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+	`CREATE TABLE IF NOT EXISTS eventos_versoes (
+	agregado VARCHAR(32) NOT NULL,
+	agregado_id INT NOT NULL,
+	versao INT NOT NULL,
+	PRIMARY KEY (agregado, agregado_id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+	`CREATE TABLE IF NOT EXISTS eventos_entregas (
+	assinante VARCHAR(128) NOT NULL,
+	evento_id BIGINT NOT NULL,
+	PRIMARY KEY (assinante, evento_id),
+	KEY ix_eventos_entregas_evento (evento_id),
+	CONSTRAINT fk_eventos_entregas_evento FOREIGN KEY (evento_id) REFERENCES eventos (id) ON DELETE CASCADE
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`,
+}
+
+// erroReferenciaInexistente é o código do MySQL para chave estrangeira sem registro correspondente
+const erroReferenciaInexistente = 1452
+
+// decodificadores reconstroem os eventos tipados lidos do banco, pelo tipo gravado
+var decodificadores = map[string]func([]byte) (Dominio, error){
+	TipoClienteCriado:      decodificar[ClienteCriado],
+	TipoClienteAlterado:    decodificar[ClienteAlterado],
+	TipoClienteExcluido:    decodificar[ClienteExcluido],
+	TipoClienteAnonimizado: decodificar[ClienteAnonimizado],
+	TipoEmpresaCriada:      decodificar[EmpresaCriada],
+	TipoEmpresaAlterada:    decodificar[EmpresaAlterada],
+	TipoEmpresaExcluida:    decodificar[EmpresaExcluida],
+	TipoUsuarioCriado:      decodificar[UsuarioCriado],
+	TipoUsuarioAlterado:    decodificar[UsuarioAlterado],
+	TipoUsuarioExcluido:    decodificar[UsuarioExcluido],
+	TipoPedidoCriado:       decodificar[PedidoCriado],
+	TipoPedidoAlterado:     decodificar[PedidoAlterado],
+	TipoNegocioCriado:      decodificar[NegocioCriado],
+	TipoNegocioAlterado:    decodificar[NegocioAlterado],
+	TipoNegocioExcluido:    decodificar[NegocioExcluido],
+	TipoNegocioGanho:       decodificar[NegocioGanho],
+	TipoProjetoCriado:      decodificar[ProjetoCriado],
+	TipoProjetoAlterado:    decodificar[ProjetoAlterado],
+	TipoProjetoExcluido:    decodificar[ProjetoExcluido],
+	TipoTarefaCriada:       decodificar[TarefaCriada],
+	TipoTarefaAlterada:     decodificar[TarefaAlterada],
+	TipoTarefaExcluida:     decodificar[TarefaExcluida],
+	TipoTarefaAtribuida:    decodificar[TarefaAtribuida],
+}
+
+func decodificar[T Dominio](dados []byte) (Dominio, error) {
+	var d T
+	if err := json.Unmarshal(dados, &d); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// MySQLOutbox implementa Outbox em tabelas do MySQL, compartilhadas entre as instâncias da aplicação.
+// A transação do banco é aberta antes da alteração e entregue a ela por Tx.SQL: os repositórios
+// no MySQL gravam por essa transação, e a alteração e os eventos são confirmados ou desfeitos juntos.
+type MySQLOutbox struct {
+	db     *sql.DB
+	travas *travas
+}
+
+// NewMySQLOutbox cria o outbox sobre a conexão informada
+func NewMySQLOutbox(db *sql.DB) *MySQLOutbox {
+	return &MySQLOutbox{db: db, travas: novasTravas()}
+}
+
+// Migrar cria as tabelas do outbox, se ainda não existirem
+func (o *MySQLOutbox) Migrar() error {
+	for _, ddl := range esquemaOutboxMySQL {
+		if _, err := o.db.Exec(ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Transacao executa a alteração e grava os eventos emitidos por ela na mesma transação do banco
+func (o *MySQLOutbox) Transacao(alteracao func(tx *Tx) error) error {
+	sqlTx, err := o.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer sqlTx.Rollback()
+
+	tx := &Tx{sql: sqlTx, travas: o.travas}
+	defer tx.liberar()
+
+	if err := alteracao(tx); err != nil {
+		return err
+	}
+
+	agora := time.Now().UTC()
+	for _, d := range tx.eventos {
+		if err := gravarEvento(sqlTx, d, agora); err != nil {
+			return err
+		}
+	}
+	return sqlTx.Commit()
+}
+
+// gravarEvento grava o evento com a próxima versão do agregado
+func gravarEvento(tx *sql.Tx, d Dominio, agora time.Time) error {
+	dados, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("evento %s: %w", d.Tipo(), err)
+	}
+
+	agregado, id := d.Agregado()
+	if _, err := tx.Exec(`INSERT INTO eventos_versoes (agregado, agregado_id, versao) VALUES (?, ?, 1)
+		ON DUPLICATE KEY UPDATE versao = versao + 1`, agregado, id); err != nil {
+		return err
+	}
+	var versao int
+	if err := tx.QueryRow(`SELECT versao FROM eventos_versoes WHERE agregado = ? AND agregado_id = ?`,
+		agregado, id).Scan(&versao); err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`INSERT INTO eventos (tipo, agregado, agregado_id, versao, empresa_id, dados, data)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, d.Tipo(), agregado, id, versao, d.Escopo(), dados, agora)
+	return err
+}
+
+// Pendentes retorna os eventos não confirmados pelo assinante. Os IDs de transações confirmadas
+// em paralelo podem chegar fora de ordem, por isso cada entrega é registrada em vez de um cursor;
+// dentro de um agregado, a trava da versão garante que os IDs seguem a ordem das alterações
+func (o *MySQLOutbox) Pendentes(assinante string, limit int) ([]*Evento, error) {
+	query := `SELECT e.id, e.tipo, e.agregado, e.agregado_id, e.versao, e.empresa_id, e.dados, e.data
+		FROM eventos e
+		WHERE NOT EXISTS (SELECT 1 FROM eventos_entregas c WHERE c.assinante = ? AND c.evento_id = e.id)
+		ORDER BY e.id`
+	args := []interface{}{assinante}
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := o.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*Evento, 0)
+	for rows.Next() {
+		var ev Evento
+		var dados []byte
+		if err := rows.Scan(&ev.ID, &ev.Tipo, &ev.Agregado, &ev.AgregadoID, &ev.Versao, &ev.EmpresaID,
+			&dados, &ev.Data); err != nil {
+			return nil, err
+		}
+
+		decodificador, ok := decodificadores[ev.Tipo]
+		if !ok {
+			return nil, fmt.Errorf("evento %d: tipo desconhecido %q", ev.ID, ev.Tipo)
+		}
+		if ev.Dados, err = decodificador(dados); err != nil {
+			return nil, fmt.Errorf("evento %d: %w", ev.ID, err)
+		}
+		result = append(result, &ev)
+	}
+	return result, rows.Err()
+}
+
+// Confirmar marca o evento como entregue ao assinante
+func (o *MySQLOutbox) Confirmar(assinante string, id int64) error {
+	_, err := o.db.Exec(`INSERT INTO eventos_entregas (assinante, evento_id) VALUES (?, ?)
+		ON DUPLICATE KEY UPDATE evento_id = evento_id`, assinante, id)
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == erroReferenciaInexistente {
+		return errors.New("evento não encontrado")
+	}
+	return err
+}
+
+// Expurgar remove os eventos antigos já entregues a todos os assinantes; as entregas saem junto
+func (o *MySQLOutbox) Expurgar(assinantes []string, antesDe time.Time) (int, error) {
+	query := `DELETE e FROM eventos e WHERE e.data < ?`
+	args := []interface{}{antesDe.UTC()}
+	if len(assinantes) > 0 {
+		query += ` AND (SELECT COUNT(*) FROM eventos_entregas c
+			WHERE c.evento_id = e.id AND c.assinante IN (?` + strings.Repeat(`, ?`, len(assinantes)-1) + `)) = ?`
+		for _, a := range assinantes {
+			args = append(args, a)
+		}
+		args = append(args, len(assinantes))
+	}
+
+	res, err := o.db.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
@@ -0,0 +1,248 @@
+package events
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/kanban"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// alterado cria um evento de alteração do negócio com o título informado
+func alterado(id int, titulo string) Dominio {
+	return NegocioAlterado{Negocio: kanban.Negocio{ID: id, EmpresaID: 1, Titulo: titulo}}
+}
+
+// As alterações concorrentes de um agregado gravam os eventos na ordem em que alteraram o registro
+func TestOrdemPorAgregado(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	const total = 200
+
+	var mu sync.Mutex
+	registro := 0
+	var wg sync.WaitGroup
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			outbox.Transacao(func(tx *Tx) error {
+				tx.Travar(AgregadoNegocio, id%2+1)
+				mu.Lock()
+				registro++
+				valor := registro
+				mu.Unlock()
+				tx.Emitir(alterado(id%2+1, strconv.Itoa(valor)))
+				return nil
+			})
+		}(i)
+	}
+	wg.Wait()
+
+	eventos, _ := outbox.Pendentes("teste", 0)
+	if len(eventos) != total {
+		t.Fatalf("%d eventos gravados, esperado %d", len(eventos), total)
+	}
+	ultimo := map[int]int{}
+	versao := map[int]int{}
+	for _, ev := range eventos {
+		valor, _ := strconv.Atoi(ev.Dados.(NegocioAlterado).Negocio.Titulo)
+		if valor <= ultimo[ev.AgregadoID] {
+			t.Errorf("negócio %d: alteração %d gravada depois da %d", ev.AgregadoID, valor, ultimo[ev.AgregadoID])
+		}
+		if ev.Versao != versao[ev.AgregadoID]+1 {
+			t.Errorf("negócio %d: versão %d depois da %d", ev.AgregadoID, ev.Versao, versao[ev.AgregadoID])
+		}
+		ultimo[ev.AgregadoID], versao[ev.AgregadoID] = valor, ev.Versao
+	}
+}
+
+// Agregados diferentes não esperam um pelo outro, e uma transação aninhada sobre outro agregado
+// não trava a de fora
+func TestTravaPorAgregado(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	feito := make(chan error, 1)
+
+	go func() {
+		feito <- outbox.Transacao(func(tx *Tx) error {
+			tx.Travar(AgregadoNegocio, 1)
+			tx.Emitir(alterado(1, "fora"))
+			return outbox.Transacao(func(tx *Tx) error {
+				tx.Emitir(alterado(2, "dentro"))
+				return nil
+			})
+		})
+	}()
+
+	select {
+	case err := <-feito:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("transação aninhada travou")
+	}
+
+	eventos, _ := outbox.Pendentes("teste", 0)
+	if len(eventos) != 2 {
+		t.Errorf("%d eventos gravados, esperado 2", len(eventos))
+	}
+}
+
+// Uma alteração que falha não grava eventos e solta as travas
+func TestTransacaoComErro(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	falha := errors.New("falha")
+
+	if err := outbox.Transacao(func(tx *Tx) error {
+		tx.Emitir(alterado(1, "perdido"))
+		return falha
+	}); err != falha {
+		t.Fatalf("erro %v, esperado %v", err, falha)
+	}
+	if err := outbox.Transacao(func(tx *Tx) error {
+		tx.Emitir(alterado(1, "gravado"))
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	eventos, _ := outbox.Pendentes("teste", 0)
+	if len(eventos) != 1 || eventos[0].Versao != 1 {
+		t.Errorf("eventos gravados: %+v", eventos)
+	}
+}
+
+// Um evento que falha é entregue de novo, e os seguintes do mesmo agregado esperam por ele;
+// os de outros agregados seguem sendo entregues
+func TestEntregaComFalha(t *testing.T) {
+	barramento := NewBarramento(NewMemoryOutbox())
+
+	falhas := 2
+	var entregues []string
+	barramento.Assinar("teste", func(ev *Evento) error {
+		titulo := ev.Dados.(NegocioAlterado).Negocio.Titulo
+		if titulo == "1a" && falhas > 0 {
+			falhas--
+			return errors.New("indisponível")
+		}
+		entregues = append(entregues, titulo)
+		return nil
+	})
+
+	for _, ev := range []Dominio{alterado(1, "1a"), alterado(2, "2a"), alterado(1, "1b")} {
+		barramento.Transacao(func(tx *Tx) error {
+			tx.Emitir(ev)
+			return nil
+		})
+	}
+
+	tests := []struct {
+		rodada    int
+		entregues []string
+	}{
+		{1, []string{"2a"}},
+		{2, []string{"2a"}},
+		{3, []string{"2a", "1a", "1b"}},
+		{4, []string{"2a", "1a", "1b"}},
+	}
+	for _, tt := range tests {
+		barramento.Processar()
+		if strings.Join(entregues, ",") != strings.Join(tt.entregues, ",") {
+			t.Errorf("rodada %d: entregues %v, esperado %v", tt.rodada, entregues, tt.entregues)
+		}
+	}
+}
+
+// Um assinante que falha, ou entra em pânico, não impede a entrega aos outros, e cada
+// assinante recebe cada evento ao menos uma vez
+func TestEntregaAoMenosUmaVez(t *testing.T) {
+	barramento := NewBarramento(NewMemoryOutbox())
+
+	recebidos := map[string]int{}
+	barramento.Assinar("estavel", func(ev *Evento) error {
+		recebidos["estavel"]++
+		return nil
+	})
+	panicos := 1
+	barramento.Assinar("instavel", func(ev *Evento) error {
+		if panicos > 0 {
+			panicos--
+			panic("assinante com defeito")
+		}
+		recebidos["instavel"]++
+		return nil
+	})
+	barramento.Assinar("outro tipo", func(ev *Evento) error {
+		recebidos["outro tipo"]++
+		return nil
+	}, TipoTarefaCriada)
+
+	for i := 0; i < 3; i++ {
+		barramento.Transacao(func(tx *Tx) error {
+			tx.Emitir(alterado(i+1, "x"))
+			return nil
+		})
+	}
+
+	for barramento.Processar() > 0 {
+	}
+	if recebidos["estavel"] != 3 || recebidos["instavel"] != 3 || recebidos["outro tipo"] != 0 {
+		t.Errorf("entregas: %v", recebidos)
+	}
+}
+
+// Expurgar remove só os eventos antigos confirmados por todos os assinantes
+func TestExpurgar(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	for i := 1; i <= 3; i++ {
+		outbox.Transacao(func(tx *Tx) error {
+			tx.Emitir(alterado(i, "x"))
+			return nil
+		})
+	}
+	outbox.Confirmar("a", 1)
+	outbox.Confirmar("a", 2)
+	outbox.Confirmar("b", 1)
+
+	n, _ := outbox.Expurgar([]string{"a", "b"}, time.Now().Add(time.Minute))
+	if n != 1 {
+		t.Errorf("%d eventos expurgados, esperado 1", n)
+	}
+	if pendentes, _ := outbox.Pendentes("b", 0); len(pendentes) != 2 {
+		t.Errorf("%d eventos pendentes para b, esperado 2", len(pendentes))
+	}
+}
+
+// Os eventos gravados no banco voltam com o tipo de origem
+func TestDecodificadores(t *testing.T) {
+	tipos := []string{TipoClienteCriado, TipoEmpresaCriada, TipoUsuarioExcluido, TipoPedidoAlterado,
+		TipoNegocioGanho, TipoProjetoCriado, TipoTarefaAtribuida}
+	for _, tipo := range tipos {
+		if decodificadores[tipo] == nil {
+			t.Errorf("tipo %s sem decodificador", tipo)
+		}
+	}
+
+	d, err := decodificadores[TipoNegocioAlterado]([]byte(`{"negocio":{"id":7,"empresa_id":3,"titulo":"t"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev, ok := d.(NegocioAlterado)
+	if !ok || ev.Negocio.ID != 7 || ev.Escopo() != 3 {
+		t.Errorf("evento decodificado: %#v", d)
+	}
+}
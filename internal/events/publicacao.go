@@ -0,0 +1,413 @@
+package events
+
+import (
+	"strings"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/kanban"
+	"github.com/Pantaleaogc/gvero/internal/pedido"
+	"github.com/Pantaleaogc/gvero/internal/seguranca"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+)
+
+// clienteRepository decora um cliente.Repository emitindo os eventos de clientes
+type clienteRepository struct {
+	cliente.Repository
+	barramento *Barramento
+}
+
+// PublicarClientes retorna um cliente.Repository que grava no outbox os eventos de cada alteração de cliente
+func PublicarClientes(repo cliente.Repository, barramento *Barramento) cliente.Repository {
+	return &clienteRepository{
+		Repository: repo,
+		barramento: barramento,
+	}
+}
+
+// Create cria o cliente e emite ClienteCriado
+func (r *clienteRepository) Create(c *cliente.Cliente) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		if err := r.Repository.Create(c); err != nil {
+			return err
+		}
+		tx.Emitir(ClienteCriado{Cliente: clienteMascarado(c)})
+		return nil
+	})
+}
+
+// Update atualiza o cliente e emite ClienteAlterado
+func (r *clienteRepository) Update(c *cliente.Cliente) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoCliente, c.ID)
+		if err := r.Repository.Update(c); err != nil {
+			return err
+		}
+		tx.Emitir(ClienteAlterado{Cliente: clienteMascarado(c)})
+		return nil
+	})
+}
+
+// Delete remove o cliente e emite ClienteExcluido
+func (r *clienteRepository) Delete(id int, empresaID int) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoCliente, id)
+		if err := r.Repository.Delete(id, empresaID); err != nil {
+			return err
+		}
+		tx.Emitir(ClienteExcluido{ID: id, EmpresaID: empresaID})
+		return nil
+	})
+}
+
+// Anonimizar elimina os dados pessoais do cliente e emite ClienteAnonimizado
+func (r *clienteRepository) Anonimizar(id, empresaID int) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoCliente, id)
+		if err := r.Repository.Anonimizar(id, empresaID); err != nil {
+			return err
+		}
+		c, err := r.Repository.GetByID(id, empresaID)
+		if err != nil {
+			return err
+		}
+		tx.Emitir(ClienteAnonimizado{Cliente: clienteMascarado(c)})
+		return nil
+	})
+}
+
+// empresaRepository decora um empresa.Repository emitindo os eventos de empresas
+type empresaRepository struct {
+	empresa.Repository
+	barramento *Barramento
+}
+
+// PublicarEmpresas retorna um empresa.Repository que grava no outbox os eventos de cada alteração de empresa
+func PublicarEmpresas(repo empresa.Repository, barramento *Barramento) empresa.Repository {
+	return &empresaRepository{
+		Repository: repo,
+		barramento: barramento,
+	}
+}
+
+// Create cria a empresa e emite EmpresaCriada
+func (r *empresaRepository) Create(e *empresa.Empresa) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		if err := r.Repository.Create(e); err != nil {
+			return err
+		}
+		tx.Emitir(EmpresaCriada{Empresa: copiaEmpresa(e)})
+		return nil
+	})
+}
+
+// Update atualiza a empresa e emite EmpresaAlterada
+func (r *empresaRepository) Update(e *empresa.Empresa) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoEmpresa, e.ID)
+		if err := r.Repository.Update(e); err != nil {
+			return err
+		}
+		tx.Emitir(EmpresaAlterada{Empresa: copiaEmpresa(e)})
+		return nil
+	})
+}
+
+// Delete remove a empresa e emite EmpresaExcluida
+func (r *empresaRepository) Delete(id int) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoEmpresa, id)
+		if err := r.Repository.Delete(id); err != nil {
+			return err
+		}
+		tx.Emitir(EmpresaExcluida{ID: id})
+		return nil
+	})
+}
+
+// usuarioRepository decora um usuario.Repository emitindo os eventos de usuários
+type usuarioRepository struct {
+	usuario.Repository
+	barramento *Barramento
+}
+
+// PublicarUsuarios retorna um usuario.Repository que grava no outbox os eventos de cada alteração de usuário
+func PublicarUsuarios(repo usuario.Repository, barramento *Barramento) usuario.Repository {
+	return &usuarioRepository{
+		Repository: repo,
+		barramento: barramento,
+	}
+}
+
+// Create cria o usuário e emite UsuarioCriado
+func (r *usuarioRepository) Create(u *usuario.Usuario) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		if err := r.Repository.Create(u); err != nil {
+			return err
+		}
+		tx.Emitir(UsuarioCriado{Usuario: semSenha(u)})
+		return nil
+	})
+}
+
+// Update atualiza o usuário e emite UsuarioAlterado
+func (r *usuarioRepository) Update(u *usuario.Usuario) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoUsuario, u.ID)
+		if err := r.Repository.Update(u); err != nil {
+			return err
+		}
+		tx.Emitir(UsuarioAlterado{Usuario: semSenha(u)})
+		return nil
+	})
+}
+
+// Delete remove o usuário e emite UsuarioExcluido
+func (r *usuarioRepository) Delete(id int) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoUsuario, id)
+		u, err := r.Repository.GetByID(id)
+		if err != nil {
+			return err
+		}
+		if err := r.Repository.Delete(id); err != nil {
+			return err
+		}
+		tx.Emitir(UsuarioExcluido{ID: id, EmpresaID: u.EmpresaID})
+		return nil
+	})
+}
+
+// pedidoRepository decora um pedido.Repository emitindo os eventos de pedidos
+type pedidoRepository struct {
+	pedido.Repository
+	barramento *Barramento
+}
+
+// PublicarPedidos retorna um pedido.Repository que grava no outbox os eventos de cada alteração de pedido
+func PublicarPedidos(repo pedido.Repository, barramento *Barramento) pedido.Repository {
+	return &pedidoRepository{
+		Repository: repo,
+		barramento: barramento,
+	}
+}
+
+// Create cria o pedido e emite PedidoCriado
+func (r *pedidoRepository) Create(p *pedido.Pedido) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		if err := r.Repository.Create(p); err != nil {
+			return err
+		}
+		tx.Emitir(PedidoCriado{Pedido: copiaPedido(p)})
+		return nil
+	})
+}
+
+// Update atualiza o pedido e emite PedidoAlterado
+func (r *pedidoRepository) Update(p *pedido.Pedido) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoPedido, p.ID)
+		if err := r.Repository.Update(p); err != nil {
+			return err
+		}
+		tx.Emitir(PedidoAlterado{Pedido: copiaPedido(p)})
+		return nil
+	})
+}
+
+// kanbanRepository decora um kanban.Repository emitindo os eventos de negócios, projetos e tarefas
+type kanbanRepository struct {
+	kanban.Repository
+	barramento *Barramento
+}
+
+// PublicarKanban retorna um kanban.Repository que grava no outbox os eventos de cada alteração
+// de negócio, projeto e tarefa
+func PublicarKanban(repo kanban.Repository, barramento *Barramento) kanban.Repository {
+	return &kanbanRepository{
+		Repository: repo,
+		barramento: barramento,
+	}
+}
+
+// CreateNegocio cria o negócio e emite NegocioCriado
+func (r *kanbanRepository) CreateNegocio(n *kanban.Negocio) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		if err := r.Repository.CreateNegocio(n); err != nil {
+			return err
+		}
+		tx.Emitir(NegocioCriado{Negocio: *n})
+		if n.Status == kanban.NegocioGanho {
+			tx.Emitir(NegocioGanho{Negocio: *n})
+		}
+		return nil
+	})
+}
+
+// UpdateNegocio atualiza o negócio e emite NegocioAlterado, e NegocioGanho quando ele passa a ganho
+func (r *kanbanRepository) UpdateNegocio(n *kanban.Negocio) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoNegocio, n.ID)
+		anterior, err := r.Repository.GetNegocio(n.ID, n.EmpresaID)
+		if err != nil {
+			return err
+		}
+		if err := r.Repository.UpdateNegocio(n); err != nil {
+			return err
+		}
+		tx.Emitir(NegocioAlterado{Negocio: *n})
+		if n.Status == kanban.NegocioGanho && anterior.Status != kanban.NegocioGanho {
+			tx.Emitir(NegocioGanho{Negocio: *n})
+		}
+		return nil
+	})
+}
+
+// DeleteNegocio remove o negócio e emite NegocioExcluido
+func (r *kanbanRepository) DeleteNegocio(id, empresaID int) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoNegocio, id)
+		if err := r.Repository.DeleteNegocio(id, empresaID); err != nil {
+			return err
+		}
+		tx.Emitir(NegocioExcluido{ID: id, EmpresaID: empresaID})
+		return nil
+	})
+}
+
+// CreateProjeto cria o projeto e emite ProjetoCriado
+func (r *kanbanRepository) CreateProjeto(p *kanban.Projeto) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		if err := r.Repository.CreateProjeto(p); err != nil {
+			return err
+		}
+		tx.Emitir(ProjetoCriado{Projeto: *p})
+		return nil
+	})
+}
+
+// UpdateProjeto atualiza o projeto e emite ProjetoAlterado
+func (r *kanbanRepository) UpdateProjeto(p *kanban.Projeto) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoProjeto, p.ID)
+		if err := r.Repository.UpdateProjeto(p); err != nil {
+			return err
+		}
+		tx.Emitir(ProjetoAlterado{Projeto: *p})
+		return nil
+	})
+}
+
+// DeleteProjeto remove o projeto e emite ProjetoExcluido
+func (r *kanbanRepository) DeleteProjeto(id, empresaID int) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoProjeto, id)
+		if err := r.Repository.DeleteProjeto(id, empresaID); err != nil {
+			return err
+		}
+		tx.Emitir(ProjetoExcluido{ID: id, EmpresaID: empresaID})
+		return nil
+	})
+}
+
+// CreateTarefa cria a tarefa e emite TarefaCriada
+func (r *kanbanRepository) CreateTarefa(t *kanban.Tarefa) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		if err := r.Repository.CreateTarefa(t); err != nil {
+			return err
+		}
+		tx.Emitir(TarefaCriada{Tarefa: *t})
+		if t.ResponsavelID > 0 {
+			tx.Emitir(TarefaAtribuida{Tarefa: *t})
+		}
+		return nil
+	})
+}
+
+// UpdateTarefa atualiza a tarefa e emite TarefaAlterada, e TarefaAtribuida quando o responsável muda
+func (r *kanbanRepository) UpdateTarefa(t *kanban.Tarefa) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoTarefa, t.ID)
+		anterior, err := r.Repository.GetTarefa(t.ID, t.EmpresaID)
+		if err != nil {
+			return err
+		}
+		if err := r.Repository.UpdateTarefa(t); err != nil {
+			return err
+		}
+		tx.Emitir(TarefaAlterada{Tarefa: *t})
+		if t.ResponsavelID > 0 && t.ResponsavelID != anterior.ResponsavelID {
+			tx.Emitir(TarefaAtribuida{Tarefa: *t})
+		}
+		return nil
+	})
+}
+
+// DeleteTarefa remove a tarefa e emite TarefaExcluida
+func (r *kanbanRepository) DeleteTarefa(id, empresaID int) error {
+	return r.barramento.Transacao(func(tx *Tx) error {
+		tx.Travar(AgregadoTarefa, id)
+		if err := r.Repository.DeleteTarefa(id, empresaID); err != nil {
+			return err
+		}
+		tx.Emitir(TarefaExcluida{ID: id, EmpresaID: empresaID})
+		return nil
+	})
+}
+
+// clienteMascarado copia o cliente sem compartilhar tags e campos com o registro gravado. Os dados
+// pessoais cifrados em repouso não circulam abertos nos eventos: CPF, CNPJ, e-mail e telefone vão
+// mascarados e o endereço fica de fora; quem precisa deles busca o cliente pelo ID
+func clienteMascarado(c *cliente.Cliente) cliente.Cliente {
+	copia := *c
+	copia.Tags = append([]string(nil), c.Tags...)
+	if c.Campos != nil {
+		copia.Campos = make(campo.Valores, len(c.Campos))
+		for k, v := range c.Campos {
+			copia.Campos[k] = v
+		}
+	}
+
+	copia.CPF = mascararFinal(c.CPF, 2)
+	copia.CNPJ = mascararFinal(c.CNPJ, 2)
+	copia.Email = seguranca.MascararEmail(c.Email)
+	copia.Telefone = mascararFinal(c.Telefone, 4)
+	copia.Endereco = ""
+	copia.IndiceDocumento, copia.IndiceEmail = "", ""
+	return copia
+}
+
+// mascararFinal mantém apenas os últimos caracteres do valor: "123.456.789-09" vira "***09"
+func mascararFinal(valor string, n int) string {
+	r := []rune(strings.TrimSpace(valor))
+	if len(r) == 0 {
+		return ""
+	}
+	if len(r) <= n {
+		return "***"
+	}
+	return "***" + string(r[len(r)-n:])
+}
+
+// copiaEmpresa copia a empresa sem compartilhar os módulos com o registro gravado
+func copiaEmpresa(e *empresa.Empresa) empresa.Empresa {
+	copia := *e
+	copia.Modulos = append([]string(nil), e.Modulos...)
+	return copia
+}
+
+// copiaPedido copia o pedido sem compartilhar itens e histórico com o registro gravado
+func copiaPedido(p *pedido.Pedido) pedido.Pedido {
+	copia := *p
+	copia.Itens = append([]pedido.Item(nil), p.Itens...)
+	copia.Historico = append([]pedido.MudancaStatus(nil), p.Historico...)
+	return copia
+}
+
+// semSenha copia o usuário sem o hash da senha, que não deve circular nos eventos
+func semSenha(u *usuario.Usuario) usuario.Usuario {
+	c := *u
+	c.Senha = ""
+	return c
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// Os eventos de clientes não levam abertos os dados pessoais cifrados em repouso
+func TestClienteMascaradoNosEventos(t *testing.T) {
+	outbox := NewMemoryOutbox()
+	repo := PublicarClientes(cliente.NewMemoryRepository(), NewBarramento(outbox))
+
+	c := &cliente.Cliente{
+		Nome:       "Ana Souza",
+		CPF:        "123.456.789-09",
+		Email:      "ana.souza@acme.com",
+		Telefone:   "(11) 98765-4321",
+		Endereco:   "Rua A, 10",
+		EmpresaID:  1,
+		TipoPessoa: "fisica",
+	}
+	if err := repo.Create(c); err != nil {
+		t.Fatal(err)
+	}
+
+	eventos, _ := outbox.Pendentes("teste", 0)
+	if len(eventos) != 1 {
+		t.Fatalf("%d eventos gravados, esperado 1", len(eventos))
+	}
+	publicado := eventos[0].Dados.(ClienteCriado).Cliente
+
+	tests := []struct {
+		campo, valor, esperado string
+	}{
+		{"cpf", publicado.CPF, "***09"},
+		{"email", publicado.Email, "a***@acme.com"},
+		{"telefone", publicado.Telefone, "***4321"},
+		{"endereco", publicado.Endereco, ""},
+		{"nome", publicado.Nome, "Ana Souza"},
+	}
+	for _, tt := range tests {
+		if tt.valor != tt.esperado {
+			t.Errorf("%s publicado %q, esperado %q", tt.campo, tt.valor, tt.esperado)
+		}
+	}
+	if publicado.ID != c.ID || c.CPF != "123.456.789-09" {
+		t.Errorf("o evento deve identificar o cliente sem alterar o registro: %+v", c)
+	}
+}
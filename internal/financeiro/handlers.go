@@ -0,0 +1,628 @@
+package financeiro
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+const formatoData = "2006-01-02"
+
+// Handlers contém os manipuladores HTTP do módulo financeiro
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas do módulo financeiro
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Route("/categorias", func(r chi.Router) {
+		r.Get("/", h.ListCategorias)
+		r.Post("/", h.CreateCategoria)
+		r.Put("/{id}", h.UpdateCategoria)
+		r.Delete("/{id}", h.DeleteCategoria)
+	})
+
+	r.Route("/contas-bancarias", func(r chi.Router) {
+		r.Get("/", h.ListContasBancarias)
+		r.Post("/", h.CreateContaBancaria)
+		r.Get("/{id}", h.GetContaBancaria)
+		r.Put("/{id}", h.UpdateContaBancaria)
+		r.Delete("/{id}", h.DeleteContaBancaria)
+	})
+
+	r.Route("/fornecedores", func(r chi.Router) {
+		r.Get("/", h.ListFornecedores)
+		r.Post("/", h.CreateFornecedor)
+		r.Get("/{id}", h.GetFornecedor)
+		r.Put("/{id}", h.UpdateFornecedor)
+		r.Delete("/{id}", h.DeleteFornecedor)
+	})
+
+	r.Route("/receber", func(r chi.Router) {
+		r.Get("/", h.listTitulos(TipoReceber))
+		r.Post("/", h.createTitulo(TipoReceber))
+		r.Get("/{id}", h.getTitulo(TipoReceber))
+		r.Post("/{id}/cancelar", h.cancelarTitulo(TipoReceber))
+	})
+
+	r.Route("/pagar", func(r chi.Router) {
+		r.Get("/", h.listTitulos(TipoPagar))
+		r.Post("/", h.createTitulo(TipoPagar))
+		r.Get("/{id}", h.getTitulo(TipoPagar))
+		r.Post("/{id}/cancelar", h.cancelarTitulo(TipoPagar))
+	})
+
+	r.Post("/parcelas/{id}/pagamentos", h.RegistrarPagamento)
+	r.Get("/fluxo-caixa", h.FluxoCaixa)
+
+	return r
+}
+
+// ListCategorias lista o plano de contas da empresa
+func (h *Handlers) ListCategorias(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	categorias, err := h.repo.ListCategorias(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(categorias)
+}
+
+// CreateCategoria cria uma nova categoria
+func (h *Handlers) CreateCategoria(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var c Categoria
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.EmpresaID = user.Empresa
+	if err := h.repo.CreateCategoria(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// UpdateCategoria atualiza uma categoria existente
+func (h *Handlers) UpdateCategoria(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var c Categoria
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.ID = id
+	c.EmpresaID = user.Empresa
+	if err := h.repo.UpdateCategoria(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// DeleteCategoria remove uma categoria
+func (h *Handlers) DeleteCategoria(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteCategoria(id, user.Empresa); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListContasBancarias lista as contas bancárias da empresa
+func (h *Handlers) ListContasBancarias(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	contas, err := h.repo.ListContasBancarias(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contas)
+}
+
+// GetContaBancaria retorna uma conta bancária por ID
+func (h *Handlers) GetContaBancaria(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.repo.GetContaBancaria(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// CreateContaBancaria cria uma nova conta bancária
+func (h *Handlers) CreateContaBancaria(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var c ContaBancaria
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.EmpresaID = user.Empresa
+	if err := h.repo.CreateContaBancaria(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// UpdateContaBancaria atualiza uma conta bancária existente
+func (h *Handlers) UpdateContaBancaria(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var c ContaBancaria
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.ID = id
+	c.EmpresaID = user.Empresa
+	if err := h.repo.UpdateContaBancaria(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// DeleteContaBancaria remove uma conta bancária
+func (h *Handlers) DeleteContaBancaria(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteContaBancaria(id, user.Empresa); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListFornecedores lista os fornecedores da empresa
+func (h *Handlers) ListFornecedores(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+
+	fornecedores, err := h.repo.ListFornecedores(user.Empresa, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fornecedores)
+}
+
+// GetFornecedor retorna um fornecedor por ID
+func (h *Handlers) GetFornecedor(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	f, err := h.repo.GetFornecedor(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f)
+}
+
+// CreateFornecedor cria um novo fornecedor
+func (h *Handlers) CreateFornecedor(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var f Fornecedor
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.EmpresaID = user.Empresa
+	if err := h.repo.CreateFornecedor(&f); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(f)
+}
+
+// UpdateFornecedor atualiza um fornecedor existente
+func (h *Handlers) UpdateFornecedor(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var f Fornecedor
+	if err := json.NewDecoder(r.Body).Decode(&f); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	f.ID = id
+	f.EmpresaID = user.Empresa
+	if err := h.repo.UpdateFornecedor(&f); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(f)
+}
+
+// DeleteFornecedor remove um fornecedor
+func (h *Handlers) DeleteFornecedor(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.DeleteFornecedor(id, user.Empresa); err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listTitulos lista contas a receber ou a pagar.
+// Filtros: status, cliente_id, fornecedor_id, categoria_id, vencimento_inicio e vencimento_fim (AAAA-MM-DD).
+func (h *Handlers) listTitulos(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+
+		if limit <= 0 {
+			limit = 100 // valor padrão
+		}
+
+		f := FiltroTitulos{
+			EmpresaID: user.Empresa,
+			Tipo:      tipo,
+			Status:    q.Get("status"),
+		}
+		f.ClienteID, _ = strconv.Atoi(q.Get("cliente_id"))
+		f.FornecedorID, _ = strconv.Atoi(q.Get("fornecedor_id"))
+		f.CategoriaID, _ = strconv.Atoi(q.Get("categoria_id"))
+
+		var err error
+		if v := q.Get("vencimento_inicio"); v != "" {
+			if f.VencimentoInicio, err = time.Parse(formatoData, v); err != nil {
+				http.Error(w, "vencimento_inicio inválido", http.StatusBadRequest)
+				return
+			}
+		}
+		if v := q.Get("vencimento_fim"); v != "" {
+			if f.VencimentoFim, err = time.Parse(formatoData, v); err != nil {
+				http.Error(w, "vencimento_fim inválido", http.StatusBadRequest)
+				return
+			}
+			// O fim é inclusivo na query
+			f.VencimentoFim = f.VencimentoFim.AddDate(0, 0, 1)
+		}
+
+		titulos, err := h.service.ListTitulos(f, limit, offset)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(titulos)
+	}
+}
+
+// getTitulo retorna uma conta a receber ou a pagar por ID
+func (h *Handlers) getTitulo(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.service.Titulo(id, user.Empresa)
+		if err != nil || t.Tipo != tipo {
+			http.Error(w, "título não encontrado", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t)
+	}
+}
+
+// createTitulo lança uma conta a receber ou a pagar
+func (h *Handlers) createTitulo(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		var in NovoTitulo
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		t, err := h.service.CriarTitulo(user.Empresa, tipo, in)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(t)
+	}
+}
+
+// cancelarTitulo cancela as parcelas em aberto de um título
+func (h *Handlers) cancelarTitulo(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return
+		}
+
+		if t, err := h.repo.GetTitulo(id, user.Empresa); err != nil || t.Tipo != tipo {
+			http.Error(w, "título não encontrado", http.StatusNotFound)
+			return
+		}
+
+		t, err := h.service.Cancelar(id, user.Empresa)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(t)
+	}
+}
+
+// RegistrarPagamento registra a baixa total ou parcial de uma parcela
+func (h *Handlers) RegistrarPagamento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var in NovoPagamento
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	t, err := h.service.RegistrarPagamento(user.Empresa, id, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(t)
+}
+
+// FluxoCaixa retorna a projeção de fluxo de caixa.
+// Parâmetros: inicio e fim (AAAA-MM-DD, padrão próximos 30 dias) e agrupamento (dia, semana ou mes).
+func (h *Handlers) FluxoCaixa(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	inicio := dataSemHora(time.Now())
+	fim := inicio.AddDate(0, 0, 30)
+
+	var err error
+	if v := q.Get("inicio"); v != "" {
+		if inicio, err = time.Parse(formatoData, v); err != nil {
+			http.Error(w, "inicio inválido", http.StatusBadRequest)
+			return
+		}
+	}
+	if v := q.Get("fim"); v != "" {
+		if fim, err = time.Parse(formatoData, v); err != nil {
+			http.Error(w, "fim inválido", http.StatusBadRequest)
+			return
+		}
+		// O fim é inclusivo na query
+		fim = fim.AddDate(0, 0, 1)
+	}
+
+	fluxo, err := h.service.FluxoCaixa(user.Empresa, inicio, fim, q.Get("agrupamento"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(fluxo)
+}
@@ -0,0 +1,184 @@
+package financeiro
+
+import (
+	"time"
+)
+
+// Tipos de título
+const (
+	TipoReceber = "receber"
+	TipoPagar   = "pagar"
+)
+
+// Tipos de categoria do plano de contas
+const (
+	CategoriaReceita = "receita"
+	CategoriaDespesa = "despesa"
+)
+
+// Status de títulos e parcelas
+const (
+	StatusAberto    = "aberto"
+	StatusParcial   = "parcial"
+	StatusPago      = "pago"
+	StatusVencido   = "vencido"
+	StatusCancelado = "cancelado"
+)
+
+// Categoria representa uma conta do plano de contas da empresa
+type Categoria struct {
+	ID        int    `json:"id"`
+	EmpresaID int    `json:"empresa_id"`
+	Codigo    string `json:"codigo,omitempty"` // ex.: "1.01.002"
+	Nome      string `json:"nome"`
+	Tipo      string `json:"tipo"` // "receita" ou "despesa"
+	PaiID     int    `json:"pai_id,omitempty"`
+	Ativa     bool   `json:"ativa"`
+}
+
+// ContaBancaria representa uma conta bancária ou caixa da empresa
+type ContaBancaria struct {
+	ID           int       `json:"id"`
+	EmpresaID    int       `json:"empresa_id"`
+	Nome         string    `json:"nome"`
+	Banco        string    `json:"banco,omitempty"` // código COMPE, ex.: "001"
+	Agencia      string    `json:"agencia,omitempty"`
+	Conta        string    `json:"conta,omitempty"`
+	SaldoInicial float64   `json:"saldo_inicial"`
+	DataSaldo    time.Time `json:"data_saldo"`
+	Ativa        bool      `json:"ativa"`
+}
+
+// Fornecedor representa um fornecedor da empresa
+type Fornecedor struct {
+	ID          int       `json:"id"`
+	EmpresaID   int       `json:"empresa_id"`
+	Nome        string    `json:"nome"`
+	CNPJ        string    `json:"cnpj,omitempty"`
+	CPF         string    `json:"cpf,omitempty"`
+	Email       string    `json:"email,omitempty"`
+	Telefone    string    `json:"telefone,omitempty"`
+	Status      bool      `json:"status"`
+	DataCriacao time.Time `json:"data_criacao"`
+}
+
+// Titulo representa uma conta a receber ou a pagar, dividida em parcelas
+type Titulo struct {
+	ID              int        `json:"id"`
+	EmpresaID       int        `json:"empresa_id"`
+	Tipo            string     `json:"tipo"` // "receber" ou "pagar"
+	Descricao       string     `json:"descricao"`
+	Documento       string     `json:"documento,omitempty"` // número da nota, pedido, contrato
+	ClienteID       int        `json:"cliente_id,omitempty"`
+	FornecedorID    int        `json:"fornecedor_id,omitempty"`
+	CategoriaID     int        `json:"categoria_id,omitempty"`
+	ContaBancariaID int        `json:"conta_bancaria_id,omitempty"`
+	ValorTotal      float64    `json:"valor_total"`
+	JurosMensal     float64    `json:"juros_mensal"` // % ao mês, pro rata die
+	Multa           float64    `json:"multa"`        // % aplicado uma vez após o vencimento
+	DataEmissao     time.Time  `json:"data_emissao"`
+	Status          string     `json:"status"`
+	Parcelas        []*Parcela `json:"parcelas"`
+	DataCriacao     time.Time  `json:"data_criacao"`
+}
+
+// Parcela representa um vencimento de um título
+type Parcela struct {
+	ID             int          `json:"id"`
+	TituloID       int          `json:"titulo_id"`
+	Numero         int          `json:"numero"`
+	Vencimento     time.Time    `json:"vencimento"`
+	Valor          float64      `json:"valor"`
+	ValorPago      float64      `json:"valor_pago"` // principal já quitado
+	MultaPaga      float64      `json:"multa_paga"`
+	JurosPagos     float64      `json:"juros_pagos"`
+	JurosPendentes float64      `json:"juros_pendentes"` // juros apurados em baixas parciais e ainda não pagos
+	JurosAte       time.Time    `json:"-"`               // data até a qual os juros já foram apurados
+	Status         string       `json:"status"`
+	Pagamentos     []*Pagamento `json:"pagamentos,omitempty"`
+}
+
+// Saldo retorna o principal ainda em aberto da parcela
+func (p *Parcela) Saldo() float64 {
+	return arredondar(p.Valor - p.ValorPago)
+}
+
+// Pagamento representa uma baixa (total ou parcial) de uma parcela
+type Pagamento struct {
+	ID              int       `json:"id"`
+	Data            time.Time `json:"data"`
+	Valor           float64   `json:"valor"` // valor efetivamente recebido ou pago
+	Principal       float64   `json:"principal"`
+	Juros           float64   `json:"juros"`
+	Multa           float64   `json:"multa"`
+	Desconto        float64   `json:"desconto"`
+	ContaBancariaID int       `json:"conta_bancaria_id,omitempty"`
+	Observacao      string    `json:"observacao,omitempty"`
+}
+
+// PeriodoFluxo representa um intervalo da projeção de fluxo de caixa
+type PeriodoFluxo struct {
+	Inicio             time.Time `json:"inicio"`
+	Fim                time.Time `json:"fim"`
+	EntradasPrevistas  float64   `json:"entradas_previstas"`
+	SaidasPrevistas    float64   `json:"saidas_previstas"`
+	EntradasRealizadas float64   `json:"entradas_realizadas"`
+	SaidasRealizadas   float64   `json:"saidas_realizadas"`
+	Saldo              float64   `json:"saldo"`
+	SaldoAcumulado     float64   `json:"saldo_acumulado"`
+}
+
+// FluxoCaixa representa a projeção de fluxo de caixa da empresa.
+// Parcelas vencidas e não pagas são informadas à parte e não entram na projeção.
+type FluxoCaixa struct {
+	Inicio          time.Time      `json:"inicio"`
+	Fim             time.Time      `json:"fim"`
+	Agrupamento     string         `json:"agrupamento"`
+	SaldoInicial    float64        `json:"saldo_inicial"`
+	SaldoFinal      float64        `json:"saldo_final"`
+	VencidosReceber float64        `json:"vencidos_receber"`
+	VencidosPagar   float64        `json:"vencidos_pagar"`
+	Periodos        []PeriodoFluxo `json:"periodos"`
+}
+
+// FiltroTitulos restringe a listagem de títulos; campos zerados não filtram
+type FiltroTitulos struct {
+	EmpresaID        int
+	Tipo             string
+	Status           string
+	ClienteID        int
+	FornecedorID     int
+	CategoriaID      int
+	VencimentoInicio time.Time
+	VencimentoFim    time.Time
+}
+
+// Repository define a interface para acesso aos dados financeiros
+type Repository interface {
+	CreateCategoria(c *Categoria) error
+	GetCategoria(id int, empresaID int) (*Categoria, error)
+	UpdateCategoria(c *Categoria) error
+	DeleteCategoria(id int, empresaID int) error
+	ListCategorias(empresaID int) ([]*Categoria, error)
+
+	CreateContaBancaria(c *ContaBancaria) error
+	GetContaBancaria(id int, empresaID int) (*ContaBancaria, error)
+	UpdateContaBancaria(c *ContaBancaria) error
+	DeleteContaBancaria(id int, empresaID int) error
+	ListContasBancarias(empresaID int) ([]*ContaBancaria, error)
+
+	CreateFornecedor(f *Fornecedor) error
+	GetFornecedor(id int, empresaID int) (*Fornecedor, error)
+	UpdateFornecedor(f *Fornecedor) error
+	DeleteFornecedor(id int, empresaID int) error
+	ListFornecedores(empresaID int, limit, offset int) ([]*Fornecedor, error)
+
+	CreateTitulo(t *Titulo) error
+	GetTitulo(id int, empresaID int) (*Titulo, error)
+	GetTituloPorParcela(parcelaID int, empresaID int) (*Titulo, error)
+	UpdateTitulo(t *Titulo) error
+	ListTitulos(f FiltroTitulos, limit, offset int) ([]*Titulo, error)
+
+	// TransferirCliente reaponta para paraID os registros de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
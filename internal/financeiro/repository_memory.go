@@ -0,0 +1,574 @@
+package financeiro
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu              sync.RWMutex
+	categorias      map[int]*Categoria
+	contas          map[int]*ContaBancaria
+	fornecedores    map[int]*Fornecedor
+	titulos         map[int]*Titulo
+	parcelaTitulo   map[int]int // parcela -> título
+	nextID          int
+	nextParcelaID   int
+	nextPagamentoID int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		categorias:      make(map[int]*Categoria),
+		contas:          make(map[int]*ContaBancaria),
+		fornecedores:    make(map[int]*Fornecedor),
+		titulos:         make(map[int]*Titulo),
+		parcelaTitulo:   make(map[int]int),
+		nextID:          1,
+		nextParcelaID:   1,
+		nextPagamentoID: 1,
+	}
+}
+
+// CreateCategoria adiciona uma nova categoria ao plano de contas
+func (r *MemoryRepository) CreateCategoria(c *Categoria) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Validação básica
+	if c.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+
+	if c.Tipo != CategoriaReceita && c.Tipo != CategoriaDespesa {
+		return errors.New("tipo de categoria inválido")
+	}
+
+	if c.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if err := r.validarCategoriaPai(c); err != nil {
+		return err
+	}
+
+	// Configurar campos
+	c.ID = r.nextID
+	r.nextID++
+	c.Ativa = true
+
+	r.categorias[c.ID] = c
+	return nil
+}
+
+// GetCategoria busca uma categoria por ID e empresa
+func (r *MemoryRepository) GetCategoria(id int, empresaID int) (*Categoria, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.categorias[id]
+	if !exists || c.EmpresaID != empresaID {
+		return nil, errors.New("categoria não encontrada")
+	}
+	return c, nil
+}
+
+// UpdateCategoria atualiza uma categoria existente
+func (r *MemoryRepository) UpdateCategoria(c *Categoria) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.categorias[c.ID]
+	if !exists || existing.EmpresaID != c.EmpresaID {
+		return errors.New("categoria não encontrada")
+	}
+
+	if c.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+
+	if c.Tipo != CategoriaReceita && c.Tipo != CategoriaDespesa {
+		return errors.New("tipo de categoria inválido")
+	}
+
+	if c.PaiID == c.ID {
+		return errors.New("categoria não pode ser pai de si mesma")
+	}
+
+	if err := r.validarCategoriaPai(c); err != nil {
+		return err
+	}
+
+	r.categorias[c.ID] = c
+	return nil
+}
+
+// DeleteCategoria remove uma categoria sem títulos ou subcategorias vinculados
+func (r *MemoryRepository) DeleteCategoria(id int, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.categorias[id]
+	if !exists || c.EmpresaID != empresaID {
+		return errors.New("categoria não encontrada")
+	}
+
+	for _, outra := range r.categorias {
+		if outra.PaiID == id {
+			return errors.New("categoria possui subcategorias")
+		}
+	}
+
+	for _, t := range r.titulos {
+		if t.CategoriaID == id {
+			return errors.New("categoria possui títulos vinculados")
+		}
+	}
+
+	delete(r.categorias, id)
+	return nil
+}
+
+// ListCategorias retorna o plano de contas da empresa ordenado por código
+func (r *MemoryRepository) ListCategorias(empresaID int) ([]*Categoria, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Categoria, 0)
+	for _, c := range r.categorias {
+		if c.EmpresaID == empresaID {
+			result = append(result, c)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Codigo == result[j].Codigo {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].Codigo < result[j].Codigo
+	})
+
+	return result, nil
+}
+
+// validarCategoriaPai garante que a categoria pai existe na mesma empresa e tem o mesmo tipo
+func (r *MemoryRepository) validarCategoriaPai(c *Categoria) error {
+	if c.PaiID == 0 {
+		return nil
+	}
+
+	pai, exists := r.categorias[c.PaiID]
+	if !exists || pai.EmpresaID != c.EmpresaID {
+		return errors.New("categoria pai não encontrada")
+	}
+
+	if pai.Tipo != c.Tipo {
+		return errors.New("categoria pai deve ser do mesmo tipo")
+	}
+
+	return nil
+}
+
+// CreateContaBancaria adiciona uma nova conta bancária
+func (r *MemoryRepository) CreateContaBancaria(c *ContaBancaria) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+
+	if c.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	c.ID = r.nextID
+	r.nextID++
+	c.Ativa = true
+	if c.DataSaldo.IsZero() {
+		c.DataSaldo = time.Now()
+	}
+
+	r.contas[c.ID] = c
+	return nil
+}
+
+// GetContaBancaria busca uma conta bancária por ID e empresa
+func (r *MemoryRepository) GetContaBancaria(id int, empresaID int) (*ContaBancaria, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.contas[id]
+	if !exists || c.EmpresaID != empresaID {
+		return nil, errors.New("conta bancária não encontrada")
+	}
+	return c, nil
+}
+
+// UpdateContaBancaria atualiza uma conta bancária existente
+func (r *MemoryRepository) UpdateContaBancaria(c *ContaBancaria) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.contas[c.ID]
+	if !exists || existing.EmpresaID != c.EmpresaID {
+		return errors.New("conta bancária não encontrada")
+	}
+
+	if c.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+
+	if c.DataSaldo.IsZero() {
+		c.DataSaldo = existing.DataSaldo
+	}
+
+	r.contas[c.ID] = c
+	return nil
+}
+
+// DeleteContaBancaria remove uma conta bancária sem títulos vinculados
+func (r *MemoryRepository) DeleteContaBancaria(id int, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, exists := r.contas[id]
+	if !exists || c.EmpresaID != empresaID {
+		return errors.New("conta bancária não encontrada")
+	}
+
+	for _, t := range r.titulos {
+		if t.ContaBancariaID == id {
+			return errors.New("conta bancária possui títulos vinculados")
+		}
+	}
+
+	delete(r.contas, id)
+	return nil
+}
+
+// ListContasBancarias retorna as contas bancárias da empresa
+func (r *MemoryRepository) ListContasBancarias(empresaID int) ([]*ContaBancaria, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*ContaBancaria, 0)
+	for _, c := range r.contas {
+		if c.EmpresaID == empresaID {
+			result = append(result, c)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// CreateFornecedor adiciona um novo fornecedor
+func (r *MemoryRepository) CreateFornecedor(f *Fornecedor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+
+	if f.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	// Verificar se CNPJ já existe na empresa
+	if f.CNPJ != "" {
+		for _, existing := range r.fornecedores {
+			if existing.EmpresaID == f.EmpresaID && existing.CNPJ == f.CNPJ {
+				return errors.New("CNPJ já cadastrado")
+			}
+		}
+	}
+
+	f.ID = r.nextID
+	r.nextID++
+	f.DataCriacao = time.Now()
+	if f.Status == false {
+		f.Status = true // padrão ativo
+	}
+
+	r.fornecedores[f.ID] = f
+	return nil
+}
+
+// GetFornecedor busca um fornecedor por ID e empresa
+func (r *MemoryRepository) GetFornecedor(id int, empresaID int) (*Fornecedor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	f, exists := r.fornecedores[id]
+	if !exists || f.EmpresaID != empresaID {
+		return nil, errors.New("fornecedor não encontrado")
+	}
+	return f, nil
+}
+
+// UpdateFornecedor atualiza um fornecedor existente
+func (r *MemoryRepository) UpdateFornecedor(f *Fornecedor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.fornecedores[f.ID]
+	if !exists || existing.EmpresaID != f.EmpresaID {
+		return errors.New("fornecedor não encontrado")
+	}
+
+	if f.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+
+	if f.CNPJ != "" {
+		for id, outro := range r.fornecedores {
+			if outro.EmpresaID == f.EmpresaID && outro.CNPJ == f.CNPJ && id != f.ID {
+				return errors.New("CNPJ já cadastrado em outro fornecedor")
+			}
+		}
+	}
+
+	// Preservar campos que não devem ser alterados
+	f.DataCriacao = existing.DataCriacao
+
+	r.fornecedores[f.ID] = f
+	return nil
+}
+
+// DeleteFornecedor remove um fornecedor sem títulos vinculados
+func (r *MemoryRepository) DeleteFornecedor(id int, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, exists := r.fornecedores[id]
+	if !exists || f.EmpresaID != empresaID {
+		return errors.New("fornecedor não encontrado")
+	}
+
+	for _, t := range r.titulos {
+		if t.FornecedorID == id {
+			return errors.New("fornecedor possui títulos vinculados")
+		}
+	}
+
+	delete(r.fornecedores, id)
+	return nil
+}
+
+// ListFornecedores retorna os fornecedores da empresa
+func (r *MemoryRepository) ListFornecedores(empresaID int, limit, offset int) ([]*Fornecedor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Fornecedor, 0)
+	for _, f := range r.fornecedores {
+		if f.EmpresaID == empresaID {
+			result = append(result, f)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return paginar(result, limit, offset), nil
+}
+
+// CreateTitulo adiciona um novo título com suas parcelas
+func (r *MemoryRepository) CreateTitulo(t *Titulo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if len(t.Parcelas) == 0 {
+		return errors.New("título deve ter ao menos uma parcela")
+	}
+
+	t.ID = r.nextID
+	r.nextID++
+	t.DataCriacao = time.Now()
+
+	for _, p := range t.Parcelas {
+		p.ID = r.nextParcelaID
+		r.nextParcelaID++
+		p.TituloID = t.ID
+		r.parcelaTitulo[p.ID] = t.ID
+		r.numerarPagamentos(p)
+	}
+
+	r.titulos[t.ID] = t
+	return nil
+}
+
+// GetTitulo busca um título por ID e empresa
+func (r *MemoryRepository) GetTitulo(id int, empresaID int) (*Titulo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, exists := r.titulos[id]
+	if !exists || t.EmpresaID != empresaID {
+		return nil, errors.New("título não encontrado")
+	}
+	return t, nil
+}
+
+// GetTituloPorParcela busca o título ao qual a parcela pertence
+func (r *MemoryRepository) GetTituloPorParcela(parcelaID int, empresaID int) (*Titulo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	tituloID, exists := r.parcelaTitulo[parcelaID]
+	if !exists {
+		return nil, errors.New("parcela não encontrada")
+	}
+
+	t, exists := r.titulos[tituloID]
+	if !exists || t.EmpresaID != empresaID {
+		return nil, errors.New("parcela não encontrada")
+	}
+	return t, nil
+}
+
+// UpdateTitulo atualiza um título e suas parcelas
+func (r *MemoryRepository) UpdateTitulo(t *Titulo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.titulos[t.ID]
+	if !exists || existing.EmpresaID != t.EmpresaID {
+		return errors.New("título não encontrado")
+	}
+
+	// Preservar campos que não devem ser alterados
+	t.DataCriacao = existing.DataCriacao
+	t.Tipo = existing.Tipo
+
+	for _, p := range t.Parcelas {
+		if p.ID == 0 {
+			p.ID = r.nextParcelaID
+			r.nextParcelaID++
+		}
+		p.TituloID = t.ID
+		r.parcelaTitulo[p.ID] = t.ID
+		r.numerarPagamentos(p)
+	}
+
+	r.titulos[t.ID] = t
+	return nil
+}
+
+// ListTitulos retorna os títulos do filtro ordenados pelo primeiro vencimento
+func (r *MemoryRepository) ListTitulos(f FiltroTitulos, limit, offset int) ([]*Titulo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Titulo, 0)
+	for _, t := range r.titulos {
+		if t.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.Tipo != "" && t.Tipo != f.Tipo {
+			continue
+		}
+		if f.Status != "" && t.Status != f.Status {
+			continue
+		}
+		if f.ClienteID > 0 && t.ClienteID != f.ClienteID {
+			continue
+		}
+		if f.FornecedorID > 0 && t.FornecedorID != f.FornecedorID {
+			continue
+		}
+		if f.CategoriaID > 0 && t.CategoriaID != f.CategoriaID {
+			continue
+		}
+		if !f.VencimentoInicio.IsZero() || !f.VencimentoFim.IsZero() {
+			if !possuiVencimentoEntre(t, f.VencimentoInicio, f.VencimentoFim) {
+				continue
+			}
+		}
+		result = append(result, t)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		vi, vj := primeiroVencimento(result[i]), primeiroVencimento(result[j])
+		if vi.Equal(vj) {
+			return result[i].ID < result[j].ID
+		}
+		return vi.Before(vj)
+	})
+
+	return paginar(result, limit, offset), nil
+}
+
+// numerarPagamentos atribui IDs aos pagamentos novos (deve ser chamado com o lock adquirido)
+func (r *MemoryRepository) numerarPagamentos(p *Parcela) {
+	for _, pg := range p.Pagamentos {
+		if pg.ID == 0 {
+			pg.ID = r.nextPagamentoID
+			r.nextPagamentoID++
+		}
+	}
+}
+
+func possuiVencimentoEntre(t *Titulo, inicio, fim time.Time) bool {
+	for _, p := range t.Parcelas {
+		if !inicio.IsZero() && p.Vencimento.Before(inicio) {
+			continue
+		}
+		if !fim.IsZero() && !p.Vencimento.Before(fim) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func primeiroVencimento(t *Titulo) time.Time {
+	var menor time.Time
+	for _, p := range t.Parcelas {
+		if menor.IsZero() || p.Vencimento.Before(menor) {
+			menor = p.Vencimento
+		}
+	}
+	return menor
+}
+
+func paginar[T any](itens []T, limit, offset int) []T {
+	if offset >= len(itens) {
+		return []T{}
+	}
+	itens = itens[offset:]
+
+	if limit > 0 && limit < len(itens) {
+		itens = itens[:limit]
+	}
+	return itens
+}
+
+// TransferirCliente move os títulos de um cliente para outro e retorna quantos foram alterados
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, t := range r.titulos {
+		if t.EmpresaID == empresaID && t.ClienteID == deID {
+			t.ClienteID = paraID
+			total++
+		}
+	}
+	return total, nil
+}
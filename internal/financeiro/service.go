@@ -0,0 +1,609 @@
+package financeiro
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// Agrupamentos disponíveis para o fluxo de caixa
+const (
+	AgrupamentoDia    = "dia"
+	AgrupamentoSemana = "semana"
+	AgrupamentoMes    = "mes"
+)
+
+// NovoTitulo contém os dados para lançar uma conta a receber ou a pagar
+type NovoTitulo struct {
+	Descricao          string             `json:"descricao"`
+	Documento          string             `json:"documento"`
+	ClienteID          int                `json:"cliente_id"`
+	FornecedorID       int                `json:"fornecedor_id"`
+	CategoriaID        int                `json:"categoria_id"`
+	ContaBancariaID    int                `json:"conta_bancaria_id"`
+	ValorTotal         float64            `json:"valor_total"`
+	NumeroParcelas     int                `json:"numero_parcelas"`
+	PrimeiroVencimento time.Time          `json:"primeiro_vencimento"`
+	IntervaloDias      int                `json:"intervalo_dias"` // 0 para vencimentos mensais
+	JurosMensal        float64            `json:"juros_mensal"`
+	Multa              float64            `json:"multa"`
+	DataEmissao        time.Time          `json:"data_emissao"`
+	Parcelas           []ParcelaInformada `json:"parcelas"` // opcional: substitui o parcelamento automático
+}
+
+// ParcelaInformada define manualmente o vencimento e o valor de uma parcela
+type ParcelaInformada struct {
+	Vencimento time.Time `json:"vencimento"`
+	Valor      float64   `json:"valor"`
+}
+
+// NovoPagamento contém os dados de uma baixa de parcela
+type NovoPagamento struct {
+	Data            time.Time `json:"data"`
+	Valor           float64   `json:"valor"`
+	Desconto        float64   `json:"desconto"`
+	ContaBancariaID int       `json:"conta_bancaria_id"`
+	Observacao      string    `json:"observacao"`
+}
+
+// Service implementa as regras de parcelamento, baixas e projeção de caixa
+type Service struct {
+	repo     Repository
+	clientes cliente.Repository
+
+	// mu serializa as baixas para evitar pagamentos concorrentes da mesma parcela
+	mu sync.Mutex
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, clientes cliente.Repository) *Service {
+	return &Service{
+		repo:     repo,
+		clientes: clientes,
+	}
+}
+
+// CriarTitulo valida os vínculos, gera as parcelas e grava o título
+func (s *Service) CriarTitulo(empresaID int, tipo string, in NovoTitulo) (*Titulo, error) {
+	if in.Descricao == "" {
+		return nil, errors.New("descrição é obrigatória")
+	}
+
+	if in.JurosMensal < 0 || in.Multa < 0 {
+		return nil, errors.New("juros e multa não podem ser negativos")
+	}
+
+	switch tipo {
+	case TipoReceber:
+		if in.ClienteID <= 0 {
+			return nil, errors.New("cliente é obrigatório para contas a receber")
+		}
+		if _, err := s.clientes.GetByID(in.ClienteID, empresaID); err != nil {
+			return nil, err
+		}
+		in.FornecedorID = 0
+	case TipoPagar:
+		if in.FornecedorID <= 0 {
+			return nil, errors.New("fornecedor é obrigatório para contas a pagar")
+		}
+		if _, err := s.repo.GetFornecedor(in.FornecedorID, empresaID); err != nil {
+			return nil, err
+		}
+		in.ClienteID = 0
+	default:
+		return nil, errors.New("tipo de título inválido")
+	}
+
+	if in.CategoriaID > 0 {
+		c, err := s.repo.GetCategoria(in.CategoriaID, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if (tipo == TipoReceber) != (c.Tipo == CategoriaReceita) {
+			return nil, errors.New("categoria incompatível com o tipo do título")
+		}
+	}
+
+	if in.ContaBancariaID > 0 {
+		if _, err := s.repo.GetContaBancaria(in.ContaBancariaID, empresaID); err != nil {
+			return nil, err
+		}
+	}
+
+	parcelas, total, err := gerarParcelas(in)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Titulo{
+		EmpresaID:       empresaID,
+		Tipo:            tipo,
+		Descricao:       in.Descricao,
+		Documento:       in.Documento,
+		ClienteID:       in.ClienteID,
+		FornecedorID:    in.FornecedorID,
+		CategoriaID:     in.CategoriaID,
+		ContaBancariaID: in.ContaBancariaID,
+		ValorTotal:      total,
+		JurosMensal:     in.JurosMensal,
+		Multa:           in.Multa,
+		DataEmissao:     in.DataEmissao,
+		Parcelas:        parcelas,
+	}
+	if t.DataEmissao.IsZero() {
+		t.DataEmissao = dataSemHora(time.Now())
+	}
+	atualizarStatus(t, time.Now())
+
+	if err := s.repo.CreateTitulo(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Titulo retorna uma cópia do título com o status atualizado para a data atual
+func (s *Service) Titulo(id int, empresaID int) (*Titulo, error) {
+	t, err := s.repo.GetTitulo(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	t = copiarTitulo(t)
+	atualizarStatus(t, time.Now())
+	return t, nil
+}
+
+// ListTitulos lista os títulos do filtro com o status atualizado para a data atual
+func (s *Service) ListTitulos(f FiltroTitulos, limit, offset int) ([]*Titulo, error) {
+	// O status "vencido" depende da data atual, então o filtro por status é aplicado após a atualização
+	status := f.Status
+	f.Status = ""
+
+	titulos, err := s.repo.ListTitulos(f, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	agora := time.Now()
+	result := make([]*Titulo, 0, len(titulos))
+	for _, t := range titulos {
+		t = copiarTitulo(t)
+		atualizarStatus(t, agora)
+		if status == "" || t.Status == status {
+			result = append(result, t)
+		}
+	}
+
+	return paginar(result, limit, offset), nil
+}
+
+// RegistrarPagamento baixa total ou parcialmente uma parcela.
+// O valor pago quita primeiro a multa, depois os juros e por fim o principal. A baixa é feita
+// numa cópia do título, que só substitui o gravado se a atualização no repositório der certo.
+func (s *Service) RegistrarPagamento(empresaID, parcelaID int, in NovoPagamento) (*Titulo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.repo.GetTituloPorParcela(parcelaID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	t = copiarTitulo(t)
+
+	if t.Status == StatusCancelado {
+		return nil, errors.New("título cancelado")
+	}
+	atualizarStatus(t, time.Now())
+
+	var p *Parcela
+	for _, parcela := range t.Parcelas {
+		if parcela.ID == parcelaID {
+			p = parcela
+		}
+	}
+	if p == nil {
+		return nil, errors.New("parcela não encontrada")
+	}
+
+	if p.Status == StatusPago || p.Status == StatusCancelado {
+		return nil, fmt.Errorf("parcela %d já está %s", p.Numero, p.Status)
+	}
+
+	if in.Valor < 0 || in.Desconto < 0 || in.Valor+in.Desconto <= 0 {
+		return nil, errors.New("valor do pagamento inválido")
+	}
+
+	if in.ContaBancariaID == 0 {
+		in.ContaBancariaID = t.ContaBancariaID
+	}
+	if in.ContaBancariaID > 0 {
+		if _, err := s.repo.GetContaBancaria(in.ContaBancariaID, empresaID); err != nil {
+			return nil, err
+		}
+	}
+
+	if in.Data.IsZero() {
+		in.Data = time.Now()
+	}
+	data := dataSemHora(in.Data)
+
+	multa, juros := Encargos(t, p, data)
+	devido := arredondar(p.Saldo() + multa + juros)
+	disponivel := arredondar(in.Valor + in.Desconto)
+	if disponivel > devido+0.005 {
+		return nil, fmt.Errorf("valor excede o saldo devedor de %.2f", devido)
+	}
+
+	pg := &Pagamento{
+		Data:            data,
+		Valor:           arredondar(in.Valor),
+		Desconto:        arredondar(in.Desconto),
+		ContaBancariaID: in.ContaBancariaID,
+		Observacao:      in.Observacao,
+	}
+
+	pg.Multa = math.Min(disponivel, multa)
+	disponivel = arredondar(disponivel - pg.Multa)
+	pg.Juros = math.Min(disponivel, juros)
+	disponivel = arredondar(disponivel - pg.Juros)
+	pg.Principal = math.Min(disponivel, p.Saldo())
+
+	p.MultaPaga = arredondar(p.MultaPaga + pg.Multa)
+	p.JurosPagos = arredondar(p.JurosPagos + pg.Juros)
+	p.JurosPendentes = arredondar(juros - pg.Juros)
+	p.ValorPago = arredondar(p.ValorPago + pg.Principal)
+	if data.After(p.JurosAte) {
+		p.JurosAte = data
+	}
+	p.Pagamentos = append(p.Pagamentos, pg)
+
+	atualizarStatus(t, time.Now())
+
+	if err := s.repo.UpdateTitulo(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Cancelar cancela as parcelas em aberto de um título
+func (s *Service) Cancelar(id int, empresaID int) (*Titulo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, err := s.repo.GetTitulo(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	t = copiarTitulo(t)
+
+	if t.Status == StatusPago {
+		return nil, errors.New("título já quitado não pode ser cancelado")
+	}
+
+	for _, p := range t.Parcelas {
+		if p.Status != StatusPago {
+			p.Status = StatusCancelado
+		}
+	}
+	t.Status = StatusCancelado
+
+	if err := s.repo.UpdateTitulo(t); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// FluxoCaixa projeta entradas e saídas da empresa no intervalo [inicio, fim)
+func (s *Service) FluxoCaixa(empresaID int, inicio, fim time.Time, agrupamento string) (*FluxoCaixa, error) {
+	inicio, fim = dataSemHora(inicio), dataSemHora(fim)
+	if !inicio.Before(fim) {
+		return nil, errors.New("período inválido")
+	}
+
+	if agrupamento == "" {
+		agrupamento = AgrupamentoDia
+	}
+
+	fluxo := &FluxoCaixa{
+		Inicio:      inicio,
+		Fim:         fim,
+		Agrupamento: agrupamento,
+		Periodos:    make([]PeriodoFluxo, 0),
+	}
+
+	for i, atual := 0, inicio; atual.Before(fim); i++ {
+		proximo, err := inicioPeriodo(inicio, i+1, agrupamento)
+		if err != nil {
+			return nil, err
+		}
+		if proximo.After(fim) {
+			proximo = fim
+		}
+		fluxo.Periodos = append(fluxo.Periodos, PeriodoFluxo{Inicio: atual, Fim: proximo})
+		atual = proximo
+	}
+
+	contas, err := s.repo.ListContasBancarias(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range contas {
+		fluxo.SaldoInicial += c.SaldoInicial
+	}
+
+	titulos, err := s.repo.ListTitulos(FiltroTitulos{EmpresaID: empresaID}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	hoje := dataSemHora(time.Now())
+	for _, t := range titulos {
+		sinal := 1.0
+		if t.Tipo == TipoPagar {
+			sinal = -1.0
+		}
+
+		for _, p := range t.Parcelas {
+			// Realizado: pagamentos efetuados
+			for _, pg := range p.Pagamentos {
+				if pg.Data.Before(inicio) {
+					fluxo.SaldoInicial += sinal * pg.Valor
+					continue
+				}
+				if i := indicePeriodo(fluxo.Periodos, pg.Data); i >= 0 {
+					if sinal > 0 {
+						fluxo.Periodos[i].EntradasRealizadas += pg.Valor
+					} else {
+						fluxo.Periodos[i].SaidasRealizadas += pg.Valor
+					}
+				}
+			}
+
+			// Previsto: saldo das parcelas em aberto
+			if p.Status == StatusCancelado || p.Saldo() <= 0 {
+				continue
+			}
+
+			vencimento := dataSemHora(p.Vencimento)
+			if vencimento.Before(hoje) {
+				if sinal > 0 {
+					fluxo.VencidosReceber += p.Saldo()
+				} else {
+					fluxo.VencidosPagar += p.Saldo()
+				}
+				continue
+			}
+
+			if i := indicePeriodo(fluxo.Periodos, vencimento); i >= 0 {
+				if sinal > 0 {
+					fluxo.Periodos[i].EntradasPrevistas += p.Saldo()
+				} else {
+					fluxo.Periodos[i].SaidasPrevistas += p.Saldo()
+				}
+			}
+		}
+	}
+
+	fluxo.SaldoInicial = arredondar(fluxo.SaldoInicial)
+	fluxo.VencidosReceber = arredondar(fluxo.VencidosReceber)
+	fluxo.VencidosPagar = arredondar(fluxo.VencidosPagar)
+
+	acumulado := fluxo.SaldoInicial
+	for i := range fluxo.Periodos {
+		p := &fluxo.Periodos[i]
+		p.EntradasPrevistas = arredondar(p.EntradasPrevistas)
+		p.SaidasPrevistas = arredondar(p.SaidasPrevistas)
+		p.EntradasRealizadas = arredondar(p.EntradasRealizadas)
+		p.SaidasRealizadas = arredondar(p.SaidasRealizadas)
+		p.Saldo = arredondar(p.EntradasPrevistas + p.EntradasRealizadas - p.SaidasPrevistas - p.SaidasRealizadas)
+		acumulado = arredondar(acumulado + p.Saldo)
+		p.SaldoAcumulado = acumulado
+	}
+	fluxo.SaldoFinal = acumulado
+
+	return fluxo, nil
+}
+
+// Encargos calcula a multa e os juros em aberto de uma parcela na data informada
+func Encargos(t *Titulo, p *Parcela, data time.Time) (multa, juros float64) {
+	vencimento := dataSemHora(p.Vencimento)
+	data = dataSemHora(data)
+
+	juros = arredondar(p.JurosPendentes)
+	if !data.After(vencimento) {
+		return 0, juros
+	}
+
+	multa = arredondar(p.Valor*t.Multa/100 - p.MultaPaga)
+	if multa < 0 {
+		multa = 0
+	}
+
+	// Juros simples pro rata die sobre o principal em aberto, a partir da última apuração. Os juros
+	// de cada período são arredondados ao centavo antes de somar aos pendentes, e as multiplicações
+	// vêm antes da divisão para não acumular o erro das frações
+	base := vencimento
+	if p.JurosAte.After(base) {
+		base = dataSemHora(p.JurosAte)
+	}
+	if dias := diasEntre(base, data); dias > 0 {
+		juros = arredondar(juros + arredondar(p.Saldo()*t.JurosMensal*float64(dias)/3000))
+	}
+
+	return multa, juros
+}
+
+// gerarParcelas divide o valor total nas parcelas; a diferença de arredondamento vai para a última
+func gerarParcelas(in NovoTitulo) ([]*Parcela, float64, error) {
+	if len(in.Parcelas) > 0 {
+		parcelas := make([]*Parcela, 0, len(in.Parcelas))
+		total := 0.0
+		for i, informada := range in.Parcelas {
+			if informada.Valor <= 0 || informada.Vencimento.IsZero() {
+				return nil, 0, fmt.Errorf("parcela %d inválida", i+1)
+			}
+			parcelas = append(parcelas, &Parcela{
+				Numero:     i + 1,
+				Vencimento: dataSemHora(informada.Vencimento),
+				Valor:      arredondar(informada.Valor),
+			})
+			total += informada.Valor
+		}
+		return parcelas, arredondar(total), nil
+	}
+
+	if in.ValorTotal <= 0 {
+		return nil, 0, errors.New("valor total deve ser maior que zero")
+	}
+
+	if in.PrimeiroVencimento.IsZero() {
+		return nil, 0, errors.New("primeiro vencimento é obrigatório")
+	}
+
+	n := in.NumeroParcelas
+	if n <= 0 {
+		n = 1
+	}
+	if n > 360 {
+		return nil, 0, errors.New("número de parcelas excede o limite")
+	}
+
+	total := arredondar(in.ValorTotal)
+	valorParcela := math.Floor(total/float64(n)*100) / 100
+	primeiro := dataSemHora(in.PrimeiroVencimento)
+
+	parcelas := make([]*Parcela, 0, n)
+	acumulado := 0.0
+	for i := 0; i < n; i++ {
+		vencimento := adicionarMeses(primeiro, i)
+		if in.IntervaloDias > 0 {
+			vencimento = primeiro.AddDate(0, 0, i*in.IntervaloDias)
+		}
+
+		valor := valorParcela
+		if i == n-1 {
+			valor = arredondar(total - acumulado)
+		}
+		acumulado = arredondar(acumulado + valor)
+
+		parcelas = append(parcelas, &Parcela{
+			Numero:     i + 1,
+			Vencimento: vencimento,
+			Valor:      valor,
+		})
+	}
+
+	return parcelas, total, nil
+}
+
+// atualizarStatus recalcula o status das parcelas e do título na data informada
+func atualizarStatus(t *Titulo, agora time.Time) {
+	if t.Status == StatusCancelado {
+		return
+	}
+
+	hoje := dataSemHora(agora)
+	pagas, vencidas, parciais, ativas := 0, 0, 0, 0
+
+	for _, p := range t.Parcelas {
+		if p.Status == StatusCancelado {
+			continue
+		}
+		ativas++
+
+		switch {
+		case p.Saldo() <= 0:
+			p.Status = StatusPago
+			pagas++
+		case dataSemHora(p.Vencimento).Before(hoje):
+			p.Status = StatusVencido
+			vencidas++
+		case p.ValorPago > 0:
+			p.Status = StatusParcial
+			parciais++
+		default:
+			p.Status = StatusAberto
+		}
+	}
+
+	switch {
+	case ativas == 0:
+		t.Status = StatusCancelado
+	case pagas == ativas:
+		t.Status = StatusPago
+	case vencidas > 0:
+		t.Status = StatusVencido
+	case pagas > 0 || parciais > 0:
+		t.Status = StatusParcial
+	default:
+		t.Status = StatusAberto
+	}
+}
+
+// inicioPeriodo retorna o início do n-ésimo período do fluxo, contado a partir do início
+func inicioPeriodo(inicio time.Time, n int, agrupamento string) (time.Time, error) {
+	switch agrupamento {
+	case AgrupamentoDia:
+		return inicio.AddDate(0, 0, n), nil
+	case AgrupamentoSemana:
+		return inicio.AddDate(0, 0, 7*n), nil
+	case AgrupamentoMes:
+		return adicionarMeses(inicio, n), nil
+	}
+	return inicio, fmt.Errorf("agrupamento inválido: %s", agrupamento)
+}
+
+// adicionarMeses soma meses mantendo o dia, limitado ao último dia do mês de destino:
+// 31/01 mais um mês é 28/02 (ou 29/02), e não 03/03 como em time.AddDate
+func adicionarMeses(t time.Time, meses int) time.Time {
+	ano, mes, dia := t.Date()
+	primeiroDia := time.Date(ano, mes+time.Month(meses), 1, 0, 0, 0, 0, t.Location())
+	if ultimo := primeiroDia.AddDate(0, 1, -1).Day(); dia > ultimo {
+		dia = ultimo
+	}
+	return time.Date(primeiroDia.Year(), primeiroDia.Month(), dia, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+}
+
+// copiarTitulo copia o título com parcelas e pagamentos, para alterá-lo sem tocar no registro gravado
+func copiarTitulo(t *Titulo) *Titulo {
+	copia := *t
+	copia.Parcelas = make([]*Parcela, len(t.Parcelas))
+	for i, p := range t.Parcelas {
+		parcela := *p
+		parcela.Pagamentos = make([]*Pagamento, len(p.Pagamentos))
+		for j, pg := range p.Pagamentos {
+			pagamento := *pg
+			parcela.Pagamentos[j] = &pagamento
+		}
+		copia.Parcelas[i] = &parcela
+	}
+	return &copia
+}
+
+func indicePeriodo(periodos []PeriodoFluxo, data time.Time) int {
+	for i, p := range periodos {
+		if !data.Before(p.Inicio) && data.Before(p.Fim) {
+			return i
+		}
+	}
+	return -1
+}
+
+func dataSemHora(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+func diasEntre(inicio, fim time.Time) int {
+	return int(math.Round(fim.Sub(inicio).Hours() / 24))
+}
+
+// arredondar arredonda valores monetários para centavos
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
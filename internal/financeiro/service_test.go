@@ -0,0 +1,76 @@
+package financeiro
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+func TestEncargos(t *testing.T) {
+	vencimento := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)
+	tests := []struct {
+		nome         string
+		valor        float64
+		jurosMensal  float64
+		multa        float64
+		pendentes    float64
+		dias         int
+		esperadaMult float64
+		esperadoJur  float64
+	}{
+		{"no vencimento", 100, 1, 2, 0, 0, 0, 0},
+		{"um mês a 1%", 100, 1, 0, 0, 30, 0, 1},
+		{"meio mês a 2%", 1000, 2, 0, 0, 15, 0, 10},
+		{"fração de centavo", 333.33, 1, 0, 0, 1, 0, 0.11},
+		{"com multa", 250, 3, 2, 0, 10, 5, 2.5},
+		{"soma aos pendentes", 100, 1.5, 0, 0.07, 7, 0, 0.42},
+		{"valor que acumularia erro", 0.1, 100, 0, 0, 3, 0, 0.01},
+	}
+	for _, tt := range tests {
+		titulo := &Titulo{JurosMensal: tt.jurosMensal, Multa: tt.multa}
+		p := &Parcela{Valor: tt.valor, Vencimento: vencimento, JurosPendentes: tt.pendentes}
+		multa, juros := Encargos(titulo, p, vencimento.AddDate(0, 0, tt.dias))
+		if multa != tt.esperadaMult || juros != tt.esperadoJur {
+			t.Errorf("%s: multa %v juros %v, esperado %v e %v", tt.nome, multa, juros, tt.esperadaMult, tt.esperadoJur)
+		}
+	}
+}
+
+// Uma baixa parcial apura os juros até a data e a final cobra o restante, ao centavo
+func TestJurosComBaixaParcial(t *testing.T) {
+	clientes := cliente.NewMemoryRepository()
+	c := &cliente.Cliente{Nome: "Ana", Email: "ana@acme.com", EmpresaID: 1}
+	if err := clientes.Create(c); err != nil {
+		t.Fatal(err)
+	}
+	s := NewService(NewMemoryRepository(), clientes)
+
+	vencimento := time.Date(2024, 3, 10, 0, 0, 0, 0, time.Local)
+	titulo, err := s.CriarTitulo(1, TipoReceber, NovoTitulo{
+		Descricao:          "Venda",
+		ClienteID:          c.ID,
+		ValorTotal:         1000,
+		PrimeiroVencimento: vencimento,
+		JurosMensal:        1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parcelaID := titulo.Parcelas[0].ID
+
+	// 10 dias: R$ 3,33 de juros; paga juros e R$ 496,67 do principal
+	if _, err := s.RegistrarPagamento(1, parcelaID, NovoPagamento{Data: vencimento.AddDate(0, 0, 10), Valor: 500}); err != nil {
+		t.Fatal(err)
+	}
+	// Mais 20 dias sobre R$ 503,33: R$ 3,36 de juros
+	titulo, err = s.RegistrarPagamento(1, parcelaID, NovoPagamento{Data: vencimento.AddDate(0, 0, 30), Valor: 506.69})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := titulo.Parcelas[0]
+	if p.Status != StatusPago || p.JurosPagos != 6.69 || p.ValorPago != 1000 || p.JurosPendentes != 0 {
+		t.Errorf("parcela %s: juros pagos %v, principal pago %v, juros pendentes %v", p.Status, p.JurosPagos, p.ValorPago, p.JurosPendentes)
+	}
+}
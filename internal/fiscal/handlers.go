@@ -0,0 +1,286 @@
+package fiscal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// tamanhoMaximoCertificado limita o upload do arquivo PKCS#12
+const tamanhoMaximoCertificado = 1 << 20
+
+// Handlers contém os manipuladores HTTP para notas fiscais
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas para notas fiscais
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/configuracao", h.GetConfiguracao)
+	r.With(auth.RequireRole("admin")).Put("/configuracao", h.SaveConfiguracao)
+	r.With(auth.RequireRole("admin")).Post("/configuracao/certificado", h.SaveCertificado)
+
+	r.Get("/notas", h.List)
+	r.Post("/notas", h.Emitir)
+	r.Get("/notas/{id}", h.GetByID)
+	r.Get("/notas/{id}/xml", h.XML)
+	r.Get("/notas/{id}/danfe", h.DANFE)
+	r.Post("/notas/{id}/consultar", h.Consultar)
+
+	return r
+}
+
+// GetConfiguracao retorna a configuração fiscal da empresa do usuário atual
+func (h *Handlers) GetConfiguracao(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	cfg, err := h.repo.GetConfiguracao(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// SaveConfiguracao grava os dados do emitente da empresa do usuário atual
+func (h *Handlers) SaveConfiguracao(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var cfg Configuracao
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg.EmpresaID = user.Empresa
+	if err := h.service.SalvarConfiguracao(&cfg); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// SaveCertificado recebe o certificado A1 no campo "arquivo" e a senha no campo "senha" de um formulário multipart
+func (h *Handlers) SaveCertificado(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, tamanhoMaximoCertificado+4096)
+	arquivo, _, err := r.FormFile("arquivo")
+	if err != nil {
+		http.Error(w, "Arquivo do certificado não enviado", http.StatusBadRequest)
+		return
+	}
+	defer arquivo.Close()
+
+	pfx, err := io.ReadAll(io.LimitReader(arquivo, tamanhoMaximoCertificado))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := h.service.SalvarCertificado(user.Empresa, pfx, r.FormValue("senha"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cfg)
+}
+
+// List lista as notas fiscais da empresa. Filtros: status, pedido_id e cliente_id.
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	pedidoID, _ := strconv.Atoi(r.URL.Query().Get("pedido_id"))
+	clienteID, _ := strconv.Atoi(r.URL.Query().Get("cliente_id"))
+
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+
+	notas, err := h.repo.List(FiltroNotas{
+		EmpresaID: user.Empresa,
+		Status:    r.URL.Query().Get("status"),
+		PedidoID:  pedidoID,
+		ClienteID: clienteID,
+	}, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notas)
+}
+
+// GetByID retorna uma nota fiscal por ID
+func (h *Handlers) GetByID(w http.ResponseWriter, r *http.Request) {
+	n, ok := h.nota(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(n)
+}
+
+// Emitir gera e transmite a NF-e de um pedido faturado.
+// Notas rejeitadas pelo schema ou pela SEFAZ são retornadas com status 422 e os motivos da rejeição.
+func (h *Handlers) Emitir(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in NovaNota
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.service.Emitir(r.Context(), user.Empresa, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if n.Status == StatusAutorizada {
+		w.WriteHeader(http.StatusCreated)
+	} else {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(n)
+}
+
+// XML retorna o XML assinado da nota, ou o nfeProc quando autorizada
+func (h *Handlers) XML(w http.ResponseWriter, r *http.Request) {
+	n, ok := h.nota(w, r)
+	if !ok {
+		return
+	}
+	if len(n.XML) == 0 {
+		http.Error(w, "Nota fiscal sem XML gerado", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-nfe.xml\"", n.Chave))
+	w.Write(n.XML)
+}
+
+// DANFE renderiza o documento auxiliar da nota para impressão
+func (h *Handlers) DANFE(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	n, pdf, err := h.service.DANFE(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"danfe-%s.pdf\"", n.Chave))
+	w.Write(pdf)
+}
+
+// Consultar atualiza a situação de uma nota pendente junto à SEFAZ
+func (h *Handlers) Consultar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	n, err := h.service.Consultar(r.Context(), id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(n)
+}
+
+// nota carrega a nota fiscal da URL, respondendo com erro quando não encontrada
+func (h *Handlers) nota(w http.ResponseWriter, r *http.Request) (*NotaFiscal, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	n, err := h.repo.GetByID(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+
+	return n, true
+}
@@ -0,0 +1,96 @@
+package fiscal
+
+import (
+	"time"
+)
+
+// Status das notas fiscais
+const (
+	StatusPendente   = "pendente" // enviada sem resposta da SEFAZ; será consultada antes de reenviar
+	StatusAutorizada = "autorizada"
+	StatusRejeitada  = "rejeitada" // o número é reaproveitado na próxima emissão do pedido
+)
+
+// Endereco é o endereço estruturado exigido na NF-e
+type Endereco struct {
+	Logradouro      string `json:"logradouro"`
+	Numero          string `json:"numero"`
+	Complemento     string `json:"complemento,omitempty"`
+	Bairro          string `json:"bairro"`
+	CodigoMunicipio string `json:"codigo_municipio"` // código IBGE de 7 dígitos
+	Municipio       string `json:"municipio"`
+	UF              string `json:"uf"`
+	CEP             string `json:"cep"`
+	Telefone        string `json:"telefone,omitempty"`
+}
+
+// Configuracao contém os dados fiscais do emitente e o certificado digital A1
+type Configuracao struct {
+	EmpresaID           int       `json:"empresa_id"`
+	CNPJ                string    `json:"cnpj"`
+	RazaoSocial         string    `json:"razao_social"`
+	NomeFantasia        string    `json:"nome_fantasia,omitempty"`
+	InscricaoEstadual   string    `json:"inscricao_estadual"`
+	CRT                 int       `json:"crt"` // 1 Simples Nacional, 2 Simples com excesso de sublimite, 3 Regime Normal
+	Endereco            Endereco  `json:"endereco"`
+	Serie               int       `json:"serie"`
+	NumeroInicial       int       `json:"numero_inicial"` // continua a numeração de outro emissor
+	Ambiente            int       `json:"ambiente"`       // 1 produção, 2 homologação
+	NaturezaOperacao    string    `json:"natureza_operacao"`
+	FormaPagamento      string    `json:"forma_pagamento"` // tPag da NF-e; padrão 15 (boleto)
+	AliquotaPIS         float64   `json:"aliquota_pis"`    // em %, para CST 01 e 02
+	AliquotaCOFINS      float64   `json:"aliquota_cofins"` // em %, para CST 01 e 02
+	Certificado         []byte    `json:"-"`
+	SenhaCertificado    string    `json:"-"`
+	CertificadoTitular  string    `json:"certificado_titular,omitempty"`
+	CertificadoValidade time.Time `json:"certificado_validade,omitempty"`
+	DataAtualizacao     time.Time `json:"data_atualizacao"`
+}
+
+// NotaFiscal representa uma NF-e emitida a partir de um pedido de venda
+type NotaFiscal struct {
+	ID              int       `json:"id"`
+	EmpresaID       int       `json:"empresa_id"`
+	PedidoID        int       `json:"pedido_id"`
+	ClienteID       int       `json:"cliente_id"`
+	Serie           int       `json:"serie"`
+	Numero          int       `json:"numero"`
+	Chave           string    `json:"chave"`
+	Ambiente        int       `json:"ambiente"`
+	Status          string    `json:"status"`
+	CodigoStatus    int       `json:"codigo_status,omitempty"` // cStat retornado pela SEFAZ
+	Motivo          string    `json:"motivo,omitempty"`
+	Erros           []string  `json:"erros,omitempty"` // violações do schema XSD
+	Protocolo       string    `json:"protocolo,omitempty"`
+	ValorTotal      float64   `json:"valor_total"`
+	XML             []byte    `json:"-"` // NF-e assinada ou nfeProc quando autorizada
+	DataEmissao     time.Time `json:"data_emissao"`
+	DataAutorizacao time.Time `json:"data_autorizacao,omitempty"`
+	DataCriacao     time.Time `json:"data_criacao"`
+	DataAtualizacao time.Time `json:"data_atualizacao"`
+}
+
+// FiltroNotas define os critérios de listagem de notas fiscais
+type FiltroNotas struct {
+	EmpresaID int
+	Status    string
+	PedidoID  int
+	ClienteID int
+}
+
+// Repository define a interface para acesso aos dados fiscais
+type Repository interface {
+	SaveConfiguracao(c *Configuracao) error
+	GetConfiguracao(empresaID int) (*Configuracao, error)
+	// ProximoNumero reserva o próximo número da série da empresa
+	ProximoNumero(empresaID, serie int) (int, error)
+
+	Create(n *NotaFiscal) error
+	GetByID(id int, empresaID int) (*NotaFiscal, error)
+	GetByPedido(pedidoID int, empresaID int) (*NotaFiscal, error)
+	Update(n *NotaFiscal) error
+	List(f FiltroNotas, limit, offset int) ([]*NotaFiscal, error)
+
+	// TransferirCliente reaponta para paraID os registros de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
@@ -0,0 +1,203 @@
+package fiscal
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	configuracoes map[int]*Configuracao
+	notas         map[int]*NotaFiscal
+	numeros       map[chaveSerie]int // último número usado por empresa e série
+	nextID        int
+}
+
+type chaveSerie struct {
+	empresaID int
+	serie     int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		configuracoes: make(map[int]*Configuracao),
+		notas:         make(map[int]*NotaFiscal),
+		numeros:       make(map[chaveSerie]int),
+		nextID:        1,
+	}
+}
+
+// SaveConfiguracao cria ou substitui a configuração fiscal da empresa
+func (r *MemoryRepository) SaveConfiguracao(c *Configuracao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	c.DataAtualizacao = time.Now()
+	r.configuracoes[c.EmpresaID] = c
+	return nil
+}
+
+// GetConfiguracao busca a configuração fiscal da empresa
+func (r *MemoryRepository) GetConfiguracao(empresaID int) (*Configuracao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.configuracoes[empresaID]
+	if !exists {
+		return nil, errors.New("configuração fiscal não encontrada")
+	}
+	return c, nil
+}
+
+// ProximoNumero reserva o próximo número da série, a partir do número inicial configurado
+func (r *MemoryRepository) ProximoNumero(empresaID, serie int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	chave := chaveSerie{empresaID, serie}
+	ultimo := r.numeros[chave]
+	if c, exists := r.configuracoes[empresaID]; exists && c.Serie == serie && c.NumeroInicial-1 > ultimo {
+		ultimo = c.NumeroInicial - 1
+	}
+	if ultimo >= 999999999 {
+		return 0, errors.New("numeração da série esgotada")
+	}
+
+	r.numeros[chave] = ultimo + 1
+	return ultimo + 1, nil
+}
+
+// Create adiciona uma nova nota fiscal
+func (r *MemoryRepository) Create(n *NotaFiscal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	for _, outra := range r.notas {
+		if outra.EmpresaID == n.EmpresaID && outra.Serie == n.Serie && outra.Numero == n.Numero {
+			return errors.New("número de nota já utilizado na série")
+		}
+	}
+
+	n.ID = r.nextID
+	r.nextID++
+	n.DataCriacao = time.Now()
+	n.DataAtualizacao = n.DataCriacao
+
+	r.notas[n.ID] = n
+	return nil
+}
+
+// GetByID busca uma nota por ID e empresa
+func (r *MemoryRepository) GetByID(id int, empresaID int) (*NotaFiscal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n, exists := r.notas[id]
+	if !exists || n.EmpresaID != empresaID {
+		return nil, errors.New("nota fiscal não encontrada")
+	}
+	return n, nil
+}
+
+// GetByPedido busca a nota mais recente de um pedido
+func (r *MemoryRepository) GetByPedido(pedidoID int, empresaID int) (*NotaFiscal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var encontrada *NotaFiscal
+	for _, n := range r.notas {
+		if n.EmpresaID == empresaID && n.PedidoID == pedidoID && (encontrada == nil || n.ID > encontrada.ID) {
+			encontrada = n
+		}
+	}
+	if encontrada == nil {
+		return nil, errors.New("nota fiscal não encontrada")
+	}
+	return encontrada, nil
+}
+
+// Update atualiza uma nota existente
+func (r *MemoryRepository) Update(n *NotaFiscal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.notas[n.ID]
+	if !exists || existing.EmpresaID != n.EmpresaID {
+		return errors.New("nota fiscal não encontrada")
+	}
+
+	// Preservar campos que não devem ser alterados
+	n.PedidoID = existing.PedidoID
+	n.Serie = existing.Serie
+	n.Numero = existing.Numero
+	n.DataCriacao = existing.DataCriacao
+	n.DataAtualizacao = time.Now()
+
+	r.notas[n.ID] = n
+	return nil
+}
+
+// List retorna as notas da empresa, das mais recentes para as mais antigas
+func (r *MemoryRepository) List(f FiltroNotas, limit, offset int) ([]*NotaFiscal, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*NotaFiscal, 0)
+	for _, n := range r.notas {
+		if n.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.Status != "" && n.Status != f.Status {
+			continue
+		}
+		if f.PedidoID > 0 && n.PedidoID != f.PedidoID {
+			continue
+		}
+		if f.ClienteID > 0 && n.ClienteID != f.ClienteID {
+			continue
+		}
+		result = append(result, n)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*NotaFiscal{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// TransferirCliente move as notas fiscais de um cliente para outro e retorna quantos foram alterados
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, n := range r.notas {
+		if n.EmpresaID == empresaID && n.ClienteID == deID {
+			n.ClienteID = paraID
+			total++
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,806 @@
+package fiscal
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/pedido"
+	"github.com/Pantaleaogc/gvero/internal/produto"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/Pantaleaogc/gvero/pkg/nfe"
+)
+
+// textoSimples é a observação obrigatória nas notas de optantes pelo Simples Nacional
+const textoSimples = "DOCUMENTO EMITIDO POR ME OU EPP OPTANTE PELO SIMPLES NACIONAL. NÃO GERA DIREITO A CRÉDITO FISCAL DE IPI."
+
+// NovaNota contém os dados informados na emissão que não constam do pedido
+type NovaNota struct {
+	PedidoID          int      `json:"pedido_id"`
+	Destinatario      Endereco `json:"destinatario"`                 // vazio usa o endereço de cobrança principal do cliente
+	InscricaoEstadual string   `json:"inscricao_estadual,omitempty"` // vazio para não contribuintes
+	Observacoes       string   `json:"observacoes,omitempty"`
+}
+
+// Service implementa a emissão de NF-e a partir dos pedidos de venda
+type Service struct {
+	repo       Repository
+	pedidos    pedido.Repository
+	clientes   cliente.Repository
+	produtos   produto.Repository
+	financeiro financeiro.Repository
+	sefaz      nfe.SEFAZ
+	validador  *nfe.Validador
+
+	// mu serializa as emissões para não reutilizar números nem enviar a mesma nota duas vezes
+	mu sync.Mutex
+}
+
+// NewService cria uma nova instância de Service. Sem validador, a emissão é recusada,
+// pois nenhuma nota deve ser enviada sem a validação contra os schemas oficiais.
+func NewService(repo Repository, pedidos pedido.Repository, clientes cliente.Repository, produtos produto.Repository,
+	financeiroRepo financeiro.Repository, sefaz nfe.SEFAZ, validador *nfe.Validador) *Service {
+	return &Service{
+		repo:       repo,
+		pedidos:    pedidos,
+		clientes:   clientes,
+		produtos:   produtos,
+		financeiro: financeiroRepo,
+		sefaz:      sefaz,
+		validador:  validador,
+	}
+}
+
+// SalvarConfiguracao valida e grava os dados do emitente, preservando o certificado já enviado
+func (s *Service) SalvarConfiguracao(c *Configuracao) error {
+	c.CNPJ = somenteDigitos(c.CNPJ)
+	c.Endereco.CEP = somenteDigitos(c.Endereco.CEP)
+	c.Endereco.UF = strings.ToUpper(c.Endereco.UF)
+
+	if len(c.CNPJ) != 14 {
+		return errors.New("CNPJ do emitente inválido")
+	}
+	if c.RazaoSocial == "" || c.InscricaoEstadual == "" {
+		return errors.New("razão social e inscrição estadual são obrigatórias")
+	}
+	if c.CRT < nfe.CRTSimplesNacional || c.CRT > nfe.CRTRegimeNormal {
+		return errors.New("regime tributário (CRT) inválido")
+	}
+	if c.Ambiente != nfe.AmbienteProducao && c.Ambiente != nfe.AmbienteHomologacao {
+		return errors.New("ambiente deve ser 1 (produção) ou 2 (homologação)")
+	}
+	if c.Serie < 0 || c.Serie > 999 {
+		return errors.New("série inválida")
+	}
+	if err := validarEndereco(c.Endereco); err != nil {
+		return fmt.Errorf("endereço do emitente: %w", err)
+	}
+	if len(c.Endereco.CEP) != 8 {
+		return errors.New("endereço do emitente: CEP inválido")
+	}
+	if c.NaturezaOperacao == "" {
+		c.NaturezaOperacao = "Venda de mercadoria"
+	}
+	if c.FormaPagamento == "" {
+		c.FormaPagamento = "15"
+	}
+
+	if atual, err := s.repo.GetConfiguracao(c.EmpresaID); err == nil {
+		c.Certificado = atual.Certificado
+		c.SenhaCertificado = atual.SenhaCertificado
+		c.CertificadoTitular = atual.CertificadoTitular
+		c.CertificadoValidade = atual.CertificadoValidade
+	}
+
+	return s.repo.SaveConfiguracao(c)
+}
+
+// SalvarCertificado grava o certificado A1 após conferir a senha, a validade e o CNPJ do titular
+func (s *Service) SalvarCertificado(empresaID int, pfx []byte, senha string) (*Configuracao, error) {
+	atual, err := s.repo.GetConfiguracao(empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := nfe.LerCertificado(pfx, senha)
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(cert.Validade()) {
+		return nil, errors.New("certificado digital vencido")
+	}
+	if cnpj := cert.CNPJ(); cnpj != "" && cnpj[:8] != atual.CNPJ[:8] {
+		return nil, errors.New("certificado pertence a outro CNPJ")
+	}
+
+	c := *atual
+	c.Certificado = pfx
+	c.SenhaCertificado = senha
+	c.CertificadoTitular = cert.Cert.Subject.CommonName
+	c.CertificadoValidade = cert.Validade()
+
+	if err := s.repo.SaveConfiguracao(&c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// Emitir gera, assina, valida e transmite a NF-e de um pedido faturado.
+// Notas rejeitadas ficam registradas e uma nova emissão do pedido reaproveita o número.
+func (s *Service) Emitir(ctx context.Context, empresaID int, in NovaNota) (*NotaFiscal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.validador == nil {
+		return nil, errors.New("schemas XSD da NF-e não carregados: emissão indisponível")
+	}
+
+	cfg, err := s.repo.GetConfiguracao(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.Certificado) == 0 {
+		return nil, errors.New("certificado digital não configurado")
+	}
+	cert, err := nfe.LerCertificado(cfg.Certificado, cfg.SenhaCertificado)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.pedidos.GetByID(in.PedidoID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if p.Tipo != pedido.TipoPedido || (p.Status != pedido.StatusFaturado && p.Status != pedido.StatusEntregue) {
+		return nil, errors.New("apenas pedidos faturados podem gerar NF-e")
+	}
+
+	cli, err := s.clientes.GetByID(p.ClienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if in.Destinatario == (Endereco{}) {
+		if in.Destinatario, err = s.enderecoCobranca(cli); err != nil {
+			return nil, err
+		}
+	}
+
+	// Uma nota anterior do pedido define se é possível emitir e qual número usar
+	nota := &NotaFiscal{EmpresaID: empresaID, PedidoID: p.ID, ClienteID: cli.ID, Serie: cfg.Serie}
+	if anterior, err := s.repo.GetByPedido(p.ID, empresaID); err == nil {
+		if anterior.Status == StatusPendente {
+			resolvida, err := s.resolverPendente(ctx, cfg, anterior)
+			if err != nil {
+				return nil, err
+			}
+			if resolvida.Status == StatusAutorizada {
+				return resolvida, nil
+			}
+			anterior = resolvida
+		}
+		if anterior.Status == StatusAutorizada {
+			return nil, errors.New("pedido já possui NF-e autorizada")
+		}
+		copia := *anterior
+		nota = &copia
+		nota.Erros = nil
+	}
+
+	if nota.Numero == 0 {
+		if nota.Numero, err = s.repo.ProximoNumero(empresaID, cfg.Serie); err != nil {
+			return nil, err
+		}
+		// Até a resposta da SEFAZ a nota fica como rejeitada, para o número ser reaproveitado se a emissão falhar
+		nota.Status = StatusRejeitada
+		nota.Motivo = "emissão não concluída"
+		if err := s.repo.Create(nota); err != nil {
+			return nil, err
+		}
+	}
+
+	emissao := time.Now()
+	doc, err := s.montar(cfg, p, cli, in, nota.Serie, nota.Numero, emissao)
+	if err != nil {
+		return nil, s.falhar(nota, err)
+	}
+	nota.Chave = strings.TrimPrefix(doc.InfNFe.ID, "NFe")
+	nota.Ambiente = cfg.Ambiente
+	nota.DataEmissao = emissao
+	nota.ValorTotal, _ = strconv.ParseFloat(doc.InfNFe.Total.ICMSTot.VNF, 64)
+
+	xmlNota, err := nfe.Marshal(doc)
+	if err != nil {
+		return nil, s.falhar(nota, err)
+	}
+	assinado, err := nfe.Assinar(xmlNota, cert)
+	if err != nil {
+		return nil, s.falhar(nota, err)
+	}
+	nota.XML = assinado
+
+	if err := s.validador.Validar(assinado); err != nil {
+		nota.Status = StatusRejeitada
+		nota.CodigoStatus = 0
+		nota.Motivo = "XML não confere com o schema da NF-e"
+		if ev, ok := err.(*nfe.ErroValidacao); ok {
+			nota.Erros = ev.Erros
+		} else {
+			nota.Erros = []string{err.Error()}
+		}
+		return nota, s.repo.Update(nota)
+	}
+
+	prot, err := s.sefaz.Autorizar(ctx, cfg.Endereco.UF, cfg.Ambiente, assinado)
+	if err != nil {
+		// Sem resposta não é possível saber se a nota foi autorizada: a próxima emissão consulta a SEFAZ
+		nota.Status = StatusPendente
+		nota.Motivo = err.Error()
+		if errUpdate := s.repo.Update(nota); errUpdate != nil {
+			logger.ErrorLogger.Printf("Falha ao registrar a NF-e %d pendente: %v", nota.Numero, errUpdate)
+		}
+		return nil, fmt.Errorf("erro na comunicação com a SEFAZ: %w", err)
+	}
+
+	if err := s.aplicarProtocolo(nota, prot); err != nil {
+		return nil, err
+	}
+	return nota, s.repo.Update(nota)
+}
+
+// Consultar atualiza a situação de uma nota pendente junto à SEFAZ
+func (s *Service) Consultar(ctx context.Context, id, empresaID int) (*NotaFiscal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	nota, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if nota.Status != StatusPendente {
+		return nota, nil
+	}
+
+	cfg, err := s.repo.GetConfiguracao(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	return s.resolverPendente(ctx, cfg, nota)
+}
+
+// DANFE gera o documento auxiliar da nota
+func (s *Service) DANFE(id, empresaID int) (*NotaFiscal, []byte, error) {
+	nota, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(nota.XML) == 0 {
+		return nil, nil, errors.New("nota fiscal sem XML gerado")
+	}
+
+	pdf, err := nfe.DANFE(nota.XML)
+	if err != nil {
+		return nil, nil, err
+	}
+	return nota, pdf, nil
+}
+
+// enderecoCobranca converte o endereço de cobrança principal do cliente para o destinatário da nota
+func (s *Service) enderecoCobranca(cli *cliente.Cliente) (Endereco, error) {
+	enderecos, err := s.clientes.ListEnderecos(cli.ID, cli.EmpresaID, cliente.EnderecoCobranca)
+	if err != nil {
+		return Endereco{}, err
+	}
+	if len(enderecos) == 0 {
+		return Endereco{}, errors.New("cliente sem endereço de cobrança cadastrado: informe o endereço do destinatário")
+	}
+
+	e := enderecos[0]
+	return Endereco{
+		Logradouro:      e.Logradouro,
+		Numero:          e.Numero,
+		Complemento:     e.Complemento,
+		Bairro:          e.Bairro,
+		CodigoMunicipio: e.CodigoMunicipio,
+		Municipio:       e.Municipio,
+		UF:              e.UF,
+		CEP:             e.CEP,
+		Telefone:        cli.Telefone,
+	}, nil
+}
+
+// falhar registra o erro na nota, cujo número será reaproveitado na próxima emissão do pedido
+func (s *Service) falhar(nota *NotaFiscal, err error) error {
+	nota.Status = StatusRejeitada
+	nota.CodigoStatus = 0
+	nota.Motivo = err.Error()
+	if errUpdate := s.repo.Update(nota); errUpdate != nil {
+		logger.ErrorLogger.Printf("Falha ao registrar o erro da NF-e %d: %v", nota.Numero, errUpdate)
+	}
+	return err
+}
+
+// resolverPendente consulta a SEFAZ e registra o resultado de uma nota enviada sem resposta
+func (s *Service) resolverPendente(ctx context.Context, cfg *Configuracao, nota *NotaFiscal) (*NotaFiscal, error) {
+	prot, err := s.sefaz.Consultar(ctx, cfg.Endereco.UF, nota.Ambiente, nota.Chave)
+	if err != nil {
+		return nil, fmt.Errorf("erro na consulta à SEFAZ: %w", err)
+	}
+
+	atualizada := *nota
+	if prot.Status == nfe.StatusNaoConsta {
+		// A nota não chegou à SEFAZ: pode ser reenviada com o mesmo número
+		atualizada.Status = StatusRejeitada
+		atualizada.CodigoStatus = prot.Status
+		atualizada.Motivo = prot.Motivo
+	} else if err := s.aplicarProtocolo(&atualizada, prot); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(&atualizada); err != nil {
+		return nil, err
+	}
+	return &atualizada, nil
+}
+
+// aplicarProtocolo registra o retorno da SEFAZ e, se autorizada, guarda o nfeProc
+func (s *Service) aplicarProtocolo(nota *NotaFiscal, prot *nfe.Protocolo) error {
+	nota.CodigoStatus = prot.Status
+	nota.Motivo = prot.Motivo
+
+	if !prot.Autorizada() {
+		nota.Status = StatusRejeitada
+		return nil
+	}
+
+	proc, err := nfe.Processada(nota.XML, prot)
+	if err != nil {
+		return err
+	}
+	nota.XML = proc
+	nota.Status = StatusAutorizada
+	nota.Protocolo = prot.Numero
+	nota.DataAutorizacao = prot.DataRecebimento
+	return nil
+}
+
+// montar converte o pedido na estrutura da NF-e, com rateio de desconto e frete entre os itens
+func (s *Service) montar(cfg *Configuracao, p *pedido.Pedido, cli *cliente.Cliente, in NovaNota, serie, numero int, emissao time.Time) (*nfe.NFe, error) {
+	cUF, err := nfe.CodigoUF(cfg.Endereco.UF)
+	if err != nil {
+		return nil, err
+	}
+
+	dest, err := destinatario(cfg, cli, in)
+	if err != nil {
+		return nil, err
+	}
+	idDest := 1
+	if dest.EnderDest.UF != cfg.Endereco.UF {
+		idDest = 2
+	}
+	indFinal := 0
+	if dest.IndIEDest == 9 {
+		indFinal = 1
+	}
+
+	codigoNumerico, err := codigoAleatorio(numero)
+	if err != nil {
+		return nil, err
+	}
+	chave, dv, err := nfe.Chave(cUF, emissao, cfg.CNPJ, serie, numero, codigoNumerico)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rateio proporcional ao total de cada item; o último item recebe a diferença de arredondamento
+	descontos := ratear(p.Desconto, p.Itens)
+	fretes := ratear(p.Frete, p.Itens)
+
+	var tot totais
+	dets := make([]nfe.Det, 0, len(p.Itens))
+	for i, item := range p.Itens {
+		prod, err := s.produtos.GetByID(item.ProdutoID, p.EmpresaID)
+		if err != nil {
+			return nil, err
+		}
+		if prod.Tipo == produto.TipoServico {
+			return nil, fmt.Errorf("o item %s é um serviço e não pode constar da NF-e", prod.SKU)
+		}
+		if ncm := somenteDigitos(prod.NCM); len(ncm) != 8 {
+			return nil, fmt.Errorf("NCM do produto %s inválido", prod.SKU)
+		}
+
+		vProd := arredondar(item.Quantidade * item.PrecoUnitario)
+		vDesc := arredondar(vProd - item.Total + descontos[i])
+		vFrete := fretes[i]
+		base := arredondar(vProd - vDesc + vFrete)
+
+		imposto, err := tributos(cfg, prod, base, &tot)
+		if err != nil {
+			return nil, err
+		}
+
+		det := nfe.Det{
+			NItem: i + 1,
+			Prod: nfe.Prod{
+				CProd:    prod.SKU,
+				CEAN:     "SEM GTIN",
+				XProd:    item.Descricao,
+				NCM:      somenteDigitos(prod.NCM),
+				CFOP:     cfop(prod.Tributacao.CFOP, idDest),
+				UCom:     item.Unidade,
+				QCom:     nfe.Quantidade(item.Quantidade),
+				VUnCom:   nfe.ValorUnitario(item.PrecoUnitario),
+				VProd:    nfe.Valor(vProd),
+				CEANTrib: "SEM GTIN",
+				UTrib:    item.Unidade,
+				QTrib:    nfe.Quantidade(item.Quantidade),
+				VUnTrib:  nfe.ValorUnitario(item.PrecoUnitario),
+				IndTot:   1,
+			},
+			Imposto: imposto,
+		}
+		if vFrete > 0 {
+			det.Prod.VFrete = nfe.Valor(vFrete)
+		}
+		if vDesc > 0 {
+			det.Prod.VDesc = nfe.Valor(vDesc)
+		}
+		dets = append(dets, det)
+
+		tot.vProd += vProd
+		tot.vDesc += vDesc
+		tot.vFrete += vFrete
+	}
+
+	vNF := arredondar(tot.vProd - tot.vDesc + tot.vFrete + tot.vIPI)
+
+	n := &nfe.NFe{InfNFe: nfe.InfNFe{
+		ID:     "NFe" + chave,
+		Versao: nfe.Versao,
+		Ide: nfe.Ide{
+			CUF:         cUF,
+			CNF:         codigoNumerico,
+			NatOp:       cfg.NaturezaOperacao,
+			Mod:         nfe.Modelo,
+			Serie:       serie,
+			NNF:         numero,
+			DhEmi:       nfe.DataHora(emissao),
+			TpNF:        1,
+			IdDest:      idDest,
+			CMunFG:      cfg.Endereco.CodigoMunicipio,
+			TpImp:       1,
+			TpEmis:      1,
+			CDV:         dv,
+			TpAmb:       cfg.Ambiente,
+			FinNFe:      1,
+			IndFinal:    indFinal,
+			IndPres:     9,
+			IndIntermed: "0",
+			ProcEmi:     0,
+			VerProc:     nfe.VersaoProcesso,
+		},
+		Emit: nfe.Emit{
+			CNPJ:      cfg.CNPJ,
+			XNome:     cfg.RazaoSocial,
+			XFant:     cfg.NomeFantasia,
+			EnderEmit: enderecoNFe(cfg.Endereco),
+			IE:        somenteDigitos(cfg.InscricaoEstadual),
+			CRT:       cfg.CRT,
+		},
+		Dest: dest,
+		Det:  dets,
+		Total: nfe.Total{ICMSTot: nfe.ICMSTot{
+			VBC:        nfe.Valor(tot.vBC),
+			VICMS:      nfe.Valor(tot.vICMS),
+			VICMSDeson: nfe.Valor(0),
+			VFCP:       nfe.Valor(0),
+			VBCST:      nfe.Valor(0),
+			VST:        nfe.Valor(0),
+			VFCPST:     nfe.Valor(0),
+			VFCPSTRet:  nfe.Valor(0),
+			VProd:      nfe.Valor(tot.vProd),
+			VFrete:     nfe.Valor(tot.vFrete),
+			VSeg:       nfe.Valor(0),
+			VDesc:      nfe.Valor(tot.vDesc),
+			VII:        nfe.Valor(0),
+			VIPI:       nfe.Valor(tot.vIPI),
+			VIPIDevol:  nfe.Valor(0),
+			VPIS:       nfe.Valor(tot.vPIS),
+			VCOFINS:    nfe.Valor(tot.vCOFINS),
+			VOutro:     nfe.Valor(0),
+			VNF:        nfe.Valor(vNF),
+		}},
+		Transp: nfe.Transp{ModFrete: nfe.FreteSemOcorrencia},
+		Cobr:   s.cobranca(p),
+		Pag: nfe.Pag{DetPag: []nfe.DetPag{{
+			IndPag: indicadorPagamento(p.CondicaoPagamento),
+			TPag:   cfg.FormaPagamento,
+			VPag:   nfe.Valor(vNF),
+		}}},
+	}}
+	if tot.vFrete > 0 {
+		n.InfNFe.Transp.ModFrete = nfe.FreteEmitente
+	}
+
+	observacoes := []string{fmt.Sprintf("Pedido de venda Nº %d.", p.Numero)}
+	if cfg.CRT == nfe.CRTSimplesNacional {
+		observacoes = append(observacoes, textoSimples)
+	}
+	if in.Observacoes != "" {
+		observacoes = append(observacoes, in.Observacoes)
+	}
+	n.InfNFe.InfAdic = &nfe.InfAdic{InfCpl: strings.Join(observacoes, " ")}
+
+	return n, nil
+}
+
+// cobranca lista as parcelas do título gerado no faturamento como duplicatas da nota
+func (s *Service) cobranca(p *pedido.Pedido) *nfe.Cobr {
+	if p.TituloID == 0 {
+		return nil
+	}
+	titulo, err := s.financeiro.GetTitulo(p.TituloID, p.EmpresaID)
+	if err != nil || len(titulo.Parcelas) == 0 {
+		return nil
+	}
+
+	c := &nfe.Cobr{Fat: nfe.Fat{
+		NFat:  fmt.Sprintf("%d", p.Numero),
+		VOrig: nfe.Valor(titulo.ValorTotal),
+		VDesc: nfe.Valor(0),
+		VLiq:  nfe.Valor(titulo.ValorTotal),
+	}}
+	for _, parcela := range titulo.Parcelas {
+		c.Dup = append(c.Dup, nfe.Dup{
+			NDup:  fmt.Sprintf("%03d", parcela.Numero),
+			DVenc: parcela.Vencimento.Format("2006-01-02"),
+			VDup:  nfe.Valor(parcela.Valor),
+		})
+	}
+	return c
+}
+
+// totais acumula os valores dos tributos dos itens
+type totais struct {
+	vProd, vDesc, vFrete float64
+	vBC, vICMS, vIPI     float64
+	vPIS, vCOFINS        float64
+}
+
+// tributos monta o grupo de impostos do item conforme o regime do emitente e a tributação do produto
+func tributos(cfg *Configuracao, prod *produto.Produto, base float64, tot *totais) (nfe.Imposto, error) {
+	t := prod.Tributacao
+	var imp nfe.Imposto
+
+	if cfg.CRT == nfe.CRTRegimeNormal {
+		switch t.CST {
+		case "00":
+			vICMS := arredondar(base * t.AliquotaICMS / 100)
+			imp.ICMS.ICMS00 = &nfe.ICMS00{Orig: t.Origem, CST: t.CST, ModBC: 3, VBC: nfe.Valor(base), PICMS: nfe.Valor(t.AliquotaICMS), VICMS: nfe.Valor(vICMS)}
+			tot.vBC += base
+			tot.vICMS += vICMS
+		case "40", "41", "50":
+			imp.ICMS.ICMS40 = &nfe.ICMS40{Orig: t.Origem, CST: t.CST}
+		default:
+			return imp, fmt.Errorf("CST do ICMS %q do produto %s não suportado", t.CST, prod.SKU)
+		}
+	} else {
+		csosn := t.CST
+		if csosn == "" {
+			csosn = "102"
+		}
+		switch csosn {
+		case "102", "103", "300", "400":
+			imp.ICMS.ICMSSN102 = &nfe.ICMSSN102{Orig: t.Origem, CSOSN: csosn}
+		default:
+			return imp, fmt.Errorf("CSOSN %q do produto %s não suportado", csosn, prod.SKU)
+		}
+	}
+
+	if t.AliquotaIPI > 0 {
+		vIPI := arredondar(base * t.AliquotaIPI / 100)
+		imp.IPI = &nfe.IPI{CEnq: "999", IPITrib: &nfe.IPITrib{CST: "50", VBC: nfe.Valor(base), PIPI: nfe.Valor(t.AliquotaIPI), VIPI: nfe.Valor(vIPI)}}
+		tot.vIPI += vIPI
+	}
+
+	cstPIS := padraoCST(t.CSTPIS, cfg.CRT)
+	switch classificarCST(cstPIS) {
+	case "aliq":
+		vPIS := arredondar(base * cfg.AliquotaPIS / 100)
+		imp.PIS.PISAliq = &nfe.Contribuicao{CST: cstPIS, VBC: nfe.Valor(base), PPIS: nfe.Valor(cfg.AliquotaPIS), VPIS: nfe.Valor(vPIS)}
+		tot.vPIS += vPIS
+	case "nt":
+		imp.PIS.PISNT = &nfe.ContribuicaoNT{CST: cstPIS}
+	case "outr":
+		imp.PIS.PISOutr = &nfe.Contribuicao{CST: cstPIS, VBC: nfe.Valor(0), PPIS: nfe.Valor(0), VPIS: nfe.Valor(0)}
+	default:
+		return imp, fmt.Errorf("CST do PIS %q do produto %s não suportado", cstPIS, prod.SKU)
+	}
+
+	cstCOFINS := padraoCST(t.CSTCOFINS, cfg.CRT)
+	switch classificarCST(cstCOFINS) {
+	case "aliq":
+		vCOFINS := arredondar(base * cfg.AliquotaCOFINS / 100)
+		imp.COFINS.COFINSAliq = &nfe.ContribuicaoCOFINS{CST: cstCOFINS, VBC: nfe.Valor(base), PCOFINS: nfe.Valor(cfg.AliquotaCOFINS), VCOFINS: nfe.Valor(vCOFINS)}
+		tot.vCOFINS += vCOFINS
+	case "nt":
+		imp.COFINS.COFINSNT = &nfe.ContribuicaoNT{CST: cstCOFINS}
+	case "outr":
+		imp.COFINS.COFINSOutr = &nfe.ContribuicaoCOFINS{CST: cstCOFINS, VBC: nfe.Valor(0), PCOFINS: nfe.Valor(0), VCOFINS: nfe.Valor(0)}
+	default:
+		return imp, fmt.Errorf("CST da COFINS %q do produto %s não suportado", cstCOFINS, prod.SKU)
+	}
+
+	return imp, nil
+}
+
+// padraoCST usa 49 (outras operações) no Simples Nacional e 01 no regime normal quando o produto não informa o CST
+func padraoCST(cst string, crt int) string {
+	if cst != "" {
+		return cst
+	}
+	if crt == nfe.CRTRegimeNormal {
+		return "01"
+	}
+	return "49"
+}
+
+// classificarCST indica o grupo do PIS/COFINS correspondente ao CST
+func classificarCST(cst string) string {
+	switch cst {
+	case "01", "02":
+		return "aliq"
+	case "04", "05", "06", "07", "08", "09":
+		return "nt"
+	case "49", "50", "51", "52", "53", "54", "55", "56", "60", "61", "62", "63", "64", "65", "66", "67",
+		"70", "71", "72", "73", "74", "75", "98", "99":
+		return "outr"
+	}
+	return ""
+}
+
+// destinatario monta a identificação do cliente; em homologação o nome é substituído, como exige a SEFAZ
+func destinatario(cfg *Configuracao, cli *cliente.Cliente, in NovaNota) (*nfe.Dest, error) {
+	in.Destinatario.CEP = somenteDigitos(in.Destinatario.CEP)
+	in.Destinatario.UF = strings.ToUpper(in.Destinatario.UF)
+	if err := validarEndereco(in.Destinatario); err != nil {
+		return nil, fmt.Errorf("endereço do destinatário: %w", err)
+	}
+
+	endereco := enderecoNFe(in.Destinatario)
+	d := &nfe.Dest{XNome: cli.Nome, EnderDest: &endereco, IndIEDest: 9, Email: cli.Email}
+
+	switch {
+	case len(somenteDigitos(cli.CNPJ)) == 14:
+		d.CNPJ = somenteDigitos(cli.CNPJ)
+	case len(somenteDigitos(cli.CPF)) == 11:
+		d.CPF = somenteDigitos(cli.CPF)
+	default:
+		return nil, errors.New("cliente sem CNPJ ou CPF válido")
+	}
+
+	if ie := somenteDigitos(in.InscricaoEstadual); ie != "" {
+		d.IE = ie
+		d.IndIEDest = 1
+	}
+	if cfg.Ambiente == nfe.AmbienteHomologacao {
+		d.XNome = nfe.NomeHomologacao
+	}
+	return d, nil
+}
+
+func validarEndereco(e Endereco) error {
+	if e.Logradouro == "" || e.Numero == "" || e.Bairro == "" || e.Municipio == "" {
+		return errors.New("logradouro, número, bairro e município são obrigatórios")
+	}
+	if len(e.CodigoMunicipio) != 7 || somenteDigitos(e.CodigoMunicipio) != e.CodigoMunicipio {
+		return errors.New("código IBGE do município deve ter 7 dígitos")
+	}
+	if _, err := nfe.CodigoUF(e.UF); err != nil {
+		return err
+	}
+	if e.CEP != "" && len(e.CEP) != 8 {
+		return errors.New("CEP inválido")
+	}
+	return nil
+}
+
+func enderecoNFe(e Endereco) nfe.Endereco {
+	return nfe.Endereco{
+		XLgr:    e.Logradouro,
+		Nro:     e.Numero,
+		XCpl:    e.Complemento,
+		XBairro: e.Bairro,
+		CMun:    e.CodigoMunicipio,
+		XMun:    e.Municipio,
+		UF:      e.UF,
+		CEP:     e.CEP,
+		CPais:   "1058",
+		XPais:   "BRASIL",
+		Fone:    somenteDigitos(e.Telefone),
+	}
+}
+
+// cfop usa o CFOP do produto, trocando o primeiro dígito conforme o destino (5 interna, 6 interestadual)
+func cfop(padrao string, idDest int) string {
+	if len(padrao) != 4 {
+		padrao = "5102"
+	}
+	if idDest == 2 {
+		return "6" + padrao[1:]
+	}
+	return "5" + padrao[1:]
+}
+
+func indicadorPagamento(c pedido.CondicaoPagamento) string {
+	if c.Parcelas <= 1 && c.PrimeiroVencDias == 0 {
+		return "0"
+	}
+	return "1"
+}
+
+// ratear distribui um valor entre os itens proporcionalmente ao total de cada um
+func ratear(valor float64, itens []pedido.Item) []float64 {
+	partes := make([]float64, len(itens))
+	if valor <= 0 || len(itens) == 0 {
+		return partes
+	}
+
+	soma := 0.0
+	for _, item := range itens {
+		soma += item.Total
+	}
+
+	restante := valor
+	for i, item := range itens {
+		if i == len(itens)-1 {
+			partes[i] = arredondar(restante)
+			break
+		}
+		parte := valor / float64(len(itens))
+		if soma > 0 {
+			parte = valor * item.Total / soma
+		}
+		partes[i] = arredondar(parte)
+		restante -= partes[i]
+	}
+	return partes
+}
+
+// codigoAleatorio gera o cNF de 8 dígitos, que não pode repetir o número da nota
+func codigoAleatorio(numero int) (string, error) {
+	for {
+		n, err := rand.Int(rand.Reader, big.NewInt(100000000))
+		if err != nil {
+			return "", err
+		}
+		codigo := fmt.Sprintf("%08d", n.Int64())
+		if codigo != fmt.Sprintf("%08d", numero) {
+			return codigo, nil
+		}
+	}
+}
+
+func somenteDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// atalhosCron são as abreviações aceitas no lugar das cinco partes
+var atalhosCron = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+// Cron é uma expressão de agendamento no formato "minuto hora dia mês dia-da-semana".
+// Cada parte aceita *, números, intervalos (1-5), listas (1,15) e passos (*/10, 8-18/2);
+// o domingo é 0 ou 7. Como no cron tradicional, quando dia e dia da semana são restritos
+// basta um deles coincidir.
+type Cron struct {
+	minutos, horas, dias, meses, semana uint64 // bit n ligado quando o valor n é aceito
+	diaLivre, semanaLivre               bool
+}
+
+// ParseCron interpreta a expressão
+func ParseCron(expressao string) (*Cron, error) {
+	expressao = strings.TrimSpace(expressao)
+	if atalho, ok := atalhosCron[expressao]; ok {
+		expressao = atalho
+	}
+
+	partes := strings.Fields(expressao)
+	if len(partes) != 5 {
+		return nil, errors.New("a expressão cron deve ter 5 partes: minuto hora dia mês dia-da-semana")
+	}
+
+	c := &Cron{diaLivre: partes[2] == "*", semanaLivre: partes[4] == "*"}
+	var err error
+	if c.minutos, err = parteCron(partes[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minuto: %v", err)
+	}
+	if c.horas, err = parteCron(partes[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hora: %v", err)
+	}
+	if c.dias, err = parteCron(partes[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("dia: %v", err)
+	}
+	if c.meses, err = parteCron(partes[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("mês: %v", err)
+	}
+	if c.semana, err = parteCron(partes[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("dia da semana: %v", err)
+	}
+	if c.semana&(1<<7) != 0 {
+		c.semana |= 1
+	}
+	return c, nil
+}
+
+// Proximo retorna o primeiro instante depois de t que satisfaz a expressão, no fuso de t
+func (c *Cron) Proximo(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limite := t.AddDate(5, 0, 0)
+
+	for t.Before(limite) {
+		if c.meses&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !c.diaAceito(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if c.horas&(1<<uint(t.Hour())) == 0 {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if c.minutos&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{} // expressão impossível, como 30 de fevereiro
+}
+
+func (c *Cron) diaAceito(t time.Time) bool {
+	dia := c.dias&(1<<uint(t.Day())) != 0
+	semana := c.semana&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.diaLivre && c.semanaLivre:
+		return true
+	case c.diaLivre:
+		return semana
+	case c.semanaLivre:
+		return dia
+	}
+	return dia || semana
+}
+
+// parteCron converte uma parte da expressão no conjunto de valores aceitos
+func parteCron(parte string, minimo, maximo int) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(parte, ",") {
+		faixa, passo := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			var err error
+			faixa = item[:i]
+			if passo, err = strconv.Atoi(item[i+1:]); err != nil || passo <= 0 {
+				return 0, fmt.Errorf("passo inválido em %q", item)
+			}
+		}
+
+		inicio, fim := minimo, maximo
+		if faixa != "*" {
+			var err error
+			if i := strings.Index(faixa, "-"); i >= 0 {
+				inicio, err = strconv.Atoi(faixa[:i])
+				if err == nil {
+					fim, err = strconv.Atoi(faixa[i+1:])
+				}
+			} else {
+				inicio, err = strconv.Atoi(faixa)
+				fim = inicio
+				if strings.Contains(item, "/") {
+					fim = maximo
+				}
+			}
+			if err != nil {
+				return 0, fmt.Errorf("valor inválido em %q", item)
+			}
+		}
+		if inicio < minimo || fim > maximo || inicio > fim {
+			return 0, fmt.Errorf("%q fora do intervalo %d-%d", item, minimo, maximo)
+		}
+
+		for v := inicio; v <= fim; v += passo {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
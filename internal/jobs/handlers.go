@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP da administração dos jobs
+type Handlers struct {
+	repo     Repository
+	service  *Service
+	usuarios usuario.Repository
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service, usuarios usuario.Repository) *Handlers {
+	return &Handlers{
+		repo:     repo,
+		service:  service,
+		usuarios: usuarios,
+	}
+}
+
+// Routes retorna as rotas de administração dos jobs, restritas a administradores.
+// Cada administrador vê os jobs da sua empresa; o administrador do sistema vê todos, inclusive os do sistema.
+func Routes(repo Repository, service *Service, usuarios usuario.Repository) http.Handler {
+	h := NewHandlers(repo, service, usuarios)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+	r.Use(auth.RequireRole("admin"))
+
+	r.Get("/", h.List)
+	r.Get("/{id}", h.Get)
+	r.Post("/{id}/reexecutar", h.Reexecutar)
+	r.Post("/{id}/cancelar", h.Cancelar)
+
+	return r
+}
+
+// List lista os jobs. Filtros: fila, tipo e status.
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	empresaID, ok := h.escopo(w, r)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+
+	if limit <= 0 {
+		limit = 50 // valor padrão
+	}
+
+	jobs, err := h.repo.List(Filtro{
+		EmpresaID: empresaID,
+		Fila:      q.Get("fila"),
+		Tipo:      q.Get("tipo"),
+		Status:    q.Get("status"),
+	}, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// Get retorna um job por ID
+func (h *Handlers) Get(w http.ResponseWriter, r *http.Request) {
+	empresaID, ok := h.escopo(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	j, err := h.service.buscar(empresaID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(j)
+}
+
+// Reexecutar devolve à fila um job que falhou, morreu ou foi cancelado
+func (h *Handlers) Reexecutar(w http.ResponseWriter, r *http.Request) {
+	h.alterar(w, r, h.service.Reexecutar)
+}
+
+// Cancelar cancela um job ativo
+func (h *Handlers) Cancelar(w http.ResponseWriter, r *http.Request) {
+	h.alterar(w, r, h.service.Cancelar)
+}
+
+func (h *Handlers) alterar(w http.ResponseWriter, r *http.Request, acao func(empresaID int, id int64) (*Job, error)) {
+	empresaID, ok := h.escopo(w, r)
+	if !ok {
+		return
+	}
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	j, err := acao(empresaID, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(j)
+}
+
+// escopo decide pelo cadastro do administrador quais jobs ele alcança: zero para o administrador do
+// sistema, que vê todos, e a empresa dele para os demais. O token não serve, pois sem empresa é
+// lido como da empresa 1.
+func (h *Handlers) escopo(w http.ResponseWriter, r *http.Request) (int, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return 0, false
+	}
+	if auth.AdminGlobal(h.usuarios, user) {
+		return 0, true
+	}
+
+	u, err := h.usuarios.GetByID(user.ID)
+	if err != nil || !u.Status || u.EmpresaID == 0 {
+		http.Error(w, "Acesso negado", http.StatusForbidden)
+		return 0, false
+	}
+	return u.EmpresaID, true
+}
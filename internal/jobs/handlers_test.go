@@ -0,0 +1,99 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// requisitar chama o handler com o usuário no contexto, como faz o Middleware
+func requisitar(h http.HandlerFunc, user auth.User, metodo, caminho string, id int64) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(metodo, caminho, nil)
+	rota := chi.NewRouteContext()
+	rota.URLParams.Add("id", strconv.FormatInt(id, 10))
+	ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rota)
+	ctx = context.WithValue(ctx, auth.UserContextKey, user)
+	rec := httptest.NewRecorder()
+	h(rec, req.WithContext(ctx))
+	return rec
+}
+
+func TestEscopoDosJobs(t *testing.T) {
+	repo := NewMemoryRepository()
+	s := NewService(repo)
+	s.Registrar("teste", "", func(context.Context, *Job) error { return nil })
+	sistema, _ := s.Enfileirar(0, "teste", nil, Opcoes{})
+	acme, _ := s.Enfileirar(1, "teste", nil, Opcoes{})
+	beta, _ := s.Enfileirar(2, "teste", nil, Opcoes{})
+
+	usuarios := usuario.NewMemoryRepository()
+	root := &usuario.Usuario{Nome: "Root", Email: "root@gvero.test", Tipo: "admin", Status: true}
+	ana := &usuario.Usuario{Nome: "Ana", Email: "ana@acme.com", Tipo: "admin", Status: true, EmpresaID: 1}
+	usuarios.Create(root)
+	usuarios.Create(ana)
+	h := NewHandlers(repo, s, usuarios)
+
+	// Sem a empresa no token, o Middleware preenche a empresa 1 para os dois administradores
+	tokenRoot := auth.User{ID: root.ID, Role: "admin", Empresa: 1}
+	tokenAna := auth.User{ID: ana.ID, Role: "admin", Empresa: 1}
+
+	listar := func(user auth.User) map[int64]bool {
+		rec := requisitar(h.List, user, http.MethodGet, "/", 0)
+		var jobs []*Job
+		if err := json.NewDecoder(rec.Body).Decode(&jobs); err != nil {
+			t.Fatalf("lista = %d", rec.Code)
+		}
+		ids := map[int64]bool{}
+		for _, j := range jobs {
+			ids[j.ID] = true
+		}
+		return ids
+	}
+	if ids := listar(tokenRoot); !ids[sistema.ID] || !ids[acme.ID] || !ids[beta.ID] {
+		t.Errorf("administrador do sistema vê %v", ids)
+	}
+	if ids := listar(tokenAna); len(ids) != 1 || !ids[acme.ID] {
+		t.Errorf("administrador da empresa 1 vê %v", ids)
+	}
+
+	for _, id := range []int64{sistema.ID, beta.ID} {
+		if rec := requisitar(h.Get, tokenAna, http.MethodGet, "/", id); rec.Code != http.StatusNotFound {
+			t.Errorf("job %d de fora da empresa = %d", id, rec.Code)
+		}
+		if rec := requisitar(h.Cancelar, tokenAna, http.MethodPost, "/", id); rec.Code != http.StatusBadRequest {
+			t.Errorf("cancelamento do job %d de fora da empresa = %d", id, rec.Code)
+		}
+	}
+
+	if rec := requisitar(h.Cancelar, tokenRoot, http.MethodPost, "/", sistema.ID); rec.Code != http.StatusOK {
+		t.Fatalf("cancelamento do job do sistema = %d %s", rec.Code, rec.Body)
+	}
+	if rec := requisitar(h.Reexecutar, tokenRoot, http.MethodPost, "/", sistema.ID); rec.Code != http.StatusOK {
+		t.Errorf("reexecução do job do sistema = %d %s", rec.Code, rec.Body)
+	}
+
+	// Um administrador desativado perde o acesso, ainda que o token não tenha vencido
+	root.Status = false
+	if rec := requisitar(h.List, tokenRoot, http.MethodGet, "/", 0); rec.Code != http.StatusForbidden {
+		t.Errorf("lista por administrador desativado = %d", rec.Code)
+	}
+}
@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Status dos jobs
+const (
+	StatusPendente   = "pendente"
+	StatusExecutando = "executando"
+	StatusFalhou     = "falhou" // a última execução falhou; haverá nova tentativa
+	StatusConcluido  = "concluido"
+	StatusMorto      = "morto" // tentativas esgotadas
+	StatusCancelado  = "cancelado"
+)
+
+// FilaPadrao recebe os jobs de tipos registrados sem fila
+const FilaPadrao = "padrao"
+
+// Política de novas tentativas: o intervalo dobra a cada falha até o máximo
+const (
+	MaxTentativasPadrao = 5
+	IntervaloInicial    = 10 * time.Second
+	IntervaloMaximo     = time.Hour
+)
+
+// DuracaoReserva é o tempo em que um job em execução fica reservado ao trabalhador.
+// A reserva é renovada durante a execução; se o processo cair, o job volta à fila quando ela vence.
+const DuracaoReserva = 5 * time.Minute
+
+// ErrDuplicado indica que já existe um job ativo do mesmo tipo com a mesma chave
+var ErrDuplicado = errors.New("já existe um job ativo com esta chave")
+
+// Job é uma tarefa executada em segundo plano
+type Job struct {
+	ID            int64           `json:"id"`
+	EmpresaID     int             `json:"empresa_id,omitempty"` // zero para jobs do sistema
+	Fila          string          `json:"fila"`
+	Tipo          string          `json:"tipo"`
+	Payload       json.RawMessage `json:"payload,omitempty"`
+	Chave         string          `json:"chave,omitempty"` // impede dois jobs ativos do mesmo tipo com a mesma chave
+	Status        string          `json:"status"`
+	Tentativas    int             `json:"tentativas"`
+	MaxTentativas int             `json:"max_tentativas"`
+	Erro          string          `json:"erro,omitempty"`
+	ExecutarEm    time.Time       `json:"executar_em"`
+	ReservadoAte  time.Time       `json:"reservado_ate,omitempty"`
+	Trabalhador   string          `json:"trabalhador,omitempty"`
+	DataCriacao   time.Time       `json:"data_criacao"`
+	DataInicio    time.Time       `json:"data_inicio,omitempty"`
+	DataConclusao time.Time       `json:"data_conclusao,omitempty"`
+}
+
+// Ativo indica se o job ainda vai ou pode ser executado
+func (j *Job) Ativo() bool {
+	return j.Status == StatusPendente || j.Status == StatusExecutando || j.Status == StatusFalhou
+}
+
+// Decodificar lê o payload do job
+func (j *Job) Decodificar(v interface{}) error {
+	return json.Unmarshal(j.Payload, v)
+}
+
+// Handler executa um job; o contexto é cancelado quando o job é cancelado ou o encerramento excede o prazo.
+// Retornar erro agenda nova tentativa, por isso os handlers devem tolerar execuções repetidas.
+type Handler func(ctx context.Context, j *Job) error
+
+// Opcoes ajustam o enfileiramento de um job
+type Opcoes struct {
+	Chave         string
+	ExecutarEm    time.Time // zero executa assim que houver trabalhador livre
+	MaxTentativas int
+}
+
+// Filtro restringe a listagem de jobs; campos zerados não filtram
+type Filtro struct {
+	EmpresaID int
+	Fila      string
+	Tipo      string
+	Status    string
+}
+
+// Repository define a interface para a fila de jobs
+type Repository interface {
+	// Create grava o job; retorna ErrDuplicado se houver job ativo do mesmo tipo com a mesma chave
+	Create(j *Job) error
+	GetByID(id int64) (*Job, error)
+	GetAtivoPorChave(tipo, chave string) (*Job, error)
+	Update(j *Job) error
+	List(f Filtro, limit, offset int) ([]*Job, error)
+
+	// Reservar marca como em execução o próximo job vencido das filas, inclusive os de reservas vencidas,
+	// e retorna nil quando não há nenhum. Dois trabalhadores nunca reservam o mesmo job.
+	Reservar(filas []string, trabalhador string, agora time.Time) (*Job, error)
+	// Renovar estende a reserva do job enquanto o trabalhador ainda o executa
+	Renovar(id int64, trabalhador string, ate time.Time) error
+	// Expurgar remove os jobs encerrados antes do instante informado
+	Expurgar(antesDe time.Time) (int, error)
+
+	// AvancarAgenda move a próxima execução do agendamento de prevista para seguinte e informa se
+	// esta chamada o fez; entre várias instâncias, só a que avança enfileira a execução prevista
+	AvancarAgenda(nome string, prevista, seguinte time.Time) (bool, error)
+}
@@ -0,0 +1,230 @@
+package jobs
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória, para desenvolvimento e testes
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	jobs    map[int64]*Job
+	agendas map[string]time.Time
+	nextID  int64
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		jobs:    make(map[int64]*Job),
+		agendas: make(map[string]time.Time),
+		nextID:  1,
+	}
+}
+
+// Create adiciona um novo job
+func (r *MemoryRepository) Create(j *Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if j.Tipo == "" || j.Fila == "" {
+		return errors.New("tipo e fila são obrigatórios")
+	}
+	if j.Chave != "" && r.ativoPorChave(j.Tipo, j.Chave) != nil {
+		return ErrDuplicado
+	}
+
+	j.ID = r.nextID
+	r.nextID++
+	if j.DataCriacao.IsZero() {
+		j.DataCriacao = time.Now()
+	}
+
+	c := *j
+	r.jobs[j.ID] = &c
+	return nil
+}
+
+// GetByID busca um job por ID
+func (r *MemoryRepository) GetByID(id int64) (*Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	j, exists := r.jobs[id]
+	if !exists {
+		return nil, errors.New("job não encontrado")
+	}
+	c := *j
+	return &c, nil
+}
+
+// GetAtivoPorChave busca o job ativo do tipo com a chave
+func (r *MemoryRepository) GetAtivoPorChave(tipo, chave string) (*Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	j := r.ativoPorChave(tipo, chave)
+	if j == nil {
+		return nil, errors.New("job não encontrado")
+	}
+	c := *j
+	return &c, nil
+}
+
+// Update substitui um job existente
+func (r *MemoryRepository) Update(j *Job) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.jobs[j.ID]; !exists {
+		return errors.New("job não encontrado")
+	}
+	if j.Chave != "" && j.Ativo() {
+		if outro := r.ativoPorChave(j.Tipo, j.Chave); outro != nil && outro.ID != j.ID {
+			return ErrDuplicado
+		}
+	}
+
+	c := *j
+	r.jobs[j.ID] = &c
+	return nil
+}
+
+// List retorna os jobs filtrados, dos mais recentes para os mais antigos
+func (r *MemoryRepository) List(f Filtro, limit, offset int) ([]*Job, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Job, 0)
+	for _, j := range r.jobs {
+		if f.EmpresaID > 0 && j.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.Fila != "" && j.Fila != f.Fila {
+			continue
+		}
+		if f.Tipo != "" && j.Tipo != f.Tipo {
+			continue
+		}
+		if f.Status != "" && j.Status != f.Status {
+			continue
+		}
+		c := *j
+		result = append(result, &c)
+	}
+
+	sort.Slice(result, func(i, k int) bool {
+		return result[i].ID > result[k].ID
+	})
+
+	if offset >= len(result) {
+		return []*Job{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// Reservar marca o próximo job vencido das filas como em execução pelo trabalhador
+func (r *MemoryRepository) Reservar(filas []string, trabalhador string, agora time.Time) (*Job, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var proximo *Job
+	for _, j := range r.jobs {
+		if !contem(filas, j.Fila) || !disponivel(j, agora) {
+			continue
+		}
+		if proximo == nil || j.ExecutarEm.Before(proximo.ExecutarEm) ||
+			(j.ExecutarEm.Equal(proximo.ExecutarEm) && j.ID < proximo.ID) {
+			proximo = j
+		}
+	}
+	if proximo == nil {
+		return nil, nil
+	}
+
+	proximo.Status = StatusExecutando
+	proximo.Tentativas++
+	proximo.Trabalhador = trabalhador
+	proximo.ReservadoAte = agora.Add(DuracaoReserva)
+	proximo.DataInicio = agora
+
+	c := *proximo
+	return &c, nil
+}
+
+// Renovar estende a reserva do job, se ele ainda pertence ao trabalhador
+func (r *MemoryRepository) Renovar(id int64, trabalhador string, ate time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	j, exists := r.jobs[id]
+	if !exists || j.Status != StatusExecutando || j.Trabalhador != trabalhador {
+		return errors.New("job não está reservado para este trabalhador")
+	}
+	j.ReservadoAte = ate
+	return nil
+}
+
+// Expurgar remove os jobs encerrados antes do instante informado
+func (r *MemoryRepository) Expurgar(antesDe time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for id, j := range r.jobs {
+		if !j.Ativo() && j.DataConclusao.Before(antesDe) {
+			delete(r.jobs, id)
+			total++
+		}
+	}
+	return total, nil
+}
+
+// AvancarAgenda avança o agendamento se a próxima execução gravada não passa da prevista
+func (r *MemoryRepository) AvancarAgenda(nome string, prevista, seguinte time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if atual, ok := r.agendas[nome]; ok && atual.After(prevista) {
+		return false, nil
+	}
+	r.agendas[nome] = seguinte
+	return true, nil
+}
+
+func (r *MemoryRepository) ativoPorChave(tipo, chave string) *Job {
+	for _, j := range r.jobs {
+		if j.Tipo == tipo && j.Chave == chave && j.Ativo() {
+			return j
+		}
+	}
+	return nil
+}
+
+// disponivel indica se o job pode ser reservado: vencido e na fila, ou em execução com a reserva vencida
+func disponivel(j *Job, agora time.Time) bool {
+	switch j.Status {
+	case StatusPendente, StatusFalhou:
+		return !j.ExecutarEm.After(agora)
+	case StatusExecutando:
+		return j.ReservadoAte.Before(agora)
+	}
+	return false
+}
+
+func contem(lista []string, valor string) bool {
+	for _, v := range lista {
+		if v == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package jobs
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Trabalhadores concorrentes nunca reservam o mesmo job
+func TestReservarUmaVez(t *testing.T) {
+	repo := NewMemoryRepository()
+	agora := time.Now()
+	const total = 200
+	for i := 0; i < total; i++ {
+		repo.Create(&Job{Fila: FilaPadrao, Tipo: "teste", Status: StatusPendente, MaxTentativas: 1, ExecutarEm: agora})
+	}
+
+	var mu sync.Mutex
+	reservas := map[int64]string{}
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(trabalhador string) {
+			defer wg.Done()
+			for {
+				j, err := repo.Reservar([]string{FilaPadrao}, trabalhador, agora)
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if j == nil {
+					return
+				}
+				mu.Lock()
+				if outro, ok := reservas[j.ID]; ok {
+					t.Errorf("job %d reservado por %s e %s", j.ID, outro, trabalhador)
+				}
+				reservas[j.ID] = trabalhador
+				mu.Unlock()
+			}
+		}("t" + strconv.Itoa(w))
+	}
+	wg.Wait()
+
+	if len(reservas) != total {
+		t.Errorf("%d de %d jobs reservados", len(reservas), total)
+	}
+}
+
+// A reserva vencida devolve o job à fila; a renovação pertence só ao trabalhador que o reservou
+func TestReservaVencida(t *testing.T) {
+	repo := NewMemoryRepository()
+	agora := time.Now()
+	repo.Create(&Job{Fila: FilaPadrao, Tipo: "teste", Status: StatusPendente, MaxTentativas: 3, ExecutarEm: agora})
+
+	j, _ := repo.Reservar([]string{FilaPadrao}, "a", agora)
+	if j == nil {
+		t.Fatal("job não reservado")
+	}
+	if outro, _ := repo.Reservar([]string{FilaPadrao}, "b", agora.Add(time.Minute)); outro != nil {
+		t.Fatal("job reservado durante a reserva de outro trabalhador")
+	}
+	if err := repo.Renovar(j.ID, "b", agora.Add(time.Hour)); err == nil {
+		t.Error("reserva renovada por outro trabalhador")
+	}
+
+	depois, _ := repo.Reservar([]string{FilaPadrao}, "b", agora.Add(DuracaoReserva+time.Second))
+	if depois == nil || depois.ID != j.ID || depois.Trabalhador != "b" || depois.Tentativas != 2 {
+		t.Errorf("job após a reserva vencida = %+v", depois)
+	}
+}
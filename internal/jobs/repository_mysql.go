@@ -0,0 +1,309 @@
+package jobs
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// esquemaMySQL cria a tabela da fila. chave_ativa repete a chave enquanto o job está ativo
+// e fica nula depois: o índice único impede duplicados ativos sem bloquear o histórico.
+// A reserva usa SKIP LOCKED, disponível a partir do MySQL 8.0.
+const esquemaMySQL = `CREATE TABLE IF NOT EXISTS jobs (
+	id BIGINT AUTO_INCREMENT PRIMARY KEY,
+	empresa_id INT NOT NULL DEFAULT 0,
+	fila VARCHAR(64) NOT NULL,
+	tipo VARCHAR(128) NOT NULL,
+	payload MEDIUMBLOB NULL,
+	chave VARCHAR(191) NULL,
+	chave_ativa VARCHAR(191) NULL,
+	status VARCHAR(16) NOT NULL,
+	tentativas INT NOT NULL DEFAULT 0,
+	max_tentativas INT NOT NULL,
+	erro TEXT NULL,
+	executar_em DATETIME(6) NOT NULL,
+	reservado_ate DATETIME(6) NULL,
+	trabalhador VARCHAR(128) NULL,
+	data_criacao DATETIME(6) NOT NULL,
+	data_inicio DATETIME(6) NULL,
+	data_conclusao DATETIME(6) NULL,
+	UNIQUE KEY uk_jobs_chave_ativa (tipo, chave_ativa),
+	KEY ix_jobs_fila (fila, status, executar_em),
+	KEY ix_jobs_empresa (empresa_id, id)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+
+// esquemaAgendasMySQL guarda a próxima execução de cada agendamento, disputada entre as instâncias
+const esquemaAgendasMySQL = `CREATE TABLE IF NOT EXISTS jobs_agendas (
+	nome VARCHAR(128) PRIMARY KEY,
+	proxima DATETIME(6) NOT NULL
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+
+// colunas lidas por scanJob, na ordem
+const colunas = `id, empresa_id, fila, tipo, payload, chave, status, tentativas, max_tentativas, erro,
+	executar_em, reservado_ate, trabalhador, data_criacao, data_inicio, data_conclusao`
+
+// erroChaveDuplicada é o código do MySQL para violação de índice único
+const erroChaveDuplicada = 1062
+
+// MySQLRepository implementa Repository numa tabela do MySQL, compartilhada entre as instâncias da aplicação
+type MySQLRepository struct {
+	db *sql.DB
+}
+
+// NewMySQLRepository cria o repositório sobre a conexão informada
+func NewMySQLRepository(db *sql.DB) *MySQLRepository {
+	return &MySQLRepository{db: db}
+}
+
+// Migrar cria as tabelas da fila, se ainda não existirem
+func (r *MySQLRepository) Migrar() error {
+	for _, ddl := range []string{esquemaMySQL, esquemaAgendasMySQL} {
+		if _, err := r.db.Exec(ddl); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Create grava um novo job
+func (r *MySQLRepository) Create(j *Job) error {
+	if j.Tipo == "" || j.Fila == "" {
+		return errors.New("tipo e fila são obrigatórios")
+	}
+	if j.DataCriacao.IsZero() {
+		j.DataCriacao = time.Now()
+	}
+
+	res, err := r.db.Exec(`INSERT INTO jobs (empresa_id, fila, tipo, payload, chave, chave_ativa, status,
+		tentativas, max_tentativas, erro, executar_em, reservado_ate, trabalhador, data_criacao, data_inicio, data_conclusao)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		j.EmpresaID, j.Fila, j.Tipo, []byte(j.Payload), textoNulo(j.Chave), chaveAtiva(j), j.Status,
+		j.Tentativas, j.MaxTentativas, textoNulo(j.Erro), j.ExecutarEm.UTC(), tempoNulo(j.ReservadoAte),
+		textoNulo(j.Trabalhador), j.DataCriacao.UTC(), tempoNulo(j.DataInicio), tempoNulo(j.DataConclusao))
+	if err != nil {
+		return traduzirErro(err)
+	}
+
+	j.ID, err = res.LastInsertId()
+	return err
+}
+
+// GetByID busca um job por ID
+func (r *MySQLRepository) GetByID(id int64) (*Job, error) {
+	j, err := scanJob(r.db.QueryRow(`SELECT `+colunas+` FROM jobs WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("job não encontrado")
+	}
+	return j, err
+}
+
+// GetAtivoPorChave busca o job ativo do tipo com a chave
+func (r *MySQLRepository) GetAtivoPorChave(tipo, chave string) (*Job, error) {
+	j, err := scanJob(r.db.QueryRow(`SELECT `+colunas+` FROM jobs WHERE tipo = ? AND chave_ativa = ?`, tipo, chave))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("job não encontrado")
+	}
+	return j, err
+}
+
+// Update grava o estado do job
+func (r *MySQLRepository) Update(j *Job) error {
+	res, err := r.db.Exec(`UPDATE jobs SET status = ?, chave_ativa = ?, tentativas = ?, max_tentativas = ?, erro = ?,
+		executar_em = ?, reservado_ate = ?, trabalhador = ?, data_inicio = ?, data_conclusao = ? WHERE id = ?`,
+		j.Status, chaveAtiva(j), j.Tentativas, j.MaxTentativas, textoNulo(j.Erro), j.ExecutarEm.UTC(),
+		tempoNulo(j.ReservadoAte), textoNulo(j.Trabalhador), tempoNulo(j.DataInicio), tempoNulo(j.DataConclusao), j.ID)
+	if err != nil {
+		return traduzirErro(err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		if _, err := r.GetByID(j.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// List retorna os jobs filtrados, dos mais recentes para os mais antigos
+func (r *MySQLRepository) List(f Filtro, limit, offset int) ([]*Job, error) {
+	var condicoes []string
+	var args []interface{}
+	if f.EmpresaID > 0 {
+		condicoes = append(condicoes, "empresa_id = ?")
+		args = append(args, f.EmpresaID)
+	}
+	if f.Fila != "" {
+		condicoes = append(condicoes, "fila = ?")
+		args = append(args, f.Fila)
+	}
+	if f.Tipo != "" {
+		condicoes = append(condicoes, "tipo = ?")
+		args = append(args, f.Tipo)
+	}
+	if f.Status != "" {
+		condicoes = append(condicoes, "status = ?")
+		args = append(args, f.Status)
+	}
+
+	query := `SELECT ` + colunas + ` FROM jobs`
+	if len(condicoes) > 0 {
+		query += ` WHERE ` + strings.Join(condicoes, " AND ")
+	}
+	query += ` ORDER BY id DESC`
+	if limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, limit, offset)
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]*Job, 0)
+	for rows.Next() {
+		j, err := scanJob(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, j)
+	}
+	return result, rows.Err()
+}
+
+// Reservar marca o próximo job vencido das filas como em execução pelo trabalhador.
+// SKIP LOCKED faz as instâncias concorrentes pularem o job já travado por outra.
+func (r *MySQLRepository) Reservar(filas []string, trabalhador string, agora time.Time) (*Job, error) {
+	if len(filas) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	args := make([]interface{}, 0, len(filas)+2)
+	for _, f := range filas {
+		args = append(args, f)
+	}
+	args = append(args, agora.UTC(), agora.UTC())
+
+	var id int64
+	err = tx.QueryRow(`SELECT id FROM jobs
+		WHERE fila IN (?`+strings.Repeat(", ?", len(filas)-1)+`)
+		AND ((status IN ('`+StatusPendente+`', '`+StatusFalhou+`') AND executar_em <= ?)
+			OR (status = '`+StatusExecutando+`' AND reservado_ate < ?))
+		ORDER BY executar_em, id LIMIT 1 FOR UPDATE SKIP LOCKED`, args...).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.Exec(`UPDATE jobs SET status = ?, tentativas = tentativas + 1, trabalhador = ?,
+		reservado_ate = ?, data_inicio = ? WHERE id = ?`,
+		StatusExecutando, trabalhador, agora.Add(DuracaoReserva).UTC(), agora.UTC(), id); err != nil {
+		return nil, err
+	}
+
+	j, err := scanJob(tx.QueryRow(`SELECT `+colunas+` FROM jobs WHERE id = ?`, id))
+	if err != nil {
+		return nil, err
+	}
+	return j, tx.Commit()
+}
+
+// Renovar estende a reserva do job, se ele ainda pertence ao trabalhador
+func (r *MySQLRepository) Renovar(id int64, trabalhador string, ate time.Time) error {
+	res, err := r.db.Exec(`UPDATE jobs SET reservado_ate = ? WHERE id = ? AND status = ? AND trabalhador = ?`,
+		ate.UTC(), id, StatusExecutando, trabalhador)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return errors.New("job não está reservado para este trabalhador")
+	}
+	return nil
+}
+
+// Expurgar remove os jobs encerrados antes do instante informado
+func (r *MySQLRepository) Expurgar(antesDe time.Time) (int, error) {
+	res, err := r.db.Exec(`DELETE FROM jobs WHERE status IN (?, ?, ?) AND data_conclusao < ?`,
+		StatusConcluido, StatusMorto, StatusCancelado, antesDe.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
+
+// AvancarAgenda avança o agendamento se a próxima execução gravada não passa da prevista.
+// A atualização condicional garante que só uma instância avance cada execução.
+func (r *MySQLRepository) AvancarAgenda(nome string, prevista, seguinte time.Time) (bool, error) {
+	if _, err := r.db.Exec(`INSERT IGNORE INTO jobs_agendas (nome, proxima) VALUES (?, ?)`, nome, prevista.UTC()); err != nil {
+		return false, err
+	}
+	res, err := r.db.Exec(`UPDATE jobs_agendas SET proxima = ? WHERE nome = ? AND proxima <= ?`,
+		seguinte.UTC(), nome, prevista.UTC())
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// scanJob lê uma linha com as colunas de colunas
+func scanJob(row interface{ Scan(...interface{}) error }) (*Job, error) {
+	var j Job
+	var payload []byte
+	var chave, erro, trabalhador sql.NullString
+	var reservado, inicio, conclusao sql.NullTime
+	if err := row.Scan(&j.ID, &j.EmpresaID, &j.Fila, &j.Tipo, &payload, &chave, &j.Status, &j.Tentativas,
+		&j.MaxTentativas, &erro, &j.ExecutarEm, &reservado, &trabalhador, &j.DataCriacao, &inicio, &conclusao); err != nil {
+		return nil, err
+	}
+
+	if len(payload) > 0 {
+		j.Payload = payload
+	}
+	j.Chave, j.Erro, j.Trabalhador = chave.String, erro.String, trabalhador.String
+	j.ReservadoAte, j.DataInicio, j.DataConclusao = reservado.Time, inicio.Time, conclusao.Time
+	return &j, nil
+}
+
+// chaveAtiva retorna o valor da coluna chave_ativa: a chave enquanto o job está ativo
+func chaveAtiva(j *Job) interface{} {
+	if j.Chave == "" || !j.Ativo() {
+		return nil
+	}
+	return j.Chave
+}
+
+func textoNulo(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func tempoNulo(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t.UTC()
+}
+
+// traduzirErro converte a violação do índice de chave ativa em ErrDuplicado
+func traduzirErro(err error) error {
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && me.Number == erroChaveDuplicada {
+		return ErrDuplicado
+	}
+	return err
+}
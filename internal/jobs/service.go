@@ -0,0 +1,477 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// TipoExpurgo remove os jobs encerrados há mais tempo que a retenção
+const TipoExpurgo = "jobs.expurgar"
+
+// intervaloConsulta é a espera entre consultas à fila quando não há aviso de novos jobs
+const intervaloConsulta = 5 * time.Second
+
+// esperaInterrupcao é quanto o encerramento espera os jobs cancelados voltarem à fila
+const esperaInterrupcao = 5 * time.Second
+
+// tipoJob é um tipo de job registrado
+type tipoJob struct {
+	fila    string
+	handler Handler
+}
+
+// agenda é um agendamento recorrente
+type agenda struct {
+	nome    string
+	cron    *Cron
+	tipo    string
+	payload interface{}
+	proxima time.Time
+}
+
+// Service enfileira os jobs e os executa nos trabalhadores de cada fila
+type Service struct {
+	repo        Repository
+	trabalhador string
+	agora       func() time.Time
+
+	mu          sync.Mutex
+	tipos       map[string]tipoJob
+	filas       map[string]int // concorrência de cada fila
+	avisos      map[string]chan struct{}
+	agendas     []*agenda
+	executando  map[int64]context.CancelFunc
+	iniciado    bool
+	parar       context.CancelFunc // interrompe a reserva de novos jobs
+	interromper context.CancelFunc // cancela os jobs em execução
+	contexto    context.Context    // contexto dos jobs em execução
+	wg          sync.WaitGroup
+}
+
+// NewService cria o serviço de jobs; o trabalhador é identificado pelo host e pelo processo
+func NewService(repo Repository) *Service {
+	host, _ := os.Hostname()
+	return &Service{
+		repo:        repo,
+		trabalhador: fmt.Sprintf("%s:%d", host, os.Getpid()),
+		agora:       time.Now,
+		tipos:       make(map[string]tipoJob),
+		filas:       map[string]int{FilaPadrao: 1},
+		avisos:      make(map[string]chan struct{}),
+		executando:  make(map[int64]context.CancelFunc),
+	}
+}
+
+// Fila define quantos jobs da fila são executados ao mesmo tempo por esta instância
+func (s *Service) Fila(nome string, concorrencia int) {
+	if concorrencia < 1 {
+		concorrencia = 1
+	}
+	s.mu.Lock()
+	s.filas[nome] = concorrencia
+	s.mu.Unlock()
+}
+
+// Registrar associa o handler ao tipo de job; fila vazia usa a FilaPadrao
+func (s *Service) Registrar(tipo, fila string, h Handler) {
+	if fila == "" {
+		fila = FilaPadrao
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tipos[tipo] = tipoJob{fila: fila, handler: h}
+	if _, ok := s.filas[fila]; !ok {
+		s.filas[fila] = 1
+	}
+}
+
+// Agendar enfileira o job periodicamente conforme a expressão cron, no horário local.
+// Uma execução não começa enquanto a anterior do mesmo agendamento estiver ativa.
+func (s *Service) Agendar(nome, expressao, tipo string, payload interface{}) error {
+	c, err := ParseCron(expressao)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.tipos[tipo]; !ok {
+		return fmt.Errorf("tipo de job não registrado: %s", tipo)
+	}
+	s.agendas = append(s.agendas, &agenda{nome: nome, cron: c, tipo: tipo, payload: payload})
+	return nil
+}
+
+// Enfileirar grava um job do tipo registrado. Com chave, se já houver job ativo do mesmo tipo
+// e chave, ele é retornado no lugar de um novo.
+func (s *Service) Enfileirar(empresaID int, tipo string, payload interface{}, op Opcoes) (*Job, error) {
+	s.mu.Lock()
+	t, ok := s.tipos[tipo]
+	s.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("tipo de job não registrado: %s", tipo)
+	}
+
+	j := &Job{
+		EmpresaID:     empresaID,
+		Fila:          t.fila,
+		Tipo:          tipo,
+		Chave:         op.Chave,
+		Status:        StatusPendente,
+		MaxTentativas: op.MaxTentativas,
+		ExecutarEm:    op.ExecutarEm,
+		DataCriacao:   s.agora(),
+	}
+	if payload != nil {
+		dados, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		j.Payload = dados
+	}
+	if j.MaxTentativas <= 0 {
+		j.MaxTentativas = MaxTentativasPadrao
+	}
+	if j.ExecutarEm.IsZero() {
+		j.ExecutarEm = j.DataCriacao
+	}
+
+	if err := s.repo.Create(j); err != nil {
+		if errors.Is(err, ErrDuplicado) {
+			return s.repo.GetAtivoPorChave(tipo, op.Chave)
+		}
+		return nil, err
+	}
+
+	s.avisar(t.fila)
+	return j, nil
+}
+
+// Reexecutar devolve à fila, para execução imediata e com as tentativas zeradas, um job que falhou,
+// morreu ou foi cancelado
+func (s *Service) Reexecutar(empresaID int, id int64) (*Job, error) {
+	j, err := s.buscar(empresaID, id)
+	if err != nil {
+		return nil, err
+	}
+	if j.Status != StatusFalhou && j.Status != StatusMorto && j.Status != StatusCancelado {
+		return nil, errors.New("só jobs com falha, mortos ou cancelados podem ser reexecutados")
+	}
+
+	j.Status = StatusPendente
+	j.Tentativas = 0
+	j.ExecutarEm = s.agora()
+	j.ReservadoAte = time.Time{}
+	j.DataConclusao = time.Time{}
+	if err := s.repo.Update(j); err != nil {
+		return nil, err
+	}
+
+	s.avisar(j.Fila)
+	return j, nil
+}
+
+// Cancelar impede novas execuções do job; se ele estiver em execução nesta instância, seu contexto é cancelado
+func (s *Service) Cancelar(empresaID int, id int64) (*Job, error) {
+	j, err := s.buscar(empresaID, id)
+	if err != nil {
+		return nil, err
+	}
+	if !j.Ativo() {
+		return nil, errors.New("o job já foi encerrado")
+	}
+
+	j.Status = StatusCancelado
+	j.ReservadoAte = time.Time{}
+	j.DataConclusao = s.agora()
+	if err := s.repo.Update(j); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if cancelar, ok := s.executando[j.ID]; ok {
+		cancelar()
+	}
+	s.mu.Unlock()
+	return j, nil
+}
+
+// Expurgo retorna o handler de TipoExpurgo, que remove os jobs encerrados há mais que a retenção
+func (s *Service) Expurgo(retencao time.Duration) Handler {
+	return func(ctx context.Context, j *Job) error {
+		n, err := s.repo.Expurgar(s.agora().Add(-retencao))
+		if err != nil {
+			return err
+		}
+		logger.InfoLogger.Printf("%d jobs encerrados expurgados", n)
+		return nil
+	}
+}
+
+// Iniciar sobe os trabalhadores de cada fila e o agendador
+func (s *Service) Iniciar() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.iniciado {
+		return
+	}
+	s.iniciado = true
+
+	var ctx context.Context
+	ctx, s.parar = context.WithCancel(context.Background())
+	s.contexto, s.interromper = context.WithCancel(context.Background())
+
+	for fila, concorrencia := range s.filas {
+		if _, ok := s.avisos[fila]; !ok {
+			s.avisos[fila] = make(chan struct{}, 1)
+		}
+		for i := 0; i < concorrencia; i++ {
+			s.wg.Add(1)
+			go s.trabalhar(ctx, fila)
+		}
+	}
+
+	s.wg.Add(1)
+	go s.agendar(ctx)
+}
+
+// Parar deixa de reservar jobs e espera os em execução terminarem. Se o contexto vencer antes,
+// os jobs restantes são cancelados e voltam à fila sem consumir tentativa.
+func (s *Service) Parar(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.iniciado {
+		s.mu.Unlock()
+		return nil
+	}
+	s.iniciado = false
+	s.parar()
+	s.mu.Unlock()
+
+	fim := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(fim)
+	}()
+
+	select {
+	case <-fim:
+		s.interromper()
+		return nil
+	case <-ctx.Done():
+		s.interromper()
+		// Espera os handlers atenderem o cancelamento e devolverem os jobs à fila
+		select {
+		case <-fim:
+		case <-time.After(esperaInterrupcao):
+		}
+		return ctx.Err()
+	}
+}
+
+// trabalhar reserva e executa os jobs da fila até o contexto ser cancelado
+func (s *Service) trabalhar(ctx context.Context, fila string) {
+	defer s.wg.Done()
+
+	for ctx.Err() == nil {
+		j, err := s.repo.Reservar([]string{fila}, s.trabalhador, s.agora())
+		if err != nil {
+			logger.ErrorLogger.Printf("Erro ao reservar job da fila %s: %v", fila, err)
+		}
+		if j != nil {
+			s.executar(j)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-s.avisos[fila]:
+		case <-time.After(intervaloConsulta):
+		}
+	}
+}
+
+// executar roda o handler do job, renovando a reserva enquanto ele executa, e grava o resultado
+func (s *Service) executar(j *Job) {
+	ctx, cancelar := context.WithCancel(s.contexto)
+	defer cancelar()
+
+	s.mu.Lock()
+	t, ok := s.tipos[j.Tipo]
+	s.executando[j.ID] = cancelar
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.executando, j.ID)
+		s.mu.Unlock()
+	}()
+
+	renovacao := make(chan struct{})
+	defer close(renovacao)
+	go s.renovar(j.ID, cancelar, renovacao)
+
+	var err error
+	if ok {
+		err = chamar(ctx, t.handler, j)
+	} else {
+		err = fmt.Errorf("tipo de job não registrado: %s", j.Tipo)
+	}
+
+	// O job pode ter sido cancelado durante a execução
+	if atual, e := s.repo.GetByID(j.ID); e == nil && atual.Status == StatusCancelado {
+		return
+	}
+
+	agora := s.agora()
+	j.ReservadoAte = time.Time{}
+	switch {
+	case err != nil && s.contexto.Err() != nil:
+		// Interrompido pelo encerramento: volta à fila sem consumir a tentativa
+		j.Status = StatusPendente
+		j.Tentativas--
+		j.ExecutarEm = agora
+		j.Erro = "interrompido pelo encerramento da aplicação"
+	case err == nil:
+		j.Status = StatusConcluido
+		j.Erro = ""
+		j.DataConclusao = agora
+	case j.Tentativas >= j.MaxTentativas:
+		j.Status = StatusMorto
+		j.Erro = err.Error()
+		j.DataConclusao = agora
+		logger.ErrorLogger.Printf("Job %d (%s) desistido após %d tentativas: %v", j.ID, j.Tipo, j.Tentativas, err)
+	default:
+		j.Status = StatusFalhou
+		j.Erro = err.Error()
+		j.ExecutarEm = agora.Add(intervaloTentativa(j.Tentativas))
+	}
+
+	if err := s.repo.Update(j); err != nil {
+		logger.ErrorLogger.Printf("Erro ao gravar o resultado do job %d: %v", j.ID, err)
+	}
+}
+
+// renovar estende a reserva do job até o fim da execução; se a reserva for perdida
+// (job cancelado ou assumido por outra instância), cancela a execução local
+func (s *Service) renovar(id int64, cancelar context.CancelFunc, fim <-chan struct{}) {
+	ticker := time.NewTicker(DuracaoReserva / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-fim:
+			return
+		case <-ticker.C:
+			if err := s.repo.Renovar(id, s.trabalhador, s.agora().Add(DuracaoReserva)); err != nil {
+				logger.ErrorLogger.Printf("Reserva do job %d perdida: %v", id, err)
+				cancelar()
+				return
+			}
+		}
+	}
+}
+
+// agendar enfileira as execuções dos agendamentos quando vencem
+func (s *Service) agendar(ctx context.Context) {
+	defer s.wg.Done()
+
+	s.mu.Lock()
+	agendas := append([]*agenda(nil), s.agendas...)
+	s.mu.Unlock()
+
+	agora := s.agora()
+	for _, a := range agendas {
+		a.proxima = a.cron.Proximo(agora)
+	}
+
+	for {
+		espera := time.Minute
+		for _, a := range agendas {
+			if d := a.proxima.Sub(s.agora()); !a.proxima.IsZero() && d < espera {
+				espera = d
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(espera):
+		}
+
+		agora := s.agora()
+		for _, a := range agendas {
+			if a.proxima.IsZero() || a.proxima.After(agora) {
+				continue
+			}
+			prevista := a.proxima
+			a.proxima = a.cron.Proximo(agora)
+
+			avancou, err := s.repo.AvancarAgenda(a.nome, prevista, a.proxima)
+			if err != nil {
+				logger.ErrorLogger.Printf("Erro ao avançar o agendamento %s: %v", a.nome, err)
+				continue
+			}
+			if !avancou {
+				continue // outra instância já enfileirou esta execução
+			}
+			if _, err := s.Enfileirar(0, a.tipo, a.payload, Opcoes{Chave: "agenda:" + a.nome}); err != nil {
+				logger.ErrorLogger.Printf("Erro ao enfileirar o agendamento %s: %v", a.nome, err)
+			}
+		}
+	}
+}
+
+// buscar retorna o job da empresa; administradores sem empresa (zero) acessam todos os jobs
+func (s *Service) buscar(empresaID int, id int64) (*Job, error) {
+	j, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if empresaID != 0 && j.EmpresaID != empresaID {
+		return nil, errors.New("job não encontrado")
+	}
+	return j, nil
+}
+
+// avisar acorda um trabalhador da fila sem bloquear
+func (s *Service) avisar(fila string) {
+	s.mu.Lock()
+	aviso, ok := s.avisos[fila]
+	s.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case aviso <- struct{}{}:
+	default:
+	}
+}
+
+// chamar executa o handler convertendo pânicos em erro
+func chamar(ctx context.Context, h Handler, j *Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("pânico no job: %v", r)
+		}
+	}()
+	return h(ctx, j)
+}
+
+// intervaloTentativa retorna a espera após a n-ésima falha: o intervalo inicial dobra a cada falha
+func intervaloTentativa(falhas int) time.Duration {
+	intervalo := IntervaloInicial
+	for i := 1; i < falhas; i++ {
+		intervalo *= 2
+		if intervalo >= IntervaloMaximo {
+			return IntervaloMaximo
+		}
+	}
+	return intervalo
+}
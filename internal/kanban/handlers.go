@@ -0,0 +1,297 @@
+package kanban
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP dos quadros de negócios, projetos e tarefas
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas dos quadros
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Route("/negocios", func(r chi.Router) {
+		r.Get("/", h.ListNegocios)
+		r.Post("/", h.SalvarNegocio)
+		r.Get("/{id}", h.GetNegocio)
+		r.Put("/{id}", h.SalvarNegocio)
+		r.Delete("/{id}", h.DeleteNegocio)
+	})
+
+	r.Route("/projetos", func(r chi.Router) {
+		r.Get("/", h.ListProjetos)
+		r.Post("/", h.SalvarProjeto)
+		r.Get("/{id}", h.GetProjeto)
+		r.Put("/{id}", h.SalvarProjeto)
+		r.Delete("/{id}", h.DeleteProjeto)
+	})
+
+	r.Route("/tarefas", func(r chi.Router) {
+		r.Get("/", h.ListTarefas)
+		r.Post("/", h.SalvarTarefa)
+		r.Get("/{id}", h.GetTarefa)
+		r.Put("/{id}", h.SalvarTarefa)
+		r.Delete("/{id}", h.DeleteTarefa)
+	})
+
+	return r
+}
+
+// ListNegocios lista os negócios da empresa
+func (h *Handlers) ListNegocios(w http.ResponseWriter, r *http.Request) {
+	f, ok := filtro(w, r)
+	if !ok {
+		return
+	}
+	negocios, err := h.repo.ListNegocios(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	responder(w, http.StatusOK, negocios)
+}
+
+// GetNegocio retorna um negócio
+func (h *Handlers) GetNegocio(w http.ResponseWriter, r *http.Request) {
+	empresaID, id, ok := identificar(w, r)
+	if !ok {
+		return
+	}
+	n, err := h.repo.GetNegocio(id, empresaID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	responder(w, http.StatusOK, n)
+}
+
+// SalvarNegocio cria (POST) ou atualiza (PUT) um negócio
+func (h *Handlers) SalvarNegocio(w http.ResponseWriter, r *http.Request) {
+	var n Negocio
+	empresaID, autorID, id, ok := decodificar(w, r, &n)
+	if !ok {
+		return
+	}
+	n.ID, n.EmpresaID, n.AtualizadoPor = id, empresaID, autorID
+	salvo, err := h.service.SalvarNegocio(&n)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	responder(w, statusSalvo(id), salvo)
+}
+
+// DeleteNegocio remove um negócio
+func (h *Handlers) DeleteNegocio(w http.ResponseWriter, r *http.Request) {
+	empresaID, id, ok := identificar(w, r)
+	if !ok {
+		return
+	}
+	if err := h.repo.DeleteNegocio(id, empresaID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListProjetos lista os projetos da empresa
+func (h *Handlers) ListProjetos(w http.ResponseWriter, r *http.Request) {
+	f, ok := filtro(w, r)
+	if !ok {
+		return
+	}
+	projetos, err := h.repo.ListProjetos(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	responder(w, http.StatusOK, projetos)
+}
+
+// GetProjeto retorna um projeto
+func (h *Handlers) GetProjeto(w http.ResponseWriter, r *http.Request) {
+	empresaID, id, ok := identificar(w, r)
+	if !ok {
+		return
+	}
+	p, err := h.repo.GetProjeto(id, empresaID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	responder(w, http.StatusOK, p)
+}
+
+// SalvarProjeto cria (POST) ou atualiza (PUT) um projeto
+func (h *Handlers) SalvarProjeto(w http.ResponseWriter, r *http.Request) {
+	var p Projeto
+	empresaID, autorID, id, ok := decodificar(w, r, &p)
+	if !ok {
+		return
+	}
+	p.ID, p.EmpresaID, p.AtualizadoPor = id, empresaID, autorID
+	salvo, err := h.service.SalvarProjeto(&p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	responder(w, statusSalvo(id), salvo)
+}
+
+// DeleteProjeto remove um projeto
+func (h *Handlers) DeleteProjeto(w http.ResponseWriter, r *http.Request) {
+	empresaID, id, ok := identificar(w, r)
+	if !ok {
+		return
+	}
+	if err := h.repo.DeleteProjeto(id, empresaID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListTarefas lista as tarefas da empresa
+func (h *Handlers) ListTarefas(w http.ResponseWriter, r *http.Request) {
+	f, ok := filtro(w, r)
+	if !ok {
+		return
+	}
+	tarefas, err := h.repo.ListTarefas(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	responder(w, http.StatusOK, tarefas)
+}
+
+// GetTarefa retorna uma tarefa
+func (h *Handlers) GetTarefa(w http.ResponseWriter, r *http.Request) {
+	empresaID, id, ok := identificar(w, r)
+	if !ok {
+		return
+	}
+	t, err := h.repo.GetTarefa(id, empresaID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	responder(w, http.StatusOK, t)
+}
+
+// SalvarTarefa cria (POST) ou atualiza (PUT) uma tarefa
+func (h *Handlers) SalvarTarefa(w http.ResponseWriter, r *http.Request) {
+	var t Tarefa
+	empresaID, autorID, id, ok := decodificar(w, r, &t)
+	if !ok {
+		return
+	}
+	t.ID, t.EmpresaID, t.AtualizadoPor = id, empresaID, autorID
+	salvo, err := h.service.SalvarTarefa(&t)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	responder(w, statusSalvo(id), salvo)
+}
+
+// DeleteTarefa remove uma tarefa
+func (h *Handlers) DeleteTarefa(w http.ResponseWriter, r *http.Request) {
+	empresaID, id, ok := identificar(w, r)
+	if !ok {
+		return
+	}
+	if err := h.repo.DeleteTarefa(id, empresaID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// filtro monta o filtro da listagem a partir dos parâmetros da consulta
+func filtro(w http.ResponseWriter, r *http.Request) (Filtro, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return Filtro{}, false
+	}
+	q := r.URL.Query()
+	f := Filtro{EmpresaID: user.Empresa, Status: q.Get("status")}
+	f.ResponsavelID, _ = strconv.Atoi(q.Get("responsavel_id"))
+	f.ClienteID, _ = strconv.Atoi(q.Get("cliente_id"))
+	f.ProjetoID, _ = strconv.Atoi(q.Get("projeto_id"))
+	f.NegocioID, _ = strconv.Atoi(q.Get("negocio_id"))
+	return f, true
+}
+
+// identificar retorna a empresa do usuário e o ID da rota
+func identificar(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return user.Empresa, id, true
+}
+
+// decodificar lê o corpo da criação ou edição e retorna a empresa e o usuário autenticado;
+// na criação, o ID retornado é zero
+func decodificar(w http.ResponseWriter, r *http.Request, destino interface{}) (int, int, int, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return 0, 0, 0, false
+	}
+	id := 0
+	if param := chi.URLParam(r, "id"); param != "" {
+		var err error
+		if id, err = strconv.Atoi(param); err != nil || id <= 0 {
+			http.Error(w, "ID inválido", http.StatusBadRequest)
+			return 0, 0, 0, false
+		}
+	}
+	if err := json.NewDecoder(r.Body).Decode(destino); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return 0, 0, 0, false
+	}
+	return user.Empresa, user.ID, id, true
+}
+
+func statusSalvo(id int) int {
+	if id == 0 {
+		return http.StatusCreated
+	}
+	return http.StatusOK
+}
+
+func responder(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,91 @@
+package kanban
+
+import (
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/dashboard"
+)
+
+// As métricas reconstroem a situação de cada registro no fim do período pelas datas de criação,
+// fechamento e conclusão, para que períodos passados não reflitam o status atual
+
+// MetricasNegocios implementa dashboard.FonteNegocios. Fechados e valor consideram os negócios
+// ganhos dentro do período.
+func (s *Service) MetricasNegocios(f dashboard.Filtro) (dashboard.MetricasNegocios, error) {
+	var m dashboard.MetricasNegocios
+
+	negocios, err := s.repo.ListNegocios(Filtro{EmpresaID: f.EmpresaID, ResponsavelID: f.UsuarioID})
+	if err != nil {
+		return m, err
+	}
+	for _, n := range negocios {
+		if !n.DataCriacao.Before(f.Periodo.Fim) {
+			continue
+		}
+		m.Total++
+		if f.Periodo.Contem(n.DataCriacao) {
+			m.Novos++
+		}
+		if n.Status == NegocioGanho && n.DataFechamento != nil && f.Periodo.Contem(*n.DataFechamento) {
+			m.Fechados++
+			m.Valor += n.Valor
+		}
+	}
+	return m, nil
+}
+
+// MetricasProjetos implementa dashboard.FonteProjetos. Em andamento são os projetos abertos no fim
+// do período; concluídos, os concluídos dentro dele.
+func (s *Service) MetricasProjetos(f dashboard.Filtro) (dashboard.MetricasProjetos, error) {
+	var m dashboard.MetricasProjetos
+
+	projetos, err := s.repo.ListProjetos(Filtro{EmpresaID: f.EmpresaID, ResponsavelID: f.UsuarioID})
+	if err != nil {
+		return m, err
+	}
+	for _, p := range projetos {
+		if !p.DataCriacao.Before(f.Periodo.Fim) {
+			continue
+		}
+		m.Total++
+		switch {
+		case abertoEm(p.DataConclusao, f.Periodo.Fim):
+			m.EmAndamento++
+		case p.Status == ProjetoConcluido && f.Periodo.Contem(*p.DataConclusao):
+			m.Concluidos++
+		}
+	}
+	return m, nil
+}
+
+// MetricasTarefas implementa dashboard.FonteTarefas. Pendentes são as tarefas abertas no fim do
+// período, e atrasadas as pendentes com prazo vencido nesse instante.
+func (s *Service) MetricasTarefas(f dashboard.Filtro) (dashboard.MetricasTarefas, error) {
+	var m dashboard.MetricasTarefas
+
+	tarefas, err := s.repo.ListTarefas(Filtro{EmpresaID: f.EmpresaID, ResponsavelID: f.UsuarioID})
+	if err != nil {
+		return m, err
+	}
+	for _, t := range tarefas {
+		if !t.DataCriacao.Before(f.Periodo.Fim) {
+			continue
+		}
+		m.Total++
+		switch {
+		case abertoEm(t.DataConclusao, f.Periodo.Fim):
+			m.Pendentes++
+			if t.Prazo != nil && t.Prazo.Before(f.Periodo.Fim) {
+				m.Atrasadas++
+			}
+		case f.Periodo.Contem(*t.DataConclusao):
+			m.Concluidas++
+		}
+	}
+	return m, nil
+}
+
+// abertoEm informa se o registro ainda não estava concluído no instante
+func abertoEm(conclusao *time.Time, instante time.Time) bool {
+	return conclusao == nil || !conclusao.Before(instante)
+}
@@ -0,0 +1,115 @@
+// Package kanban mantém o pipeline de negócios, os projetos e as tarefas exibidos nos quadros
+// do frontend (/kanban)
+package kanban
+
+import (
+	"time"
+)
+
+// Status dos negócios
+const (
+	NegocioAberto  = "aberto"
+	NegocioGanho   = "ganho"
+	NegocioPerdido = "perdido"
+)
+
+// Status dos projetos
+const (
+	ProjetoPlanejado   = "planejado"
+	ProjetoEmAndamento = "em_andamento"
+	ProjetoConcluido   = "concluido"
+	ProjetoCancelado   = "cancelado"
+)
+
+// Status das tarefas
+const (
+	TarefaPendente    = "pendente"
+	TarefaEmAndamento = "em_andamento"
+	TarefaConcluida   = "concluida"
+)
+
+// EtapaPadrao é a coluna do pipeline dos negócios criados sem etapa
+const EtapaPadrao = "prospeccao"
+
+// Negocio é uma oportunidade de venda no pipeline
+type Negocio struct {
+	ID              int        `json:"id"`
+	EmpresaID       int        `json:"empresa_id"`
+	Titulo          string     `json:"titulo"`
+	ClienteID       int        `json:"cliente_id,omitempty"`
+	Valor           float64    `json:"valor"`
+	Etapa           string     `json:"etapa"` // coluna do quadro
+	Status          string     `json:"status"`
+	ResponsavelID   int        `json:"responsavel_id,omitempty"`
+	DataCriacao     time.Time  `json:"data_criacao"`
+	DataAtualizacao time.Time  `json:"data_atualizacao"`
+	DataFechamento  *time.Time `json:"data_fechamento,omitempty"` // preenchida ao ganhar ou perder
+	AtualizadoPor   int        `json:"atualizado_por,omitempty"`  // usuário da última alteração
+}
+
+// Projeto agrupa tarefas com um objetivo comum
+type Projeto struct {
+	ID              int        `json:"id"`
+	EmpresaID       int        `json:"empresa_id"`
+	Nome            string     `json:"nome"`
+	Descricao       string     `json:"descricao,omitempty"`
+	ClienteID       int        `json:"cliente_id,omitempty"`
+	Status          string     `json:"status"`
+	ResponsavelID   int        `json:"responsavel_id,omitempty"`
+	Prazo           *time.Time `json:"prazo,omitempty"`
+	DataCriacao     time.Time  `json:"data_criacao"`
+	DataAtualizacao time.Time  `json:"data_atualizacao"`
+	DataConclusao   *time.Time `json:"data_conclusao,omitempty"` // preenchida ao concluir ou cancelar
+	AtualizadoPor   int        `json:"atualizado_por,omitempty"`
+}
+
+// Tarefa é um cartão do quadro de tarefas, opcionalmente ligado a um projeto ou negócio
+type Tarefa struct {
+	ID              int        `json:"id"`
+	EmpresaID       int        `json:"empresa_id"`
+	Titulo          string     `json:"titulo"`
+	Descricao       string     `json:"descricao,omitempty"`
+	ProjetoID       int        `json:"projeto_id,omitempty"`
+	NegocioID       int        `json:"negocio_id,omitempty"`
+	Status          string     `json:"status"`
+	ResponsavelID   int        `json:"responsavel_id,omitempty"`
+	Prazo           *time.Time `json:"prazo,omitempty"`
+	DataCriacao     time.Time  `json:"data_criacao"`
+	DataAtualizacao time.Time  `json:"data_atualizacao"`
+	DataConclusao   *time.Time `json:"data_conclusao,omitempty"`
+	AtualizadoPor   int        `json:"atualizado_por,omitempty"`
+}
+
+// Filtro restringe as listagens; campos zerados não filtram
+type Filtro struct {
+	EmpresaID     int
+	Status        string
+	ResponsavelID int
+	ClienteID     int // negócios e projetos
+	ProjetoID     int // tarefas
+	NegocioID     int // tarefas
+}
+
+// Repository define a interface para acesso aos negócios, projetos e tarefas
+type Repository interface {
+	CreateNegocio(n *Negocio) error
+	GetNegocio(id, empresaID int) (*Negocio, error)
+	UpdateNegocio(n *Negocio) error
+	DeleteNegocio(id, empresaID int) error
+	ListNegocios(f Filtro) ([]*Negocio, error)
+
+	CreateProjeto(p *Projeto) error
+	GetProjeto(id, empresaID int) (*Projeto, error)
+	UpdateProjeto(p *Projeto) error
+	DeleteProjeto(id, empresaID int) error
+	ListProjetos(f Filtro) ([]*Projeto, error)
+
+	CreateTarefa(t *Tarefa) error
+	GetTarefa(id, empresaID int) (*Tarefa, error)
+	UpdateTarefa(t *Tarefa) error
+	DeleteTarefa(id, empresaID int) error
+	ListTarefas(f Filtro) ([]*Tarefa, error)
+
+	// TransferirCliente reaponta para paraID os negócios e projetos de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
@@ -0,0 +1,312 @@
+package kanban
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	negocios    map[int]*Negocio
+	projetos    map[int]*Projeto
+	tarefas     map[int]*Tarefa
+	nextNegocio int
+	nextProjeto int
+	nextTarefa  int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		negocios:    make(map[int]*Negocio),
+		projetos:    make(map[int]*Projeto),
+		tarefas:     make(map[int]*Tarefa),
+		nextNegocio: 1,
+		nextProjeto: 1,
+		nextTarefa:  1,
+	}
+}
+
+// CreateNegocio adiciona um novo negócio
+func (r *MemoryRepository) CreateNegocio(n *Negocio) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if n.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	n.ID = r.nextNegocio
+	r.nextNegocio++
+	n.DataCriacao = time.Now()
+	n.DataAtualizacao = n.DataCriacao
+
+	c := *n
+	r.negocios[n.ID] = &c
+	return nil
+}
+
+// GetNegocio busca um negócio por ID e empresa
+func (r *MemoryRepository) GetNegocio(id, empresaID int) (*Negocio, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n, exists := r.negocios[id]
+	if !exists || n.EmpresaID != empresaID {
+		return nil, errors.New("negócio não encontrado")
+	}
+	c := *n
+	return &c, nil
+}
+
+// UpdateNegocio atualiza um negócio existente
+func (r *MemoryRepository) UpdateNegocio(n *Negocio) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.negocios[n.ID]
+	if !exists || existing.EmpresaID != n.EmpresaID {
+		return errors.New("negócio não encontrado")
+	}
+
+	n.DataCriacao = existing.DataCriacao
+	n.DataAtualizacao = time.Now()
+
+	c := *n
+	r.negocios[n.ID] = &c
+	return nil
+}
+
+// DeleteNegocio remove um negócio; as tarefas ligadas a ele ficam sem negócio
+func (r *MemoryRepository) DeleteNegocio(id, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, exists := r.negocios[id]
+	if !exists || n.EmpresaID != empresaID {
+		return errors.New("negócio não encontrado")
+	}
+	delete(r.negocios, id)
+	for _, t := range r.tarefas {
+		if t.NegocioID == id {
+			t.NegocioID = 0
+		}
+	}
+	return nil
+}
+
+// ListNegocios retorna os negócios da empresa, dos mais recentes para os mais antigos
+func (r *MemoryRepository) ListNegocios(f Filtro) ([]*Negocio, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Negocio, 0)
+	for _, n := range r.negocios {
+		if n.EmpresaID != f.EmpresaID ||
+			(f.Status != "" && n.Status != f.Status) ||
+			(f.ResponsavelID > 0 && n.ResponsavelID != f.ResponsavelID) ||
+			(f.ClienteID > 0 && n.ClienteID != f.ClienteID) {
+			continue
+		}
+		c := *n
+		result = append(result, &c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result, nil
+}
+
+// CreateProjeto adiciona um novo projeto
+func (r *MemoryRepository) CreateProjeto(p *Projeto) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	p.ID = r.nextProjeto
+	r.nextProjeto++
+	p.DataCriacao = time.Now()
+	p.DataAtualizacao = p.DataCriacao
+
+	c := *p
+	r.projetos[p.ID] = &c
+	return nil
+}
+
+// GetProjeto busca um projeto por ID e empresa
+func (r *MemoryRepository) GetProjeto(id, empresaID int) (*Projeto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, exists := r.projetos[id]
+	if !exists || p.EmpresaID != empresaID {
+		return nil, errors.New("projeto não encontrado")
+	}
+	c := *p
+	return &c, nil
+}
+
+// UpdateProjeto atualiza um projeto existente
+func (r *MemoryRepository) UpdateProjeto(p *Projeto) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.projetos[p.ID]
+	if !exists || existing.EmpresaID != p.EmpresaID {
+		return errors.New("projeto não encontrado")
+	}
+
+	p.DataCriacao = existing.DataCriacao
+	p.DataAtualizacao = time.Now()
+
+	c := *p
+	r.projetos[p.ID] = &c
+	return nil
+}
+
+// DeleteProjeto remove um projeto; as tarefas do projeto ficam sem projeto
+func (r *MemoryRepository) DeleteProjeto(id, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, exists := r.projetos[id]
+	if !exists || p.EmpresaID != empresaID {
+		return errors.New("projeto não encontrado")
+	}
+	delete(r.projetos, id)
+	for _, t := range r.tarefas {
+		if t.ProjetoID == id {
+			t.ProjetoID = 0
+		}
+	}
+	return nil
+}
+
+// ListProjetos retorna os projetos da empresa, dos mais recentes para os mais antigos
+func (r *MemoryRepository) ListProjetos(f Filtro) ([]*Projeto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Projeto, 0)
+	for _, p := range r.projetos {
+		if p.EmpresaID != f.EmpresaID ||
+			(f.Status != "" && p.Status != f.Status) ||
+			(f.ResponsavelID > 0 && p.ResponsavelID != f.ResponsavelID) ||
+			(f.ClienteID > 0 && p.ClienteID != f.ClienteID) {
+			continue
+		}
+		c := *p
+		result = append(result, &c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result, nil
+}
+
+// CreateTarefa adiciona uma nova tarefa
+func (r *MemoryRepository) CreateTarefa(t *Tarefa) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	t.ID = r.nextTarefa
+	r.nextTarefa++
+	t.DataCriacao = time.Now()
+	t.DataAtualizacao = t.DataCriacao
+
+	c := *t
+	r.tarefas[t.ID] = &c
+	return nil
+}
+
+// GetTarefa busca uma tarefa por ID e empresa
+func (r *MemoryRepository) GetTarefa(id, empresaID int) (*Tarefa, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, exists := r.tarefas[id]
+	if !exists || t.EmpresaID != empresaID {
+		return nil, errors.New("tarefa não encontrada")
+	}
+	c := *t
+	return &c, nil
+}
+
+// UpdateTarefa atualiza uma tarefa existente
+func (r *MemoryRepository) UpdateTarefa(t *Tarefa) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.tarefas[t.ID]
+	if !exists || existing.EmpresaID != t.EmpresaID {
+		return errors.New("tarefa não encontrada")
+	}
+
+	t.DataCriacao = existing.DataCriacao
+	t.DataAtualizacao = time.Now()
+
+	c := *t
+	r.tarefas[t.ID] = &c
+	return nil
+}
+
+// DeleteTarefa remove uma tarefa
+func (r *MemoryRepository) DeleteTarefa(id, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, exists := r.tarefas[id]
+	if !exists || t.EmpresaID != empresaID {
+		return errors.New("tarefa não encontrada")
+	}
+	delete(r.tarefas, id)
+	return nil
+}
+
+// ListTarefas retorna as tarefas da empresa, das mais recentes para as mais antigas
+func (r *MemoryRepository) ListTarefas(f Filtro) ([]*Tarefa, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Tarefa, 0)
+	for _, t := range r.tarefas {
+		if t.EmpresaID != f.EmpresaID ||
+			(f.Status != "" && t.Status != f.Status) ||
+			(f.ResponsavelID > 0 && t.ResponsavelID != f.ResponsavelID) ||
+			(f.ProjetoID > 0 && t.ProjetoID != f.ProjetoID) ||
+			(f.NegocioID > 0 && t.NegocioID != f.NegocioID) {
+			continue
+		}
+		c := *t
+		result = append(result, &c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+	return result, nil
+}
+
+// TransferirCliente reaponta para paraID os negócios e projetos de deID
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, neg := range r.negocios {
+		if neg.EmpresaID == empresaID && neg.ClienteID == deID {
+			neg.ClienteID = paraID
+			n++
+		}
+	}
+	for _, p := range r.projetos {
+		if p.EmpresaID == empresaID && p.ClienteID == deID {
+			p.ClienteID = paraID
+			n++
+		}
+	}
+	return n, nil
+}
@@ -0,0 +1,180 @@
+package kanban
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+)
+
+// Service valida e grava negócios, projetos e tarefas, mantendo as datas de fechamento e
+// conclusão usadas nas métricas do dashboard
+type Service struct {
+	repo     Repository
+	clientes cliente.Repository
+	usuarios usuario.Repository
+	agora    func() time.Time
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, clientes cliente.Repository, usuarios usuario.Repository) *Service {
+	return &Service{
+		repo:     repo,
+		clientes: clientes,
+		usuarios: usuarios,
+		agora:    time.Now,
+	}
+}
+
+// SalvarNegocio cria o negócio (ID zero) ou atualiza o existente. Ganhar ou perder registra a
+// data de fechamento; reabrir a descarta.
+func (s *Service) SalvarNegocio(n *Negocio) (*Negocio, error) {
+	n.Titulo = strings.TrimSpace(n.Titulo)
+	if n.Titulo == "" {
+		return nil, errors.New("informe o título do negócio")
+	}
+	if n.Valor < 0 {
+		return nil, errors.New("o valor do negócio não pode ser negativo")
+	}
+	if n.Status == "" {
+		n.Status = NegocioAberto
+	}
+	if n.Status != NegocioAberto && n.Status != NegocioGanho && n.Status != NegocioPerdido {
+		return nil, errors.New("status deve ser aberto, ganho ou perdido")
+	}
+	if n.Etapa = strings.TrimSpace(n.Etapa); n.Etapa == "" {
+		n.Etapa = EtapaPadrao
+	}
+	if err := s.verificarVinculos(n.EmpresaID, n.ClienteID, n.ResponsavelID); err != nil {
+		return nil, err
+	}
+
+	var anterior *Negocio
+	if n.ID > 0 {
+		var err error
+		if anterior, err = s.repo.GetNegocio(n.ID, n.EmpresaID); err != nil {
+			return nil, err
+		}
+		n.DataFechamento = anterior.DataFechamento
+	}
+	switch {
+	case n.Status == NegocioAberto:
+		n.DataFechamento = nil
+	case anterior == nil || anterior.Status != n.Status:
+		agora := s.agora()
+		n.DataFechamento = &agora
+	}
+
+	if anterior == nil {
+		return n, s.repo.CreateNegocio(n)
+	}
+	return n, s.repo.UpdateNegocio(n)
+}
+
+// SalvarProjeto cria o projeto (ID zero) ou atualiza o existente. Concluir ou cancelar registra a
+// data de conclusão; voltar a planejado ou em andamento a descarta.
+func (s *Service) SalvarProjeto(p *Projeto) (*Projeto, error) {
+	p.Nome = strings.TrimSpace(p.Nome)
+	if p.Nome == "" {
+		return nil, errors.New("informe o nome do projeto")
+	}
+	if p.Status == "" {
+		p.Status = ProjetoPlanejado
+	}
+	switch p.Status {
+	case ProjetoPlanejado, ProjetoEmAndamento, ProjetoConcluido, ProjetoCancelado:
+	default:
+		return nil, errors.New("status deve ser planejado, em_andamento, concluido ou cancelado")
+	}
+	if err := s.verificarVinculos(p.EmpresaID, p.ClienteID, p.ResponsavelID); err != nil {
+		return nil, err
+	}
+
+	var anterior *Projeto
+	if p.ID > 0 {
+		var err error
+		if anterior, err = s.repo.GetProjeto(p.ID, p.EmpresaID); err != nil {
+			return nil, err
+		}
+		p.DataConclusao = anterior.DataConclusao
+	}
+	switch {
+	case p.Status == ProjetoPlanejado || p.Status == ProjetoEmAndamento:
+		p.DataConclusao = nil
+	case anterior == nil || anterior.Status != p.Status:
+		agora := s.agora()
+		p.DataConclusao = &agora
+	}
+
+	if anterior == nil {
+		return p, s.repo.CreateProjeto(p)
+	}
+	return p, s.repo.UpdateProjeto(p)
+}
+
+// SalvarTarefa cria a tarefa (ID zero) ou atualiza a existente. Concluir registra a data de
+// conclusão; reabrir a descarta.
+func (s *Service) SalvarTarefa(t *Tarefa) (*Tarefa, error) {
+	t.Titulo = strings.TrimSpace(t.Titulo)
+	if t.Titulo == "" {
+		return nil, errors.New("informe o título da tarefa")
+	}
+	if t.Status == "" {
+		t.Status = TarefaPendente
+	}
+	if t.Status != TarefaPendente && t.Status != TarefaEmAndamento && t.Status != TarefaConcluida {
+		return nil, errors.New("status deve ser pendente, em_andamento ou concluida")
+	}
+	if err := s.verificarVinculos(t.EmpresaID, 0, t.ResponsavelID); err != nil {
+		return nil, err
+	}
+	if t.ProjetoID > 0 {
+		if _, err := s.repo.GetProjeto(t.ProjetoID, t.EmpresaID); err != nil {
+			return nil, err
+		}
+	}
+	if t.NegocioID > 0 {
+		if _, err := s.repo.GetNegocio(t.NegocioID, t.EmpresaID); err != nil {
+			return nil, err
+		}
+	}
+
+	var anterior *Tarefa
+	if t.ID > 0 {
+		var err error
+		if anterior, err = s.repo.GetTarefa(t.ID, t.EmpresaID); err != nil {
+			return nil, err
+		}
+		t.DataConclusao = anterior.DataConclusao
+	}
+	switch {
+	case t.Status != TarefaConcluida:
+		t.DataConclusao = nil
+	case anterior == nil || anterior.Status != TarefaConcluida:
+		agora := s.agora()
+		t.DataConclusao = &agora
+	}
+
+	if anterior == nil {
+		return t, s.repo.CreateTarefa(t)
+	}
+	return t, s.repo.UpdateTarefa(t)
+}
+
+// verificarVinculos confere que o cliente e o responsável informados pertencem à empresa
+func (s *Service) verificarVinculos(empresaID, clienteID, responsavelID int) error {
+	if clienteID > 0 {
+		if _, err := s.clientes.GetByID(clienteID, empresaID); err != nil {
+			return errors.New("cliente não encontrado")
+		}
+	}
+	if responsavelID > 0 {
+		u, err := s.usuarios.GetByID(responsavelID)
+		if err != nil || u.EmpresaID != empresaID {
+			return errors.New("responsável não encontrado")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package lgpd
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP de privacidade e proteção de dados
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas da LGPD; encerrar solicitações e anonimizar são restritos a administradores
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/clientes/{id}/consentimentos", h.ListConsentimentos)
+	r.Post("/clientes/{id}/consentimentos", h.CreateConsentimento)
+	r.Get("/clientes/{id}/mapa", h.Mapa)
+	r.Get("/clientes/{id}/exportar", h.Exportar)
+	r.With(auth.RequireRole("admin")).Post("/clientes/{id}/anonimizar", h.Anonimizar)
+
+	r.Get("/solicitacoes", h.ListSolicitacoes)
+	r.Post("/solicitacoes", h.CreateSolicitacao)
+	r.Get("/solicitacoes/{id}", h.GetSolicitacao)
+	r.With(auth.RequireRole("admin")).Post("/solicitacoes/{id}/concluir", h.Concluir)
+	r.With(auth.RequireRole("admin")).Post("/solicitacoes/{id}/recusar", h.Recusar)
+
+	return r
+}
+
+// ListConsentimentos retorna os consentimentos vigentes do cliente, ou o histórico completo com historico=true
+func (h *Handlers) ListConsentimentos(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var consentimentos []*Consentimento
+	if r.URL.Query().Get("historico") == "true" {
+		consentimentos, err = h.repo.ListConsentimentos(id, user.Empresa)
+	} else {
+		consentimentos, err = h.service.ConsentimentosVigentes(user.Empresa, id)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(consentimentos)
+}
+
+// CreateConsentimento registra a concessão ou a revogação de uma finalidade
+func (h *Handlers) CreateConsentimento(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var c Consentimento
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c.ClienteID = id
+	c.EmpresaID = user.Empresa
+	c.UsuarioID = user.ID
+
+	if err := h.service.RegistrarConsentimento(&c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// Mapa informa onde estão os dados do cliente
+func (h *Handlers) Mapa(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	mapa, err := h.service.Mapa(user.Empresa, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mapa)
+}
+
+// Exportar baixa os dados pessoais do cliente em JSON, para acesso e portabilidade
+func (h *Handlers) Exportar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	exportacao, err := h.service.Exportar(user.Empresa, id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"dados-cliente-%d.json\"", id))
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.Encode(exportacao)
+}
+
+// Anonimizar elimina os dados pessoais do cliente
+func (h *Handlers) Anonimizar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.Anonimizar(user.Empresa, id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSolicitacoes lista as solicitações da empresa, das mais urgentes para as mais folgadas.
+// Filtros: cliente_id, tipo, status e atrasadas=true.
+func (h *Handlers) ListSolicitacoes(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	q := r.URL.Query()
+	limit, _ := strconv.Atoi(q.Get("limit"))
+	offset, _ := strconv.Atoi(q.Get("offset"))
+	clienteID, _ := strconv.Atoi(q.Get("cliente_id"))
+
+	if limit <= 0 {
+		limit = 50 // valor padrão
+	}
+
+	solicitacoes, err := h.repo.ListSolicitacoes(FiltroSolicitacoes{
+		EmpresaID: user.Empresa,
+		ClienteID: clienteID,
+		Tipo:      q.Get("tipo"),
+		Status:    q.Get("status"),
+		Atrasadas: q.Get("atrasadas") == "true",
+	}, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(solicitacoes)
+}
+
+// CreateSolicitacao registra um pedido do titular
+func (h *Handlers) CreateSolicitacao(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var s Solicitacao
+	if err := json.NewDecoder(r.Body).Decode(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.EmpresaID = user.Empresa
+	s.UsuarioID = user.ID
+
+	if err := h.service.AbrirSolicitacao(&s); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s)
+}
+
+// GetSolicitacao retorna uma solicitação por ID
+func (h *Handlers) GetSolicitacao(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	s, err := h.repo.GetSolicitacao(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
+
+// Concluir encerra a solicitação como atendida; eliminações anonimizam o cliente
+func (h *Handlers) Concluir(w http.ResponseWriter, r *http.Request) {
+	h.encerrar(w, r, h.service.ConcluirSolicitacao)
+}
+
+// Recusar encerra a solicitação sem atendê-la
+func (h *Handlers) Recusar(w http.ResponseWriter, r *http.Request) {
+	h.encerrar(w, r, h.service.RecusarSolicitacao)
+}
+
+func (h *Handlers) encerrar(w http.ResponseWriter, r *http.Request,
+	acao func(empresaID, id, usuarioID int, resposta string) (*Solicitacao, error)) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	var in struct {
+		Resposta string `json:"resposta"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s, err := acao(user.Empresa, id, user.ID, in.Resposta)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s)
+}
@@ -0,0 +1,129 @@
+package lgpd
+
+import (
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// PrazoAtendimento é o prazo para responder às solicitações do titular (art. 19, II da LGPD)
+const PrazoAtendimento = 15 * 24 * time.Hour
+
+// Bases legais para o tratamento de dados pessoais (art. 7º da LGPD)
+const (
+	BaseConsentimento     = "consentimento"
+	BaseObrigacaoLegal    = "obrigacao_legal"
+	BaseContrato          = "execucao_contrato"
+	BaseExercicioDireitos = "exercicio_direitos"
+	BaseLegitimoInteresse = "legitimo_interesse"
+	BaseProtecaoCredito   = "protecao_credito"
+)
+
+// Tipos de solicitação do titular (art. 18 da LGPD)
+const (
+	TipoAcesso        = "acesso"
+	TipoCorrecao      = "correcao"
+	TipoPortabilidade = "portabilidade"
+	TipoEliminacao    = "eliminacao" // atendida pela anonimização do cliente
+)
+
+// Status das solicitações
+const (
+	StatusAberta    = "aberta"
+	StatusConcluida = "concluida"
+	StatusRecusada  = "recusada"
+)
+
+// Consentimento registra a concessão ou a revogação do tratamento para uma finalidade.
+// Os registros não são alterados: o estado vigente é o mais recente de cada finalidade.
+type Consentimento struct {
+	ID         int       `json:"id"`
+	EmpresaID  int       `json:"empresa_id"`
+	ClienteID  int       `json:"cliente_id"`
+	Finalidade string    `json:"finalidade"` // ex.: marketing, newsletter, analise_credito
+	BaseLegal  string    `json:"base_legal"`
+	Concedido  bool      `json:"concedido"`        // false registra a revogação
+	Origem     string    `json:"origem,omitempty"` // canal da coleta: formulario, contrato, telefone...
+	Termo      string    `json:"termo,omitempty"`  // versão do termo apresentado ao titular
+	UsuarioID  int       `json:"usuario_id"`
+	Data       time.Time `json:"data"`
+}
+
+// Solicitacao representa um pedido do titular dos dados
+type Solicitacao struct {
+	ID            int       `json:"id"`
+	EmpresaID     int       `json:"empresa_id"`
+	ClienteID     int       `json:"cliente_id"`
+	Tipo          string    `json:"tipo"`
+	Status        string    `json:"status"`
+	Descricao     string    `json:"descricao,omitempty"`
+	Resposta      string    `json:"resposta,omitempty"`
+	Prazo         time.Time `json:"prazo"`
+	UsuarioID     int       `json:"usuario_id"`               // quem registrou
+	ResponsavelID int       `json:"responsavel_id,omitempty"` // quem concluiu ou recusou
+	DataCriacao   time.Time `json:"data_criacao"`
+	DataConclusao time.Time `json:"data_conclusao,omitempty"`
+}
+
+// Atrasada indica se a solicitação continua aberta depois do prazo
+func (s *Solicitacao) Atrasada(agora time.Time) bool {
+	return s.Status == StatusAberta && agora.After(s.Prazo)
+}
+
+// FiltroSolicitacoes restringe a listagem de solicitações; campos zerados não filtram
+type FiltroSolicitacoes struct {
+	EmpresaID int
+	ClienteID int
+	Tipo      string
+	Status    string
+	Atrasadas bool
+}
+
+// Localizacao descreve onde estão dados de um titular
+type Localizacao struct {
+	Modulo    string   `json:"modulo"`
+	Dados     []string `json:"dados"` // categorias de dados pessoais guardadas
+	Registros int      `json:"registros"`
+	BaseLegal string   `json:"base_legal"`
+	Retencao  string   `json:"retencao"` // o que acontece com os registros na eliminação
+}
+
+// MapaDados lista os locais em que há dados do titular
+type MapaDados struct {
+	ClienteID    int           `json:"cliente_id"`
+	Anonimizado  bool          `json:"anonimizado"`
+	Localizacoes []Localizacao `json:"localizacoes"`
+	GeradoEm     time.Time     `json:"gerado_em"`
+}
+
+// Exportacao reúne os dados pessoais do titular em formato estruturado, para acesso e portabilidade
+type Exportacao struct {
+	Cliente        *cliente.Cliente    `json:"cliente"`
+	Contatos       []*cliente.Contato  `json:"contatos"`
+	Enderecos      []*cliente.Endereco `json:"enderecos"`
+	Consentimentos []*Consentimento    `json:"consentimentos"`
+	Solicitacoes   []*Solicitacao      `json:"solicitacoes"`
+	GeradoEm       time.Time           `json:"gerado_em"`
+}
+
+// Repository define a interface para acesso aos registros de privacidade
+type Repository interface {
+	CreateConsentimento(c *Consentimento) error
+	// ListConsentimentos retorna o histórico do cliente, do mais antigo para o mais recente
+	ListConsentimentos(clienteID, empresaID int) ([]*Consentimento, error)
+
+	CreateSolicitacao(s *Solicitacao) error
+	GetSolicitacao(id, empresaID int) (*Solicitacao, error)
+	UpdateSolicitacao(s *Solicitacao) error
+	ListSolicitacoes(f FiltroSolicitacoes, limit, offset int) ([]*Solicitacao, error)
+}
+
+// baseValida verifica se a base legal é uma das previstas
+func baseValida(base string) bool {
+	switch base {
+	case BaseConsentimento, BaseObrigacaoLegal, BaseContrato, BaseExercicioDireitos,
+		BaseLegitimoInteresse, BaseProtecaoCredito:
+		return true
+	}
+	return false
+}
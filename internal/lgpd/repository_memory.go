@@ -0,0 +1,146 @@
+package lgpd
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu                sync.RWMutex
+	consentimentos    []*Consentimento
+	solicitacoes      map[int]*Solicitacao
+	nextSolicitacaoID int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		solicitacoes:      make(map[int]*Solicitacao),
+		nextSolicitacaoID: 1,
+	}
+}
+
+// CreateConsentimento adiciona um registro ao histórico de consentimentos
+func (r *MemoryRepository) CreateConsentimento(c *Consentimento) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c.ID = len(r.consentimentos) + 1
+	if c.Data.IsZero() {
+		c.Data = time.Now()
+	}
+	r.consentimentos = append(r.consentimentos, c)
+	return nil
+}
+
+// ListConsentimentos retorna o histórico de consentimentos do cliente
+func (r *MemoryRepository) ListConsentimentos(clienteID, empresaID int) ([]*Consentimento, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Consentimento, 0)
+	for _, c := range r.consentimentos {
+		if c.ClienteID == clienteID && c.EmpresaID == empresaID {
+			result = append(result, c)
+		}
+	}
+	return result, nil
+}
+
+// CreateSolicitacao adiciona uma nova solicitação
+func (r *MemoryRepository) CreateSolicitacao(s *Solicitacao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if s.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	s.ID = r.nextSolicitacaoID
+	r.nextSolicitacaoID++
+	if s.DataCriacao.IsZero() {
+		s.DataCriacao = time.Now()
+	}
+
+	r.solicitacoes[s.ID] = s
+	return nil
+}
+
+// GetSolicitacao busca uma solicitação por ID e empresa
+func (r *MemoryRepository) GetSolicitacao(id, empresaID int) (*Solicitacao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	s, exists := r.solicitacoes[id]
+	if !exists || s.EmpresaID != empresaID {
+		return nil, errors.New("solicitação não encontrada")
+	}
+	return s, nil
+}
+
+// UpdateSolicitacao atualiza uma solicitação existente
+func (r *MemoryRepository) UpdateSolicitacao(s *Solicitacao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.solicitacoes[s.ID]
+	if !exists || existing.EmpresaID != s.EmpresaID {
+		return errors.New("solicitação não encontrada")
+	}
+
+	// Preservar campos que não devem ser alterados
+	s.ClienteID = existing.ClienteID
+	s.Tipo = existing.Tipo
+	s.Prazo = existing.Prazo
+	s.UsuarioID = existing.UsuarioID
+	s.DataCriacao = existing.DataCriacao
+
+	r.solicitacoes[s.ID] = s
+	return nil
+}
+
+// ListSolicitacoes retorna as solicitações pelo prazo, das mais urgentes para as mais folgadas
+func (r *MemoryRepository) ListSolicitacoes(f FiltroSolicitacoes, limit, offset int) ([]*Solicitacao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	agora := time.Now()
+	result := make([]*Solicitacao, 0)
+	for _, s := range r.solicitacoes {
+		if s.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.ClienteID > 0 && s.ClienteID != f.ClienteID {
+			continue
+		}
+		if f.Tipo != "" && s.Tipo != f.Tipo {
+			continue
+		}
+		if f.Status != "" && s.Status != f.Status {
+			continue
+		}
+		if f.Atrasadas && !s.Atrasada(agora) {
+			continue
+		}
+		result = append(result, s)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].Prazo.Equal(result[j].Prazo) {
+			return result[i].Prazo.Before(result[j].Prazo)
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*Solicitacao{}, nil
+	}
+	result = result[offset:]
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
@@ -0,0 +1,340 @@
+package lgpd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/atividade"
+	"github.com/Pantaleaogc/gvero/internal/boleto"
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/fiscal"
+	"github.com/Pantaleaogc/gvero/internal/pedido"
+	"github.com/Pantaleaogc/gvero/internal/pix"
+)
+
+// formatoFinalidade restringe as finalidades a identificadores simples, comparáveis entre registros
+var formatoFinalidade = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// Service implementa consentimentos, solicitações do titular e eliminação de dados.
+// Os demais módulos são consultados para montar o mapa de dados de cada titular.
+type Service struct {
+	repo       Repository
+	clientes   cliente.Repository
+	cadastro   *cliente.Service
+	atividades atividade.Repository
+	titulos    financeiro.Repository
+	cobrancas  pix.Repository
+	boletos    boleto.Repository
+	pedidos    pedido.Repository
+	notas      fiscal.Repository
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, clientes cliente.Repository, cadastro *cliente.Service, atividades atividade.Repository,
+	titulos financeiro.Repository, cobrancas pix.Repository, boletos boleto.Repository, pedidos pedido.Repository,
+	notas fiscal.Repository) *Service {
+	return &Service{
+		repo:       repo,
+		clientes:   clientes,
+		cadastro:   cadastro,
+		atividades: atividades,
+		titulos:    titulos,
+		cobrancas:  cobrancas,
+		boletos:    boletos,
+		pedidos:    pedidos,
+		notas:      notas,
+	}
+}
+
+// RegistrarConsentimento grava a concessão ou a revogação de uma finalidade para o cliente
+func (s *Service) RegistrarConsentimento(c *Consentimento) error {
+	cli, err := s.clientes.GetByID(c.ClienteID, c.EmpresaID)
+	if err != nil {
+		return err
+	}
+	if cli.Anonimizado() {
+		return errors.New("cliente anonimizado")
+	}
+
+	c.Finalidade = strings.TrimSpace(c.Finalidade)
+	if !formatoFinalidade.MatchString(c.Finalidade) {
+		return errors.New("finalidade deve começar com letra e conter apenas letras minúsculas, números e _")
+	}
+	if c.BaseLegal == "" {
+		c.BaseLegal = BaseConsentimento
+	}
+	if !baseValida(c.BaseLegal) {
+		return fmt.Errorf("base legal inválida: %s", c.BaseLegal)
+	}
+	c.Origem = strings.TrimSpace(c.Origem)
+	c.Termo = strings.TrimSpace(c.Termo)
+	c.Data = time.Now()
+
+	return s.repo.CreateConsentimento(c)
+}
+
+// ConsentimentosVigentes retorna o registro mais recente de cada finalidade do cliente
+func (s *Service) ConsentimentosVigentes(empresaID, clienteID int) ([]*Consentimento, error) {
+	historico, err := s.repo.ListConsentimentos(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	indice := make(map[string]int)
+	result := make([]*Consentimento, 0)
+	for _, c := range historico {
+		if i, ok := indice[c.Finalidade]; ok {
+			result[i] = c
+			continue
+		}
+		indice[c.Finalidade] = len(result)
+		result = append(result, c)
+	}
+	return result, nil
+}
+
+// Consentiu indica se o cliente autoriza a finalidade no momento, para uso antes de comunicações e campanhas
+func (s *Service) Consentiu(empresaID, clienteID int, finalidade string) (bool, error) {
+	vigentes, err := s.ConsentimentosVigentes(empresaID, clienteID)
+	if err != nil {
+		return false, err
+	}
+	for _, c := range vigentes {
+		if c.Finalidade == finalidade {
+			return c.Concedido, nil
+		}
+	}
+	return false, nil
+}
+
+// AbrirSolicitacao registra um pedido do titular com o prazo legal de resposta
+func (s *Service) AbrirSolicitacao(sol *Solicitacao) error {
+	switch sol.Tipo {
+	case TipoAcesso, TipoCorrecao, TipoPortabilidade, TipoEliminacao:
+	default:
+		return fmt.Errorf("tipo de solicitação inválido: %s", sol.Tipo)
+	}
+	if _, err := s.clientes.GetByID(sol.ClienteID, sol.EmpresaID); err != nil {
+		return err
+	}
+
+	agora := time.Now()
+	sol.ID = 0
+	sol.Status = StatusAberta
+	sol.Descricao = strings.TrimSpace(sol.Descricao)
+	sol.Resposta = ""
+	sol.ResponsavelID = 0
+	sol.DataCriacao = agora
+	sol.DataConclusao = time.Time{}
+	sol.Prazo = agora.Add(PrazoAtendimento)
+
+	return s.repo.CreateSolicitacao(sol)
+}
+
+// ConcluirSolicitacao encerra a solicitação como atendida.
+// Pedidos de eliminação anonimizam o cliente antes de serem concluídos.
+func (s *Service) ConcluirSolicitacao(empresaID, id, usuarioID int, resposta string) (*Solicitacao, error) {
+	sol, err := s.solicitacaoAberta(empresaID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if sol.Tipo == TipoEliminacao {
+		cli, err := s.clientes.GetByID(sol.ClienteID, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if !cli.Anonimizado() {
+			if err := s.cadastro.Anonimizar(sol.ClienteID, empresaID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return s.encerrar(sol, StatusConcluida, usuarioID, resposta)
+}
+
+// RecusarSolicitacao encerra a solicitação sem atendê-la; a justificativa é obrigatória
+func (s *Service) RecusarSolicitacao(empresaID, id, usuarioID int, justificativa string) (*Solicitacao, error) {
+	if strings.TrimSpace(justificativa) == "" {
+		return nil, errors.New("informe a justificativa da recusa")
+	}
+
+	sol, err := s.solicitacaoAberta(empresaID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.encerrar(sol, StatusRecusada, usuarioID, justificativa)
+}
+
+func (s *Service) solicitacaoAberta(empresaID, id int) (*Solicitacao, error) {
+	sol, err := s.repo.GetSolicitacao(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if sol.Status != StatusAberta {
+		return nil, fmt.Errorf("solicitação já está %s", sol.Status)
+	}
+	return sol, nil
+}
+
+func (s *Service) encerrar(sol *Solicitacao, status string, usuarioID int, resposta string) (*Solicitacao, error) {
+	atualizada := *sol
+	atualizada.Status = status
+	atualizada.Resposta = strings.TrimSpace(resposta)
+	atualizada.ResponsavelID = usuarioID
+	atualizada.DataConclusao = time.Now()
+
+	if err := s.repo.UpdateSolicitacao(&atualizada); err != nil {
+		return nil, err
+	}
+	return &atualizada, nil
+}
+
+// Anonimizar elimina os dados pessoais do cliente; registros financeiros e fiscais continuam intactos
+func (s *Service) Anonimizar(empresaID, clienteID int) error {
+	cli, err := s.clientes.GetByID(clienteID, empresaID)
+	if err != nil {
+		return err
+	}
+	if cli.Anonimizado() {
+		return errors.New("cliente já anonimizado")
+	}
+	return s.cadastro.Anonimizar(clienteID, empresaID)
+}
+
+// Exportar reúne os dados pessoais do cliente para atender acesso e portabilidade
+func (s *Service) Exportar(empresaID, clienteID int) (*Exportacao, error) {
+	cli, err := s.clientes.GetByID(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	contatos, err := s.clientes.ListContatos(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	enderecos, err := s.clientes.ListEnderecos(clienteID, empresaID, "")
+	if err != nil {
+		return nil, err
+	}
+	consentimentos, err := s.repo.ListConsentimentos(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	solicitacoes, err := s.repo.ListSolicitacoes(FiltroSolicitacoes{EmpresaID: empresaID, ClienteID: clienteID}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Exportacao{
+		Cliente:        cli,
+		Contatos:       contatos,
+		Enderecos:      enderecos,
+		Consentimentos: consentimentos,
+		Solicitacoes:   solicitacoes,
+		GeradoEm:       time.Now(),
+	}, nil
+}
+
+// Mapa informa em quais módulos há dados do cliente, com a base legal e o tratamento na eliminação
+func (s *Service) Mapa(empresaID, clienteID int) (*MapaDados, error) {
+	cli, err := s.clientes.GetByID(clienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	mapa := &MapaDados{
+		ClienteID:   clienteID,
+		Anonimizado: cli.Anonimizado(),
+		Localizacoes: []Localizacao{{
+			Modulo:    "clientes",
+			Dados:     []string{"nome", "cpf/cnpj", "email", "telefone", "endereço", "observações", "tags", "campos personalizados"},
+			Registros: 1,
+			BaseLegal: BaseContrato,
+			Retencao:  "anonimizado",
+		}},
+		GeradoEm: time.Now(),
+	}
+
+	// Cada fonte conta os registros do cliente; apenas as que possuem registros entram no mapa
+	fontes := []struct {
+		Localizacao
+		contar func() (int, error)
+	}{
+		{Localizacao{Modulo: "contatos", Dados: []string{"nome", "cargo", "email", "telefone"},
+			BaseLegal: BaseContrato, Retencao: "excluídos"},
+			func() (int, error) {
+				l, err := s.clientes.ListContatos(clienteID, empresaID)
+				return len(l), err
+			}},
+		{Localizacao{Modulo: "enderecos", Dados: []string{"endereço"},
+			BaseLegal: BaseContrato, Retencao: "excluídos"},
+			func() (int, error) {
+				l, err := s.clientes.ListEnderecos(clienteID, empresaID, "")
+				return len(l), err
+			}},
+		{Localizacao{Modulo: "atividades", Dados: []string{"registro de interações"},
+			BaseLegal: BaseLegitimoInteresse, Retencao: "mantidas, vinculadas ao cadastro anonimizado"},
+			func() (int, error) {
+				return s.atividades.Count(atividade.Filtro{EmpresaID: empresaID, ClienteID: clienteID})
+			}},
+		{Localizacao{Modulo: "pedidos", Dados: []string{"itens comprados", "valores", "observações"},
+			BaseLegal: BaseContrato, Retencao: "mantidos, vinculados ao cadastro anonimizado"},
+			func() (int, error) {
+				l, err := s.pedidos.List(pedido.FiltroPedidos{EmpresaID: empresaID, ClienteID: clienteID}, 0, 0)
+				return len(l), err
+			}},
+		{Localizacao{Modulo: "financeiro", Dados: []string{"títulos a receber", "pagamentos"},
+			BaseLegal: BaseObrigacaoLegal, Retencao: "mantidos (escrituração contábil)"},
+			func() (int, error) {
+				l, err := s.titulos.ListTitulos(financeiro.FiltroTitulos{EmpresaID: empresaID, ClienteID: clienteID}, 0, 0)
+				return len(l), err
+			}},
+		{Localizacao{Modulo: "pix", Dados: []string{"cobranças", "pagamentos"},
+			BaseLegal: BaseContrato, Retencao: "mantidas (registro financeiro)"},
+			func() (int, error) {
+				l, err := s.cobrancas.List(empresaID, clienteID, "", 0, 0)
+				return len(l), err
+			}},
+		{Localizacao{Modulo: "boletos", Dados: []string{"nome, documento e endereço do pagador"},
+			BaseLegal: BaseContrato, Retencao: "mantidos (registro bancário)"},
+			func() (int, error) {
+				l, err := s.boletos.List(empresaID, clienteID, "", 0, 0)
+				return len(l), err
+			}},
+		{Localizacao{Modulo: "fiscal", Dados: []string{"nome, documento e endereço do destinatário"},
+			BaseLegal: BaseObrigacaoLegal, Retencao: "mantidas pelo prazo da legislação fiscal"},
+			func() (int, error) {
+				l, err := s.notas.List(fiscal.FiltroNotas{EmpresaID: empresaID, ClienteID: clienteID}, 0, 0)
+				return len(l), err
+			}},
+		{Localizacao{Modulo: "lgpd", Dados: []string{"consentimentos", "solicitações do titular"},
+			BaseLegal: BaseObrigacaoLegal, Retencao: "mantidos como prova do atendimento"},
+			func() (int, error) {
+				c, err := s.repo.ListConsentimentos(clienteID, empresaID)
+				if err != nil {
+					return 0, err
+				}
+				sol, err := s.repo.ListSolicitacoes(FiltroSolicitacoes{EmpresaID: empresaID, ClienteID: clienteID}, 0, 0)
+				return len(c) + len(sol), err
+			}},
+	}
+
+	for _, f := range fontes {
+		total, err := f.contar()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Modulo, err)
+		}
+		if total == 0 {
+			continue
+		}
+		l := f.Localizacao
+		l.Registros = total
+		mapa.Localizacoes = append(mapa.Localizacoes, l)
+	}
+	return mapa, nil
+}
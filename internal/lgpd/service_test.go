@@ -0,0 +1,248 @@
+package lgpd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/atividade"
+	"github.com/Pantaleaogc/gvero/internal/boleto"
+	"github.com/Pantaleaogc/gvero/internal/campo"
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/fiscal"
+	"github.com/Pantaleaogc/gvero/internal/pedido"
+	"github.com/Pantaleaogc/gvero/internal/pix"
+)
+
+// cenario monta o serviço com um cliente que tem contato, endereço e um título a receber
+type cenario struct {
+	s          *Service
+	clientes   *cliente.MemoryRepository
+	cadastro   *cliente.Service
+	financeiro *financeiro.Service
+	titular    *cliente.Cliente
+}
+
+func novoCenario(t *testing.T) *cenario {
+	t.Helper()
+	clientes := cliente.NewMemoryRepository()
+	cadastro := cliente.NewService(clientes, nil, campo.NewService(campo.NewMemoryRepository()), time.Hour)
+	titulos := financeiro.NewMemoryRepository()
+	c := &cenario{
+		s: NewService(NewMemoryRepository(), clientes, cadastro, atividade.NewMemoryRepository(), titulos,
+			pix.NewMemoryRepository(), boleto.NewMemoryRepository(), pedido.NewMemoryRepository(), fiscal.NewMemoryRepository()),
+		clientes:   clientes,
+		cadastro:   cadastro,
+		financeiro: financeiro.NewService(titulos, clientes),
+		titular: &cliente.Cliente{EmpresaID: 1, Nome: "Ana Souza", Email: "ana@gmail.com", TipoPessoa: "fisica",
+			CPF: "529.982.247-25", Telefone: "11 98765-4321", Tags: []string{"vip"}},
+	}
+
+	if err := cadastro.Criar(c.titular); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientes.CreateContato(&cliente.Contato{EmpresaID: 1, ClienteID: c.titular.ID, Nome: "Bia", Email: "bia@gmail.com"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := clientes.CreateEndereco(&cliente.Endereco{EmpresaID: 1, ClienteID: c.titular.ID, Tipo: cliente.EnderecoEntrega, Logradouro: "Rua A"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.financeiro.CriarTitulo(1, financeiro.TipoReceber, financeiro.NovoTitulo{
+		Descricao: "Venda", ClienteID: c.titular.ID, ValorTotal: 100, PrimeiroVencimento: time.Now(),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// A eliminação anonimiza o cadastro, apaga contatos e endereços e mantém os registros financeiros
+func TestSolicitacaoDeEliminacao(t *testing.T) {
+	c := novoCenario(t)
+	id := c.titular.ID
+
+	sol := &Solicitacao{EmpresaID: 1, ClienteID: id, Tipo: TipoEliminacao, UsuarioID: 3}
+	if err := c.s.AbrirSolicitacao(sol); err != nil {
+		t.Fatal(err)
+	}
+	if sol.Status != StatusAberta || sol.Prazo.Sub(sol.DataCriacao) != PrazoAtendimento {
+		t.Errorf("solicitação aberta: %+v", sol)
+	}
+
+	concluida, err := c.s.ConcluirSolicitacao(1, sol.ID, 4, "Dados eliminados")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if concluida.Status != StatusConcluida || concluida.ResponsavelID != 4 {
+		t.Errorf("solicitação concluída: %+v", concluida)
+	}
+
+	anonimo, err := c.clientes.GetByID(id, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !anonimo.Anonimizado() || anonimo.CPF != "" || anonimo.Telefone != "" || anonimo.Tags != nil || anonimo.Status ||
+		strings.Contains(anonimo.Nome+anonimo.Email, "Ana") || strings.Contains(anonimo.Email, "gmail") {
+		t.Errorf("cadastro anonimizado: %+v", anonimo)
+	}
+	if contatos, _ := c.clientes.ListContatos(id, 1); len(contatos) != 0 {
+		t.Errorf("%d contatos mantidos", len(contatos))
+	}
+	if enderecos, _ := c.clientes.ListEnderecos(id, 1, ""); len(enderecos) != 0 {
+		t.Errorf("%d endereços mantidos", len(enderecos))
+	}
+
+	mapa, err := c.s.Mapa(1, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	modulos := make([]string, 0, len(mapa.Localizacoes))
+	for _, l := range mapa.Localizacoes {
+		modulos = append(modulos, l.Modulo)
+	}
+	if !mapa.Anonimizado || strings.Join(modulos, ",") != "clientes,financeiro,lgpd" {
+		t.Errorf("mapa depois da eliminação: anonimizado %v, módulos %v", mapa.Anonimizado, modulos)
+	}
+
+	// O cadastro anonimizado não volta a receber dados pessoais
+	alterado := *anonimo
+	alterado.Nome = "Ana Souza"
+	if err := c.cadastro.Atualizar(&alterado); err == nil {
+		t.Error("cliente anonimizado alterado")
+	}
+	if err := c.s.Anonimizar(1, id); err == nil {
+		t.Error("cliente anonimizado duas vezes")
+	}
+	if err := c.s.RegistrarConsentimento(&Consentimento{EmpresaID: 1, ClienteID: id, Finalidade: "marketing", Concedido: true}); err == nil {
+		t.Error("consentimento registrado para cliente anonimizado")
+	}
+}
+
+// A anonimização alcança as cópias do cadastro guardadas nas mesclagens, inclusive as dos
+// cadastros mesclados no titular, direta ou indiretamente
+func TestAnonimizarMesclagens(t *testing.T) {
+	c := novoCenario(t)
+	duplicado := &cliente.Cliente{EmpresaID: 1, Nome: "Ana S.", Email: "ana.souza@acme.com", Telefone: "11 3333-4444"}
+	antigo := &cliente.Cliente{EmpresaID: 1, Nome: "Ana Souza ME", Email: "ana@hotmail.com"}
+	for _, cli := range []*cliente.Cliente{duplicado, antigo} {
+		if err := c.cadastro.Criar(cli); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// O antigo é mesclado no duplicado, e o duplicado no titular
+	for _, par := range [][2]int{{duplicado.ID, antigo.ID}, {c.titular.ID, duplicado.ID}} {
+		if _, err := c.cadastro.Mesclar(1, par[0], par[1], 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := c.s.Anonimizar(1, c.titular.ID); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int{c.titular.ID, antigo.ID} {
+		mesclagens, err := c.clientes.ListMesclagens(id, 1)
+		if err != nil || len(mesclagens) != 1 {
+			t.Fatalf("mesclagens do cliente %d: %+v, %v", id, mesclagens, err)
+		}
+		for _, copia := range []*cliente.Cliente{mesclagens[0].Anterior, mesclagens[0].Mesclado} {
+			if !copia.Anonimizado() || copia.Telefone != "" || strings.Contains(copia.Nome, "Ana") ||
+				!strings.HasSuffix(copia.Email, "@anonimizado.invalid") {
+				t.Errorf("cópia do cliente %d na mesclagem %d: %+v", copia.ID, mesclagens[0].ID, copia)
+			}
+		}
+	}
+}
+
+// Anonimizar um cliente não alcança as mesclagens de outros titulares
+func TestAnonimizarOutroTitular(t *testing.T) {
+	c := novoCenario(t)
+	bia := &cliente.Cliente{EmpresaID: 1, Nome: "Bia", Email: "bia@acme.com"}
+	dupla := &cliente.Cliente{EmpresaID: 1, Nome: "Bia Lima", Email: "bia.lima@acme.com"}
+	for _, cli := range []*cliente.Cliente{bia, dupla} {
+		if err := c.cadastro.Criar(cli); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.cadastro.Mesclar(1, bia.ID, dupla.ID, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.s.Anonimizar(1, c.titular.ID); err != nil {
+		t.Fatal(err)
+	}
+	mesclagens, _ := c.clientes.ListMesclagens(bia.ID, 1)
+	if len(mesclagens) != 1 || mesclagens[0].Anterior.Nome != "Bia" || mesclagens[0].Mesclado.Nome != "Bia Lima" {
+		t.Errorf("mesclagem de outro titular alterada: %+v", mesclagens)
+	}
+}
+
+func TestConsentimentos(t *testing.T) {
+	c := novoCenario(t)
+	id := c.titular.ID
+
+	for _, concedido := range []bool{true, false} {
+		if err := c.s.RegistrarConsentimento(&Consentimento{EmpresaID: 1, ClienteID: id, Finalidade: "marketing", Concedido: concedido}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.s.RegistrarConsentimento(&Consentimento{EmpresaID: 1, ClienteID: id, Finalidade: "analise_credito",
+		BaseLegal: BaseProtecaoCredito, Concedido: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		finalidade string
+		esperado   bool
+	}{
+		{"marketing", false},
+		{"analise_credito", true},
+		{"newsletter", false},
+	}
+	for _, tt := range tests {
+		if ok, err := c.s.Consentiu(1, id, tt.finalidade); err != nil || ok != tt.esperado {
+			t.Errorf("%s: %v %v, esperado %v", tt.finalidade, ok, err, tt.esperado)
+		}
+	}
+	if vigentes, _ := c.s.ConsentimentosVigentes(1, id); len(vigentes) != 2 {
+		t.Errorf("%d consentimentos vigentes, esperado 2", len(vigentes))
+	}
+
+	invalidos := []Consentimento{
+		{EmpresaID: 1, ClienteID: id, Finalidade: "Marketing Direto"},
+		{EmpresaID: 1, ClienteID: id, Finalidade: "marketing", BaseLegal: "vontade"},
+		{EmpresaID: 2, ClienteID: id, Finalidade: "marketing"},
+	}
+	for _, cons := range invalidos {
+		if err := c.s.RegistrarConsentimento(&cons); err == nil {
+			t.Errorf("consentimento aceito: %+v", cons)
+		}
+	}
+}
+
+func TestEncerrarSolicitacao(t *testing.T) {
+	c := novoCenario(t)
+	sol := &Solicitacao{EmpresaID: 1, ClienteID: c.titular.ID, Tipo: TipoAcesso}
+	if err := c.s.AbrirSolicitacao(sol); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.s.RecusarSolicitacao(1, sol.ID, 4, "  "); err == nil {
+		t.Error("recusa sem justificativa")
+	}
+	if _, err := c.s.ConcluirSolicitacao(2, sol.ID, 4, ""); err == nil {
+		t.Error("solicitação concluída por outra empresa")
+	}
+	if _, err := c.s.RecusarSolicitacao(1, sol.ID, 4, "Titular não confirmou a identidade"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.s.ConcluirSolicitacao(1, sol.ID, 4, ""); err == nil {
+		t.Error("solicitação recusada concluída")
+	}
+	if cli, _ := c.clientes.GetByID(c.titular.ID, 1); cli.Anonimizado() {
+		t.Error("solicitação de acesso anonimizou o cliente")
+	}
+
+	if err := c.s.AbrirSolicitacao(&Solicitacao{EmpresaID: 1, ClienteID: c.titular.ID, Tipo: "esquecimento"}); err == nil {
+		t.Error("solicitação de tipo inválido aberta")
+	}
+}
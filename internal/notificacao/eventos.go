@@ -0,0 +1,23 @@
+package notificacao
+
+import (
+	"github.com/Pantaleaogc/gvero/internal/events"
+)
+
+// AssinarEventos notifica a empresa a cada cliente criado e negócio ganho, e o responsável a cada
+// tarefa atribuída a ele
+func AssinarEventos(barramento *events.Barramento, service *Service) {
+	barramento.Assinar("notificacoes", func(ev *events.Evento) error {
+		switch d := ev.Dados.(type) {
+		case events.ClienteCriado:
+			return service.ClienteCriado(d.Cliente.EmpresaID, 0, d.Cliente.ID, d.Cliente.Nome)
+		case events.NegocioGanho:
+			n := d.Negocio
+			return service.NegocioGanho(n.EmpresaID, n.AtualizadoPor, n.ID, n.Titulo, n.Valor)
+		case events.TarefaAtribuida:
+			t := d.Tarefa
+			return service.TarefaAtribuida(t.EmpresaID, t.AtualizadoPor, t.ResponsavelID, t.ID, t.Titulo)
+		}
+		return nil
+	}, events.TipoClienteCriado, events.TipoNegocioGanho, events.TipoTarefaAtribuida)
+}
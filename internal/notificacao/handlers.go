@@ -0,0 +1,221 @@
+package notificacao
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// intervaloHeartbeat mantém a conexão SSE ativa através de proxies
+const intervaloHeartbeat = 30 * time.Second
+
+// Handlers contém os manipuladores HTTP para notificações
+type Handlers struct {
+	repo Repository
+	hub  *Hub
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, hub *Hub) *Handlers {
+	return &Handlers{
+		repo: repo,
+		hub:  hub,
+	}
+}
+
+// Routes retorna as rotas para notificações
+func Routes(repo Repository, hub *Hub) http.Handler {
+	h := NewHandlers(repo, hub)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/", h.List)
+	r.Get("/nao-lidas", h.CountNaoLidas)
+	r.Get("/stream", h.Stream)
+	r.Post("/marcar-todas-lidas", h.MarcarTodasLidas)
+	r.Put("/{id}/lida", h.MarcarLida)
+
+	return r
+}
+
+// List lista as notificações do usuário atual
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	apenasNaoLidas, _ := strconv.ParseBool(r.URL.Query().Get("nao_lidas"))
+
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+
+	notificacoes, err := h.repo.List(user.ID, apenasNaoLidas, limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(notificacoes)
+}
+
+// CountNaoLidas retorna a quantidade de notificações não lidas do usuário atual
+func (h *Handlers) CountNaoLidas(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	total, err := h.repo.CountNaoLidas(user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"total": total})
+}
+
+// MarcarLida marca uma notificação do usuário atual como lida
+func (h *Handlers) MarcarLida(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.repo.MarcarLida(id, user.ID); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	n, err := h.repo.GetByID(id, user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(n)
+}
+
+// MarcarTodasLidas marca todas as notificações do usuário atual como lidas
+func (h *Handlers) MarcarTodasLidas(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	total, err := h.repo.MarcarTodasLidas(user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"atualizadas": total})
+}
+
+// Stream entrega novas notificações em tempo real via Server-Sent Events.
+// Ao reconectar com o cabeçalho Last-Event-ID, as notificações perdidas são reenviadas.
+func (h *Handlers) Stream(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	rc := http.NewResponseController(w)
+	// O WriteTimeout do servidor encerraria a conexão longa
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+		logger.DebugLogger.Printf("Não foi possível remover o prazo de escrita do stream: %v", err)
+	}
+
+	// Assinar antes de buscar as pendentes para não perder notificações no intervalo
+	ch, cancel := h.hub.Subscribe(user.ID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	ultimoID, _ := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	if ultimoID > 0 {
+		pendentes, err := h.repo.List(user.ID, false, 100, 0)
+		if err == nil {
+			// A lista vem da mais recente para a mais antiga
+			for i := len(pendentes) - 1; i >= 0; i-- {
+				if pendentes[i].ID > ultimoID {
+					escreverEvento(w, pendentes[i])
+					ultimoID = pendentes[i].ID
+				}
+			}
+		}
+	}
+
+	if err := rc.Flush(); err != nil {
+		logger.ErrorLogger.Printf("Streaming não suportado: %v", err)
+		return
+	}
+
+	logger.DebugLogger.Printf("Stream de notificações aberto para o usuário %d", user.ID)
+
+	heartbeat := time.NewTicker(intervaloHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.DebugLogger.Printf("Stream de notificações encerrado para o usuário %d", user.ID)
+			return
+		case n, ok := <-ch:
+			if !ok {
+				return
+			}
+			if n.ID <= ultimoID {
+				continue
+			}
+			escreverEvento(w, n)
+			ultimoID = n.ID
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+		}
+
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// escreverEvento serializa a notificação no formato SSE
+func escreverEvento(w http.ResponseWriter, n *Notificacao) {
+	data, err := json.Marshal(n)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro ao serializar notificação %d: %v", n.ID, err)
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: notificacao\ndata: %s\n\n", n.ID, data)
+}
@@ -0,0 +1,69 @@
+package notificacao
+
+import (
+	"sync"
+)
+
+// Hub distribui notificações em tempo real para as conexões abertas de cada usuário
+type Hub struct {
+	mu          sync.RWMutex
+	assinantes  map[int]map[chan *Notificacao]struct{}
+	tamanhoFila int
+}
+
+// NewHub cria um novo Hub
+func NewHub() *Hub {
+	return &Hub{
+		assinantes:  make(map[int]map[chan *Notificacao]struct{}),
+		tamanhoFila: 16,
+	}
+}
+
+// Subscribe registra uma conexão do usuário e retorna o canal de entrega e a função para cancelar
+func (h *Hub) Subscribe(usuarioID int) (<-chan *Notificacao, func()) {
+	ch := make(chan *Notificacao, h.tamanhoFila)
+
+	h.mu.Lock()
+	if h.assinantes[usuarioID] == nil {
+		h.assinantes[usuarioID] = make(map[chan *Notificacao]struct{})
+	}
+	h.assinantes[usuarioID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.assinantes[usuarioID], ch)
+			if len(h.assinantes[usuarioID]) == 0 {
+				delete(h.assinantes, usuarioID)
+			}
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+
+	return ch, cancel
+}
+
+// Publish entrega a notificação para todas as conexões do destinatário.
+// Conexões lentas com a fila cheia perdem a entrega em tempo real, mas a notificação continua persistida.
+func (h *Hub) Publish(n *Notificacao) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for ch := range h.assinantes[n.UsuarioID] {
+		select {
+		case ch <- n:
+		default:
+		}
+	}
+}
+
+// Conectados retorna a quantidade de conexões abertas do usuário
+func (h *Hub) Conectados(usuarioID int) int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	return len(h.assinantes[usuarioID])
+}
@@ -0,0 +1,48 @@
+package notificacao
+
+import (
+	"time"
+)
+
+// Tipos de notificação gerados a partir de eventos de domínio
+const (
+	TipoClienteCriado   = "cliente_criado"
+	TipoTarefaAtribuida = "tarefa_atribuida"
+	TipoNegocioGanho    = "negocio_ganho"
+	TipoSistema         = "sistema"
+)
+
+// Notificacao representa uma notificação exibida para um usuário
+type Notificacao struct {
+	ID          int        `json:"id"`
+	EmpresaID   int        `json:"empresa_id"`
+	UsuarioID   int        `json:"usuario_id"`
+	Tipo        string     `json:"tipo"`
+	Titulo      string     `json:"titulo"`
+	Mensagem    string     `json:"mensagem,omitempty"`
+	Link        string     `json:"link,omitempty"`
+	Lida        bool       `json:"lida"`
+	DataCriacao time.Time  `json:"data_criacao"`
+	DataLeitura *time.Time `json:"data_leitura,omitempty"`
+}
+
+// Evento representa um acontecimento de domínio que gera notificações
+type Evento struct {
+	Tipo      string
+	EmpresaID int
+	AutorID   int   // usuário que originou o evento (não é notificado)
+	Usuarios  []int // destinatários explícitos; vazio para notificar toda a empresa
+	Titulo    string
+	Mensagem  string
+	Link      string
+}
+
+// Repository define a interface para acesso aos dados de notificações
+type Repository interface {
+	Create(n *Notificacao) error
+	GetByID(id int, usuarioID int) (*Notificacao, error)
+	List(usuarioID int, apenasNaoLidas bool, limit, offset int) ([]*Notificacao, error)
+	CountNaoLidas(usuarioID int) (int, error)
+	MarcarLida(id int, usuarioID int) error
+	MarcarTodasLidas(usuarioID int) (int, error)
+}
@@ -0,0 +1,138 @@
+package notificacao
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu           sync.RWMutex
+	notificacoes map[int]*Notificacao
+	nextID       int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		notificacoes: make(map[int]*Notificacao),
+		nextID:       1,
+	}
+}
+
+// Create adiciona uma nova notificação
+func (r *MemoryRepository) Create(n *Notificacao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Validação básica
+	if n.UsuarioID <= 0 || n.Titulo == "" {
+		return errors.New("usuário e título são obrigatórios")
+	}
+
+	// Configurar campos
+	n.ID = r.nextID
+	r.nextID++
+	n.DataCriacao = time.Now()
+	n.Lida = false
+	n.DataLeitura = nil
+
+	// Adicionar ao mapa
+	r.notificacoes[n.ID] = n
+	return nil
+}
+
+// GetByID busca uma notificação por ID e usuário
+func (r *MemoryRepository) GetByID(id int, usuarioID int) (*Notificacao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	n, exists := r.notificacoes[id]
+	if !exists || n.UsuarioID != usuarioID {
+		return nil, errors.New("notificação não encontrada")
+	}
+	return n, nil
+}
+
+// List retorna as notificações de um usuário, das mais recentes para as mais antigas
+func (r *MemoryRepository) List(usuarioID int, apenasNaoLidas bool, limit, offset int) ([]*Notificacao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	todas := make([]*Notificacao, 0)
+	for _, n := range r.notificacoes {
+		if n.UsuarioID != usuarioID {
+			continue
+		}
+		if apenasNaoLidas && n.Lida {
+			continue
+		}
+		todas = append(todas, n)
+	}
+
+	sort.Slice(todas, func(i, j int) bool {
+		return todas[i].ID > todas[j].ID
+	})
+
+	if offset >= len(todas) {
+		return []*Notificacao{}, nil
+	}
+	todas = todas[offset:]
+
+	if limit > 0 && limit < len(todas) {
+		todas = todas[:limit]
+	}
+
+	return todas, nil
+}
+
+// CountNaoLidas retorna a quantidade de notificações não lidas do usuário
+func (r *MemoryRepository) CountNaoLidas(usuarioID int) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	total := 0
+	for _, n := range r.notificacoes {
+		if n.UsuarioID == usuarioID && !n.Lida {
+			total++
+		}
+	}
+	return total, nil
+}
+
+// MarcarLida marca uma notificação como lida
+func (r *MemoryRepository) MarcarLida(id int, usuarioID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n, exists := r.notificacoes[id]
+	if !exists || n.UsuarioID != usuarioID {
+		return errors.New("notificação não encontrada")
+	}
+
+	if !n.Lida {
+		agora := time.Now()
+		n.Lida = true
+		n.DataLeitura = &agora
+	}
+	return nil
+}
+
+// MarcarTodasLidas marca todas as notificações do usuário como lidas e retorna quantas foram alteradas
+func (r *MemoryRepository) MarcarTodasLidas(usuarioID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	agora := time.Now()
+	total := 0
+	for _, n := range r.notificacoes {
+		if n.UsuarioID == usuarioID && !n.Lida {
+			n.Lida = true
+			n.DataLeitura = &agora
+			total++
+		}
+	}
+	return total, nil
+}
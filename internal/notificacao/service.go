@@ -0,0 +1,110 @@
+package notificacao
+
+import (
+	"fmt"
+
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+)
+
+// Service cria notificações a partir de eventos de domínio e as entrega em tempo real
+type Service struct {
+	repo     Repository
+	hub      *Hub
+	usuarios usuario.Repository
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, hub *Hub, usuarios usuario.Repository) *Service {
+	return &Service{
+		repo:     repo,
+		hub:      hub,
+		usuarios: usuarios,
+	}
+}
+
+// Publicar gera uma notificação para cada destinatário do evento
+func (s *Service) Publicar(ev Evento) error {
+	destinatarios := ev.Usuarios
+	if len(destinatarios) == 0 {
+		var err error
+		destinatarios, err = s.usuariosDaEmpresa(ev.EmpresaID)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, usuarioID := range destinatarios {
+		if usuarioID == ev.AutorID {
+			continue
+		}
+
+		n := &Notificacao{
+			EmpresaID: ev.EmpresaID,
+			UsuarioID: usuarioID,
+			Tipo:      ev.Tipo,
+			Titulo:    ev.Titulo,
+			Mensagem:  ev.Mensagem,
+			Link:      ev.Link,
+		}
+		if err := s.repo.Create(n); err != nil {
+			return fmt.Errorf("erro ao criar notificação: %w", err)
+		}
+
+		s.hub.Publish(n)
+	}
+
+	return nil
+}
+
+// ClienteCriado notifica a empresa sobre um novo cliente cadastrado
+func (s *Service) ClienteCriado(empresaID, autorID, clienteID int, nome string) error {
+	return s.Publicar(Evento{
+		Tipo:      TipoClienteCriado,
+		EmpresaID: empresaID,
+		AutorID:   autorID,
+		Titulo:    "Novo cliente cadastrado",
+		Mensagem:  nome,
+		Link:      fmt.Sprintf("/clientes/%d", clienteID),
+	})
+}
+
+// TarefaAtribuida notifica o responsável por uma tarefa
+func (s *Service) TarefaAtribuida(empresaID, autorID, responsavelID, tarefaID int, titulo string) error {
+	return s.Publicar(Evento{
+		Tipo:      TipoTarefaAtribuida,
+		EmpresaID: empresaID,
+		AutorID:   autorID,
+		Usuarios:  []int{responsavelID},
+		Titulo:    "Tarefa atribuída a você",
+		Mensagem:  titulo,
+		Link:      fmt.Sprintf("/kanban/tarefas/%d", tarefaID),
+	})
+}
+
+// NegocioGanho notifica a empresa sobre um negócio fechado
+func (s *Service) NegocioGanho(empresaID, autorID, negocioID int, titulo string, valor float64) error {
+	return s.Publicar(Evento{
+		Tipo:      TipoNegocioGanho,
+		EmpresaID: empresaID,
+		AutorID:   autorID,
+		Titulo:    "Negócio ganho",
+		Mensagem:  fmt.Sprintf("%s (R$ %.2f)", titulo, valor),
+		Link:      fmt.Sprintf("/kanban/negocios/%d", negocioID),
+	})
+}
+
+// usuariosDaEmpresa retorna os IDs dos usuários ativos de uma empresa
+func (s *Service) usuariosDaEmpresa(empresaID int) ([]int, error) {
+	usuarios, err := s.usuarios.List(0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int, 0)
+	for _, u := range usuarios {
+		if u.Status && u.EmpresaID == empresaID {
+			ids = append(ids, u.ID)
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,174 @@
+package notificacao
+
+import (
+	"io"
+	"log"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/events"
+	"github.com/Pantaleaogc/gvero/internal/kanban"
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// novoServico cadastra três usuários ativos e um inativo na empresa 1 e um na empresa 2
+func novoServico(t *testing.T) (*Service, *MemoryRepository, *Hub) {
+	t.Helper()
+	usuarios := usuario.NewMemoryRepository()
+	for _, u := range []*usuario.Usuario{
+		{Nome: "Ana", Email: "ana@acme.com", EmpresaID: 1},
+		{Nome: "Bia", Email: "bia@acme.com", EmpresaID: 1},
+		{Nome: "Caio", Email: "caio@acme.com", EmpresaID: 1},
+		{Nome: "Davi", Email: "davi@acme.com", EmpresaID: 1},
+		{Nome: "Eva", Email: "eva@outra.com", EmpresaID: 2},
+	} {
+		if err := usuarios.Create(u); err != nil {
+			t.Fatal(err)
+		}
+	}
+	inativo, _ := usuarios.GetByID(4)
+	inativo.Status = false
+
+	repo := NewMemoryRepository()
+	hub := NewHub()
+	return NewService(repo, hub, usuarios), repo, hub
+}
+
+// destinatarios retorna os usuários que receberam alguma notificação
+func destinatarios(repo *MemoryRepository) []int {
+	ids := make([]int, 0)
+	for id := 1; id <= 5; id++ {
+		if n, _ := repo.CountNaoLidas(id); n > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func TestPublicar(t *testing.T) {
+	tests := []struct {
+		nome     string
+		evento   Evento
+		esperado []int
+	}{
+		{"toda a empresa menos o autor e os inativos", Evento{Tipo: TipoSistema, EmpresaID: 1, AutorID: 2, Titulo: "x"}, []int{1, 3}},
+		{"outra empresa", Evento{Tipo: TipoSistema, EmpresaID: 2, Titulo: "x"}, []int{5}},
+		{"destinatário explícito", Evento{Tipo: TipoSistema, EmpresaID: 1, AutorID: 1, Usuarios: []int{3}, Titulo: "x"}, []int{3}},
+		{"autor é o destinatário", Evento{Tipo: TipoSistema, EmpresaID: 1, AutorID: 3, Usuarios: []int{3}, Titulo: "x"}, []int{}},
+	}
+	for _, tt := range tests {
+		s, repo, _ := novoServico(t)
+		if err := s.Publicar(tt.evento); err != nil {
+			t.Fatalf("%s: %v", tt.nome, err)
+		}
+		if got := destinatarios(repo); !iguais(got, tt.esperado) {
+			t.Errorf("%s: notificados %v, esperado %v", tt.nome, got, tt.esperado)
+		}
+	}
+}
+
+// Cada usuário só lê e marca as próprias notificações
+func TestNotificacoesPorUsuario(t *testing.T) {
+	s, repo, _ := novoServico(t)
+	if err := s.TarefaAtribuida(1, 1, 3, 9, "Ligar para o cliente"); err != nil {
+		t.Fatal(err)
+	}
+	lista, _ := repo.List(3, true, 0, 0)
+	if len(lista) != 1 || lista[0].Link != "/kanban/tarefas/9" {
+		t.Fatalf("notificações do responsável: %+v", lista)
+	}
+	id := lista[0].ID
+
+	if _, err := repo.GetByID(id, 1); err == nil {
+		t.Error("notificação lida por outro usuário")
+	}
+	if err := repo.MarcarLida(id, 1); err == nil {
+		t.Error("notificação marcada por outro usuário")
+	}
+	if n, _ := repo.MarcarTodasLidas(1); n != 0 {
+		t.Errorf("%d notificações de outro usuário marcadas", n)
+	}
+	if err := repo.MarcarLida(id, 3); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := repo.CountNaoLidas(3); n != 0 {
+		t.Errorf("%d não lidas depois de marcar", n)
+	}
+}
+
+// O hub entrega em tempo real só às conexões do destinatário
+func TestHubEntregaAoDestinatario(t *testing.T) {
+	s, _, hub := novoServico(t)
+	ch1, cancel1 := hub.Subscribe(1)
+	defer cancel1()
+	ch3, cancel3 := hub.Subscribe(3)
+	defer cancel3()
+
+	if err := s.TarefaAtribuida(1, 1, 3, 9, "Ligar para o cliente"); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case n := <-ch3:
+		if n.UsuarioID != 3 {
+			t.Errorf("entregue a %d", n.UsuarioID)
+		}
+	default:
+		t.Error("responsável não recebeu a notificação")
+	}
+	select {
+	case n := <-ch1:
+		t.Errorf("autor recebeu %+v", n)
+	default:
+	}
+
+	cancel3()
+	if hub.Conectados(3) != 0 {
+		t.Error("conexão continua registrada depois de cancelada")
+	}
+}
+
+// Os eventos de domínio geram as notificações correspondentes
+func TestAssinarEventos(t *testing.T) {
+	s, repo, _ := novoServico(t)
+	barramento := events.NewBarramento(events.NewMemoryOutbox())
+	AssinarEventos(barramento, s)
+
+	barramento.Transacao(func(tx *events.Tx) error {
+		tx.Emitir(events.TarefaAtribuida{Tarefa: kanban.Tarefa{ID: 9, EmpresaID: 1, Titulo: "t", ResponsavelID: 3, AtualizadoPor: 1}})
+		tx.Emitir(events.ClienteCriado{Cliente: cliente.Cliente{ID: 4, EmpresaID: 2, Nome: "Zeca"}})
+		return nil
+	})
+	for barramento.Processar() > 0 {
+	}
+
+	if got := destinatarios(repo); !iguais(got, []int{3, 5}) {
+		t.Errorf("notificados %v, esperado [3 5]", got)
+	}
+	lista, _ := repo.List(5, false, 0, 0)
+	if len(lista) != 1 || lista[0].Tipo != TipoClienteCriado || lista[0].EmpresaID != 2 {
+		t.Errorf("notificação do cliente: %+v", lista)
+	}
+}
+
+func iguais(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
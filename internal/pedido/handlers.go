@@ -0,0 +1,287 @@
+package pedido
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP para orçamentos e pedidos
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas de orçamentos e pedidos de venda
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Route("/orcamentos", func(r chi.Router) {
+		r.Get("/", h.list(TipoOrcamento))
+		r.Post("/", h.create(TipoOrcamento))
+		r.Get("/{id}", h.get(TipoOrcamento))
+		r.Put("/{id}", h.update(TipoOrcamento))
+		r.Get("/{id}/pdf", h.pdf(TipoOrcamento))
+		r.Post("/{id}/email", h.email(TipoOrcamento))
+		r.Post("/{id}/status", h.alterarStatus(TipoOrcamento))
+		r.Post("/{id}/converter", h.Converter)
+	})
+
+	r.Route("/pedidos", func(r chi.Router) {
+		r.Get("/", h.list(TipoPedido))
+		r.Post("/", h.create(TipoPedido))
+		r.Get("/{id}", h.get(TipoPedido))
+		r.Put("/{id}", h.update(TipoPedido))
+		r.Get("/{id}/pdf", h.pdf(TipoPedido))
+		r.Post("/{id}/email", h.email(TipoPedido))
+		r.Post("/{id}/status", h.alterarStatus(TipoPedido))
+	})
+
+	return r
+}
+
+func (h *Handlers) list(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		q := r.URL.Query()
+		limit, _ := strconv.Atoi(q.Get("limit"))
+		offset, _ := strconv.Atoi(q.Get("offset"))
+
+		if limit <= 0 {
+			limit = 100 // valor padrão
+		}
+
+		f := FiltroPedidos{
+			EmpresaID: user.Empresa,
+			Tipo:      tipo,
+			Status:    q.Get("status"),
+		}
+		f.ClienteID, _ = strconv.Atoi(q.Get("cliente_id"))
+		f.VendedorID, _ = strconv.Atoi(q.Get("vendedor_id"))
+
+		pedidos, err := h.repo.List(f, limit, offset)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(pedidos)
+	}
+}
+
+func (h *Handlers) create(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		var in NovoPedido
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		p, err := h.service.Criar(user.Empresa, user.ID, tipo, in)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+func (h *Handlers) get(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := h.pedido(w, r, tipo)
+		if !ok {
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(p)
+	}
+}
+
+func (h *Handlers) update(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := h.pedido(w, r, tipo)
+		if !ok {
+			return
+		}
+
+		var in NovoPedido
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		atualizado, err := h.service.Atualizar(p.ID, p.EmpresaID, in)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(atualizado)
+	}
+}
+
+func (h *Handlers) alterarStatus(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, ok := auth.FromContext(r.Context())
+		if !ok {
+			http.Error(w, "Não autorizado", http.StatusUnauthorized)
+			return
+		}
+
+		p, ok := h.pedido(w, r, tipo)
+		if !ok {
+			return
+		}
+
+		var in struct {
+			Status string `json:"status"`
+			Motivo string `json:"motivo"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		atualizado, err := h.service.AlterarStatus(p.ID, user.Empresa, user.ID, in.Status, in.Motivo)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(atualizado)
+	}
+}
+
+func (h *Handlers) pdf(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := h.pedido(w, r, tipo)
+		if !ok {
+			return
+		}
+
+		_, pdf, err := h.service.Documento(p.ID, p.EmpresaID)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=\"%s-%d.pdf\"", p.Tipo, p.Numero))
+		w.Write(pdf)
+	}
+}
+
+// email envia o PDF do documento por e-mail; sem destinatários, envia ao cliente
+func (h *Handlers) email(tipo string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := h.pedido(w, r, tipo)
+		if !ok {
+			return
+		}
+		user, _ := auth.FromContext(r.Context())
+
+		var in EnvioEmail
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		m, err := h.service.EnviarPorEmail(p.ID, p.EmpresaID, user.ID, in)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		// O PDF já pode ser obtido pela rota do documento
+		for i := range m.Anexos {
+			m.Anexos[i].Conteudo = nil
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(m)
+	}
+}
+
+// Converter gera um pedido de venda a partir de um orçamento
+func (h *Handlers) Converter(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.service.Converter(id, user.Empresa, user.ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(p)
+}
+
+// pedido carrega o documento da URL garantindo que pertence à empresa e ao tipo da rota
+func (h *Handlers) pedido(w http.ResponseWriter, r *http.Request, tipo string) (*Pedido, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	p, err := h.repo.GetByID(id, user.Empresa)
+	if err != nil || p.Tipo != tipo {
+		http.Error(w, "documento não encontrado", http.StatusNotFound)
+		return nil, false
+	}
+	return p, true
+}
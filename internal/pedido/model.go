@@ -0,0 +1,118 @@
+package pedido
+
+import (
+	"time"
+)
+
+// Tipos de documento de venda
+const (
+	TipoOrcamento = "orcamento"
+	TipoPedido    = "pedido"
+)
+
+// Status de pedidos e orçamentos
+const (
+	StatusRascunho   = "rascunho"
+	StatusAprovado   = "aprovado"
+	StatusFaturado   = "faturado"
+	StatusEntregue   = "entregue"
+	StatusCancelado  = "cancelado"
+	StatusEnviado    = "enviado"    // orçamento enviado ao cliente
+	StatusConvertido = "convertido" // orçamento transformado em pedido
+	StatusRecusado   = "recusado"
+)
+
+// transicoes define o fluxo de status permitido para cada tipo de documento
+var transicoes = map[string]map[string][]string{
+	TipoPedido: {
+		StatusRascunho: {StatusAprovado, StatusCancelado},
+		StatusAprovado: {StatusFaturado, StatusCancelado},
+		StatusFaturado: {StatusEntregue},
+	},
+	TipoOrcamento: {
+		StatusRascunho: {StatusEnviado, StatusConvertido, StatusRecusado},
+		StatusEnviado:  {StatusConvertido, StatusRecusado},
+	},
+}
+
+// Item representa uma linha de produto ou serviço do pedido
+type Item struct {
+	ProdutoID          int     `json:"produto_id"`
+	SKU                string  `json:"sku"`
+	Descricao          string  `json:"descricao"`
+	Unidade            string  `json:"unidade"`
+	Quantidade         float64 `json:"quantidade"`
+	PrecoUnitario      float64 `json:"preco_unitario"` // zero usa o preço da tabela do cliente
+	DescontoPercentual float64 `json:"desconto_percentual"`
+	Total              float64 `json:"total"`
+}
+
+// CondicaoPagamento define como o total será parcelado no faturamento
+type CondicaoPagamento struct {
+	Descricao        string `json:"descricao,omitempty"` // ex.: "30/60/90 dias"
+	Parcelas         int    `json:"parcelas"`
+	PrimeiroVencDias int    `json:"primeiro_venc_dias"` // dias após o faturamento
+	IntervaloDias    int    `json:"intervalo_dias"`     // 0 para vencimentos mensais
+}
+
+// MudancaStatus registra uma transição no fluxo do pedido
+type MudancaStatus struct {
+	De        string    `json:"de"`
+	Para      string    `json:"para"`
+	UsuarioID int       `json:"usuario_id"`
+	Motivo    string    `json:"motivo,omitempty"`
+	Data      time.Time `json:"data"`
+}
+
+// Pedido representa um orçamento ou um pedido de venda
+type Pedido struct {
+	ID                 int               `json:"id"`
+	EmpresaID          int               `json:"empresa_id"`
+	Tipo               string            `json:"tipo"`
+	Numero             int               `json:"numero"` // sequencial por empresa e tipo
+	ClienteID          int               `json:"cliente_id"`
+	VendedorID         int               `json:"vendedor_id"`
+	OrcamentoID        int               `json:"orcamento_id,omitempty"` // orçamento de origem
+	PedidoID           int               `json:"pedido_id,omitempty"`    // pedido gerado pelo orçamento
+	DepositoID         int               `json:"deposito_id,omitempty"`
+	Itens              []Item            `json:"itens"`
+	Subtotal           float64           `json:"subtotal"`
+	DescontoPercentual float64           `json:"desconto_percentual"`
+	DescontoValor      float64           `json:"desconto_valor"`
+	Desconto           float64           `json:"desconto"` // total de desconto sobre o subtotal
+	Frete              float64           `json:"frete"`
+	Total              float64           `json:"total"`
+	CondicaoPagamento  CondicaoPagamento `json:"condicao_pagamento"`
+	Validade           time.Time         `json:"validade,omitempty"` // orçamentos
+	Observacoes        string            `json:"observacoes,omitempty"`
+	Status             string            `json:"status"`
+	TituloID           int               `json:"titulo_id,omitempty"` // conta a receber gerada no faturamento
+	Historico          []MudancaStatus   `json:"historico"`
+	DataCriacao        time.Time         `json:"data_criacao"`
+	DataAtualizacao    time.Time         `json:"data_atualizacao"`
+}
+
+// Editavel indica se os itens e valores ainda podem ser alterados
+func (p *Pedido) Editavel() bool {
+	return p.Status == StatusRascunho || (p.Tipo == TipoOrcamento && p.Status == StatusEnviado)
+}
+
+// FiltroPedidos restringe a listagem; campos zerados não filtram
+type FiltroPedidos struct {
+	EmpresaID  int
+	Tipo       string
+	Status     string
+	ClienteID  int
+	VendedorID int
+}
+
+// Repository define a interface para acesso aos dados de pedidos e orçamentos
+type Repository interface {
+	Create(p *Pedido) error
+	GetByID(id int, empresaID int) (*Pedido, error)
+	Update(p *Pedido) error
+	List(f FiltroPedidos, limit, offset int) ([]*Pedido, error)
+
+	// TransferirCliente reaponta para paraID os registros de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
@@ -0,0 +1,273 @@
+package pedido
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/go-pdf/fpdf"
+)
+
+// Dimensões do documento em milímetros
+const (
+	margem      = 12.0
+	larguraUtil = 186.0
+	alturaLinha = 6.0
+	alturaLogo  = 18.0
+)
+
+// tempoLogo limita a espera pelo download do logotipo da empresa
+const tempoLogo = 5 * time.Second
+
+// colunaItem descreve uma coluna da tabela de itens
+type colunaItem struct {
+	titulo  string
+	largura float64
+	alinha  string
+}
+
+var colunasItens = []colunaItem{
+	{"Código", 24, "L"},
+	{"Descrição", 68, "L"},
+	{"Un", 12, "C"},
+	{"Qtd", 18, "R"},
+	{"Preço Unit.", 24, "R"},
+	{"Desc. %", 16, "R"},
+	{"Total", 24, "R"},
+}
+
+// PDF renderiza o orçamento ou pedido com a identidade visual da empresa
+func PDF(p *Pedido, emp *empresa.Empresa, cli *cliente.Cliente) ([]byte, error) {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margem, margem, margem)
+	pdf.SetAutoPageBreak(true, margem)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("cp1252")
+
+	// Cabeçalho com logotipo e dados da empresa
+	x := margem
+	if logo := registrarLogo(pdf, emp.LogoURL); logo != "" {
+		pdf.ImageOptions(logo, margem, margem, 0, alturaLogo, false, fpdf.ImageOptions{}, 0, "")
+		x = margem + 45
+	}
+	pdf.SetXY(x, margem)
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(0, 6, tr(emp.Nome), "", 2, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 8)
+	for _, linha := range []string{rotulado("CNPJ", emp.CNPJ), emp.Endereco, juntarNaoVazios(" - ", emp.Telefone, emp.Email)} {
+		if linha != "" {
+			pdf.CellFormat(0, 4, tr(linha), "", 2, "L", false, 0, "")
+		}
+	}
+
+	titulo := "Pedido de Venda"
+	if p.Tipo == TipoOrcamento {
+		titulo = "Orçamento"
+	}
+	pdf.SetXY(margem, margem+alturaLogo+4)
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(larguraUtil/2, 8, tr(fmt.Sprintf("%s Nº %d", titulo, p.Numero)), "", 0, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(larguraUtil/2, 8, tr("Emissão: "+p.DataCriacao.Format("02/01/2006")), "", 1, "R", false, 0, "")
+	pdf.Line(margem, pdf.GetY(), margem+larguraUtil, pdf.GetY())
+	pdf.Ln(2)
+
+	// Dados do cliente
+	pdf.SetFont("Helvetica", "B", 9)
+	pdf.CellFormat(0, 5, tr("Cliente"), "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	documento := rotulado("CNPJ", cli.CNPJ)
+	if documento == "" {
+		documento = rotulado("CPF", cli.CPF)
+	}
+	pdf.CellFormat(0, 5, tr(juntarNaoVazios(" - ", cli.Nome, documento)), "", 1, "L", false, 0, "")
+	if contato := juntarNaoVazios(" - ", cli.Email, cli.Telefone); contato != "" {
+		pdf.CellFormat(0, 5, tr(contato), "", 1, "L", false, 0, "")
+	}
+	if cli.Endereco != "" {
+		pdf.CellFormat(0, 5, tr(cli.Endereco), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(3)
+
+	// Itens
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.SetFillColor(230, 230, 230)
+	for _, c := range colunasItens {
+		pdf.CellFormat(c.largura, alturaLinha, tr(c.titulo), "1", 0, c.alinha, true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 8)
+	for _, item := range p.Itens {
+		valores := []string{
+			item.SKU,
+			truncar(pdf, tr(item.Descricao), colunasItens[1].largura-2),
+			item.Unidade,
+			quantidade(item.Quantidade),
+			moeda(item.PrecoUnitario),
+			quantidade(item.DescontoPercentual),
+			moeda(item.Total),
+		}
+		for i, c := range colunasItens {
+			texto := valores[i]
+			if i != 1 {
+				texto = tr(texto)
+			}
+			pdf.CellFormat(c.largura, alturaLinha, texto, "1", 0, c.alinha, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+	pdf.Ln(3)
+
+	// Totais
+	totais := [][2]string{{"Subtotal", moeda(p.Subtotal)}}
+	if p.Desconto > 0 {
+		totais = append(totais, [2]string{"(-) Desconto", moeda(p.Desconto)})
+	}
+	if p.Frete > 0 {
+		totais = append(totais, [2]string{"(+) Frete", moeda(p.Frete)})
+	}
+	totais = append(totais, [2]string{"Total", moeda(p.Total)})
+	for i, t := range totais {
+		if i == len(totais)-1 {
+			pdf.SetFont("Helvetica", "B", 10)
+		}
+		pdf.CellFormat(larguraUtil-60, alturaLinha, "", "", 0, "", false, 0, "")
+		pdf.CellFormat(30, alturaLinha, tr(t[0]), "", 0, "L", false, 0, "")
+		pdf.CellFormat(30, alturaLinha, tr("R$ "+t[1]), "", 1, "R", false, 0, "")
+	}
+	pdf.Ln(3)
+
+	// Condições comerciais
+	pdf.SetFont("Helvetica", "", 9)
+	pdf.CellFormat(0, 5, tr("Condição de pagamento: "+descreverCondicao(p.CondicaoPagamento)), "", 1, "L", false, 0, "")
+	if p.Tipo == TipoOrcamento && !p.Validade.IsZero() {
+		pdf.CellFormat(0, 5, tr("Válido até: "+p.Validade.Format("02/01/2006")), "", 1, "L", false, 0, "")
+	}
+	if p.Observacoes != "" {
+		pdf.Ln(2)
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(0, 5, tr("Observações"), "", 1, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.MultiCell(0, 4.5, tr(p.Observacoes), "", "L", false)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// registrarLogo baixa o logotipo da empresa e o registra no documento. Falhas
+// no download não impedem a geração do PDF, que segue sem o logotipo.
+func registrarLogo(pdf *fpdf.Fpdf, url string) string {
+	if url == "" {
+		return ""
+	}
+
+	client := &http.Client{Timeout: tempoLogo}
+	resp, err := client.Get(url)
+	if err != nil {
+		return ""
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return ""
+	}
+
+	var tipo string
+	switch ct := resp.Header.Get("Content-Type"); {
+	case strings.Contains(ct, "png"):
+		tipo = "PNG"
+	case strings.Contains(ct, "jpeg"), strings.Contains(ct, "jpg"):
+		tipo = "JPG"
+	default:
+		lower := strings.ToLower(url)
+		if strings.HasSuffix(lower, ".png") {
+			tipo = "PNG"
+		} else if strings.HasSuffix(lower, ".jpg") || strings.HasSuffix(lower, ".jpeg") {
+			tipo = "JPG"
+		} else {
+			return ""
+		}
+	}
+
+	dados, err := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
+	if err != nil {
+		return ""
+	}
+
+	pdf.RegisterImageOptionsReader("logo", fpdf.ImageOptions{ImageType: tipo}, bytes.NewReader(dados))
+	if !pdf.Ok() {
+		pdf.ClearError()
+		return ""
+	}
+	return "logo"
+}
+
+// descreverCondicao monta o texto da condição de pagamento, como "30/60/90 dias"
+func descreverCondicao(c CondicaoPagamento) string {
+	if c.Descricao != "" {
+		return c.Descricao
+	}
+	if c.Parcelas <= 1 {
+		if c.PrimeiroVencDias == 0 {
+			return "À vista"
+		}
+		return fmt.Sprintf("%d dias", c.PrimeiroVencDias)
+	}
+
+	dias := make([]string, c.Parcelas)
+	for i := range dias {
+		dias[i] = fmt.Sprint(c.PrimeiroVencDias + i*c.IntervaloDias)
+	}
+	return strings.Join(dias, "/") + " dias"
+}
+
+func rotulado(rotulo, valor string) string {
+	if valor == "" {
+		return ""
+	}
+	return rotulo + " " + valor
+}
+
+func juntarNaoVazios(sep string, partes ...string) string {
+	var out []string
+	for _, p := range partes {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// truncar corta o texto para caber na largura informada
+func truncar(pdf *fpdf.Fpdf, s string, largura float64) string {
+	for len(s) > 0 && pdf.GetStringWidth(s) > largura {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func quantidade(v float64) string {
+	return strings.Replace(strings.TrimRight(strings.TrimRight(fmt.Sprintf("%.3f", v), "0"), "."), ".", ",", 1)
+}
+
+func moeda(v float64) string {
+	s := fmt.Sprintf("%.2f", v)
+	inteiro, centavos := s[:len(s)-3], s[len(s)-2:]
+
+	var partes []string
+	for len(inteiro) > 3 {
+		partes = append([]string{inteiro[len(inteiro)-3:]}, partes...)
+		inteiro = inteiro[:len(inteiro)-3]
+	}
+	partes = append([]string{inteiro}, partes...)
+	return strings.Join(partes, ".") + "," + centavos
+}
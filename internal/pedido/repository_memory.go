@@ -0,0 +1,144 @@
+package pedido
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	pedidos map[int]*Pedido
+	numeros map[chaveNumero]int // último número por empresa e tipo
+	nextID  int
+}
+
+type chaveNumero struct {
+	empresaID int
+	tipo      string
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		pedidos: make(map[int]*Pedido),
+		numeros: make(map[chaveNumero]int),
+		nextID:  1,
+	}
+}
+
+// Create adiciona um novo pedido ou orçamento, atribuindo o próximo número da empresa
+func (r *MemoryRepository) Create(p *Pedido) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if p.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if p.Tipo != TipoPedido && p.Tipo != TipoOrcamento {
+		return errors.New("tipo inválido")
+	}
+
+	chave := chaveNumero{p.EmpresaID, p.Tipo}
+	r.numeros[chave]++
+	p.Numero = r.numeros[chave]
+
+	p.ID = r.nextID
+	r.nextID++
+	p.DataCriacao = time.Now()
+	p.DataAtualizacao = p.DataCriacao
+
+	r.pedidos[p.ID] = p
+	return nil
+}
+
+// GetByID busca um pedido por ID e empresa
+func (r *MemoryRepository) GetByID(id int, empresaID int) (*Pedido, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, exists := r.pedidos[id]
+	if !exists || p.EmpresaID != empresaID {
+		return nil, errors.New("pedido não encontrado")
+	}
+	return p, nil
+}
+
+// Update atualiza um pedido existente
+func (r *MemoryRepository) Update(p *Pedido) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.pedidos[p.ID]
+	if !exists || existing.EmpresaID != p.EmpresaID {
+		return errors.New("pedido não encontrado")
+	}
+
+	// Preservar campos que não devem ser alterados
+	p.Tipo = existing.Tipo
+	p.Numero = existing.Numero
+	p.DataCriacao = existing.DataCriacao
+	p.DataAtualizacao = time.Now()
+
+	r.pedidos[p.ID] = p
+	return nil
+}
+
+// List retorna os pedidos da empresa, dos mais recentes para os mais antigos
+func (r *MemoryRepository) List(f FiltroPedidos, limit, offset int) ([]*Pedido, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Pedido, 0)
+	for _, p := range r.pedidos {
+		if p.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.Tipo != "" && p.Tipo != f.Tipo {
+			continue
+		}
+		if f.Status != "" && p.Status != f.Status {
+			continue
+		}
+		if f.ClienteID > 0 && p.ClienteID != f.ClienteID {
+			continue
+		}
+		if f.VendedorID > 0 && p.VendedorID != f.VendedorID {
+			continue
+		}
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*Pedido{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// TransferirCliente move os orçamentos e pedidos de um cliente para outro e retorna quantos foram alterados
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, p := range r.pedidos {
+		if p.EmpresaID == empresaID && p.ClienteID == deID {
+			p.ClienteID = paraID
+			total++
+		}
+	}
+	return total, nil
+}
@@ -0,0 +1,461 @@
+package pedido
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/email"
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/estoque"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/produto"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// validadePadrao é o prazo de validade dos orçamentos quando não informado
+const validadePadrao = 15 * 24 * time.Hour
+
+// NovoPedido contém os dados informados na criação ou edição de um pedido ou orçamento
+type NovoPedido struct {
+	ClienteID          int               `json:"cliente_id"`
+	DepositoID         int               `json:"deposito_id"` // zero usa o depósito padrão
+	Itens              []Item            `json:"itens"`
+	DescontoPercentual float64           `json:"desconto_percentual"`
+	DescontoValor      float64           `json:"desconto_valor"`
+	Frete              float64           `json:"frete"`
+	CondicaoPagamento  CondicaoPagamento `json:"condicao_pagamento"`
+	Validade           time.Time         `json:"validade"`
+	Observacoes        string            `json:"observacoes"`
+}
+
+// EnvioEmail contém os dados do envio de um documento por e-mail
+type EnvioEmail struct {
+	Para     []string `json:"para"`     // vazio usa o e-mail do cliente
+	Idioma   string   `json:"idioma"`   // vazio usa o idioma da marca da empresa
+	Mensagem string   `json:"mensagem"` // texto incluído no corpo do e-mail
+}
+
+// Service implementa o fluxo de orçamentos e pedidos de venda
+type Service struct {
+	repo       Repository
+	clientes   cliente.Repository
+	produtos   produto.Repository
+	precos     *produto.Service
+	estoque    *estoque.Service
+	financeiro *financeiro.Service
+	empresas   empresa.Repository
+	emails     *email.Service
+
+	// mu serializa as mudanças de status para evitar faturamentos duplicados
+	mu sync.Mutex
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, clientes cliente.Repository, produtos produto.Repository, precos *produto.Service,
+	estoqueService *estoque.Service, financeiroService *financeiro.Service, empresas empresa.Repository) *Service {
+	return &Service{
+		repo:       repo,
+		clientes:   clientes,
+		produtos:   produtos,
+		precos:     precos,
+		estoque:    estoqueService,
+		financeiro: financeiroService,
+		empresas:   empresas,
+	}
+}
+
+// UsarEmail habilita o envio dos documentos por e-mail
+func (s *Service) UsarEmail(e *email.Service) {
+	s.emails = e
+}
+
+// Criar grava um novo orçamento ou pedido em rascunho
+func (s *Service) Criar(empresaID, vendedorID int, tipo string, in NovoPedido) (*Pedido, error) {
+	p := &Pedido{
+		EmpresaID:  empresaID,
+		Tipo:       tipo,
+		VendedorID: vendedorID,
+		Status:     StatusRascunho,
+		Historico:  []MudancaStatus{},
+	}
+
+	if err := s.montar(p, in); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(p); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Atualizar substitui os itens e valores de um documento ainda editável
+func (s *Service) Atualizar(id, empresaID int, in NovoPedido) (*Pedido, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	atual, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if !atual.Editavel() {
+		return nil, fmt.Errorf("%s %s não pode ser alterado", atual.Tipo, atual.Status)
+	}
+
+	p := *atual
+	if err := s.montar(&p, in); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Update(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// AlterarStatus avança o documento no fluxo. Aprovar reserva o estoque, faturar gera
+// a conta a receber e baixa o estoque reservado, e cancelar libera as reservas.
+func (s *Service) AlterarStatus(id, empresaID, usuarioID int, status, motivo string) (*Pedido, error) {
+	if status == StatusConvertido {
+		return nil, errors.New("use a conversão do orçamento para gerar o pedido")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	atual, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validarTransicao(atual, status); err != nil {
+		return nil, err
+	}
+
+	p := *atual
+	if p.Tipo == TipoPedido {
+		switch status {
+		case StatusAprovado:
+			if err := s.reservar(&p); err != nil {
+				return nil, err
+			}
+		case StatusFaturado:
+			if err := s.faturar(&p, usuarioID); err != nil {
+				return nil, err
+			}
+		case StatusCancelado:
+			if err := s.estoque.LiberarPedido(empresaID, p.ID); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	registrar(&p, status, usuarioID, motivo)
+	if err := s.repo.Update(&p); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Converter gera um pedido em rascunho a partir de um orçamento
+func (s *Service) Converter(id, empresaID, usuarioID int) (*Pedido, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	orc, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if orc.Tipo != TipoOrcamento {
+		return nil, errors.New("apenas orçamentos podem ser convertidos")
+	}
+	if err := validarTransicao(orc, StatusConvertido); err != nil {
+		return nil, err
+	}
+	if !orc.Validade.IsZero() && time.Now().After(orc.Validade) {
+		return nil, errors.New("orçamento expirado")
+	}
+
+	itens := make([]Item, len(orc.Itens))
+	copy(itens, orc.Itens)
+
+	p := &Pedido{
+		EmpresaID:          empresaID,
+		Tipo:               TipoPedido,
+		ClienteID:          orc.ClienteID,
+		VendedorID:         orc.VendedorID,
+		OrcamentoID:        orc.ID,
+		DepositoID:         orc.DepositoID,
+		Itens:              itens,
+		Subtotal:           orc.Subtotal,
+		DescontoPercentual: orc.DescontoPercentual,
+		DescontoValor:      orc.DescontoValor,
+		Desconto:           orc.Desconto,
+		Frete:              orc.Frete,
+		Total:              orc.Total,
+		CondicaoPagamento:  orc.CondicaoPagamento,
+		Observacoes:        orc.Observacoes,
+		Status:             StatusRascunho,
+		Historico:          []MudancaStatus{},
+	}
+	if err := s.repo.Create(p); err != nil {
+		return nil, err
+	}
+
+	atualizado := *orc
+	atualizado.PedidoID = p.ID
+	registrar(&atualizado, StatusConvertido, usuarioID, fmt.Sprintf("Pedido %d", p.Numero))
+	if err := s.repo.Update(&atualizado); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Documento gera o PDF do orçamento ou pedido com os dados da empresa e do cliente
+func (s *Service) Documento(id, empresaID int) (*Pedido, []byte, error) {
+	p, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	emp, err := s.empresas.GetByID(empresaID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cli, err := s.clientes.GetByID(p.ClienteID, empresaID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	pdf, err := PDF(p, emp, cli)
+	if err != nil {
+		return nil, nil, err
+	}
+	return p, pdf, nil
+}
+
+// EnviarPorEmail envia o PDF do documento ao cliente. Orçamentos em rascunho passam a enviados.
+func (s *Service) EnviarPorEmail(id, empresaID, usuarioID int, in EnvioEmail) (*email.Mensagem, error) {
+	if s.emails == nil {
+		return nil, errors.New("envio de e-mails não configurado")
+	}
+
+	p, pdf, err := s.Documento(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	cli, err := s.clientes.GetByID(p.ClienteID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	para := in.Para
+	if len(para) == 0 {
+		if cli.Email == "" {
+			return nil, errors.New("o cliente não possui e-mail cadastrado")
+		}
+		para = []string{cli.Email}
+	}
+
+	dados := map[string]interface{}{
+		"tipo":     p.Tipo,
+		"numero":   p.Numero,
+		"cliente":  cli.Nome,
+		"total":    p.Total,
+		"mensagem": in.Mensagem,
+	}
+	if p.Tipo == TipoOrcamento && !p.Validade.IsZero() {
+		dados["validade"] = p.Validade
+	}
+
+	m, err := s.emails.Enviar(email.Envio{
+		EmpresaID: empresaID,
+		UsuarioID: usuarioID,
+		Modelo:    email.ModeloDocumentoVenda,
+		Idioma:    in.Idioma,
+		Para:      para,
+		Dados:     dados,
+		Anexos: []email.Anexo{{
+			Nome:     fmt.Sprintf("%s-%d.pdf", p.Tipo, p.Numero),
+			Tipo:     "application/pdf",
+			Conteudo: pdf,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Tipo == TipoOrcamento && p.Status == StatusRascunho {
+		motivo := "Enviado por e-mail para " + strings.Join(m.Para, ", ")
+		if _, err := s.AlterarStatus(p.ID, empresaID, usuarioID, StatusEnviado, motivo); err != nil {
+			logger.ErrorLogger.Printf("Erro ao marcar o orçamento %d como enviado: %v", p.ID, err)
+		}
+	}
+	return m, nil
+}
+
+// montar valida o cliente e os produtos, completa os itens com o catálogo e calcula os totais
+func (s *Service) montar(p *Pedido, in NovoPedido) error {
+	if in.ClienteID <= 0 {
+		return errors.New("cliente é obrigatório")
+	}
+	if _, err := s.clientes.GetByID(in.ClienteID, p.EmpresaID); err != nil {
+		return err
+	}
+	if len(in.Itens) == 0 {
+		return errors.New("informe ao menos um item")
+	}
+	if in.DescontoPercentual < 0 || in.DescontoPercentual > 100 || in.DescontoValor < 0 || in.Frete < 0 {
+		return errors.New("desconto e frete inválidos")
+	}
+
+	agora := time.Now()
+	itens := make([]Item, 0, len(in.Itens))
+	subtotal := 0.0
+	for _, item := range in.Itens {
+		prod, err := s.produtos.GetByID(item.ProdutoID, p.EmpresaID)
+		if err != nil {
+			return err
+		}
+		if !prod.Ativo {
+			return fmt.Errorf("produto %s está inativo", prod.SKU)
+		}
+		if item.Quantidade <= 0 {
+			return fmt.Errorf("quantidade inválida para o produto %s", prod.SKU)
+		}
+		if item.DescontoPercentual < 0 || item.DescontoPercentual > 100 {
+			return fmt.Errorf("desconto inválido para o produto %s", prod.SKU)
+		}
+
+		if item.PrecoUnitario <= 0 {
+			preco, err := s.precos.Preco(p.EmpresaID, prod.ID, in.ClienteID, item.Quantidade, agora)
+			if err != nil {
+				return err
+			}
+			item.PrecoUnitario = preco.PrecoUnitario
+		}
+
+		item.SKU = prod.SKU
+		item.Unidade = prod.Unidade
+		if item.Descricao == "" {
+			item.Descricao = prod.Nome
+		}
+		item.Total = arredondar(item.Quantidade * item.PrecoUnitario * (1 - item.DescontoPercentual/100))
+
+		subtotal += item.Total
+		itens = append(itens, item)
+	}
+
+	p.ClienteID = in.ClienteID
+	p.DepositoID = in.DepositoID
+	p.Itens = itens
+	p.Subtotal = arredondar(subtotal)
+	p.DescontoPercentual = in.DescontoPercentual
+	p.DescontoValor = in.DescontoValor
+	p.Desconto = arredondar(p.Subtotal*in.DescontoPercentual/100 + in.DescontoValor)
+	p.Frete = in.Frete
+	p.Total = arredondar(p.Subtotal - p.Desconto + p.Frete)
+	if p.Total < 0 {
+		return errors.New("desconto maior que o valor do pedido")
+	}
+
+	p.CondicaoPagamento = in.CondicaoPagamento
+	if p.CondicaoPagamento.Parcelas <= 0 {
+		p.CondicaoPagamento.Parcelas = 1
+	}
+	if p.CondicaoPagamento.PrimeiroVencDias < 0 || p.CondicaoPagamento.IntervaloDias < 0 {
+		return errors.New("condição de pagamento inválida")
+	}
+
+	p.Observacoes = in.Observacoes
+	if p.Tipo == TipoOrcamento {
+		p.Validade = in.Validade
+		if p.Validade.IsZero() {
+			p.Validade = agora.Add(validadePadrao)
+		}
+	}
+
+	return nil
+}
+
+// reservar separa no estoque os produtos do pedido
+func (s *Service) reservar(p *Pedido) error {
+	itens := make([]estoque.ItemReserva, 0, len(p.Itens))
+	for _, item := range p.Itens {
+		itens = append(itens, estoque.ItemReserva{
+			ProdutoID:  item.ProdutoID,
+			DepositoID: p.DepositoID,
+			Quantidade: item.Quantidade,
+		})
+	}
+
+	_, err := s.estoque.Reservar(p.EmpresaID, p.ID, itens)
+	return err
+}
+
+// faturar gera a conta a receber conforme a condição de pagamento e baixa o estoque reservado
+func (s *Service) faturar(p *Pedido, usuarioID int) error {
+	documento := fmt.Sprintf("PED-%d", p.Numero)
+
+	if p.Total > 0 {
+		cond := p.CondicaoPagamento
+		hoje := time.Now()
+		t, err := s.financeiro.CriarTitulo(p.EmpresaID, financeiro.TipoReceber, financeiro.NovoTitulo{
+			Descricao:          fmt.Sprintf("Pedido de venda %d", p.Numero),
+			Documento:          documento,
+			ClienteID:          p.ClienteID,
+			ValorTotal:         p.Total,
+			NumeroParcelas:     cond.Parcelas,
+			PrimeiroVencimento: time.Date(hoje.Year(), hoje.Month(), hoje.Day()+cond.PrimeiroVencDias, 0, 0, 0, 0, hoje.Location()),
+			IntervaloDias:      cond.IntervaloDias,
+		})
+		if err != nil {
+			return fmt.Errorf("erro ao gerar conta a receber: %w", err)
+		}
+		p.TituloID = t.ID
+	}
+
+	if _, err := s.estoque.BaixarPedido(p.EmpresaID, p.ID, usuarioID, documento); err != nil {
+		if p.TituloID > 0 {
+			if _, errCancel := s.financeiro.Cancelar(p.TituloID, p.EmpresaID); errCancel != nil {
+				logger.ErrorLogger.Printf("Falha ao cancelar o título %d do pedido %d: %v", p.TituloID, p.ID, errCancel)
+			}
+			p.TituloID = 0
+		}
+		return fmt.Errorf("erro ao baixar o estoque: %w", err)
+	}
+
+	return nil
+}
+
+// validarTransicao verifica se o fluxo permite passar do status atual para o novo
+func validarTransicao(p *Pedido, status string) error {
+	for _, permitido := range transicoes[p.Tipo][p.Status] {
+		if permitido == status {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s não pode passar de %s para %s", p.Tipo, p.Status, status)
+}
+
+// registrar aplica o novo status e o acrescenta ao histórico
+func registrar(p *Pedido, status string, usuarioID int, motivo string) {
+	p.Historico = append(append([]MudancaStatus{}, p.Historico...), MudancaStatus{
+		De:        p.Status,
+		Para:      status,
+		UsuarioID: usuarioID,
+		Motivo:    motivo,
+		Data:      time.Now(),
+	})
+	p.Status = status
+}
+
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,216 @@
+package pedido
+
+import (
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/empresa"
+	"github.com/Pantaleaogc/gvero/internal/estoque"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/produto"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// cenario monta o serviço com um cliente e um produto com 10 unidades em estoque
+type cenario struct {
+	s          *Service
+	estoque    *estoque.Service
+	financeiro *financeiro.Service
+	clienteID  int
+	produtoID  int
+}
+
+func novoCenario(t *testing.T) *cenario {
+	t.Helper()
+	clientes := cliente.NewMemoryRepository()
+	c := &cliente.Cliente{Nome: "Ana", Email: "ana@acme.com", EmpresaID: 1}
+	if err := clientes.Create(c); err != nil {
+		t.Fatal(err)
+	}
+
+	produtos := produto.NewMemoryRepository()
+	p := &produto.Produto{EmpresaID: 1, SKU: "CAN-01", Nome: "Caneta", Custo: 1, Preco: 2.5, Ativo: true}
+	if err := produtos.Create(p); err != nil {
+		t.Fatal(err)
+	}
+
+	estoqueRepo := estoque.NewMemoryRepository()
+	estoqueService := estoque.NewService(estoqueRepo, produtos)
+	if err := estoqueRepo.CreateDeposito(&estoque.Deposito{EmpresaID: 1, Codigo: "CD", Nome: "Central", Padrao: true, Ativo: true}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := estoqueService.Movimentar(1, 1, estoque.NovoMovimento{Tipo: estoque.TipoEntrada, ProdutoID: p.ID, Quantidade: 10}); err != nil {
+		t.Fatal(err)
+	}
+
+	financeiroService := financeiro.NewService(financeiro.NewMemoryRepository(), clientes)
+	s := NewService(NewMemoryRepository(), clientes, produtos, produto.NewService(produtos, clientes, nil),
+		estoqueService, financeiroService, empresa.NewMemoryRepository())
+	return &cenario{s: s, estoque: estoqueService, financeiro: financeiroService, clienteID: c.ID, produtoID: p.ID}
+}
+
+func (c *cenario) criar(t *testing.T, tipo string, quantidade float64) *Pedido {
+	t.Helper()
+	p, err := c.s.Criar(1, 1, tipo, NovoPedido{
+		ClienteID: c.clienteID,
+		Itens:     []Item{{ProdutoID: c.produtoID, Quantidade: quantidade}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func (c *cenario) saldo(t *testing.T) estoque.Saldo {
+	t.Helper()
+	saldos, err := c.estoque.Saldos(1, c.produtoID, 0)
+	if err != nil || len(saldos) != 1 {
+		t.Fatalf("saldos = %+v, %v", saldos, err)
+	}
+	return saldos[0]
+}
+
+// O pedido só percorre as transições previstas no fluxo
+func TestTransicoes(t *testing.T) {
+	tests := []struct {
+		nome     string
+		caminho  []string
+		invalido string
+	}{
+		{"faturar rascunho", nil, StatusFaturado},
+		{"entregar aprovado", []string{StatusAprovado}, StatusEntregue},
+		{"cancelar faturado", []string{StatusAprovado, StatusFaturado}, StatusCancelado},
+		{"reabrir cancelado", []string{StatusCancelado}, StatusAprovado},
+		{"converter pedido", nil, StatusConvertido},
+	}
+	for _, tt := range tests {
+		c := novoCenario(t)
+		p := c.criar(t, TipoPedido, 1)
+		for _, status := range tt.caminho {
+			if _, err := c.s.AlterarStatus(p.ID, 1, 1, status, ""); err != nil {
+				t.Fatalf("%s: %s: %v", tt.nome, status, err)
+			}
+		}
+		if _, err := c.s.AlterarStatus(p.ID, 1, 1, tt.invalido, ""); err == nil {
+			t.Errorf("%s: transição aceita", tt.nome)
+		}
+	}
+}
+
+// Aprovar reserva o estoque, faturar gera a conta a receber e baixa a reserva
+func TestAprovarEFaturar(t *testing.T) {
+	c := novoCenario(t)
+	p := c.criar(t, TipoPedido, 4)
+	if p.Total != 10 {
+		t.Fatalf("total %v, esperado 10 pelo preço do catálogo", p.Total)
+	}
+
+	if _, err := c.s.AlterarStatus(p.ID, 1, 1, StatusAprovado, ""); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.saldo(t); s.Quantidade != 10 || s.Reservado != 4 || s.Disponivel != 6 {
+		t.Errorf("saldo depois de aprovar: %+v", s)
+	}
+
+	p, err := c.s.AlterarStatus(p.ID, 1, 1, StatusFaturado, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s := c.saldo(t); s.Quantidade != 6 || s.Reservado != 0 || s.Disponivel != 6 {
+		t.Errorf("saldo depois de faturar: %+v", s)
+	}
+	titulo, err := c.financeiro.Titulo(p.TituloID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if titulo.Tipo != financeiro.TipoReceber || titulo.ValorTotal != 10 || titulo.ClienteID != c.clienteID {
+		t.Errorf("título gerado: %+v", titulo)
+	}
+	if len(p.Historico) != 2 || p.Historico[1].De != StatusAprovado || p.Historico[1].Para != StatusFaturado {
+		t.Errorf("histórico: %+v", p.Historico)
+	}
+}
+
+// Sem estoque disponível o pedido não é aprovado e nada fica reservado
+func TestAprovarSemEstoque(t *testing.T) {
+	c := novoCenario(t)
+	p := c.criar(t, TipoPedido, 11)
+
+	if _, err := c.s.AlterarStatus(p.ID, 1, 1, StatusAprovado, ""); err == nil {
+		t.Fatal("pedido aprovado sem estoque")
+	}
+	atual, _ := c.s.repo.GetByID(p.ID, 1)
+	if atual.Status != StatusRascunho {
+		t.Errorf("status %s, esperado %s", atual.Status, StatusRascunho)
+	}
+	if s := c.saldo(t); s.Reservado != 0 {
+		t.Errorf("%v unidades reservadas", s.Reservado)
+	}
+}
+
+// Cancelar um pedido aprovado devolve as reservas ao disponível
+func TestCancelarLiberaReservas(t *testing.T) {
+	c := novoCenario(t)
+	p := c.criar(t, TipoPedido, 3)
+	if _, err := c.s.AlterarStatus(p.ID, 1, 1, StatusAprovado, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.s.AlterarStatus(p.ID, 1, 1, StatusCancelado, "desistência"); err != nil {
+		t.Fatal(err)
+	}
+	if s := c.saldo(t); s.Reservado != 0 || s.Disponivel != 10 {
+		t.Errorf("saldo depois de cancelar: %+v", s)
+	}
+	if _, err := c.s.Atualizar(p.ID, 1, NovoPedido{ClienteID: c.clienteID, Itens: []Item{{ProdutoID: c.produtoID, Quantidade: 1}}}); err == nil {
+		t.Error("pedido cancelado alterado")
+	}
+}
+
+// Um orçamento vigente vira um pedido em rascunho uma única vez; um expirado não é convertido
+func TestConverterOrcamento(t *testing.T) {
+	c := novoCenario(t)
+	orc := c.criar(t, TipoOrcamento, 2)
+
+	p, err := c.s.Converter(orc.ID, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Tipo != TipoPedido || p.Status != StatusRascunho || p.OrcamentoID != orc.ID || p.Total != orc.Total {
+		t.Errorf("pedido convertido: %+v", p)
+	}
+	if _, err := c.s.Converter(orc.ID, 1, 1); err == nil {
+		t.Error("orçamento convertido duas vezes")
+	}
+
+	expirado, err := c.s.Criar(1, 1, TipoOrcamento, NovoPedido{
+		ClienteID: c.clienteID,
+		Itens:     []Item{{ProdutoID: c.produtoID, Quantidade: 1}},
+		Validade:  time.Now().Add(-time.Hour),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.s.Converter(expirado.ID, 1, 1); err == nil {
+		t.Error("orçamento expirado convertido")
+	}
+}
+
+// Um pedido de outra empresa não é encontrado
+func TestPedidoDeOutraEmpresa(t *testing.T) {
+	c := novoCenario(t)
+	p := c.criar(t, TipoPedido, 1)
+	if _, err := c.s.AlterarStatus(p.ID, 2, 1, StatusAprovado, ""); err == nil {
+		t.Error("pedido alterado por outra empresa")
+	}
+}
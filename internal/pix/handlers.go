@@ -0,0 +1,242 @@
+package pix
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/auth"
+	"github.com/Pantaleaogc/gvero/pkg/qrcode"
+	"github.com/go-chi/chi/v5"
+)
+
+// Handlers contém os manipuladores HTTP para cobranças PIX
+type Handlers struct {
+	repo    Repository
+	service *Service
+}
+
+// NewHandlers cria uma nova instância de Handlers
+func NewHandlers(repo Repository, service *Service) *Handlers {
+	return &Handlers{
+		repo:    repo,
+		service: service,
+	}
+}
+
+// Routes retorna as rotas para cobranças PIX
+func Routes(repo Repository, service *Service) http.Handler {
+	h := NewHandlers(repo, service)
+
+	r := chi.NewRouter()
+	// Middleware de autenticação
+	r.Use(auth.Middleware)
+
+	r.Get("/recebedor", h.GetRecebedor)
+	r.With(auth.RequireRole("admin")).Put("/recebedor", h.SaveRecebedor)
+
+	r.Get("/cobrancas", h.List)
+	r.Post("/cobrancas", h.Create)
+	r.Get("/cobrancas/{id}", h.GetByID)
+	r.Post("/cobrancas/{id}/cancelar", h.Cancelar)
+	r.Get("/cobrancas/{id}/qrcode.png", h.QRCodePNG)
+	r.Get("/cobrancas/{id}/qrcode.svg", h.QRCodeSVG)
+
+	return r
+}
+
+// GetRecebedor retorna a configuração PIX da empresa do usuário atual
+func (h *Handlers) GetRecebedor(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	rec, err := h.repo.GetRecebedor(user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rec)
+}
+
+// SaveRecebedor grava a configuração PIX da empresa do usuário atual
+func (h *Handlers) SaveRecebedor(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var rec Recebedor
+	if err := json.NewDecoder(r.Body).Decode(&rec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rec.EmpresaID = user.Empresa
+	if err := h.repo.SaveRecebedor(&rec); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rec)
+}
+
+// List lista as cobranças da empresa. Filtros: cliente_id e status.
+func (h *Handlers) List(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+	clienteID, _ := strconv.Atoi(r.URL.Query().Get("cliente_id"))
+
+	if limit <= 0 {
+		limit = 100 // valor padrão
+	}
+
+	cobrancas, err := h.repo.List(user.Empresa, clienteID, r.URL.Query().Get("status"), limit, offset)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	agora := time.Now()
+	for _, c := range cobrancas {
+		atualizarExpiracao(c, agora)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cobrancas)
+}
+
+// GetByID retorna uma cobrança por ID
+func (h *Handlers) GetByID(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.cobranca(w, r)
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// Create emite uma nova cobrança PIX
+func (h *Handlers) Create(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	var in NovaCobranca
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.service.CriarCobranca(user.Empresa, in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(c)
+}
+
+// Cancelar cancela uma cobrança ainda não paga
+func (h *Handlers) Cancelar(w http.ResponseWriter, r *http.Request) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.service.Cancelar(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c)
+}
+
+// QRCodePNG renderiza o QR Code da cobrança em PNG. Parâmetro opcional: tamanho (pixels).
+func (h *Handlers) QRCodePNG(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.cobranca(w, r)
+	if !ok {
+		return
+	}
+
+	tamanho, _ := strconv.Atoi(r.URL.Query().Get("tamanho"))
+	if tamanho > 1024 {
+		tamanho = 1024
+	}
+
+	png, err := qrcode.PNG(c.CopiaECola, tamanho)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/png")
+	w.Write(png)
+}
+
+// QRCodeSVG renderiza o QR Code da cobrança em SVG
+func (h *Handlers) QRCodeSVG(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.cobranca(w, r)
+	if !ok {
+		return
+	}
+
+	svg, err := qrcode.SVG(c.CopiaECola)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "image/svg+xml")
+	w.Write(svg)
+}
+
+// cobranca carrega a cobrança da URL, respondendo com erro quando não encontrada
+func (h *Handlers) cobranca(w http.ResponseWriter, r *http.Request) (*Cobranca, bool) {
+	user, ok := auth.FromContext(r.Context())
+	if !ok {
+		http.Error(w, "Não autorizado", http.StatusUnauthorized)
+		return nil, false
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "ID inválido", http.StatusBadRequest)
+		return nil, false
+	}
+
+	c, err := h.service.Cobranca(id, user.Empresa)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	}
+
+	return c, true
+}
@@ -0,0 +1,75 @@
+package pix
+
+import (
+	"time"
+)
+
+// Tipos de cobrança
+const (
+	TipoEstatica = "estatica"
+	TipoDinamica = "dinamica"
+)
+
+// Status de cobrança
+const (
+	StatusAtiva      = "ativa"
+	StatusConcluida  = "concluida"
+	StatusExpirada   = "expirada"
+	StatusCancelada  = "cancelada"
+	StatusDivergente = "divergente" // paga com valor diferente do cobrado; a baixa é feita manualmente
+)
+
+// Recebedor representa a configuração PIX de uma empresa
+type Recebedor struct {
+	EmpresaID int    `json:"empresa_id"`
+	Chave     string `json:"chave"`
+	Nome      string `json:"nome"`   // nome exibido ao pagador (até 25 caracteres)
+	Cidade    string `json:"cidade"` // até 15 caracteres
+	CEP       string `json:"cep,omitempty"`
+}
+
+// Cobranca representa uma cobrança PIX emitida pela empresa
+type Cobranca struct {
+	ID            int        `json:"id"`
+	EmpresaID     int        `json:"empresa_id"`
+	Tipo          string     `json:"tipo"`
+	TxID          string     `json:"txid"`
+	ClienteID     int        `json:"cliente_id,omitempty"`
+	TituloID      int        `json:"titulo_id,omitempty"`
+	ParcelaID     int        `json:"parcela_id,omitempty"`
+	Valor         float64    `json:"valor"`
+	Descricao     string     `json:"descricao,omitempty"`
+	Location      string     `json:"location,omitempty"` // URL da cobrança no PSP (dinâmica)
+	CopiaECola    string     `json:"copia_e_cola"`
+	Status        string     `json:"status"`
+	Expiracao     time.Time  `json:"expiracao,omitempty"`
+	DataCriacao   time.Time  `json:"data_criacao"`
+	DataPagamento *time.Time `json:"data_pagamento,omitempty"`
+	EndToEndID    string     `json:"end_to_end_id,omitempty"`
+	ValorPago     float64    `json:"valor_pago,omitempty"`
+	BaixaPendente bool       `json:"baixa_pendente,omitempty"` // paga, com a baixa da parcela ainda por fazer
+}
+
+// Pagamento representa a confirmação de um PIX recebido, no formato do webhook do BACEN
+type Pagamento struct {
+	EndToEndID  string    `json:"endToEndId"`
+	TxID        string    `json:"txid"`
+	Valor       string    `json:"valor"`
+	Horario     time.Time `json:"horario"`
+	InfoPagador string    `json:"infoPagador,omitempty"`
+}
+
+// Repository define a interface para acesso aos dados de cobranças PIX
+type Repository interface {
+	SaveRecebedor(r *Recebedor) error
+	GetRecebedor(empresaID int) (*Recebedor, error)
+
+	Create(c *Cobranca) error
+	GetByID(id int, empresaID int) (*Cobranca, error)
+	GetByTxID(txid string) (*Cobranca, error)
+	Update(c *Cobranca) error
+	List(empresaID int, clienteID int, status string, limit, offset int) ([]*Cobranca, error)
+
+	// TransferirCliente reaponta para paraID os registros de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
@@ -0,0 +1,112 @@
+package pix
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+)
+
+// CobrancaPSP contém os dados enviados ao PSP para registrar uma cobrança dinâmica
+type CobrancaPSP struct {
+	TxID               string
+	Chave              string
+	Valor              float64
+	ExpiracaoSegundos  int
+	SolicitacaoPagador string
+}
+
+// PSP define a integração com o provedor de serviços de pagamento que hospeda as cobranças dinâmicas
+type PSP interface {
+	// CriarCobranca registra a cobrança e retorna a location (URL sem protocolo) usada no QR dinâmico
+	CriarCobranca(c CobrancaPSP) (string, error)
+}
+
+// FakePSP simula um PSP localmente, para desenvolvimento e testes.
+// Pagar envia ao webhook configurado a mesma notificação que um PSP real enviaria.
+type FakePSP struct {
+	mu         sync.Mutex
+	baseURL    string
+	webhookURL string
+	segredo    string
+	client     *http.Client
+	cobrancas  map[string]CobrancaPSP
+	sequencia  int
+}
+
+// NewFakePSP cria um PSP simulado; baseURL é o host usado nas locations (ex.: "localhost:8080/pix/qr")
+func NewFakePSP(baseURL, webhookURL, segredo string) *FakePSP {
+	return &FakePSP{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		webhookURL: webhookURL,
+		segredo:    segredo,
+		client:     &http.Client{Timeout: 10 * time.Second},
+		cobrancas:  make(map[string]CobrancaPSP),
+	}
+}
+
+// CriarCobranca registra a cobrança na memória do PSP simulado
+func (p *FakePSP) CriarCobranca(c CobrancaPSP) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.cobrancas[c.TxID]; exists {
+		return "", errors.New("txid já registrado no PSP")
+	}
+
+	p.cobrancas[c.TxID] = c
+	return fmt.Sprintf("%s/v2/cobv/%s", p.baseURL, c.TxID), nil
+}
+
+// Pagar simula o pagamento de uma cobrança e notifica o webhook
+func (p *FakePSP) Pagar(txid string, valor float64) error {
+	p.mu.Lock()
+	_, exists := p.cobrancas[txid]
+	p.sequencia++
+	e2e := fmt.Sprintf("E00000000%s%011d", time.Now().Format("200601021504"), p.sequencia)
+	p.mu.Unlock()
+
+	if !exists {
+		return errors.New("cobrança não registrada no PSP")
+	}
+
+	notificacao := NotificacaoWebhook{
+		Pix: []Pagamento{{
+			EndToEndID: e2e,
+			TxID:       txid,
+			Valor:      strconv.FormatFloat(valor, 'f', 2, 64),
+			Horario:    time.Now(),
+		}},
+	}
+
+	body, err := json.Marshal(notificacao)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	timestamp := time.Now().Unix()
+	req.Header.Set(cabecalhoAssinatura, cripto.CabecalhoHMAC(p.segredo, timestamp, body))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook respondeu com status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package pix
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu          sync.RWMutex
+	recebedores map[int]*Recebedor
+	cobrancas   map[int]*Cobranca
+	nextID      int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		recebedores: make(map[int]*Recebedor),
+		cobrancas:   make(map[int]*Cobranca),
+		nextID:      1,
+	}
+}
+
+// SaveRecebedor grava a configuração PIX da empresa
+func (r *MemoryRepository) SaveRecebedor(rec *Recebedor) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if rec.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if rec.Chave == "" || rec.Nome == "" || rec.Cidade == "" {
+		return errors.New("chave, nome e cidade são obrigatórios")
+	}
+
+	r.recebedores[rec.EmpresaID] = rec
+	return nil
+}
+
+// GetRecebedor busca a configuração PIX da empresa
+func (r *MemoryRepository) GetRecebedor(empresaID int) (*Recebedor, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	rec, exists := r.recebedores[empresaID]
+	if !exists {
+		return nil, errors.New("recebedor PIX não configurado")
+	}
+	return rec, nil
+}
+
+// Create adiciona uma nova cobrança
+func (r *MemoryRepository) Create(c *Cobranca) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if c.TxID == "" || c.CopiaECola == "" {
+		return errors.New("txid e payload são obrigatórios")
+	}
+
+	// Verificar se txid já existe
+	for _, existing := range r.cobrancas {
+		if existing.TxID == c.TxID && c.TxID != "***" {
+			return errors.New("txid já utilizado")
+		}
+	}
+
+	c.ID = r.nextID
+	r.nextID++
+	c.DataCriacao = time.Now()
+
+	r.cobrancas[c.ID] = c
+	return nil
+}
+
+// GetByID busca uma cobrança por ID e empresa
+func (r *MemoryRepository) GetByID(id int, empresaID int) (*Cobranca, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.cobrancas[id]
+	if !exists || c.EmpresaID != empresaID {
+		return nil, errors.New("cobrança não encontrada")
+	}
+	return c, nil
+}
+
+// GetByTxID busca uma cobrança pelo txid informado ao PSP
+func (r *MemoryRepository) GetByTxID(txid string) (*Cobranca, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, c := range r.cobrancas {
+		if c.TxID == txid {
+			return c, nil
+		}
+	}
+	return nil, errors.New("cobrança não encontrada")
+}
+
+// Update atualiza uma cobrança existente
+func (r *MemoryRepository) Update(c *Cobranca) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.cobrancas[c.ID]
+	if !exists || existing.EmpresaID != c.EmpresaID {
+		return errors.New("cobrança não encontrada")
+	}
+
+	// Preservar campos que não devem ser alterados
+	c.DataCriacao = existing.DataCriacao
+	c.TxID = existing.TxID
+
+	r.cobrancas[c.ID] = c
+	return nil
+}
+
+// List retorna as cobranças da empresa, das mais recentes para as mais antigas
+func (r *MemoryRepository) List(empresaID int, clienteID int, status string, limit, offset int) ([]*Cobranca, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Cobranca, 0)
+	for _, c := range r.cobrancas {
+		if c.EmpresaID != empresaID {
+			continue
+		}
+		if clienteID > 0 && c.ClienteID != clienteID {
+			continue
+		}
+		if status != "" && c.Status != status {
+			continue
+		}
+		result = append(result, c)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*Cobranca{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// TransferirCliente move as cobranças de um cliente para outro e retorna quantos foram alterados
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, c := range r.cobrancas {
+		if c.EmpresaID == empresaID && c.ClienteID == deID {
+			c.ClienteID = paraID
+			total++
+		}
+	}
+	return total, nil
+}
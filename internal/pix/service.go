@@ -0,0 +1,377 @@
+package pix
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/jobs"
+	"github.com/Pantaleaogc/gvero/pkg/brcode"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// expiracaoPadrao é a validade das cobranças dinâmicas quando não informada
+const expiracaoPadrao = 24 * time.Hour
+
+// TipoJobBaixa refaz a baixa da parcela de uma cobrança paga quando ela falha na confirmação
+const TipoJobBaixa = "pix.baixar"
+
+// jobBaixa é o payload de TipoJobBaixa
+type jobBaixa struct {
+	CobrancaID int `json:"cobranca_id"`
+	EmpresaID  int `json:"empresa_id"`
+}
+
+// NovaCobranca contém os dados para emitir uma cobrança PIX.
+// Informe ParcelaID para cobrar uma conta a receber ou ClienteID para cobrar o cliente diretamente.
+type NovaCobranca struct {
+	ClienteID         int     `json:"cliente_id"`
+	ParcelaID         int     `json:"parcela_id"`
+	Valor             float64 `json:"valor"`
+	Descricao         string  `json:"descricao"`
+	Dinamica          bool    `json:"dinamica"`
+	ExpiracaoSegundos int     `json:"expiracao_segundos"`
+}
+
+// Service emite cobranças PIX e processa as confirmações de pagamento
+type Service struct {
+	repo       Repository
+	psp        PSP
+	clientes   cliente.Repository
+	financeiro financeiro.Repository
+	baixas     *financeiro.Service
+	fila       *jobs.Service
+
+	// mu serializa confirmações e cancelamentos: um reenvio do PSP concorrente com a notificação
+	// original não pode passar pela verificação de cobrança paga e baixar a parcela duas vezes
+	mu sync.Mutex
+}
+
+// NewService cria uma nova instância de Service e registra na fila o job que refaz as baixas
+func NewService(repo Repository, psp PSP, clientes cliente.Repository, financeiroRepo financeiro.Repository, baixas *financeiro.Service, fila *jobs.Service) *Service {
+	s := &Service{
+		repo:       repo,
+		psp:        psp,
+		clientes:   clientes,
+		financeiro: financeiroRepo,
+		baixas:     baixas,
+		fila:       fila,
+	}
+	fila.Registrar(TipoJobBaixa, "", s.processarBaixa)
+	return s
+}
+
+// CriarCobranca gera o payload "copia e cola" e, para cobranças dinâmicas, registra a cobrança no PSP
+func (s *Service) CriarCobranca(empresaID int, in NovaCobranca) (*Cobranca, error) {
+	rec, err := s.repo.GetRecebedor(empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Cobranca{
+		EmpresaID: empresaID,
+		Tipo:      TipoEstatica,
+		ClienteID: in.ClienteID,
+		ParcelaID: in.ParcelaID,
+		Valor:     in.Valor,
+		Descricao: in.Descricao,
+		Status:    StatusAtiva,
+	}
+
+	if in.ParcelaID > 0 {
+		t, err := s.financeiro.GetTituloPorParcela(in.ParcelaID, empresaID)
+		if err != nil {
+			return nil, err
+		}
+		if t.Tipo != financeiro.TipoReceber {
+			return nil, errors.New("apenas contas a receber podem ser cobradas via PIX")
+		}
+
+		for _, p := range t.Parcelas {
+			if p.ID != in.ParcelaID {
+				continue
+			}
+			if p.Status == financeiro.StatusPago || p.Status == financeiro.StatusCancelado {
+				return nil, fmt.Errorf("parcela já está %s", p.Status)
+			}
+			// Cobrar o saldo atualizado com multa e juros quando o valor não for informado
+			if c.Valor <= 0 {
+				multa, juros := financeiro.Encargos(t, p, time.Now())
+				c.Valor = p.Saldo() + multa + juros
+			}
+			if c.Descricao == "" {
+				c.Descricao = fmt.Sprintf("%s - parcela %d/%d", t.Descricao, p.Numero, len(t.Parcelas))
+			}
+		}
+
+		c.TituloID = t.ID
+		c.ClienteID = t.ClienteID
+	} else {
+		if in.ClienteID <= 0 {
+			return nil, errors.New("informe o cliente ou a parcela a ser cobrada")
+		}
+		if _, err := s.clientes.GetByID(in.ClienteID, empresaID); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.Valor < 0 {
+		return nil, errors.New("valor inválido")
+	}
+	c.Valor = math.Round(c.Valor*100) / 100
+
+	payload := brcode.Payload{
+		NomeRecebedor: rec.Nome,
+		Cidade:        rec.Cidade,
+		CEP:           rec.CEP,
+		Valor:         c.Valor,
+	}
+
+	if in.Dinamica {
+		if c.Valor <= 0 {
+			return nil, errors.New("cobranças dinâmicas exigem valor")
+		}
+		if s.psp == nil {
+			return nil, errors.New("PSP não configurado para cobranças dinâmicas")
+		}
+
+		expiracao := expiracaoPadrao
+		if in.ExpiracaoSegundos > 0 {
+			expiracao = time.Duration(in.ExpiracaoSegundos) * time.Second
+		}
+
+		c.Tipo = TipoDinamica
+		c.TxID = gerarTxID(32)
+		c.Expiracao = time.Now().Add(expiracao)
+
+		c.Location, err = s.psp.CriarCobranca(CobrancaPSP{
+			TxID:               c.TxID,
+			Chave:              rec.Chave,
+			Valor:              c.Valor,
+			ExpiracaoSegundos:  int(expiracao.Seconds()),
+			SolicitacaoPagador: c.Descricao,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("erro ao registrar cobrança no PSP: %w", err)
+		}
+
+		payload.URL = c.Location
+		payload.UsoUnico = true
+	} else {
+		c.TxID = gerarTxID(25)
+		payload.Chave = rec.Chave
+		payload.InfoAdicional = limitar(c.Descricao, 40)
+	}
+	payload.TxID = c.TxID
+
+	if c.CopiaECola, err = payload.String(); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.Create(c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Cobranca retorna uma cobrança com o status atualizado
+func (s *Service) Cobranca(id int, empresaID int) (*Cobranca, error) {
+	c, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	atualizarExpiracao(c, time.Now())
+	return c, nil
+}
+
+// Cancelar cancela uma cobrança ainda não paga
+func (s *Service) Cancelar(id int, empresaID int) (*Cobranca, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.repo.GetByID(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Status == StatusConcluida || c.Status == StatusDivergente {
+		return nil, errors.New("cobrança já foi paga")
+	}
+
+	c.Status = StatusCancelada
+	if err := s.repo.Update(c); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ConfirmarPagamento marca a cobrança como paga e baixa a parcela vinculada. Um valor diferente do
+// cobrado deixa a cobrança divergente, sem baixa; uma baixa que falha é refeita pela fila de jobs.
+// Notificações repetidas do mesmo endToEndId são ignoradas.
+func (s *Service) ConfirmarPagamento(p Pagamento) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.repo.GetByTxID(p.TxID)
+	if err != nil {
+		// Cobranças desconhecidas não devem gerar reenvio infinito pelo PSP
+		logger.InfoLogger.Printf("PIX %s recebido para txid desconhecido: %s", p.EndToEndID, p.TxID)
+		return nil
+	}
+
+	if c.Status == StatusConcluida || c.Status == StatusDivergente {
+		if c.EndToEndID != p.EndToEndID {
+			logger.InfoLogger.Printf("PIX %s duplicado para a cobrança %d já paga por %s", p.EndToEndID, c.ID, c.EndToEndID)
+		} else if c.BaixaPendente {
+			// O reenvio do PSP reagenda a baixa que ficou sem job
+			return s.agendarBaixa(c)
+		}
+		return nil
+	}
+
+	valor, err := strconv.ParseFloat(p.Valor, 64)
+	if err != nil || valor <= 0 {
+		return fmt.Errorf("valor inválido: %s", p.Valor)
+	}
+
+	horario := p.Horario
+	if horario.IsZero() {
+		horario = time.Now()
+	}
+
+	c.Status = StatusConcluida
+	c.EndToEndID = p.EndToEndID
+	c.ValorPago = valor
+	c.DataPagamento = &horario
+	c.BaixaPendente = c.ParcelaID > 0
+
+	// Cobranças sem valor aceitam qualquer quantia; as demais só são baixadas pelo valor exato
+	if c.Valor > 0 && centavos(valor) != centavos(c.Valor) {
+		c.Status = StatusDivergente
+		c.BaixaPendente = false
+		logger.ErrorLogger.Printf("PIX %s de R$ %.2f para a cobrança %d de R$ %.2f: valor divergente, baixa manual",
+			p.EndToEndID, valor, c.ID, c.Valor)
+	}
+
+	if err := s.repo.Update(c); err != nil {
+		return err
+	}
+
+	if c.BaixaPendente {
+		if err := s.baixar(c); err != nil {
+			logger.ErrorLogger.Printf("PIX %s recebido, mas a baixa da parcela %d falhou e será refeita: %v", p.EndToEndID, c.ParcelaID, err)
+			if err := s.agendarBaixa(c); err != nil {
+				return err
+			}
+		}
+	}
+
+	logger.InfoLogger.Printf("PIX %s confirmado para a cobrança %d (R$ %.2f)", p.EndToEndID, c.ID, valor)
+	return nil
+}
+
+// baixar registra o pagamento da cobrança na parcela e marca a baixa como feita. O pagamento é
+// identificado pelo endToEndId, para que uma baixa já registrada não seja repetida. Chamado com s.mu
+func (s *Service) baixar(c *Cobranca) error {
+	observacao := "PIX " + c.EndToEndID
+
+	t, err := s.financeiro.GetTituloPorParcela(c.ParcelaID, c.EmpresaID)
+	if err != nil {
+		return err
+	}
+	registrado := false
+	for _, parcela := range t.Parcelas {
+		if parcela.ID != c.ParcelaID {
+			continue
+		}
+		for _, pg := range parcela.Pagamentos {
+			registrado = registrado || pg.Observacao == observacao
+		}
+	}
+
+	if !registrado {
+		if _, err := s.baixas.RegistrarPagamento(c.EmpresaID, c.ParcelaID, financeiro.NovoPagamento{
+			Data:       *c.DataPagamento,
+			Valor:      c.ValorPago,
+			Observacao: observacao,
+		}); err != nil {
+			return err
+		}
+	}
+
+	c.BaixaPendente = false
+	return s.repo.Update(c)
+}
+
+// agendarBaixa enfileira a baixa da cobrança; a chave evita dois jobs ativos para a mesma cobrança
+func (s *Service) agendarBaixa(c *Cobranca) error {
+	_, err := s.fila.Enfileirar(c.EmpresaID, TipoJobBaixa, jobBaixa{CobrancaID: c.ID, EmpresaID: c.EmpresaID},
+		jobs.Opcoes{Chave: "cobranca-" + strconv.Itoa(c.ID)})
+	return err
+}
+
+// processarBaixa refaz a baixa de uma cobrança paga. Esgotadas as tentativas, o job fica morto na
+// fila e a cobrança continua com a baixa pendente para conciliação manual
+func (s *Service) processarBaixa(ctx context.Context, j *jobs.Job) error {
+	var in jobBaixa
+	if err := j.Decodificar(&in); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.repo.GetByID(in.CobrancaID, in.EmpresaID)
+	if err != nil {
+		return err
+	}
+	if !c.BaixaPendente {
+		return nil
+	}
+	return s.baixar(c)
+}
+
+// centavos converte o valor em reais para centavos inteiros, evitando comparar frações de ponto flutuante
+func centavos(v float64) int64 {
+	return int64(math.Round(v * 100))
+}
+
+// atualizarExpiracao marca como expirada a cobrança dinâmica vencida
+func atualizarExpiracao(c *Cobranca, agora time.Time) {
+	if c.Status == StatusAtiva && c.Tipo == TipoDinamica && agora.After(c.Expiracao) {
+		c.Status = StatusExpirada
+	}
+}
+
+const alfabetoTxID = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// gerarTxID gera um identificador alfanumérico aleatório aceito pelo arranjo PIX
+func gerarTxID(tamanho int) string {
+	b := make([]byte, tamanho)
+	max := big.NewInt(int64(len(alfabetoTxID)))
+	for i := range b {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			panic(err)
+		}
+		b[i] = alfabetoTxID[n.Int64()]
+	}
+	return string(b)
+}
+
+func limitar(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
@@ -0,0 +1,161 @@
+package pix
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+	"github.com/Pantaleaogc/gvero/internal/financeiro"
+	"github.com/Pantaleaogc/gvero/internal/jobs"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// financeiroInstavel falha as consultas de títulos enquanto houver falhas programadas
+type financeiroInstavel struct {
+	financeiro.Repository
+	falhas int
+}
+
+func (r *financeiroInstavel) GetTituloPorParcela(parcelaID, empresaID int) (*financeiro.Titulo, error) {
+	if r.falhas > 0 {
+		r.falhas--
+		return nil, errors.New("banco indisponível")
+	}
+	return r.Repository.GetTituloPorParcela(parcelaID, empresaID)
+}
+
+type cenario struct {
+	service    *Service
+	financeiro *financeiroInstavel
+	baixas     *financeiro.Service
+	jobs       jobs.Repository
+	cobranca   *Cobranca
+	parcelaID  int
+}
+
+// novoCenario emite uma cobrança de R$ 100,00 para a parcela única de uma conta a receber
+func novoCenario(t *testing.T) *cenario {
+	t.Helper()
+	clientes := cliente.NewMemoryRepository()
+	c := &cliente.Cliente{Nome: "Ana", Email: "ana@acme.com", EmpresaID: 1}
+	if err := clientes.Create(c); err != nil {
+		t.Fatal(err)
+	}
+
+	fin := &financeiroInstavel{Repository: financeiro.NewMemoryRepository()}
+	baixas := financeiro.NewService(fin, clientes)
+	titulo, err := baixas.CriarTitulo(1, financeiro.TipoReceber, financeiro.NovoTitulo{
+		Descricao:          "Venda",
+		ClienteID:          c.ID,
+		ValorTotal:         100,
+		NumeroParcelas:     1,
+		PrimeiroVencimento: time.Now().AddDate(0, 0, 10),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := NewMemoryRepository()
+	if err := repo.SaveRecebedor(&Recebedor{EmpresaID: 1, Chave: "pix@acme.com", Nome: "Acme", Cidade: "Sao Paulo"}); err != nil {
+		t.Fatal(err)
+	}
+	filaRepo := jobs.NewMemoryRepository()
+	s := NewService(repo, nil, clientes, fin, baixas, jobs.NewService(filaRepo))
+
+	cobranca, err := s.CriarCobranca(1, NovaCobranca{ParcelaID: titulo.Parcelas[0].ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &cenario{service: s, financeiro: fin, baixas: baixas, jobs: filaRepo, cobranca: cobranca, parcelaID: titulo.Parcelas[0].ID}
+}
+
+// parcela retorna a parcela cobrada como está gravada
+func (c *cenario) parcela(t *testing.T) *financeiro.Parcela {
+	t.Helper()
+	titulo, err := c.financeiro.Repository.GetTituloPorParcela(c.parcelaID, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return titulo.Parcelas[0]
+}
+
+func TestConfirmarPagamento(t *testing.T) {
+	tests := []struct {
+		nome       string
+		valor      string
+		status     string
+		pagamentos int
+	}{
+		{"valor exato", "100.00", StatusConcluida, 1},
+		{"valor menor", "10.00", StatusDivergente, 0},
+		{"valor maior", "100.01", StatusDivergente, 0},
+	}
+	for _, tt := range tests {
+		c := novoCenario(t)
+		p := Pagamento{EndToEndID: "E1", TxID: c.cobranca.TxID, Valor: tt.valor}
+
+		// A notificação repetida pelo PSP não baixa a parcela de novo
+		for i := 0; i < 2; i++ {
+			if err := c.service.ConfirmarPagamento(p); err != nil {
+				t.Fatalf("%s: %v", tt.nome, err)
+			}
+		}
+
+		cobranca, _ := c.service.Cobranca(c.cobranca.ID, 1)
+		if cobranca.Status != tt.status || cobranca.BaixaPendente {
+			t.Errorf("%s: cobrança %s, baixa pendente %v", tt.nome, cobranca.Status, cobranca.BaixaPendente)
+		}
+		if n := len(c.parcela(t).Pagamentos); n != tt.pagamentos {
+			t.Errorf("%s: %d pagamentos na parcela, esperado %d", tt.nome, n, tt.pagamentos)
+		}
+	}
+}
+
+// A baixa que falha na confirmação fica pendente e é feita uma única vez pelo job
+func TestBaixaRefeitaPelaFila(t *testing.T) {
+	c := novoCenario(t)
+	c.financeiro.falhas = 1
+
+	p := Pagamento{EndToEndID: "E2", TxID: c.cobranca.TxID, Valor: "100.00"}
+	if err := c.service.ConfirmarPagamento(p); err != nil {
+		t.Fatal(err)
+	}
+	cobranca, _ := c.service.Cobranca(c.cobranca.ID, 1)
+	if cobranca.Status != StatusConcluida || !cobranca.BaixaPendente {
+		t.Fatalf("cobrança %s, baixa pendente %v", cobranca.Status, cobranca.BaixaPendente)
+	}
+
+	// O reenvio do PSP não duplica o job ativo
+	if err := c.service.ConfirmarPagamento(p); err != nil {
+		t.Fatal(err)
+	}
+	pendentes, _ := c.jobs.List(jobs.Filtro{Tipo: TipoJobBaixa}, 0, 0)
+	if len(pendentes) != 1 {
+		t.Fatalf("%d jobs de baixa, esperado 1", len(pendentes))
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := c.service.processarBaixa(context.Background(), pendentes[0]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	parcela := c.parcela(t)
+	if parcela.Status != financeiro.StatusPago || len(parcela.Pagamentos) != 1 {
+		t.Errorf("parcela %s com %d pagamentos", parcela.Status, len(parcela.Pagamentos))
+	}
+	if cobranca, _ := c.service.Cobranca(c.cobranca.ID, 1); cobranca.BaixaPendente {
+		t.Error("baixa continua pendente")
+	}
+}
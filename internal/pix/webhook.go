@@ -0,0 +1,73 @@
+package pix
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/go-chi/chi/v5"
+)
+
+// cabecalhoAssinatura contém o timestamp e a assinatura HMAC da notificação: "t=<unix>,v1=<hex>"
+const cabecalhoAssinatura = "X-Pix-Signature"
+
+// toleranciaWebhook é a diferença aceita entre o timestamp assinado e o relógio do servidor
+const toleranciaWebhook = 5 * time.Minute
+
+// tamanhoMaximoWebhook limita o corpo aceito no webhook
+const tamanhoMaximoWebhook = 1 << 20
+
+// NotificacaoWebhook é o corpo enviado pelo PSP ao confirmar pagamentos
+type NotificacaoWebhook struct {
+	Pix []Pagamento `json:"pix"`
+}
+
+// Receptor processa as confirmações de pagamento recebidas pelo webhook
+type Receptor interface {
+	ConfirmarPagamento(p Pagamento) error
+}
+
+// WebhookRoutes retorna as rotas do webhook de confirmação do PSP.
+// As requisições são autenticadas pela assinatura HMAC, não pelo JWT.
+func WebhookRoutes(receptor Receptor, segredo string) http.Handler {
+	r := chi.NewRouter()
+	r.Post("/", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(io.LimitReader(r.Body, tamanhoMaximoWebhook))
+		if err != nil {
+			http.Error(w, "Erro ao ler requisição", http.StatusBadRequest)
+			return
+		}
+
+		if segredo == "" {
+			http.Error(w, "Assinatura inválida", http.StatusUnauthorized)
+			return
+		}
+		if err := cripto.VerificarHMAC(segredo, r.Header.Get(cabecalhoAssinatura), body, toleranciaWebhook, time.Now()); err != nil {
+			logger.InfoLogger.Printf("Webhook PIX recusado de %s: %v", r.RemoteAddr, err)
+			http.Error(w, "Assinatura inválida", http.StatusUnauthorized)
+			return
+		}
+
+		var n NotificacaoWebhook
+		if err := json.Unmarshal(body, &n); err != nil {
+			http.Error(w, "Formato inválido", http.StatusBadRequest)
+			return
+		}
+
+		for _, p := range n.Pix {
+			if err := receptor.ConfirmarPagamento(p); err != nil {
+				logger.ErrorLogger.Printf("Erro ao confirmar PIX %s (txid %s): %v", p.EndToEndID, p.TxID, err)
+				// O PSP reenvia notificações não confirmadas
+				http.Error(w, "Erro ao processar pagamento", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusOK)
+	})
+
+	return r
+}
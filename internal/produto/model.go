@@ -0,0 +1,122 @@
+package produto
+
+import (
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
+)
+
+// Tipos de item do catálogo
+const (
+	TipoProduto = "produto"
+	TipoServico = "servico"
+)
+
+// Tributacao contém a classificação fiscal usada na emissão de documentos fiscais
+type Tributacao struct {
+	Origem        int     `json:"origem"`         // origem da mercadoria (0 a 8)
+	CFOP          string  `json:"cfop,omitempty"` // CFOP padrão de venda
+	CST           string  `json:"cst,omitempty"`  // CST do ICMS ou CSOSN no Simples Nacional
+	AliquotaICMS  float64 `json:"aliquota_icms"`  // em %
+	AliquotaIPI   float64 `json:"aliquota_ipi"`   // em %
+	CSTPIS        string  `json:"cst_pis,omitempty"`
+	CSTCOFINS     string  `json:"cst_cofins,omitempty"`
+	CodigoServico string  `json:"codigo_servico,omitempty"` // item da lista da LC 116 (serviços)
+	AliquotaISS   float64 `json:"aliquota_iss"`             // em %
+}
+
+// Produto representa um produto ou serviço do catálogo da empresa
+type Produto struct {
+	ID              int        `json:"id"`
+	EmpresaID       int        `json:"empresa_id"`
+	Tipo            string     `json:"tipo"` // "produto" ou "servico"
+	SKU             string     `json:"sku"`
+	Nome            string     `json:"nome"`
+	Descricao       string     `json:"descricao,omitempty"`
+	Categoria       string     `json:"categoria,omitempty"`
+	NCM             string     `json:"ncm,omitempty"`
+	Unidade         string     `json:"unidade"` // UN, KG, CX, HR...
+	Custo           float64    `json:"custo"`
+	Preco           float64    `json:"preco"`
+	Tributacao      Tributacao `json:"tributacao"`
+	Ativo           bool       `json:"ativo"`
+	DataCriacao     time.Time  `json:"data_criacao"`
+	DataAtualizacao time.Time  `json:"data_atualizacao"`
+
+	Campos campo.Valores `json:"campos,omitempty"` // campos personalizados definidos pela empresa
+}
+
+// TabelaPreco representa uma lista de preços da empresa.
+// Sem clientes vinculados, a tabela vale para todos; com clientes, apenas para eles.
+type TabelaPreco struct {
+	ID             int          `json:"id"`
+	EmpresaID      int          `json:"empresa_id"`
+	Nome           string       `json:"nome"`
+	ClienteIDs     []int        `json:"cliente_ids,omitempty"`
+	VigenciaInicio time.Time    `json:"vigencia_inicio,omitempty"`
+	VigenciaFim    time.Time    `json:"vigencia_fim,omitempty"`
+	Ativa          bool         `json:"ativa"`
+	Itens          []ItemTabela `json:"itens"`
+	DataCriacao    time.Time    `json:"data_criacao"`
+}
+
+// ItemTabela define o preço de um produto a partir de uma quantidade mínima.
+// Várias faixas do mesmo produto formam o preço escalonado por quantidade.
+type ItemTabela struct {
+	ProdutoID        int     `json:"produto_id"`
+	QuantidadeMinima float64 `json:"quantidade_minima"`
+	Preco            float64 `json:"preco"`
+}
+
+// Vigente indica se a tabela está ativa e dentro da vigência na data informada
+func (t *TabelaPreco) Vigente(data time.Time) bool {
+	if !t.Ativa {
+		return false
+	}
+	if !t.VigenciaInicio.IsZero() && data.Before(t.VigenciaInicio) {
+		return false
+	}
+	if !t.VigenciaFim.IsZero() && data.After(t.VigenciaFim) {
+		return false
+	}
+	return true
+}
+
+// AtendeCliente indica se a tabela é específica para o cliente informado
+func (t *TabelaPreco) AtendeCliente(clienteID int) bool {
+	for _, id := range t.ClienteIDs {
+		if id == clienteID {
+			return true
+		}
+	}
+	return false
+}
+
+// FiltroProdutos restringe a listagem do catálogo; campos zerados não filtram
+type FiltroProdutos struct {
+	EmpresaID int
+	Busca     string // nome, SKU, descrição, NCM ou campos personalizados
+	Categoria string
+	Tipo      string
+	Ativo     *bool
+	Campos    []campo.Filtro
+}
+
+// Repository define a interface para acesso aos dados do catálogo
+type Repository interface {
+	Create(p *Produto) error
+	GetByID(id int, empresaID int) (*Produto, error)
+	GetBySKU(sku string, empresaID int) (*Produto, error)
+	Update(p *Produto) error
+	Delete(id int, empresaID int) error
+	List(f FiltroProdutos, limit, offset int) ([]*Produto, error)
+
+	CreateTabela(t *TabelaPreco) error
+	GetTabela(id int, empresaID int) (*TabelaPreco, error)
+	UpdateTabela(t *TabelaPreco) error
+	DeleteTabela(id int, empresaID int) error
+	ListTabelas(empresaID int) ([]*TabelaPreco, error)
+
+	// TransferirCliente reaponta para paraID os registros de deID; usado na mesclagem de clientes
+	TransferirCliente(empresaID, deID, paraID int) (int, error)
+}
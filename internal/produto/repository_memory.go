@@ -0,0 +1,360 @@
+package produto
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu           sync.RWMutex
+	produtos     map[int]*Produto
+	tabelas      map[int]*TabelaPreco
+	nextID       int
+	nextTabelaID int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		produtos:     make(map[int]*Produto),
+		tabelas:      make(map[int]*TabelaPreco),
+		nextID:       1,
+		nextTabelaID: 1,
+	}
+}
+
+// Create adiciona um novo produto ao catálogo
+func (r *MemoryRepository) Create(p *Produto) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := validarProduto(p); err != nil {
+		return err
+	}
+
+	// Verificar se o SKU já existe na empresa
+	for _, existing := range r.produtos {
+		if existing.EmpresaID == p.EmpresaID && strings.EqualFold(existing.SKU, p.SKU) {
+			return errors.New("SKU já cadastrado")
+		}
+	}
+
+	p.ID = r.nextID
+	r.nextID++
+	p.DataCriacao = time.Now()
+	p.DataAtualizacao = p.DataCriacao
+
+	r.produtos[p.ID] = p
+	return nil
+}
+
+// GetByID busca um produto por ID e empresa
+func (r *MemoryRepository) GetByID(id int, empresaID int) (*Produto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	p, exists := r.produtos[id]
+	if !exists || p.EmpresaID != empresaID {
+		return nil, errors.New("produto não encontrado")
+	}
+	return p, nil
+}
+
+// GetBySKU busca um produto pelo SKU na empresa
+func (r *MemoryRepository) GetBySKU(sku string, empresaID int) (*Produto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, p := range r.produtos {
+		if p.EmpresaID == empresaID && strings.EqualFold(p.SKU, sku) {
+			return p, nil
+		}
+	}
+	return nil, errors.New("produto não encontrado")
+}
+
+// Update atualiza um produto existente
+func (r *MemoryRepository) Update(p *Produto) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.produtos[p.ID]
+	if !exists || existing.EmpresaID != p.EmpresaID {
+		return errors.New("produto não encontrado")
+	}
+
+	if err := validarProduto(p); err != nil {
+		return err
+	}
+
+	for _, outro := range r.produtos {
+		if outro.ID != p.ID && outro.EmpresaID == p.EmpresaID && strings.EqualFold(outro.SKU, p.SKU) {
+			return errors.New("SKU já cadastrado")
+		}
+	}
+
+	// Preservar campos que não devem ser alterados
+	p.DataCriacao = existing.DataCriacao
+	p.DataAtualizacao = time.Now()
+
+	r.produtos[p.ID] = p
+	return nil
+}
+
+// Delete remove um produto que não esteja em nenhuma tabela de preços
+func (r *MemoryRepository) Delete(id int, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	p, exists := r.produtos[id]
+	if !exists || p.EmpresaID != empresaID {
+		return errors.New("produto não encontrado")
+	}
+
+	for _, t := range r.tabelas {
+		for _, item := range t.Itens {
+			if item.ProdutoID == id {
+				return errors.New("produto possui preços em tabelas; inative-o em vez de excluir")
+			}
+		}
+	}
+
+	delete(r.produtos, id)
+	return nil
+}
+
+// List retorna os produtos da empresa ordenados por nome
+func (r *MemoryRepository) List(f FiltroProdutos, limit, offset int) ([]*Produto, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	busca := strings.ToLower(f.Busca)
+
+	result := make([]*Produto, 0)
+	for _, p := range r.produtos {
+		if p.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.Categoria != "" && !strings.EqualFold(p.Categoria, f.Categoria) {
+			continue
+		}
+		if f.Tipo != "" && p.Tipo != f.Tipo {
+			continue
+		}
+		if f.Ativo != nil && p.Ativo != *f.Ativo {
+			continue
+		}
+		if !p.Campos.Atende(f.Campos) {
+			continue
+		}
+
+		// Buscar por nome, SKU, descrição, NCM ou campos personalizados
+		if busca != "" {
+			match := strings.Contains(strings.ToLower(p.Nome), busca) ||
+				strings.Contains(strings.ToLower(p.SKU), busca) ||
+				strings.Contains(strings.ToLower(p.Descricao), busca) ||
+				strings.Contains(p.NCM, busca) ||
+				p.Campos.Contem(busca)
+			if !match {
+				continue
+			}
+		}
+
+		result = append(result, p)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return strings.ToLower(result[i].Nome) < strings.ToLower(result[j].Nome)
+	})
+
+	return paginar(result, limit, offset), nil
+}
+
+// CreateTabela adiciona uma nova tabela de preços
+func (r *MemoryRepository) CreateTabela(t *TabelaPreco) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.validarTabela(t); err != nil {
+		return err
+	}
+
+	t.ID = r.nextTabelaID
+	r.nextTabelaID++
+	t.DataCriacao = time.Now()
+
+	r.tabelas[t.ID] = t
+	return nil
+}
+
+// GetTabela busca uma tabela de preços por ID e empresa
+func (r *MemoryRepository) GetTabela(id int, empresaID int) (*TabelaPreco, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t, exists := r.tabelas[id]
+	if !exists || t.EmpresaID != empresaID {
+		return nil, errors.New("tabela de preços não encontrada")
+	}
+	return t, nil
+}
+
+// UpdateTabela atualiza uma tabela de preços existente
+func (r *MemoryRepository) UpdateTabela(t *TabelaPreco) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	existing, exists := r.tabelas[t.ID]
+	if !exists || existing.EmpresaID != t.EmpresaID {
+		return errors.New("tabela de preços não encontrada")
+	}
+
+	if err := r.validarTabela(t); err != nil {
+		return err
+	}
+
+	// Preservar campos que não devem ser alterados
+	t.DataCriacao = existing.DataCriacao
+
+	r.tabelas[t.ID] = t
+	return nil
+}
+
+// DeleteTabela remove uma tabela de preços
+func (r *MemoryRepository) DeleteTabela(id int, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, exists := r.tabelas[id]
+	if !exists || t.EmpresaID != empresaID {
+		return errors.New("tabela de preços não encontrada")
+	}
+
+	delete(r.tabelas, id)
+	return nil
+}
+
+// ListTabelas retorna as tabelas de preços da empresa
+func (r *MemoryRepository) ListTabelas(empresaID int) ([]*TabelaPreco, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*TabelaPreco, 0)
+	for _, t := range r.tabelas {
+		if t.EmpresaID == empresaID {
+			result = append(result, t)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// validarTabela verifica os campos e os produtos da tabela (deve ser chamado com o lock adquirido)
+func (r *MemoryRepository) validarTabela(t *TabelaPreco) error {
+	if t.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if t.Nome == "" {
+		return errors.New("nome é obrigatório")
+	}
+
+	if !t.VigenciaInicio.IsZero() && !t.VigenciaFim.IsZero() && t.VigenciaFim.Before(t.VigenciaInicio) {
+		return errors.New("fim da vigência anterior ao início")
+	}
+
+	for _, item := range t.Itens {
+		p, exists := r.produtos[item.ProdutoID]
+		if !exists || p.EmpresaID != t.EmpresaID {
+			return errors.New("produto da tabela não encontrado")
+		}
+		if item.Preco < 0 || item.QuantidadeMinima < 0 {
+			return errors.New("preço e quantidade mínima não podem ser negativos")
+		}
+	}
+
+	if t.Itens == nil {
+		t.Itens = []ItemTabela{}
+	}
+	return nil
+}
+
+// validarProduto verifica os campos obrigatórios do produto
+func validarProduto(p *Produto) error {
+	if p.EmpresaID <= 0 {
+		return errors.New("empresa inválida")
+	}
+
+	if p.Nome == "" || p.SKU == "" {
+		return errors.New("nome e SKU são obrigatórios")
+	}
+
+	if p.Tipo == "" {
+		p.Tipo = TipoProduto
+	}
+	if p.Tipo != TipoProduto && p.Tipo != TipoServico {
+		return errors.New("tipo deve ser \"produto\" ou \"servico\"")
+	}
+
+	if p.NCM != "" && len(p.NCM) != 8 {
+		return errors.New("NCM deve ter 8 dígitos")
+	}
+	for _, c := range p.NCM {
+		if c < '0' || c > '9' {
+			return errors.New("NCM deve ter 8 dígitos")
+		}
+	}
+
+	if p.Preco < 0 || p.Custo < 0 {
+		return errors.New("preço e custo não podem ser negativos")
+	}
+
+	if p.Unidade == "" {
+		p.Unidade = "UN"
+	}
+	p.Unidade = strings.ToUpper(p.Unidade)
+
+	return nil
+}
+
+func paginar[T any](itens []T, limit, offset int) []T {
+	if offset >= len(itens) {
+		return []T{}
+	}
+	itens = itens[offset:]
+
+	if limit > 0 && limit < len(itens) {
+		itens = itens[:limit]
+	}
+	return itens
+}
+
+// TransferirCliente substitui o cliente nas tabelas de preço restritas a ele e retorna quantas foram alteradas
+func (r *MemoryRepository) TransferirCliente(empresaID, deID, paraID int) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	total := 0
+	for _, t := range r.tabelas {
+		if t.EmpresaID != empresaID || !t.AtendeCliente(deID) {
+			continue
+		}
+		ids := make([]int, 0, len(t.ClienteIDs))
+		for _, id := range t.ClienteIDs {
+			if id != deID && id != paraID {
+				ids = append(ids, id)
+			}
+		}
+		t.ClienteIDs = append(ids, paraID)
+		total++
+	}
+	return total, nil
+}
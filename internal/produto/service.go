@@ -0,0 +1,251 @@
+package produto
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/campo"
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// PrecoCalculado é o preço unitário de um produto para um cliente e uma quantidade
+type PrecoCalculado struct {
+	ProdutoID        int     `json:"produto_id"`
+	ClienteID        int     `json:"cliente_id,omitempty"`
+	Quantidade       float64 `json:"quantidade"`
+	PrecoBase        float64 `json:"preco_base"`
+	PrecoUnitario    float64 `json:"preco_unitario"`
+	Total            float64 `json:"total"`
+	TabelaID         int     `json:"tabela_id,omitempty"`
+	Tabela           string  `json:"tabela,omitempty"`
+	QuantidadeMinima float64 `json:"quantidade_minima,omitempty"` // faixa aplicada
+}
+
+// Reajuste descreve uma alteração percentual de preços em lote.
+// Informe a categoria ou a lista de produtos; TabelaID zero reajusta o preço base do catálogo.
+type Reajuste struct {
+	Categoria  string  `json:"categoria"`
+	ProdutoIDs []int   `json:"produto_ids"`
+	TabelaID   int     `json:"tabela_id"`
+	Percentual float64 `json:"percentual"` // ex.: 10 para +10%, -5 para -5%
+}
+
+// ResultadoReajuste informa quantos preços foram alterados
+type ResultadoReajuste struct {
+	Produtos int `json:"produtos"`
+	Itens    int `json:"itens"` // faixas de tabela alteradas
+}
+
+// Service implementa o cadastro, a formação de preços e os reajustes do catálogo
+type Service struct {
+	repo     Repository
+	clientes cliente.Repository
+	campos   *campo.Service
+}
+
+// NewService cria uma nova instância de Service
+func NewService(repo Repository, clientes cliente.Repository, campos *campo.Service) *Service {
+	return &Service{
+		repo:     repo,
+		clientes: clientes,
+		campos:   campos,
+	}
+}
+
+// Criar valida os campos personalizados e cadastra o produto
+func (s *Service) Criar(p *Produto) error {
+	valores, err := s.campos.Validar(p.EmpresaID, campo.EntidadeProduto, p.Campos)
+	if err != nil {
+		return err
+	}
+	p.Campos = valores
+	return s.repo.Create(p)
+}
+
+// Atualizar valida os campos personalizados e atualiza o produto
+func (s *Service) Atualizar(p *Produto) error {
+	valores, err := s.campos.Validar(p.EmpresaID, campo.EntidadeProduto, p.Campos)
+	if err != nil {
+		return err
+	}
+	p.Campos = valores
+	return s.repo.Update(p)
+}
+
+// SalvarTabela cria ou atualiza uma tabela de preços, validando os clientes vinculados
+func (s *Service) SalvarTabela(t *TabelaPreco) error {
+	for _, id := range t.ClienteIDs {
+		if _, err := s.clientes.GetByID(id, t.EmpresaID); err != nil {
+			return err
+		}
+	}
+
+	if t.ID > 0 {
+		return s.repo.UpdateTabela(t)
+	}
+	return s.repo.CreateTabela(t)
+}
+
+// Preco calcula o preço unitário do produto. Tabelas específicas do cliente têm prioridade
+// sobre as tabelas gerais; dentro de cada tabela vale a maior faixa atingida pela quantidade.
+// Se mais de uma tabela do mesmo nível se aplicar, prevalece o menor preço.
+func (s *Service) Preco(empresaID, produtoID, clienteID int, quantidade float64, data time.Time) (*PrecoCalculado, error) {
+	p, err := s.repo.GetByID(produtoID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Ativo {
+		return nil, errors.New("produto inativo")
+	}
+	if quantidade <= 0 {
+		quantidade = 1
+	}
+
+	tabelas, err := s.repo.ListTabelas(empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &PrecoCalculado{
+		ProdutoID:     p.ID,
+		ClienteID:     clienteID,
+		Quantidade:    quantidade,
+		PrecoBase:     p.Preco,
+		PrecoUnitario: p.Preco,
+	}
+
+	var melhorCliente, melhorGeral *candidato
+	for _, t := range tabelas {
+		if !t.Vigente(data) {
+			continue
+		}
+
+		var alvo **candidato
+		switch {
+		case len(t.ClienteIDs) == 0:
+			alvo = &melhorGeral
+		case clienteID > 0 && t.AtendeCliente(clienteID):
+			alvo = &melhorCliente
+		default:
+			continue
+		}
+
+		item, ok := faixa(t, p.ID, quantidade)
+		if !ok {
+			continue
+		}
+		if *alvo == nil || item.Preco < (*alvo).item.Preco {
+			*alvo = &candidato{tabela: t, item: item}
+		}
+	}
+
+	escolhido := melhorCliente
+	if escolhido == nil {
+		escolhido = melhorGeral
+	}
+	if escolhido != nil {
+		res.PrecoUnitario = escolhido.item.Preco
+		res.TabelaID = escolhido.tabela.ID
+		res.Tabela = escolhido.tabela.Nome
+		res.QuantidadeMinima = escolhido.item.QuantidadeMinima
+	}
+
+	res.Total = arredondar(res.PrecoUnitario * quantidade)
+	return res, nil
+}
+
+// Reajustar aplica um percentual sobre os preços selecionados
+func (s *Service) Reajustar(empresaID int, in Reajuste) (*ResultadoReajuste, error) {
+	if in.Categoria == "" && len(in.ProdutoIDs) == 0 {
+		return nil, errors.New("informe a categoria ou os produtos a reajustar")
+	}
+	if in.Percentual <= -100 {
+		return nil, errors.New("percentual inválido")
+	}
+
+	produtos, err := s.repo.List(FiltroProdutos{EmpresaID: empresaID, Categoria: in.Categoria}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	selecionados := make(map[int]*Produto)
+	for _, p := range produtos {
+		if len(in.ProdutoIDs) > 0 && !contem(in.ProdutoIDs, p.ID) {
+			continue
+		}
+		selecionados[p.ID] = p
+	}
+
+	fator := 1 + in.Percentual/100
+	res := &ResultadoReajuste{}
+
+	if in.TabelaID == 0 {
+		for _, p := range selecionados {
+			atualizado := *p
+			atualizado.Preco = arredondar(p.Preco * fator)
+			if err := s.repo.Update(&atualizado); err != nil {
+				return res, err
+			}
+			res.Produtos++
+		}
+		return res, nil
+	}
+
+	t, err := s.repo.GetTabela(in.TabelaID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	atualizada := *t
+	atualizada.Itens = make([]ItemTabela, len(t.Itens))
+	reajustados := make(map[int]bool)
+	for i, item := range t.Itens {
+		if _, ok := selecionados[item.ProdutoID]; ok {
+			item.Preco = arredondar(item.Preco * fator)
+			reajustados[item.ProdutoID] = true
+			res.Itens++
+		}
+		atualizada.Itens[i] = item
+	}
+
+	if err := s.repo.UpdateTabela(&atualizada); err != nil {
+		return nil, err
+	}
+	res.Produtos = len(reajustados)
+	return res, nil
+}
+
+type candidato struct {
+	tabela *TabelaPreco
+	item   ItemTabela
+}
+
+// faixa retorna o item da tabela com a maior quantidade mínima atingida
+func faixa(t *TabelaPreco, produtoID int, quantidade float64) (ItemTabela, bool) {
+	var melhor ItemTabela
+	encontrado := false
+	for _, item := range t.Itens {
+		if item.ProdutoID != produtoID || item.QuantidadeMinima > quantidade {
+			continue
+		}
+		if !encontrado || item.QuantidadeMinima > melhor.QuantidadeMinima {
+			melhor = item
+			encontrado = true
+		}
+	}
+	return melhor, encontrado
+}
+
+func contem(ids []int, id int) bool {
+	for _, i := range ids {
+		if i == id {
+			return true
+		}
+	}
+	return false
+}
+
+func arredondar(v float64) float64 {
+	return math.Round(v*100) / 100
+}
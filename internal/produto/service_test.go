@@ -0,0 +1,169 @@
+package produto
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/cliente"
+)
+
+// cenario cadastra dois clientes na empresa 1, um na empresa 2 e dois produtos na empresa 1
+type cenario struct {
+	s                *Service
+	repo             *MemoryRepository
+	ana, bia, alheio int
+	caneta, lapis    *Produto
+}
+
+func novoCenario(t *testing.T) *cenario {
+	t.Helper()
+	clientes := cliente.NewMemoryRepository()
+	var ids []int
+	for _, c := range []*cliente.Cliente{
+		{Nome: "Ana", Email: "ana@acme.com", EmpresaID: 1},
+		{Nome: "Bia", Email: "bia@acme.com", EmpresaID: 1},
+		{Nome: "Caio", Email: "caio@beta.com", EmpresaID: 2},
+	} {
+		if err := clientes.Create(c); err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, c.ID)
+	}
+
+	repo := NewMemoryRepository()
+	caneta := &Produto{EmpresaID: 1, SKU: "CAN-01", Nome: "Caneta", Categoria: "escrita", Preco: 10, Ativo: true}
+	lapis := &Produto{EmpresaID: 1, SKU: "LAP-01", Nome: "Lápis", Categoria: "escrita", Preco: 3.33, Ativo: true}
+	for _, p := range []*Produto{caneta, lapis} {
+		if err := repo.Create(p); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &cenario{s: NewService(repo, clientes, nil), repo: repo, ana: ids[0], bia: ids[1], alheio: ids[2], caneta: caneta, lapis: lapis}
+}
+
+func (c *cenario) tabela(t *testing.T, tabela *TabelaPreco) *TabelaPreco {
+	t.Helper()
+	tabela.EmpresaID = 1
+	if err := c.s.SalvarTabela(tabela); err != nil {
+		t.Fatal(err)
+	}
+	return tabela
+}
+
+func TestPreco(t *testing.T) {
+	c := novoCenario(t)
+	hoje := time.Now()
+	c.tabela(t, &TabelaPreco{Nome: "Atacado", Ativa: true, Itens: []ItemTabela{
+		{ProdutoID: c.caneta.ID, QuantidadeMinima: 10, Preco: 9},
+		{ProdutoID: c.caneta.ID, QuantidadeMinima: 100, Preco: 8},
+	}})
+	c.tabela(t, &TabelaPreco{Nome: "Promoção", Ativa: true, Itens: []ItemTabela{
+		{ProdutoID: c.caneta.ID, QuantidadeMinima: 50, Preco: 8.5},
+	}})
+	c.tabela(t, &TabelaPreco{Nome: "Encerrada", Ativa: true, VigenciaFim: hoje.AddDate(0, 0, -1), Itens: []ItemTabela{
+		{ProdutoID: c.caneta.ID, Preco: 1},
+	}})
+	c.tabela(t, &TabelaPreco{Nome: "Inativa", Itens: []ItemTabela{
+		{ProdutoID: c.caneta.ID, Preco: 1},
+	}})
+	// A tabela do cliente prevalece sobre as gerais mesmo mais cara
+	c.tabela(t, &TabelaPreco{Nome: "Ana", Ativa: true, ClienteIDs: []int{c.ana}, Itens: []ItemTabela{
+		{ProdutoID: c.caneta.ID, Preco: 9.5},
+	}})
+
+	tests := []struct {
+		nome       string
+		clienteID  int
+		quantidade float64
+		preco      float64
+		tabela     string
+	}{
+		{"sem faixa atingida", 0, 5, 10, ""},
+		{"primeira faixa", 0, 10, 9, "Atacado"},
+		{"menor preço entre as tabelas gerais", 0, 60, 8.5, "Promoção"},
+		{"maior faixa atingida", c.bia, 150, 8, "Atacado"},
+		{"tabela do cliente", c.ana, 150, 9.5, "Ana"},
+	}
+	for _, tt := range tests {
+		p, err := c.s.Preco(1, c.caneta.ID, tt.clienteID, tt.quantidade, hoje)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.nome, err)
+		}
+		if p.PrecoUnitario != tt.preco || p.Tabela != tt.tabela || p.Total != tt.preco*tt.quantidade {
+			t.Errorf("%s: %v pela tabela %q (total %v), esperado %v pela tabela %q", tt.nome, p.PrecoUnitario, p.Tabela, p.Total, tt.preco, tt.tabela)
+		}
+	}
+}
+
+// Produto inativo ou de outra empresa não tem preço
+func TestPrecoIndisponivel(t *testing.T) {
+	c := novoCenario(t)
+	if _, err := c.s.Preco(2, c.caneta.ID, 0, 1, time.Now()); err == nil {
+		t.Error("preço de produto de outra empresa")
+	}
+	inativo := *c.lapis
+	inativo.Ativo = false
+	if err := c.repo.Update(&inativo); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.s.Preco(1, c.lapis.ID, 0, 1, time.Now()); err == nil {
+		t.Error("preço de produto inativo")
+	}
+}
+
+// Uma tabela não pode ser vinculada a cliente nem a produto de outra empresa
+func TestSalvarTabelaOutraEmpresa(t *testing.T) {
+	c := novoCenario(t)
+	if err := c.s.SalvarTabela(&TabelaPreco{EmpresaID: 1, Nome: "x", ClienteIDs: []int{c.alheio}}); err == nil {
+		t.Error("tabela vinculada a cliente de outra empresa")
+	}
+	if err := c.s.SalvarTabela(&TabelaPreco{EmpresaID: 2, Nome: "x", Itens: []ItemTabela{{ProdutoID: c.caneta.ID, Preco: 1}}}); err == nil {
+		t.Error("tabela com produto de outra empresa")
+	}
+}
+
+func TestReajustar(t *testing.T) {
+	c := novoCenario(t)
+
+	res, err := c.s.Reajustar(1, Reajuste{Categoria: "escrita", Percentual: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caneta, _ := c.repo.GetByID(c.caneta.ID, 1)
+	lapis, _ := c.repo.GetByID(c.lapis.ID, 1)
+	if res.Produtos != 2 || caneta.Preco != 11 || lapis.Preco != 3.66 {
+		t.Errorf("%d produtos reajustados: caneta %v, lápis %v", res.Produtos, caneta.Preco, lapis.Preco)
+	}
+
+	tabela := c.tabela(t, &TabelaPreco{Nome: "Atacado", Ativa: true, Itens: []ItemTabela{
+		{ProdutoID: c.caneta.ID, QuantidadeMinima: 10, Preco: 9},
+		{ProdutoID: c.caneta.ID, QuantidadeMinima: 100, Preco: 8},
+		{ProdutoID: c.lapis.ID, Preco: 3},
+	}})
+	res, err = c.s.Reajustar(1, Reajuste{ProdutoIDs: []int{c.caneta.ID}, TabelaID: tabela.ID, Percentual: -5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	atualizada, _ := c.repo.GetTabela(tabela.ID, 1)
+	if res.Produtos != 1 || res.Itens != 2 || atualizada.Itens[0].Preco != 8.55 || atualizada.Itens[1].Preco != 7.6 || atualizada.Itens[2].Preco != 3 {
+		t.Errorf("reajuste da tabela %+v: %+v", res, atualizada.Itens)
+	}
+	// O preço de catálogo não muda no reajuste da tabela
+	if caneta, _ = c.repo.GetByID(c.caneta.ID, 1); caneta.Preco != 11 {
+		t.Errorf("preço de catálogo alterado para %v", caneta.Preco)
+	}
+
+	tests := []struct {
+		nome string
+		in   Reajuste
+	}{
+		{"sem seleção", Reajuste{Percentual: 10}},
+		{"zerando os preços", Reajuste{Categoria: "escrita", Percentual: -100}},
+		{"tabela inexistente", Reajuste{Categoria: "escrita", TabelaID: tabela.ID + 1, Percentual: 1}},
+	}
+	for _, tt := range tests {
+		if _, err := c.s.Reajustar(1, tt.in); err == nil {
+			t.Errorf("%s: reajuste aceito", tt.nome)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package seguranca
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryContadores implementa Contadores em memória, para uma única instância
+type MemoryContadores struct {
+	mu      sync.Mutex
+	estados map[string]*Estado
+}
+
+// NewMemoryContadores cria contadores em memória
+func NewMemoryContadores() *MemoryContadores {
+	return &MemoryContadores{estados: make(map[string]*Estado)}
+}
+
+// Obter retorna o estado da chave
+func (c *MemoryContadores) Obter(chave string) (*Estado, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.estados[chave]
+	if !exists {
+		return &Estado{Chave: chave}, nil
+	}
+	return copiarEstado(e), nil
+}
+
+// Tentar soma a tentativa à chave liberada
+func (c *MemoryContadores) Tentar(chave string, agora time.Time, l Limites) (*Estado, bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.estados[chave]
+	if !exists {
+		e = &Estado{Chave: chave}
+	}
+	if !e.LiberadoEm(l, agora).IsZero() {
+		return copiarEstado(e), false, nil
+	}
+
+	if e.Falhas == 0 || agora.Sub(e.UltimaFalha) >= l.Janela {
+		e.Falhas = 0
+		e.PrimeiraFalha = agora
+	}
+	e.Falhas++
+	e.UltimaFalha = agora
+	c.estados[chave] = e
+	return copiarEstado(e), true, nil
+}
+
+// Estornar desconta uma tentativa da chave
+func (c *MemoryContadores) Estornar(chave string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, exists := c.estados[chave]; exists && e.Falhas > 0 {
+		e.Falhas--
+	}
+	return nil
+}
+
+// Bloquear bloqueia a chave até o instante informado
+func (c *MemoryContadores) Bloquear(chave string, ate time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, exists := c.estados[chave]
+	if !exists {
+		e = &Estado{Chave: chave}
+		c.estados[chave] = e
+	}
+	e.BloqueadoAte = &ate
+	return nil
+}
+
+// Zerar esquece as falhas e o bloqueio da chave
+func (c *MemoryContadores) Zerar(chave string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.estados, chave)
+	return nil
+}
+
+// Expurgar remove as chaves inativas
+func (c *MemoryContadores) Expurgar(antesDe time.Time) (int, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	n := 0
+	for chave, e := range c.estados {
+		if e.UltimaFalha.Before(antesDe) && (e.BloqueadoAte == nil || e.BloqueadoAte.Before(antesDe)) {
+			delete(c.estados, chave)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func copiarEstado(e *Estado) *Estado {
+	c := *e
+	if e.BloqueadoAte != nil {
+		ate := *e.BloqueadoAte
+		c.BloqueadoAte = &ate
+	}
+	return &c
+}
@@ -0,0 +1,132 @@
+package seguranca
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// esquemaContadoresMySQL cria a tabela dos contadores de falhas de login
+const esquemaContadoresMySQL = `CREATE TABLE IF NOT EXISTS seguranca_contadores (
+	chave VARCHAR(191) PRIMARY KEY,
+	falhas INT NOT NULL,
+	primeira_falha DATETIME(6) NOT NULL,
+	ultima_falha DATETIME(6) NOT NULL,
+	bloqueado_ate DATETIME(6) NULL,
+	KEY ix_seguranca_contadores_ultima (ultima_falha)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4`
+
+// MySQLContadores implementa Contadores numa tabela do MySQL, compartilhada entre as instâncias
+type MySQLContadores struct {
+	db *sql.DB
+}
+
+// NewMySQLContadores cria os contadores sobre a conexão informada
+func NewMySQLContadores(db *sql.DB) *MySQLContadores {
+	return &MySQLContadores{db: db}
+}
+
+// Migrar cria a tabela dos contadores, se ainda não existir
+func (c *MySQLContadores) Migrar() error {
+	_, err := c.db.Exec(esquemaContadoresMySQL)
+	return err
+}
+
+// Obter retorna o estado da chave
+func (c *MySQLContadores) Obter(chave string) (*Estado, error) {
+	e := &Estado{Chave: chave}
+	var bloqueado sql.NullTime
+	err := c.db.QueryRow(`SELECT falhas, primeira_falha, ultima_falha, bloqueado_ate
+		FROM seguranca_contadores WHERE chave = ?`, chave).Scan(&e.Falhas, &e.PrimeiraFalha, &e.UltimaFalha, &bloqueado)
+	if errors.Is(err, sql.ErrNoRows) {
+		return e, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if bloqueado.Valid {
+		e.BloqueadoAte = &bloqueado.Time
+	}
+	return e, nil
+}
+
+// Tentar soma a tentativa à chave liberada. A linha da chave é criada antes da transação para que
+// o SELECT ... FOR UPDATE a trave mesmo na primeira tentativa, serializando as instâncias.
+func (c *MySQLContadores) Tentar(chave string, agora time.Time, l Limites) (*Estado, bool, error) {
+	agora = agora.UTC()
+	if _, err := c.db.Exec(`INSERT IGNORE INTO seguranca_contadores (chave, falhas, primeira_falha, ultima_falha)
+		VALUES (?, 0, ?, ?)`, chave, agora, agora); err != nil {
+		return nil, false, err
+	}
+
+	tx, err := c.db.Begin()
+	if err != nil {
+		return nil, false, err
+	}
+	defer tx.Rollback()
+
+	e := &Estado{Chave: chave}
+	var bloqueado sql.NullTime
+	err = tx.QueryRow(`SELECT falhas, primeira_falha, ultima_falha, bloqueado_ate
+		FROM seguranca_contadores WHERE chave = ? FOR UPDATE`, chave).Scan(&e.Falhas, &e.PrimeiraFalha, &e.UltimaFalha, &bloqueado)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, err
+	}
+	if bloqueado.Valid {
+		e.BloqueadoAte = &bloqueado.Time
+	}
+	if !e.LiberadoEm(l, agora).IsZero() {
+		return e, false, tx.Commit()
+	}
+
+	if e.Falhas == 0 || agora.Sub(e.UltimaFalha) >= l.Janela {
+		e.Falhas = 0
+		e.PrimeiraFalha = agora
+	}
+	e.Falhas++
+	e.UltimaFalha = agora
+	// O expurgo pode ter removido a linha entre o INSERT IGNORE e o SELECT; o upsert a recria
+	_, err = tx.Exec(`INSERT INTO seguranca_contadores (chave, falhas, primeira_falha, ultima_falha)
+		VALUES (?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE falhas = VALUES(falhas), primeira_falha = VALUES(primeira_falha),
+			ultima_falha = VALUES(ultima_falha)`,
+		chave, e.Falhas, e.PrimeiraFalha.UTC(), e.UltimaFalha)
+	if err != nil {
+		return nil, false, err
+	}
+	return e, true, tx.Commit()
+}
+
+// Estornar desconta uma tentativa da chave
+func (c *MySQLContadores) Estornar(chave string) error {
+	_, err := c.db.Exec(`UPDATE seguranca_contadores SET falhas = falhas - 1 WHERE chave = ? AND falhas > 0`, chave)
+	return err
+}
+
+// Bloquear bloqueia a chave até o instante informado
+func (c *MySQLContadores) Bloquear(chave string, ate time.Time) error {
+	agora := time.Now().UTC()
+	_, err := c.db.Exec(`INSERT INTO seguranca_contadores (chave, falhas, primeira_falha, ultima_falha, bloqueado_ate)
+		VALUES (?, 0, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE bloqueado_ate = VALUES(bloqueado_ate)`,
+		chave, agora, agora, ate.UTC())
+	return err
+}
+
+// Zerar esquece as falhas e o bloqueio da chave
+func (c *MySQLContadores) Zerar(chave string) error {
+	_, err := c.db.Exec(`DELETE FROM seguranca_contadores WHERE chave = ?`, chave)
+	return err
+}
+
+// Expurgar remove as chaves inativas
+func (c *MySQLContadores) Expurgar(antesDe time.Time) (int, error) {
+	antesDe = antesDe.UTC()
+	res, err := c.db.Exec(`DELETE FROM seguranca_contadores
+		WHERE ultima_falha < ? AND (bloqueado_ate IS NULL OR bloqueado_ate < ?)`, antesDe, antesDe)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	return int(n), err
+}
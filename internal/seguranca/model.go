@@ -0,0 +1,136 @@
+package seguranca
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tipos de eventos de segurança
+const (
+	EventoLoginSucesso        = "login.sucesso"
+	EventoLoginFalha          = "login.falha"
+	EventoLoginRecusado       = "login.recusado" // tentativa recusada por atraso ou bloqueio, sem conferir a senha
+	EventoContaBloqueada      = "conta.bloqueada"
+	EventoContaDesbloqueada   = "conta.desbloqueada"
+	EventoIPBloqueado         = "ip.bloqueado"
+	EventoIPDesbloqueado      = "ip.desbloqueado"
+	EventoSegundoFatorFalha   = "segundo_fator.falha"
+	EventoSegundoFatorSucesso = "segundo_fator.sucesso"
+)
+
+// Motivos das falhas de login
+const (
+	MotivoEmailDesconhecido = "email_desconhecido"
+	MotivoSenhaIncorreta    = "senha_incorreta"
+	MotivoSegundoFator      = "segundo_fator_incorreto"
+)
+
+// Limites define quando as falhas de uma chave passam a atrasar e a bloquear as tentativas
+type Limites struct {
+	AtrasoAPartirDe int           // falhas a partir das quais cada nova tentativa espera
+	AtrasoInicial   time.Duration // dobra a cada falha seguinte
+	AtrasoMaximo    time.Duration
+	Bloqueio        int // falhas que bloqueiam a chave
+	DuracaoBloqueio time.Duration
+	Janela          time.Duration // sem novas falhas por esse tempo, o contador recomeça
+}
+
+// Limites padrão. O contador da conta não zera no fim do bloqueio: cada nova falha dentro da
+// janela bloqueia de novo.
+var (
+	LimitesConta = Limites{AtrasoAPartirDe: 3, AtrasoInicial: time.Second, AtrasoMaximo: time.Minute,
+		Bloqueio: 10, DuracaoBloqueio: 15 * time.Minute, Janela: 24 * time.Hour}
+	LimitesIP = Limites{AtrasoAPartirDe: 20, AtrasoInicial: time.Second, AtrasoMaximo: 30 * time.Second,
+		Bloqueio: 100, DuracaoBloqueio: 30 * time.Minute, Janela: time.Hour}
+)
+
+// Estado são as falhas recentes de uma chave (conta ou IP)
+type Estado struct {
+	Chave         string     `json:"-"`
+	Falhas        int        `json:"falhas"`
+	PrimeiraFalha time.Time  `json:"primeira_falha"`
+	UltimaFalha   time.Time  `json:"ultima_falha"`
+	BloqueadoAte  *time.Time `json:"bloqueado_ate,omitempty"`
+}
+
+// LiberadoEm retorna o instante a partir do qual a chave pode tentar de novo; zero se já pode
+func (e *Estado) LiberadoEm(l Limites, agora time.Time) time.Time {
+	if e.BloqueadoAte != nil && agora.Before(*e.BloqueadoAte) {
+		return *e.BloqueadoAte
+	}
+	if e.Falhas < l.AtrasoAPartirDe || agora.Sub(e.UltimaFalha) >= l.Janela {
+		return time.Time{}
+	}
+
+	atraso := l.AtrasoInicial
+	for i := l.AtrasoAPartirDe; i < e.Falhas && atraso < l.AtrasoMaximo; i++ {
+		atraso *= 2
+	}
+	if atraso > l.AtrasoMaximo {
+		atraso = l.AtrasoMaximo
+	}
+	if liberado := e.UltimaFalha.Add(atraso); agora.Before(liberado) {
+		return liberado
+	}
+	return time.Time{}
+}
+
+// Contadores guarda as falhas por chave. A implementação em memória atende uma instância;
+// a do MySQL compartilha os contadores entre as instâncias da aplicação.
+type Contadores interface {
+	// Obter retorna o estado da chave; chaves sem falhas retornam o estado vazio
+	Obter(chave string) (*Estado, error)
+	// Tentar confere se a chave está liberada pelos limites e, se estiver, já soma a tentativa como
+	// falha, recomeçando a contagem se a última falha for anterior à janela. A conferência e a soma
+	// são atômicas, para que tentativas simultâneas não escapem do atraso. Recusada, a chave não muda.
+	// Retorna o estado resultante e se a tentativa foi aceita.
+	Tentar(chave string, agora time.Time, l Limites) (*Estado, bool, error)
+	// Estornar desconta uma tentativa aceita por Tentar
+	Estornar(chave string) error
+	Bloquear(chave string, ate time.Time) error
+	Zerar(chave string) error
+	// Expurgar remove as chaves sem falhas desde antesDe e não bloqueadas
+	Expurgar(antesDe time.Time) (int, error)
+}
+
+// Evento é um registro do log de segurança. O e-mail aparece mascarado.
+type Evento struct {
+	ID        int       `json:"id"`
+	Tipo      string    `json:"tipo"`
+	EmpresaID int       `json:"empresa_id,omitempty"` // zero quando a conta não é conhecida
+	UsuarioID int       `json:"usuario_id,omitempty"`
+	Email     string    `json:"email,omitempty"`
+	IP        string    `json:"ip,omitempty"`
+	Detalhe   string    `json:"detalhe,omitempty"`
+	AutorID   int       `json:"autor_id,omitempty"` // administrador que executou a ação
+	Data      time.Time `json:"data"`
+}
+
+// FiltroEventos restringe a listagem do log; campos vazios não filtram
+type FiltroEventos struct {
+	EmpresaID int
+	UsuarioID int
+	Tipo      string
+	IP        string
+	Desde     time.Time
+}
+
+// Repository define a interface para acesso ao log de segurança
+type Repository interface {
+	RegistrarEvento(e *Evento) error
+	ListEventos(f FiltroEventos, limit, offset int) ([]*Evento, error)
+	ExpurgarEventos(antesDe time.Time) (int, error)
+}
+
+// ErroEspera indica que a tentativa foi recusada antes de conferir a senha
+type ErroEspera struct {
+	Ate       time.Time
+	Bloqueado bool // bloqueio temporário; falso para o atraso progressivo
+}
+
+func (e *ErroEspera) Error() string {
+	if e.Bloqueado {
+		return fmt.Sprintf("acesso bloqueado temporariamente até %s", e.Ate.Format(time.RFC3339))
+	}
+	return "muitas tentativas; aguarde antes de tentar novamente"
+}
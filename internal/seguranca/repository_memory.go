@@ -0,0 +1,92 @@
+package seguranca
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu      sync.RWMutex
+	eventos []*Evento
+	nextID  int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{nextID: 1}
+}
+
+// RegistrarEvento adiciona um evento ao log
+func (r *MemoryRepository) RegistrarEvento(e *Evento) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.Tipo == "" {
+		return errors.New("tipo é obrigatório")
+	}
+	e.ID = r.nextID
+	r.nextID++
+	if e.Data.IsZero() {
+		e.Data = time.Now()
+	}
+
+	c := *e
+	r.eventos = append(r.eventos, &c)
+	return nil
+}
+
+// ListEventos retorna os eventos do filtro, dos mais recentes para os mais antigos
+func (r *MemoryRepository) ListEventos(f FiltroEventos, limit, offset int) ([]*Evento, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Evento, 0)
+	for _, e := range r.eventos {
+		if f.EmpresaID != 0 && e.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.UsuarioID != 0 && e.UsuarioID != f.UsuarioID {
+			continue
+		}
+		if f.Tipo != "" && e.Tipo != f.Tipo {
+			continue
+		}
+		if f.IP != "" && e.IP != f.IP {
+			continue
+		}
+		if !f.Desde.IsZero() && e.Data.Before(f.Desde) {
+			continue
+		}
+		c := *e
+		result = append(result, &c)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID > result[j].ID })
+
+	if offset >= len(result) {
+		return []*Evento{}, nil
+	}
+	result = result[offset:]
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+// ExpurgarEventos remove os eventos anteriores à data
+func (r *MemoryRepository) ExpurgarEventos(antesDe time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	mantidos := r.eventos[:0]
+	for _, e := range r.eventos {
+		if !e.Data.Before(antesDe) {
+			mantidos = append(mantidos, e)
+		}
+	}
+	n := len(r.eventos) - len(mantidos)
+	r.eventos = mantidos
+	return n, nil
+}
@@ -0,0 +1,267 @@
+package seguranca
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// TipoJobExpurgo é o job periódico que chama Expurgar
+const TipoJobExpurgo = "seguranca.expurgo"
+
+// Tentativa identifica uma tentativa de login. UsuarioID e EmpresaID ficam zerados enquanto
+// a conta não é conhecida.
+type Tentativa struct {
+	Email     string
+	IP        string
+	UsuarioID int
+	EmpresaID int
+}
+
+// Service protege o login contra força bruta e mantém o log de segurança
+type Service struct {
+	contadores Contadores
+	repo       Repository
+	conta      Limites
+	ip         Limites
+	agora      func() time.Time
+}
+
+// NewService cria um novo serviço com os limites padrão
+func NewService(contadores Contadores, repo Repository) *Service {
+	return &Service{
+		contadores: contadores,
+		repo:       repo,
+		conta:      LimitesConta,
+		ip:         LimitesIP,
+		agora:      time.Now,
+	}
+}
+
+// Verificar informa se a tentativa pode conferir a senha. Retorna *ErroEspera durante o atraso
+// progressivo ou o bloqueio da conta ou do IP. A conta é identificada pelo e-mail informado,
+// exista ou não, para que a resposta não revele as contas cadastradas.
+// A tentativa permitida já é contada como falha, de forma atômica com a verificação; se a
+// credencial conferir, o chamador a desconta com Estornar.
+func (s *Service) Verificar(t Tentativa) error {
+	agora := s.agora()
+	ate, bloqueado := time.Time{}, false
+	var contadas []string
+	for _, c := range s.chaves(t) {
+		e, aceita, err := s.contadores.Tentar(c.chave, agora, c.limites)
+		if err != nil {
+			// Sem os contadores, o login segue; a indisponibilidade não deve barrar todos os acessos
+			logger.ErrorLogger.Printf("Erro ao consultar contadores de login: %v", err)
+			continue
+		}
+		if aceita {
+			contadas = append(contadas, c.chave)
+			continue
+		}
+		if liberado := e.LiberadoEm(c.limites, agora); liberado.After(ate) {
+			ate = liberado
+			bloqueado = e.BloqueadoAte != nil && liberado.Equal(*e.BloqueadoAte)
+		}
+	}
+	if ate.IsZero() {
+		return nil
+	}
+
+	// Recusada por uma das chaves, a tentativa não conta para as demais
+	for _, chave := range contadas {
+		s.estornar(chave)
+	}
+
+	detalhe := "atraso"
+	if bloqueado {
+		detalhe = "bloqueio"
+	}
+	s.registrar(EventoLoginRecusado, t, detalhe, 0)
+	return &ErroEspera{Ate: ate, Bloqueado: bloqueado}
+}
+
+// RegistrarFalha registra a falha da tentativa já contada por Verificar, bloqueando a conta e o IP
+// ao atingir os limites
+func (s *Service) RegistrarFalha(t Tentativa, motivo string) {
+	s.registrar(EventoLoginFalha, t, motivo, 0)
+
+	agora := s.agora()
+	if chave := chaveConta(t.Email); chave != "" {
+		if s.bloquear(chave, s.conta, agora) {
+			s.registrar(EventoContaBloqueada, t, "", 0)
+			logger.InfoLogger.Printf("Conta %s bloqueada até %s após falhas de login", MascararEmail(t.Email), agora.Add(s.conta.DuracaoBloqueio).Format(time.RFC3339))
+		}
+	}
+	if chave := chaveIP(t.IP); chave != "" {
+		if s.bloquear(chave, s.ip, agora) {
+			s.registrar(EventoIPBloqueado, Tentativa{IP: t.IP}, "", 0)
+			logger.InfoLogger.Printf("IP %s bloqueado até %s após falhas de login", t.IP, agora.Add(s.ip.DuracaoBloqueio).Format(time.RFC3339))
+		}
+	}
+}
+
+// Estornar desconta da conta e do IP a tentativa contada por Verificar cuja credencial conferiu
+func (s *Service) Estornar(t Tentativa) {
+	for _, c := range s.chaves(t) {
+		s.estornar(c.chave)
+	}
+}
+
+// RegistrarSucesso zera as falhas da conta. As do IP continuam, para que uma conta válida não
+// sirva para renovar as tentativas contra outras.
+func (s *Service) RegistrarSucesso(t Tentativa) {
+	if err := s.contadores.Zerar(chaveConta(t.Email)); err != nil {
+		logger.ErrorLogger.Printf("Erro ao zerar contadores de login: %v", err)
+	}
+	s.registrar(EventoLoginSucesso, t, "", 0)
+}
+
+// RegistrarSegundoFator registra o resultado da segunda etapa do login
+func (s *Service) RegistrarSegundoFator(t Tentativa, sucesso bool, detalhe string) {
+	tipo := EventoSegundoFatorFalha
+	if sucesso {
+		tipo = EventoSegundoFatorSucesso
+	}
+	s.registrar(tipo, t, detalhe, 0)
+}
+
+// EstadoConta retorna as falhas recentes e o bloqueio da conta
+func (s *Service) EstadoConta(email string) (*Estado, error) {
+	return s.contadores.Obter(chaveConta(email))
+}
+
+// DesbloquearConta zera as falhas e o bloqueio da conta
+func (s *Service) DesbloquearConta(t Tentativa, autorID int) error {
+	if err := s.contadores.Zerar(chaveConta(t.Email)); err != nil {
+		return err
+	}
+	s.registrar(EventoContaDesbloqueada, t, "", autorID)
+	return nil
+}
+
+// DesbloquearIP zera as falhas e o bloqueio do IP, como o de um escritório atrás de NAT. O IP é
+// compartilhado entre as empresas, por isso o evento não pertence a nenhuma.
+func (s *Service) DesbloquearIP(ip string, autorID int) error {
+	if err := s.contadores.Zerar(chaveIP(ip)); err != nil {
+		return err
+	}
+	s.registrar(EventoIPDesbloqueado, Tentativa{IP: ip}, "", autorID)
+	return nil
+}
+
+// Eventos lista o log de segurança
+func (s *Service) Eventos(f FiltroEventos, limit, offset int) ([]*Evento, error) {
+	return s.repo.ListEventos(f, limit, offset)
+}
+
+// Expurgar remove os contadores inativos e os eventos mais antigos que a retenção
+func (s *Service) Expurgar(retencao time.Duration) error {
+	agora := s.agora()
+	janela := s.conta.Janela
+	if s.ip.Janela > janela {
+		janela = s.ip.Janela
+	}
+	contadores, err := s.contadores.Expurgar(agora.Add(-janela))
+	if err != nil {
+		return err
+	}
+	eventos, err := s.repo.ExpurgarEventos(agora.Add(-retencao))
+	if err != nil {
+		return err
+	}
+	logger.InfoLogger.Printf("%d contadores de login e %d eventos de segurança expurgados", contadores, eventos)
+	return nil
+}
+
+// chaveLimitada é uma chave dos contadores com os limites que se aplicam a ela
+type chaveLimitada struct {
+	chave   string
+	limites Limites
+}
+
+// chaves retorna as chaves da conta e do IP da tentativa, omitindo as desconhecidas
+func (s *Service) chaves(t Tentativa) []chaveLimitada {
+	var chaves []chaveLimitada
+	if chave := chaveConta(t.Email); chave != "" {
+		chaves = append(chaves, chaveLimitada{chave, s.conta})
+	}
+	if chave := chaveIP(t.IP); chave != "" {
+		chaves = append(chaves, chaveLimitada{chave, s.ip})
+	}
+	return chaves
+}
+
+func (s *Service) estornar(chave string) {
+	if err := s.contadores.Estornar(chave); err != nil {
+		logger.ErrorLogger.Printf("Erro ao estornar tentativa de login: %v", err)
+	}
+}
+
+// bloquear bloqueia a chave cujas falhas atingiram o limite e que ainda não está bloqueada;
+// informa se bloqueou
+func (s *Service) bloquear(chave string, l Limites, agora time.Time) bool {
+	e, err := s.contadores.Obter(chave)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro ao registrar falha de login: %v", err)
+		return false
+	}
+	if e.Falhas < l.Bloqueio || (e.BloqueadoAte != nil && agora.Before(*e.BloqueadoAte)) {
+		return false
+	}
+	if err := s.contadores.Bloquear(chave, agora.Add(l.DuracaoBloqueio)); err != nil {
+		logger.ErrorLogger.Printf("Erro ao bloquear após falhas de login: %v", err)
+		return false
+	}
+	return true
+}
+
+// registrar grava o evento no log de segurança
+func (s *Service) registrar(tipo string, t Tentativa, detalhe string, autorID int) {
+	e := &Evento{
+		Tipo:      tipo,
+		EmpresaID: t.EmpresaID,
+		UsuarioID: t.UsuarioID,
+		Email:     MascararEmail(t.Email),
+		IP:        t.IP,
+		Detalhe:   detalhe,
+		AutorID:   autorID,
+		Data:      s.agora(),
+	}
+	if err := s.repo.RegistrarEvento(e); err != nil {
+		logger.ErrorLogger.Printf("Erro ao registrar evento de segurança: %v", err)
+	}
+}
+
+// chaveConta identifica a conta pelo hash do e-mail normalizado, sem guardá-lo em claro
+func chaveConta(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return ""
+	}
+	h := sha256.Sum256([]byte(email))
+	return "conta:" + hex.EncodeToString(h[:])
+}
+
+func chaveIP(ip string) string {
+	if ip == "" {
+		return ""
+	}
+	return "ip:" + ip
+}
+
+// MascararEmail reduz o e-mail para os logs: "ana.souza@acme.com" vira "a***@acme.com"
+func MascararEmail(email string) string {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return ""
+	}
+	local, dominio, ok := strings.Cut(email, "@")
+	if !ok || local == "" {
+		return "***"
+	}
+	r := []rune(local)
+	return string(r[0]) + "***@" + dominio
+}
@@ -0,0 +1,164 @@
+package seguranca
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+func novoServico() (*Service, *MemoryContadores, *time.Time) {
+	contadores := NewMemoryContadores()
+	s := NewService(contadores, NewMemoryRepository())
+	agora := time.Unix(1700000000, 0)
+	s.agora = func() time.Time { return agora }
+	return s, contadores, &agora
+}
+
+// Tentativas simultâneas não escapam do atraso: só as anteriores ao limite são conferidas
+func TestVerificarSimultaneo(t *testing.T) {
+	s, contadores, _ := novoServico()
+	tentativa := Tentativa{Email: "ana@acme.com", IP: "203.0.113.7"}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	permitidas := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if s.Verificar(tentativa) == nil {
+				mu.Lock()
+				permitidas++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if permitidas != LimitesConta.AtrasoAPartirDe {
+		t.Errorf("%d tentativas simultâneas permitidas, esperado %d", permitidas, LimitesConta.AtrasoAPartirDe)
+	}
+	conta, _ := contadores.Obter(chaveConta(tentativa.Email))
+	ip, _ := contadores.Obter(chaveIP(tentativa.IP))
+	if conta.Falhas != permitidas || ip.Falhas != permitidas {
+		t.Errorf("falhas contadas: conta %d, IP %d; esperado %d", conta.Falhas, ip.Falhas, permitidas)
+	}
+}
+
+func TestVerificarAtrasoEBloqueio(t *testing.T) {
+	s, contadores, agora := novoServico()
+	tentativa := Tentativa{Email: "Ana@Acme.com ", IP: "203.0.113.7"}
+
+	for i := 1; i <= LimitesConta.Bloqueio; i++ {
+		// Espera o atraso máximo entre as tentativas
+		*agora = agora.Add(LimitesConta.AtrasoMaximo)
+		if err := s.Verificar(tentativa); err != nil {
+			t.Fatalf("tentativa %d recusada: %v", i, err)
+		}
+		s.RegistrarFalha(tentativa, MotivoSenhaIncorreta)
+
+		if i == LimitesConta.AtrasoAPartirDe {
+			var espera *ErroEspera
+			if err := s.Verificar(tentativa); !errors.As(err, &espera) || espera.Bloqueado {
+				t.Fatalf("sem atraso após %d falhas: %v", i, err)
+			}
+		}
+	}
+
+	var espera *ErroEspera
+	if err := s.Verificar(tentativa); !errors.As(err, &espera) || !espera.Bloqueado {
+		t.Fatalf("conta não bloqueada: %v", err)
+	}
+	// As recusas não contam como falhas
+	e, _ := contadores.Obter(chaveConta("ana@acme.com"))
+	if e.Falhas != LimitesConta.Bloqueio {
+		t.Errorf("falhas = %d, esperado %d", e.Falhas, LimitesConta.Bloqueio)
+	}
+
+	// Outro e-mail do mesmo IP continua liberado, abaixo dos limites do IP
+	if err := s.Verificar(Tentativa{Email: "bia@acme.com", IP: tentativa.IP}); err != nil {
+		t.Errorf("outra conta recusada: %v", err)
+	}
+}
+
+func TestVerificarRecusadaPeloIPNaoContaParaAConta(t *testing.T) {
+	s, contadores, _ := novoServico()
+	ip := "203.0.113.7"
+	if err := contadores.Bloquear(chaveIP(ip), time.Unix(1700000000, 0).Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Verificar(Tentativa{Email: "ana@acme.com", IP: ip}); err == nil {
+		t.Fatal("IP bloqueado permitido")
+	}
+	if e, _ := contadores.Obter(chaveConta("ana@acme.com")); e.Falhas != 0 {
+		t.Errorf("tentativa recusada contada para a conta: %d", e.Falhas)
+	}
+}
+
+func TestEstornarESucesso(t *testing.T) {
+	s, contadores, agora := novoServico()
+	tentativa := Tentativa{Email: "ana@acme.com", IP: "203.0.113.7"}
+
+	if err := s.Verificar(tentativa); err != nil {
+		t.Fatal(err)
+	}
+	s.RegistrarFalha(tentativa, MotivoSenhaIncorreta)
+
+	// Senha correta: a tentativa é estornada e o login conclui
+	*agora = agora.Add(time.Second)
+	if err := s.Verificar(tentativa); err != nil {
+		t.Fatal(err)
+	}
+	s.Estornar(tentativa)
+	if e, _ := contadores.Obter(chaveIP(tentativa.IP)); e.Falhas != 1 {
+		t.Errorf("falhas do IP após o estorno = %d, esperado 1", e.Falhas)
+	}
+	s.RegistrarSucesso(tentativa)
+
+	if e, _ := contadores.Obter(chaveConta(tentativa.Email)); e.Falhas != 0 {
+		t.Errorf("falhas da conta após o sucesso = %d", e.Falhas)
+	}
+	// As falhas do IP continuam depois do sucesso
+	if e, _ := contadores.Obter(chaveIP(tentativa.IP)); e.Falhas != 1 {
+		t.Errorf("falhas do IP após o sucesso = %d, esperado 1", e.Falhas)
+	}
+
+	// Estornar sem falhas não deixa o contador negativo
+	s.Estornar(Tentativa{Email: "nova@acme.com"})
+	if e, _ := contadores.Obter(chaveConta("nova@acme.com")); e.Falhas != 0 {
+		t.Errorf("falhas após estorno sem tentativa = %d", e.Falhas)
+	}
+}
+
+func TestDesbloquearIP(t *testing.T) {
+	s, contadores, _ := novoServico()
+	ip := "203.0.113.7"
+	contadores.Bloquear(chaveIP(ip), time.Unix(1700000000, 0).Add(time.Hour))
+
+	if err := s.DesbloquearIP(ip, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Verificar(Tentativa{IP: ip}); err != nil {
+		t.Errorf("IP continua bloqueado: %v", err)
+	}
+
+	eventos, _ := s.Eventos(FiltroEventos{Tipo: EventoIPDesbloqueado}, 10, 0)
+	if len(eventos) != 1 || eventos[0].EmpresaID != 0 || eventos[0].AutorID != 1 {
+		t.Errorf("eventos = %+v", eventos)
+	}
+}
@@ -0,0 +1,104 @@
+package sso
+
+import (
+	"errors"
+	"time"
+)
+
+// PapelUsuario é o papel padrão dos usuários provisionados, o mesmo dos convites. O SSO só atribui
+// os usuario.PapeisComuns; administradores são promovidos na aplicação, nunca pelos grupos do provedor.
+const PapelUsuario = "usuario"
+
+// ValidadeFluxo é o prazo para o usuário voltar do provedor com o código
+const ValidadeFluxo = 10 * time.Minute
+
+// ValidadeTroca é o prazo para o frontend trocar o código do login concluído pelo token
+const ValidadeTroca = time.Minute
+
+// NomeCookieFluxo é o cookie que prende o estado ao navegador que iniciou o login
+const NomeCookieFluxo = "gvero_sso"
+
+// ErrNaoConfigurado indica empresa sem login SSO ativo
+var ErrNaoConfigurado = errors.New("login SSO não configurado para a empresa")
+
+// ErrFluxoInvalido é a resposta única para estados inexistentes, expirados, já usados ou de outra empresa
+var ErrFluxoInvalido = errors.New("login SSO inválido ou expirado; comece novamente")
+
+// ErrTrocaInvalida indica código de troca inexistente, expirado ou já usado
+var ErrTrocaInvalida = errors.New("código de login inválido ou expirado; comece novamente")
+
+// Configuracao é o provedor OpenID Connect de uma empresa. O segredo do cliente é informado
+// na gravação e nunca retornado; gravado vazio, o anterior é mantido.
+type Configuracao struct {
+	EmpresaID          int      `json:"empresa_id"`
+	Ativo              bool     `json:"ativo"`
+	Emissor            string   `json:"emissor"` // issuer, como https://accounts.google.com
+	ClientID           string   `json:"client_id"`
+	ClientSecret       string   `json:"client_secret,omitempty"`
+	SegredoConfigurado bool     `json:"segredo_configurado"`
+	DominiosPermitidos []string `json:"dominios_permitidos"` // domínios de e-mail aceitos, como acme.com.br
+
+	// Papel dos usuários: ClaimPapeis é a declaração do ID token com grupos ou papéis (groups,
+	// roles) e Papeis mapeia seus valores para um dos usuario.PapeisComuns; vale o primeiro valor
+	// mapeado, na ordem da declaração, e sem nenhum vale PapelPadrao. O papel admin não é aceito,
+	// e o papel dos administradores existentes é mantido.
+	ClaimPapeis string            `json:"claim_papeis,omitempty"`
+	Papeis      map[string]string `json:"papeis,omitempty"`
+	PapelPadrao string            `json:"papel_padrao"`
+
+	// DispensarSegundoFator confia no segundo fator do provedor; sem ele, os logins SSO seguem
+	// a política de segundo fator da empresa
+	DispensarSegundoFator bool `json:"dispensar_segundo_fator"`
+
+	UsuarioID       int       `json:"usuario_id,omitempty"` // administrador da última alteração
+	DataAtualizacao time.Time `json:"data_atualizacao"`
+}
+
+// Fluxo é um login iniciado aguardando o retorno do provedor. O estado (state) é entregue ao
+// provedor; apenas o hash é gravado.
+type Fluxo struct {
+	Hash        string    `json:"-"`
+	EmpresaID   int       `json:"empresa_id"`
+	Verificador string    `json:"-"` // code_verifier do PKCE
+	Nonce       string    `json:"-"`
+	Expira      time.Time `json:"expira"`
+}
+
+// Troca é um login SSO concluído aguardando o frontend buscar o token. O código vai na URL
+// de retorno ao frontend; apenas o hash é gravado.
+type Troca struct {
+	Hash      string    `json:"-"`
+	EmpresaID int       `json:"empresa_id"`
+	UsuarioID int       `json:"usuario_id"`
+	Expira    time.Time `json:"expira"`
+}
+
+// Vinculo liga a identidade do provedor (emissor e sub) ao usuário, para que a troca de
+// e-mail no provedor não leve a outra conta
+type Vinculo struct {
+	EmpresaID   int       `json:"empresa_id"`
+	Emissor     string    `json:"emissor"`
+	Assunto     string    `json:"assunto"`
+	UsuarioID   int       `json:"usuario_id"`
+	DataCriacao time.Time `json:"data_criacao"`
+}
+
+// Repository define a interface para acesso às configurações, fluxos e vínculos do SSO
+type Repository interface {
+	GetConfiguracao(empresaID int) (*Configuracao, error)
+	SalvarConfiguracao(c *Configuracao) error
+	DeleteConfiguracao(empresaID int) error
+
+	CreateFluxo(f *Fluxo) error
+	// ConsumirFluxo retorna e remove o fluxo, que vale uma única vez
+	ConsumirFluxo(hash string) (*Fluxo, error)
+	// ExpurgarFluxos remove os fluxos e as trocas expirados antes da data
+	ExpurgarFluxos(antesDe time.Time) (int, error)
+
+	CreateTroca(t *Troca) error
+	// ConsumirTroca retorna e remove a troca, que vale uma única vez
+	ConsumirTroca(hash string) (*Troca, error)
+
+	GetVinculo(emissor, assunto string) (*Vinculo, error)
+	SalvarVinculo(v *Vinculo) error
+}
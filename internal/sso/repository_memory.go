@@ -0,0 +1,177 @@
+package sso
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu            sync.RWMutex
+	configuracoes map[int]*Configuracao
+	fluxos        map[string]*Fluxo
+	trocas        map[string]*Troca
+	vinculos      map[string]*Vinculo
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		configuracoes: make(map[int]*Configuracao),
+		fluxos:        make(map[string]*Fluxo),
+		trocas:        make(map[string]*Troca),
+		vinculos:      make(map[string]*Vinculo),
+	}
+}
+
+// GetConfiguracao busca a configuração da empresa
+func (r *MemoryRepository) GetConfiguracao(empresaID int) (*Configuracao, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, exists := r.configuracoes[empresaID]
+	if !exists {
+		return nil, ErrNaoConfigurado
+	}
+	return copiarConfiguracao(c), nil
+}
+
+// SalvarConfiguracao cria ou substitui a configuração da empresa
+func (r *MemoryRepository) SalvarConfiguracao(c *Configuracao) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if c.EmpresaID <= 0 {
+		return errors.New("empresa é obrigatória")
+	}
+	r.configuracoes[c.EmpresaID] = copiarConfiguracao(c)
+	return nil
+}
+
+// DeleteConfiguracao remove a configuração da empresa
+func (r *MemoryRepository) DeleteConfiguracao(empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.configuracoes[empresaID]; !exists {
+		return ErrNaoConfigurado
+	}
+	delete(r.configuracoes, empresaID)
+	return nil
+}
+
+// CreateFluxo grava um login iniciado
+func (r *MemoryRepository) CreateFluxo(f *Fluxo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f.Hash == "" {
+		return errors.New("hash do estado é obrigatório")
+	}
+	c := *f
+	r.fluxos[f.Hash] = &c
+	return nil
+}
+
+// ConsumirFluxo retorna e remove o fluxo
+func (r *MemoryRepository) ConsumirFluxo(hash string) (*Fluxo, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, exists := r.fluxos[hash]
+	if !exists {
+		return nil, ErrFluxoInvalido
+	}
+	delete(r.fluxos, hash)
+	return f, nil
+}
+
+// ExpurgarFluxos remove os fluxos e as trocas expirados antes da data
+func (r *MemoryRepository) ExpurgarFluxos(antesDe time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for hash, f := range r.fluxos {
+		if f.Expira.Before(antesDe) {
+			delete(r.fluxos, hash)
+			n++
+		}
+	}
+	for hash, t := range r.trocas {
+		if t.Expira.Before(antesDe) {
+			delete(r.trocas, hash)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// CreateTroca grava um login concluído
+func (r *MemoryRepository) CreateTroca(t *Troca) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if t.Hash == "" {
+		return errors.New("hash do código é obrigatório")
+	}
+	c := *t
+	r.trocas[t.Hash] = &c
+	return nil
+}
+
+// ConsumirTroca retorna e remove a troca
+func (r *MemoryRepository) ConsumirTroca(hash string) (*Troca, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, exists := r.trocas[hash]
+	if !exists {
+		return nil, ErrTrocaInvalida
+	}
+	delete(r.trocas, hash)
+	return t, nil
+}
+
+// GetVinculo busca o vínculo da identidade do provedor
+func (r *MemoryRepository) GetVinculo(emissor, assunto string) (*Vinculo, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	v, exists := r.vinculos[chaveVinculo(emissor, assunto)]
+	if !exists {
+		return nil, errors.New("vínculo não encontrado")
+	}
+	c := *v
+	return &c, nil
+}
+
+// SalvarVinculo cria ou substitui o vínculo da identidade do provedor
+func (r *MemoryRepository) SalvarVinculo(v *Vinculo) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if v.Emissor == "" || v.Assunto == "" || v.UsuarioID <= 0 {
+		return errors.New("emissor, assunto e usuário são obrigatórios")
+	}
+	c := *v
+	r.vinculos[chaveVinculo(v.Emissor, v.Assunto)] = &c
+	return nil
+}
+
+func chaveVinculo(emissor, assunto string) string {
+	return emissor + "\x00" + assunto
+}
+
+func copiarConfiguracao(c *Configuracao) *Configuracao {
+	copia := *c
+	copia.DominiosPermitidos = append([]string(nil), c.DominiosPermitidos...)
+	if c.Papeis != nil {
+		copia.Papeis = make(map[string]string, len(c.Papeis))
+		for k, v := range c.Papeis {
+			copia.Papeis[k] = v
+		}
+	}
+	return &copia
+}
@@ -0,0 +1,536 @@
+package sso
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/Pantaleaogc/gvero/pkg/oidc"
+)
+
+// campoSegredo identifica o segredo do cliente na cifragem
+const campoSegredo = "sso.client_secret"
+
+// paginaConclusao é a página do frontend que troca o código do login pelo token
+const paginaConclusao = "sso"
+
+// papelAdmin é o Tipo dos administradores da empresa, que o SSO nunca atribui nem altera
+const papelAdmin = "admin"
+
+// LimiteUsuarios retorna o máximo de usuários do plano da empresa (zero é ilimitado) e erro para
+// empresas inexistentes. Recebe a consulta ao empresa.Repository, pois o pacote empresa depende
+// de auth, que depende deste.
+type LimiteUsuarios func(empresaID int) (int, error)
+
+// Service configura o login SSO das empresas e conduz o fluxo OpenID Connect, provisionando
+// os usuários no primeiro acesso
+type Service struct {
+	repo     Repository
+	usuarios usuario.Repository
+	limite   LimiteUsuarios
+	oidc     *oidc.Cliente
+	cifrador *cripto.Cifrador
+	urlAPI   string
+	urlBase  string
+	agora    func() time.Time
+}
+
+// NewService cria um novo serviço. urlAPI é o endereço público da API, usado no redirect_uri
+// cadastrado no provedor, e urlBase o do frontend, que recebe o login concluído; sem cifrador,
+// o segredo do cliente é gravado sem cifragem.
+func NewService(repo Repository, usuarios usuario.Repository, limite LimiteUsuarios, cliente *oidc.Cliente, cifrador *cripto.Cifrador, urlAPI, urlBase string) *Service {
+	if cliente == nil {
+		cliente = oidc.NewCliente(nil)
+	}
+	return &Service{
+		repo:     repo,
+		usuarios: usuarios,
+		limite:   limite,
+		oidc:     cliente,
+		cifrador: cifrador,
+		urlAPI:   strings.TrimRight(urlAPI, "/"),
+		urlBase:  strings.TrimRight(urlBase, "/"),
+		agora:    time.Now,
+	}
+}
+
+// URLCallback é o redirect_uri da empresa, a ser cadastrado no provedor
+func (s *Service) URLCallback(empresaID int) string {
+	return s.urlAPI + "/api/v1/auth/sso/" + strconv.Itoa(empresaID) + "/callback"
+}
+
+// Configuracao retorna a configuração da empresa, sem o segredo
+func (s *Service) Configuracao(empresaID int) (*Configuracao, error) {
+	c, err := s.repo.GetConfiguracao(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	return ocultarSegredo(c), nil
+}
+
+// SalvarConfiguracao valida e grava a configuração da empresa. Ativa, o emissor é consultado
+// para confirmar que responde como provedor OpenID Connect.
+func (s *Service) SalvarConfiguracao(ctx context.Context, c *Configuracao, autorID int) (*Configuracao, error) {
+	c.Emissor = strings.TrimRight(strings.TrimSpace(c.Emissor), "/")
+	c.ClientID = strings.TrimSpace(c.ClientID)
+	if err := validarEmissor(c.Emissor); err != nil {
+		return nil, err
+	}
+	if c.ClientID == "" {
+		return nil, errors.New("informe o client ID")
+	}
+
+	dominios := make([]string, 0, len(c.DominiosPermitidos))
+	for _, d := range c.DominiosPermitidos {
+		d = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(d)), "@")
+		if d == "" {
+			continue
+		}
+		if strings.ContainsAny(d, "@/ ") || !strings.Contains(d, ".") {
+			return nil, fmt.Errorf("domínio inválido: %s", d)
+		}
+		dominios = append(dominios, d)
+	}
+	// Sem restrição de domínio, um provedor multiempresa aceitaria contas de qualquer organização
+	if len(dominios) == 0 {
+		return nil, errors.New("informe ao menos um domínio de e-mail permitido")
+	}
+	c.DominiosPermitidos = dominios
+
+	if c.PapelPadrao == "" {
+		c.PapelPadrao = PapelUsuario
+	}
+	// Os grupos do provedor não concedem administração: uma conta comprometida ou um grupo mal
+	// configurado no provedor daria o controle da empresa
+	if !papelValido(c.PapelPadrao) {
+		return nil, fmt.Errorf("papel padrão inválido: %q; use %s, pois administradores não são definidos pelo SSO", c.PapelPadrao, strings.Join(usuario.PapeisComuns, ", "))
+	}
+	for valor, papel := range c.Papeis {
+		if !papelValido(papel) {
+			return nil, fmt.Errorf("papel inválido para %q: %q; use %s, pois administradores não são definidos pelo SSO", valor, papel, strings.Join(usuario.PapeisComuns, ", "))
+		}
+	}
+	c.ClaimPapeis = strings.TrimSpace(c.ClaimPapeis)
+
+	// Segredo em branco mantém o anterior
+	if c.ClientSecret == "" {
+		anterior, err := s.repo.GetConfiguracao(c.EmpresaID)
+		if err != nil || anterior.ClientSecret == "" {
+			return nil, errors.New("informe o segredo do cliente")
+		}
+		c.ClientSecret = anterior.ClientSecret
+	} else if s.cifrador != nil {
+		cifrado, err := s.cifrador.Cifrar(campoSegredo, cripto.ContextoEmpresa(c.EmpresaID), c.ClientSecret)
+		if err != nil {
+			return nil, err
+		}
+		c.ClientSecret = cifrado
+	}
+
+	if c.Ativo {
+		if _, err := s.oidc.Descobrir(ctx, c.Emissor); err != nil {
+			return nil, fmt.Errorf("o emissor não respondeu como provedor OpenID Connect: %w", err)
+		}
+	}
+
+	c.UsuarioID = autorID
+	c.DataAtualizacao = s.agora()
+	if err := s.repo.SalvarConfiguracao(c); err != nil {
+		return nil, err
+	}
+
+	logger.InfoLogger.Printf("Login SSO da empresa %d configurado com %s por usuário %d (ativo: %t)", c.EmpresaID, c.Emissor, autorID, c.Ativo)
+	return s.Configuracao(c.EmpresaID)
+}
+
+// RemoverConfiguracao desativa o login SSO da empresa. Os usuários provisionados continuam,
+// mas sem senha só voltam a entrar pela redefinição de senha.
+func (s *Service) RemoverConfiguracao(empresaID int) error {
+	return s.repo.DeleteConfiguracao(empresaID)
+}
+
+// Recifrar regrava o segredo do cliente da empresa que ainda esteja em texto puro ou cifrado por
+// uma chave mestra anterior. Retorna quantas configurações foram regravadas.
+func (s *Service) Recifrar(empresaID int) (int, error) {
+	if s.cifrador == nil {
+		return 0, nil
+	}
+	c, err := s.repo.GetConfiguracao(empresaID)
+	if errors.Is(err, ErrNaoConfigurado) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if s.cifrador.Atualizado(c.ClientSecret) {
+		return 0, nil
+	}
+
+	contexto := cripto.ContextoEmpresa(empresaID)
+	segredo, err := s.cifrador.Decifrar(campoSegredo, contexto, c.ClientSecret)
+	if err != nil {
+		return 0, err
+	}
+	if c.ClientSecret, err = s.cifrador.Cifrar(campoSegredo, contexto, segredo); err != nil {
+		return 0, err
+	}
+	return 1, s.repo.SalvarConfiguracao(c)
+}
+
+// Iniciar cria o fluxo e retorna o endereço do provedor para onde o navegador deve ir, com o
+// cookie que prende o fluxo a esse navegador. A dica, quando informada, é repassada como login_hint.
+func (s *Service) Iniciar(ctx context.Context, empresaID int, dica string) (string, *http.Cookie, error) {
+	c, err := s.configuracaoAtiva(empresaID)
+	if err != nil {
+		return "", nil, err
+	}
+	p, err := s.oidc.Descobrir(ctx, c.Emissor)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro na descoberta do provedor SSO da empresa %d: %v", empresaID, err)
+		return "", nil, errors.New("provedor de identidade indisponível")
+	}
+
+	estado, err := oidc.GerarVerificador()
+	if err != nil {
+		return "", nil, err
+	}
+	nonce, err := oidc.GerarVerificador()
+	if err != nil {
+		return "", nil, err
+	}
+	verificador, err := oidc.GerarVerificador()
+	if err != nil {
+		return "", nil, err
+	}
+
+	// Os fluxos abandonados no provedor são descartados nos próximos inícios
+	agora := s.agora()
+	s.repo.ExpurgarFluxos(agora)
+	if err := s.repo.CreateFluxo(&Fluxo{
+		Hash:        hashEstado(estado),
+		EmpresaID:   empresaID,
+		Verificador: verificador,
+		Nonce:       nonce,
+		Expira:      agora.Add(ValidadeFluxo),
+	}); err != nil {
+		return "", nil, err
+	}
+
+	destino := p.URLAutorizacao(c.ClientID, s.URLCallback(empresaID), estado, nonce, verificador, nil)
+	if dica = strings.TrimSpace(dica); dica != "" {
+		destino += "&login_hint=" + url.QueryEscape(dica)
+	}
+	return destino, s.cookieFluxo(empresaID, hashEstado(estado), int(ValidadeFluxo.Seconds())), nil
+}
+
+// CookieExpirado apaga o cookie do fluxo no navegador
+func (s *Service) CookieExpirado(empresaID int) *http.Cookie {
+	return s.cookieFluxo(empresaID, "", -1)
+}
+
+// cookieFluxo leva o hash do estado só às rotas SSO da empresa. SameSite Lax o envia no retorno
+// do provedor, que é uma navegação, e não nas requisições de outros sites.
+func (s *Service) cookieFluxo(empresaID int, valor string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     NomeCookieFluxo,
+		Value:    valor,
+		Path:     "/api/v1/auth/sso/" + strconv.Itoa(empresaID),
+		MaxAge:   maxAge,
+		Secure:   strings.HasPrefix(s.urlAPI, "https://"),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Concluir troca o código do provedor, verifica o ID token e, com o usuário da empresa
+// provisionado no primeiro acesso, retorna o endereço do frontend com o código de troca. O
+// cookie é o valor recebido de Iniciar: sem ele, o retorno veio de outro navegador, como no
+// login forçado com a conta de um atacante.
+func (s *Service) Concluir(ctx context.Context, empresaID int, estado, codigo, cookie string) (string, error) {
+	if estado == "" || codigo == "" {
+		return "", ErrFluxoInvalido
+	}
+	hash := hashEstado(estado)
+	if subtle.ConstantTimeCompare([]byte(cookie), []byte(hash)) != 1 {
+		logger.InfoLogger.Printf("Login SSO da empresa %d recusado: estado sem o cookie do navegador que o iniciou", empresaID)
+		return "", ErrFluxoInvalido
+	}
+	f, err := s.repo.ConsumirFluxo(hash)
+	if err != nil || f.EmpresaID != empresaID || !s.agora().Before(f.Expira) {
+		return "", ErrFluxoInvalido
+	}
+
+	u, err := s.autenticar(ctx, empresaID, f, codigo)
+	if err != nil {
+		return "", err
+	}
+
+	troca, err := oidc.GerarVerificador()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.CreateTroca(&Troca{
+		Hash:      hashEstado(troca),
+		EmpresaID: empresaID,
+		UsuarioID: u.ID,
+		Expira:    s.agora().Add(ValidadeTroca),
+	}); err != nil {
+		return "", err
+	}
+	return s.urlBase + "/" + paginaConclusao + "?codigo=" + url.QueryEscape(troca), nil
+}
+
+// Trocar consome o código do login concluído e retorna o usuário, com a indicação de que a empresa
+// dispensa o segundo fator da aplicação nos logins SSO
+func (s *Service) Trocar(codigo string) (*usuario.Usuario, bool, error) {
+	if codigo == "" {
+		return nil, false, ErrTrocaInvalida
+	}
+	t, err := s.repo.ConsumirTroca(hashEstado(codigo))
+	if err != nil || !s.agora().Before(t.Expira) {
+		return nil, false, ErrTrocaInvalida
+	}
+
+	c, err := s.configuracaoAtiva(t.EmpresaID)
+	if err != nil {
+		return nil, false, err
+	}
+	u, err := s.usuarios.GetByID(t.UsuarioID)
+	if err != nil || u.EmpresaID != t.EmpresaID || !u.Status {
+		return nil, false, ErrTrocaInvalida
+	}
+	return u, c.DispensarSegundoFator, nil
+}
+
+// autenticar troca o código do provedor, verifica o ID token e retorna o usuário da empresa,
+// provisionando-o no primeiro acesso
+func (s *Service) autenticar(ctx context.Context, empresaID int, f *Fluxo, codigo string) (*usuario.Usuario, error) {
+
+	c, err := s.configuracaoAtiva(empresaID)
+	if err != nil {
+		return nil, err
+	}
+	segredo := c.ClientSecret
+	if s.cifrador != nil {
+		if segredo, err = s.cifrador.Decifrar(campoSegredo, cripto.ContextoEmpresa(empresaID), c.ClientSecret); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := s.oidc.Descobrir(ctx, c.Emissor)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro na descoberta do provedor SSO da empresa %d: %v", empresaID, err)
+		return nil, errors.New("provedor de identidade indisponível")
+	}
+	tokens, err := p.TrocarCodigo(ctx, c.ClientID, segredo, s.URLCallback(empresaID), codigo, f.Verificador)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro no login SSO da empresa %d: %v", empresaID, err)
+		return nil, ErrFluxoInvalido
+	}
+	claims, err := p.VerificarIDToken(ctx, tokens.IDToken, c.ClientID, f.Nonce)
+	if err != nil {
+		logger.ErrorLogger.Printf("ID token recusado no login SSO da empresa %d: %v", empresaID, err)
+		return nil, ErrFluxoInvalido
+	}
+
+	return s.provisionar(c, claims)
+}
+
+// provisionar localiza o usuário pelo vínculo ou pelo e-mail e o cria no primeiro acesso
+func (s *Service) provisionar(c *Configuracao, claims *oidc.Claims) (*usuario.Usuario, error) {
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	// O Microsoft Entra pode omitir email; o preferred_username costuma ser o UPN
+	if email == "" && strings.Contains(claims.UsuarioPreferido, "@") {
+		email = strings.ToLower(strings.TrimSpace(claims.UsuarioPreferido))
+	}
+	if email == "" {
+		return nil, errors.New("o provedor não informou o e-mail do usuário")
+	}
+	if claims.EmailVerificado != nil && !*claims.EmailVerificado {
+		return nil, errors.New("o e-mail não foi verificado pelo provedor")
+	}
+	_, dominio, _ := strings.Cut(email, "@")
+	if !contem(c.DominiosPermitidos, dominio) {
+		logger.InfoLogger.Printf("Login SSO recusado na empresa %d: domínio %s não permitido", c.EmpresaID, dominio)
+		return nil, errors.New("o domínio do e-mail não é permitido para esta empresa")
+	}
+	papel := s.papel(c, claims)
+
+	// Identidade já vinculada: o e-mail pode ter mudado no provedor
+	if v, err := s.repo.GetVinculo(c.Emissor, claims.Assunto); err == nil {
+		u, err := s.usuarios.GetByID(v.UsuarioID)
+		if err == nil && u.EmpresaID == c.EmpresaID {
+			return s.atualizar(c, u, papel)
+		}
+	}
+
+	u, err := s.usuarios.GetByEmail(email)
+	if err == nil {
+		if u.EmpresaID != c.EmpresaID {
+			logger.InfoLogger.Printf("Login SSO recusado na empresa %d: usuário %d pertence a outra empresa", c.EmpresaID, u.ID)
+			return nil, errors.New("este e-mail pertence a outra empresa")
+		}
+		if err := s.vincular(c, claims, u); err != nil {
+			return nil, err
+		}
+		return s.atualizar(c, u, papel)
+	}
+
+	if err := s.verificarVagas(c.EmpresaID); err != nil {
+		return nil, err
+	}
+	nome := strings.TrimSpace(claims.Nome)
+	if nome == "" {
+		nome, _, _ = strings.Cut(email, "@")
+	}
+	// Sem senha local: o usuário entra apenas pelo provedor, até definir uma pela redefinição
+	u = &usuario.Usuario{
+		Nome:      nome,
+		Email:     email,
+		Status:    true,
+		Tipo:      papel,
+		EmpresaID: c.EmpresaID,
+	}
+	if err := s.usuarios.Create(u); err != nil {
+		return nil, err
+	}
+	if err := s.vincular(c, claims, u); err != nil {
+		return nil, err
+	}
+
+	logger.InfoLogger.Printf("Usuário %d provisionado pelo login SSO na empresa %d como %s", u.ID, c.EmpresaID, papel)
+	return u, nil
+}
+
+// atualizar confere se o usuário está ativo e sincroniza o papel vindo do provedor. O papel dos
+// administradores é mantido: eles só deixam de sê-lo pela aplicação.
+func (s *Service) atualizar(c *Configuracao, u *usuario.Usuario, papel string) (*usuario.Usuario, error) {
+	if !u.Status {
+		return nil, errors.New("usuário inativo")
+	}
+	if c.ClaimPapeis != "" && u.Tipo != papel && u.Tipo != papelAdmin {
+		logger.InfoLogger.Printf("Papel do usuário %d alterado de %s para %s pelo login SSO", u.ID, u.Tipo, papel)
+		u.Tipo = papel
+		if err := s.usuarios.Update(u); err != nil {
+			return nil, err
+		}
+	}
+	return u, nil
+}
+
+func (s *Service) vincular(c *Configuracao, claims *oidc.Claims, u *usuario.Usuario) error {
+	return s.repo.SalvarVinculo(&Vinculo{
+		EmpresaID:   c.EmpresaID,
+		Emissor:     c.Emissor,
+		Assunto:     claims.Assunto,
+		UsuarioID:   u.ID,
+		DataCriacao: s.agora(),
+	})
+}
+
+// papel aplica o mapeamento da declaração de grupos: vale o primeiro valor mapeado e, sem nenhum,
+// o papel padrão
+func (s *Service) papel(c *Configuracao, claims *oidc.Claims) string {
+	if c.ClaimPapeis != "" {
+		for _, valor := range claims.Strings(c.ClaimPapeis) {
+			if papel, ok := c.Papeis[valor]; ok && papelValido(papel) {
+				return papel
+			}
+		}
+	}
+	if papelValido(c.PapelPadrao) {
+		return c.PapelPadrao
+	}
+	return PapelUsuario
+}
+
+// verificarVagas confere o limite de usuários do plano da empresa
+func (s *Service) verificarVagas(empresaID int) error {
+	max, err := s.limite(empresaID)
+	if err != nil {
+		return ErrNaoConfigurado
+	}
+	if max <= 0 {
+		return nil
+	}
+
+	usuarios, err := s.usuarios.List(0, 0)
+	if err != nil {
+		return err
+	}
+	ocupadas := 0
+	for _, u := range usuarios {
+		if u.EmpresaID == empresaID {
+			ocupadas++
+		}
+	}
+	if ocupadas >= max {
+		return fmt.Errorf("limite de %d usuários do plano atingido", max)
+	}
+	return nil
+}
+
+// configuracaoAtiva carrega a configuração de uma empresa existente com o SSO ativo
+func (s *Service) configuracaoAtiva(empresaID int) (*Configuracao, error) {
+	if _, err := s.limite(empresaID); err != nil {
+		return nil, ErrNaoConfigurado
+	}
+	c, err := s.repo.GetConfiguracao(empresaID)
+	if err != nil || !c.Ativo {
+		return nil, ErrNaoConfigurado
+	}
+	return c, nil
+}
+
+// validarEmissor exige HTTPS, salvo em endereços locais, como o do provedor simulado
+func validarEmissor(emissor string) error {
+	u, err := url.Parse(emissor)
+	if err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+		return errors.New("emissor inválido; informe a URL do provedor, como https://accounts.google.com")
+	}
+	if u.Scheme == "https" {
+		return nil
+	}
+	host := u.Hostname()
+	if ip := net.ParseIP(host); u.Scheme == "http" && (host == "localhost" || (ip != nil && ip.IsLoopback())) {
+		return nil
+	}
+	return errors.New("o emissor deve usar HTTPS")
+}
+
+func ocultarSegredo(c *Configuracao) *Configuracao {
+	c.SegredoConfigurado = c.ClientSecret != ""
+	c.ClientSecret = ""
+	return c
+}
+
+// papelValido aceita apenas os papéis comuns: os grupos do provedor não concedem administração
+func papelValido(papel string) bool {
+	return papel != papelAdmin && usuario.PapelComum(papel)
+}
+
+func hashEstado(estado string) string {
+	h := sha256.Sum256([]byte(estado))
+	return hex.EncodeToString(h[:])
+}
+
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,275 @@
+package sso
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/internal/usuario"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+	"github.com/Pantaleaogc/gvero/pkg/oidc"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+const (
+	urlAPITeste      = "https://api.gvero.test"
+	urlFrontendTeste = "https://app.gvero.test"
+)
+
+// ambiente é o serviço ligado ao provedor simulado, com o SSO ativo na empresa 1
+type ambiente struct {
+	s         *Service
+	repo      *MemoryRepository
+	usuarios  usuario.Repository
+	mock      *oidc.Mock
+	navegador *http.Client
+}
+
+func novoAmbiente(t *testing.T) *ambiente {
+	t.Helper()
+	mock, err := oidc.NewMock("", "gvero", "segredo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mock)
+	t.Cleanup(srv.Close)
+	mock.Emissor = srv.URL
+
+	repo := NewMemoryRepository()
+	usuarios := usuario.NewMemoryRepository()
+	limite := func(empresaID int) (int, error) {
+		if empresaID != 1 {
+			return 0, errors.New("empresa não encontrada")
+		}
+		return 0, nil
+	}
+	s := NewService(repo, usuarios, limite, oidc.NewCliente(srv.Client()), nil, urlAPITeste, urlFrontendTeste)
+
+	if _, err := s.SalvarConfiguracao(context.Background(), &Configuracao{
+		EmpresaID:          1,
+		Ativo:              true,
+		Emissor:            srv.URL,
+		ClientID:           "gvero",
+		ClientSecret:       "segredo",
+		DominiosPermitidos: []string{"acme.com"},
+	}, 1); err != nil {
+		t.Fatalf("SalvarConfiguracao: %v", err)
+	}
+
+	// O navegador não segue o retorno do provedor, que aponta para a API
+	navegador := srv.Client()
+	navegador.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	return &ambiente{s: s, repo: repo, usuarios: usuarios, mock: mock, navegador: navegador}
+}
+
+// autorizar leva o navegador ao provedor e retorna o estado e o código do retorno ao callback
+func (a *ambiente) autorizar(t *testing.T, destino string) (string, string) {
+	t.Helper()
+	resp, err := a.navegador.Get(destino)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("provedor respondeu %d", resp.StatusCode)
+	}
+	retorno, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(retorno.String(), a.s.URLCallback(1)+"?") {
+		t.Fatalf("retorno ao callback errado: %s", retorno)
+	}
+	return retorno.Query().Get("state"), retorno.Query().Get("code")
+}
+
+// entrar percorre o fluxo completo no mesmo navegador e retorna o código de troca
+func (a *ambiente) entrar(t *testing.T, email string) string {
+	t.Helper()
+	destino, cookie, err := a.s.Iniciar(context.Background(), 1, email)
+	if err != nil {
+		t.Fatalf("Iniciar: %v", err)
+	}
+	estado, codigo := a.autorizar(t, destino)
+	conclusao, err := a.s.Concluir(context.Background(), 1, estado, codigo, cookie.Value)
+	if err != nil {
+		t.Fatalf("Concluir: %v", err)
+	}
+	return codigoDeTroca(t, conclusao)
+}
+
+func codigoDeTroca(t *testing.T, conclusao string) string {
+	t.Helper()
+	if !strings.HasPrefix(conclusao, urlFrontendTeste+"/sso?codigo=") {
+		t.Fatalf("conclusão fora do frontend: %s", conclusao)
+	}
+	u, _ := url.Parse(conclusao)
+	return u.Query().Get("codigo")
+}
+
+func TestFluxoCompleto(t *testing.T) {
+	a := novoAmbiente(t)
+	ctx := context.Background()
+
+	destino, cookie, err := a.s.Iniciar(ctx, 1, "ana@acme.com")
+	if err != nil {
+		t.Fatalf("Iniciar: %v", err)
+	}
+	if cookie.Name != NomeCookieFluxo || !cookie.HttpOnly || !cookie.Secure || cookie.SameSite != http.SameSiteLaxMode ||
+		cookie.Path != "/api/v1/auth/sso/1" || cookie.MaxAge != int(ValidadeFluxo.Seconds()) {
+		t.Errorf("cookie do fluxo = %+v", cookie)
+	}
+
+	estado, codigo := a.autorizar(t, destino)
+	if cookie.Value == estado || cookie.Value != hashEstado(estado) {
+		t.Error("o cookie deve levar o hash do estado, não o estado")
+	}
+
+	// Login forçado: o retorno chega a um navegador sem o cookie ou com o de outro fluxo
+	_, outro, _ := a.s.Iniciar(ctx, 1, "")
+	for _, c := range []string{"", outro.Value, estado} {
+		if _, err := a.s.Concluir(ctx, 1, estado, codigo, c); !errors.Is(err, ErrFluxoInvalido) {
+			t.Fatalf("Concluir com o cookie %q: %v", c, err)
+		}
+	}
+	if _, err := a.s.Concluir(ctx, 2, estado, codigo, cookie.Value); err == nil {
+		t.Fatal("fluxo concluído em outra empresa")
+	}
+
+	// O retorno na outra empresa consumiu o fluxo; o navegador começa de novo
+	destino, cookie, _ = a.s.Iniciar(ctx, 1, "ana@acme.com")
+	estado, codigo = a.autorizar(t, destino)
+	conclusao, err := a.s.Concluir(ctx, 1, estado, codigo, cookie.Value)
+	if err != nil {
+		t.Fatalf("Concluir: %v", err)
+	}
+	if strings.Contains(conclusao, "token") || strings.Contains(conclusao, codigo) {
+		t.Errorf("conclusão expõe credenciais: %s", conclusao)
+	}
+	if _, err := a.s.Concluir(ctx, 1, estado, codigo, cookie.Value); !errors.Is(err, ErrFluxoInvalido) {
+		t.Errorf("fluxo reutilizado: %v", err)
+	}
+
+	troca := codigoDeTroca(t, conclusao)
+	u, dispensado, err := a.s.Trocar(troca)
+	if err != nil {
+		t.Fatalf("Trocar: %v", err)
+	}
+	if u.Email != "ana@acme.com" || u.EmpresaID != 1 || u.Tipo != PapelUsuario || !u.Status || dispensado {
+		t.Errorf("Trocar = %+v, dispensado %t", u, dispensado)
+	}
+	if _, _, err := a.s.Trocar(troca); !errors.Is(err, ErrTrocaInvalida) {
+		t.Errorf("código de troca reutilizado: %v", err)
+	}
+
+	// O segundo login encontra o usuário pelo vínculo, sem duplicá-lo
+	u2, _, err := a.s.Trocar(a.entrar(t, "ana@acme.com"))
+	if err != nil || u2.ID != u.ID {
+		t.Errorf("segundo login = %+v, %v", u2, err)
+	}
+}
+
+func TestTrocaExpirada(t *testing.T) {
+	a := novoAmbiente(t)
+	troca := a.entrar(t, "ana@acme.com")
+
+	agora := time.Now().Add(ValidadeTroca)
+	a.s.agora = func() time.Time { return agora }
+	if _, _, err := a.s.Trocar(troca); !errors.Is(err, ErrTrocaInvalida) {
+		t.Errorf("código de troca expirado aceito: %v", err)
+	}
+	if _, _, err := a.s.Trocar(""); !errors.Is(err, ErrTrocaInvalida) {
+		t.Errorf("código vazio aceito: %v", err)
+	}
+}
+
+func TestDispensarSegundoFator(t *testing.T) {
+	a := novoAmbiente(t)
+	c, _ := a.repo.GetConfiguracao(1)
+	c.DispensarSegundoFator = true
+	a.repo.SalvarConfiguracao(c)
+
+	if _, dispensado, err := a.s.Trocar(a.entrar(t, "ana@acme.com")); err != nil || !dispensado {
+		t.Errorf("Trocar = dispensado %t, %v", dispensado, err)
+	}
+}
+
+func TestGruposNaoConcedemAdmin(t *testing.T) {
+	a := novoAmbiente(t)
+	ctx := context.Background()
+	c, _ := a.repo.GetConfiguracao(1)
+
+	for _, alterar := range []func(c *Configuracao){
+		func(c *Configuracao) { c.PapelPadrao = "admin" },
+		func(c *Configuracao) { c.ClaimPapeis, c.Papeis = "groups", map[string]string{"ti": "admin"} },
+		func(c *Configuracao) { c.ClaimPapeis, c.Papeis = "groups", map[string]string{"ti": "root"} },
+		func(c *Configuracao) { c.PapelPadrao = "gerente" },
+	} {
+		nova := copiarConfiguracao(c)
+		nova.ClientSecret = ""
+		alterar(nova)
+		if _, err := a.s.SalvarConfiguracao(ctx, nova, 1); err == nil {
+			t.Errorf("configuração com papel fora dos comuns aceita: %+v", nova)
+		}
+	}
+}
+
+func TestMapeamentoDePapeis(t *testing.T) {
+	a := novoAmbiente(t)
+	ctx := context.Background()
+	c, _ := a.repo.GetConfiguracao(1)
+	nova := copiarConfiguracao(c)
+	nova.ClientSecret = ""
+	nova.ClaimPapeis = "groups"
+	nova.Papeis = map[string]string{"comercial": "vendedor", "contas": "financeiro"}
+	nova.PapelPadrao = "suporte"
+	if _, err := a.s.SalvarConfiguracao(ctx, nova, 1); err != nil {
+		t.Fatalf("SalvarConfiguracao: %v", err)
+	}
+
+	entrar := func(email string, grupos ...string) *usuario.Usuario {
+		t.Helper()
+		a.mock.Identidade["groups"] = grupos
+		u, _, err := a.s.Trocar(a.entrar(t, email))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	// Provisionado pelo primeiro grupo mapeado, na ordem da declaração
+	if u := entrar("ana@acme.com", "todos", "contas", "comercial"); u.Tipo != "financeiro" {
+		t.Errorf("provisionada como %s", u.Tipo)
+	}
+	// O papel acompanha os grupos a cada login, e sem grupo mapeado vale o padrão
+	if u := entrar("ana@acme.com", "comercial"); u.Tipo != "vendedor" {
+		t.Errorf("depois da troca de grupo: %s", u.Tipo)
+	}
+	if u := entrar("ana@acme.com"); u.Tipo != "suporte" {
+		t.Errorf("sem grupo mapeado: %s", u.Tipo)
+	}
+
+	// O administrador existente continua administrador, mesmo em um grupo mapeado
+	admin := &usuario.Usuario{Nome: "Bia", Email: "bia@acme.com", Tipo: "admin", Status: true, EmpresaID: 1}
+	if err := a.usuarios.Create(admin); err != nil {
+		t.Fatal(err)
+	}
+	if u := entrar("bia@acme.com", "comercial"); u.ID != admin.ID || u.Tipo != "admin" {
+		t.Errorf("administrador alterado pelo SSO: %+v", u)
+	}
+}
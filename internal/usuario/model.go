@@ -13,7 +13,25 @@ type Usuario struct {
     UltimoAcesso time.Time `json:"ultimo_acesso"`
     Status       bool      `json:"status"`
     DataCriacao  time.Time `json:"data_criacao"`
-    Tipo         string    `json:"tipo"` // "admin", "cliente", etc.
+    Tipo         string    `json:"tipo"` // "admin" ou um dos PapeisComuns
+    EmpresaID    int       `json:"empresa_id,omitempty"`
+}
+
+// TipoAdmin é o administrador da empresa, ou do sistema quando não tem empresa
+const TipoAdmin = "admin"
+
+// PapeisComuns são os tipos de usuário sem administração. Todos têm o mesmo acesso às rotas; o
+// papel descreve a função do usuário na empresa e é o que o SSO atribui pelos grupos do provedor.
+var PapeisComuns = []string{"usuario", "vendedor", "financeiro", "suporte"}
+
+// PapelComum indica se o tipo é um dos PapeisComuns
+func PapelComum(tipo string) bool {
+    for _, p := range PapeisComuns {
+        if p == tipo {
+            return true
+        }
+    }
+    return false
 }
 
 // Repository define a interface para acesso aos dados de usuários
@@ -24,6 +42,7 @@ type Repository interface {
     Update(u *Usuario) error
     Delete(id int) error
     List(limit, offset int) ([]*Usuario, error)
+    ListByEmpresa(empresaID, limit, offset int) ([]*Usuario, error)
 }
 
 // Implementação do repositório será expandida mais tarde
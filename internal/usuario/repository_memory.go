@@ -2,6 +2,7 @@ package usuario
 
 import (
 	"errors"
+	"sort"
 	"sync"
 	"time"
 )
@@ -46,11 +47,6 @@ func (r *MemoryRepository) Create(u *Usuario) error {
 		u.Status = true // padrão ativo
 	}
 
-	// Simular senha (na implementação real deve ser hasheada)
-	if u.Senha == "" {
-		u.Senha = "senha_padrao" // Apenas para teste
-	}
-
 	// Adicionar no mapa
 	r.usuarios[u.ID] = u
 	return nil
@@ -102,8 +98,11 @@ func (r *MemoryRepository) Update(u *Usuario) error {
 		}
 	}
 
-	// Preservar campos que não devem ser alterados
+	// Preservar campos que não devem ser alterados; a senha só muda quando informada
 	u.DataCriacao = r.usuarios[u.ID].DataCriacao
+	if u.Senha == "" {
+		u.Senha = r.usuarios[u.ID].Senha
+	}
 
 	// Atualizar
 	r.usuarios[u.ID] = u
@@ -152,3 +151,26 @@ func (r *MemoryRepository) List(limit, offset int) ([]*Usuario, error) {
 
 	return result, nil
 }
+
+// ListByEmpresa retorna os usuários de uma empresa, em ordem de ID
+func (r *MemoryRepository) ListByEmpresa(empresaID, limit, offset int) ([]*Usuario, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var result []*Usuario
+	for _, u := range r.usuarios {
+		if u.EmpresaID == empresaID {
+			result = append(result, u)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	if offset >= len(result) {
+		return []*Usuario{}, nil
+	}
+	result = result[offset:]
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
@@ -0,0 +1,57 @@
+package usuario
+
+import (
+	"errors"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Limites de tamanho das senhas; o bcrypt considera apenas os primeiros 72 bytes
+const (
+	TamanhoMinimoSenha = 8
+	TamanhoMaximoSenha = 72
+)
+
+// ValidarSenha verifica os requisitos mínimos de uma nova senha
+func ValidarSenha(senha string) error {
+	if utf8.RuneCountInString(senha) < TamanhoMinimoSenha {
+		return errors.New("a senha deve ter ao menos 8 caracteres")
+	}
+	if len(senha) > TamanhoMaximoSenha {
+		return errors.New("a senha deve ter no máximo 72 bytes")
+	}
+	return nil
+}
+
+// HashSenha gera o hash bcrypt da senha para gravação
+func HashSenha(senha string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(senha), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// VerificarSenha compara a senha informada com o hash gravado.
+// Usuários sem senha definida (convidados ou criados sem senha) não podem entrar.
+func (u *Usuario) VerificarSenha(senha string) bool {
+	if u.Senha == "" {
+		SimularVerificacao(senha)
+		return false
+	}
+	return bcrypt.CompareHashAndPassword([]byte(u.Senha), []byte(senha)) == nil
+}
+
+// hashFicticio é o hash comparado quando não há senha a conferir, gerado na primeira comparação
+var hashFicticio = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("senha-ficticia-para-comparacao"), bcrypt.DefaultCost)
+	return hash
+})
+
+// SimularVerificacao gasta o mesmo tempo de VerificarSenha quando a conta não existe ou não tem
+// senha, para que o tempo de resposta do login não revele quais e-mails estão cadastrados
+func SimularVerificacao(senha string) {
+	bcrypt.CompareHashAndPassword(hashFicticio(), []byte(senha))
+}
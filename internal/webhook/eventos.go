@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"github.com/Pantaleaogc/gvero/internal/events"
+)
+
+// AssinarEventos publica nos webhooks das empresas os eventos de domínio de clientes, pedidos e negócios
+func AssinarEventos(barramento *events.Barramento, service *Service) {
+	barramento.Assinar("webhooks", func(ev *events.Evento) error {
+		switch d := ev.Dados.(type) {
+		case events.ClienteCriado:
+			return service.Publicar(ev.EmpresaID, EventoClienteCriado, &d.Cliente)
+		case events.ClienteAlterado:
+			return service.Publicar(ev.EmpresaID, EventoClienteAlterado, &d.Cliente)
+		case events.ClienteAnonimizado:
+			return service.Publicar(ev.EmpresaID, EventoClienteAlterado, &d.Cliente)
+		case events.ClienteExcluido:
+			return service.Publicar(ev.EmpresaID, EventoClienteExcluido, map[string]int{"id": d.ID})
+		case events.PedidoCriado:
+			return service.Publicar(ev.EmpresaID, EventoPedidoCriado, &d.Pedido)
+		case events.PedidoAlterado:
+			return service.Publicar(ev.EmpresaID, EventoPedidoAlterado, &d.Pedido)
+		case events.NegocioCriado:
+			return service.Publicar(ev.EmpresaID, EventoNegocioCriado, &d.Negocio)
+		case events.NegocioAlterado:
+			return service.Publicar(ev.EmpresaID, EventoNegocioAlterado, &d.Negocio)
+		case events.NegocioGanho:
+			return service.Publicar(ev.EmpresaID, EventoNegocioGanho, &d.Negocio)
+		}
+		return nil
+	}, events.TipoClienteCriado, events.TipoClienteAlterado, events.TipoClienteAnonimizado,
+		events.TipoClienteExcluido, events.TipoPedidoCriado, events.TipoPedidoAlterado,
+		events.TipoNegocioCriado, events.TipoNegocioAlterado, events.TipoNegocioGanho)
+}
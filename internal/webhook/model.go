@@ -0,0 +1,159 @@
+package webhook
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Eventos que podem ser assinados pelos endpoints
+const (
+	EventoClienteCriado   = "cliente.created"
+	EventoClienteAlterado = "cliente.updated"
+	EventoClienteExcluido = "cliente.deleted"
+	EventoPedidoCriado    = "pedido.created"
+	EventoPedidoAlterado  = "pedido.updated"
+	EventoNegocioCriado   = "negocio.created"
+	EventoNegocioAlterado = "negocio.updated"
+	EventoNegocioGanho    = "negocio.won"
+
+	// EventoTeste é enviado apenas pelo teste manual do endpoint
+	EventoTeste = "webhook.test"
+
+	// TodosEventos assina todos os eventos, inclusive os criados depois do cadastro do endpoint
+	TodosEventos = "*"
+)
+
+// Eventos é o catálogo de eventos disponíveis para assinatura
+var Eventos = []string{
+	EventoClienteCriado,
+	EventoClienteAlterado,
+	EventoClienteExcluido,
+	EventoPedidoCriado,
+	EventoPedidoAlterado,
+	EventoNegocioCriado,
+	EventoNegocioAlterado,
+	EventoNegocioGanho,
+}
+
+// Status das entregas
+const (
+	StatusPendente = "pendente" // aguardando a primeira tentativa
+	StatusFalhou   = "falhou"   // a última tentativa falhou; haverá nova tentativa
+	StatusEntregue = "entregue"
+	StatusMorta    = "morta" // tentativas esgotadas; só é reenviada manualmente
+)
+
+// Política de novas tentativas: o intervalo dobra a cada falha até o máximo
+const (
+	MaxTentativas    = 8
+	IntervaloInicial = 30 * time.Second
+	IntervaloMaximo  = 6 * time.Hour
+)
+
+// Endpoint é uma URL da empresa que recebe os eventos assinados
+type Endpoint struct {
+	ID              int       `json:"id"`
+	EmpresaID       int       `json:"empresa_id"`
+	URL             string    `json:"url"`
+	Descricao       string    `json:"descricao,omitempty"`
+	Eventos         []string  `json:"eventos"`
+	Segredo         string    `json:"segredo,omitempty"` // exibido apenas na criação e na rotação
+	Ativo           bool      `json:"ativo"`
+	UsuarioID       int       `json:"usuario_id"`
+	DataCriacao     time.Time `json:"data_criacao"`
+	DataAtualizacao time.Time `json:"data_atualizacao"`
+}
+
+// Assina indica se o endpoint recebe o evento
+func (e *Endpoint) Assina(evento string) bool {
+	for _, ev := range e.Eventos {
+		if ev == evento || ev == TodosEventos {
+			return true
+		}
+	}
+	return false
+}
+
+// Evento é o corpo enviado aos endpoints
+type Evento struct {
+	ID        string      `json:"id"`
+	Tipo      string      `json:"tipo"`
+	EmpresaID int         `json:"empresa_id"`
+	Data      time.Time   `json:"data"`
+	Dados     interface{} `json:"dados"`
+}
+
+// Entrega acompanha o envio de um evento a um endpoint
+type Entrega struct {
+	ID               int             `json:"id"`
+	EmpresaID        int             `json:"empresa_id"`
+	EndpointID       int             `json:"endpoint_id"`
+	EventoID         string          `json:"evento_id"`
+	Evento           string          `json:"evento"`
+	Payload          json.RawMessage `json:"payload"`
+	Status           string          `json:"status"`
+	Tentativas       int             `json:"tentativas"`
+	ProximaTentativa time.Time       `json:"proxima_tentativa,omitempty"`
+	Registros        []Tentativa     `json:"registros"`
+	DataCriacao      time.Time       `json:"data_criacao"`
+	DataEntrega      time.Time       `json:"data_entrega,omitempty"`
+}
+
+// Tentativa registra o resultado de um envio
+type Tentativa struct {
+	Numero     int       `json:"numero"`
+	Data       time.Time `json:"data"`
+	Manual     bool      `json:"manual"`
+	StatusHTTP int       `json:"status_http,omitempty"`
+	Erro       string    `json:"erro,omitempty"`
+	DuracaoMs  int64     `json:"duracao_ms"`
+}
+
+// tentativasAutomaticas conta as tentativas feitas pelo despachante, que esgotam a entrega
+func (e *Entrega) tentativasAutomaticas() int {
+	n := 0
+	for _, t := range e.Registros {
+		if !t.Manual {
+			n++
+		}
+	}
+	return n
+}
+
+// FiltroEntregas restringe a listagem de entregas; campos zerados não filtram
+type FiltroEntregas struct {
+	EmpresaID  int
+	EndpointID int
+	Status     string
+	Evento     string
+}
+
+// Repository define a interface para acesso aos endpoints e às entregas
+type Repository interface {
+	CreateEndpoint(e *Endpoint) error
+	GetEndpoint(id, empresaID int) (*Endpoint, error)
+	UpdateEndpoint(e *Endpoint) error
+	DeleteEndpoint(id, empresaID int) error
+	ListEndpoints(empresaID int) ([]*Endpoint, error)
+
+	CreateEntrega(e *Entrega) error
+	GetEntrega(id, empresaID int) (*Entrega, error)
+	UpdateEntrega(e *Entrega) error
+	// ListEntregas retorna as entregas das mais recentes para as mais antigas
+	ListEntregas(f FiltroEntregas, limit, offset int) ([]*Entrega, error)
+	// Pendentes retorna as entregas de todas as empresas com tentativa prevista até o instante informado
+	Pendentes(ate time.Time, limit int) ([]*Entrega, error)
+}
+
+// eventoValido verifica se o evento está no catálogo
+func eventoValido(evento string) bool {
+	if evento == TodosEventos {
+		return true
+	}
+	for _, ev := range Eventos {
+		if ev == evento {
+			return true
+		}
+	}
+	return false
+}
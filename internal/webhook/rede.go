@@ -0,0 +1,87 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// ErrDestinoProibido indica um endpoint que resolve para a rede interna do servidor
+var ErrDestinoProibido = errors.New("o endpoint não pode apontar para endereços internos, de loopback ou link-local")
+
+// redesProibidas completa as verificações de netip com faixas não roteáveis na internet
+var redesProibidas = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"), // CGNAT, usada por provedores de nuvem internamente
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"), // NAT64, que alcançaria endereços IPv4 internos
+}
+
+// ipPublico indica se o endereço pode receber entregas: não é privado, de loopback, link-local
+// (inclusive o serviço de metadados da nuvem, 169.254.169.254), multicast nem não especificado
+func ipPublico(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	if !ip.IsValid() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return false
+	}
+	for _, rede := range redesProibidas {
+		if rede.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// verificarHost resolve o host da URL e recusa o cadastro se algum endereço não for público
+func (s *Service) verificarHost(ctx context.Context, host string) error {
+	ips, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return fmt.Errorf("não foi possível resolver o host do endpoint: %s", host)
+	}
+	for _, ip := range ips {
+		if !s.ipPermitido(ip) {
+			return ErrDestinoProibido
+		}
+	}
+	return nil
+}
+
+// novoCliente cria o cliente HTTP das entregas. A conexão confere o endereço já resolvido, de
+// modo que um DNS alterado depois do cadastro também não alcança a rede interna, e os
+// redirecionamentos não são seguidos.
+func (s *Service) novoCliente(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(_, endereco string, _ syscall.RawConn) error {
+			ap, err := netip.ParseAddrPort(endereco)
+			if err != nil || !s.ipPermitido(ap.Addr()) {
+				return ErrDestinoProibido
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// Sem proxy: o destino conferido na conexão é o próprio endpoint
+			Proxy:                 nil,
+			DialContext:           dialer.DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConnsPerHost:   maxPorEndpoint,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: timeout,
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryRepository implementa Repository em memória
+type MemoryRepository struct {
+	mu             sync.RWMutex
+	endpoints      map[int]*Endpoint
+	entregas       map[int]*Entrega
+	nextEndpointID int
+	nextEntregaID  int
+}
+
+// NewMemoryRepository cria um novo repositório em memória
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{
+		endpoints:      make(map[int]*Endpoint),
+		entregas:       make(map[int]*Entrega),
+		nextEndpointID: 1,
+		nextEntregaID:  1,
+	}
+}
+
+// CreateEndpoint adiciona um novo endpoint
+func (r *MemoryRepository) CreateEndpoint(e *Endpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.EmpresaID <= 0 || e.URL == "" {
+		return errors.New("empresa e URL são obrigatórias")
+	}
+
+	e.ID = r.nextEndpointID
+	r.nextEndpointID++
+	e.DataCriacao = time.Now()
+	e.DataAtualizacao = e.DataCriacao
+
+	r.endpoints[e.ID] = e
+	return nil
+}
+
+// GetEndpoint busca um endpoint por ID e empresa
+func (r *MemoryRepository) GetEndpoint(id, empresaID int) (*Endpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, exists := r.endpoints[id]
+	if !exists || e.EmpresaID != empresaID {
+		return nil, errors.New("endpoint não encontrado")
+	}
+	return e, nil
+}
+
+// UpdateEndpoint atualiza um endpoint existente
+func (r *MemoryRepository) UpdateEndpoint(e *Endpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	atual, exists := r.endpoints[e.ID]
+	if !exists || atual.EmpresaID != e.EmpresaID {
+		return errors.New("endpoint não encontrado")
+	}
+
+	e.DataCriacao = atual.DataCriacao
+	e.DataAtualizacao = time.Now()
+	r.endpoints[e.ID] = e
+	return nil
+}
+
+// DeleteEndpoint remove um endpoint; as entregas são mantidas no histórico
+func (r *MemoryRepository) DeleteEndpoint(id, empresaID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, exists := r.endpoints[id]
+	if !exists || e.EmpresaID != empresaID {
+		return errors.New("endpoint não encontrado")
+	}
+
+	delete(r.endpoints, id)
+	return nil
+}
+
+// ListEndpoints retorna os endpoints da empresa
+func (r *MemoryRepository) ListEndpoints(empresaID int) ([]*Endpoint, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Endpoint, 0)
+	for _, e := range r.endpoints {
+		if e.EmpresaID == empresaID {
+			result = append(result, e)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// CreateEntrega adiciona uma nova entrega
+func (r *MemoryRepository) CreateEntrega(e *Entrega) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e.EmpresaID <= 0 || e.EndpointID <= 0 {
+		return errors.New("empresa e endpoint são obrigatórios")
+	}
+
+	e.ID = r.nextEntregaID
+	r.nextEntregaID++
+	if e.DataCriacao.IsZero() {
+		e.DataCriacao = time.Now()
+	}
+
+	r.entregas[e.ID] = e
+	return nil
+}
+
+// GetEntrega busca uma entrega por ID e empresa
+func (r *MemoryRepository) GetEntrega(id, empresaID int) (*Entrega, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	e, exists := r.entregas[id]
+	if !exists || e.EmpresaID != empresaID {
+		return nil, errors.New("entrega não encontrada")
+	}
+	return e, nil
+}
+
+// UpdateEntrega substitui uma entrega existente
+func (r *MemoryRepository) UpdateEntrega(e *Entrega) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	atual, exists := r.entregas[e.ID]
+	if !exists || atual.EmpresaID != e.EmpresaID {
+		return errors.New("entrega não encontrada")
+	}
+
+	r.entregas[e.ID] = e
+	return nil
+}
+
+// ListEntregas retorna as entregas filtradas, das mais recentes para as mais antigas
+func (r *MemoryRepository) ListEntregas(f FiltroEntregas, limit, offset int) ([]*Entrega, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Entrega, 0)
+	for _, e := range r.entregas {
+		if e.EmpresaID != f.EmpresaID {
+			continue
+		}
+		if f.EndpointID > 0 && e.EndpointID != f.EndpointID {
+			continue
+		}
+		if f.Status != "" && e.Status != f.Status {
+			continue
+		}
+		if f.Evento != "" && e.Evento != f.Evento {
+			continue
+		}
+		result = append(result, e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID > result[j].ID
+	})
+
+	if offset >= len(result) {
+		return []*Entrega{}, nil
+	}
+	result = result[offset:]
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+
+	return result, nil
+}
+
+// Pendentes retorna as entregas aguardando tentativa até o instante informado, das mais antigas para as mais recentes
+func (r *MemoryRepository) Pendentes(ate time.Time, limit int) ([]*Entrega, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	result := make([]*Entrega, 0)
+	for _, e := range r.entregas {
+		if e.Status != StatusPendente && e.Status != StatusFalhou {
+			continue
+		}
+		if e.ProximaTentativa.After(ate) {
+			continue
+		}
+		result = append(result, e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if !result[i].ProximaTentativa.Equal(result[j].ProximaTentativa) {
+			return result[i].ProximaTentativa.Before(result[j].ProximaTentativa)
+		}
+		return result[i].ID < result[j].ID
+	})
+
+	if limit > 0 && limit < len(result) {
+		result = result[:limit]
+	}
+	return result, nil
+}
@@ -0,0 +1,428 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+// Cabeçalhos enviados em cada entrega
+const (
+	CabecalhoAssinatura = "X-Gvero-Signature"
+	CabecalhoEvento     = "X-Gvero-Event"
+	CabecalhoEntrega    = "X-Gvero-Delivery"
+)
+
+// loteDespacho é a quantidade de entregas enviadas a cada rodada do despachante
+const loteDespacho = 100
+
+// Limites de envios simultâneos de cada rodada: no total e por endpoint, para que um endpoint
+// lento não ocupe todos os envios nem receba uma rajada de conexões
+const (
+	maxEnvios      = 16
+	maxPorEndpoint = 2
+)
+
+// tempoEnvio é o tempo limite de cada envio
+const tempoEnvio = 10 * time.Second
+
+// Service contém as regras de cadastro de endpoints e de envio dos eventos
+type Service struct {
+	repo        Repository
+	client      *http.Client
+	agora       func() time.Time
+	avisar      chan struct{}
+	ipPermitido func(netip.Addr) bool // destinos aceitos no cadastro e na conexão
+
+	mu       sync.Mutex
+	enviando map[int]bool // entregas com tentativa em andamento
+}
+
+// NewService cria um novo serviço de webhooks. As entregas só alcançam endereços públicos.
+func NewService(repo Repository) *Service {
+	s := &Service{
+		repo:        repo,
+		agora:       time.Now,
+		avisar:      make(chan struct{}, 1),
+		ipPermitido: ipPublico,
+		enviando:    make(map[int]bool),
+	}
+	s.client = s.novoCliente(tempoEnvio)
+	return s
+}
+
+// CriarEndpoint valida e cadastra o endpoint, gerando seu segredo de assinatura
+func (s *Service) CriarEndpoint(ctx context.Context, e *Endpoint) error {
+	if err := s.validarEndpoint(ctx, e); err != nil {
+		return err
+	}
+
+	segredo, err := gerarSegredo()
+	if err != nil {
+		return err
+	}
+	e.Segredo = segredo
+	e.Ativo = true
+
+	return s.repo.CreateEndpoint(e)
+}
+
+// AtualizarEndpoint altera URL, descrição, eventos e situação do endpoint, mantendo o segredo
+func (s *Service) AtualizarEndpoint(ctx context.Context, e *Endpoint) error {
+	atual, err := s.repo.GetEndpoint(e.ID, e.EmpresaID)
+	if err != nil {
+		return err
+	}
+	if err := s.validarEndpoint(ctx, e); err != nil {
+		return err
+	}
+
+	e.Segredo = atual.Segredo
+	e.UsuarioID = atual.UsuarioID
+	return s.repo.UpdateEndpoint(e)
+}
+
+// RotacionarSegredo gera um novo segredo; as próximas tentativas já são assinadas com ele
+func (s *Service) RotacionarSegredo(empresaID, id int) (*Endpoint, error) {
+	atual, err := s.repo.GetEndpoint(id, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	segredo, err := gerarSegredo()
+	if err != nil {
+		return nil, err
+	}
+	e := *atual
+	e.Segredo = segredo
+	if err := s.repo.UpdateEndpoint(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// Publicar agenda a entrega do evento a todos os endpoints ativos da empresa que o assinam
+func (s *Service) Publicar(empresaID int, tipo string, dados interface{}) error {
+	endpoints, err := s.repo.ListEndpoints(empresaID)
+	if err != nil {
+		return err
+	}
+
+	var destinos []*Endpoint
+	for _, e := range endpoints {
+		if e.Ativo && e.Assina(tipo) {
+			destinos = append(destinos, e)
+		}
+	}
+	if len(destinos) == 0 {
+		return nil
+	}
+
+	evento, payload, err := s.novoEvento(empresaID, tipo, dados)
+	if err != nil {
+		return err
+	}
+	for _, e := range destinos {
+		if _, err := s.agendar(e, evento, payload); err != nil {
+			return err
+		}
+	}
+
+	s.acordar()
+	return nil
+}
+
+// Testar envia imediatamente um evento de teste ao endpoint, mesmo que ele não o assine
+func (s *Service) Testar(empresaID, endpointID int) (*Entrega, error) {
+	endpoint, err := s.repo.GetEndpoint(endpointID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+
+	evento, payload, err := s.novoEvento(empresaID, EventoTeste, map[string]interface{}{
+		"endpoint_id": endpoint.ID,
+		"mensagem":    "Evento de teste do webhook",
+	})
+	if err != nil {
+		return nil, err
+	}
+	entrega, err := s.agendar(endpoint, evento, payload)
+	if err != nil {
+		return nil, err
+	}
+	return s.tentar(entrega, true)
+}
+
+// Reenviar faz uma tentativa manual imediata de qualquer entrega, inclusive das mortas ou já entregues.
+// A falha de um reenvio manual não consome as tentativas automáticas.
+func (s *Service) Reenviar(empresaID, entregaID int) (*Entrega, error) {
+	entrega, err := s.repo.GetEntrega(entregaID, empresaID)
+	if err != nil {
+		return nil, err
+	}
+	return s.tentar(entrega, true)
+}
+
+// Processar envia as entregas com tentativa vencida e retorna quantas foram tentadas. Os envios
+// correm em paralelo, até maxEnvios no total e maxPorEndpoint para o mesmo endpoint; as entregas
+// de cada endpoint saem na ordem da fila.
+func (s *Service) Processar() int {
+	pendentes, err := s.repo.Pendentes(s.agora(), loteDespacho)
+	if err != nil {
+		logger.ErrorLogger.Printf("Erro ao buscar entregas de webhook pendentes: %v", err)
+		return 0
+	}
+
+	filas := make(map[int]chan *Entrega)
+	for _, e := range pendentes {
+		if filas[e.EndpointID] == nil {
+			filas[e.EndpointID] = make(chan *Entrega, len(pendentes))
+		}
+		filas[e.EndpointID] <- e
+	}
+
+	vagas := make(chan struct{}, maxEnvios)
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	for _, fila := range filas {
+		close(fila)
+		for i := 0; i < min(maxPorEndpoint, len(fila)); i++ {
+			wg.Add(1)
+			go func(fila chan *Entrega) {
+				defer wg.Done()
+				for e := range fila {
+					vagas <- struct{}{}
+					_, err := s.tentar(e, false)
+					<-vagas
+					if err != nil {
+						logger.ErrorLogger.Printf("Erro ao enviar entrega de webhook %d: %v", e.ID, err)
+						continue
+					}
+					total.Add(1)
+				}
+			}(fila)
+		}
+	}
+	wg.Wait()
+	return int(total.Load())
+}
+
+// Executar despacha as entregas a cada intervalo, ou assim que novos eventos são publicados,
+// até o contexto ser cancelado
+func (s *Service) Executar(ctx context.Context, intervalo time.Duration) {
+	ticker := time.NewTicker(intervalo)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.avisar:
+		}
+
+		// Lotes cheios indicam mais entregas vencidas na fila
+		for s.Processar() == loteDespacho && ctx.Err() == nil {
+		}
+	}
+}
+
+// acordar avisa o despachante sem bloquear quando ele já tem um aviso pendente
+func (s *Service) acordar() {
+	select {
+	case s.avisar <- struct{}{}:
+	default:
+	}
+}
+
+// novoEvento monta o corpo do evento, serializado uma única vez para todas as entregas
+func (s *Service) novoEvento(empresaID int, tipo string, dados interface{}) (*Evento, []byte, error) {
+	id := make([]byte, 12)
+	if _, err := rand.Read(id); err != nil {
+		return nil, nil, err
+	}
+
+	evento := &Evento{
+		ID:        "evt_" + hex.EncodeToString(id),
+		Tipo:      tipo,
+		EmpresaID: empresaID,
+		Data:      s.agora(),
+		Dados:     dados,
+	}
+	payload, err := json.Marshal(evento)
+	if err != nil {
+		return nil, nil, err
+	}
+	return evento, payload, nil
+}
+
+// agendar cria a entrega do evento ao endpoint com a primeira tentativa para já
+func (s *Service) agendar(endpoint *Endpoint, evento *Evento, payload []byte) (*Entrega, error) {
+	entrega := &Entrega{
+		EmpresaID:        endpoint.EmpresaID,
+		EndpointID:       endpoint.ID,
+		EventoID:         evento.ID,
+		Evento:           evento.Tipo,
+		Payload:          payload,
+		Status:           StatusPendente,
+		ProximaTentativa: evento.Data,
+		Registros:        []Tentativa{},
+		DataCriacao:      evento.Data,
+	}
+	if err := s.repo.CreateEntrega(entrega); err != nil {
+		return nil, err
+	}
+	return entrega, nil
+}
+
+// tentar envia a entrega e grava o resultado. A entrega gravada não é alterada: o resultado
+// é gravado numa cópia, para não disputar com leituras concorrentes do histórico.
+func (s *Service) tentar(atual *Entrega, manual bool) (*Entrega, error) {
+	s.mu.Lock()
+	if s.enviando[atual.ID] {
+		s.mu.Unlock()
+		return nil, errors.New("entrega com tentativa em andamento")
+	}
+	s.enviando[atual.ID] = true
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		delete(s.enviando, atual.ID)
+		s.mu.Unlock()
+	}()
+
+	e := *atual
+	e.Registros = append([]Tentativa{}, atual.Registros...)
+	e.Tentativas++
+
+	t := Tentativa{Numero: e.Tentativas, Data: s.agora(), Manual: manual}
+
+	endpoint, err := s.repo.GetEndpoint(e.EndpointID, e.EmpresaID)
+	switch {
+	case err != nil:
+		t.Erro = "endpoint excluído"
+	case !endpoint.Ativo && !manual:
+		t.Erro = "endpoint desativado"
+	default:
+		s.enviar(endpoint, &e, &t)
+	}
+	e.Registros = append(e.Registros, t)
+
+	switch {
+	case t.Erro == "":
+		e.Status = StatusEntregue
+		e.DataEntrega = t.Data
+		e.ProximaTentativa = time.Time{}
+	case manual:
+		// Mantém a situação anterior: a fila automática continua de onde estava
+	case endpoint == nil || !endpoint.Ativo || e.tentativasAutomaticas() >= MaxTentativas:
+		e.Status = StatusMorta
+		e.ProximaTentativa = time.Time{}
+		logger.InfoLogger.Printf("Entrega de webhook %d (%s) desistida após %d tentativas: %s",
+			e.ID, e.Evento, e.Tentativas, t.Erro)
+	default:
+		e.Status = StatusFalhou
+		e.ProximaTentativa = t.Data.Add(intervaloTentativa(e.tentativasAutomaticas()))
+	}
+
+	if err := s.repo.UpdateEntrega(&e); err != nil {
+		return nil, err
+	}
+	return &e, nil
+}
+
+// enviar faz o POST assinado e registra o resultado na tentativa; respostas 2xx confirmam a entrega
+func (s *Service) enviar(endpoint *Endpoint, e *Entrega, t *Tentativa) {
+	inicio := time.Now()
+	defer func() { t.DuracaoMs = time.Since(inicio).Milliseconds() }()
+
+	req, err := http.NewRequest(http.MethodPost, endpoint.URL, bytes.NewReader(e.Payload))
+	if err != nil {
+		t.Erro = err.Error()
+		return
+	}
+	timestamp := t.Data.Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gvero-webhooks/1.0")
+	req.Header.Set(CabecalhoEvento, e.Evento)
+	req.Header.Set(CabecalhoEntrega, strconv.Itoa(e.ID))
+	req.Header.Set(CabecalhoAssinatura, cripto.CabecalhoHMAC(endpoint.Segredo, timestamp, e.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		t.Erro = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+
+	// Só o status é guardado; o corpo é descartado para reaproveitar a conexão
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	t.StatusHTTP = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		t.Erro = fmt.Sprintf("resposta HTTP %d", resp.StatusCode)
+	}
+}
+
+// intervaloTentativa retorna a espera após a n-ésima falha: o intervalo inicial dobra a cada falha
+func intervaloTentativa(falhas int) time.Duration {
+	intervalo := IntervaloInicial
+	for i := 1; i < falhas; i++ {
+		intervalo *= 2
+		if intervalo >= IntervaloMaximo {
+			return IntervaloMaximo
+		}
+	}
+	return intervalo
+}
+
+// validarEndpoint confere a URL, o destino e os eventos assinados
+func (s *Service) validarEndpoint(ctx context.Context, e *Endpoint) error {
+	u, err := url.Parse(strings.TrimSpace(e.URL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return errors.New("URL do endpoint deve ser http ou https")
+	}
+	if u.User != nil {
+		return errors.New("URL do endpoint não pode conter credenciais")
+	}
+	if err := s.verificarHost(ctx, u.Hostname()); err != nil {
+		return err
+	}
+	e.URL = u.String()
+
+	if len(e.Eventos) == 0 {
+		return errors.New("informe ao menos um evento")
+	}
+	for _, ev := range e.Eventos {
+		if !eventoValido(ev) {
+			return fmt.Errorf("evento desconhecido: %s", ev)
+		}
+	}
+	return nil
+}
+
+// gerarSegredo cria o segredo de assinatura do endpoint
+func gerarSegredo() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
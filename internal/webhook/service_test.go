@@ -0,0 +1,194 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Pantaleaogc/gvero/pkg/cripto"
+	"github.com/Pantaleaogc/gvero/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	// Os testes não gravam o arquivo de log
+	logger.InfoLogger = log.New(io.Discard, "", 0)
+	logger.ErrorLogger = log.New(io.Discard, "", 0)
+	logger.DebugLogger = log.New(io.Discard, "", 0)
+	os.Exit(m.Run())
+}
+
+// servicoLocal aceita o loopback, onde rodam os servidores de teste
+func servicoLocal() (*Service, *MemoryRepository) {
+	repo := NewMemoryRepository()
+	s := NewService(repo)
+	s.ipPermitido = func(ip netip.Addr) bool { return ip.Unmap().IsLoopback() }
+	return s, repo
+}
+
+func TestIPPublico(t *testing.T) {
+	casos := map[string]bool{
+		"8.8.8.8":                true,
+		"2001:4860:4860::8888":   true,
+		"127.0.0.1":              false,
+		"10.1.2.3":               false,
+		"172.16.0.1":             false,
+		"192.168.0.10":           false,
+		"169.254.169.254":        false,
+		"100.100.100.200":        false,
+		"0.0.0.0":                false,
+		"::1":                    false,
+		"fe80::1":                false,
+		"fd00::1":                false,
+		"::ffff:127.0.0.1":       false,
+		"::ffff:169.254.169.254": false,
+		"64:ff9b::a9fe:a9fe":     false,
+	}
+	for ip, publico := range casos {
+		if ipPublico(netip.MustParseAddr(ip)) != publico {
+			t.Errorf("ipPublico(%s) = %t", ip, !publico)
+		}
+	}
+}
+
+func TestCadastroRecusaRedeInterna(t *testing.T) {
+	s := NewService(NewMemoryRepository())
+	for _, url := range []string{
+		"http://127.0.0.1:8080/webhook",
+		"http://localhost/webhook",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://[::1]/webhook",
+		"https://10.0.0.5/webhook",
+	} {
+		e := &Endpoint{EmpresaID: 1, URL: url, Eventos: []string{EventoClienteCriado}}
+		if err := s.CriarEndpoint(context.Background(), e); !errors.Is(err, ErrDestinoProibido) {
+			t.Errorf("CriarEndpoint(%s) = %v", url, err)
+		}
+	}
+}
+
+// A conexão confere o endereço resolvido: um endpoint que passou a apontar para a rede interna
+// depois do cadastro não é alcançado
+func TestConexaoRecusaRedeInterna(t *testing.T) {
+	var recebidas atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		recebidas.Add(1)
+	}))
+	defer srv.Close()
+
+	repo := NewMemoryRepository()
+	s := NewService(repo)
+	repo.CreateEndpoint(&Endpoint{EmpresaID: 1, URL: srv.URL, Eventos: []string{TodosEventos}, Ativo: true})
+
+	if err := s.Publicar(1, EventoClienteCriado, map[string]int{"id": 1}); err != nil {
+		t.Fatal(err)
+	}
+	s.Processar()
+
+	entregas, _ := repo.ListEntregas(FiltroEntregas{EmpresaID: 1}, 0, 0)
+	if len(entregas) != 1 || entregas[0].Status != StatusFalhou || !strings.Contains(entregas[0].Registros[0].Erro, ErrDestinoProibido.Error()) {
+		t.Errorf("entregas = %+v", entregas)
+	}
+	if n := recebidas.Load(); n != 0 {
+		t.Errorf("%d requisições chegaram à rede interna", n)
+	}
+}
+
+func TestEntregaAssinadaSemCorpoDaResposta(t *testing.T) {
+	var assinatura, corpo string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		assinatura, corpo = r.Header.Get(CabecalhoAssinatura), string(b)
+		io.WriteString(w, "segredo-interno-do-endpoint")
+	}))
+	defer srv.Close()
+
+	s, repo := servicoLocal()
+	e := &Endpoint{EmpresaID: 1, URL: srv.URL, Eventos: []string{EventoClienteCriado}}
+	if err := s.CriarEndpoint(context.Background(), e); err != nil {
+		t.Fatal(err)
+	}
+	s.Publicar(1, EventoClienteCriado, map[string]int{"id": 1})
+	if n := s.Processar(); n != 1 {
+		t.Fatalf("Processar = %d", n)
+	}
+
+	if err := cripto.VerificarHMAC(e.Segredo, assinatura, []byte(corpo), 5*time.Minute, time.Now()); err != nil {
+		t.Errorf("assinatura recebida: %v", err)
+	}
+	entregas, _ := repo.ListEntregas(FiltroEntregas{EmpresaID: 1}, 0, 0)
+	if entregas[0].Status != StatusEntregue || entregas[0].Registros[0].StatusHTTP != http.StatusOK {
+		t.Errorf("entrega = %+v", entregas[0])
+	}
+}
+
+func TestRedirecionamentoNaoSeguido(t *testing.T) {
+	var seguidas atomic.Int32
+	destino := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seguidas.Add(1)
+	}))
+	defer destino.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, destino.URL, http.StatusTemporaryRedirect)
+	}))
+	defer srv.Close()
+
+	s, repo := servicoLocal()
+	s.CriarEndpoint(context.Background(), &Endpoint{EmpresaID: 1, URL: srv.URL, Eventos: []string{EventoClienteCriado}})
+	s.Publicar(1, EventoClienteCriado, map[string]int{"id": 1})
+	s.Processar()
+
+	entregas, _ := repo.ListEntregas(FiltroEntregas{EmpresaID: 1}, 0, 0)
+	if entregas[0].Status == StatusEntregue || entregas[0].Registros[0].StatusHTTP != http.StatusTemporaryRedirect {
+		t.Errorf("entrega redirecionada = %+v", entregas[0])
+	}
+	if n := seguidas.Load(); n != 0 {
+		t.Errorf("redirecionamento seguido %d vezes", n)
+	}
+}
+
+func TestProcessarLimitaPorEndpoint(t *testing.T) {
+	var mu sync.Mutex
+	simultaneos, pico := map[string]int{}, map[string]int{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		simultaneos[r.URL.Path]++
+		pico[r.URL.Path] = max(pico[r.URL.Path], simultaneos[r.URL.Path])
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		simultaneos[r.URL.Path]--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	s, repo := servicoLocal()
+	for _, caminho := range []string{"/a", "/b", "/c"} {
+		s.CriarEndpoint(context.Background(), &Endpoint{EmpresaID: 1, URL: srv.URL + caminho, Eventos: []string{EventoClienteCriado}})
+	}
+	for i := 0; i < 6; i++ {
+		s.Publicar(1, EventoClienteCriado, map[string]int{"id": i})
+	}
+
+	if n := s.Processar(); n != 18 {
+		t.Errorf("Processar = %d", n)
+	}
+	entregues, _ := repo.ListEntregas(FiltroEntregas{EmpresaID: 1, Status: StatusEntregue}, 0, 0)
+	if len(entregues) != 18 {
+		t.Errorf("%d entregas concluídas", len(entregues))
+	}
+	for caminho, n := range pico {
+		if n > maxPorEndpoint {
+			t.Errorf("%d envios simultâneos para %s", n, caminho)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package brcode
+
+import (
+	"strings"
+	"testing"
+)
+
+// exemploBCB é o BR Code estático do exemplo do Manual de Padrões para Iniciação do PIX (Banco Central)
+const exemploBCB = "00020126580014br.gov.bcb.pix0136123e4567-e12b-12d1-a456-4266554400005204000053039865802BR5913Fulano de Tal6008BRASILIA62070503***63041D3D"
+
+func TestCRC16(t *testing.T) {
+	casos := []struct {
+		nome  string
+		dados string
+		crc   uint16
+	}{
+		{"vetor de verificação CRC-16/CCITT-FALSE", "123456789", 0x29B1},
+		{"vazio mantém o valor inicial", "", 0xFFFF},
+		{"exemplo do Banco Central", exemploBCB[:len(exemploBCB)-4], 0x1D3D},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if got := CRC16([]byte(c.dados)); got != c.crc {
+				t.Errorf("CRC16(%q) = %04X, esperado %04X", c.dados, got, c.crc)
+			}
+		})
+	}
+}
+
+func TestPayloadString(t *testing.T) {
+	casos := []struct {
+		nome    string
+		payload Payload
+		corpo   string // payload sem os 4 dígitos do CRC
+		crc     string // vazio confere apenas a consistência do CRC
+	}{
+		{
+			nome: "estático do exemplo do Banco Central",
+			payload: Payload{
+				Chave:         "123e4567-e12b-12d1-a456-426655440000",
+				NomeRecebedor: "Fulano de Tal",
+				Cidade:        "BRASILIA",
+			},
+			corpo: exemploBCB[:len(exemploBCB)-4],
+			crc:   "1D3D",
+		},
+		{
+			nome: "estático com valor, txid e acentos removidos",
+			payload: Payload{
+				Chave:         "fulano@example.com",
+				NomeRecebedor: "João Conceição",
+				Cidade:        "São Paulo",
+				Valor:         10.5,
+				TxID:          "PEDIDO123",
+			},
+			corpo: "00020126400014br.gov.bcb.pix0118fulano@example.com" +
+				"520400005303986540510.505802BR5914Joao Conceicao6009Sao Paulo" +
+				"62130509PEDIDO1236304",
+		},
+		{
+			nome: "dinâmico usa a URL e txid ***",
+			payload: Payload{
+				URL:           "pix.example.com/v2/cobv/abc",
+				NomeRecebedor: "Loja",
+				Cidade:        "Curitiba",
+				Valor:         99.9,
+				TxID:          "ignorado",
+			},
+			corpo: "00020101021226490014br.gov.bcb.pix2527pix.example.com/v2/cobv/abc" +
+				"520400005303986540599.905802BR5904Loja6008Curitiba62070503***6304",
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			got, err := c.payload.String()
+			if err != nil {
+				t.Fatalf("String: %v", err)
+			}
+			if corpo := got[:len(got)-4]; corpo != c.corpo {
+				t.Errorf("payload = %q, esperado %q", corpo, c.corpo)
+			}
+			if c.crc != "" && got[len(got)-4:] != c.crc {
+				t.Errorf("CRC = %s, esperado %s", got[len(got)-4:], c.crc)
+			}
+			if err := ValidarCRC(got); err != nil {
+				t.Errorf("ValidarCRC: %v", err)
+			}
+		})
+	}
+}
+
+func TestPayloadInvalido(t *testing.T) {
+	casos := []struct {
+		nome    string
+		payload Payload
+	}{
+		{"sem chave nem URL", Payload{NomeRecebedor: "A", Cidade: "B"}},
+		{"chave e URL juntas", Payload{Chave: "a@b.com", URL: "x.com/1", NomeRecebedor: "A", Cidade: "B"}},
+		{"URL com protocolo", Payload{URL: "https://x.com/1", NomeRecebedor: "A", Cidade: "B"}},
+		{"sem recebedor", Payload{Chave: "a@b.com", Cidade: "B"}},
+		{"valor negativo", Payload{Chave: "a@b.com", NomeRecebedor: "A", Cidade: "B", Valor: -1}},
+		{"txid com símbolos", Payload{Chave: "a@b.com", NomeRecebedor: "A", Cidade: "B", TxID: "abc-123"}},
+		{"txid estático longo", Payload{Chave: "a@b.com", NomeRecebedor: "A", Cidade: "B", TxID: strings.Repeat("a", 26)}},
+		{"campo 26 excedido", Payload{Chave: strings.Repeat("a", 78), NomeRecebedor: "A", Cidade: "B"}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if _, err := c.payload.String(); err == nil {
+				t.Error("payload inválido aceito")
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	p, err := Parse(exemploBCB)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if p.Chave != "123e4567-e12b-12d1-a456-426655440000" || p.NomeRecebedor != "Fulano de Tal" ||
+		p.Cidade != "BRASILIA" || p.TxID != "***" || p.Valor != 0 {
+		t.Errorf("Parse = %+v", p)
+	}
+
+	original := Payload{Chave: "+5541999999999", NomeRecebedor: "Loja", Cidade: "Curitiba", Valor: 1234.56, TxID: "ABC1"}
+	s, err := original.String()
+	if err != nil {
+		t.Fatal(err)
+	}
+	lido, err := Parse(s)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if lido.Chave != original.Chave || lido.Valor != original.Valor || lido.TxID != original.TxID {
+		t.Errorf("ida e volta: %+v, esperado %+v", lido, original)
+	}
+}
+
+func TestValidarCRC(t *testing.T) {
+	casos := []struct {
+		nome    string
+		payload string
+		valido  bool
+	}{
+		{"exemplo do Banco Central", exemploBCB, true},
+		{"CRC em minúsculas", exemploBCB[:len(exemploBCB)-4] + "1d3d", true},
+		{"CRC alterado", exemploBCB[:len(exemploBCB)-4] + "1D3E", false},
+		{"conteúdo alterado", strings.Replace(exemploBCB, "Fulano", "Ciclano", 1), false},
+		{"sem campo 63", exemploBCB[:len(exemploBCB)-8], false},
+		{"curto", "6304", false},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if err := ValidarCRC(c.payload); (err == nil) != c.valido {
+				t.Errorf("ValidarCRC = %v, válido esperado %t", err, c.valido)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package brcode
+
+import (
+	"fmt"
+)
+
+// CRC16 calcula o CRC16-CCITT (polinômio 0x1021, valor inicial 0xFFFF) exigido pelo BR Code
+func CRC16(data []byte) uint16 {
+	crc := uint16(0xFFFF)
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// crcHex retorna o CRC no formato de 4 dígitos hexadecimais maiúsculos usado no campo 63
+func crcHex(data string) string {
+	return fmt.Sprintf("%04X", CRC16([]byte(data)))
+}
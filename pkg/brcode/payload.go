@@ -0,0 +1,288 @@
+package brcode
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Identificadores dos campos EMV usados no BR Code do PIX
+const (
+	idPayloadFormat        = "00"
+	idPointOfInitiation    = "01"
+	idMerchantAccount      = "26"
+	idMerchantCategoryCode = "52"
+	idTransactionCurrency  = "53"
+	idTransactionAmount    = "54"
+	idCountryCode          = "58"
+	idMerchantName         = "59"
+	idMerchantCity         = "60"
+	idPostalCode           = "61"
+	idAdditionalData       = "62"
+	idCRC16                = "63"
+
+	// Subcampos do Merchant Account Information (26)
+	idGUI           = "00"
+	idChave         = "01"
+	idInfoAdicional = "02"
+	idURL           = "25"
+
+	// Subcampos do Additional Data Field (62)
+	idTxID = "05"
+
+	gui = "br.gov.bcb.pix"
+)
+
+// Limites de tamanho definidos pelo manual do BR Code
+const (
+	maxNomeRecebedor = 25
+	maxCidade        = 15
+	maxTxIDEstatico  = 25
+	maxTxIDDinamico  = 35
+)
+
+// Payload representa os dados de uma cobrança PIX no padrão EMV/BR Code.
+// Para QR estático informe Chave; para QR dinâmico informe URL (location do PSP, sem "https://").
+type Payload struct {
+	Chave         string
+	URL           string
+	InfoAdicional string
+	NomeRecebedor string
+	Cidade        string
+	CEP           string
+	Valor         float64 // 0 permite que o pagador informe o valor (apenas estático)
+	TxID          string
+	UsoUnico      bool // Point of Initiation Method = 12
+}
+
+// Dinamico indica se o payload aponta para uma cobrança hospedada no PSP
+func (p Payload) Dinamico() bool {
+	return p.URL != ""
+}
+
+// String monta o "copia e cola" do PIX, incluindo o CRC16
+func (p Payload) String() (string, error) {
+	if err := p.validar(); err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	b.WriteString(campo(idPayloadFormat, "01"))
+	if p.UsoUnico || p.Dinamico() {
+		b.WriteString(campo(idPointOfInitiation, "12"))
+	}
+
+	conta := campo(idGUI, gui)
+	if p.Dinamico() {
+		conta += campo(idURL, p.URL)
+	} else {
+		conta += campo(idChave, p.Chave)
+		if info := normalizar(p.InfoAdicional, 99); info != "" {
+			conta += campo(idInfoAdicional, info)
+		}
+	}
+	b.WriteString(campo(idMerchantAccount, conta))
+
+	b.WriteString(campo(idMerchantCategoryCode, "0000"))
+	b.WriteString(campo(idTransactionCurrency, "986"))
+	if p.Valor > 0 {
+		b.WriteString(campo(idTransactionAmount, strconv.FormatFloat(p.Valor, 'f', 2, 64)))
+	}
+	b.WriteString(campo(idCountryCode, "BR"))
+	b.WriteString(campo(idMerchantName, normalizar(p.NomeRecebedor, maxNomeRecebedor)))
+	b.WriteString(campo(idMerchantCity, normalizar(p.Cidade, maxCidade)))
+	if p.CEP != "" {
+		b.WriteString(campo(idPostalCode, somenteDigitos(p.CEP)))
+	}
+
+	// No QR dinâmico o txid fica registrado no PSP e o campo recebe "***"
+	txid := p.TxID
+	if txid == "" || p.Dinamico() {
+		txid = "***"
+	}
+	b.WriteString(campo(idAdditionalData, campo(idTxID, txid)))
+
+	// O CRC é calculado sobre todo o payload, incluindo o identificador e o tamanho do próprio campo 63
+	b.WriteString(idCRC16 + "04")
+	s := b.String()
+	return s + crcHex(s), nil
+}
+
+// Parse interpreta um "copia e cola" do PIX, validando o CRC16
+func Parse(s string) (Payload, error) {
+	var p Payload
+
+	if err := ValidarCRC(s); err != nil {
+		return p, err
+	}
+
+	campos, err := lerCampos(s)
+	if err != nil {
+		return p, err
+	}
+
+	if campos[idPayloadFormat] != "01" {
+		return p, errors.New("formato de payload não suportado")
+	}
+	p.UsoUnico = campos[idPointOfInitiation] == "12"
+
+	conta, err := lerCampos(campos[idMerchantAccount])
+	if err != nil {
+		return p, fmt.Errorf("campo 26 inválido: %w", err)
+	}
+	if !strings.EqualFold(conta[idGUI], gui) {
+		return p, errors.New("payload não é um PIX")
+	}
+	p.Chave = conta[idChave]
+	p.InfoAdicional = conta[idInfoAdicional]
+	p.URL = conta[idURL]
+
+	if v := campos[idTransactionAmount]; v != "" {
+		if p.Valor, err = strconv.ParseFloat(v, 64); err != nil {
+			return p, errors.New("valor inválido")
+		}
+	}
+	p.NomeRecebedor = campos[idMerchantName]
+	p.Cidade = campos[idMerchantCity]
+	p.CEP = campos[idPostalCode]
+
+	if adicional, err := lerCampos(campos[idAdditionalData]); err == nil {
+		p.TxID = adicional[idTxID]
+	}
+
+	return p, nil
+}
+
+// ValidarCRC verifica se o CRC16 no final do payload confere
+func ValidarCRC(s string) error {
+	if len(s) < 8 || s[len(s)-8:len(s)-4] != idCRC16+"04" {
+		return errors.New("payload sem CRC16")
+	}
+	if !strings.EqualFold(crcHex(s[:len(s)-4]), s[len(s)-4:]) {
+		return errors.New("CRC16 inválido")
+	}
+	return nil
+}
+
+// validar aplica as regras do manual do BR Code antes da montagem
+func (p Payload) validar() error {
+	if p.Chave == "" && p.URL == "" {
+		return errors.New("chave PIX ou URL da cobrança é obrigatória")
+	}
+	if p.Chave != "" && p.URL != "" {
+		return errors.New("informe apenas chave PIX (estático) ou URL (dinâmico)")
+	}
+	if strings.HasPrefix(p.URL, "http") {
+		return errors.New("URL deve ser informada sem o protocolo")
+	}
+	if p.NomeRecebedor == "" || p.Cidade == "" {
+		return errors.New("nome do recebedor e cidade são obrigatórios")
+	}
+	if p.Valor < 0 {
+		return errors.New("valor inválido")
+	}
+
+	limite := maxTxIDEstatico
+	if p.Dinamico() {
+		limite = maxTxIDDinamico
+	}
+	if len(p.TxID) > limite {
+		return fmt.Errorf("txid deve ter no máximo %d caracteres", limite)
+	}
+	if p.TxID != "***" {
+		for _, r := range p.TxID {
+			if r >= unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r)) {
+				return errors.New("txid deve conter apenas letras e números")
+			}
+		}
+	}
+
+	// O campo 26 completo não pode passar de 99 caracteres
+	tamanhoConta := len(campo(idGUI, gui))
+	if p.Dinamico() {
+		tamanhoConta += len(campo(idURL, p.URL))
+	} else {
+		tamanhoConta += len(campo(idChave, p.Chave))
+		if info := normalizar(p.InfoAdicional, 99); info != "" {
+			tamanhoConta += len(campo(idInfoAdicional, info))
+		}
+	}
+	if tamanhoConta > 99 {
+		return errors.New("chave, URL e informação adicional excedem o tamanho do campo 26")
+	}
+
+	return nil
+}
+
+// campo monta um TLV no formato ID (2) + tamanho (2) + valor
+func campo(id, valor string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(valor), valor)
+}
+
+// lerCampos interpreta uma sequência de TLVs
+func lerCampos(s string) (map[string]string, error) {
+	campos := make(map[string]string)
+	for i := 0; i < len(s); {
+		if i+4 > len(s) {
+			return nil, errors.New("campo truncado")
+		}
+		id := s[i : i+2]
+		tamanho, err := strconv.Atoi(s[i+2 : i+4])
+		if err != nil {
+			return nil, fmt.Errorf("tamanho inválido no campo %s", id)
+		}
+		i += 4
+		if i+tamanho > len(s) {
+			return nil, fmt.Errorf("campo %s truncado", id)
+		}
+		campos[id] = s[i : i+tamanho]
+		i += tamanho
+	}
+	return campos, nil
+}
+
+// normalizar remove acentos e caracteres fora do ASCII e limita o tamanho do texto
+func normalizar(s string, max int) string {
+	var b strings.Builder
+	for _, r := range s {
+		if sub, ok := semAcento[r]; ok {
+			r = sub
+		}
+		if r < unicode.MaxASCII && unicode.IsPrint(r) {
+			b.WriteRune(r)
+		}
+	}
+
+	resultado := strings.TrimSpace(b.String())
+	if len(resultado) > max {
+		resultado = strings.TrimSpace(resultado[:max])
+	}
+	return resultado
+}
+
+func somenteDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+var semAcento = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+	'Á': 'A', 'À': 'A', 'Â': 'A', 'Ã': 'A', 'Ä': 'A',
+	'É': 'E', 'È': 'E', 'Ê': 'E', 'Ë': 'E',
+	'Í': 'I', 'Ì': 'I', 'Î': 'I', 'Ï': 'I',
+	'Ó': 'O', 'Ò': 'O', 'Ô': 'O', 'Õ': 'O', 'Ö': 'O',
+	'Ú': 'U', 'Ù': 'U', 'Û': 'U', 'Ü': 'U',
+	'Ç': 'C', 'Ñ': 'N',
+}
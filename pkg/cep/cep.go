@@ -0,0 +1,111 @@
+// Package cep consulta endereços pelo CEP em provedores intercambiáveis,
+// com uma base offline usada quando os serviços externos estão indisponíveis.
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNaoEncontrado indica que o provedor não conhece o CEP
+var ErrNaoEncontrado = errors.New("CEP não encontrado")
+
+// Endereco é o endereço retornado na consulta. Provedores offline podem
+// preencher apenas o município e a UF, deixando logradouro e bairro vazios.
+type Endereco struct {
+	CEP             string `json:"cep"`
+	Logradouro      string `json:"logradouro,omitempty"`
+	Complemento     string `json:"complemento,omitempty"`
+	Bairro          string `json:"bairro,omitempty"`
+	CodigoMunicipio string `json:"codigo_municipio,omitempty"` // código IBGE de 7 dígitos
+	Municipio       string `json:"municipio,omitempty"`
+	UF              string `json:"uf"`
+	Fonte           string `json:"fonte"` // provedor que respondeu a consulta
+}
+
+// Provider define um serviço de consulta de CEP
+type Provider interface {
+	Consultar(ctx context.Context, cep string) (*Endereco, error)
+}
+
+// codigosUF relaciona as UFs aos códigos do IBGE, que prefixam os códigos de município
+var codigosUF = map[string]string{
+	"RO": "11", "AC": "12", "AM": "13", "RR": "14", "PA": "15", "AP": "16", "TO": "17",
+	"MA": "21", "PI": "22", "CE": "23", "RN": "24", "PB": "25", "PE": "26", "AL": "27", "SE": "28", "BA": "29",
+	"MG": "31", "ES": "32", "RJ": "33", "SP": "35",
+	"PR": "41", "SC": "42", "RS": "43",
+	"MS": "50", "MT": "51", "GO": "52", "DF": "53",
+}
+
+// Normalizar remove a formatação do CEP e confere se tem 8 dígitos
+func Normalizar(cep string) (string, error) {
+	var b strings.Builder
+	for _, r := range cep {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '-' || r == '.' || r == ' ':
+		default:
+			return "", fmt.Errorf("CEP inválido: %s", cep)
+		}
+	}
+	if b.Len() != 8 || b.String() == "00000000" {
+		return "", fmt.Errorf("CEP inválido: %s", cep)
+	}
+	return b.String(), nil
+}
+
+// ValidarUF confere se a sigla é de uma unidade da federação
+func ValidarUF(uf string) error {
+	if _, ok := codigosUF[uf]; !ok {
+		return fmt.Errorf("UF inválida: %s", uf)
+	}
+	return nil
+}
+
+// ValidarMunicipio confere se o código IBGE tem 7 dígitos e pertence à UF
+func ValidarMunicipio(codigo, uf string) error {
+	if len(codigo) != 7 || strings.Trim(codigo, "0123456789") != "" {
+		return errors.New("código IBGE do município deve ter 7 dígitos")
+	}
+	if prefixo, ok := codigosUF[uf]; ok && !strings.HasPrefix(codigo, prefixo) {
+		return fmt.Errorf("município %s não pertence à UF %s", codigo, uf)
+	}
+	return nil
+}
+
+// Cadeia consulta os provedores em ordem, passando ao seguinte quando um deles está indisponível
+type Cadeia []Provider
+
+// NewCadeia cria uma cadeia de provedores; o último costuma ser a base offline
+func NewCadeia(providers ...Provider) Cadeia {
+	return Cadeia(providers)
+}
+
+// Consultar retorna a resposta do primeiro provedor que encontrar o CEP
+func (c Cadeia) Consultar(ctx context.Context, cep string) (*Endereco, error) {
+	numero, err := Normalizar(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	var erros []string
+	for _, p := range c {
+		e, err := p.Consultar(ctx, numero)
+		if err == nil {
+			return e, nil
+		}
+		// A resposta de que o CEP não existe é definitiva; só falhas de comunicação passam adiante
+		if errors.Is(err, ErrNaoEncontrado) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		erros = append(erros, err.Error())
+	}
+
+	return nil, fmt.Errorf("nenhum provedor de CEP disponível: %s", strings.Join(erros, "; "))
+}
@@ -0,0 +1,137 @@
+package cep
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed dados/faixas.csv
+var faixasPadrao []byte
+
+// faixa associa um intervalo de CEPs a um endereço; CEPs individuais têm inicio igual a fim
+type faixa struct {
+	inicio, fim string
+	endereco    Endereco
+}
+
+// Offline consulta uma base local de faixas de CEP. A base embutida resolve a UF de
+// qualquer CEP e o município das capitais; bases completas podem ser carregadas de arquivo.
+type Offline struct {
+	faixas []faixa
+}
+
+// NewOffline cria o provedor com a base embutida
+func NewOffline() *Offline {
+	o := &Offline{}
+	if err := o.Carregar(bytes.NewReader(faixasPadrao)); err != nil {
+		panic(fmt.Sprintf("base de CEP embutida inválida: %v", err))
+	}
+	return o
+}
+
+// NewOfflineArquivo cria o provedor com a base embutida acrescida das faixas do arquivo informado
+func NewOfflineArquivo(caminho string) (*Offline, error) {
+	f, err := os.Open(caminho)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	o := NewOffline()
+	if err := o.Carregar(f); err != nil {
+		return nil, fmt.Errorf("%s: %w", caminho, err)
+	}
+	return o, nil
+}
+
+// Carregar acrescenta faixas no formato "inicio;fim;uf;municipio;codigo_ibge;logradouro;bairro".
+// Linhas vazias e iniciadas por # são ignoradas.
+func (o *Offline) Carregar(r io.Reader) error {
+	s := bufio.NewScanner(r)
+	linha := 0
+	for s.Scan() {
+		linha++
+		texto := strings.TrimSpace(s.Text())
+		if texto == "" || strings.HasPrefix(texto, "#") {
+			continue
+		}
+
+		campos := strings.Split(texto, ";")
+		if len(campos) != 7 {
+			return fmt.Errorf("linha %d: esperados 7 campos, encontrados %d", linha, len(campos))
+		}
+		inicio, err := Normalizar(campos[0])
+		if err != nil {
+			return fmt.Errorf("linha %d: %w", linha, err)
+		}
+		fim, err := Normalizar(campos[1])
+		if err != nil {
+			return fmt.Errorf("linha %d: %w", linha, err)
+		}
+		if fim < inicio {
+			return fmt.Errorf("linha %d: faixa invertida", linha)
+		}
+		uf := strings.ToUpper(strings.TrimSpace(campos[2]))
+		if err := ValidarUF(uf); err != nil {
+			return fmt.Errorf("linha %d: %w", linha, err)
+		}
+		if campos[4] != "" {
+			if err := ValidarMunicipio(campos[4], uf); err != nil {
+				return fmt.Errorf("linha %d: %w", linha, err)
+			}
+		}
+
+		o.faixas = append(o.faixas, faixa{
+			inicio: inicio,
+			fim:    fim,
+			endereco: Endereco{
+				UF:              uf,
+				Municipio:       strings.TrimSpace(campos[3]),
+				CodigoMunicipio: campos[4],
+				Logradouro:      strings.TrimSpace(campos[5]),
+				Bairro:          strings.TrimSpace(campos[6]),
+				Fonte:           "offline",
+			},
+		})
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+
+	// As faixas mais estreitas vêm primeiro, para prevalecerem sobre as da UF
+	sort.SliceStable(o.faixas, func(i, j int) bool {
+		return amplitude(o.faixas[i]) < amplitude(o.faixas[j])
+	})
+	return nil
+}
+
+// Consultar retorna o endereço da faixa mais específica que contém o CEP
+func (o *Offline) Consultar(ctx context.Context, cep string) (*Endereco, error) {
+	numero, err := Normalizar(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range o.faixas {
+		if numero >= f.inicio && numero <= f.fim {
+			e := f.endereco
+			e.CEP = numero
+			return &e, nil
+		}
+	}
+	return nil, ErrNaoEncontrado
+}
+
+func amplitude(f faixa) int {
+	inicio, _ := strconv.Atoi(f.inicio)
+	fim, _ := strconv.Atoi(f.fim)
+	return fim - inicio
+}
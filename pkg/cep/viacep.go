@@ -0,0 +1,81 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// URLViaCEP é o endereço público do serviço ViaCEP
+const URLViaCEP = "https://viacep.com.br/ws"
+
+// ViaCEP consulta o serviço ViaCEP, que usa a base dos Correios
+type ViaCEP struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewViaCEP cria o provedor ViaCEP; baseURL vazio usa o serviço público
+func NewViaCEP(baseURL string) *ViaCEP {
+	if baseURL == "" {
+		baseURL = URLViaCEP
+	}
+	return &ViaCEP{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// Consultar busca o endereço do CEP no ViaCEP
+func (v *ViaCEP) Consultar(ctx context.Context, cep string) (*Endereco, error) {
+	numero, err := Normalizar(cep)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf("%s/%s/json/", v.baseURL, numero), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("ViaCEP indisponível: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ViaCEP respondeu com status %d", resp.StatusCode)
+	}
+
+	var r struct {
+		CEP         string      `json:"cep"`
+		Logradouro  string      `json:"logradouro"`
+		Complemento string      `json:"complemento"`
+		Bairro      string      `json:"bairro"`
+		Localidade  string      `json:"localidade"`
+		UF          string      `json:"uf"`
+		IBGE        string      `json:"ibge"`
+		Erro        interface{} `json:"erro"` // true ou "true" quando o CEP não existe
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, fmt.Errorf("resposta inválida do ViaCEP: %w", err)
+	}
+	if r.Erro != nil && r.Erro != false && r.Erro != "false" {
+		return nil, ErrNaoEncontrado
+	}
+
+	return &Endereco{
+		CEP:             numero,
+		Logradouro:      r.Logradouro,
+		Complemento:     r.Complemento,
+		Bairro:          r.Bairro,
+		CodigoMunicipio: r.IBGE,
+		Municipio:       r.Localidade,
+		UF:              r.UF,
+		Fonte:           "viacep",
+	}, nil
+}
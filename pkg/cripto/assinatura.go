@@ -0,0 +1,60 @@
+package cripto
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// AssinarHMAC calcula a assinatura HMAC-SHA256 de "<timestamp>.<corpo>" em hexadecimal, usada
+// nas notificações enviadas por HTTP (webhooks das empresas e do PSP de Pix)
+func AssinarHMAC(segredo string, timestamp int64, corpo []byte) string {
+	mac := hmac.New(sha256.New, []byte(segredo))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(corpo)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CabecalhoHMAC monta o cabeçalho "t=<timestamp>,v1=<assinatura>" da notificação
+func CabecalhoHMAC(segredo string, timestamp int64, corpo []byte) string {
+	return fmt.Sprintf("t=%d,v1=%s", timestamp, AssinarHMAC(segredo, timestamp, corpo))
+}
+
+// VerificarHMAC valida o cabeçalho "t=<timestamp>,v1=<assinatura>", recusando timestamps fora da
+// tolerância para que notificações capturadas não possam ser repetidas. Aceita mais de um v1,
+// como durante a rotação do segredo.
+func VerificarHMAC(segredo, cabecalho string, corpo []byte, tolerancia time.Duration, agora time.Time) error {
+	var timestamp int64
+	var assinaturas []string
+	for _, parte := range strings.Split(cabecalho, ",") {
+		chave, valor, _ := strings.Cut(strings.TrimSpace(parte), "=")
+		switch chave {
+		case "t":
+			timestamp, _ = strconv.ParseInt(valor, 10, 64)
+		case "v1":
+			assinaturas = append(assinaturas, valor)
+		}
+	}
+	if timestamp == 0 || len(assinaturas) == 0 {
+		return errors.New("cabeçalho de assinatura inválido")
+	}
+
+	diferenca := agora.Sub(time.Unix(timestamp, 0))
+	if diferenca < -tolerancia || diferenca > tolerancia {
+		return errors.New("timestamp da assinatura fora da tolerância")
+	}
+
+	esperada := AssinarHMAC(segredo, timestamp, corpo)
+	for _, a := range assinaturas {
+		if hmac.Equal([]byte(a), []byte(esperada)) {
+			return nil
+		}
+	}
+	return errors.New("assinatura inválida")
+}
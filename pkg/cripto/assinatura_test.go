@@ -0,0 +1,40 @@
+package cripto
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerificarHMAC(t *testing.T) {
+	agora := time.Unix(1700000000, 0)
+	corpo := []byte(`{"id":"evt_1"}`)
+	cabecalho := CabecalhoHMAC("segredo", agora.Unix(), corpo)
+	tolerancia := 5 * time.Minute
+
+	casos := []struct {
+		nome      string
+		segredo   string
+		cabecalho string
+		corpo     string
+		agora     time.Time
+		valido    bool
+	}{
+		{"assinatura correta", "segredo", cabecalho, string(corpo), agora, true},
+		{"dentro da tolerância", "segredo", cabecalho, string(corpo), agora.Add(4 * time.Minute), true},
+		{"rotação com duas assinaturas", "segredo", cabecalho + ",v1=" + AssinarHMAC("antigo", agora.Unix(), corpo), string(corpo), agora, true},
+		{"corpo alterado", "segredo", cabecalho, `{"id":"evt_2"}`, agora, false},
+		{"outro segredo", "outro", cabecalho, string(corpo), agora, false},
+		{"repetida fora da tolerância", "segredo", cabecalho, string(corpo), agora.Add(6 * time.Minute), false},
+		{"do futuro", "segredo", cabecalho, string(corpo), agora.Add(-6 * time.Minute), false},
+		{"sem timestamp", "segredo", "v1=" + AssinarHMAC("segredo", agora.Unix(), corpo), string(corpo), agora, false},
+		{"vazio", "segredo", "", string(corpo), agora, false},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			err := VerificarHMAC(c.segredo, c.cabecalho, []byte(c.corpo), tolerancia, c.agora)
+			if (err == nil) != c.valido {
+				t.Errorf("VerificarHMAC = %v, esperado válido %t", err, c.valido)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+// Package cripto implementa a cifragem de campos em repouso por envelope: cada valor é cifrado com
+// AES-GCM por uma chave de dados, que é gravada junto ao valor envolvida por uma chave mestra do KMS.
+// Índices cegos (HMAC) permitem busca exata e unicidade sobre campos cifrados.
+package cripto
+
+import (
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Prefixos dos valores cifrados; valores sem eles são tratados como texto puro legado. Na versão 1
+// o valor é vinculado apenas ao campo; na 2, também ao contexto do registro dono.
+const (
+	prefixoV1 = "enc:v1:"
+	prefixo   = "enc:v2:"
+)
+
+// ErrValorInvalido indica um valor cifrado corrompido ou cifrado para outro campo
+var ErrValorInvalido = errors.New("valor cifrado inválido")
+
+// Cifrador cifra e decifra campos e calcula seus índices cegos
+type Cifrador struct {
+	kms         KMS
+	chaveIndice []byte
+
+	mu      sync.Mutex
+	atual   *chaveDados
+	abertas map[string]cipher.AEAD // chaves de dados já desenvolvidas, pela forma envolvida
+}
+
+// chaveDados é a chave usada nos novos valores enquanto a chave mestra atual não muda
+type chaveDados struct {
+	mestra    string
+	envolvida string
+	aead      cipher.AEAD
+}
+
+// NewCifrador cria um Cifrador. A chave de índice é independente das chaves mestras
+// para que a rotação não altere os índices já gravados.
+func NewCifrador(kms KMS, chaveIndice []byte) (*Cifrador, error) {
+	if len(chaveIndice) != TamanhoChave {
+		return nil, fmt.Errorf("a chave de índice deve ter %d bytes", TamanhoChave)
+	}
+	return &Cifrador{
+		kms:         kms,
+		chaveIndice: chaveIndice,
+		abertas:     make(map[string]cipher.AEAD),
+	}, nil
+}
+
+// NewCifradorConfig cria um Cifrador com KMS local a partir da configuração:
+// chaves mestras no formato de LerChavesMestras e chave de índice em base64
+func NewCifradorConfig(chavesMestras, chaveIndice string) (*Cifrador, error) {
+	chaves, atual, err := LerChavesMestras(chavesMestras)
+	if err != nil {
+		return nil, err
+	}
+	kms, err := NewLocalKMS(chaves, atual)
+	if err != nil {
+		return nil, err
+	}
+	indice, err := base64.StdEncoding.DecodeString(strings.TrimSpace(chaveIndice))
+	if err != nil {
+		return nil, errors.New("chave de índice não está em base64")
+	}
+	return NewCifrador(kms, indice)
+}
+
+// Cifrar cifra o texto vinculando-o ao campo e ao contexto (ex.: a empresa dona do registro), de
+// modo que não possa ser copiado para outro campo nem para outro registro. Texto vazio continua vazio.
+func (c *Cifrador) Cifrar(campo, contexto, texto string) (string, error) {
+	if texto == "" {
+		return "", nil
+	}
+
+	k, err := c.chaveAtual()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, k.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	dados := k.aead.Seal(nonce, nonce, []byte(texto), dadosAssociados(campo, contexto))
+	return prefixo + k.mestra + ":" + k.envolvida + ":" + base64.RawStdEncoding.EncodeToString(dados), nil
+}
+
+// Decifrar abre um valor gerado por Cifrar para o mesmo campo e contexto; valores não cifrados são
+// devolvidos como estão. Valores da versão 1 são abertos apenas pelo campo até serem recifrados.
+func (c *Cifrador) Decifrar(campo, contexto, valor string) (string, error) {
+	if !Cifrado(valor) {
+		return valor, nil
+	}
+
+	ad := dadosAssociados(campo, contexto)
+	if strings.HasPrefix(valor, prefixoV1) {
+		ad = []byte(campo)
+	}
+	// As duas versões têm prefixos do mesmo tamanho
+	partes := strings.SplitN(valor[len(prefixo):], ":", 3)
+	if len(partes) != 3 {
+		return "", ErrValorInvalido
+	}
+	aead, err := c.abrir(partes[0], partes[1])
+	if err != nil {
+		return "", err
+	}
+	dados, err := base64.RawStdEncoding.DecodeString(partes[2])
+	if err != nil || len(dados) < aead.NonceSize() {
+		return "", ErrValorInvalido
+	}
+	texto, err := aead.Open(nil, dados[:aead.NonceSize()], dados[aead.NonceSize():], ad)
+	if err != nil {
+		return "", ErrValorInvalido
+	}
+	return string(texto), nil
+}
+
+// Atualizado indica se o valor está vazio ou cifrado na versão atual sob a chave mestra atual,
+// dispensando a rotação
+func (c *Cifrador) Atualizado(valor string) bool {
+	if valor == "" {
+		return true
+	}
+	if !strings.HasPrefix(valor, prefixo) {
+		return false
+	}
+	mestra := strings.SplitN(strings.TrimPrefix(valor, prefixo), ":", 2)[0]
+	return mestra == c.kms.ChaveAtual()
+}
+
+// Indice calcula o índice cego do valor já normalizado; valor vazio não tem índice
+func (c *Cifrador) Indice(campo, valor string) string {
+	if valor == "" {
+		return ""
+	}
+	mac := hmac.New(sha256.New, c.chaveIndice)
+	mac.Write([]byte(campo))
+	mac.Write([]byte{0})
+	mac.Write([]byte(valor))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Cifrado indica se o valor foi gerado por Cifrar
+func Cifrado(valor string) bool {
+	return strings.HasPrefix(valor, prefixo) || strings.HasPrefix(valor, prefixoV1)
+}
+
+// ContextoEmpresa é o contexto dos valores pertencentes a uma empresa, que não podem ser abertos
+// no cadastro de outra
+func ContextoEmpresa(empresaID int) string {
+	return "empresa:" + strconv.Itoa(empresaID)
+}
+
+// dadosAssociados monta os dados autenticados pelo AES-GCM; o separador impede que campo e
+// contexto diferentes produzam a mesma sequência
+func dadosAssociados(campo, contexto string) []byte {
+	return []byte(campo + "\x00" + contexto)
+}
+
+// chaveAtual retorna a chave de dados dos novos valores, gerando outra quando a chave mestra muda
+func (c *Cifrador) chaveAtual() (*chaveDados, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mestra := c.kms.ChaveAtual()
+	if c.atual != nil && c.atual.mestra == mestra {
+		return c.atual, nil
+	}
+
+	chave := make([]byte, TamanhoChave)
+	if _, err := rand.Read(chave); err != nil {
+		return nil, err
+	}
+	envolvida, err := c.kms.Envolver(mestra, chave)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := novoAEAD(chave)
+	if err != nil {
+		return nil, err
+	}
+
+	k := &chaveDados{
+		mestra:    mestra,
+		envolvida: base64.RawStdEncoding.EncodeToString(envolvida),
+		aead:      aead,
+	}
+	c.atual = k
+	c.abertas[mestra+":"+k.envolvida] = aead
+	return k, nil
+}
+
+// abrir desenvolve a chave de dados de um valor, mantendo-a em memória para os próximos
+func (c *Cifrador) abrir(mestra, envolvida string) (cipher.AEAD, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if aead, ok := c.abertas[mestra+":"+envolvida]; ok {
+		return aead, nil
+	}
+
+	bruta, err := base64.RawStdEncoding.DecodeString(envolvida)
+	if err != nil {
+		return nil, ErrValorInvalido
+	}
+	chave, err := c.kms.Desenvolver(mestra, bruta)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := novoAEAD(chave)
+	if err != nil {
+		return nil, err
+	}
+	c.abertas[mestra+":"+envolvida] = aead
+	return aead, nil
+}
@@ -0,0 +1,203 @@
+package cripto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// novaChave gera uma chave mestra no formato id:base64 de LerChavesMestras
+func novaChave(t *testing.T, id string) string {
+	t.Helper()
+	chave := make([]byte, TamanhoChave)
+	if _, err := rand.Read(chave); err != nil {
+		t.Fatal(err)
+	}
+	return id + ":" + base64.StdEncoding.EncodeToString(chave)
+}
+
+func novoCifrador(t *testing.T, chavesMestras, indice string) *Cifrador {
+	t.Helper()
+	c, err := NewCifradorConfig(chavesMestras, indice)
+	if err != nil {
+		t.Fatalf("NewCifradorConfig: %v", err)
+	}
+	return c
+}
+
+func chaveIndice() string {
+	return base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, TamanhoChave))
+}
+
+func TestCifrarDecifrar(t *testing.T) {
+	c := novoCifrador(t, novaChave(t, "k1"), chaveIndice())
+	ctx := ContextoEmpresa(1)
+
+	for _, texto := range []string{"123.456.789-09", "fulano@example.com", "Rua São João, 100 – ç"} {
+		cifrado, err := c.Cifrar("cliente.cpf", ctx, texto)
+		if err != nil {
+			t.Fatalf("Cifrar: %v", err)
+		}
+		if !Cifrado(cifrado) || !strings.HasPrefix(cifrado, "enc:v2:k1:") || strings.Contains(cifrado, texto) {
+			t.Errorf("valor cifrado inesperado: %q", cifrado)
+		}
+		aberto, err := c.Decifrar("cliente.cpf", ctx, cifrado)
+		if err != nil || aberto != texto {
+			t.Errorf("Decifrar = %q, %v; esperado %q", aberto, err, texto)
+		}
+	}
+
+	// O nonce aleatório impede que valores iguais gerem o mesmo texto cifrado
+	a, _ := c.Cifrar("cliente.cpf", ctx, "igual")
+	b, _ := c.Cifrar("cliente.cpf", ctx, "igual")
+	if a == b {
+		t.Error("dois valores iguais produziram o mesmo texto cifrado")
+	}
+
+	if v, err := c.Cifrar("cliente.cpf", ctx, ""); err != nil || v != "" {
+		t.Errorf("texto vazio: %q, %v", v, err)
+	}
+	if v, err := c.Decifrar("cliente.cpf", ctx, "texto puro legado"); err != nil || v != "texto puro legado" {
+		t.Errorf("texto puro: %q, %v", v, err)
+	}
+}
+
+func TestDecifrarAdulterado(t *testing.T) {
+	c := novoCifrador(t, novaChave(t, "k1"), chaveIndice())
+	ctx := ContextoEmpresa(1)
+	cifrado, err := c.Cifrar("cliente.email", ctx, "fulano@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Troca o último caractere do texto cifrado, mantendo o base64 válido
+	ultimo := cifrado[len(cifrado)-1]
+	troca := byte('A')
+	if ultimo == 'A' {
+		troca = 'B'
+	}
+
+	casos := []struct {
+		nome     string
+		campo    string
+		contexto string
+		valor    string
+	}{
+		{"texto cifrado alterado", "cliente.email", ctx, cifrado[:len(cifrado)-1] + string(troca)},
+		{"outro campo", "cliente.telefone", ctx, cifrado},
+		{"outra empresa", "cliente.email", ContextoEmpresa(2), cifrado},
+		{"truncado", "cliente.email", ctx, cifrado[:len(cifrado)-20]},
+		{"sem partes", "cliente.email", ctx, "enc:v2:k1"},
+		{"rebaixado para v1", "cliente.email", ctx, "enc:v1:" + strings.TrimPrefix(cifrado, "enc:v2:")},
+	}
+	for _, caso := range casos {
+		t.Run(caso.nome, func(t *testing.T) {
+			if v, err := c.Decifrar(caso.campo, caso.contexto, caso.valor); err == nil {
+				t.Errorf("valor adulterado aberto: %q", v)
+			}
+		})
+	}
+
+	// Chave mestra que o KMS não conhece
+	outro := novoCifrador(t, novaChave(t, "k9"), chaveIndice())
+	if _, err := outro.Decifrar("cliente.email", ctx, cifrado); err == nil {
+		t.Error("valor aberto sem a chave mestra")
+	}
+}
+
+func TestDecifrarV1(t *testing.T) {
+	c := novoCifrador(t, novaChave(t, "k1"), chaveIndice())
+
+	// Monta um valor da versão 1, vinculado apenas ao campo
+	k, err := c.chaveAtual()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nonce := make([]byte, k.aead.NonceSize())
+	dados := k.aead.Seal(nonce, nonce, []byte("legado"), []byte("cliente.cpf"))
+	v1 := prefixoV1 + k.mestra + ":" + k.envolvida + ":" + base64.RawStdEncoding.EncodeToString(dados)
+
+	if !Cifrado(v1) {
+		t.Error("valor v1 não reconhecido como cifrado")
+	}
+	if v, err := c.Decifrar("cliente.cpf", ContextoEmpresa(1), v1); err != nil || v != "legado" {
+		t.Errorf("Decifrar v1 = %q, %v", v, err)
+	}
+	if _, err := c.Decifrar("cliente.email", ContextoEmpresa(1), v1); !errors.Is(err, ErrValorInvalido) {
+		t.Errorf("v1 de outro campo: %v", err)
+	}
+	if c.Atualizado(v1) {
+		t.Error("valor v1 não deve ser considerado atualizado")
+	}
+}
+
+func TestRotacao(t *testing.T) {
+	k1, k2 := novaChave(t, "k1"), novaChave(t, "k2")
+	ctx := ContextoEmpresa(3)
+
+	antigo := novoCifrador(t, k1, chaveIndice())
+	cifrado, err := antigo.Cifrar("cliente.telefone", ctx, "(41) 99999-0000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !antigo.Atualizado(cifrado) || antigo.Atualizado("texto puro") || !antigo.Atualizado("") {
+		t.Error("Atualizado incorreto antes da rotação")
+	}
+
+	// Nova chave no início da lista; a anterior continua abrindo os dados antigos
+	rotacionado := novoCifrador(t, k2+","+k1, chaveIndice())
+	if rotacionado.Atualizado(cifrado) {
+		t.Error("valor da chave anterior considerado atualizado")
+	}
+	aberto, err := rotacionado.Decifrar("cliente.telefone", ctx, cifrado)
+	if err != nil || aberto != "(41) 99999-0000" {
+		t.Fatalf("Decifrar após a rotação = %q, %v", aberto, err)
+	}
+	recifrado, err := rotacionado.Cifrar("cliente.telefone", ctx, aberto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(recifrado, "enc:v2:k2:") || !rotacionado.Atualizado(recifrado) {
+		t.Errorf("recifrado = %q", recifrado)
+	}
+
+	// Retirada a chave antiga, só o valor recifrado continua legível
+	novo := novoCifrador(t, k2, chaveIndice())
+	if _, err := novo.Decifrar("cliente.telefone", ctx, cifrado); err == nil {
+		t.Error("valor da chave retirada aberto")
+	}
+	if v, err := novo.Decifrar("cliente.telefone", ctx, recifrado); err != nil || v != aberto {
+		t.Errorf("Decifrar recifrado = %q, %v", v, err)
+	}
+
+	// O índice cego não depende das chaves mestras
+	if antigo.Indice("cliente.email", "a@b.com") != novo.Indice("cliente.email", "a@b.com") {
+		t.Error("índice cego mudou com a rotação")
+	}
+	if antigo.Indice("cliente.email", "a@b.com") == antigo.Indice("cliente.documento", "a@b.com") {
+		t.Error("índice cego igual para campos diferentes")
+	}
+}
+
+func TestLerChavesMestras(t *testing.T) {
+	chaves, atual, err := LerChavesMestras(" k2:" + base64.StdEncoding.EncodeToString(make([]byte, 32)) + " , k1:" +
+		base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	if err != nil || atual != "k2" || len(chaves) != 2 {
+		t.Errorf("LerChavesMestras = %d chaves, %q, %v", len(chaves), atual, err)
+	}
+
+	for _, invalida := range []string{"", "k1", "k1:***", "k1:AAAA,k1:AAAA"} {
+		if _, _, err := LerChavesMestras(invalida); err == nil {
+			t.Errorf("configuração %q aceita", invalida)
+		}
+	}
+	if _, err := NewCifradorConfig("k1:AAAA", chaveIndice()); err == nil {
+		t.Error("chave mestra curta aceita")
+	}
+	if _, err := NewCifradorConfig(novaChave(t, "k 1"), chaveIndice()); err == nil {
+		t.Error("identificador inválido aceito")
+	}
+}
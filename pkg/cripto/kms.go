@@ -0,0 +1,132 @@
+package cripto
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// TamanhoChave é o tamanho das chaves mestras, de dados e de índice (AES-256)
+const TamanhoChave = 32
+
+// formatoIDChave restringe os identificadores das chaves mestras, gravados junto aos dados cifrados
+var formatoIDChave = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// KMS guarda as chaves mestras e envolve (cifra) as chaves de dados com elas.
+// As chaves mestras nunca saem do KMS; apenas as chaves de dados envolvidas são gravadas.
+type KMS interface {
+	// ChaveAtual retorna o identificador da chave mestra usada para novas chaves de dados
+	ChaveAtual() string
+	Envolver(chaveID string, chave []byte) ([]byte, error)
+	Desenvolver(chaveID string, envolvida []byte) ([]byte, error)
+}
+
+// LocalKMS substitui um KMS externo usando chaves mestras lidas da configuração
+type LocalKMS struct {
+	chaves map[string]cipher.AEAD
+	atual  string
+}
+
+// NewLocalKMS cria um KMS local; atual indica a chave mestra das novas chaves de dados
+// e as demais continuam disponíveis para abrir dados antigos até a rotação terminar
+func NewLocalKMS(chaves map[string][]byte, atual string) (*LocalKMS, error) {
+	if _, ok := chaves[atual]; !ok {
+		return nil, fmt.Errorf("chave mestra atual %q não informada", atual)
+	}
+
+	k := &LocalKMS{chaves: make(map[string]cipher.AEAD), atual: atual}
+	for id, chave := range chaves {
+		if !formatoIDChave.MatchString(id) {
+			return nil, fmt.Errorf("identificador de chave mestra inválido: %q", id)
+		}
+		aead, err := novoAEAD(chave)
+		if err != nil {
+			return nil, fmt.Errorf("chave mestra %s: %v", id, err)
+		}
+		k.chaves[id] = aead
+	}
+	return k, nil
+}
+
+// LerChavesMestras interpreta a lista "id:base64,id:base64"; a primeira chave é a atual
+func LerChavesMestras(texto string) (map[string][]byte, string, error) {
+	chaves := make(map[string][]byte)
+	atual := ""
+	for _, item := range strings.Split(texto, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		partes := strings.SplitN(item, ":", 2)
+		if len(partes) != 2 {
+			return nil, "", fmt.Errorf("chave mestra deve estar no formato id:base64: %q", partes[0])
+		}
+		chave, err := base64.StdEncoding.DecodeString(partes[1])
+		if err != nil {
+			return nil, "", fmt.Errorf("chave mestra %s não está em base64", partes[0])
+		}
+		if _, repetida := chaves[partes[0]]; repetida {
+			return nil, "", fmt.Errorf("chave mestra %s repetida", partes[0])
+		}
+		chaves[partes[0]] = chave
+		if atual == "" {
+			atual = partes[0]
+		}
+	}
+	if atual == "" {
+		return nil, "", errors.New("nenhuma chave mestra informada")
+	}
+	return chaves, atual, nil
+}
+
+// ChaveAtual retorna o identificador da chave mestra atual
+func (k *LocalKMS) ChaveAtual() string {
+	return k.atual
+}
+
+// Envolver cifra a chave de dados com a chave mestra informada
+func (k *LocalKMS) Envolver(chaveID string, chave []byte) ([]byte, error) {
+	aead, ok := k.chaves[chaveID]
+	if !ok {
+		return nil, fmt.Errorf("chave mestra desconhecida: %s", chaveID)
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, chave, []byte(chaveID)), nil
+}
+
+// Desenvolver abre uma chave de dados envolvida pela chave mestra informada
+func (k *LocalKMS) Desenvolver(chaveID string, envolvida []byte) ([]byte, error) {
+	aead, ok := k.chaves[chaveID]
+	if !ok {
+		return nil, fmt.Errorf("chave mestra desconhecida: %s", chaveID)
+	}
+	if len(envolvida) < aead.NonceSize() {
+		return nil, errors.New("chave de dados envolvida inválida")
+	}
+	nonce, dados := envolvida[:aead.NonceSize()], envolvida[aead.NonceSize():]
+	chave, err := aead.Open(nil, nonce, dados, []byte(chaveID))
+	if err != nil {
+		return nil, errors.New("chave de dados envolvida inválida")
+	}
+	return chave, nil
+}
+
+// novoAEAD cria um AES-256-GCM com a chave
+func novoAEAD(chave []byte) (cipher.AEAD, error) {
+	if len(chave) != TamanhoChave {
+		return nil, fmt.Errorf("a chave deve ter %d bytes", TamanhoChave)
+	}
+	bloco, err := aes.NewCipher(chave)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(bloco)
+}
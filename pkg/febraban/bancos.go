@@ -0,0 +1,135 @@
+package febraban
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Conta contém os dados do beneficiário junto ao banco
+type Conta struct {
+	Agencia       string
+	DigitoAgencia string
+	Conta         string
+	DigitoConta   string
+	Carteira      string
+	Convenio      string
+}
+
+// Banco define as regras de cada banco para o campo livre e o nosso número
+type Banco interface {
+	Codigo() string
+	Nome() string
+	CampoLivre(c Conta, sequencial int64) (string, error)
+	NossoNumero(c Conta, sequencial int64) string
+	NossoNumeroExibicao(c Conta, sequencial int64) string
+}
+
+// BancoPorCodigo retorna a implementação do banco pelo código COMPE
+func BancoPorCodigo(codigo string) (Banco, error) {
+	switch codigo {
+	case "001":
+		return BancoDoBrasil{}, nil
+	case "341":
+		return Itau{}, nil
+	}
+	return nil, fmt.Errorf("banco %s não suportado", codigo)
+}
+
+// BancoDoBrasil implementa o layout do Banco do Brasil para convênios de 7 dígitos (carteiras 17 e 18)
+type BancoDoBrasil struct{}
+
+// Codigo retorna o código COMPE do banco
+func (BancoDoBrasil) Codigo() string { return "001" }
+
+// Nome retorna o nome do banco
+func (BancoDoBrasil) Nome() string { return "Banco do Brasil S.A." }
+
+// CampoLivre monta "000000" + convênio (7) + sequencial (10) + carteira (2)
+func (b BancoDoBrasil) CampoLivre(c Conta, sequencial int64) (string, error) {
+	convenio, err := zeros(c.Convenio, 7)
+	if err != nil {
+		return "", fmt.Errorf("convênio: %w", err)
+	}
+	if len(somenteDigitos(c.Convenio)) != 7 {
+		return "", fmt.Errorf("apenas convênios de 7 dígitos são suportados")
+	}
+	carteira, err := zeros(c.Carteira, 2)
+	if err != nil {
+		return "", fmt.Errorf("carteira: %w", err)
+	}
+	seq, err := zeros(strconv.FormatInt(sequencial, 10), 10)
+	if err != nil {
+		return "", fmt.Errorf("nosso número: %w", err)
+	}
+
+	return "000000" + convenio + seq + carteira, nil
+}
+
+// NossoNumero retorna convênio (7) + sequencial (10), sem dígito verificador
+func (b BancoDoBrasil) NossoNumero(c Conta, sequencial int64) string {
+	convenio, _ := zeros(c.Convenio, 7)
+	return convenio + fmt.Sprintf("%010d", sequencial)
+}
+
+// NossoNumeroExibicao retorna o nosso número como impresso no boleto
+func (b BancoDoBrasil) NossoNumeroExibicao(c Conta, sequencial int64) string {
+	return b.NossoNumero(c, sequencial)
+}
+
+// Itau implementa o layout do Itaú para as carteiras de cobrança simples (ex.: 109, 112, 157)
+type Itau struct{}
+
+// Codigo retorna o código COMPE do banco
+func (Itau) Codigo() string { return "341" }
+
+// Nome retorna o nome do banco
+func (Itau) Nome() string { return "Itaú Unibanco S.A." }
+
+// carteirasSemContaNoDAC são as carteiras cujo DAC do nosso número considera apenas carteira e nosso número
+var carteirasSemContaNoDAC = map[string]bool{
+	"126": true, "131": true, "146": true, "150": true, "168": true,
+}
+
+// CampoLivre monta carteira (3) + nosso número (8) + DAC + agência (4) + conta (5) + DAC + "000"
+func (b Itau) CampoLivre(c Conta, sequencial int64) (string, error) {
+	carteira, err := zeros(c.Carteira, 3)
+	if err != nil {
+		return "", fmt.Errorf("carteira: %w", err)
+	}
+	agencia, err := zeros(c.Agencia, 4)
+	if err != nil {
+		return "", fmt.Errorf("agência: %w", err)
+	}
+	conta, err := zeros(c.Conta, 5)
+	if err != nil {
+		return "", fmt.Errorf("conta: %w", err)
+	}
+	nn, err := zeros(strconv.FormatInt(sequencial, 10), 8)
+	if err != nil {
+		return "", fmt.Errorf("nosso número: %w", err)
+	}
+
+	dacConta := strconv.Itoa(Modulo10(agencia + conta))
+	return carteira + nn + b.dacNossoNumero(agencia, conta, carteira, nn) + agencia + conta + dacConta + "000", nil
+}
+
+// NossoNumero retorna o nosso número de 8 dígitos enviado na remessa
+func (b Itau) NossoNumero(c Conta, sequencial int64) string {
+	return fmt.Sprintf("%08d", sequencial)
+}
+
+// NossoNumeroExibicao retorna o nosso número no formato carteira/número-DAC
+func (b Itau) NossoNumeroExibicao(c Conta, sequencial int64) string {
+	carteira, _ := zeros(c.Carteira, 3)
+	agencia, _ := zeros(c.Agencia, 4)
+	conta, _ := zeros(c.Conta, 5)
+	nn := b.NossoNumero(c, sequencial)
+	return fmt.Sprintf("%s/%s-%s", carteira, nn, b.dacNossoNumero(agencia, conta, carteira, nn))
+}
+
+func (b Itau) dacNossoNumero(agencia, conta, carteira, nn string) string {
+	if carteirasSemContaNoDAC[carteira] {
+		return strconv.Itoa(Modulo10(carteira + nn))
+	}
+	return strconv.Itoa(Modulo10(agencia + conta + carteira + nn))
+}
@@ -0,0 +1,194 @@
+package febraban
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Beneficiario identifica a empresa e a conta no cabeçalho dos arquivos CNAB
+type Beneficiario struct {
+	Banco     Banco
+	Conta     Conta
+	Nome      string
+	Documento string // CNPJ ou CPF
+}
+
+// Pagador identifica o sacado de um título na remessa
+type Pagador struct {
+	Nome      string
+	Documento string
+	Endereco  string
+	Bairro    string
+	CEP       string
+	Cidade    string
+	UF        string
+}
+
+// TituloRemessa é um boleto a ser registrado no banco
+type TituloRemessa struct {
+	NossoNumero     string
+	NumeroDocumento string
+	Emissao         time.Time
+	Vencimento      time.Time
+	Valor           float64
+	JurosDia        float64 // valor de mora por dia de atraso
+	Multa           float64 // percentual de multa após o vencimento
+	Pagador         Pagador
+}
+
+// Remessa é o conteúdo de um arquivo de remessa
+type Remessa struct {
+	Beneficiario Beneficiario
+	Sequencial   int
+	Data         time.Time
+	Titulos      []TituloRemessa
+}
+
+// Ocorrencia é um registro lido do arquivo de retorno
+type Ocorrencia struct {
+	NossoNumero     string    `json:"nosso_numero"`
+	NumeroDocumento string    `json:"numero_documento"`
+	Codigo          string    `json:"codigo"`
+	Liquidacao      bool      `json:"liquidacao"`
+	DataOcorrencia  time.Time `json:"data_ocorrencia"`
+	DataCredito     time.Time `json:"data_credito,omitempty"`
+	ValorTitulo     float64   `json:"valor_titulo"`
+	ValorPago       float64   `json:"valor_pago"` // total pago, incluindo juros e multa
+	Juros           float64   `json:"juros"`
+	Desconto        float64   `json:"desconto"`
+	Tarifa          float64   `json:"tarifa"`
+}
+
+// registro monta uma linha de tamanho fixo preenchida com brancos
+type registro []byte
+
+func novoRegistro(tamanho int) registro {
+	return registro(bytes.Repeat([]byte{' '}, tamanho))
+}
+
+// alfa grava texto alinhado à esquerda, em maiúsculas e sem acentos, nas posições ini..fim (base 1)
+func (r registro) alfa(ini, fim int, valor string) {
+	tamanho := fim - ini + 1
+	valor = strings.ToUpper(semAcentos(valor))
+	if len(valor) > tamanho {
+		valor = valor[:tamanho]
+	}
+	copy(r[ini-1:fim], fmt.Sprintf("%-*s", tamanho, valor))
+}
+
+// num grava dígitos alinhados à direita com zeros nas posições ini..fim (base 1)
+func (r registro) num(ini, fim int, valor string) {
+	tamanho := fim - ini + 1
+	valor = somenteDigitos(valor)
+	if len(valor) > tamanho {
+		valor = valor[len(valor)-tamanho:]
+	}
+	copy(r[ini-1:fim], fmt.Sprintf("%0*s", tamanho, valor))
+}
+
+// inteiro grava um número inteiro nas posições ini..fim
+func (r registro) inteiro(ini, fim int, valor int) {
+	r.num(ini, fim, strconv.Itoa(valor))
+}
+
+// valor grava um valor monetário com duas casas decimais implícitas
+func (r registro) valor(ini, fim int, v float64) {
+	r.num(ini, fim, strconv.FormatInt(int64(math.Round(v*100)), 10))
+}
+
+// data grava uma data como DDMMAAAA ou, em campos de 6 posições, DDMMAA
+func (r registro) data(ini, fim int, t time.Time) {
+	if t.IsZero() {
+		r.num(ini, fim, "0")
+		return
+	}
+	if fim-ini+1 == 6 {
+		r.num(ini, fim, t.Format("020106"))
+		return
+	}
+	r.num(ini, fim, t.Format("02012006"))
+}
+
+// campo lê as posições ini..fim (base 1) de uma linha
+func campo(linha string, ini, fim int) string {
+	if len(linha) < fim {
+		return ""
+	}
+	return linha[ini-1 : fim]
+}
+
+// lerValor converte um campo numérico com duas casas decimais implícitas
+func lerValor(linha string, ini, fim int) float64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(campo(linha, ini, fim)), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return float64(n) / 100
+}
+
+// lerData converte um campo DDMMAAAA ou DDMMAA; datas zeradas retornam o valor zero
+func lerData(linha string, ini, fim int) time.Time {
+	s := campo(linha, ini, fim)
+	layout := "02012006"
+	if len(s) == 6 {
+		layout = "020106"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// linhas divide o arquivo em registros, aceitando CRLF ou LF
+func linhas(conteudo []byte) []string {
+	var resultado []string
+	scanner := bufio.NewScanner(bytes.NewReader(conteudo))
+	for scanner.Scan() {
+		l := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(l) != "" {
+			resultado = append(resultado, l)
+		}
+	}
+	return resultado
+}
+
+// tipoInscricao retorna 1 para CPF e 2 para CNPJ
+func tipoInscricao(documento string) int {
+	if len(somenteDigitos(documento)) == 11 {
+		return 1
+	}
+	return 2
+}
+
+func semAcentos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if sub, ok := semAcento[unicode.ToLower(r)]; ok {
+			if unicode.IsUpper(r) {
+				sub = unicode.ToUpper(sub)
+			}
+			r = sub
+		}
+		if r > unicode.MaxASCII || !unicode.IsPrint(r) {
+			r = ' '
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var semAcento = map[rune]rune{
+	'á': 'a', 'à': 'a', 'â': 'a', 'ã': 'a', 'ä': 'a',
+	'é': 'e', 'è': 'e', 'ê': 'e', 'ë': 'e',
+	'í': 'i', 'ì': 'i', 'î': 'i', 'ï': 'i',
+	'ó': 'o', 'ò': 'o', 'ô': 'o', 'õ': 'o', 'ö': 'o',
+	'ú': 'u', 'ù': 'u', 'û': 'u', 'ü': 'u',
+	'ç': 'c', 'ñ': 'n',
+}
@@ -0,0 +1,231 @@
+package febraban
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Versões do layout FEBRABAN CNAB 240 para cobrança
+const (
+	versaoArquivo240 = "103"
+	versaoLote240    = "060"
+)
+
+// Códigos de movimento de retorno que indicam liquidação no CNAB 240
+var liquidacao240 = map[string]bool{"06": true, "17": true}
+
+// GerarRemessa240 gera um arquivo de remessa de cobrança no padrão FEBRABAN CNAB 240 (segmentos P, Q e R)
+func GerarRemessa240(r Remessa) ([]byte, error) {
+	if r.Beneficiario.Banco == nil {
+		return nil, errors.New("banco do beneficiário não informado")
+	}
+	if len(r.Titulos) == 0 {
+		return nil, errors.New("remessa sem títulos")
+	}
+
+	banco := r.Beneficiario.Banco.Codigo()
+	c := r.Beneficiario.Conta
+	var out []registro
+
+	// Header de arquivo
+	h := novoRegistro(240)
+	h.num(1, 3, banco)
+	h.num(4, 7, "0000")
+	h.num(8, 8, "0")
+	h.inteiro(18, 18, tipoInscricao(r.Beneficiario.Documento))
+	h.num(19, 32, r.Beneficiario.Documento)
+	h.alfa(33, 52, c.Convenio)
+	h.num(53, 57, c.Agencia)
+	h.alfa(58, 58, c.DigitoAgencia)
+	h.num(59, 70, c.Conta)
+	h.alfa(71, 71, c.DigitoConta)
+	h.alfa(73, 102, r.Beneficiario.Nome)
+	h.alfa(103, 132, r.Beneficiario.Banco.Nome())
+	h.num(143, 143, "1")
+	h.data(144, 151, r.Data)
+	h.num(152, 157, r.Data.Format("150405"))
+	h.inteiro(158, 163, r.Sequencial)
+	h.num(164, 166, versaoArquivo240)
+	h.num(167, 171, "0")
+	out = append(out, h)
+
+	// Header de lote
+	hl := novoRegistro(240)
+	hl.num(1, 3, banco)
+	hl.num(4, 7, "0001")
+	hl.num(8, 8, "1")
+	hl.alfa(9, 9, "R")
+	hl.num(10, 11, "01")
+	hl.num(14, 16, versaoLote240)
+	hl.inteiro(18, 18, tipoInscricao(r.Beneficiario.Documento))
+	hl.num(19, 33, r.Beneficiario.Documento)
+	hl.alfa(34, 53, c.Convenio)
+	hl.num(54, 58, c.Agencia)
+	hl.alfa(59, 59, c.DigitoAgencia)
+	hl.num(60, 71, c.Conta)
+	hl.alfa(72, 72, c.DigitoConta)
+	hl.alfa(74, 103, r.Beneficiario.Nome)
+	hl.inteiro(184, 191, r.Sequencial)
+	hl.data(192, 199, r.Data)
+	hl.num(200, 207, "0")
+	out = append(out, hl)
+
+	seq := 0
+	total := 0.0
+	for _, t := range r.Titulos {
+		detalhe := func(segmento string) registro {
+			seq++
+			d := novoRegistro(240)
+			d.num(1, 3, banco)
+			d.num(4, 7, "0001")
+			d.num(8, 8, "3")
+			d.inteiro(9, 13, seq)
+			d.alfa(14, 14, segmento)
+			d.num(16, 17, "01") // entrada de título
+			return d
+		}
+
+		// Segmento P: dados do título
+		p := detalhe("P")
+		p.num(18, 22, c.Agencia)
+		p.alfa(23, 23, c.DigitoAgencia)
+		p.num(24, 35, c.Conta)
+		p.alfa(36, 36, c.DigitoConta)
+		p.alfa(38, 57, t.NossoNumero)
+		p.num(58, 58, "1") // cobrança simples
+		p.num(59, 59, "1") // com cadastramento
+		p.alfa(60, 60, "1")
+		p.num(61, 61, "2") // boleto emitido pelo beneficiário
+		p.alfa(62, 62, "2")
+		p.alfa(63, 77, t.NumeroDocumento)
+		p.data(78, 85, t.Vencimento)
+		p.valor(86, 100, t.Valor)
+		p.num(101, 106, "0")
+		p.num(107, 108, "02") // duplicata mercantil
+		p.alfa(109, 109, "N")
+		p.data(110, 117, t.Emissao)
+		if t.JurosDia > 0 {
+			p.num(118, 118, "1") // valor por dia
+			p.data(119, 126, t.Vencimento.AddDate(0, 0, 1))
+			p.valor(127, 141, t.JurosDia)
+		} else {
+			p.num(118, 118, "3") // isento
+			p.num(119, 141, "0")
+		}
+		p.num(142, 195, "0")
+		p.alfa(196, 220, t.NumeroDocumento)
+		p.num(221, 221, "3") // não protestar
+		p.num(222, 223, "0")
+		p.num(224, 224, "0")
+		p.num(225, 227, "0")
+		p.num(228, 229, "09") // real
+		p.num(230, 239, "0")
+		out = append(out, p)
+
+		// Segmento Q: dados do pagador
+		pg := t.Pagador
+		cep := somenteDigitos(pg.CEP)
+		q := detalhe("Q")
+		q.inteiro(18, 18, tipoInscricao(pg.Documento))
+		q.num(19, 33, pg.Documento)
+		q.alfa(34, 73, pg.Nome)
+		q.alfa(74, 113, pg.Endereco)
+		q.alfa(114, 128, pg.Bairro)
+		q.num(129, 136, cep)
+		q.alfa(137, 151, pg.Cidade)
+		q.alfa(152, 153, pg.UF)
+		q.num(154, 169, "0")
+		q.num(210, 212, "0")
+		out = append(out, q)
+
+		// Segmento R: multa
+		if t.Multa > 0 {
+			rr := detalhe("R")
+			rr.num(18, 65, "0")
+			rr.num(66, 66, "2") // percentual
+			rr.data(67, 74, t.Vencimento.AddDate(0, 0, 1))
+			rr.valor(75, 89, t.Multa)
+			rr.num(200, 215, "0")
+			rr.num(217, 228, "0")
+			rr.num(231, 231, "0")
+			out = append(out, rr)
+		}
+
+		total += t.Valor
+	}
+
+	// Trailer de lote
+	tl := novoRegistro(240)
+	tl.num(1, 3, banco)
+	tl.num(4, 7, "0001")
+	tl.num(8, 8, "5")
+	tl.inteiro(18, 23, seq+2)
+	tl.inteiro(24, 29, len(r.Titulos))
+	tl.valor(30, 46, total)
+	tl.num(47, 115, "0")
+	out = append(out, tl)
+
+	// Trailer de arquivo
+	ta := novoRegistro(240)
+	ta.num(1, 3, banco)
+	ta.num(4, 7, "9999")
+	ta.num(8, 8, "9")
+	ta.inteiro(18, 23, 1)
+	ta.inteiro(24, 29, len(out)+1)
+	ta.num(30, 35, "0")
+	out = append(out, ta)
+
+	return juntar(out), nil
+}
+
+// LerRetorno240 lê um arquivo de retorno CNAB 240, combinando os segmentos T e U de cada título
+func LerRetorno240(conteudo []byte) ([]Ocorrencia, error) {
+	var ocorrencias []Ocorrencia
+	var atual *Ocorrencia
+
+	for i, l := range linhas(conteudo) {
+		if len(l) < 240 {
+			return nil, fmt.Errorf("linha %d: registro com %d posições, esperado 240", i+1, len(l))
+		}
+		if campo(l, 8, 8) != "3" {
+			continue
+		}
+
+		switch campo(l, 14, 14) {
+		case "T":
+			codigo := campo(l, 16, 17)
+			ocorrencias = append(ocorrencias, Ocorrencia{
+				NossoNumero:     strings.TrimSpace(campo(l, 38, 57)),
+				NumeroDocumento: strings.TrimSpace(campo(l, 59, 73)),
+				Codigo:          codigo,
+				Liquidacao:      liquidacao240[codigo],
+				ValorTitulo:     lerValor(l, 82, 96),
+				Tarifa:          lerValor(l, 199, 213),
+			})
+			atual = &ocorrencias[len(ocorrencias)-1]
+		case "U":
+			if atual == nil {
+				return nil, fmt.Errorf("linha %d: segmento U sem segmento T", i+1)
+			}
+			atual.Juros = lerValor(l, 18, 32)
+			atual.Desconto = lerValor(l, 33, 47)
+			atual.ValorPago = lerValor(l, 78, 92)
+			atual.DataOcorrencia = lerData(l, 138, 145)
+			atual.DataCredito = lerData(l, 146, 153)
+			atual = nil
+		}
+	}
+
+	return ocorrencias, nil
+}
+
+// juntar concatena os registros separados por CRLF
+func juntar(registros []registro) []byte {
+	var b []byte
+	for _, r := range registros {
+		b = append(b, r...)
+		b = append(b, '\r', '\n')
+	}
+	return b
+}
@@ -0,0 +1,142 @@
+package febraban
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Códigos de ocorrência de retorno que indicam liquidação no CNAB 400 do Itaú
+var liquidacao400 = map[string]bool{"06": true, "07": true, "08": true}
+
+// GerarRemessa400 gera um arquivo de remessa no layout CNAB 400 do Itaú
+func GerarRemessa400(r Remessa) ([]byte, error) {
+	if r.Beneficiario.Banco == nil {
+		return nil, errors.New("banco do beneficiário não informado")
+	}
+	if r.Beneficiario.Banco.Codigo() != "341" {
+		return nil, fmt.Errorf("CNAB 400 não suportado para o banco %s", r.Beneficiario.Banco.Codigo())
+	}
+	if len(r.Titulos) == 0 {
+		return nil, errors.New("remessa sem títulos")
+	}
+
+	c := r.Beneficiario.Conta
+	var out []registro
+	seq := 1
+
+	// Header
+	h := novoRegistro(400)
+	h.num(1, 1, "0")
+	h.num(2, 2, "1")
+	h.alfa(3, 9, "REMESSA")
+	h.num(10, 11, "01")
+	h.alfa(12, 26, "COBRANCA")
+	h.num(27, 30, c.Agencia)
+	h.num(31, 32, "0")
+	h.num(33, 37, c.Conta)
+	h.alfa(38, 38, c.DigitoConta)
+	h.alfa(47, 76, r.Beneficiario.Nome)
+	h.num(77, 79, "341")
+	h.alfa(80, 94, "BANCO ITAU SA")
+	h.data(95, 100, r.Data)
+	h.inteiro(395, 400, seq)
+	out = append(out, h)
+
+	for _, t := range r.Titulos {
+		pg := t.Pagador
+
+		seq++
+		d := novoRegistro(400)
+		d.num(1, 1, "1")
+		d.inteiro(2, 3, tipoInscricao(r.Beneficiario.Documento))
+		d.num(4, 17, r.Beneficiario.Documento)
+		d.num(18, 21, c.Agencia)
+		d.num(22, 23, "0")
+		d.num(24, 28, c.Conta)
+		d.alfa(29, 29, c.DigitoConta)
+		d.num(34, 37, "0")
+		d.alfa(38, 62, t.NumeroDocumento)
+		d.num(63, 70, t.NossoNumero)
+		d.num(71, 83, "0")
+		d.num(84, 86, c.Carteira)
+		d.alfa(108, 108, "I")
+		d.num(109, 110, "01") // remessa
+		d.alfa(111, 120, t.NumeroDocumento)
+		d.data(121, 126, t.Vencimento)
+		d.valor(127, 139, t.Valor)
+		d.num(140, 142, "341")
+		d.num(143, 147, "0")
+		d.num(148, 149, "01") // duplicata mercantil
+		d.alfa(150, 150, "N")
+		d.data(151, 156, t.Emissao)
+		d.num(157, 160, "0")
+		d.valor(161, 173, t.JurosDia)
+		d.num(174, 218, "0")
+		d.inteiro(219, 220, tipoInscricao(pg.Documento))
+		d.num(221, 234, pg.Documento)
+		d.alfa(235, 264, pg.Nome)
+		d.alfa(275, 314, pg.Endereco)
+		d.alfa(315, 326, pg.Bairro)
+		d.num(327, 334, pg.CEP)
+		d.alfa(335, 349, pg.Cidade)
+		d.alfa(350, 351, pg.UF)
+		d.num(386, 393, "0")
+		d.inteiro(395, 400, seq)
+		out = append(out, d)
+
+		// Registro complementar de multa
+		if t.Multa > 0 {
+			seq++
+			m := novoRegistro(400)
+			m.num(1, 1, "2")
+			m.num(2, 2, "2") // percentual
+			m.data(3, 10, t.Vencimento.AddDate(0, 0, 1))
+			m.valor(11, 23, t.Multa)
+			m.inteiro(395, 400, seq)
+			out = append(out, m)
+		}
+	}
+
+	// Trailer
+	seq++
+	tr := novoRegistro(400)
+	tr.num(1, 1, "9")
+	tr.inteiro(395, 400, seq)
+	out = append(out, tr)
+
+	return juntar(out), nil
+}
+
+// LerRetorno400 lê um arquivo de retorno CNAB 400 do Itaú
+func LerRetorno400(conteudo []byte) ([]Ocorrencia, error) {
+	var ocorrencias []Ocorrencia
+
+	for i, l := range linhas(conteudo) {
+		if len(l) < 400 {
+			return nil, fmt.Errorf("linha %d: registro com %d posições, esperado 400", i+1, len(l))
+		}
+		if campo(l, 1, 1) != "1" {
+			continue
+		}
+
+		// O Itaú informa o principal e os encargos separadamente
+		codigo := campo(l, 109, 110)
+		juros := lerValor(l, 267, 279)
+		ocorrencias = append(ocorrencias, Ocorrencia{
+			NossoNumero:     campo(l, 63, 70),
+			NumeroDocumento: strings.TrimSpace(campo(l, 117, 126)),
+			Codigo:          codigo,
+			Liquidacao:      liquidacao400[codigo],
+			DataOcorrencia:  lerData(l, 111, 116),
+			ValorTitulo:     lerValor(l, 153, 165),
+			Tarifa:          lerValor(l, 176, 188),
+			Desconto:        lerValor(l, 241, 253),
+			ValorPago:       lerValor(l, 254, 266) + juros,
+			Juros:           juros,
+			DataCredito:     lerData(l, 296, 301),
+		})
+	}
+
+	return ocorrencias, nil
+}
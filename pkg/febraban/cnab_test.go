@@ -0,0 +1,206 @@
+package febraban
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func linha240(tipo, segmento string, preencher func(r registro)) string {
+	r := novoRegistro(240)
+	r.num(1, 3, "001")
+	r.num(8, 8, tipo)
+	r.alfa(14, 14, segmento)
+	if preencher != nil {
+		preencher(r)
+	}
+	return string(r)
+}
+
+func retorno240(t *testing.T, linhas ...string) []Ocorrencia {
+	t.Helper()
+	ocorrencias, err := LerRetorno240([]byte(strings.Join(linhas, "\r\n") + "\r\n"))
+	if err != nil {
+		t.Fatalf("LerRetorno240: %v", err)
+	}
+	return ocorrencias
+}
+
+func TestLerRetorno240(t *testing.T) {
+	segmentoT := func(nossoNumero, codigo string, valor, tarifa float64) string {
+		return linha240("3", "T", func(r registro) {
+			r.num(16, 17, codigo)
+			r.alfa(38, 57, nossoNumero)
+			r.alfa(59, 73, "NF"+nossoNumero[12:])
+			r.valor(82, 96, valor)
+			r.valor(199, 213, tarifa)
+		})
+	}
+	segmentoU := func(juros, desconto, pago float64, ocorrencia, credito time.Time) string {
+		return linha240("3", "U", func(r registro) {
+			r.valor(18, 32, juros)
+			r.valor(33, 47, desconto)
+			r.valor(78, 92, pago)
+			r.data(138, 145, ocorrencia)
+			r.data(146, 153, credito)
+		})
+	}
+	dia := time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC)
+
+	ocorrencias := retorno240(t,
+		linha240("0", "", nil),
+		linha240("1", "", nil),
+		segmentoT("12345670000000042", "06", 100, 2.5),
+		segmentoU(1.2, 0, 101.2, dia, dia.AddDate(0, 0, 1)),
+		segmentoT("12345670000000043", "02", 50, 0),
+		segmentoU(0, 0, 0, dia, time.Time{}),
+		linha240("5", "", nil),
+		linha240("9", "", nil),
+	)
+
+	esperadas := []Ocorrencia{
+		{
+			NossoNumero: "12345670000000042", NumeroDocumento: "NF00042", Codigo: "06",
+			Liquidacao: true, DataOcorrencia: dia, DataCredito: dia.AddDate(0, 0, 1),
+			ValorTitulo: 100, ValorPago: 101.2, Juros: 1.2, Tarifa: 2.5,
+		},
+		{
+			NossoNumero: "12345670000000043", NumeroDocumento: "NF00043", Codigo: "02",
+			DataOcorrencia: dia, ValorTitulo: 50,
+		},
+	}
+	if len(ocorrencias) != len(esperadas) {
+		t.Fatalf("%d ocorrências, esperado %d", len(ocorrencias), len(esperadas))
+	}
+	for i, o := range ocorrencias {
+		if o != esperadas[i] {
+			t.Errorf("ocorrência %d = %+v, esperado %+v", i, o, esperadas[i])
+		}
+	}
+}
+
+func TestLerRetorno240Invalido(t *testing.T) {
+	casos := []struct {
+		nome     string
+		conteudo string
+	}{
+		{"registro curto", "00100003" + strings.Repeat(" ", 231)},
+		{"segmento U sem T", linha240("3", "U", nil)},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if _, err := LerRetorno240([]byte(c.conteudo)); err == nil {
+				t.Error("retorno inválido aceito")
+			}
+		})
+	}
+}
+
+func TestLerRetorno400(t *testing.T) {
+	detalhe := novoRegistro(400)
+	detalhe.num(1, 1, "1")
+	detalhe.num(63, 70, "12345678")
+	detalhe.num(109, 110, "06")
+	detalhe.data(111, 116, time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC))
+	detalhe.alfa(117, 126, "NF123")
+	detalhe.valor(153, 165, 150.75)
+	detalhe.valor(176, 188, 1.9)
+	detalhe.valor(241, 253, 0.75)
+	detalhe.valor(254, 266, 150)
+	detalhe.valor(267, 279, 3.1)
+	detalhe.data(296, 301, time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC))
+
+	header := novoRegistro(400)
+	header.num(1, 1, "0")
+	trailer := novoRegistro(400)
+	trailer.num(1, 1, "9")
+
+	// Retornos do Itaú chegam com LF em alguns canais; o leitor aceita ambos
+	conteudo := string(header) + "\n" + string(detalhe) + "\n" + string(trailer) + "\n"
+	ocorrencias, err := LerRetorno400([]byte(conteudo))
+	if err != nil {
+		t.Fatalf("LerRetorno400: %v", err)
+	}
+	esperada := Ocorrencia{
+		NossoNumero: "12345678", NumeroDocumento: "NF123", Codigo: "06", Liquidacao: true,
+		DataOcorrencia: time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC),
+		DataCredito:    time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC),
+		ValorTitulo:    150.75, ValorPago: 153.1, Juros: 3.1, Desconto: 0.75, Tarifa: 1.9,
+	}
+	if len(ocorrencias) != 1 || ocorrencias[0] != esperada {
+		t.Errorf("ocorrências = %+v, esperado [%+v]", ocorrencias, esperada)
+	}
+
+	if _, err := LerRetorno400([]byte(linha240("0", "", nil))); err == nil {
+		t.Error("registro de 240 posições aceito no CNAB 400")
+	}
+}
+
+func TestGerarRemessa(t *testing.T) {
+	titulo := TituloRemessa{
+		NossoNumero:     "12345678",
+		NumeroDocumento: "NF123",
+		Emissao:         time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		Vencimento:      time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Valor:           150.75,
+		JurosDia:        0.05,
+		Multa:           2,
+		Pagador:         Pagador{Nome: "José Conceição", Documento: "123.456.789-09", CEP: "80000-000", UF: "PR"},
+	}
+	remessa := Remessa{
+		Beneficiario: Beneficiario{
+			Banco:     Itau{},
+			Conta:     Conta{Agencia: "0057", Conta: "12345", DigitoConta: "7", Carteira: "109"},
+			Nome:      "Empresa Exemplo",
+			Documento: "12.345.678/0001-95",
+		},
+		Sequencial: 3,
+		Data:       time.Date(2025, 3, 1, 10, 30, 0, 0, time.UTC),
+		Titulos:    []TituloRemessa{titulo},
+	}
+
+	casos := []struct {
+		nome    string
+		gerar   func(Remessa) ([]byte, error)
+		tamanho int
+		tipos   []string // tipo de cada registro, lido na posição posTipo
+		posTipo int
+		pagador [3]int // registro, posição inicial e final do nome do pagador
+	}{
+		{"CNAB 240", GerarRemessa240, 240, []string{"0", "1", "3", "3", "3", "5", "9"}, 8, [3]int{3, 34, 73}},
+		{"CNAB 400", GerarRemessa400, 400, []string{"0", "1", "2", "9"}, 1, [3]int{1, 235, 264}},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			conteudo, err := c.gerar(remessa)
+			if err != nil {
+				t.Fatalf("gerar: %v", err)
+			}
+			if !strings.HasSuffix(string(conteudo), "\r\n") {
+				t.Error("arquivo sem CRLF final")
+			}
+			registros := linhas(conteudo)
+			if len(registros) != len(c.tipos) {
+				t.Fatalf("%d registros, esperado %d", len(registros), len(c.tipos))
+			}
+			for i, r := range registros {
+				if len(r) != c.tamanho {
+					t.Errorf("registro %d com %d posições", i+1, len(r))
+				}
+				if tipo := campo(r, c.posTipo, c.posTipo); tipo != c.tipos[i] {
+					t.Errorf("registro %d do tipo %s, esperado %s", i+1, tipo, c.tipos[i])
+				}
+			}
+			// Nomes vão em maiúsculas e sem acentos
+			nome := strings.TrimSpace(campo(registros[c.pagador[0]], c.pagador[1], c.pagador[2]))
+			if nome != "JOSE CONCEICAO" {
+				t.Errorf("pagador = %q, esperado JOSE CONCEICAO", nome)
+			}
+		})
+	}
+
+	remessa.Beneficiario.Banco = BancoDoBrasil{}
+	if _, err := GerarRemessa400(remessa); err == nil {
+		t.Error("CNAB 400 gerado para banco sem suporte")
+	}
+}
@@ -0,0 +1,166 @@
+package febraban
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Datas base do fator de vencimento. Ao atingir 9999 em 21/02/2025 o fator reiniciou em 1000.
+var (
+	dataBaseFator     = time.Date(1997, 10, 7, 0, 0, 0, 0, time.UTC)
+	dataReinicioFator = time.Date(2025, 2, 22, 0, 0, 0, 0, time.UTC)
+)
+
+// Codigo contém as representações de um boleto calculadas a partir dos dados do título
+type Codigo struct {
+	Barras              string `json:"codigo_barras"`   // 44 dígitos
+	LinhaDigitavel      string `json:"linha_digitavel"` // 47 dígitos formatados
+	NossoNumero         string `json:"nosso_numero"`    // apenas dígitos, como enviado ao banco
+	NossoNumeroExibicao string `json:"nosso_numero_exibicao"`
+}
+
+// Gerar calcula o nosso número, o código de barras e a linha digitável de um boleto
+func Gerar(b Banco, c Conta, sequencial int64, vencimento time.Time, valor float64) (*Codigo, error) {
+	if sequencial <= 0 {
+		return nil, errors.New("sequencial do nosso número inválido")
+	}
+
+	campoLivre, err := b.CampoLivre(c, sequencial)
+	if err != nil {
+		return nil, err
+	}
+	if len(campoLivre) != 25 {
+		return nil, fmt.Errorf("campo livre do banco %s deve ter 25 dígitos", b.Codigo())
+	}
+
+	fator, err := FatorVencimento(vencimento)
+	if err != nil {
+		return nil, err
+	}
+
+	centavos := int64(math.Round(valor * 100))
+	if centavos <= 0 || centavos > 9999999999 {
+		return nil, errors.New("valor do boleto inválido")
+	}
+
+	// Código de barras sem o DV geral (posição 5)
+	semDV := b.Codigo() + "9" + fmt.Sprintf("%04d%010d", fator, centavos) + campoLivre
+	dv := DVCodigoBarras(semDV)
+	barras := semDV[:4] + strconv.Itoa(dv) + semDV[4:]
+
+	return &Codigo{
+		Barras:              barras,
+		LinhaDigitavel:      LinhaDigitavel(barras),
+		NossoNumero:         b.NossoNumero(c, sequencial),
+		NossoNumeroExibicao: b.NossoNumeroExibicao(c, sequencial),
+	}, nil
+}
+
+// CodigoBancoComDV retorna o código do banco com o dígito verificador impresso no boleto (ex.: 341-7)
+func CodigoBancoComDV(codigo string) string {
+	dv := 11 - Modulo11(codigo, 9)
+	if dv >= 10 {
+		dv = 0
+	}
+	return codigo + "-" + strconv.Itoa(dv)
+}
+
+// FatorVencimento calcula o fator de vencimento (posições 6 a 9 do código de barras)
+func FatorVencimento(vencimento time.Time) (int, error) {
+	data := time.Date(vencimento.Year(), vencimento.Month(), vencimento.Day(), 0, 0, 0, 0, time.UTC)
+
+	var fator int
+	if data.Before(dataReinicioFator) {
+		fator = int(data.Sub(dataBaseFator).Hours() / 24)
+	} else {
+		fator = 1000 + int(data.Sub(dataReinicioFator).Hours()/24)
+	}
+
+	if fator < 1000 || fator > 9999 {
+		return 0, errors.New("data de vencimento fora da faixa do fator de vencimento")
+	}
+	return fator, nil
+}
+
+// DVCodigoBarras calcula o dígito verificador geral (módulo 11) dos 43 dígitos restantes do código de barras
+func DVCodigoBarras(semDV string) int {
+	dv := 11 - Modulo11(semDV, 9)
+	if dv == 0 || dv == 10 || dv == 11 {
+		return 1
+	}
+	return dv
+}
+
+// LinhaDigitavel monta a linha digitável formatada a partir do código de barras de 44 dígitos
+func LinhaDigitavel(barras string) string {
+	banco := barras[0:4] // banco + moeda
+	dv := barras[4:5]
+	fatorValor := barras[5:19]
+	livre := barras[19:44]
+
+	campo1 := banco + livre[0:5]
+	campo1 += strconv.Itoa(Modulo10(campo1))
+	campo2 := livre[5:15]
+	campo2 += strconv.Itoa(Modulo10(campo2))
+	campo3 := livre[15:25]
+	campo3 += strconv.Itoa(Modulo10(campo3))
+
+	return fmt.Sprintf("%s.%s %s.%s %s.%s %s %s",
+		campo1[:5], campo1[5:], campo2[:5], campo2[5:], campo3[:5], campo3[5:], dv, fatorValor)
+}
+
+// Modulo10 calcula o dígito verificador módulo 10 (pesos 2 e 1 da direita para a esquerda)
+func Modulo10(numero string) int {
+	soma := 0
+	peso := 2
+	for i := len(numero) - 1; i >= 0; i-- {
+		produto := int(numero[i]-'0') * peso
+		if produto > 9 {
+			produto = produto/10 + produto%10
+		}
+		soma += produto
+		if peso == 2 {
+			peso = 1
+		} else {
+			peso = 2
+		}
+	}
+	return (10 - soma%10) % 10
+}
+
+// Modulo11 retorna o resto da divisão por 11 da soma ponderada (pesos de 2 até pesoMaximo, da direita para a esquerda)
+func Modulo11(numero string, pesoMaximo int) int {
+	soma := 0
+	peso := 2
+	for i := len(numero) - 1; i >= 0; i-- {
+		soma += int(numero[i]-'0') * peso
+		peso++
+		if peso > pesoMaximo {
+			peso = 2
+		}
+	}
+	return soma % 11
+}
+
+// somenteDigitos remove qualquer caractere que não seja dígito
+func somenteDigitos(s string) string {
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			b = append(b, s[i])
+		}
+	}
+	return string(b)
+}
+
+// zeros formata o número com zeros à esquerda, falhando se não couber no tamanho
+func zeros(s string, tamanho int) (string, error) {
+	s = somenteDigitos(s)
+	if len(s) > tamanho {
+		return "", fmt.Errorf("valor %s excede %d dígitos", s, tamanho)
+	}
+	return fmt.Sprintf("%0*s", tamanho, s), nil
+}
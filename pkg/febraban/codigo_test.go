@@ -0,0 +1,188 @@
+package febraban
+
+import (
+	"testing"
+	"time"
+)
+
+func TestModulos(t *testing.T) {
+	casos := []struct {
+		numero   string
+		modulo10 int
+		modulo11 int
+	}{
+		{"261533", 4, 2},
+		{"0", 0, 0},
+		{"0019373700", 4, 0},
+		{"9", 1, 7},
+	}
+	for _, c := range casos {
+		t.Run(c.numero, func(t *testing.T) {
+			if got := Modulo10(c.numero); got != c.modulo10 {
+				t.Errorf("Modulo10(%s) = %d, esperado %d", c.numero, got, c.modulo10)
+			}
+			if got := Modulo11(c.numero, 9); got != c.modulo11 {
+				t.Errorf("Modulo11(%s) = %d, esperado %d", c.numero, got, c.modulo11)
+			}
+		})
+	}
+}
+
+func TestCodigoBancoComDV(t *testing.T) {
+	casos := map[string]string{
+		"001": "001-9",
+		"104": "104-0",
+		"237": "237-2",
+		"341": "341-7",
+	}
+	for codigo, esperado := range casos {
+		if got := CodigoBancoComDV(codigo); got != esperado {
+			t.Errorf("CodigoBancoComDV(%s) = %s, esperado %s", codigo, got, esperado)
+		}
+	}
+}
+
+func TestFatorVencimento(t *testing.T) {
+	casos := []struct {
+		nome       string
+		vencimento time.Time
+		fator      int
+		erro       bool
+	}{
+		{"primeiro fator do ciclo original", time.Date(2000, 7, 3, 0, 0, 0, 0, time.UTC), 1000, false},
+		{"último dia do ciclo original", time.Date(2025, 2, 21, 0, 0, 0, 0, time.UTC), 9999, false},
+		{"reinício em 1000", time.Date(2025, 2, 22, 0, 0, 0, 0, time.UTC), 1000, false},
+		{"horário e fuso ignorados", time.Date(2025, 3, 10, 23, 59, 0, 0, time.FixedZone("BRT", -3*3600)), 1016, false},
+		{"antes da faixa", time.Date(2000, 7, 2, 0, 0, 0, 0, time.UTC), 0, true},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			fator, err := FatorVencimento(c.vencimento)
+			if (err != nil) != c.erro {
+				t.Fatalf("erro = %v, esperado erro %t", err, c.erro)
+			}
+			if fator != c.fator {
+				t.Errorf("fator = %d, esperado %d", fator, c.fator)
+			}
+		})
+	}
+}
+
+func TestGerar(t *testing.T) {
+	casos := []struct {
+		nome       string
+		banco      Banco
+		conta      Conta
+		sequencial int64
+		vencimento time.Time
+		valor      float64
+		barras     string
+		linha      string
+		exibicao   string
+	}{
+		{
+			nome:       "Itaú carteira 109",
+			banco:      Itau{},
+			conta:      Conta{Agencia: "0057", Conta: "12345", Carteira: "109"},
+			sequencial: 12345678,
+			vencimento: time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+			valor:      150.75,
+			barras:     "34196101600000150751091234567800057123457000",
+			linha:      "34191.09123 34567.800056 71234.570001 6 10160000015075",
+			exibicao:   "109/12345678-0",
+		},
+		{
+			nome:       "Banco do Brasil convênio de 7 dígitos",
+			banco:      BancoDoBrasil{},
+			conta:      Conta{Convenio: "1234567", Carteira: "17"},
+			sequencial: 42,
+			vencimento: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+			valor:      1000,
+			barras:     "00191994700001000000000001234567000000004217",
+			linha:      "00190.00009 01234.567004 00000.042176 1 99470000100000",
+			exibicao:   "12345670000000042",
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			codigo, err := Gerar(c.banco, c.conta, c.sequencial, c.vencimento, c.valor)
+			if err != nil {
+				t.Fatalf("Gerar: %v", err)
+			}
+			if codigo.Barras != c.barras {
+				t.Errorf("código de barras = %s, esperado %s", codigo.Barras, c.barras)
+			}
+			if codigo.LinhaDigitavel != c.linha {
+				t.Errorf("linha digitável = %s, esperado %s", codigo.LinhaDigitavel, c.linha)
+			}
+			if codigo.NossoNumeroExibicao != c.exibicao {
+				t.Errorf("nosso número = %s, esperado %s", codigo.NossoNumeroExibicao, c.exibicao)
+			}
+			conferirLinhaDigitavel(t, codigo.LinhaDigitavel, codigo.Barras)
+		})
+	}
+}
+
+// conferirLinhaDigitavel recompõe o código de barras a partir da linha e confere os dígitos verificadores
+func conferirLinhaDigitavel(t *testing.T, linha, barras string) {
+	t.Helper()
+	d := somenteDigitos(linha)
+	if len(d) != 47 {
+		t.Fatalf("linha digitável com %d dígitos", len(d))
+	}
+	campos := []string{d[0:10], d[10:21], d[21:32]}
+	for i, c := range campos {
+		if dv := Modulo10(c[:len(c)-1]); int(c[len(c)-1]-'0') != dv {
+			t.Errorf("campo %d com DV %c, esperado %d", i+1, c[len(c)-1], dv)
+		}
+	}
+	recomposto := d[0:4] + d[32:33] + d[33:47] + d[4:9] + d[10:20] + d[21:31]
+	if recomposto != barras {
+		t.Errorf("linha recompõe %s, esperado %s", recomposto, barras)
+	}
+	if dv := DVCodigoBarras(barras[:4] + barras[5:]); int(barras[4]-'0') != dv {
+		t.Errorf("DV geral %c, esperado %d", barras[4], dv)
+	}
+}
+
+func TestGerarInvalido(t *testing.T) {
+	vencimento := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	itau := Conta{Agencia: "0057", Conta: "12345", Carteira: "109"}
+	casos := []struct {
+		nome       string
+		banco      Banco
+		conta      Conta
+		sequencial int64
+		valor      float64
+	}{
+		{"sequencial zero", Itau{}, itau, 0, 10},
+		{"nosso número excede 8 dígitos", Itau{}, itau, 123456789, 10},
+		{"valor zero", Itau{}, itau, 1, 0},
+		{"valor acima do campo", Itau{}, itau, 1, 100000000},
+		{"convênio de 6 dígitos", BancoDoBrasil{}, Conta{Convenio: "123456", Carteira: "17"}, 1, 10},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if _, err := Gerar(c.banco, c.conta, c.sequencial, vencimento, c.valor); err == nil {
+				t.Error("boleto inválido gerado")
+			}
+		})
+	}
+}
+
+func TestDVCodigoBarras(t *testing.T) {
+	casos := []struct {
+		semDV string
+		dv    int
+	}{
+		{"9", 4}, // resto 7
+		{"0", 1}, // resto 0: DV 11 vira 1
+		{"6", 1}, // resto 1: DV 10 vira 1
+		{"5", 1}, // resto 10: DV 1
+	}
+	for _, c := range casos {
+		if dv := DVCodigoBarras(c.semDV); dv != c.dv {
+			t.Errorf("DVCodigoBarras(%s) = %d, esperado %d", c.semDV, dv, c.dv)
+		}
+	}
+}
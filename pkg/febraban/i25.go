@@ -0,0 +1,50 @@
+package febraban
+
+import (
+	"errors"
+)
+
+// Padrões do Interleaved 2 of 5 para cada dígito (N = estreito, W = largo)
+var padroesI25 = [10]string{
+	"NNWWN", "WNNNW", "NWNNW", "WWNNN", "NNWNW",
+	"WNWNN", "NWWNN", "NNNWW", "WNNWN", "NWNWN",
+}
+
+// LarguraLarga é a proporção entre elementos largos e estreitos usada nos boletos
+const LarguraLarga = 3
+
+// I25 codifica os dígitos em Interleaved 2 of 5 e retorna as larguras dos elementos em módulos,
+// alternando barra e espaço, começando por uma barra
+func I25(digitos string) ([]int, error) {
+	if len(digitos)%2 != 0 {
+		return nil, errors.New("Interleaved 2 of 5 exige quantidade par de dígitos")
+	}
+	for i := 0; i < len(digitos); i++ {
+		if digitos[i] < '0' || digitos[i] > '9' {
+			return nil, errors.New("Interleaved 2 of 5 aceita apenas dígitos")
+		}
+	}
+
+	largura := func(c byte) int {
+		if c == 'W' {
+			return LarguraLarga
+		}
+		return 1
+	}
+
+	// Início: barra, espaço, barra, espaço estreitos
+	elementos := []int{1, 1, 1, 1}
+
+	for i := 0; i < len(digitos); i += 2 {
+		barras := padroesI25[digitos[i]-'0']
+		espacos := padroesI25[digitos[i+1]-'0']
+		for j := 0; j < 5; j++ {
+			elementos = append(elementos, largura(barras[j]), largura(espacos[j]))
+		}
+	}
+
+	// Fim: barra larga, espaço estreito, barra estreita
+	elementos = append(elementos, LarguraLarga, 1, 1)
+
+	return elementos, nil
+}
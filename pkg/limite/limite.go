@@ -0,0 +1,181 @@
+// Package limite limita a frequência de operações por chave (IP, e-mail, usuário), em memória.
+package limite
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// limpezaACada é o número de chamadas entre as remoções das chaves sem eventos recentes
+const limpezaACada = 1000
+
+// Janela permite até max eventos por chave em uma janela deslizante
+type Janela struct {
+	max     int
+	duracao time.Duration
+	agora   func() time.Time
+
+	mu       sync.Mutex
+	eventos  map[string][]time.Time
+	chamadas int
+}
+
+// NewJanela cria um limitador de max eventos por chave a cada duracao
+func NewJanela(max int, duracao time.Duration) *Janela {
+	return &Janela{
+		max:     max,
+		duracao: duracao,
+		agora:   time.Now,
+		eventos: make(map[string][]time.Time),
+	}
+}
+
+// Permitir registra o evento e informa se a chave ainda está dentro do limite.
+// Eventos recusados não são registrados.
+func (j *Janela) Permitir(chave string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	agora := j.agora()
+	j.chamadas++
+	if j.chamadas%limpezaACada == 0 {
+		j.limpar(agora)
+	}
+
+	recentes := j.recentes(chave, agora)
+	if len(recentes) >= j.max {
+		j.eventos[chave] = recentes
+		return false
+	}
+	j.eventos[chave] = append(recentes, agora)
+	return true
+}
+
+// Liberar esquece os eventos da chave
+func (j *Janela) Liberar(chave string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	delete(j.eventos, chave)
+}
+
+// recentes retorna os eventos da chave dentro da janela
+func (j *Janela) recentes(chave string, agora time.Time) []time.Time {
+	eventos := j.eventos[chave]
+	inicio := agora.Add(-j.duracao)
+	i := 0
+	for i < len(eventos) && !eventos[i].After(inicio) {
+		i++
+	}
+	return eventos[i:]
+}
+
+// limpar remove as chaves sem eventos na janela
+func (j *Janela) limpar(agora time.Time) {
+	for chave := range j.eventos {
+		if len(j.recentes(chave, agora)) == 0 {
+			delete(j.eventos, chave)
+		}
+	}
+}
+
+// IP retorna o endereço de origem da requisição, sem a porta. Atrás de proxy, depende do
+// middleware Proxies.RealIP para refletir o cliente.
+func IP(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	return r.RemoteAddr
+}
+
+// Proxies são as redes dos proxies reversos confiáveis. Só as requisições vindas deles têm os
+// cabeçalhos X-Forwarded-For e X-Real-IP considerados; de qualquer outra origem, eles poderiam
+// ser forjados para escapar dos limites por IP.
+type Proxies struct {
+	redes []*net.IPNet
+}
+
+// NewProxies interpreta a lista de IPs e redes CIDR separados por vírgula; vazia não confia em nenhum
+func NewProxies(lista string) (*Proxies, error) {
+	p := &Proxies{}
+	for _, item := range strings.Split(lista, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.Contains(item, "/") {
+			ip := net.ParseIP(item)
+			if ip == nil {
+				return nil, fmt.Errorf("proxy inválido: %q", item)
+			}
+			bits := 128
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 32
+			}
+			p.redes = append(p.redes, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, rede, err := net.ParseCIDR(item)
+		if err != nil {
+			return nil, fmt.Errorf("rede de proxy inválida: %q", item)
+		}
+		p.redes = append(p.redes, rede)
+	}
+	return p, nil
+}
+
+// Confiavel indica se o endereço pertence a um proxy confiável
+func (p *Proxies) Confiavel(endereco string) bool {
+	ip := net.ParseIP(strings.TrimSpace(endereco))
+	if ip == nil {
+		return false
+	}
+	for _, rede := range p.redes {
+		if rede.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Cliente retorna o IP do cliente. Vinda de um proxy confiável, a requisição é atribuída ao último
+// endereço do X-Forwarded-For que não seja de proxy confiável (os anteriores foram informados pelo
+// próprio cliente), ou ao X-Real-IP; de outra origem, ao endereço da conexão.
+func (p *Proxies) Cliente(r *http.Request) string {
+	origem := IP(r)
+	if !p.Confiavel(origem) {
+		return origem
+	}
+
+	if xff := r.Header.Values("X-Forwarded-For"); len(xff) > 0 {
+		enderecos := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(enderecos) - 1; i >= 0; i-- {
+			ip := net.ParseIP(strings.TrimSpace(enderecos[i]))
+			if ip == nil {
+				break
+			}
+			if !p.Confiavel(ip.String()) {
+				return ip.String()
+			}
+		}
+		return origem
+	}
+	if ip := net.ParseIP(strings.TrimSpace(r.Header.Get("X-Real-IP"))); ip != nil {
+		return ip.String()
+	}
+	return origem
+}
+
+// RealIP substitui o RemoteAddr pelo IP do cliente segundo Cliente, para que IP e os logs reflitam
+// o cliente atrás dos proxies confiáveis
+func (p *Proxies) RealIP(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ip := p.Cliente(r); ip != IP(r) {
+			r.RemoteAddr = ip
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,115 @@
+package limite
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestJanela(t *testing.T) {
+	agora := time.Unix(1700000000, 0)
+	j := NewJanela(2, time.Minute)
+	j.agora = func() time.Time { return agora }
+
+	if !j.Permitir("a") || !j.Permitir("a") {
+		t.Fatal("eventos dentro do limite recusados")
+	}
+	if j.Permitir("a") {
+		t.Error("terceiro evento na janela permitido")
+	}
+	if !j.Permitir("b") {
+		t.Error("chaves diferentes compartilham o limite")
+	}
+
+	agora = agora.Add(time.Minute + time.Second)
+	if !j.Permitir("a") {
+		t.Error("evento recusado depois da janela")
+	}
+
+	j.Permitir("a")
+	j.Liberar("a")
+	if !j.Permitir("a") {
+		t.Error("evento recusado depois de Liberar")
+	}
+}
+
+func TestNewProxies(t *testing.T) {
+	p, err := NewProxies(" 10.0.0.0/8, 192.168.1.10 ,2001:db8::/32,::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ip, confiavel := range map[string]bool{
+		"10.1.2.3":      true,
+		"192.168.1.10":  true,
+		"192.168.1.11":  false,
+		"2001:db8::5":   true,
+		"::1":           true,
+		"203.0.113.7":   false,
+		"não é um IP":   false,
+		"":              false,
+		"11.0.0.1":      false,
+		"2001:db9::100": false,
+	} {
+		if got := p.Confiavel(ip); got != confiavel {
+			t.Errorf("Confiavel(%q) = %t", ip, got)
+		}
+	}
+
+	for _, invalida := range []string{"10.0.0.0/33", "proxy.local", "10.0.0"} {
+		if _, err := NewProxies(invalida); err == nil {
+			t.Errorf("lista %q aceita", invalida)
+		}
+	}
+
+	vazia, err := NewProxies("")
+	if err != nil || vazia.Confiavel("127.0.0.1") {
+		t.Errorf("lista vazia: %v", err)
+	}
+}
+
+func TestProxiesCliente(t *testing.T) {
+	p, _ := NewProxies("10.0.0.0/8")
+
+	casos := []struct {
+		nome     string
+		remoto   string
+		xff      []string
+		realIP   string
+		esperado string
+	}{
+		{"conexão direta ignora os cabeçalhos", "203.0.113.7:5000", []string{"1.2.3.4"}, "5.6.7.8", "203.0.113.7"},
+		{"proxy confiável com X-Forwarded-For", "10.0.0.1:5000", []string{"198.51.100.20"}, "", "198.51.100.20"},
+		{"endereços forjados antes do cliente", "10.0.0.1:5000", []string{"1.1.1.1, 198.51.100.20"}, "", "198.51.100.20"},
+		{"cadeia de proxies confiáveis", "10.0.0.1:5000", []string{"198.51.100.20, 10.0.0.2", "10.0.0.3"}, "", "198.51.100.20"},
+		{"X-Real-IP do proxy confiável", "10.0.0.1:5000", nil, "198.51.100.21", "198.51.100.21"},
+		{"X-Real-IP inválido", "10.0.0.1:5000", nil, "forjado", "10.0.0.1"},
+		{"X-Forwarded-For inválido", "10.0.0.1:5000", []string{"198.51.100.20, lixo"}, "", "10.0.0.1"},
+		{"só proxies no X-Forwarded-For", "10.0.0.1:5000", []string{"10.0.0.9"}, "", "10.0.0.1"},
+		{"IPv6 direto", "[2001:db8::1]:443", []string{"1.2.3.4"}, "", "2001:db8::1"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = c.remoto
+			for _, v := range c.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if c.realIP != "" {
+				r.Header.Set("X-Real-IP", c.realIP)
+			}
+
+			if got := p.Cliente(r); got != c.esperado {
+				t.Errorf("Cliente = %q, esperado %q", got, c.esperado)
+			}
+
+			var visto string
+			p.RealIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				visto = IP(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if visto != c.esperado {
+				t.Errorf("IP depois de RealIP = %q, esperado %q", visto, c.esperado)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package nfe
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"software.sslmate.com/src/go-pkcs12"
+)
+
+// Algoritmos XMLDSig exigidos pelo Manual de Orientação do Contribuinte
+const (
+	algoritmoC14N       = "http://www.w3.org/TR/2001/REC-xml-c14n-20010315"
+	algoritmoEnveloped  = "http://www.w3.org/2000/09/xmldsig#enveloped-signature"
+	algoritmoRSASHA1    = "http://www.w3.org/2000/09/xmldsig#rsa-sha1"
+	algoritmoDigestSHA1 = "http://www.w3.org/2000/09/xmldsig#sha1"
+)
+
+// Certificado é um certificado digital ICP-Brasil do tipo A1 com a chave privada
+type Certificado struct {
+	Cert  *x509.Certificate
+	chave *rsa.PrivateKey
+}
+
+// LerCertificado abre um arquivo PKCS#12 (.pfx/.p12) protegido por senha
+func LerCertificado(pfx []byte, senha string) (*Certificado, error) {
+	chave, cert, _, err := pkcs12.DecodeChain(pfx, senha)
+	if err != nil {
+		return nil, fmt.Errorf("erro ao abrir o certificado: %w", err)
+	}
+
+	rsaChave, ok := chave.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("o certificado deve usar chave RSA")
+	}
+
+	return &Certificado{Cert: cert, chave: rsaChave}, nil
+}
+
+// Validade retorna o fim da validade do certificado
+func (c *Certificado) Validade() time.Time {
+	return c.Cert.NotAfter
+}
+
+// CNPJ extrai o CNPJ do titular, que nos certificados e-CNPJ vem ao fim do CN ("RAZAO SOCIAL:CNPJ")
+func (c *Certificado) CNPJ() string {
+	cn := c.Cert.Subject.CommonName
+	if i := strings.LastIndex(cn, ":"); i >= 0 {
+		if cnpj := somenteDigitos(cn[i+1:]); len(cnpj) == 14 {
+			return cnpj
+		}
+	}
+	return ""
+}
+
+// Assinar assina o primeiro elemento com atributo Id do documento (infNFe, infEvento...)
+// e inclui a assinatura enveloped como último filho do elemento raiz
+func Assinar(doc []byte, cert *Certificado) ([]byte, error) {
+	agora := time.Now()
+	if agora.Before(cert.Cert.NotBefore) || agora.After(cert.Cert.NotAfter) {
+		return nil, errors.New("certificado digital fora do prazo de validade")
+	}
+
+	id, err := primeiroID(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	canonico, err := canonicalizar(doc, porID(id))
+	if err != nil {
+		return nil, err
+	}
+	digest := sha1.Sum(canonico)
+
+	signedInfo := `<SignedInfo xmlns="` + NamespaceXMLDSig + `">` +
+		`<CanonicalizationMethod Algorithm="` + algoritmoC14N + `"></CanonicalizationMethod>` +
+		`<SignatureMethod Algorithm="` + algoritmoRSASHA1 + `"></SignatureMethod>` +
+		`<Reference URI="#` + escaparAtributo(id) + `"><Transforms>` +
+		`<Transform Algorithm="` + algoritmoEnveloped + `"></Transform>` +
+		`<Transform Algorithm="` + algoritmoC14N + `"></Transform>` +
+		`</Transforms><DigestMethod Algorithm="` + algoritmoDigestSHA1 + `"></DigestMethod>` +
+		`<DigestValue>` + base64.StdEncoding.EncodeToString(digest[:]) + `</DigestValue>` +
+		`</Reference></SignedInfo>`
+
+	hash := sha1.Sum([]byte(signedInfo))
+	valor, err := rsa.SignPKCS1v15(nil, cert.chave, crypto.SHA1, hash[:])
+	if err != nil {
+		return nil, err
+	}
+
+	assinatura := `<Signature xmlns="` + NamespaceXMLDSig + `">` +
+		strings.Replace(signedInfo, ` xmlns="`+NamespaceXMLDSig+`"`, "", 1) +
+		`<SignatureValue>` + base64.StdEncoding.EncodeToString(valor) + `</SignatureValue>` +
+		`<KeyInfo><X509Data><X509Certificate>` + base64.StdEncoding.EncodeToString(cert.Cert.Raw) +
+		`</X509Certificate></X509Data></KeyInfo></Signature>`
+
+	fim := bytes.LastIndex(doc, []byte("</"))
+	if fim < 0 {
+		return nil, errors.New("documento XML inválido")
+	}
+
+	assinado := make([]byte, 0, len(doc)+len(assinatura))
+	assinado = append(assinado, doc[:fim]...)
+	assinado = append(assinado, assinatura...)
+	assinado = append(assinado, doc[fim:]...)
+	return assinado, nil
+}
+
+// assinaturaXML é a estrutura da assinatura lida na verificação
+type assinaturaXML struct {
+	SignedInfo struct {
+		Reference struct {
+			URI         string `xml:"URI,attr"`
+			DigestValue string `xml:"DigestValue"`
+		} `xml:"Reference"`
+	} `xml:"SignedInfo"`
+	SignatureValue  string `xml:"SignatureValue"`
+	X509Certificate string `xml:"KeyInfo>X509Data>X509Certificate"`
+}
+
+// VerificarAssinatura confere o digest do elemento referenciado e a assinatura do SignedInfo,
+// retornando o certificado do signatário
+func VerificarAssinatura(doc []byte) (*x509.Certificate, error) {
+	var raiz struct {
+		Signature *assinaturaXML `xml:"Signature"`
+		NFe       *struct {
+			Signature *assinaturaXML `xml:"Signature"`
+		} `xml:"NFe"`
+	}
+	if err := xml.Unmarshal(doc, &raiz); err != nil {
+		return nil, err
+	}
+
+	sig := raiz.Signature
+	if sig == nil && raiz.NFe != nil {
+		sig = raiz.NFe.Signature
+	}
+	if sig == nil {
+		return nil, errors.New("documento não está assinado")
+	}
+
+	der, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig.X509Certificate))
+	if err != nil {
+		return nil, errors.New("certificado da assinatura inválido")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	chave, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return nil, errors.New("a assinatura deve usar chave RSA")
+	}
+
+	id := strings.TrimPrefix(sig.SignedInfo.Reference.URI, "#")
+	canonico, err := canonicalizar(doc, porID(id))
+	if err != nil {
+		return nil, err
+	}
+	digest := sha1.Sum(canonico)
+	if base64.StdEncoding.EncodeToString(digest[:]) != strings.TrimSpace(sig.SignedInfo.Reference.DigestValue) {
+		return nil, errors.New("digest não confere: o documento foi alterado após a assinatura")
+	}
+
+	signedInfo, err := canonicalizar(doc, porNome("SignedInfo"))
+	if err != nil {
+		return nil, err
+	}
+	valor, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig.SignatureValue))
+	if err != nil {
+		return nil, errors.New("valor da assinatura inválido")
+	}
+	hash := sha1.Sum(signedInfo)
+	if err := rsa.VerifyPKCS1v15(chave, crypto.SHA1, hash[:], valor); err != nil {
+		return nil, errors.New("assinatura digital inválida")
+	}
+
+	return cert, nil
+}
+
+// primeiroID retorna o valor do primeiro atributo Id do documento
+func primeiroID(doc []byte) (string, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+	for {
+		tok, err := d.RawToken()
+		if err != nil {
+			return "", errors.New("documento não possui elemento com atributo Id")
+		}
+		if t, ok := tok.(xml.StartElement); ok {
+			for _, a := range t.Attr {
+				if a.Name.Local == "Id" {
+					return a.Value, nil
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package nfe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+// canonicalizar aplica a canonicalização XML inclusiva 1.0 (sem comentários) ao primeiro
+// elemento aceito por seleciona, com os namespaces herdados dos ancestrais.
+// Cobre os documentos da NF-e, que usam apenas namespaces padrão (sem prefixos).
+func canonicalizar(doc []byte, seleciona func(xml.StartElement) bool) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+
+	var (
+		out         bytes.Buffer
+		namespaces  = []string{""} // namespace padrão em vigor em cada nível
+		renderizado []string       // namespace padrão já declarado na saída em cada nível capturado
+		capturando  bool
+	)
+
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			ns := namespaces[len(namespaces)-1]
+			var attrs []xml.Attr
+			for _, a := range t.Attr {
+				if a.Name.Space == "" && a.Name.Local == "xmlns" {
+					ns = a.Value
+					continue
+				}
+				if a.Name.Space == "xmlns" {
+					return nil, errors.New("canonicalização não suporta prefixos de namespace")
+				}
+				attrs = append(attrs, a)
+			}
+			namespaces = append(namespaces, ns)
+
+			if !capturando && seleciona(t) {
+				capturando = true
+				renderizado = []string{""}
+			}
+			if !capturando {
+				continue
+			}
+
+			out.WriteString("<" + nome(t.Name))
+			if ns != renderizado[len(renderizado)-1] {
+				out.WriteString(` xmlns="` + escaparAtributo(ns) + `"`)
+			}
+			renderizado = append(renderizado, ns)
+
+			sort.Slice(attrs, func(i, j int) bool { return nome(attrs[i].Name) < nome(attrs[j].Name) })
+			for _, a := range attrs {
+				out.WriteString(" " + nome(a.Name) + `="` + escaparAtributo(a.Value) + `"`)
+			}
+			out.WriteString(">")
+
+		case xml.EndElement:
+			namespaces = namespaces[:len(namespaces)-1]
+			if !capturando {
+				continue
+			}
+
+			out.WriteString("</" + nome(t.Name) + ">")
+			renderizado = renderizado[:len(renderizado)-1]
+			if len(renderizado) == 1 {
+				return out.Bytes(), nil
+			}
+
+		case xml.CharData:
+			if capturando {
+				out.WriteString(escaparTexto(string(t)))
+			}
+		}
+	}
+
+	return nil, errors.New("elemento a canonicalizar não encontrado")
+}
+
+// porID seleciona o elemento cujo atributo Id é igual ao informado
+func porID(id string) func(xml.StartElement) bool {
+	return func(t xml.StartElement) bool {
+		for _, a := range t.Attr {
+			if a.Name.Local == "Id" && a.Value == id {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// porNome seleciona o primeiro elemento com o nome local informado
+func porNome(local string) func(xml.StartElement) bool {
+	return func(t xml.StartElement) bool {
+		return t.Name.Local == local
+	}
+}
+
+func nome(n xml.Name) string {
+	if n.Space != "" {
+		return n.Space + ":" + n.Local
+	}
+	return n.Local
+}
+
+var (
+	escapeTexto    = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	escapeAtributo = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func escaparTexto(s string) string {
+	return escapeTexto.Replace(s)
+}
+
+func escaparAtributo(s string) string {
+	return escapeAtributo.Replace(s)
+}
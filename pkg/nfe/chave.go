@@ -0,0 +1,117 @@
+package nfe
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// codigosUF são os códigos IBGE das unidades federativas usados no cUF e na chave de acesso
+var codigosUF = map[string]string{
+	"RO": "11", "AC": "12", "AM": "13", "RR": "14", "PA": "15", "AP": "16", "TO": "17",
+	"MA": "21", "PI": "22", "CE": "23", "RN": "24", "PB": "25", "PE": "26", "AL": "27", "SE": "28", "BA": "29",
+	"MG": "31", "ES": "32", "RJ": "33", "SP": "35",
+	"PR": "41", "SC": "42", "RS": "43",
+	"MS": "50", "MT": "51", "GO": "52", "DF": "53",
+}
+
+// CodigoUF retorna o código IBGE da UF
+func CodigoUF(uf string) (string, error) {
+	codigo, ok := codigosUF[strings.ToUpper(uf)]
+	if !ok {
+		return "", fmt.Errorf("UF inválida: %s", uf)
+	}
+	return codigo, nil
+}
+
+// Chave monta a chave de acesso de 44 dígitos e retorna também o dígito verificador
+func Chave(cUF string, emissao time.Time, cnpj string, serie, numero int, codigoNumerico string) (string, int, error) {
+	cnpj = somenteDigitos(cnpj)
+	if len(cUF) != 2 || len(cnpj) != 14 {
+		return "", 0, errors.New("UF e CNPJ do emitente são obrigatórios para a chave de acesso")
+	}
+	if serie < 0 || serie > 999 || numero <= 0 || numero > 999999999 {
+		return "", 0, errors.New("série ou número da nota fora da faixa permitida")
+	}
+	if len(codigoNumerico) != 8 {
+		return "", 0, errors.New("código numérico deve ter 8 dígitos")
+	}
+
+	base := fmt.Sprintf("%s%s%s%02d%03d%09d%d%s", cUF, emissao.Format("0601"), cnpj, Modelo, serie, numero, 1, codigoNumerico)
+	dv := DigitoChave(base)
+	return base + strconv.Itoa(dv), dv, nil
+}
+
+// DigitoChave calcula o dígito verificador da chave de acesso (módulo 11, pesos de 2 a 9)
+func DigitoChave(base string) int {
+	soma, peso := 0, 2
+	for i := len(base) - 1; i >= 0; i-- {
+		soma += int(base[i]-'0') * peso
+		peso++
+		if peso > 9 {
+			peso = 2
+		}
+	}
+
+	resto := soma % 11
+	if resto < 2 {
+		return 0
+	}
+	return 11 - resto
+}
+
+// ValidarChave verifica o tamanho e o dígito verificador de uma chave de acesso
+func ValidarChave(chave string) error {
+	if len(chave) != 44 || somenteDigitos(chave) != chave {
+		return errors.New("chave de acesso deve ter 44 dígitos")
+	}
+	if DigitoChave(chave[:43]) != int(chave[43]-'0') {
+		return errors.New("dígito verificador da chave de acesso inválido")
+	}
+	return nil
+}
+
+// FormatarChave agrupa a chave de acesso em blocos de 4 dígitos, como impresso no DANFE
+func FormatarChave(chave string) string {
+	var blocos []string
+	for i := 0; i < len(chave); i += 4 {
+		fim := i + 4
+		if fim > len(chave) {
+			fim = len(chave)
+		}
+		blocos = append(blocos, chave[i:fim])
+	}
+	return strings.Join(blocos, " ")
+}
+
+// Valor formata um valor monetário com 2 casas decimais, como exigido pelo leiaute
+func Valor(v float64) string {
+	return strconv.FormatFloat(v, 'f', 2, 64)
+}
+
+// Quantidade formata quantidades com 4 casas decimais
+func Quantidade(v float64) string {
+	return strconv.FormatFloat(v, 'f', 4, 64)
+}
+
+// ValorUnitario formata valores unitários com 10 casas decimais
+func ValorUnitario(v float64) string {
+	return strconv.FormatFloat(v, 'f', 10, 64)
+}
+
+// DataHora formata datas com o fuso horário (AAAA-MM-DDThh:mm:ss-03:00), como exigido nos campos dh*
+func DataHora(t time.Time) string {
+	return t.Format("2006-01-02T15:04:05-07:00")
+}
+
+func somenteDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,581 @@
+package nfe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode/code128"
+	"github.com/go-pdf/fpdf"
+)
+
+// Dimensões do DANFE em milímetros
+const (
+	margemDANFE    = 7.0
+	larguraDANFE   = 196.0
+	alturaCampoDF  = 7.0
+	alturaCanhoto  = 16.0
+	alturaQuadro   = 33.0
+	alturaItem     = 4.5
+	limiteItens    = 270.0
+	alturaBarraChv = 11.0
+)
+
+// colunaItemDANFE descreve uma coluna do quadro de produtos
+type colunaItemDANFE struct {
+	titulo  string
+	largura float64
+	alinha  string
+}
+
+var colunasDANFE = []colunaItemDANFE{
+	{"CÓDIGO", 16, "L"},
+	{"DESCRIÇÃO DO PRODUTO / SERVIÇO", 50, "L"},
+	{"NCM/SH", 13, "C"},
+	{"CST", 8, "C"},
+	{"CFOP", 8, "C"},
+	{"UN", 8, "C"},
+	{"QUANT.", 13, "R"},
+	{"V. UNIT.", 14, "R"},
+	{"V. TOTAL", 14, "R"},
+	{"BC ICMS", 13, "R"},
+	{"V. ICMS", 11, "R"},
+	{"V. IPI", 10, "R"},
+	{"ALÍQ. ICMS", 9, "R"},
+	{"ALÍQ. IPI", 9, "R"},
+}
+
+// coluna é um campo de uma linha do DANFE, com largura proporcional à linha
+type coluna struct {
+	rotulo, valor string
+	largura       float64
+	alinha        string
+}
+
+// documentoDANFE é a NF-e lida do XML, com o protocolo quando distribuída como nfeProc
+type documentoDANFE struct {
+	NFe     *NFe `xml:"NFe"`
+	ProtNFe *struct {
+		InfProt struct {
+			DhRecbto string `xml:"dhRecbto"`
+			NProt    string `xml:"nProt"`
+			CStat    int    `xml:"cStat"`
+		} `xml:"infProt"`
+	} `xml:"protNFe"`
+}
+
+// DANFE gera o Documento Auxiliar da NF-e a partir do XML da nota ou do nfeProc.
+// Notas sem protocolo de autorização ou de homologação recebem a marca "SEM VALOR FISCAL".
+func DANFE(doc []byte) ([]byte, error) {
+	var d documentoDANFE
+	if err := xml.Unmarshal(doc, &d); err != nil {
+		return nil, err
+	}
+	if d.NFe == nil {
+		n, err := Unmarshal(doc)
+		if err != nil {
+			return nil, err
+		}
+		d.NFe = n
+	}
+	inf := &d.NFe.InfNFe
+	chave := strings.TrimPrefix(inf.ID, "NFe")
+	if err := ValidarChave(chave); err != nil {
+		return nil, err
+	}
+
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margemDANFE, margemDANFE, margemDANFE)
+	pdf.SetAutoPageBreak(false, margemDANFE)
+	pdf.AliasNbPages("{nb}")
+	r := &renderizador{pdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("cp1252")}
+
+	protocolo := ""
+	autorizada := false
+	if d.ProtNFe != nil && d.ProtNFe.InfProt.NProt != "" {
+		p := d.ProtNFe.InfProt
+		autorizada = p.CStat == StatusAutorizada || p.CStat == StatusAutorizadaForaPrazo
+		protocolo = p.NProt
+		if recebimento, err := time.Parse("2006-01-02T15:04:05-07:00", p.DhRecbto); err == nil {
+			protocolo += " - " + recebimento.Format("02/01/2006 15:04:05")
+		}
+	}
+	semValor := !autorizada || inf.Ide.TpAmb == AmbienteHomologacao
+
+	novaPagina := func() float64 {
+		pdf.AddPage()
+		if semValor {
+			r.marcaDagua()
+		}
+		y := margemDANFE
+		if pdf.PageNo() == 1 {
+			y = r.canhoto(y, inf)
+		}
+		if err := r.quadroEmitente(y, inf, chave); err != nil {
+			pdf.SetError(err)
+		}
+		y += alturaQuadro
+		r.linha(y, []coluna{
+			{"NATUREZA DA OPERAÇÃO", inf.Ide.NatOp, 0.6, "L"},
+			{"PROTOCOLO DE AUTORIZAÇÃO DE USO", protocolo, 0.4, "C"},
+		})
+		y += alturaCampoDF
+		r.linha(y, []coluna{
+			{"INSCRIÇÃO ESTADUAL", inf.Emit.IE, 0.34, "L"},
+			{"INSCRIÇÃO ESTADUAL DO SUBST. TRIB.", "", 0.33, "L"},
+			{"CNPJ", formatarCNPJ(inf.Emit.CNPJ), 0.33, "L"},
+		})
+		return y + alturaCampoDF + 1
+	}
+
+	y := novaPagina()
+	y = r.destinatario(y, inf)
+	y = r.fatura(y, inf)
+	y = r.impostos(y, inf)
+	y = r.transporte(y, inf)
+
+	y = r.cabecalhoItens(y)
+	for _, det := range inf.Det {
+		if y+alturaItem > limiteItens {
+			y = r.cabecalhoItens(novaPagina())
+		}
+		r.item(y, det)
+		y += alturaItem
+	}
+
+	if inf.InfAdic != nil && inf.InfAdic.InfCpl != "" {
+		if y+25 > limiteItens+15 {
+			y = novaPagina()
+		}
+		r.dadosAdicionais(y+2, inf.InfAdic.InfCpl)
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type renderizador struct {
+	pdf *fpdf.Fpdf
+	tr  func(string) string
+}
+
+// campo desenha uma caixa com rótulo pequeno e valor
+func (r *renderizador) campo(x, y, largura, altura float64, rotulo, valor, alinhamento string) {
+	pdf := r.pdf
+	pdf.Rect(x, y, largura, altura, "D")
+	pdf.SetFont("Helvetica", "", 5)
+	pdf.Text(x+0.8, y+2.2, r.tr(rotulo))
+	pdf.SetFont("Helvetica", "", 8)
+	pdf.SetXY(x+0.8, y+2.6)
+	pdf.CellFormat(largura-1.6, altura-2.8, truncar(pdf, r.tr(valor), largura-1.6), "", 0, alinhamento, false, 0, "")
+}
+
+// linha desenha uma sequência de campos com larguras proporcionais à largura útil
+func (r *renderizador) linha(y float64, colunas []coluna) {
+	x := margemDANFE
+	for _, c := range colunas {
+		w := larguraDANFE * c.largura
+		r.campo(x, y, w, alturaCampoDF, c.rotulo, c.valor, c.alinha)
+		x += w
+	}
+}
+
+// titulo escreve o nome de um quadro do DANFE
+func (r *renderizador) titulo(y float64, texto string) float64 {
+	r.pdf.SetFont("Helvetica", "B", 6)
+	r.pdf.Text(margemDANFE, y+2.5, r.tr(texto))
+	return y + 3
+}
+
+// canhoto desenha o comprovante de entrega destacável
+func (r *renderizador) canhoto(y float64, inf *InfNFe) float64 {
+	pdf := r.pdf
+	larguraNF := 36.0
+	esquerda := larguraDANFE - larguraNF
+
+	pdf.Rect(margemDANFE, y, esquerda, alturaCanhoto/2, "D")
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.SetXY(margemDANFE+1, y+0.8)
+	pdf.MultiCell(esquerda-2, 2.6, r.tr(fmt.Sprintf(
+		"RECEBEMOS DE %s OS PRODUTOS E/OU SERVIÇOS CONSTANTES DA NOTA FISCAL ELETRÔNICA INDICADA AO LADO. "+
+			"EMISSÃO: %s VALOR TOTAL: R$ %s DESTINATÁRIO: %s",
+		strings.ToUpper(inf.Emit.XNome), dataEmissao(inf.Ide.DhEmi), moeda(inf.Total.ICMSTot.VNF), nomeDestinatario(inf))), "", "L", false)
+
+	r.campo(margemDANFE, y+alturaCanhoto/2, 40, alturaCanhoto/2, "DATA DE RECEBIMENTO", "", "L")
+	r.campo(margemDANFE+40, y+alturaCanhoto/2, esquerda-40, alturaCanhoto/2, "IDENTIFICAÇÃO E ASSINATURA DO RECEBEDOR", "", "L")
+
+	pdf.Rect(margemDANFE+esquerda, y, larguraNF, alturaCanhoto, "D")
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetXY(margemDANFE+esquerda, y+2)
+	pdf.CellFormat(larguraNF, 5, "NF-e", "", 2, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.CellFormat(larguraNF, 4, r.tr("Nº "+numeroNota(inf.Ide.NNF)), "", 2, "C", false, 0, "")
+	pdf.CellFormat(larguraNF, 4, r.tr(fmt.Sprintf("SÉRIE %03d", inf.Ide.Serie)), "", 0, "C", false, 0, "")
+
+	y += alturaCanhoto + 1.5
+	pdf.SetDashPattern([]float64{1, 1}, 0)
+	pdf.Line(margemDANFE, y, margemDANFE+larguraDANFE, y)
+	pdf.SetDashPattern([]float64{}, 0)
+	return y + 1.5
+}
+
+// quadroEmitente desenha a identificação do emitente, o quadro DANFE e a chave de acesso
+func (r *renderizador) quadroEmitente(y float64, inf *InfNFe, chave string) error {
+	pdf := r.pdf
+	larguraEmitente, larguraTitulo := 80.0, 34.0
+	larguraChave := larguraDANFE - larguraEmitente - larguraTitulo
+	x := margemDANFE
+
+	// Emitente
+	pdf.Rect(x, y, larguraEmitente, alturaQuadro, "D")
+	e := inf.Emit
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetXY(x+1, y+3)
+	pdf.MultiCell(larguraEmitente-2, 4.5, r.tr(e.XNome), "", "C", false)
+	pdf.SetFont("Helvetica", "", 7)
+	end := e.EnderEmit
+	for _, l := range []string{
+		juntar(", ", end.XLgr, end.Nro, end.XCpl),
+		juntar(" - ", end.XBairro, formatarCEP(end.CEP)),
+		juntar(" - ", end.XMun, end.UF),
+		rotulo("Fone: ", end.Fone),
+	} {
+		if l != "" {
+			pdf.SetX(x + 1)
+			pdf.CellFormat(larguraEmitente-2, 3.4, r.tr(l), "", 2, "C", false, 0, "")
+		}
+	}
+
+	// DANFE
+	x += larguraEmitente
+	pdf.Rect(x, y, larguraTitulo, alturaQuadro, "D")
+	pdf.SetXY(x, y+1.5)
+	pdf.SetFont("Helvetica", "B", 12)
+	pdf.CellFormat(larguraTitulo, 5, "DANFE", "", 2, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.MultiCell(larguraTitulo, 2.6, r.tr("Documento Auxiliar da Nota Fiscal Eletrônica"), "", "C", false)
+	pdf.SetX(x + 3)
+	pdf.CellFormat(18, 3, "0 - ENTRADA", "", 2, "L", false, 0, "")
+	pdf.CellFormat(18, 3, r.tr("1 - SAÍDA"), "", 0, "L", false, 0, "")
+	pdf.Rect(x+larguraTitulo-10, y+12.5, 6, 5, "D")
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.Text(x+larguraTitulo-8, y+16.3, strconv.Itoa(inf.Ide.TpNF))
+	pdf.SetXY(x, y+19.5)
+	pdf.SetFont("Helvetica", "B", 8)
+	pdf.CellFormat(larguraTitulo, 4, r.tr("Nº "+numeroNota(inf.Ide.NNF)), "", 2, "C", false, 0, "")
+	pdf.CellFormat(larguraTitulo, 4, r.tr(fmt.Sprintf("SÉRIE %03d", inf.Ide.Serie)), "", 2, "C", false, 0, "")
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.CellFormat(larguraTitulo, 4, fmt.Sprintf("FOLHA %d/{nb}", pdf.PageNo()), "", 0, "C", false, 0, "")
+
+	// Código de barras e chave de acesso
+	x += larguraTitulo
+	pdf.Rect(x, y, larguraChave, alturaQuadro, "D")
+	if err := r.codigoBarras(x+3, y+2, larguraChave-6, chave); err != nil {
+		return err
+	}
+	r.campo(x, y+alturaBarraChv+4, larguraChave, alturaCampoDF, "CHAVE DE ACESSO", FormatarChave(chave), "C")
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.SetXY(x+1, y+alturaBarraChv+4+alturaCampoDF+1)
+	pdf.MultiCell(larguraChave-2, 3, r.tr("Consulta de autenticidade no portal nacional da NF-e www.nfe.fazenda.gov.br/portal ou no site da Sefaz Autorizadora"), "", "C", false)
+	return nil
+}
+
+// destinatario desenha o quadro do destinatário
+func (r *renderizador) destinatario(y float64, inf *InfNFe) float64 {
+	y = r.titulo(y, "DESTINATÁRIO / REMETENTE")
+
+	var documento, ie string
+	var end Endereco
+	email := ""
+	if inf.Dest != nil {
+		documento = formatarCNPJ(inf.Dest.CNPJ)
+		if inf.Dest.CPF != "" {
+			documento = formatarCPF(inf.Dest.CPF)
+		}
+		ie = inf.Dest.IE
+		email = inf.Dest.Email
+		if inf.Dest.EnderDest != nil {
+			end = *inf.Dest.EnderDest
+		}
+	}
+
+	r.linha(y, []coluna{
+		{"NOME / RAZÃO SOCIAL", nomeDestinatario(inf), 0.6, "L"},
+		{"CNPJ / CPF", documento, 0.25, "L"},
+		{"DATA DA EMISSÃO", dataEmissao(inf.Ide.DhEmi), 0.15, "C"},
+	})
+	y += alturaCampoDF
+	r.linha(y, []coluna{
+		{"ENDEREÇO", juntar(", ", end.XLgr, end.Nro, end.XCpl), 0.5, "L"},
+		{"BAIRRO / DISTRITO", end.XBairro, 0.23, "L"},
+		{"CEP", formatarCEP(end.CEP), 0.12, "C"},
+		{"DATA DA SAÍDA/ENTRADA", dataEmissao(inf.Ide.DhSaiEnt), 0.15, "C"},
+	})
+	y += alturaCampoDF
+	r.linha(y, []coluna{
+		{"MUNICÍPIO", end.XMun, 0.35, "L"},
+		{"FONE / FAX", end.Fone, 0.15, "L"},
+		{"UF", end.UF, 0.05, "C"},
+		{"INSCRIÇÃO ESTADUAL", ie, 0.15, "L"},
+		{"E-MAIL", email, 0.3, "L"},
+	})
+	return y + alturaCampoDF + 1
+}
+
+// fatura desenha as duplicatas quando a nota possui cobrança
+func (r *renderizador) fatura(y float64, inf *InfNFe) float64 {
+	if inf.Cobr == nil || len(inf.Cobr.Dup) == 0 {
+		return y
+	}
+
+	y = r.titulo(y, "FATURA / DUPLICATAS")
+	largura := larguraDANFE / 6
+	for i, dup := range inf.Cobr.Dup {
+		if i > 0 && i%6 == 0 {
+			y += alturaCampoDF
+		}
+		vencimento := dup.DVenc
+		if t, err := time.Parse("2006-01-02", dup.DVenc); err == nil {
+			vencimento = t.Format("02/01/2006")
+		}
+		x := margemDANFE + float64(i%6)*largura
+		r.campo(x, y, largura, alturaCampoDF, "Nº "+dup.NDup+" - VENC. "+vencimento, "R$ "+moeda(dup.VDup), "R")
+	}
+	return y + alturaCampoDF + 1
+}
+
+// impostos desenha o quadro de cálculo do imposto
+func (r *renderizador) impostos(y float64, inf *InfNFe) float64 {
+	t := inf.Total.ICMSTot
+	y = r.titulo(y, "CÁLCULO DO IMPOSTO")
+	r.linha(y, []coluna{
+		{"BASE DE CÁLCULO DO ICMS", moeda(t.VBC), 0.2, "R"},
+		{"VALOR DO ICMS", moeda(t.VICMS), 0.2, "R"},
+		{"BASE DE CÁLC. ICMS S.T.", moeda(t.VBCST), 0.2, "R"},
+		{"VALOR DO ICMS SUBST.", moeda(t.VST), 0.2, "R"},
+		{"VALOR TOTAL DOS PRODUTOS", moeda(t.VProd), 0.2, "R"},
+	})
+	y += alturaCampoDF
+	r.linha(y, []coluna{
+		{"VALOR DO FRETE", moeda(t.VFrete), 0.16, "R"},
+		{"VALOR DO SEGURO", moeda(t.VSeg), 0.16, "R"},
+		{"DESCONTO", moeda(t.VDesc), 0.16, "R"},
+		{"OUTRAS DESPESAS", moeda(t.VOutro), 0.16, "R"},
+		{"VALOR TOTAL DO IPI", moeda(t.VIPI), 0.16, "R"},
+		{"VALOR TOTAL DA NOTA", moeda(t.VNF), 0.2, "R"},
+	})
+	return y + alturaCampoDF + 1
+}
+
+// transporte desenha o quadro do transportador
+func (r *renderizador) transporte(y float64, inf *InfNFe) float64 {
+	modalidades := map[int]string{
+		0: "0 - Por conta do Remetente",
+		1: "1 - Por conta do Destinatário",
+		2: "2 - Por conta de Terceiros",
+		3: "3 - Próprio por conta do Remetente",
+		4: "4 - Próprio por conta do Destinatário",
+		9: "9 - Sem Ocorrência de Transporte",
+	}
+
+	y = r.titulo(y, "TRANSPORTADOR / VOLUMES TRANSPORTADOS")
+	r.linha(y, []coluna{
+		{"RAZÃO SOCIAL", "", 0.45, "L"},
+		{"FRETE POR CONTA", modalidades[inf.Transp.ModFrete], 0.3, "L"},
+		{"CNPJ / CPF", "", 0.25, "L"},
+	})
+	return y + alturaCampoDF + 1
+}
+
+// cabecalhoItens desenha o título e as colunas do quadro de produtos
+func (r *renderizador) cabecalhoItens(y float64) float64 {
+	pdf := r.pdf
+	y = r.titulo(y, "DADOS DOS PRODUTOS / SERVIÇOS")
+	pdf.SetFont("Helvetica", "B", 5)
+	pdf.SetXY(margemDANFE, y)
+	for _, c := range colunasDANFE {
+		pdf.CellFormat(c.largura, 5, r.tr(c.titulo), "1", 0, "C", false, 0, "")
+	}
+	return y + 5
+}
+
+// item desenha uma linha do quadro de produtos
+func (r *renderizador) item(y float64, det Det) {
+	pdf := r.pdf
+	p := det.Prod
+	cst, vBC, vICMS, pICMS := "", "0,00", "0,00", "0,00"
+	switch icms := det.Imposto.ICMS; {
+	case icms.ICMS00 != nil:
+		cst = strconv.Itoa(icms.ICMS00.Orig) + icms.ICMS00.CST
+		vBC, vICMS, pICMS = moeda(icms.ICMS00.VBC), moeda(icms.ICMS00.VICMS), moeda(icms.ICMS00.PICMS)
+	case icms.ICMS40 != nil:
+		cst = strconv.Itoa(icms.ICMS40.Orig) + icms.ICMS40.CST
+	case icms.ICMSSN102 != nil:
+		cst = strconv.Itoa(icms.ICMSSN102.Orig) + icms.ICMSSN102.CSOSN
+	}
+	vIPI, pIPI := "0,00", "0,00"
+	if ipi := det.Imposto.IPI; ipi != nil && ipi.IPITrib != nil {
+		vIPI, pIPI = moeda(ipi.IPITrib.VIPI), moeda(ipi.IPITrib.PIPI)
+	}
+
+	valores := []string{
+		p.CProd, p.XProd, p.NCM, cst, p.CFOP, p.UCom,
+		decimal(p.QCom, 4), decimal(p.VUnCom, 4), moeda(p.VProd),
+		vBC, vICMS, vIPI, pICMS, pIPI,
+	}
+
+	pdf.SetFont("Helvetica", "", 6)
+	pdf.SetXY(margemDANFE, y)
+	for i, c := range colunasDANFE {
+		pdf.CellFormat(c.largura, alturaItem, truncar(pdf, r.tr(valores[i]), c.largura-1), "LR", 0, c.alinha, false, 0, "")
+	}
+	pdf.Line(margemDANFE, y+alturaItem, margemDANFE+larguraDANFE, y+alturaItem)
+}
+
+// dadosAdicionais desenha as informações complementares
+func (r *renderizador) dadosAdicionais(y float64, texto string) {
+	pdf := r.pdf
+	y = r.titulo(y, "DADOS ADICIONAIS")
+	pdf.Rect(margemDANFE, y, larguraDANFE, 22, "D")
+	pdf.SetFont("Helvetica", "", 5)
+	pdf.Text(margemDANFE+0.8, y+2.2, r.tr("INFORMAÇÕES COMPLEMENTARES"))
+	pdf.SetFont("Helvetica", "", 7)
+	pdf.SetXY(margemDANFE+0.8, y+3)
+	pdf.MultiCell(larguraDANFE-1.6, 3, r.tr(texto), "", "L", false)
+}
+
+// marcaDagua identifica documentos sem valor fiscal
+func (r *renderizador) marcaDagua() {
+	pdf := r.pdf
+	pdf.SetFont("Helvetica", "B", 48)
+	pdf.SetTextColor(215, 215, 215)
+	pdf.TransformBegin()
+	pdf.TransformRotate(45, 105, 160)
+	pdf.Text(35, 170, "SEM VALOR FISCAL")
+	pdf.TransformEnd()
+	pdf.SetTextColor(0, 0, 0)
+}
+
+// codigoBarras desenha a chave de acesso em Code 128 (conjunto C)
+func (r *renderizador) codigoBarras(x, y, largura float64, chave string) error {
+	codigo, err := code128.Encode(chave)
+	if err != nil {
+		return err
+	}
+
+	limites := codigo.Bounds()
+	modulos := limites.Dx()
+	if modulos == 0 {
+		return errors.New("código de barras vazio")
+	}
+	modulo := largura / float64(modulos)
+
+	r.pdf.SetFillColor(0, 0, 0)
+	for i := 0; i < modulos; i++ {
+		if cor, _, _, _ := codigo.At(limites.Min.X+i, limites.Min.Y).RGBA(); cor == 0 {
+			r.pdf.Rect(x+float64(i)*modulo, y, modulo, alturaBarraChv, "F")
+		}
+	}
+	return nil
+}
+
+// truncar corta o texto para caber na largura informada
+func truncar(pdf *fpdf.Fpdf, s string, largura float64) string {
+	for len(s) > 0 && pdf.GetStringWidth(s) > largura {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func nomeDestinatario(inf *InfNFe) string {
+	if inf.Dest == nil {
+		return ""
+	}
+	return inf.Dest.XNome
+}
+
+func numeroNota(n int) string {
+	s := fmt.Sprintf("%09d", n)
+	return s[:3] + "." + s[3:6] + "." + s[6:]
+}
+
+func dataEmissao(dh string) string {
+	t, err := time.Parse("2006-01-02T15:04:05-07:00", dh)
+	if err != nil {
+		return ""
+	}
+	return t.Format("02/01/2006")
+}
+
+func formatarCNPJ(s string) string {
+	if len(s) != 14 {
+		return s
+	}
+	return s[:2] + "." + s[2:5] + "." + s[5:8] + "/" + s[8:12] + "-" + s[12:]
+}
+
+func formatarCPF(s string) string {
+	if len(s) != 11 {
+		return s
+	}
+	return s[:3] + "." + s[3:6] + "." + s[6:9] + "-" + s[9:]
+}
+
+func formatarCEP(s string) string {
+	if len(s) != 8 {
+		return s
+	}
+	return s[:5] + "-" + s[5:]
+}
+
+func rotulo(prefixo, valor string) string {
+	if valor == "" {
+		return ""
+	}
+	return prefixo + valor
+}
+
+func juntar(sep string, partes ...string) string {
+	var out []string
+	for _, p := range partes {
+		if p != "" {
+			out = append(out, p)
+		}
+	}
+	return strings.Join(out, sep)
+}
+
+// decimal converte um valor do XML para o formato brasileiro com as casas informadas
+func decimal(v string, casas int) string {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return v
+	}
+	s := strconv.FormatFloat(f, 'f', casas, 64)
+	return strings.Replace(s, ".", ",", 1)
+}
+
+// moeda converte um valor do XML para o formato monetário brasileiro (1.234,56)
+func moeda(v string) string {
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return v
+	}
+	s := strconv.FormatFloat(f, 'f', 2, 64)
+	inteiro, centavos := s[:len(s)-3], s[len(s)-2:]
+
+	var partes []string
+	for len(inteiro) > 3 {
+		partes = append([]string{inteiro[len(inteiro)-3:]}, partes...)
+		inteiro = inteiro[:len(inteiro)-3]
+	}
+	partes = append([]string{inteiro}, partes...)
+	return strings.Join(partes, ".") + "," + centavos
+}
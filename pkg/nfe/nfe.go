@@ -0,0 +1,339 @@
+package nfe
+
+import (
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+// Namespaces e versão do leiaute
+const (
+	Namespace        = "http://www.portalfiscal.inf.br/nfe"
+	NamespaceXMLDSig = "http://www.w3.org/2000/09/xmldsig#"
+	Versao           = "4.00"
+	Modelo           = 55
+	VersaoProcesso   = "gvero 0.1.0"
+)
+
+// Ambientes de emissão
+const (
+	AmbienteProducao    = 1
+	AmbienteHomologacao = 2
+)
+
+// Regimes tributários do emitente (CRT)
+const (
+	CRTSimplesNacional       = 1
+	CRTSimplesExcessoReceita = 2
+	CRTRegimeNormal          = 3
+)
+
+// NomeHomologacao é o nome do destinatário exigido pela SEFAZ em ambiente de homologação
+const NomeHomologacao = "NF-E EMITIDA EM AMBIENTE DE HOMOLOGACAO - SEM VALOR FISCAL"
+
+// NFe é o documento fiscal eletrônico modelo 55, leiaute 4.00
+type NFe struct {
+	XMLName xml.Name `xml:"http://www.portalfiscal.inf.br/nfe NFe"`
+	InfNFe  InfNFe   `xml:"infNFe"`
+}
+
+// InfNFe contém as informações da nota assinadas digitalmente
+type InfNFe struct {
+	ID      string   `xml:"Id,attr"`
+	Versao  string   `xml:"versao,attr"`
+	Ide     Ide      `xml:"ide"`
+	Emit    Emit     `xml:"emit"`
+	Dest    *Dest    `xml:"dest,omitempty"`
+	Det     []Det    `xml:"det"`
+	Total   Total    `xml:"total"`
+	Transp  Transp   `xml:"transp"`
+	Cobr    *Cobr    `xml:"cobr,omitempty"`
+	Pag     Pag      `xml:"pag"`
+	InfAdic *InfAdic `xml:"infAdic,omitempty"`
+}
+
+// Ide identifica a nota fiscal
+type Ide struct {
+	CUF         string `xml:"cUF"`
+	CNF         string `xml:"cNF"`
+	NatOp       string `xml:"natOp"`
+	Mod         int    `xml:"mod"`
+	Serie       int    `xml:"serie"`
+	NNF         int    `xml:"nNF"`
+	DhEmi       string `xml:"dhEmi"`
+	DhSaiEnt    string `xml:"dhSaiEnt,omitempty"`
+	TpNF        int    `xml:"tpNF"`   // 0 entrada, 1 saída
+	IdDest      int    `xml:"idDest"` // 1 interna, 2 interestadual, 3 exterior
+	CMunFG      string `xml:"cMunFG"`
+	TpImp       int    `xml:"tpImp"`  // 1 DANFE retrato
+	TpEmis      int    `xml:"tpEmis"` // 1 emissão normal
+	CDV         int    `xml:"cDV"`
+	TpAmb       int    `xml:"tpAmb"`
+	FinNFe      int    `xml:"finNFe"`   // 1 normal
+	IndFinal    int    `xml:"indFinal"` // 1 consumidor final
+	IndPres     int    `xml:"indPres"`
+	IndIntermed string `xml:"indIntermed,omitempty"`
+	ProcEmi     int    `xml:"procEmi"` // 0 aplicativo do contribuinte
+	VerProc     string `xml:"verProc"`
+}
+
+// Endereco é o endereço estruturado do emitente ou do destinatário
+type Endereco struct {
+	XLgr    string `xml:"xLgr"`
+	Nro     string `xml:"nro"`
+	XCpl    string `xml:"xCpl,omitempty"`
+	XBairro string `xml:"xBairro"`
+	CMun    string `xml:"cMun"`
+	XMun    string `xml:"xMun"`
+	UF      string `xml:"UF"`
+	CEP     string `xml:"CEP,omitempty"`
+	CPais   string `xml:"cPais,omitempty"`
+	XPais   string `xml:"xPais,omitempty"`
+	Fone    string `xml:"fone,omitempty"`
+}
+
+// Emit identifica o emitente
+type Emit struct {
+	CNPJ      string   `xml:"CNPJ"`
+	XNome     string   `xml:"xNome"`
+	XFant     string   `xml:"xFant,omitempty"`
+	EnderEmit Endereco `xml:"enderEmit"`
+	IE        string   `xml:"IE"`
+	CRT       int      `xml:"CRT"`
+}
+
+// Dest identifica o destinatário
+type Dest struct {
+	CNPJ      string    `xml:"CNPJ,omitempty"`
+	CPF       string    `xml:"CPF,omitempty"`
+	XNome     string    `xml:"xNome"`
+	EnderDest *Endereco `xml:"enderDest,omitempty"`
+	IndIEDest int       `xml:"indIEDest"` // 1 contribuinte, 2 isento, 9 não contribuinte
+	IE        string    `xml:"IE,omitempty"`
+	Email     string    `xml:"email,omitempty"`
+}
+
+// Det é um item da nota
+type Det struct {
+	NItem   int     `xml:"nItem,attr"`
+	Prod    Prod    `xml:"prod"`
+	Imposto Imposto `xml:"imposto"`
+}
+
+// Prod contém os dados do produto do item
+type Prod struct {
+	CProd    string `xml:"cProd"`
+	CEAN     string `xml:"cEAN"`
+	XProd    string `xml:"xProd"`
+	NCM      string `xml:"NCM"`
+	CFOP     string `xml:"CFOP"`
+	UCom     string `xml:"uCom"`
+	QCom     string `xml:"qCom"`
+	VUnCom   string `xml:"vUnCom"`
+	VProd    string `xml:"vProd"`
+	CEANTrib string `xml:"cEANTrib"`
+	UTrib    string `xml:"uTrib"`
+	QTrib    string `xml:"qTrib"`
+	VUnTrib  string `xml:"vUnTrib"`
+	VFrete   string `xml:"vFrete,omitempty"`
+	VDesc    string `xml:"vDesc,omitempty"`
+	IndTot   int    `xml:"indTot"` // 1 compõe o total da nota
+}
+
+// Imposto agrupa os tributos do item
+type Imposto struct {
+	VTotTrib string `xml:"vTotTrib,omitempty"`
+	ICMS     ICMS   `xml:"ICMS"`
+	IPI      *IPI   `xml:"IPI,omitempty"`
+	PIS      PIS    `xml:"PIS"`
+	COFINS   COFINS `xml:"COFINS"`
+}
+
+// ICMS contém um dos grupos de tributação do ICMS suportados
+type ICMS struct {
+	ICMS00    *ICMS00    `xml:"ICMS00,omitempty"`
+	ICMS40    *ICMS40    `xml:"ICMS40,omitempty"`
+	ICMSSN102 *ICMSSN102 `xml:"ICMSSN102,omitempty"`
+}
+
+// ICMS00 é a tributação integral
+type ICMS00 struct {
+	Orig  int    `xml:"orig"`
+	CST   string `xml:"CST"`
+	ModBC int    `xml:"modBC"` // 3 valor da operação
+	VBC   string `xml:"vBC"`
+	PICMS string `xml:"pICMS"`
+	VICMS string `xml:"vICMS"`
+}
+
+// ICMS40 cobre isenção (40), não incidência (41) e suspensão (50)
+type ICMS40 struct {
+	Orig int    `xml:"orig"`
+	CST  string `xml:"CST"`
+}
+
+// ICMSSN102 cobre o Simples Nacional sem permissão de crédito (CSOSN 102, 103, 300 e 400)
+type ICMSSN102 struct {
+	Orig  int    `xml:"orig"`
+	CSOSN string `xml:"CSOSN"`
+}
+
+// IPI contém a tributação do IPI
+type IPI struct {
+	CEnq    string   `xml:"cEnq"`
+	IPITrib *IPITrib `xml:"IPITrib,omitempty"`
+}
+
+// IPITrib é o IPI tributado por alíquota
+type IPITrib struct {
+	CST  string `xml:"CST"`
+	VBC  string `xml:"vBC"`
+	PIPI string `xml:"pIPI"`
+	VIPI string `xml:"vIPI"`
+}
+
+// PIS contém um dos grupos de tributação do PIS
+type PIS struct {
+	PISAliq *Contribuicao   `xml:"PISAliq,omitempty"`
+	PISNT   *ContribuicaoNT `xml:"PISNT,omitempty"`
+	PISOutr *Contribuicao   `xml:"PISOutr,omitempty"`
+}
+
+// COFINS contém um dos grupos de tributação da COFINS
+type COFINS struct {
+	COFINSAliq *ContribuicaoCOFINS `xml:"COFINSAliq,omitempty"`
+	COFINSNT   *ContribuicaoNT     `xml:"COFINSNT,omitempty"`
+	COFINSOutr *ContribuicaoCOFINS `xml:"COFINSOutr,omitempty"`
+}
+
+// Contribuicao é o PIS calculado por alíquota
+type Contribuicao struct {
+	CST  string `xml:"CST"`
+	VBC  string `xml:"vBC"`
+	PPIS string `xml:"pPIS"`
+	VPIS string `xml:"vPIS"`
+}
+
+// ContribuicaoCOFINS é a COFINS calculada por alíquota
+type ContribuicaoCOFINS struct {
+	CST     string `xml:"CST"`
+	VBC     string `xml:"vBC"`
+	PCOFINS string `xml:"pCOFINS"`
+	VCOFINS string `xml:"vCOFINS"`
+}
+
+// ContribuicaoNT é o PIS ou COFINS não tributado
+type ContribuicaoNT struct {
+	CST string `xml:"CST"`
+}
+
+// Total contém os totais da nota
+type Total struct {
+	ICMSTot ICMSTot `xml:"ICMSTot"`
+}
+
+// ICMSTot contém os totais de tributos e valores da nota
+type ICMSTot struct {
+	VBC        string `xml:"vBC"`
+	VICMS      string `xml:"vICMS"`
+	VICMSDeson string `xml:"vICMSDeson"`
+	VFCP       string `xml:"vFCP"`
+	VBCST      string `xml:"vBCST"`
+	VST        string `xml:"vST"`
+	VFCPST     string `xml:"vFCPST"`
+	VFCPSTRet  string `xml:"vFCPSTRet"`
+	VProd      string `xml:"vProd"`
+	VFrete     string `xml:"vFrete"`
+	VSeg       string `xml:"vSeg"`
+	VDesc      string `xml:"vDesc"`
+	VII        string `xml:"vII"`
+	VIPI       string `xml:"vIPI"`
+	VIPIDevol  string `xml:"vIPIDevol"`
+	VPIS       string `xml:"vPIS"`
+	VCOFINS    string `xml:"vCOFINS"`
+	VOutro     string `xml:"vOutro"`
+	VNF        string `xml:"vNF"`
+}
+
+// Modalidades de frete
+const (
+	FreteEmitente      = 0
+	FreteSemOcorrencia = 9
+)
+
+// Transp contém os dados do transporte
+type Transp struct {
+	ModFrete int `xml:"modFrete"`
+}
+
+// Cobr contém a fatura e as duplicatas
+type Cobr struct {
+	Fat Fat   `xml:"fat"`
+	Dup []Dup `xml:"dup"`
+}
+
+// Fat é a fatura da nota
+type Fat struct {
+	NFat  string `xml:"nFat"`
+	VOrig string `xml:"vOrig"`
+	VDesc string `xml:"vDesc"`
+	VLiq  string `xml:"vLiq"`
+}
+
+// Dup é uma duplicata da fatura
+type Dup struct {
+	NDup  string `xml:"nDup"`
+	DVenc string `xml:"dVenc"`
+	VDup  string `xml:"vDup"`
+}
+
+// Pag contém as formas de pagamento
+type Pag struct {
+	DetPag []DetPag `xml:"detPag"`
+}
+
+// DetPag é uma forma de pagamento
+type DetPag struct {
+	IndPag string `xml:"indPag,omitempty"` // 0 à vista, 1 a prazo
+	TPag   string `xml:"tPag"`
+	VPag   string `xml:"vPag"`
+}
+
+// InfAdic contém as informações adicionais da nota
+type InfAdic struct {
+	InfCpl string `xml:"infCpl,omitempty"`
+}
+
+// Marshal serializa a nota sem declaração XML e sem indentação, forma exigida para a assinatura
+func Marshal(n *NFe) ([]byte, error) {
+	return xml.Marshal(n)
+}
+
+// Unmarshal lê uma nota, assinada ou não, a partir do XML da NFe ou do nfeProc
+func Unmarshal(data []byte) (*NFe, error) {
+	var proc struct {
+		XMLName xml.Name
+		NFe     *NFe    `xml:"NFe"`
+		InfNFe  *InfNFe `xml:"infNFe"`
+	}
+	if err := xml.Unmarshal(data, &proc); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case proc.NFe != nil:
+		return proc.NFe, nil
+	case proc.InfNFe != nil:
+		return &NFe{InfNFe: *proc.InfNFe}, nil
+	}
+	return nil, errors.New("XML não contém uma NF-e")
+}
+
+// ErroValidacao lista as violações do schema encontradas em um documento
+type ErroValidacao struct {
+	Erros []string `json:"erros"`
+}
+
+func (e *ErroValidacao) Error() string {
+	return "XML não confere com o schema: " + strings.Join(e.Erros, "; ")
+}
@@ -0,0 +1,181 @@
+package nfe
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Códigos de status (cStat) retornados pela SEFAZ usados pelo sistema
+const (
+	StatusAutorizada          = 100
+	StatusAutorizadaForaPrazo = 150
+	StatusDuplicidade         = 204
+	StatusNaoConsta           = 217
+	StatusAssinaturaInvalida  = 297
+)
+
+// Protocolo é o resultado do processamento de uma NF-e pela SEFAZ
+type Protocolo struct {
+	Ambiente        int       `json:"ambiente"`
+	VersaoAplicacao string    `json:"versao_aplicacao"`
+	Chave           string    `json:"chave"`
+	DataRecebimento time.Time `json:"data_recebimento"`
+	Numero          string    `json:"numero,omitempty"` // nProt, presente quando autorizada
+	DigestValue     string    `json:"digest_value,omitempty"`
+	Status          int       `json:"status"`
+	Motivo          string    `json:"motivo"`
+}
+
+// Autorizada indica se o uso da NF-e foi autorizado
+func (p *Protocolo) Autorizada() bool {
+	return p.Status == StatusAutorizada || p.Status == StatusAutorizadaForaPrazo
+}
+
+// SEFAZ define a comunicação com os web services de autorização da NF-e
+type SEFAZ interface {
+	// Autorizar envia uma NF-e assinada e aguarda o processamento síncrono
+	Autorizar(ctx context.Context, uf string, ambiente int, nfe []byte) (*Protocolo, error)
+	// Consultar retorna a situação atual de uma NF-e pela chave de acesso
+	Consultar(ctx context.Context, uf string, ambiente int, chave string) (*Protocolo, error)
+}
+
+// Processada monta o nfeProc, documento de distribuição com a NF-e e o protocolo de autorização
+func Processada(nfe []byte, p *Protocolo) ([]byte, error) {
+	if i := bytes.Index(nfe, []byte("?>")); bytes.HasPrefix(nfe, []byte("<?xml")) && i >= 0 {
+		nfe = nfe[i+2:]
+	}
+
+	type infProt struct {
+		TpAmb    int    `xml:"tpAmb"`
+		VerAplic string `xml:"verAplic"`
+		ChNFe    string `xml:"chNFe"`
+		DhRecbto string `xml:"dhRecbto"`
+		NProt    string `xml:"nProt,omitempty"`
+		DigVal   string `xml:"digVal,omitempty"`
+		CStat    int    `xml:"cStat"`
+		XMotivo  string `xml:"xMotivo"`
+	}
+	prot, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"protNFe"`
+		Versao  string   `xml:"versao,attr"`
+		InfProt infProt  `xml:"infProt"`
+	}{
+		Versao: Versao,
+		InfProt: infProt{
+			TpAmb:    p.Ambiente,
+			VerAplic: p.VersaoAplicacao,
+			ChNFe:    p.Chave,
+			DhRecbto: DataHora(p.DataRecebimento),
+			NProt:    p.Numero,
+			DigVal:   p.DigestValue,
+			CStat:    p.Status,
+			XMotivo:  p.Motivo,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<nfeProc xmlns="` + Namespace + `" versao="` + Versao + `">`)
+	buf.Write(bytes.TrimSpace(nfe))
+	buf.Write(prot)
+	buf.WriteString(`</nfeProc>`)
+	return buf.Bytes(), nil
+}
+
+// MockSEFAZ simula localmente a autorização da SEFAZ, para desenvolvimento e testes.
+// Confere a chave de acesso e a assinatura digital e autoriza as notas válidas.
+type MockSEFAZ struct {
+	mu        sync.Mutex
+	notas     map[string]*Protocolo
+	sequencia int64
+}
+
+// NewMockSEFAZ cria uma SEFAZ simulada
+func NewMockSEFAZ() *MockSEFAZ {
+	return &MockSEFAZ{
+		notas: make(map[string]*Protocolo),
+	}
+}
+
+// Autorizar valida a nota e registra o protocolo de autorização
+func (m *MockSEFAZ) Autorizar(ctx context.Context, uf string, ambiente int, doc []byte) (*Protocolo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	n, err := Unmarshal(doc)
+	if err != nil {
+		return nil, err
+	}
+
+	chave := n.InfNFe.ID
+	if len(chave) > 3 {
+		chave = chave[3:]
+	}
+	p := &Protocolo{
+		Ambiente:        ambiente,
+		VersaoAplicacao: "MOCK-SEFAZ-" + uf,
+		Chave:           chave,
+		DataRecebimento: time.Now(),
+	}
+
+	canonico, _ := canonicalizar(doc, porID(n.InfNFe.ID))
+	digest := sha1.Sum(canonico)
+	p.DigestValue = base64.StdEncoding.EncodeToString(digest[:])
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	switch {
+	case ValidarChave(chave) != nil:
+		p.Status, p.Motivo = 236, "Rejeição: Chave de Acesso com dígito verificador inválido"
+	case n.InfNFe.Ide.TpAmb != ambiente:
+		p.Status, p.Motivo = 252, "Rejeição: Ambiente informado diverge do Ambiente de recebimento"
+	case m.notas[chave] != nil && m.notas[chave].Autorizada():
+		p.Status, p.Motivo = StatusDuplicidade, "Rejeição: Duplicidade de NF-e"
+	default:
+		if _, err := VerificarAssinatura(doc); err != nil {
+			p.Status, p.Motivo = StatusAssinaturaInvalida, "Rejeição: Assinatura difere do calculado"
+			break
+		}
+		m.sequencia++
+		cUF, _ := CodigoUF(uf)
+		p.Numero = fmt.Sprintf("1%s%s%010d", cUF, p.DataRecebimento.Format("06"), m.sequencia)
+		p.Status, p.Motivo = StatusAutorizada, "Autorizado o uso da NF-e"
+		m.notas[chave] = p
+	}
+
+	return p, nil
+}
+
+// Consultar retorna o protocolo registrado para a chave
+func (m *MockSEFAZ) Consultar(ctx context.Context, uf string, ambiente int, chave string) (*Protocolo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p, ok := m.notas[chave]; ok {
+		copia := *p
+		return &copia, nil
+	}
+	return &Protocolo{
+		Ambiente:        ambiente,
+		VersaoAplicacao: "MOCK-SEFAZ-" + uf,
+		Chave:           chave,
+		DataRecebimento: time.Now(),
+		Status:          StatusNaoConsta,
+		Motivo:          "Rejeição: NF-e não consta na base de dados da SEFAZ",
+	}, nil
+}
@@ -0,0 +1,130 @@
+//go:build cgo && libxml2
+
+package nfe
+
+/*
+#cgo pkg-config: libxml-2.0
+#include <stdlib.h>
+#include <string.h>
+#include <libxml/parser.h>
+#include <libxml/xmlschemas.h>
+
+typedef struct {
+	char *buf;
+	size_t len;
+	int n;
+} erros_xsd;
+
+// coletar acumula as mensagens de erro do libxml2, uma por linha
+static void coletar(void *ctx, const xmlError *err) {
+	erros_xsd *e = ctx;
+	if (err == NULL || err->message == NULL || e->n >= 20) {
+		return;
+	}
+	size_t m = strlen(err->message);
+	char *nb = realloc(e->buf, e->len + m + 1);
+	if (nb == NULL) {
+		return;
+	}
+	memcpy(nb + e->len, err->message, m);
+	e->len += m;
+	nb[e->len] = 0;
+	e->buf = nb;
+	e->n++;
+}
+
+static xmlSchemaPtr carregar_xsd(const char *caminho, erros_xsd *e) {
+	xmlSchemaParserCtxtPtr p = xmlSchemaNewParserCtxt(caminho);
+	if (p == NULL) {
+		return NULL;
+	}
+	xmlSchemaSetParserStructuredErrors(p, (xmlStructuredErrorFunc)coletar, e);
+	xmlSchemaPtr s = xmlSchemaParse(p);
+	xmlSchemaFreeParserCtxt(p);
+	return s;
+}
+
+static int validar_xsd(xmlSchemaPtr s, const char *doc, int tamanho, erros_xsd *e) {
+	xmlDocPtr d = xmlReadMemory(doc, tamanho, "nfe.xml", NULL, XML_PARSE_NONET | XML_PARSE_NOERROR | XML_PARSE_NOWARNING);
+	if (d == NULL) {
+		return -1;
+	}
+	xmlSchemaValidCtxtPtr v = xmlSchemaNewValidCtxt(s);
+	xmlSchemaSetValidStructuredErrors(v, (xmlStructuredErrorFunc)coletar, e);
+	int r = xmlSchemaValidateDoc(v, d);
+	xmlSchemaFreeValidCtxt(v);
+	xmlFreeDoc(d);
+	return r;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+var iniciarLibxml sync.Once
+
+// Validador valida documentos contra os schemas XSD oficiais do Portal da NF-e usando o libxml2.
+// Usado apenas com a tag de compilação libxml2 (go build -tags libxml2), que exige cgo e libxml2-dev.
+type Validador struct {
+	schema C.xmlSchemaPtr
+}
+
+// NewValidador carrega o schema principal (ex.: nfe_v4.00.xsd); os schemas incluídos
+// por ele são lidos do mesmo diretório
+func NewValidador(caminho string) (*Validador, error) {
+	iniciarLibxml.Do(func() { C.xmlInitParser() })
+
+	cCaminho := C.CString(caminho)
+	defer C.free(unsafe.Pointer(cCaminho))
+
+	var e C.erros_xsd
+	defer C.free(unsafe.Pointer(e.buf))
+
+	schema := C.carregar_xsd(cCaminho, &e)
+	if schema == nil {
+		return nil, fmt.Errorf("erro ao carregar o schema %s: %s", caminho, mensagens(&e))
+	}
+	return &Validador{schema: schema}, nil
+}
+
+// Validar confere a estrutura e os formatos do documento, retornando todas as violações encontradas
+func (v *Validador) Validar(doc []byte) error {
+	if len(doc) == 0 {
+		return errors.New("documento vazio")
+	}
+
+	cDoc := C.CBytes(doc)
+	defer C.free(cDoc)
+
+	var e C.erros_xsd
+	defer C.free(unsafe.Pointer(e.buf))
+
+	switch r := C.validar_xsd(v.schema, (*C.char)(cDoc), C.int(len(doc)), &e); {
+	case r < 0:
+		return errors.New("XML malformado")
+	case r > 0:
+		return &ErroValidacao{Erros: strings.Split(mensagens(&e), "\n")}
+	}
+	return nil
+}
+
+// Close libera o schema carregado
+func (v *Validador) Close() {
+	if v.schema != nil {
+		C.xmlSchemaFree(v.schema)
+		v.schema = nil
+	}
+}
+
+func mensagens(e *C.erros_xsd) string {
+	if e.buf == nil {
+		return "erro desconhecido"
+	}
+	return strings.TrimSpace(C.GoStringN(e.buf, C.int(e.len)))
+}
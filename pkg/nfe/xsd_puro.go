@@ -0,0 +1,1023 @@
+//go:build !libxml2 || !cgo
+
+package nfe
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Namespaces usados nos schemas e nas instâncias
+const (
+	nsXSD = "http://www.w3.org/2001/XMLSchema"
+	nsXSI = "http://www.w3.org/2001/XMLSchema-instance"
+	nsXML = "http://www.w3.org/XML/1998/namespace"
+)
+
+// maxErrosXSD limita as violações informadas por documento, como na validação pelo libxml2
+const maxErrosXSD = 20
+
+// Validador valida documentos contra os schemas XSD oficiais do Portal da NF-e.
+// Esta implementação em Go puro cobre o subconjunto de XSD usado pelos schemas da NF-e e do
+// XML-DSig (sequence, choice, all, any, grupos, simpleContent, complexContent e as facetas de
+// tipos simples); restrições de identidade (xs:unique, xs:key) e grupos de substituição não são
+// verificados. A validação pelo libxml2 fica disponível com a tag de compilação libxml2.
+type Validador struct {
+	schema *schemaXSD
+}
+
+// NewValidador carrega o schema principal (ex.: nfe_v4.00.xsd); os schemas incluídos e
+// importados por ele são lidos em relação ao seu diretório
+func NewValidador(caminho string) (*Validador, error) {
+	s := &schemaXSD{
+		elementos:         map[xml.Name]*noXSD{},
+		tipos:             map[xml.Name]*noXSD{},
+		grupos:            map[xml.Name]*noXSD{},
+		gruposAtributos:   map[xml.Name]*noXSD{},
+		atributos:         map[xml.Name]*noXSD{},
+		lidos:             map[string]bool{},
+		declElementos:     map[*noXSD]*declElemento{},
+		tiposComplexos:    map[*noXSD]*tipoComplexo{},
+		tiposSimples:      map[*noXSD]*tipoSimples{},
+		tiposNativos:      map[string]*tipoSimples{},
+		declAtributosGlob: map[*noXSD]*declAtributo{},
+	}
+	if err := s.ler(caminho, ""); err != nil {
+		return nil, fmt.Errorf("erro ao carregar o schema %s: %w", caminho, err)
+	}
+	// Compila todas as declarações para que erros no schema apareçam na carga, não na emissão
+	for _, n := range s.elementos {
+		if _, err := s.elementoGlobal(n); err != nil {
+			return nil, fmt.Errorf("erro ao carregar o schema %s: %w", caminho, err)
+		}
+	}
+	return &Validador{schema: s}, nil
+}
+
+// Validar confere a estrutura e os formatos do documento, retornando todas as violações encontradas
+func (v *Validador) Validar(doc []byte) error {
+	if len(doc) == 0 {
+		return errors.New("documento vazio")
+	}
+	raiz, err := lerDocumento(doc)
+	if err != nil {
+		return errors.New("XML malformado")
+	}
+
+	val := &validacao{schema: v.schema}
+	if n, ok := v.schema.elementos[raiz.nome]; !ok {
+		val.erro(raiz, "elemento '%s' não declarado no schema", raiz.nome.Local)
+	} else {
+		d, _ := v.schema.elementoGlobal(n)
+		val.elemento(raiz, d)
+	}
+	if len(val.erros) > 0 {
+		return &ErroValidacao{Erros: val.erros}
+	}
+	return nil
+}
+
+// Close libera o schema carregado
+func (v *Validador) Close() {
+	v.schema = nil
+}
+
+// noXSD é um elemento de um arquivo de schema, com os prefixos de namespace em vigor
+type noXSD struct {
+	nome     string
+	attr     map[string]string
+	filhos   []*noXSD
+	prefixos map[string]string
+	arquivo  *arquivoXSD
+}
+
+// arquivoXSD guarda as opções de um arquivo de schema que afetam suas declarações
+type arquivoXSD struct {
+	alvo                  string // targetNamespace
+	elementosQualificados bool   // elementFormDefault="qualified"
+	atributosQualificados bool   // attributeFormDefault="qualified"
+}
+
+// schemaXSD reúne as definições globais de todos os arquivos e as versões já compiladas
+type schemaXSD struct {
+	elementos       map[xml.Name]*noXSD
+	tipos           map[xml.Name]*noXSD
+	grupos          map[xml.Name]*noXSD
+	gruposAtributos map[xml.Name]*noXSD
+	atributos       map[xml.Name]*noXSD
+	lidos           map[string]bool
+
+	declElementos     map[*noXSD]*declElemento
+	tiposComplexos    map[*noXSD]*tipoComplexo
+	tiposSimples      map[*noXSD]*tipoSimples
+	tiposNativos      map[string]*tipoSimples
+	declAtributosGlob map[*noXSD]*declAtributo
+}
+
+// ler carrega um arquivo de schema e os que ele inclui ou importa. Um arquivo incluído sem
+// targetNamespace assume o namespace de quem o incluiu.
+func (s *schemaXSD) ler(caminho, alvoInclusao string) error {
+	abs, err := filepath.Abs(caminho)
+	if err != nil {
+		return err
+	}
+	if s.lidos[abs] {
+		return nil
+	}
+	s.lidos[abs] = true
+
+	conteudo, err := os.ReadFile(abs)
+	if err != nil {
+		return err
+	}
+	raiz, err := lerArquivoXSD(conteudo)
+	if err != nil {
+		return fmt.Errorf("%s: %w", filepath.Base(abs), err)
+	}
+	if raiz.nome != "schema" {
+		return fmt.Errorf("%s: elemento raiz deve ser xs:schema", filepath.Base(abs))
+	}
+
+	arq := &arquivoXSD{
+		alvo:                  raiz.attr["targetNamespace"],
+		elementosQualificados: raiz.attr["elementFormDefault"] == "qualified",
+		atributosQualificados: raiz.attr["attributeFormDefault"] == "qualified",
+	}
+	if arq.alvo == "" {
+		arq.alvo = alvoInclusao
+	}
+	atribuirArquivo(raiz, arq)
+
+	dir := filepath.Dir(abs)
+	for _, n := range raiz.filhos {
+		nome := xml.Name{Space: arq.alvo, Local: n.attr["name"]}
+		switch n.nome {
+		case "include", "redefine":
+			if err := s.ler(filepath.Join(dir, n.attr["schemaLocation"]), arq.alvo); err != nil {
+				return err
+			}
+		case "import":
+			if local := n.attr["schemaLocation"]; local != "" {
+				if err := s.ler(filepath.Join(dir, local), ""); err != nil {
+					return err
+				}
+			}
+		case "element":
+			s.elementos[nome] = n
+		case "complexType", "simpleType":
+			s.tipos[nome] = n
+		case "group":
+			s.grupos[nome] = n
+		case "attributeGroup":
+			s.gruposAtributos[nome] = n
+		case "attribute":
+			s.atributos[nome] = n
+		}
+	}
+	return nil
+}
+
+func atribuirArquivo(n *noXSD, arq *arquivoXSD) {
+	n.arquivo = arq
+	for _, f := range n.filhos {
+		atribuirArquivo(f, arq)
+	}
+}
+
+// lerArquivoXSD monta a árvore dos elementos XSD de um arquivo, descartando as anotações
+func lerArquivoXSD(conteudo []byte) (*noXSD, error) {
+	d := xml.NewDecoder(bytes.NewReader(bytes.TrimPrefix(conteudo, []byte("\xef\xbb\xbf"))))
+
+	var (
+		raiz  *noXSD
+		pilha []*noXSD
+		pular int // profundidade dentro de xs:annotation ou de elementos de outros namespaces
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if pular > 0 || t.Name.Space != nsXSD || t.Name.Local == "annotation" {
+				pular++
+				continue
+			}
+			n := &noXSD{nome: t.Name.Local, attr: map[string]string{}, prefixos: map[string]string{}}
+			if len(pilha) > 0 {
+				for p, ns := range pilha[len(pilha)-1].prefixos {
+					n.prefixos[p] = ns
+				}
+			}
+			for _, a := range t.Attr {
+				switch {
+				case a.Name.Space == "xmlns":
+					n.prefixos[a.Name.Local] = a.Value
+				case a.Name.Space == "" && a.Name.Local == "xmlns":
+					n.prefixos[""] = a.Value
+				case a.Name.Space == "":
+					n.attr[a.Name.Local] = a.Value
+				}
+			}
+			if len(pilha) > 0 {
+				pai := pilha[len(pilha)-1]
+				pai.filhos = append(pai.filhos, n)
+			} else {
+				raiz = n
+			}
+			pilha = append(pilha, n)
+		case xml.EndElement:
+			if pular > 0 {
+				pular--
+				continue
+			}
+			pilha = pilha[:len(pilha)-1]
+		}
+	}
+	if raiz == nil {
+		return nil, errors.New("arquivo sem elementos")
+	}
+	return raiz, nil
+}
+
+// qname resolve um nome qualificado usado como valor de atributo (type, base, ref)
+func (n *noXSD) qname(valor string) xml.Name {
+	prefixo, local, ok := strings.Cut(strings.TrimSpace(valor), ":")
+	if !ok {
+		return xml.Name{Space: n.prefixos[""], Local: prefixo}
+	}
+	if prefixo == "xml" {
+		return xml.Name{Space: nsXML, Local: local}
+	}
+	return xml.Name{Space: n.prefixos[prefixo], Local: local}
+}
+
+// ocorrencias lê minOccurs e maxOccurs; máximo negativo significa unbounded
+func (n *noXSD) ocorrencias() (int, int, error) {
+	min, max := 1, 1
+	if v, ok := n.attr["minOccurs"]; ok {
+		var err error
+		if min, err = strconv.Atoi(v); err != nil {
+			return 0, 0, fmt.Errorf("minOccurs inválido: %s", v)
+		}
+	}
+	if v, ok := n.attr["maxOccurs"]; ok {
+		if v == "unbounded" {
+			max = -1
+		} else {
+			var err error
+			if max, err = strconv.Atoi(v); err != nil {
+				return 0, 0, fmt.Errorf("maxOccurs inválido: %s", v)
+			}
+		}
+	}
+	return min, max, nil
+}
+
+// declElemento é uma declaração de elemento compilada
+type declElemento struct {
+	nome     xml.Name
+	simples  *tipoSimples  // tipo simples do conteúdo
+	complexo *tipoComplexo // tipo complexo; nil com simples preenchido ou em xs:anyType
+	fixo     *string
+}
+
+// tipoComplexo descreve os filhos, o texto e os atributos permitidos em um elemento
+type tipoComplexo struct {
+	conteudo         *particula   // nil: sem elementos filhos
+	simples          *tipoSimples // simpleContent
+	misto            bool
+	atributos        []*declAtributo
+	qualquerAtributo bool
+}
+
+// declAtributo é uma declaração de atributo compilada
+type declAtributo struct {
+	nome        xml.Name
+	tipo        *tipoSimples
+	obrigatorio bool
+	proibido    bool
+	fixo        *string
+}
+
+// Tipos de partícula do modelo de conteúdo
+const (
+	partElemento = iota
+	partSequencia
+	partEscolha
+	partTodos
+	partQualquer
+)
+
+// particula é um termo do modelo de conteúdo com suas ocorrências
+type particula struct {
+	tipo     int
+	elemento *declElemento
+	filhas   []*particula
+	min, max int
+
+	// xs:any
+	namespaces []string // ##any, ##other, ##local, ##targetNamespace ou URIs
+	alvo       string
+	processo   string // strict, lax ou skip
+}
+
+// elementoGlobal compila uma declaração global de elemento
+func (s *schemaXSD) elementoGlobal(n *noXSD) (*declElemento, error) {
+	if d, ok := s.declElementos[n]; ok {
+		return d, nil
+	}
+	d := &declElemento{nome: xml.Name{Space: n.arquivo.alvo, Local: n.attr["name"]}}
+	s.declElementos[n] = d
+	return d, s.tipoDoElemento(n, d)
+}
+
+// elementoLocal compila a declaração de um elemento dentro de um modelo de conteúdo
+func (s *schemaXSD) elementoLocal(n *noXSD) (*declElemento, error) {
+	if ref, ok := n.attr["ref"]; ok {
+		nome := n.qname(ref)
+		global, ok := s.elementos[nome]
+		if !ok {
+			return nil, fmt.Errorf("elemento %s não declarado", ref)
+		}
+		return s.elementoGlobal(global)
+	}
+
+	d := &declElemento{nome: xml.Name{Local: n.attr["name"]}}
+	if form := n.attr["form"]; form == "qualified" || (form == "" && n.arquivo.elementosQualificados) {
+		d.nome.Space = n.arquivo.alvo
+	}
+	return d, s.tipoDoElemento(n, d)
+}
+
+func (s *schemaXSD) tipoDoElemento(n *noXSD, d *declElemento) error {
+	if v, ok := n.attr["fixed"]; ok {
+		d.fixo = &v
+	}
+	if tipo, ok := n.attr["type"]; ok {
+		nome := n.qname(tipo)
+		if nome.Space == nsXSD && nome.Local == "anyType" {
+			return nil
+		}
+		var err error
+		d.simples, d.complexo, err = s.tipoPorNome(nome)
+		return err
+	}
+	for _, f := range n.filhos {
+		var err error
+		switch f.nome {
+		case "complexType":
+			d.complexo, err = s.tipoComplexo(f)
+			return err
+		case "simpleType":
+			d.simples, err = s.tipoSimples(f)
+			return err
+		}
+	}
+	// Sem tipo declarado o elemento é xs:anyType
+	return nil
+}
+
+// tipoPorNome resolve um tipo nativo ou global, simples ou complexo
+func (s *schemaXSD) tipoPorNome(nome xml.Name) (*tipoSimples, *tipoComplexo, error) {
+	if nome.Space == nsXSD {
+		if nome.Local == "anyType" {
+			return nil, nil, nil
+		}
+		t, err := s.tipoNativo(nome.Local)
+		return t, nil, err
+	}
+	n, ok := s.tipos[nome]
+	if !ok {
+		return nil, nil, fmt.Errorf("tipo %s não declarado", nome.Local)
+	}
+	if n.nome == "simpleType" {
+		t, err := s.tipoSimples(n)
+		return t, nil, err
+	}
+	t, err := s.tipoComplexo(n)
+	return nil, t, err
+}
+
+// tipoSimplesPorNome resolve a base de uma restrição, lista ou união
+func (s *schemaXSD) tipoSimplesPorNome(nome xml.Name) (*tipoSimples, error) {
+	simples, complexo, err := s.tipoPorNome(nome)
+	if err != nil {
+		return nil, err
+	}
+	if simples == nil {
+		if complexo != nil && complexo.simples != nil {
+			return complexo.simples, nil
+		}
+		return nil, fmt.Errorf("tipo %s não é simples", nome.Local)
+	}
+	return simples, nil
+}
+
+// tipoComplexo compila um xs:complexType
+func (s *schemaXSD) tipoComplexo(n *noXSD) (*tipoComplexo, error) {
+	if t, ok := s.tiposComplexos[n]; ok {
+		return t, nil
+	}
+	t := &tipoComplexo{misto: n.attr["mixed"] == "true"}
+	s.tiposComplexos[n] = t
+
+	for _, f := range n.filhos {
+		switch f.nome {
+		case "sequence", "choice", "all", "group":
+			p, err := s.particula(f)
+			if err != nil {
+				return nil, err
+			}
+			t.conteudo = p
+		case "simpleContent":
+			if err := s.conteudoSimples(f, t); err != nil {
+				return nil, err
+			}
+		case "complexContent":
+			if err := s.conteudoComplexo(f, t); err != nil {
+				return nil, err
+			}
+		default:
+			if err := s.atributo(f, t); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return t, nil
+}
+
+// conteudoSimples compila um xs:simpleContent: texto de um tipo simples com atributos
+func (s *schemaXSD) conteudoSimples(n *noXSD, t *tipoComplexo) error {
+	for _, d := range n.filhos {
+		if d.nome != "extension" && d.nome != "restriction" {
+			continue
+		}
+		simples, complexo, err := s.tipoPorNome(d.qname(d.attr["base"]))
+		if err != nil {
+			return err
+		}
+		if complexo != nil {
+			simples = complexo.simples
+			t.atributos = append(t.atributos, complexo.atributos...)
+			t.qualquerAtributo = complexo.qualquerAtributo
+		}
+		if simples == nil {
+			return fmt.Errorf("base de simpleContent sem conteúdo simples: %s", d.attr["base"])
+		}
+		if d.nome == "restriction" {
+			if simples, err = s.restricao(d, simples); err != nil {
+				return err
+			}
+		}
+		t.simples = simples
+		for _, f := range d.filhos {
+			if err := s.atributo(f, t); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// conteudoComplexo compila um xs:complexContent. A extensão acrescenta o conteúdo próprio ao
+// da base; a restrição substitui o conteúdo e mantém os atributos não redeclarados.
+func (s *schemaXSD) conteudoComplexo(n *noXSD, t *tipoComplexo) error {
+	if n.attr["mixed"] == "true" {
+		t.misto = true
+	}
+	for _, d := range n.filhos {
+		if d.nome != "extension" && d.nome != "restriction" {
+			continue
+		}
+		_, base, err := s.tipoPorNome(d.qname(d.attr["base"]))
+		if err != nil {
+			return err
+		}
+		if base != nil {
+			t.atributos = append(t.atributos, base.atributos...)
+			t.qualquerAtributo = base.qualquerAtributo
+			if d.nome == "extension" {
+				t.conteudo = base.conteudo
+				t.misto = t.misto || base.misto
+			}
+		}
+		for _, f := range d.filhos {
+			switch f.nome {
+			case "sequence", "choice", "all", "group":
+				p, err := s.particula(f)
+				if err != nil {
+					return err
+				}
+				if t.conteudo != nil && d.nome == "extension" {
+					p = &particula{tipo: partSequencia, filhas: []*particula{t.conteudo, p}, min: 1, max: 1}
+				}
+				t.conteudo = p
+			default:
+				if err := s.atributo(f, t); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// atributo acrescenta ao tipo um xs:attribute, xs:attributeGroup ou xs:anyAttribute
+func (s *schemaXSD) atributo(n *noXSD, t *tipoComplexo) error {
+	switch n.nome {
+	case "anyAttribute":
+		t.qualquerAtributo = true
+	case "attributeGroup":
+		ref, ok := n.attr["ref"]
+		if !ok {
+			return nil
+		}
+		grupo, ok := s.gruposAtributos[n.qname(ref)]
+		if !ok {
+			return fmt.Errorf("grupo de atributos %s não declarado", ref)
+		}
+		for _, f := range grupo.filhos {
+			if err := s.atributo(f, t); err != nil {
+				return err
+			}
+		}
+	case "attribute":
+		a, err := s.declAtributo(n)
+		if err != nil {
+			return err
+		}
+		// A redeclaração em uma derivação substitui a da base
+		for i, existente := range t.atributos {
+			if existente.nome == a.nome {
+				t.atributos = append(t.atributos[:i:i], t.atributos[i+1:]...)
+				break
+			}
+		}
+		t.atributos = append(t.atributos, a)
+	}
+	return nil
+}
+
+func (s *schemaXSD) declAtributo(n *noXSD) (*declAtributo, error) {
+	a := &declAtributo{}
+	if ref, ok := n.attr["ref"]; ok {
+		nome := n.qname(ref)
+		global, ok := s.atributos[nome]
+		if !ok && nome.Space != nsXML {
+			return nil, fmt.Errorf("atributo %s não declarado", ref)
+		}
+		a.nome = nome
+		if global != nil {
+			g, err := s.atributoGlobal(global)
+			if err != nil {
+				return nil, err
+			}
+			a.tipo, a.fixo = g.tipo, g.fixo
+		}
+	} else {
+		a.nome = xml.Name{Local: n.attr["name"]}
+		if form := n.attr["form"]; form == "qualified" || (form == "" && n.arquivo.atributosQualificados) {
+			a.nome.Space = n.arquivo.alvo
+		}
+		if err := s.tipoDoAtributo(n, a); err != nil {
+			return nil, err
+		}
+	}
+	a.obrigatorio = n.attr["use"] == "required"
+	a.proibido = n.attr["use"] == "prohibited"
+	if v, ok := n.attr["fixed"]; ok {
+		a.fixo = &v
+	}
+	return a, nil
+}
+
+func (s *schemaXSD) atributoGlobal(n *noXSD) (*declAtributo, error) {
+	if a, ok := s.declAtributosGlob[n]; ok {
+		return a, nil
+	}
+	a := &declAtributo{nome: xml.Name{Space: n.arquivo.alvo, Local: n.attr["name"]}}
+	s.declAtributosGlob[n] = a
+	if v, ok := n.attr["fixed"]; ok {
+		a.fixo = &v
+	}
+	return a, s.tipoDoAtributo(n, a)
+}
+
+func (s *schemaXSD) tipoDoAtributo(n *noXSD, a *declAtributo) error {
+	var err error
+	if tipo, ok := n.attr["type"]; ok {
+		a.tipo, err = s.tipoSimplesPorNome(n.qname(tipo))
+		return err
+	}
+	for _, f := range n.filhos {
+		if f.nome == "simpleType" {
+			a.tipo, err = s.tipoSimples(f)
+			return err
+		}
+	}
+	return nil
+}
+
+// particula compila um termo do modelo de conteúdo
+func (s *schemaXSD) particula(n *noXSD) (*particula, error) {
+	min, max, err := n.ocorrencias()
+	if err != nil {
+		return nil, err
+	}
+	p := &particula{min: min, max: max}
+
+	switch n.nome {
+	case "element":
+		p.tipo = partElemento
+		if p.elemento, err = s.elementoLocal(n); err != nil {
+			return nil, err
+		}
+	case "sequence", "choice", "all":
+		p.tipo = map[string]int{"sequence": partSequencia, "choice": partEscolha, "all": partTodos}[n.nome]
+		for _, f := range n.filhos {
+			switch f.nome {
+			case "element", "sequence", "choice", "group", "any":
+				filha, err := s.particula(f)
+				if err != nil {
+					return nil, err
+				}
+				p.filhas = append(p.filhas, filha)
+			}
+		}
+	case "group":
+		ref := n.attr["ref"]
+		grupo, ok := s.grupos[n.qname(ref)]
+		if !ok {
+			return nil, fmt.Errorf("grupo %s não declarado", ref)
+		}
+		for _, f := range grupo.filhos {
+			if f.nome == "sequence" || f.nome == "choice" || f.nome == "all" {
+				modelo, err := s.particula(f)
+				if err != nil {
+					return nil, err
+				}
+				// As ocorrências da referência valem para o grupo inteiro
+				p = &particula{tipo: partSequencia, filhas: []*particula{modelo}, min: min, max: max}
+			}
+		}
+	case "any":
+		p.tipo = partQualquer
+		p.alvo = n.arquivo.alvo
+		p.namespaces = strings.Fields(n.attr["namespace"])
+		if len(p.namespaces) == 0 {
+			p.namespaces = []string{"##any"}
+		}
+		if p.processo = n.attr["processContents"]; p.processo == "" {
+			p.processo = "strict"
+		}
+	default:
+		return nil, fmt.Errorf("partícula %s não suportada", n.nome)
+	}
+	return p, nil
+}
+
+// aceita informa se o xs:any admite elementos do namespace
+func (p *particula) aceita(ns string) bool {
+	for _, permitido := range p.namespaces {
+		switch permitido {
+		case "##any":
+			return true
+		case "##other":
+			if ns != p.alvo && ns != "" {
+				return true
+			}
+		case "##local":
+			if ns == "" {
+				return true
+			}
+		case "##targetNamespace":
+			if ns == p.alvo {
+				return true
+			}
+		default:
+			if ns == permitido {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// noDoc é um elemento do documento validado
+type noDoc struct {
+	nome   xml.Name
+	attr   []xml.Attr
+	texto  string
+	filhos []*noDoc
+	linha  int
+}
+
+// lerDocumento monta a árvore do documento, registrando a linha de cada elemento
+func lerDocumento(doc []byte) (*noDoc, error) {
+	d := xml.NewDecoder(bytes.NewReader(doc))
+
+	var (
+		raiz  *noDoc
+		pilha []*noDoc
+		texto []*strings.Builder
+	)
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			linha, _ := d.InputPos()
+			n := &noDoc{nome: t.Name, attr: t.Attr, linha: linha}
+			if len(pilha) > 0 {
+				pai := pilha[len(pilha)-1]
+				pai.filhos = append(pai.filhos, n)
+			} else if raiz == nil {
+				raiz = n
+			}
+			pilha = append(pilha, n)
+			texto = append(texto, &strings.Builder{})
+		case xml.CharData:
+			if len(texto) > 0 {
+				texto[len(texto)-1].Write(t)
+			}
+		case xml.EndElement:
+			pilha[len(pilha)-1].texto = texto[len(texto)-1].String()
+			pilha = pilha[:len(pilha)-1]
+			texto = texto[:len(texto)-1]
+		}
+	}
+	if raiz == nil {
+		return nil, errors.New("documento sem elementos")
+	}
+	return raiz, nil
+}
+
+// validacao acumula as violações encontradas em um documento
+type validacao struct {
+	schema *schemaXSD
+	erros  []string
+}
+
+func (v *validacao) erro(n *noDoc, formato string, args ...interface{}) {
+	if len(v.erros) < maxErrosXSD {
+		v.erros = append(v.erros, fmt.Sprintf("linha %d: ", n.linha)+fmt.Sprintf(formato, args...))
+	}
+}
+
+// elemento valida um elemento, seus atributos e, recursivamente, seus filhos
+func (v *validacao) elemento(n *noDoc, d *declElemento) {
+	switch {
+	case d.simples != nil:
+		v.atributos(n, nil)
+		if len(n.filhos) > 0 {
+			v.erro(n, "elemento '%s' não admite elementos filhos", n.nome.Local)
+			return
+		}
+		v.valor(n, d.simples, d.fixo)
+	case d.complexo != nil:
+		t := d.complexo
+		v.atributos(n, t)
+		if t.simples != nil {
+			if len(n.filhos) > 0 {
+				v.erro(n, "elemento '%s' não admite elementos filhos", n.nome.Local)
+				return
+			}
+			v.valor(n, t.simples, d.fixo)
+			return
+		}
+		if !t.misto && strings.TrimSpace(n.texto) != "" {
+			v.erro(n, "elemento '%s' não admite texto", n.nome.Local)
+		}
+		v.conteudo(n, t.conteudo)
+	}
+	// Elementos sem tipo (xs:anyType) aceitam qualquer conteúdo
+}
+
+func (v *validacao) valor(n *noDoc, t *tipoSimples, fixo *string) {
+	if err := t.validar(n.texto); err != nil {
+		v.erro(n, "elemento '%s': %v", n.nome.Local, err)
+		return
+	}
+	if fixo != nil && t.normalizar(n.texto) != t.normalizar(*fixo) {
+		v.erro(n, "elemento '%s': valor deve ser '%s'", n.nome.Local, *fixo)
+	}
+}
+
+// atributos confere os atributos do elemento; sem tipo complexo nenhum atributo é aceito
+func (v *validacao) atributos(n *noDoc, t *tipoComplexo) {
+	var declarados []*declAtributo
+	if t != nil {
+		declarados = t.atributos
+	}
+	presentes := map[xml.Name]bool{}
+
+	for _, a := range n.attr {
+		if a.Name.Space == "xmlns" || (a.Name.Space == "" && a.Name.Local == "xmlns") || a.Name.Space == nsXSI {
+			continue
+		}
+		presentes[a.Name] = true
+
+		var decl *declAtributo
+		for _, d := range declarados {
+			if d.nome == a.Name {
+				decl = d
+				break
+			}
+		}
+		switch {
+		case decl == nil && t != nil && t.qualquerAtributo:
+		case decl == nil || decl.proibido:
+			v.erro(n, "elemento '%s': atributo '%s' não permitido", n.nome.Local, a.Name.Local)
+		case decl.tipo != nil:
+			if err := decl.tipo.validar(a.Value); err != nil {
+				v.erro(n, "elemento '%s', atributo '%s': %v", n.nome.Local, a.Name.Local, err)
+			} else if decl.fixo != nil && decl.tipo.normalizar(a.Value) != decl.tipo.normalizar(*decl.fixo) {
+				v.erro(n, "elemento '%s', atributo '%s': valor deve ser '%s'", n.nome.Local, a.Name.Local, *decl.fixo)
+			}
+		}
+	}
+
+	for _, d := range declarados {
+		if d.obrigatorio && !presentes[d.nome] {
+			v.erro(n, "elemento '%s': atributo obrigatório '%s' ausente", n.nome.Local, d.nome.Local)
+		}
+	}
+}
+
+// conteudo casa os filhos com o modelo de conteúdo e valida cada um pela declaração casada
+func (v *validacao) conteudo(n *noDoc, modelo *particula) {
+	if modelo == nil {
+		if len(n.filhos) > 0 {
+			v.erro(n.filhos[0], "elemento '%s' não admite elementos filhos", n.nome.Local)
+		}
+		return
+	}
+
+	c := &casador{schema: v.schema, filhos: n.filhos, decl: make([]*declElemento, len(n.filhos)), maisLonge: -1}
+	fim, ok := c.casar(modelo, 0)
+	if !ok || fim < len(n.filhos) {
+		pos := fim
+		if c.maisLonge > pos {
+			pos = c.maisLonge
+		}
+		esperado := strings.Join(c.esperado, ", ")
+		switch {
+		case pos < len(n.filhos) && esperado != "":
+			v.erro(n.filhos[pos], "elemento '%s' inesperado em '%s'; esperado: %s",
+				n.filhos[pos].nome.Local, n.nome.Local, esperado)
+		case pos < len(n.filhos):
+			v.erro(n.filhos[pos], "elemento '%s' não permitido em '%s'", n.filhos[pos].nome.Local, n.nome.Local)
+		default:
+			v.erro(n, "elemento '%s' incompleto; esperado: %s", n.nome.Local, esperado)
+		}
+		// Os filhos já casados continuam sendo validados
+		for i := 0; i < pos && i < len(n.filhos); i++ {
+			if c.decl[i] != nil {
+				v.elemento(n.filhos[i], c.decl[i])
+			}
+		}
+		return
+	}
+
+	for i, f := range n.filhos {
+		if c.decl[i] != nil {
+			v.elemento(f, c.decl[i])
+		} else if c.estrito[i] {
+			v.erro(f, "elemento '%s' não declarado no schema", f.nome.Local)
+		}
+	}
+}
+
+// casador associa os filhos de um elemento às partículas do modelo de conteúdo. O casamento é
+// guloso, o que basta para os schemas determinísticos exigidos pela especificação XSD.
+type casador struct {
+	schema  *schemaXSD
+	filhos  []*noDoc
+	decl    []*declElemento
+	estrito map[int]bool // filhos casados por xs:any strict sem declaração global
+
+	// posição mais distante em que o casamento falhou e os elementos esperados nela
+	maisLonge int
+	esperado  []string
+}
+
+// casar aplica a partícula a partir do filho i com suas ocorrências, retornando a próxima posição
+func (c *casador) casar(p *particula, i int) (int, bool) {
+	n := 0
+	for p.max < 0 || n < p.max {
+		j, ok := c.casarUma(p, i)
+		if !ok {
+			break
+		}
+		if j == i {
+			// Casou sem consumir filhos: as ocorrências restantes também casam vazias
+			return i, true
+		}
+		i = j
+		n++
+	}
+	return i, n >= p.min
+}
+
+func (c *casador) casarUma(p *particula, i int) (int, bool) {
+	switch p.tipo {
+	case partElemento:
+		if i < len(c.filhos) && c.filhos[i].nome == p.elemento.nome {
+			c.decl[i] = p.elemento
+			return i + 1, true
+		}
+		c.falha(i, p.elemento.nome.Local)
+	case partQualquer:
+		if i < len(c.filhos) && p.aceita(c.filhos[i].nome.Space) {
+			c.decl[i] = nil
+			if p.processo != "skip" {
+				if global, ok := c.schema.elementos[c.filhos[i].nome]; ok {
+					c.decl[i], _ = c.schema.elementoGlobal(global)
+				} else if p.processo == "strict" {
+					if c.estrito == nil {
+						c.estrito = map[int]bool{}
+					}
+					c.estrito[i] = true
+				}
+			}
+			return i + 1, true
+		}
+		c.falha(i, "qualquer elemento")
+	case partSequencia:
+		j := i
+		for _, f := range p.filhas {
+			var ok bool
+			if j, ok = c.casar(f, j); !ok {
+				return i, false
+			}
+		}
+		return j, true
+	case partEscolha:
+		vazia := false
+		for _, f := range p.filhas {
+			if j, ok := c.casar(f, i); ok {
+				if j > i {
+					return j, true
+				}
+				vazia = true
+			}
+		}
+		return i, vazia
+	case partTodos:
+		usadas := make([]bool, len(p.filhas))
+		j := i
+		for avancou := true; avancou; {
+			avancou = false
+			for k, f := range p.filhas {
+				if usadas[k] {
+					continue
+				}
+				if prox, ok := c.casarUma(f, j); ok && prox > j {
+					usadas[k], j, avancou = true, prox, true
+				}
+			}
+		}
+		for k, f := range p.filhas {
+			if !usadas[k] && f.min > 0 {
+				c.falha(j, f.elemento.nome.Local)
+				return i, false
+			}
+		}
+		return j, true
+	}
+	return i, false
+}
+
+// falha registra o elemento esperado na posição, guardando apenas a posição mais distante
+func (c *casador) falha(i int, esperado string) {
+	if i > c.maisLonge {
+		c.maisLonge, c.esperado = i, nil
+	}
+	if i == c.maisLonge {
+		for _, e := range c.esperado {
+			if e == esperado {
+				return
+			}
+		}
+		c.esperado = append(c.esperado, esperado)
+	}
+}
@@ -0,0 +1,144 @@
+package nfe
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// nfeTeste confere com testdata/xsd/nfe_teste.xsd, recorte do leiaute 4.00
+const nfeTeste = `<?xml version="1.0" encoding="UTF-8"?>
+<NFe xmlns="http://www.portalfiscal.inf.br/nfe">
+	<infNFe versao="4.00" Id="NFe41250312345678000195550010000000011000000019">
+		<ide>
+			<cUF>41</cUF>
+			<natOp>Venda de mercadoria</natOp>
+			<dhEmi>2025-03-10T14:30:00-03:00</dhEmi>
+		</ide>
+		<emit>
+			<CNPJ>12345678000195</CNPJ>
+			<xNome>Empresa Exemplo</xNome>
+		</emit>
+		<det nItem="1">
+			<xProd>Produto A</xProd>
+			<vProd>150.75</vProd>
+		</det>
+		<det nItem="2">
+			<xProd>Produto B</xProd>
+			<vProd>0</vProd>
+		</det>
+	</infNFe>
+	<Signature xmlns="http://www.w3.org/2000/09/xmldsig#">
+		<SignedInfo><Reference URI="#NFe41250312345678000195550010000000011000000019"/></SignedInfo>
+		<SignatureValue>c2Vsbw==</SignatureValue>
+		<KeyInfo><X509Data><X509Certificate>Y2VydA==</X509Certificate></X509Data></KeyInfo>
+	</Signature>
+</NFe>`
+
+func validadorTeste(t *testing.T) *Validador {
+	t.Helper()
+	v, err := NewValidador(filepath.Join("testdata", "xsd", "nfe_teste.xsd"))
+	if err != nil {
+		t.Fatalf("NewValidador: %v", err)
+	}
+	t.Cleanup(v.Close)
+	return v
+}
+
+func TestValidadorAceitaDocumentoValido(t *testing.T) {
+	v := validadorTeste(t)
+	if err := v.Validar([]byte(nfeTeste)); err != nil {
+		t.Fatalf("Validar: %v", err)
+	}
+
+	// Emitente pessoa física pelo outro ramo do xs:choice, com o grupo opcional presente
+	doc := strings.Replace(nfeTeste, "<CNPJ>12345678000195</CNPJ>", "<CPF>12345678909</CPF>", 1)
+	doc = strings.Replace(doc, "</infNFe>", "<infAdic><infCpl>Pedido 123</infCpl></infAdic></infNFe>", 1)
+	if err := v.Validar([]byte(doc)); err != nil {
+		t.Fatalf("Validar: %v", err)
+	}
+}
+
+func TestValidadorRejeitaViolacoes(t *testing.T) {
+	v := validadorTeste(t)
+
+	casos := []struct {
+		nome     string
+		de, para string
+		mensagem string // trecho esperado em alguma das violações
+	}{
+		{"valor fora da enumeração", "<cUF>41</cUF>", "<cUF>99</cUF>", "cUF"},
+		{"padrão do TString", "<xNome>Empresa Exemplo</xNome>", "<xNome> Empresa</xNome>", "xNome"},
+		{"tamanho máximo", "<natOp>Venda de mercadoria</natOp>", "<natOp>" + strings.Repeat("a", 61) + "</natOp>", "natOp"},
+		{"decimal com três casas", "<vProd>150.75</vProd>", "<vProd>150.755</vProd>", "vProd"},
+		{"data sem fuso", "2025-03-10T14:30:00-03:00", "2025-03-10T14:30:00", "dhEmi"},
+		{"elemento obrigatório ausente", "<xNome>Empresa Exemplo</xNome>", "", "emit"},
+		{"elemento fora de ordem", "<xProd>Produto A</xProd>\n\t\t\t<vProd>150.75</vProd>", "<vProd>150.75</vProd><xProd>Produto A</xProd>", "vProd"},
+		{"elemento desconhecido", "</ide>", "<serie>1</serie></ide>", "serie"},
+		{"ramos do choice juntos", "<CNPJ>12345678000195</CNPJ>", "<CNPJ>12345678000195</CNPJ><CPF>12345678909</CPF>", "CPF"},
+		{"atributo obrigatório ausente", `<det nItem="2">`, `<det>`, "nItem"},
+		{"atributo com padrão inválido", `<det nItem="2">`, `<det nItem="0">`, "nItem"},
+		{"atributo não declarado", `<det nItem="2">`, `<det nItem="2" extra="x">`, "extra"},
+		{"versão do leiaute", `versao="4.00"`, `versao="3.10"`, "versao"},
+		{"texto em elemento complexo", "<ide>", "<ide>texto", "ide"},
+		{"base64 inválido", "<SignatureValue>c2Vsbw==</SignatureValue>", "<SignatureValue>c2V$bw==</SignatureValue>", "SignatureValue"},
+		{"assinatura ausente", "<KeyInfo>", "<Extra/><KeyInfo>", "Extra"},
+		{"namespace errado", `<NFe xmlns="http://www.portalfiscal.inf.br/nfe">`, `<NFe xmlns="urn:outro">`, "NFe"},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if !strings.Contains(nfeTeste, c.de) {
+				t.Fatalf("trecho %q não encontrado no documento de teste", c.de)
+			}
+			err := v.Validar([]byte(strings.Replace(nfeTeste, c.de, c.para, 1)))
+			ev, ok := err.(*ErroValidacao)
+			if !ok {
+				t.Fatalf("Validar = %v, esperado *ErroValidacao", err)
+			}
+			if !strings.Contains(strings.Join(ev.Erros, "\n"), c.mensagem) {
+				t.Errorf("violações %q não mencionam %s", ev.Erros, c.mensagem)
+			}
+		})
+	}
+}
+
+func TestValidadorDocumentoMalformado(t *testing.T) {
+	v := validadorTeste(t)
+	for _, doc := range []string{"", "<NFe>", "texto"} {
+		err := v.Validar([]byte(doc))
+		if err == nil {
+			t.Errorf("Validar(%q) aceito", doc)
+		}
+		if _, ok := err.(*ErroValidacao); ok {
+			t.Errorf("Validar(%q) = %v, esperado erro de leitura", doc, err)
+		}
+	}
+}
+
+func TestNewValidadorSchemaInvalido(t *testing.T) {
+	dir := t.TempDir()
+	escrever := func(nome, conteudo string) string {
+		caminho := filepath.Join(dir, nome)
+		if err := os.WriteFile(caminho, []byte(conteudo), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		return caminho
+	}
+
+	casos := map[string]string{
+		"arquivo inexistente": filepath.Join(dir, "nao_existe.xsd"),
+		"tipo não declarado": escrever("tipo.xsd", `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+			<xs:element name="a" type="Inexistente"/></xs:schema>`),
+		"inclusão ausente": escrever("inclusao.xsd", `<xs:schema xmlns:xs="http://www.w3.org/2001/XMLSchema">
+			<xs:include schemaLocation="ausente.xsd"/></xs:schema>`),
+	}
+	for nome, caminho := range casos {
+		t.Run(nome, func(t *testing.T) {
+			if v, err := NewValidador(caminho); err == nil {
+				v.Close()
+				t.Error("schema inválido carregado")
+			}
+		})
+	}
+}
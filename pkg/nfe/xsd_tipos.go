@@ -0,0 +1,517 @@
+//go:build !libxml2 || !cgo
+
+package nfe
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tipoSimples é um tipo simples compilado: um tipo nativo ou uma derivação por restrição,
+// lista ou união. Cada restrição valida primeiro o valor contra a base.
+type tipoSimples struct {
+	nativo string       // nome do tipo nativo, preenchido apenas na raiz da derivação
+	base   *tipoSimples // tipo restringido
+	lista  *tipoSimples // tipo dos itens de xs:list
+	uniao  []*tipoSimples
+
+	espacos    string           // preserve, replace ou collapse; vazio herda da base
+	padroes    []*regexp.Regexp // alternativas de xs:pattern deste passo da derivação
+	textos     []string         // texto original dos padrões, para as mensagens de erro
+	enumeracao []string
+
+	tamanho, minTamanho, maxTamanho int // -1 quando não informados
+	totalDigitos, digitosFracao     int
+
+	minIncl, maxIncl, minExcl, maxExcl *float64
+}
+
+func novoTipoSimples() *tipoSimples {
+	return &tipoSimples{tamanho: -1, minTamanho: -1, maxTamanho: -1, totalDigitos: -1, digitosFracao: -1}
+}
+
+// tipoNativo retorna o tipo nativo do XSD com o nome informado
+func (s *schemaXSD) tipoNativo(nome string) (*tipoSimples, error) {
+	if t, ok := s.tiposNativos[nome]; ok {
+		return t, nil
+	}
+	if _, ok := tiposNativosSuportados[nome]; !ok {
+		return nil, fmt.Errorf("tipo xs:%s não suportado", nome)
+	}
+	t := novoTipoSimples()
+	t.nativo = nome
+	s.tiposNativos[nome] = t
+	return t, nil
+}
+
+// tipoSimples compila um xs:simpleType
+func (s *schemaXSD) tipoSimples(n *noXSD) (*tipoSimples, error) {
+	if t, ok := s.tiposSimples[n]; ok {
+		return t, nil
+	}
+	for _, d := range n.filhos {
+		switch d.nome {
+		case "restriction":
+			base, err := s.baseSimples(d, "base")
+			if err != nil {
+				return nil, err
+			}
+			t, err := s.restricao(d, base)
+			if err != nil {
+				return nil, err
+			}
+			s.tiposSimples[n] = t
+			return t, nil
+		case "list":
+			item, err := s.baseSimples(d, "itemType")
+			if err != nil {
+				return nil, err
+			}
+			t := novoTipoSimples()
+			t.lista = item
+			s.tiposSimples[n] = t
+			return t, nil
+		case "union":
+			t := novoTipoSimples()
+			for _, nome := range strings.Fields(d.attr["memberTypes"]) {
+				membro, err := s.tipoSimplesPorNome(d.qname(nome))
+				if err != nil {
+					return nil, err
+				}
+				t.uniao = append(t.uniao, membro)
+			}
+			for _, f := range d.filhos {
+				if f.nome == "simpleType" {
+					membro, err := s.tipoSimples(f)
+					if err != nil {
+						return nil, err
+					}
+					t.uniao = append(t.uniao, membro)
+				}
+			}
+			s.tiposSimples[n] = t
+			return t, nil
+		}
+	}
+	return nil, fmt.Errorf("tipo simples %s sem restriction, list ou union", n.attr["name"])
+}
+
+// baseSimples resolve o tipo indicado no atributo ou, na falta dele, o xs:simpleType interno
+func (s *schemaXSD) baseSimples(n *noXSD, atributo string) (*tipoSimples, error) {
+	if nome, ok := n.attr[atributo]; ok {
+		return s.tipoSimplesPorNome(n.qname(nome))
+	}
+	for _, f := range n.filhos {
+		if f.nome == "simpleType" {
+			return s.tipoSimples(f)
+		}
+	}
+	return nil, fmt.Errorf("%s sem tipo base", n.nome)
+}
+
+// restricao cria o tipo derivado de base pelas facetas de um xs:restriction
+func (s *schemaXSD) restricao(n *noXSD, base *tipoSimples) (*tipoSimples, error) {
+	t := novoTipoSimples()
+	t.base = base
+
+	for _, f := range n.filhos {
+		valor := f.attr["value"]
+		var err error
+		switch f.nome {
+		case "pattern":
+			var re *regexp.Regexp
+			if re, err = compilarPadrao(valor); err == nil {
+				t.padroes = append(t.padroes, re)
+				t.textos = append(t.textos, valor)
+			}
+		case "enumeration":
+			t.enumeracao = append(t.enumeracao, valor)
+		case "whiteSpace":
+			t.espacos = valor
+		case "length":
+			t.tamanho, err = strconv.Atoi(valor)
+		case "minLength":
+			t.minTamanho, err = strconv.Atoi(valor)
+		case "maxLength":
+			t.maxTamanho, err = strconv.Atoi(valor)
+		case "totalDigits":
+			t.totalDigitos, err = strconv.Atoi(valor)
+		case "fractionDigits":
+			t.digitosFracao, err = strconv.Atoi(valor)
+		case "minInclusive":
+			t.minIncl, err = limite(valor)
+		case "maxInclusive":
+			t.maxIncl, err = limite(valor)
+		case "minExclusive":
+			t.minExcl, err = limite(valor)
+		case "maxExclusive":
+			t.maxExcl, err = limite(valor)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("faceta %s inválida (%s): %w", f.nome, valor, err)
+		}
+	}
+
+	// As enumerações são comparadas com o valor já normalizado
+	for i, e := range t.enumeracao {
+		t.enumeracao[i] = t.normalizar(e)
+	}
+	return t, nil
+}
+
+func limite(valor string) (*float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(valor), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// espacosEfetivos retorna o tratamento de espaços em vigor no tipo
+func (t *tipoSimples) espacosEfetivos() string {
+	switch {
+	case t.espacos != "":
+		return t.espacos
+	case t.base != nil:
+		return t.base.espacosEfetivos()
+	case t.nativo == "string" || t.nativo == "anySimpleType":
+		return "preserve"
+	case t.nativo == "normalizedString":
+		return "replace"
+	}
+	return "collapse"
+}
+
+// normalizar aplica ao valor o tratamento de espaços do tipo
+func (t *tipoSimples) normalizar(valor string) string {
+	switch t.espacosEfetivos() {
+	case "replace":
+		return strings.Map(substituirEspaco, valor)
+	case "collapse":
+		return strings.Join(strings.Fields(strings.Map(substituirEspaco, valor)), " ")
+	}
+	return valor
+}
+
+func substituirEspaco(r rune) rune {
+	if r == '\t' || r == '\n' || r == '\r' {
+		return ' '
+	}
+	return r
+}
+
+// validar confere o valor contra o tipo e toda a sua cadeia de derivação
+func (t *tipoSimples) validar(valor string) error {
+	if len(t.uniao) > 0 {
+		for _, membro := range t.uniao {
+			if membro.validar(valor) == nil {
+				return nil
+			}
+		}
+		return fmt.Errorf("valor '%s' não pertence a nenhum dos tipos da união", valor)
+	}
+
+	valor = t.normalizar(valor)
+	switch {
+	case t.lista != nil:
+		for _, item := range strings.Fields(valor) {
+			if err := t.lista.validar(item); err != nil {
+				return err
+			}
+		}
+	case t.base != nil:
+		if err := t.base.validar(valor); err != nil {
+			return err
+		}
+	default:
+		if err := validarNativo(t.nativo, valor); err != nil {
+			return err
+		}
+	}
+	return t.facetas(valor)
+}
+
+// facetas confere as facetas declaradas neste passo da derivação
+func (t *tipoSimples) facetas(valor string) error {
+	if len(t.padroes) > 0 {
+		casou := false
+		for _, re := range t.padroes {
+			if re.MatchString(valor) {
+				casou = true
+				break
+			}
+		}
+		if !casou {
+			return fmt.Errorf("valor '%s' não confere com o padrão '%s'", valor, strings.Join(t.textos, "|"))
+		}
+	}
+
+	if len(t.enumeracao) > 0 {
+		permitido := false
+		for _, e := range t.enumeracao {
+			if valor == e {
+				permitido = true
+				break
+			}
+		}
+		if !permitido {
+			return fmt.Errorf("valor '%s' não está entre os permitidos (%s)", valor, strings.Join(t.enumeracao, ", "))
+		}
+	}
+
+	if t.tamanho >= 0 || t.minTamanho >= 0 || t.maxTamanho >= 0 {
+		n := t.comprimento(valor)
+		switch {
+		case t.tamanho >= 0 && n != t.tamanho:
+			return fmt.Errorf("valor '%s' deve ter tamanho %d", valor, t.tamanho)
+		case t.minTamanho >= 0 && n < t.minTamanho:
+			return fmt.Errorf("valor '%s' deve ter tamanho mínimo %d", valor, t.minTamanho)
+		case t.maxTamanho >= 0 && n > t.maxTamanho:
+			return fmt.Errorf("valor '%s' excede o tamanho máximo %d", valor, t.maxTamanho)
+		}
+	}
+
+	if t.totalDigitos >= 0 || t.digitosFracao >= 0 {
+		total, fracao := digitos(valor)
+		if t.totalDigitos >= 0 && total > t.totalDigitos {
+			return fmt.Errorf("valor '%s' excede %d dígitos", valor, t.totalDigitos)
+		}
+		if t.digitosFracao >= 0 && fracao > t.digitosFracao {
+			return fmt.Errorf("valor '%s' excede %d casas decimais", valor, t.digitosFracao)
+		}
+	}
+
+	if t.minIncl != nil || t.maxIncl != nil || t.minExcl != nil || t.maxExcl != nil {
+		f, err := strconv.ParseFloat(valor, 64)
+		if err != nil {
+			return fmt.Errorf("valor '%s' não é numérico", valor)
+		}
+		if (t.minIncl != nil && f < *t.minIncl) || (t.minExcl != nil && f <= *t.minExcl) ||
+			(t.maxIncl != nil && f > *t.maxIncl) || (t.maxExcl != nil && f >= *t.maxExcl) {
+			return fmt.Errorf("valor '%s' fora da faixa permitida", valor)
+		}
+	}
+	return nil
+}
+
+// comprimento mede o valor conforme o tipo: itens em listas, octetos em tipos binários e
+// caracteres nos demais
+func (t *tipoSimples) comprimento(valor string) int {
+	raiz := t
+	for raiz.base != nil {
+		raiz = raiz.base
+	}
+	switch {
+	case raiz.lista != nil:
+		return len(strings.Fields(valor))
+	case raiz.nativo == "base64Binary":
+		b, _ := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(valor), ""))
+		return len(b)
+	case raiz.nativo == "hexBinary":
+		return len(valor) / 2
+	}
+	return utf8.RuneCountInString(valor)
+}
+
+// digitos conta os dígitos significativos e as casas decimais de um número decimal
+func digitos(valor string) (int, int) {
+	valor = strings.TrimLeft(valor, "+-")
+	inteiro, fracao, _ := strings.Cut(valor, ".")
+	inteiro = strings.TrimLeft(inteiro, "0")
+	fracao = strings.TrimRight(fracao, "0")
+	return len(inteiro) + len(fracao), len(fracao)
+}
+
+// Formatos léxicos dos tipos nativos verificados
+var (
+	reDecimal = regexp.MustCompile(`^[+-]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+	reInteiro = regexp.MustCompile(`^[+-]?[0-9]+$`)
+	reFuso    = regexp.MustCompile(`(Z|[+-][0-9]{2}:[0-9]{2})$`)
+)
+
+// tiposNativosSuportados associa cada tipo nativo aceito à faixa dos inteiros limitados
+var tiposNativosSuportados = map[string][2]float64{
+	"anySimpleType": {}, "string": {}, "normalizedString": {}, "token": {}, "language": {},
+	"Name": {}, "NCName": {}, "NMTOKEN": {}, "NMTOKENS": {}, "ID": {}, "IDREF": {}, "IDREFS": {},
+	"QName": {}, "anyURI": {}, "boolean": {}, "decimal": {}, "float": {}, "double": {},
+	"date": {}, "dateTime": {}, "time": {}, "base64Binary": {}, "hexBinary": {},
+	"gYear": {}, "gYearMonth": {}, "gMonth": {}, "gMonthDay": {}, "gDay": {}, "duration": {},
+	"integer": {}, "nonNegativeInteger": {}, "positiveInteger": {}, "nonPositiveInteger": {},
+	"negativeInteger": {},
+	"long":            {-9223372036854775808, 9223372036854775807},
+	"int":             {-2147483648, 2147483647},
+	"short":           {-32768, 32767},
+	"byte":            {-128, 127},
+	"unsignedLong":    {0, 18446744073709551615},
+	"unsignedInt":     {0, 4294967295},
+	"unsignedShort":   {0, 65535},
+	"unsignedByte":    {0, 255},
+}
+
+// validarNativo confere o formato léxico dos tipos nativos; os tipos de texto aceitam qualquer valor
+func validarNativo(nome, valor string) error {
+	invalido := fmt.Errorf("valor '%s' não é um xs:%s válido", valor, nome)
+
+	switch nome {
+	case "boolean":
+		if valor != "true" && valor != "false" && valor != "1" && valor != "0" {
+			return invalido
+		}
+	case "decimal":
+		if !reDecimal.MatchString(valor) {
+			return invalido
+		}
+	case "float", "double":
+		if valor != "INF" && valor != "-INF" && valor != "NaN" {
+			if _, err := strconv.ParseFloat(valor, 64); err != nil || strings.ContainsAny(valor, "xXpP_") {
+				return invalido
+			}
+		}
+	case "integer", "nonNegativeInteger", "positiveInteger", "nonPositiveInteger", "negativeInteger",
+		"long", "int", "short", "byte", "unsignedLong", "unsignedInt", "unsignedShort", "unsignedByte":
+		if !reInteiro.MatchString(valor) {
+			return invalido
+		}
+		f, _ := strconv.ParseFloat(valor, 64)
+		negativo := strings.HasPrefix(valor, "-") && strings.Trim(valor, "-0") != ""
+		switch nome {
+		case "nonNegativeInteger":
+			if negativo {
+				return invalido
+			}
+		case "positiveInteger":
+			if negativo || f == 0 {
+				return invalido
+			}
+		case "nonPositiveInteger":
+			if f > 0 {
+				return invalido
+			}
+		case "negativeInteger":
+			if !negativo {
+				return invalido
+			}
+		case "integer":
+		default:
+			if faixa := tiposNativosSuportados[nome]; f < faixa[0] || f > faixa[1] {
+				return invalido
+			}
+		}
+	case "date":
+		if !dataValida(valor, "2006-01-02") {
+			return invalido
+		}
+	case "dateTime":
+		if !dataValida(valor, "2006-01-02T15:04:05") {
+			return invalido
+		}
+	case "time":
+		if !dataValida(valor, "15:04:05") {
+			return invalido
+		}
+	case "base64Binary":
+		if _, err := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(valor), "")); err != nil {
+			return invalido
+		}
+	case "hexBinary":
+		if _, err := hex.DecodeString(valor); err != nil {
+			return invalido
+		}
+	}
+	return nil
+}
+
+// dataValida confere datas e horas com fuso e frações de segundo opcionais
+func dataValida(valor, layout string) bool {
+	valor = reFuso.ReplaceAllString(valor, "")
+	if i := strings.LastIndex(valor, "."); i > 0 && strings.Contains(layout, ":") {
+		if !reInteiro.MatchString(valor[i+1:]) {
+			return false
+		}
+		valor = valor[:i]
+	}
+	_, err := time.Parse(layout, valor)
+	return err == nil
+}
+
+// compilarPadrao traduz uma expressão regular do XSD para a sintaxe do Go. No XSD o padrão
+// casa o valor inteiro, ^ e $ são literais e \i, \c são classes de nomes XML. Repetições acima
+// do limite do Go (1000) são abertas; o tamanho fica a cargo das facetas de comprimento.
+func compilarPadrao(padrao string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	emClasse := false
+	r := []rune(padrao)
+	for i := 0; i < len(r); i++ {
+		c := r[i]
+		switch {
+		case c == '\\' && i+1 < len(r):
+			i++
+			switch r[i] {
+			case 'i', 'I', 'c', 'C':
+				if emClasse && (r[i] == 'I' || r[i] == 'C') {
+					return nil, fmt.Errorf("classe \\%c dentro de colchetes não suportada no padrão %s", r[i], padrao)
+				}
+				b.WriteString(classeNomeXML(r[i], emClasse))
+			default:
+				b.WriteRune('\\')
+				b.WriteRune(r[i])
+			}
+		case emClasse && c == '-' && i+1 < len(r) && r[i+1] == '[':
+			return nil, fmt.Errorf("subtração de classes não suportada no padrão %s", padrao)
+		case emClasse && c == ']':
+			emClasse = false
+			b.WriteRune(c)
+		case emClasse && c == '[':
+			b.WriteString(`\[`)
+		case c == '[':
+			emClasse = true
+			b.WriteRune(c)
+			if i+1 < len(r) && r[i+1] == '^' {
+				i++
+				b.WriteRune('^')
+			}
+		case !emClasse && (c == '^' || c == '$'):
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		default:
+			b.WriteRune(c)
+		}
+	}
+
+	traduzido := reRepeticaoLonga.ReplaceAllStringFunc(b.String(), func(s string) string {
+		m := reRepeticaoLonga.FindStringSubmatch(s)
+		if max, _ := strconv.Atoi(m[2]); max > 1000 {
+			return "{" + m[1] + ",}"
+		}
+		return s
+	})
+	re, err := regexp.Compile(`^(?:` + traduzido + `)$`)
+	if err != nil {
+		return nil, fmt.Errorf("padrão %s: %w", padrao, err)
+	}
+	return re, nil
+}
+
+var reRepeticaoLonga = regexp.MustCompile(`\{([0-9]+),([0-9]+)\}`)
+
+// classeNomeXML aproxima \i (início de nome XML), \c (caractere de nome XML) e suas negações
+// \I e \C pelos caracteres ASCII permitidos em nomes
+func classeNomeXML(escape rune, emClasse bool) string {
+	conjunto := `_:A-Za-z`
+	if escape == 'c' || escape == 'C' {
+		conjunto = `\-._:A-Za-z0-9`
+	}
+	switch {
+	case emClasse:
+		return conjunto
+	case escape == 'I' || escape == 'C':
+		return `[^` + conjunto + `]`
+	}
+	return `[` + conjunto + `]`
+}
@@ -0,0 +1,162 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"math/big"
+
+	// Registra SHA-384 e SHA-512 para RS384, ES384 e RS512
+	_ "crypto/sha512"
+)
+
+// Chave é uma chave pública no formato JWK (RFC 7517)
+type Chave struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// ConjuntoChaves é o documento publicado em jwks_uri
+type ConjuntoChaves struct {
+	Chaves []Chave `json:"keys"`
+}
+
+// buscarChaves carrega as chaves de assinatura do provedor; chaves de outros usos e tipos são ignoradas
+func (c *Cliente) buscarChaves(ctx context.Context, endereco string) (map[string]interface{}, error) {
+	var conjunto ConjuntoChaves
+	if err := c.obterJSON(ctx, endereco, &conjunto); err != nil {
+		return nil, fmt.Errorf("chaves do provedor: %w", err)
+	}
+
+	chaves := make(map[string]interface{})
+	for _, k := range conjunto.Chaves {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		publica, err := k.Publica()
+		if err != nil {
+			continue
+		}
+		chaves[k.Kid] = publica
+	}
+	if len(chaves) == 0 {
+		return nil, errors.New("o provedor não publica chaves de assinatura suportadas")
+	}
+	return chaves, nil
+}
+
+// Publica converte a JWK em *rsa.PublicKey ou *ecdsa.PublicKey
+func (k Chave) Publica() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := inteiro(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := inteiro(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 {
+			return nil, errors.New("expoente RSA inválido")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curva elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curva = elliptic.P256()
+		case "P-384":
+			curva = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("curva %q não suportada", k.Crv)
+		}
+		x, err := inteiro(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := inteiro(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curva.IsOnCurve(x, y) {
+			return nil, errors.New("ponto fora da curva")
+		}
+		return &ecdsa.PublicKey{Curve: curva, X: x, Y: y}, nil
+	}
+	return nil, fmt.Errorf("tipo de chave %q não suportado", k.Kty)
+}
+
+// ChaveRSA converte a chave pública RSA em JWK
+func ChaveRSA(kid string, publica *rsa.PublicKey) Chave {
+	return Chave{
+		Kty: "RSA",
+		Kid: kid,
+		Use: "sig",
+		Alg: "RS256",
+		N:   base64.RawURLEncoding.EncodeToString(publica.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publica.E)).Bytes()),
+	}
+}
+
+// verificarAssinatura confere a assinatura JWS conforme o algoritmo do cabeçalho. O algoritmo
+// precisa combinar com o tipo da chave, o que impede trocar RS256 por outro na mesma chave.
+func verificarAssinatura(alg string, chave interface{}, conteudo, assinatura []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256":
+		hash = crypto.SHA256
+	case "RS384", "ES384":
+		hash = crypto.SHA384
+	case "RS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("algoritmo %q não aceito no ID token", alg)
+	}
+	h := hash.New()
+	h.Write(conteudo)
+	resumo := h.Sum(nil)
+
+	switch k := chave.(type) {
+	case *rsa.PublicKey:
+		if alg[0] != 'R' {
+			break
+		}
+		if err := rsa.VerifyPKCS1v15(k, hash, resumo, assinatura); err != nil {
+			return errors.New("assinatura do ID token inválida")
+		}
+		return nil
+	case *ecdsa.PublicKey:
+		tamanho := (k.Curve.Params().BitSize + 7) / 8
+		if alg[0] != 'E' || len(assinatura) != 2*tamanho {
+			break
+		}
+		r := new(big.Int).SetBytes(assinatura[:tamanho])
+		s := new(big.Int).SetBytes(assinatura[tamanho:])
+		if !ecdsa.Verify(k, resumo, r, s) {
+			return errors.New("assinatura do ID token inválida")
+		}
+		return nil
+	}
+	return errors.New("assinatura do ID token inválida")
+}
+
+func inteiro(valor string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(valor)
+	if err != nil || len(b) == 0 {
+		return nil, errors.New("JWK malformada")
+	}
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,243 @@
+package oidc
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// validadeCodigoMock é o prazo para trocar o código emitido pelo provedor simulado
+const validadeCodigoMock = time.Minute
+
+// Mock é um provedor OpenID Connect simulado, para desenvolvimento e testes. Aprova toda
+// autorização sem tela de login, com a Identidade configurada; o parâmetro login_hint troca o
+// e-mail. Exige PKCE S256 e o segredo do cliente, como os provedores reais.
+type Mock struct {
+	Emissor      string
+	ClientID     string
+	ClientSecret string
+	// Identidade são as declarações dos ID tokens emitidos, além de iss, aud, exp, iat e nonce
+	Identidade map[string]interface{}
+
+	chave *rsa.PrivateKey
+	kid   string
+	agora func() time.Time
+
+	mu      sync.Mutex
+	codigos map[string]*codigoMock
+}
+
+// codigoMock é uma autorização aprovada aguardando a troca pelo token
+type codigoMock struct {
+	redirectURI string
+	desafio     string
+	nonce       string
+	declaracoes map[string]interface{}
+	expira      time.Time
+}
+
+// NewMock cria o provedor simulado com uma nova chave RSA. O emissor é o endereço em que o
+// handler é servido, por exemplo http://localhost:9000.
+func NewMock(emissor, clientID, clientSecret string) (*Mock, error) {
+	chave, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	kid, err := GerarVerificador()
+	if err != nil {
+		return nil, err
+	}
+	return &Mock{
+		Emissor:      strings.TrimRight(emissor, "/"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Identidade: map[string]interface{}{
+			"sub":            "mock-usuario",
+			"email":          "usuario@exemplo.com.br",
+			"email_verified": true,
+			"name":           "Usuário de Teste",
+		},
+		chave:   chave,
+		kid:     kid[:16],
+		agora:   time.Now,
+		codigos: make(map[string]*codigoMock),
+	}, nil
+}
+
+// ServeHTTP atende a descoberta, a autorização, o token e as chaves
+func (m *Mock) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	caminho := r.URL.Path
+	if u, err := url.Parse(m.Emissor); err == nil {
+		caminho = strings.TrimPrefix(caminho, strings.TrimRight(u.Path, "/"))
+	}
+
+	switch caminho {
+	case "/.well-known/openid-configuration":
+		responderJSON(w, http.StatusOK, map[string]interface{}{
+			"issuer":                                m.Emissor,
+			"authorization_endpoint":                m.Emissor + "/authorize",
+			"token_endpoint":                        m.Emissor + "/token",
+			"jwks_uri":                              m.Emissor + "/jwks",
+			"response_types_supported":              []string{"code"},
+			"subject_types_supported":               []string{"public"},
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+			"code_challenge_methods_supported":      []string{"S256"},
+			"scopes_supported":                      EscoposPadrao,
+		})
+	case "/jwks":
+		responderJSON(w, http.StatusOK, ConjuntoChaves{Chaves: []Chave{ChaveRSA(m.kid, &m.chave.PublicKey)}})
+	case "/authorize":
+		m.autorizar(w, r)
+	case "/token":
+		m.token(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// autorizar aprova o pedido e devolve o navegador ao cliente com o código
+func (m *Mock) autorizar(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	destino, err := url.Parse(redirectURI)
+	if err != nil || redirectURI == "" {
+		http.Error(w, "redirect_uri inválido", http.StatusBadRequest)
+		return
+	}
+	if q.Get("client_id") != m.ClientID || q.Get("response_type") != "code" {
+		http.Error(w, "client_id ou response_type inválido", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256" {
+		http.Error(w, "PKCE S256 obrigatório", http.StatusBadRequest)
+		return
+	}
+
+	declaracoes := make(map[string]interface{}, len(m.Identidade))
+	for k, v := range m.Identidade {
+		declaracoes[k] = v
+	}
+	if dica := q.Get("login_hint"); dica != "" {
+		declaracoes["email"] = dica
+		declaracoes["sub"] = "mock-" + dica
+	}
+
+	codigo, err := GerarVerificador()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	m.mu.Lock()
+	m.codigos[codigo] = &codigoMock{
+		redirectURI: redirectURI,
+		desafio:     q.Get("code_challenge"),
+		nonce:       q.Get("nonce"),
+		declaracoes: declaracoes,
+		expira:      m.agora().Add(validadeCodigoMock),
+	}
+	m.mu.Unlock()
+
+	retorno := destino.Query()
+	retorno.Set("code", codigo)
+	retorno.Set("state", q.Get("state"))
+	destino.RawQuery = retorno.Encode()
+	http.Redirect(w, r, destino.String(), http.StatusFound)
+}
+
+// token troca o código pelo ID token, conferindo o cliente, o redirect_uri e o PKCE
+func (m *Mock) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		responderErro(w, http.StatusBadRequest, "invalid_request")
+		return
+	}
+
+	clientID, segredo, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		segredo, _ = url.QueryUnescape(segredo)
+	} else {
+		clientID, segredo = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != m.ClientID || subtle.ConstantTimeCompare([]byte(segredo), []byte(m.ClientSecret)) != 1 {
+		responderErro(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		responderErro(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	// O código vale uma única vez
+	m.mu.Lock()
+	c, existe := m.codigos[r.PostForm.Get("code")]
+	delete(m.codigos, r.PostForm.Get("code"))
+	m.mu.Unlock()
+
+	agora := m.agora()
+	if !existe || !agora.Before(c.expira) || c.redirectURI != r.PostForm.Get("redirect_uri") ||
+		DesafioPKCE(r.PostForm.Get("code_verifier")) != c.desafio {
+		responderErro(w, http.StatusBadRequest, "invalid_grant")
+		return
+	}
+
+	declaracoes := c.declaracoes
+	declaracoes["iss"] = m.Emissor
+	declaracoes["aud"] = m.ClientID
+	declaracoes["iat"] = agora.Unix()
+	declaracoes["exp"] = agora.Add(time.Hour).Unix()
+	if c.nonce != "" {
+		declaracoes["nonce"] = c.nonce
+	}
+	idToken, err := m.Assinar(declaracoes)
+	if err != nil {
+		responderErro(w, http.StatusInternalServerError, "server_error")
+		return
+	}
+	acesso, _ := GerarVerificador()
+
+	w.Header().Set("Cache-Control", "no-store")
+	responderJSON(w, http.StatusOK, Tokens{
+		AccessToken: acesso,
+		TokenType:   "Bearer",
+		IDToken:     idToken,
+		ExpiresIn:   3600,
+	})
+}
+
+// Assinar emite um JWT RS256 com as declarações, como o ID token do provedor
+func (m *Mock) Assinar(declaracoes map[string]interface{}) (string, error) {
+	cabecalho, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": m.kid})
+	if err != nil {
+		return "", err
+	}
+	corpo, err := json.Marshal(declaracoes)
+	if err != nil {
+		return "", err
+	}
+	conteudo := base64.RawURLEncoding.EncodeToString(cabecalho) + "." + base64.RawURLEncoding.EncodeToString(corpo)
+	resumo := sha256.Sum256([]byte(conteudo))
+	assinatura, err := rsa.SignPKCS1v15(rand.Reader, m.chave, crypto.SHA256, resumo[:])
+	if err != nil {
+		return "", err
+	}
+	return conteudo + "." + base64.RawURLEncoding.EncodeToString(assinatura), nil
+}
+
+func responderJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func responderErro(w http.ResponseWriter, status int, erro string) {
+	responderJSON(w, status, map[string]string{"error": erro})
+}
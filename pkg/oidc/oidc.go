@@ -0,0 +1,399 @@
+// Package oidc implementa o lado cliente do OpenID Connect: descoberta do provedor, fluxo de
+// código de autorização com PKCE (S256) e verificação do ID token pelas chaves públicas (JWKS).
+// Aceita ID tokens assinados com RS256, RS384, RS512, ES256 e ES384.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Parâmetros dos caches e das verificações
+const (
+	// ValidadeDescoberta é o tempo em que o documento de descoberta e as chaves ficam em cache
+	ValidadeDescoberta = time.Hour
+	// Folga é a diferença de relógio tolerada na validade do ID token
+	Folga = time.Minute
+	// intervaloChaves evita buscar as chaves a cada token com kid desconhecido
+	intervaloChaves = time.Minute
+	// tamanhoMaximoResposta limita as respostas lidas do provedor
+	tamanhoMaximoResposta = 1 << 20
+)
+
+// EscoposPadrao são os escopos pedidos na autorização
+var EscoposPadrao = []string{"openid", "email", "profile"}
+
+// Cliente descobre e mantém em cache os provedores pelo emissor
+type Cliente struct {
+	http  *http.Client
+	agora func() time.Time
+
+	mu         sync.Mutex
+	provedores map[string]*Provedor
+}
+
+// NewCliente cria um cliente; sem http, usa um cliente com tempo limite de 10 segundos
+func NewCliente(client *http.Client) *Cliente {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Cliente{
+		http:       client,
+		agora:      time.Now,
+		provedores: make(map[string]*Provedor),
+	}
+}
+
+// Provedor é um provedor OpenID Connect descoberto
+type Provedor struct {
+	Emissor               string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint,omitempty"`
+
+	cliente    *Cliente
+	descoberto time.Time
+
+	mu            sync.Mutex
+	chaves        map[string]interface{} // chaves públicas pelo kid
+	buscaDeChaves time.Time
+}
+
+// Tokens é a resposta do endpoint de token
+type Tokens struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Claims são as declarações do ID token usadas no login. Valores traz todas as declarações,
+// inclusive as específicas do provedor, como grupos e papéis.
+type Claims struct {
+	Emissor          string
+	Assunto          string
+	Audiencia        []string
+	Expira           time.Time
+	EmitidoEm        time.Time
+	Nonce            string
+	Email            string
+	EmailVerificado  *bool // ausente em alguns provedores, como o Microsoft Entra
+	Nome             string
+	UsuarioPreferido string // preferred_username
+	DominioHospedado string // hd, no Google Workspace
+	Valores          map[string]interface{}
+}
+
+// Strings retorna a declaração como lista de textos, aceitando texto único ou lista
+func (c *Claims) Strings(nome string) []string {
+	switch v := c.Valores[nome].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		valores := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				valores = append(valores, s)
+			}
+		}
+		return valores
+	}
+	return nil
+}
+
+// Descobrir carrega o provedor pelo documento /.well-known/openid-configuration do emissor
+func (c *Cliente) Descobrir(ctx context.Context, emissor string) (*Provedor, error) {
+	emissor = strings.TrimRight(emissor, "/")
+
+	c.mu.Lock()
+	p, ok := c.provedores[emissor]
+	c.mu.Unlock()
+	if ok && c.agora().Sub(p.descoberto) < ValidadeDescoberta {
+		return p, nil
+	}
+
+	p = &Provedor{}
+	if err := c.obterJSON(ctx, emissor+"/.well-known/openid-configuration", p); err != nil {
+		return nil, fmt.Errorf("descoberta do provedor: %w", err)
+	}
+	// O documento precisa ser do próprio emissor (OpenID Connect Discovery, seção 4.3)
+	if strings.TrimRight(p.Emissor, "/") != emissor {
+		return nil, fmt.Errorf("o provedor informa o emissor %q, diferente de %q", p.Emissor, emissor)
+	}
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" || p.JWKSURI == "" {
+		return nil, errors.New("documento de descoberta sem os endpoints de autorização, token ou chaves")
+	}
+	p.cliente = c
+	p.descoberto = c.agora()
+
+	c.mu.Lock()
+	c.provedores[emissor] = p
+	c.mu.Unlock()
+	return p, nil
+}
+
+// URLAutorizacao monta o endereço para onde o navegador é enviado no início do login
+func (p *Provedor) URLAutorizacao(clientID, redirectURI, estado, nonce, verificador string, escopos []string) string {
+	if len(escopos) == 0 {
+		escopos = EscoposPadrao
+	}
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {clientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(escopos, " ")},
+		"state":                 {estado},
+		"nonce":                 {nonce},
+		"code_challenge":        {DesafioPKCE(verificador)},
+		"code_challenge_method": {"S256"},
+	}
+	separador := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		separador = "&"
+	}
+	return p.AuthorizationEndpoint + separador + q.Encode()
+}
+
+// TrocarCodigo troca o código de autorização pelos tokens, autenticando o cliente com o segredo
+func (p *Provedor) TrocarCodigo(ctx context.Context, clientID, clientSecret, redirectURI, codigo, verificador string) (*Tokens, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {codigo},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {clientID},
+		"code_verifier": {verificador},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := p.cliente.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("troca do código: %w", err)
+	}
+	defer resp.Body.Close()
+	corpo, err := io.ReadAll(io.LimitReader(resp.Body, tamanhoMaximoResposta))
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Erro      string `json:"error"`
+			Descricao string `json:"error_description"`
+		}
+		json.Unmarshal(corpo, &e)
+		if e.Erro != "" {
+			return nil, fmt.Errorf("troca do código recusada pelo provedor: %s %s", e.Erro, e.Descricao)
+		}
+		return nil, fmt.Errorf("troca do código: status %d", resp.StatusCode)
+	}
+
+	var t Tokens
+	if err := json.Unmarshal(corpo, &t); err != nil {
+		return nil, fmt.Errorf("resposta de token inválida: %w", err)
+	}
+	if t.IDToken == "" {
+		return nil, errors.New("o provedor não retornou o ID token")
+	}
+	return &t, nil
+}
+
+// VerificarIDToken confere a assinatura, o emissor, a audiência, a validade e o nonce do ID token
+func (p *Provedor) VerificarIDToken(ctx context.Context, bruto, clientID, nonce string) (*Claims, error) {
+	partes := strings.Split(bruto, ".")
+	if len(partes) != 3 {
+		return nil, errors.New("ID token malformado")
+	}
+
+	var cabecalho struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodificarParte(partes[0], &cabecalho); err != nil {
+		return nil, fmt.Errorf("cabeçalho do ID token: %w", err)
+	}
+	assinatura, err := base64.RawURLEncoding.DecodeString(partes[2])
+	if err != nil {
+		return nil, errors.New("assinatura do ID token malformada")
+	}
+
+	chave, err := p.chave(ctx, cabecalho.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verificarAssinatura(cabecalho.Alg, chave, []byte(partes[0]+"."+partes[1]), assinatura); err != nil {
+		return nil, err
+	}
+
+	var valores map[string]interface{}
+	if err := decodificarParte(partes[1], &valores); err != nil {
+		return nil, fmt.Errorf("declarações do ID token: %w", err)
+	}
+	c := lerClaims(valores)
+
+	agora := p.cliente.agora()
+	if c.Emissor != p.Emissor {
+		return nil, fmt.Errorf("ID token de outro emissor: %s", c.Emissor)
+	}
+	if !contem(c.Audiencia, clientID) {
+		return nil, errors.New("ID token emitido para outro cliente")
+	}
+	// Com várias audiências, o azp identifica o cliente autorizado
+	if azp, _ := valores["azp"].(string); len(c.Audiencia) > 1 && azp != clientID {
+		return nil, errors.New("ID token emitido para outro cliente")
+	}
+	if c.Expira.IsZero() || !agora.Before(c.Expira.Add(Folga)) {
+		return nil, errors.New("ID token expirado")
+	}
+	if c.EmitidoEm.After(agora.Add(Folga)) {
+		return nil, errors.New("ID token emitido no futuro")
+	}
+	if c.Nonce != nonce {
+		return nil, errors.New("nonce do ID token não confere")
+	}
+	if c.Assunto == "" {
+		return nil, errors.New("ID token sem o identificador do usuário")
+	}
+	return c, nil
+}
+
+// chave retorna a chave pública do kid, buscando o JWKS de novo quando o provedor troca as chaves
+func (p *Provedor) chave(ctx context.Context, kid string) (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	agora := p.cliente.agora()
+	if k, ok := p.buscarChave(kid); ok && agora.Sub(p.buscaDeChaves) < ValidadeDescoberta {
+		return k, nil
+	}
+	// Chaves vencidas ou kid desconhecido: busca de novo, no máximo uma vez por intervaloChaves
+	if p.chaves == nil || agora.Sub(p.buscaDeChaves) >= intervaloChaves {
+		chaves, err := p.cliente.buscarChaves(ctx, p.JWKSURI)
+		if err != nil {
+			return nil, err
+		}
+		p.chaves, p.buscaDeChaves = chaves, agora
+	}
+	if k, ok := p.buscarChave(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("chave %q do ID token não encontrada no provedor", kid)
+}
+
+// buscarChave localiza a chave; sem kid, vale a única chave publicada. Deve ser chamado com p.mu travado.
+func (p *Provedor) buscarChave(kid string) (interface{}, bool) {
+	if kid == "" && len(p.chaves) == 1 {
+		for _, k := range p.chaves {
+			return k, true
+		}
+	}
+	k, ok := p.chaves[kid]
+	return k, ok
+}
+
+// obterJSON faz um GET e decodifica a resposta
+func (c *Cliente) obterJSON(ctx context.Context, endereco string, destino interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endereco, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: status %d", endereco, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, tamanhoMaximoResposta)).Decode(destino)
+}
+
+// GerarVerificador gera o code_verifier do PKCE (RFC 7636), também usado como estado e nonce
+func GerarVerificador() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DesafioPKCE calcula o code_challenge S256 do verificador
+func DesafioPKCE(verificador string) string {
+	h := sha256.Sum256([]byte(verificador))
+	return base64.RawURLEncoding.EncodeToString(h[:])
+}
+
+func decodificarParte(parte string, destino interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(parte)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, destino)
+}
+
+// lerClaims extrai as declarações conhecidas; números chegam do JSON como float64
+func lerClaims(v map[string]interface{}) *Claims {
+	texto := func(nome string) string {
+		s, _ := v[nome].(string)
+		return s
+	}
+	data := func(nome string) time.Time {
+		if n, ok := v[nome].(float64); ok {
+			return time.Unix(int64(n), 0)
+		}
+		return time.Time{}
+	}
+
+	c := &Claims{
+		Emissor:          texto("iss"),
+		Assunto:          texto("sub"),
+		Expira:           data("exp"),
+		EmitidoEm:        data("iat"),
+		Nonce:            texto("nonce"),
+		Email:            texto("email"),
+		Nome:             texto("name"),
+		UsuarioPreferido: texto("preferred_username"),
+		DominioHospedado: texto("hd"),
+		Valores:          v,
+	}
+	c.Audiencia = c.Strings("aud")
+	// Alguns provedores enviam email_verified como texto
+	switch ev := v["email_verified"].(type) {
+	case bool:
+		c.EmailVerificado = &ev
+	case string:
+		b := ev == "true"
+		c.EmailVerificado = &b
+	}
+	return c
+}
+
+func contem(lista []string, valor string) bool {
+	for _, item := range lista {
+		if item == valor {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,215 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const (
+	clienteTeste = "gvero"
+	nonceTeste   = "nonce-do-fluxo"
+)
+
+// novoProvedor serve o provedor simulado e o descobre com o relógio controlado pelo teste
+func novoProvedor(t *testing.T) (*Mock, *Provedor, *time.Time) {
+	t.Helper()
+	m, err := NewMock("", clienteTeste, "segredo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(m)
+	t.Cleanup(srv.Close)
+	m.Emissor = srv.URL
+
+	agora := time.Unix(1700000000, 0)
+	c := NewCliente(srv.Client())
+	c.agora = func() time.Time { return agora }
+	p, err := c.Descobrir(context.Background(), srv.URL)
+	if err != nil {
+		t.Fatalf("Descobrir: %v", err)
+	}
+	return m, p, &agora
+}
+
+// declaracoes são as de um ID token válido no instante informado
+func declaracoes(m *Mock, agora time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":   m.Emissor,
+		"aud":   clienteTeste,
+		"sub":   "usuario-1",
+		"email": "ana@acme.com",
+		"iat":   agora.Unix(),
+		"exp":   agora.Add(time.Hour).Unix(),
+		"nonce": nonceTeste,
+	}
+}
+
+// montar codifica o cabeçalho e as declarações e anexa a assinatura calculada sobre eles
+func montar(t *testing.T, cabecalho map[string]string, d map[string]interface{}, assinar func(conteudo []byte) []byte) string {
+	t.Helper()
+	c, err := json.Marshal(cabecalho)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corpo, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conteudo := base64.RawURLEncoding.EncodeToString(c) + "." + base64.RawURLEncoding.EncodeToString(corpo)
+	return conteudo + "." + base64.RawURLEncoding.EncodeToString(assinar([]byte(conteudo)))
+}
+
+// rs256 assina com a chave RSA informada
+func rs256(t *testing.T, chave *rsa.PrivateKey) func([]byte) []byte {
+	return func(conteudo []byte) []byte {
+		resumo := sha256.Sum256(conteudo)
+		assinatura, err := rsa.SignPKCS1v15(rand.Reader, chave, crypto.SHA256, resumo[:])
+		if err != nil {
+			t.Fatal(err)
+		}
+		return assinatura
+	}
+}
+
+func TestVerificarIDToken(t *testing.T) {
+	m, p, agora := novoProvedor(t)
+
+	bruto, err := m.Assinar(declaracoes(m, *agora))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := p.VerificarIDToken(context.Background(), bruto, clienteTeste, nonceTeste)
+	if err != nil {
+		t.Fatalf("ID token válido recusado: %v", err)
+	}
+	if c.Assunto != "usuario-1" || c.Email != "ana@acme.com" || c.Emissor != m.Emissor {
+		t.Errorf("declarações = %+v", c)
+	}
+
+	// Expirado há mais que a folga do relógio
+	*agora = agora.Add(time.Hour + Folga)
+	if _, err := p.VerificarIDToken(context.Background(), bruto, clienteTeste, nonceTeste); err == nil {
+		t.Error("ID token expirado aceito")
+	}
+}
+
+func TestVerificarIDTokenDeclaracoes(t *testing.T) {
+	m, p, agora := novoProvedor(t)
+
+	casos := []struct {
+		nome    string
+		alterar func(d map[string]interface{})
+		cliente string
+		nonce   string
+	}{
+		{"outro emissor", func(d map[string]interface{}) { d["iss"] = "https://atacante.example.com" }, clienteTeste, nonceTeste},
+		{"emissor com barra final", func(d map[string]interface{}) { d["iss"] = m.Emissor + "/" }, clienteTeste, nonceTeste},
+		{"outra audiência", func(d map[string]interface{}) { d["aud"] = "outro-cliente" }, clienteTeste, nonceTeste},
+		{"cliente verificado diferente", func(d map[string]interface{}) {}, "outro-cliente", nonceTeste},
+		{"várias audiências sem azp", func(d map[string]interface{}) { d["aud"] = []string{"outro-cliente", clienteTeste} }, clienteTeste, nonceTeste},
+		{"azp de outro cliente", func(d map[string]interface{}) {
+			d["aud"] = []string{"outro-cliente", clienteTeste}
+			d["azp"] = "outro-cliente"
+		}, clienteTeste, nonceTeste},
+		{"expirado", func(d map[string]interface{}) { d["exp"] = agora.Add(-Folga - time.Second).Unix() }, clienteTeste, nonceTeste},
+		{"sem exp", func(d map[string]interface{}) { delete(d, "exp") }, clienteTeste, nonceTeste},
+		{"emitido no futuro", func(d map[string]interface{}) { d["iat"] = agora.Add(Folga + time.Minute).Unix() }, clienteTeste, nonceTeste},
+		{"nonce diferente", func(d map[string]interface{}) { d["nonce"] = "outro-nonce" }, clienteTeste, nonceTeste},
+		{"sem nonce", func(d map[string]interface{}) { delete(d, "nonce") }, clienteTeste, nonceTeste},
+		{"nonce esperado diferente", func(d map[string]interface{}) {}, clienteTeste, "nonce-de-outro-fluxo"},
+		{"sem sub", func(d map[string]interface{}) { delete(d, "sub") }, clienteTeste, nonceTeste},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			d := declaracoes(m, *agora)
+			c.alterar(d)
+			bruto, err := m.Assinar(d)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := p.VerificarIDToken(context.Background(), bruto, c.cliente, c.nonce); err == nil {
+				t.Error("ID token aceito")
+			}
+		})
+	}
+
+	// Várias audiências com o azp do cliente são aceitas
+	d := declaracoes(m, *agora)
+	d["aud"] = []string{"outro-cliente", clienteTeste}
+	d["azp"] = clienteTeste
+	bruto, _ := m.Assinar(d)
+	if _, err := p.VerificarIDToken(context.Background(), bruto, clienteTeste, nonceTeste); err != nil {
+		t.Errorf("azp do cliente recusado: %v", err)
+	}
+}
+
+func TestVerificarIDTokenAssinatura(t *testing.T) {
+	m, p, agora := novoProvedor(t)
+	d := declaracoes(m, *agora)
+
+	valido, err := m.Assinar(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	partes := strings.Split(valido, ".")
+
+	// Declarações trocadas depois da assinatura
+	d["sub"] = "admin"
+	adulteradas, _ := json.Marshal(d)
+	d["sub"] = "usuario-1"
+
+	outraChave, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	publica, err := x509.MarshalPKIXPublicKey(&m.chave.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	casos := []struct {
+		nome  string
+		bruto string
+	}{
+		{"declarações adulteradas", partes[0] + "." + base64.RawURLEncoding.EncodeToString(adulteradas) + "." + partes[2]},
+		{"assinatura truncada", partes[0] + "." + partes[1] + "." + partes[2][:len(partes[2])-8]},
+		{"sem assinatura", partes[0] + "." + partes[1] + "."},
+		{"malformado", partes[0] + "." + partes[1]},
+		{"assinado por outra chave com o mesmo kid", montar(t, map[string]string{"alg": "RS256", "kid": m.kid}, d, rs256(t, outraChave))},
+		{"kid desconhecido", montar(t, map[string]string{"alg": "RS256", "kid": "outro"}, d, rs256(t, m.chave))},
+		// Confusão de algoritmos: o cabeçalho não escolhe como a chave do provedor é usada
+		{"alg none", montar(t, map[string]string{"alg": "none", "kid": m.kid}, d, func([]byte) []byte { return nil })},
+		{"alg HS256 com a chave pública como segredo", montar(t, map[string]string{"alg": "HS256", "kid": m.kid}, d, func(conteudo []byte) []byte {
+			mac := hmac.New(sha256.New, publica)
+			mac.Write(conteudo)
+			return mac.Sum(nil)
+		})},
+		{"alg ES256 com a chave RSA", montar(t, map[string]string{"alg": "ES256", "kid": m.kid}, d, rs256(t, m.chave))},
+		{"alg PS256", montar(t, map[string]string{"alg": "PS256", "kid": m.kid}, d, rs256(t, m.chave))},
+		{"alg RS384 com assinatura RS256", montar(t, map[string]string{"alg": "RS384", "kid": m.kid}, d, rs256(t, m.chave))},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			if _, err := p.VerificarIDToken(context.Background(), c.bruto, clienteTeste, nonceTeste); err == nil {
+				t.Error("ID token aceito")
+			}
+		})
+	}
+
+	// O mesmo token montado aqui, com a chave e o algoritmo corretos, é aceito
+	correto := montar(t, map[string]string{"alg": "RS256", "kid": m.kid}, d, rs256(t, m.chave))
+	if _, err := p.VerificarIDToken(context.Background(), correto, clienteTeste, nonceTeste); err != nil {
+		t.Errorf("ID token montado no teste recusado: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestPNG(t *testing.T) {
+	tests := []struct {
+		nome     string
+		tamanho  int
+		esperado int
+	}{
+		{"tamanho informado", 128, 128},
+		{"tamanho padrão", 0, 256},
+	}
+	for _, tt := range tests {
+		dados, err := PNG("otpauth://totp/Gvero:ana@acme.com?secret=ABC", tt.tamanho)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.nome, err)
+		}
+		img, err := png.Decode(bytes.NewReader(dados))
+		if err != nil {
+			t.Fatalf("%s: PNG inválido: %v", tt.nome, err)
+		}
+		if l := img.Bounds().Dx(); l != tt.esperado {
+			t.Errorf("%s: largura %d, esperado %d", tt.nome, l, tt.esperado)
+		}
+	}
+}
+
+func TestSVG(t *testing.T) {
+	svg, err := SVG("00020126360014br.gov.bcb.pix")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(svg)
+	if !strings.HasPrefix(s, "<svg ") || !strings.HasSuffix(s, "</svg>") || !strings.Contains(s, "h1v1h-1z") {
+		t.Errorf("SVG inesperado: %.80s", s)
+	}
+}
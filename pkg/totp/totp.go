@@ -0,0 +1,119 @@
+// Package totp implementa senhas de uso único baseadas em tempo (RFC 6238), compatíveis com
+// Google Authenticator, Authy e similares: HMAC-SHA1, 6 dígitos e passos de 30 segundos.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Parâmetros usados pelos aplicativos autenticadores
+const (
+	Digitos = 6
+	Periodo = 30 * time.Second
+	// Tolerancia é o número de passos aceitos antes e depois do atual, para relógios defasados
+	Tolerancia = 1
+)
+
+// tamanhoSegredo é o tamanho recomendado pela RFC 4226 para HMAC-SHA1 (160 bits)
+const tamanhoSegredo = 20
+
+var codificacao = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// ErrSegredoInvalido indica um segredo que não está em base32
+var ErrSegredoInvalido = errors.New("segredo TOTP inválido")
+
+// GerarSegredo gera um segredo aleatório em base32, como exibido aos usuários
+func GerarSegredo() (string, error) {
+	b := make([]byte, tamanhoSegredo)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return codificacao.EncodeToString(b), nil
+}
+
+// Passo retorna o número do passo de tempo do instante
+func Passo(t time.Time) int64 {
+	return t.Unix() / int64(Periodo/time.Second)
+}
+
+// Codigo calcula o código do segredo no instante informado
+func Codigo(segredo string, t time.Time) (string, error) {
+	chave, err := decodificar(segredo)
+	if err != nil {
+		return "", err
+	}
+	return codigo(chave, Passo(t)), nil
+}
+
+// Validar verifica o código no instante informado, aceitando a tolerância de passos.
+// Retorna o passo correspondente, que o chamador deve guardar para recusar a reutilização.
+func Validar(segredo, informado string, t time.Time) (int64, bool) {
+	chave, err := decodificar(segredo)
+	if err != nil {
+		return 0, false
+	}
+	informado = strings.ReplaceAll(strings.TrimSpace(informado), " ", "")
+	if len(informado) != Digitos {
+		return 0, false
+	}
+
+	atual := Passo(t)
+	for d := int64(-Tolerancia); d <= Tolerancia; d++ {
+		passo := atual + d
+		if subtle.ConstantTimeCompare([]byte(codigo(chave, passo)), []byte(informado)) == 1 {
+			return passo, true
+		}
+	}
+	return 0, false
+}
+
+// URL monta o endereço otpauth:// lido pelos aplicativos a partir do QR Code
+func URL(emissor, conta, segredo string) string {
+	rotulo := url.PathEscape(emissor) + ":" + url.PathEscape(conta)
+	q := url.Values{}
+	q.Set("secret", segredo)
+	q.Set("issuer", emissor)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(Digitos))
+	q.Set("period", fmt.Sprint(int(Periodo/time.Second)))
+	return "otpauth://totp/" + rotulo + "?" + q.Encode()
+}
+
+// codigo aplica o HOTP (RFC 4226) ao passo
+func codigo(chave []byte, passo int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(passo))
+
+	mac := hmac.New(sha1.New, chave)
+	mac.Write(msg[:])
+	soma := mac.Sum(nil)
+
+	// Truncamento dinâmico
+	deslocamento := soma[len(soma)-1] & 0x0f
+	valor := binary.BigEndian.Uint32(soma[deslocamento:deslocamento+4]) & 0x7fffffff
+
+	modulo := uint32(1)
+	for i := 0; i < Digitos; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", Digitos, valor%modulo)
+}
+
+func decodificar(segredo string) ([]byte, error) {
+	segredo = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(segredo), " ", ""))
+	chave, err := codificacao.DecodeString(strings.TrimRight(segredo, "="))
+	if err != nil || len(chave) == 0 {
+		return nil, ErrSegredoInvalido
+	}
+	return chave, nil
+}
@@ -0,0 +1,139 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// segredoRFC é a chave ASCII "12345678901234567890" dos vetores SHA1 da RFC 6238, em base32
+const segredoRFC = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// vetoresRFC são os vetores SHA1 do apêndice B da RFC 6238, reduzidos aos 6 dígitos finais
+var vetoresRFC = []struct {
+	unix   int64
+	passo  int64
+	codigo string // 8 dígitos na RFC
+}{
+	{59, 0x1, "94287082"},
+	{1111111109, 0x23523EC, "07081804"},
+	{1111111111, 0x23523ED, "14050471"},
+	{1234567890, 0x273EF07, "89005924"},
+	{2000000000, 0x3F940AA, "69279037"},
+	{20000000000, 0x27BC86AA, "65353130"},
+}
+
+func TestVetoresRFC6238(t *testing.T) {
+	for _, v := range vetoresRFC {
+		instante := time.Unix(v.unix, 0).UTC()
+		esperado := v.codigo[len(v.codigo)-Digitos:]
+
+		if p := Passo(instante); p != v.passo {
+			t.Errorf("Passo(%d) = %X, esperado %X", v.unix, p, v.passo)
+		}
+		got, err := Codigo(segredoRFC, instante)
+		if err != nil {
+			t.Fatalf("Codigo: %v", err)
+		}
+		if got != esperado {
+			t.Errorf("Codigo em %d = %s, esperado %s", v.unix, got, esperado)
+		}
+		if passo, ok := Validar(segredoRFC, esperado, instante); !ok || passo != v.passo {
+			t.Errorf("Validar em %d = %X, %t", v.unix, passo, ok)
+		}
+	}
+}
+
+func TestSegredoNormalizado(t *testing.T) {
+	instante := time.Unix(59, 0)
+	// Minúsculas, espaços e preenchimento, como os usuários digitam
+	for _, segredo := range []string{strings.ToLower(segredoRFC), " GEZD GNBV GY3T QOJQ GEZD GNBV GY3T QOJQ ", segredoRFC + "===="} {
+		if got, err := Codigo(segredo, instante); err != nil || got != "287082" {
+			t.Errorf("Codigo(%q) = %s, %v", segredo, got, err)
+		}
+	}
+	for _, segredo := range []string{"", "1111", "não é base32"} {
+		if _, err := Codigo(segredo, instante); err != ErrSegredoInvalido {
+			t.Errorf("Codigo(%q) = %v, esperado ErrSegredoInvalido", segredo, err)
+		}
+	}
+}
+
+func TestValidarTolerancia(t *testing.T) {
+	instante := time.Unix(1234567890, 0)
+	atual := Passo(instante)
+	codigo, _ := Codigo(segredoRFC, instante)
+
+	casos := []struct {
+		nome   string
+		em     time.Time
+		valido bool
+		passo  int64
+	}{
+		{"mesmo passo", instante, true, atual},
+		{"relógio adiantado um passo", instante.Add(Periodo), true, atual},
+		{"relógio atrasado um passo", instante.Add(-Periodo), true, atual},
+		{"dois passos depois", instante.Add(2 * Periodo), false, 0},
+		{"dois passos antes", instante.Add(-2 * Periodo), false, 0},
+	}
+	for _, c := range casos {
+		t.Run(c.nome, func(t *testing.T) {
+			passo, ok := Validar(segredoRFC, codigo, c.em)
+			if ok != c.valido || passo != c.passo {
+				t.Errorf("Validar = %d, %t; esperado %d, %t", passo, ok, c.passo, c.valido)
+			}
+		})
+	}
+
+	for _, informado := range []string{"", "12345", "1234567", "abcdef", codigo[:3] + " " + codigo[3:] + "0"} {
+		if _, ok := Validar(segredoRFC, informado, instante); ok {
+			t.Errorf("código malformado %q aceito", informado)
+		}
+	}
+	if _, ok := Validar(segredoRFC, codigo[:3]+" "+codigo[3:], instante); !ok {
+		t.Error("código com espaço recusado")
+	}
+}
+
+// O passo retornado é o do código, não o do relógio: reutilizar o código no passo seguinte retorna
+// o mesmo passo, que o chamador recusa por já ter sido usado
+func TestValidarReutilizacao(t *testing.T) {
+	instante := time.Unix(2000000000, 0)
+	codigo, _ := Codigo(segredoRFC, instante)
+
+	usado, ok := Validar(segredoRFC, codigo, instante)
+	if !ok {
+		t.Fatal("código recusado")
+	}
+	repetido, ok := Validar(segredoRFC, codigo, instante.Add(Periodo))
+	if !ok || repetido != usado {
+		t.Errorf("reutilização = %d, %t; esperado o passo %d já usado", repetido, ok, usado)
+	}
+
+	seguinte, _ := Codigo(segredoRFC, instante.Add(Periodo))
+	if passo, ok := Validar(segredoRFC, seguinte, instante.Add(Periodo)); !ok || passo <= usado {
+		t.Errorf("código do passo seguinte = %d, %t", passo, ok)
+	}
+}
+
+func TestGerarSegredo(t *testing.T) {
+	a, err := GerarSegredo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := GerarSegredo()
+	if a == b || len(a) != 32 {
+		t.Errorf("segredos %q e %q", a, b)
+	}
+	if _, err := Codigo(a, time.Now()); err != nil {
+		t.Errorf("segredo gerado inválido: %v", err)
+	}
+}
+
+func TestURL(t *testing.T) {
+	got := URL("Gvero ERP", "ana@acme.com", segredoRFC)
+	esperado := "otpauth://totp/Gvero%20ERP:ana@acme.com?algorithm=SHA1&digits=6&issuer=Gvero+ERP&period=30&secret=" + segredoRFC
+	if got != esperado {
+		t.Errorf("URL = %q\nesperado %q", got, esperado)
+	}
+}
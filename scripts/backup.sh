@@ -27,10 +27,25 @@ mysqldump --host=$DB_HOST --port=$DB_PORT --user=$DB_USER --password=$DB_PASS $D
 if [ $? -eq 0 ]; then
   # Comprimir o arquivo
   gzip $BACKUP_FILE
-  log_message "Backup concluído com sucesso: ${BACKUP_FILE}.gz"
+  ARQUIVO_FINAL="${BACKUP_FILE}.gz"
+
+  # Cifrar o arquivo quando houver senha configurada. Para restaurar:
+  # openssl enc -d -aes-256-cbc -pbkdf2 -in ARQUIVO.enc -pass env:BACKUP_SENHA | gunzip | mysql ...
+  if [ -n "$BACKUP_SENHA" ]; then
+    export BACKUP_SENHA
+    if openssl enc -aes-256-cbc -pbkdf2 -salt -in "$ARQUIVO_FINAL" -out "${ARQUIVO_FINAL}.enc" -pass env:BACKUP_SENHA; then
+      rm -f "$ARQUIVO_FINAL"
+      ARQUIVO_FINAL="${ARQUIVO_FINAL}.enc"
+    else
+      rm -f "$ARQUIVO_FINAL" "${ARQUIVO_FINAL}.enc"
+      log_message "ERRO: Falha ao cifrar o backup"
+      exit 1
+    fi
+  fi
+  log_message "Backup concluído com sucesso: $ARQUIVO_FINAL"
   
   # Remover backups antigos (manter os últimos 7 dias)
-  find $BACKUP_DIR -name "db_backup_*.sql.gz" -type f -mtime +7 -delete
+  find $BACKUP_DIR -name "db_backup_*.sql.gz*" -type f -mtime +7 -delete
   log_message "Backups mais antigos que 7 dias foram removidos"
 else
   log_message "ERRO: Falha ao realizar o backup do banco de dados"